@@ -2,10 +2,18 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/lay-g/winpower-g2-exporter/internal/collector"
+	"github.com/lay-g/winpower-g2-exporter/internal/energy"
+	"github.com/lay-g/winpower-g2-exporter/internal/metrics"
 	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
 	"github.com/lay-g/winpower-g2-exporter/internal/scheduler"
+	"github.com/lay-g/winpower-g2-exporter/internal/server"
+	"github.com/lay-g/winpower-g2-exporter/internal/storage"
+	"github.com/lay-g/winpower-g2-exporter/internal/winpower"
 	"go.uber.org/zap"
 )
 
@@ -60,11 +68,28 @@ func convertFields(keysAndValues ...interface{}) []log.Field {
 // CollectorSchedulerAdapter 适配器，适配 collector 到 scheduler 需要的接口
 type CollectorSchedulerAdapter struct {
 	collector *collector.CollectorService
+
+	// metrics 非空时每次采集后记录采集结果，用于推送模式
+	metrics *metrics.MetricsService
+
+	// client 非空时在每个采集周期的期限开始前完成认证，见 winpower.auth_timeout
+	client *winpower.Client
+}
+
+// Authenticate 实现 scheduler.Authenticator，未设置 client 时不执行任何操作
+func (c *CollectorSchedulerAdapter) Authenticate(ctx context.Context) error {
+	if c.client == nil {
+		return nil
+	}
+	return c.client.Authenticate(ctx)
 }
 
 // CollectDeviceData 实现 scheduler.CollectorInterface
 func (c *CollectorSchedulerAdapter) CollectDeviceData(ctx context.Context) (*scheduler.CollectionResult, error) {
 	result, err := c.collector.CollectDeviceData(ctx)
+	if c.metrics != nil {
+		c.metrics.RecordCollection(result)
+	}
 	if err != nil {
 		return &scheduler.CollectionResult{
 			Success:      false,
@@ -73,9 +98,142 @@ func (c *CollectorSchedulerAdapter) CollectDeviceData(ctx context.Context) (*sch
 		}, err
 	}
 
+	devicePower := make(map[string]float64, len(result.Devices))
+	for deviceID, device := range result.Devices {
+		if device.ErrorMsg == "" {
+			devicePower[deviceID] = device.LoadTotalWatt
+		}
+	}
+
 	return &scheduler.CollectionResult{
 		Success:      result.Success,
 		DeviceCount:  result.DeviceCount,
 		ErrorMessage: result.ErrorMessage,
+		DevicePower:  devicePower,
+	}, nil
+}
+
+// SchedulerControlAdapter 适配器，暂停/恢复调度器时同步更新指标与电能模块
+type SchedulerControlAdapter struct {
+	scheduler scheduler.Scheduler
+	metrics   *metrics.MetricsService
+	energy    *energy.EnergyService
+}
+
+// Pause 暂停采集，/metrics 改为返回最近一次的指标
+func (a *SchedulerControlAdapter) Pause() bool {
+	changed := a.scheduler.Pause()
+	a.metrics.SetCollectionPaused(true)
+	return changed
+}
+
+// Resume 恢复采集
+func (a *SchedulerControlAdapter) Resume() bool {
+	if !a.scheduler.IsPaused() {
+		return false
+	}
+
+	// 先标记恢复时间，确保恢复后的首次积分能识别暂停间隔
+	a.energy.MarkResumed()
+	changed := a.scheduler.Resume()
+	a.metrics.SetCollectionPaused(false)
+	return changed
+}
+
+// IsPaused 实现 server.SchedulerController
+func (a *SchedulerControlAdapter) IsPaused() bool {
+	return a.scheduler.IsPaused()
+}
+
+// EnergyReaderAdapter 适配器，为 /api 端点提供设备累计电能
+type EnergyReaderAdapter struct {
+	energy    *energy.EnergyService
+	collector *collector.CollectorService
+}
+
+// energyKey 返回设备电能的存储键；设备按稳定标识跟踪时为该标识对应的键
+func (a *EnergyReaderAdapter) energyKey(deviceID string) string {
+	if a.collector == nil {
+		return deviceID
+	}
+	return a.collector.EnergyKey(deviceID)
+}
+
+// GetDeviceEnergy 实现 server.DeviceEnergyReader
+func (a *EnergyReaderAdapter) GetDeviceEnergy(deviceID string) (*server.DeviceEnergy, error) {
+	data, err := a.energy.GetData(a.energyKey(deviceID))
+	if err != nil {
+		// 未知设备或非法设备ID均视为设备不存在
+		if errors.Is(err, energy.ErrDeviceNotFound) || errors.Is(err, storage.ErrInvalidDeviceID) {
+			return nil, fmt.Errorf("%w: %s", server.ErrDeviceNotFound, deviceID)
+		}
+		return nil, err
+	}
+
+	return &server.DeviceEnergy{
+		DeviceID:  deviceID,
+		EnergyWH:  data.EnergyWH,
+		Timestamp: time.UnixMilli(data.Timestamp),
+	}, nil
+}
+
+// ResetDeviceEnergy 实现 server.DeviceEnergyResetter
+func (a *EnergyReaderAdapter) ResetDeviceEnergy(deviceID string) (*server.DeviceEnergyReset, error) {
+	before, after, err := a.energy.Reset(a.energyKey(deviceID))
+	if err != nil {
+		// 未知设备或非法设备ID均视为设备不存在
+		if errors.Is(err, energy.ErrDeviceNotFound) || errors.Is(err, storage.ErrInvalidDeviceID) {
+			return nil, fmt.Errorf("%w: %s", server.ErrDeviceNotFound, deviceID)
+		}
+		return nil, err
+	}
+
+	return &server.DeviceEnergyReset{
+		DeviceID:  deviceID,
+		BeforeWH:  before.EnergyWH,
+		AfterWH:   after.EnergyWH,
+		Timestamp: time.UnixMilli(after.Timestamp),
+	}, nil
+}
+
+// DeviceMetadataAdapter 适配器，将指标模块跟踪的设备标签保存为设备元数据，供下次启动预加载电能
+type DeviceMetadataAdapter struct {
+	storage storage.MetadataStorage
+}
+
+// RecordDeviceLabels 实现 metrics.DeviceLabelRecorder
+func (a *DeviceMetadataAdapter) RecordDeviceLabels(devices []metrics.DeviceLabels) error {
+	metadata := make(map[string]storage.DeviceMetadata, len(devices))
+	for _, device := range devices {
+		metadata[device.DeviceID] = storage.DeviceMetadata{
+			DeviceName: device.DeviceName,
+			DeviceType: device.DeviceType,
+			EnergyKey:  device.EnergyKey,
+		}
+	}
+	return a.storage.WriteMetadata(metadata)
+}
+
+// RawResponseAdapter 适配器，为 /debug/winpower/raw 提供最近一次采集中设备的 WinPower 原始数据
+type RawResponseAdapter struct {
+	winpower *winpower.Client
+}
+
+// RawDeviceResponse 实现 server.RawResponseProvider
+func (a *RawResponseAdapter) RawDeviceResponse(deviceID string) (*server.RawDeviceResponse, error) {
+	fragment, receivedAt, err := a.winpower.RawDeviceData(deviceID)
+	switch {
+	case errors.Is(err, winpower.ErrDeviceNotInResponse):
+		return nil, fmt.Errorf("%w: %s", server.ErrDeviceNotFound, deviceID)
+	case errors.Is(err, winpower.ErrRawResponseUnavailable):
+		return nil, fmt.Errorf("%w: %w", server.ErrRawResponseUnavailable, err)
+	case err != nil:
+		return nil, err
+	}
+
+	return &server.RawDeviceResponse{
+		DeviceID:   deviceID,
+		ReceivedAt: receivedAt,
+		Response:   fragment,
 	}, nil
 }
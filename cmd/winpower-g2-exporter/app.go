@@ -3,12 +3,14 @@ package main
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/lay-g/winpower-g2-exporter/internal/collector"
 	"github.com/lay-g/winpower-g2-exporter/internal/config"
 	"github.com/lay-g/winpower-g2-exporter/internal/energy"
 	"github.com/lay-g/winpower-g2-exporter/internal/metrics"
 	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/shutdown"
 	"github.com/lay-g/winpower-g2-exporter/internal/scheduler"
 	"github.com/lay-g/winpower-g2-exporter/internal/server"
 	"github.com/lay-g/winpower-g2-exporter/internal/storage"
@@ -25,11 +27,18 @@ type App struct {
 	Collector collector.CollectorInterface
 	Metrics   *metrics.MetricsService
 	Server    server.Server
+	Health    *HealthService
 	Scheduler scheduler.Scheduler
+
+	// RemoteConfig 远程配置来源，重新加载配置时同样优先使用；nil 时只读取本地配置文件
+	RemoteConfig *config.RemoteSource
+
+	// PasswordPrompted WinPower 密码是否在启动时交互式输入；为 true 时重新加载的配置沿用该密码
+	PasswordPrompted bool
 }
 
 // initializeApp 按依赖顺序初始化所有模块
-func initializeApp(ctx context.Context, cfg *config.Config, logger log.Logger) (*App, error) {
+func initializeApp(cfg *config.Config, logger log.Logger) (*App, error) {
 	// 1. 初始化存储模块
 	// 依赖: 配置模块、日志模块
 	storageManager, err := storage.NewFileStorageManager(cfg.Storage, logger)
@@ -46,14 +55,15 @@ func initializeApp(ctx context.Context, cfg *config.Config, logger log.Logger) (
 
 	// 3. 初始化电能计算模块
 	// 依赖: 配置模块、日志模块、存储模块
-	energyService := energy.NewEnergyService(storageManager, logger)
+	energyService := energy.NewEnergyServiceWithConfig(storageManager, logger, cfg.Energy)
 
 	// 4. 初始化采集器模块
 	// 依赖: 配置模块、日志模块、WinPower 模块、电能计算模块
-	collectorService, err := collector.NewCollectorService(
+	collectorService, err := collector.NewCollectorServiceWithConfig(
 		winpowerClient,
 		energyService,
 		logger,
+		cfg.Collector,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("初始化采集器模块失败: %w", err)
@@ -61,12 +71,8 @@ func initializeApp(ctx context.Context, cfg *config.Config, logger log.Logger) (
 
 	// 5. 初始化指标模块
 	// 依赖: 配置模块、日志模块、采集器模块
-	metricsConfig := &metrics.MetricsConfig{
-		Namespace:           "winpower",
-		Subsystem:           "exporter",
-		WinPowerHost:        cfg.WinPower.BaseURL,
-		EnableMemoryMetrics: true,
-	}
+	metricsConfig := newMetricsConfig(cfg)
+	metricsConfig.WinPowerHost = cfg.WinPower.BaseURL
 
 	metricsService, err := metrics.NewMetricsService(
 		collectorService,
@@ -76,6 +82,9 @@ func initializeApp(ctx context.Context, cfg *config.Config, logger log.Logger) (
 	if err != nil {
 		return nil, fmt.Errorf("初始化指标模块失败: %w", err)
 	}
+	winpowerClient.SetResponseObserver(metricsService)
+	energyService.SetDegradedObserver(metricsService)
+	collectorService.SetEnergyStallObserver(metricsService)
 
 	// 6. 初始化健康检查服务
 	healthService := NewHealthService(collectorService, logger)
@@ -92,17 +101,30 @@ func initializeApp(ctx context.Context, cfg *config.Config, logger log.Logger) (
 	if err != nil {
 		return nil, fmt.Errorf("初始化服务器模块失败: %w", err)
 	}
+	httpServer.SetDebugInfo(buildStartupSummary(cfg))
+	httpServer.SetEnergyReader(&EnergyReaderAdapter{energy: energyService, collector: collectorService})
+	if cfg.WinPower.RawResponseMaxBytes > 0 {
+		httpServer.SetRawResponseProvider(&RawResponseAdapter{winpower: winpowerClient})
+	}
 
 	// 8. 初始化调度器模块
 	// 依赖: 配置模块、日志模块、采集器模块
 	schedulerService, err := scheduler.NewDefaultScheduler(
 		cfg.Scheduler,
-		&CollectorSchedulerAdapter{collector: collectorService},
+		&CollectorSchedulerAdapter{collector: collectorService, metrics: metricsService, client: winpowerClient},
 		loggerAdapter,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("初始化调度器模块失败: %w", err)
 	}
+	schedulerService.SetOverrunRecorder(metricsService)
+	schedulerService.SetTickRecorder(metricsService)
+	schedulerService.SetHealthRecorder(metricsService)
+	httpServer.SetSchedulerController(&SchedulerControlAdapter{
+		scheduler: schedulerService,
+		metrics:   metricsService,
+		energy:    energyService,
+	})
 
 	return &App{
 		Config:    cfg,
@@ -113,10 +135,45 @@ func initializeApp(ctx context.Context, cfg *config.Config, logger log.Logger) (
 		Collector: collectorService,
 		Metrics:   metricsService,
 		Server:    httpServer,
+		Health:    healthService,
 		Scheduler: schedulerService,
 	}, nil
 }
 
+// newMetricsConfig 由应用配置生成指标模块配置，未配置 metrics 时使用默认值
+func newMetricsConfig(cfg *config.Config) *metrics.MetricsConfig {
+	metricsConfig := metrics.DefaultMetricsConfig()
+	if cfg.Metrics != nil {
+		metricsConfig.EnableMemoryMetrics = cfg.Metrics.EnableMemoryMetrics
+		metricsConfig.EnableDeviceUptime = cfg.Metrics.EnableDeviceUptime
+		metricsConfig.EnableReportedEnergy = cfg.Metrics.EnableReportedEnergy
+		metricsConfig.EnableEnergyResetEpoch = cfg.Metrics.EnableEnergyResetEpoch
+		metricsConfig.EnableRuntimeMetrics = cfg.Metrics.EnableRuntimeMetrics
+		metricsConfig.RuntimeMetricsInterval = cfg.Metrics.RuntimeMetricsInterval
+		metricsConfig.MaxConcurrentCollections = cfg.Metrics.MaxConcurrentCollections
+		metricsConfig.CollectionWaitTimeout = cfg.Metrics.CollectionWaitTimeout
+		metricsConfig.StaleMaxAge = cfg.Metrics.StaleMaxAge
+		metricsConfig.MaxDevices = cfg.Metrics.MaxDevices
+		metricsConfig.MaxLabelValueLength = cfg.Metrics.MaxLabelValueLength
+		metricsConfig.BatteryRuntimeLowMinutes = cfg.Metrics.BatteryRuntimeLowMinutes
+		metricsConfig.DeviceTypePrefixes = cfg.Metrics.DeviceTypePrefixes
+		metricsConfig.EnableDeviceTypeCode = cfg.Metrics.EnableDeviceTypeCode
+		metricsConfig.DeviceTypeCodes = cfg.Metrics.DeviceTypeCodes
+		metricsConfig.DeviceTypeDefaultCode = cfg.Metrics.DeviceTypeDefaultCode
+		metricsConfig.EnableAlarmTypes = cfg.Metrics.EnableAlarmTypes
+		metricsConfig.DeviceGroups = cfg.Metrics.DeviceGroups
+		metricsConfig.DeviceGroupDefault = cfg.Metrics.DeviceGroupDefault
+		metricsConfig.HelpOverrides = cfg.Metrics.HelpOverrides
+		metricsConfig.BatchDeviceUpdates = cfg.Metrics.BatchDeviceUpdates
+		metricsConfig.InvalidValueMode = cfg.Metrics.InvalidValueMode
+		metricsConfig.PushgatewayURL = cfg.Metrics.PushgatewayURL
+		metricsConfig.PushJob = cfg.Metrics.PushJob
+		metricsConfig.PushGrouping = cfg.Metrics.PushGrouping
+		metricsConfig.PushInterval = cfg.Metrics.PushInterval
+	}
+	return metricsConfig
+}
+
 // Start 启动应用程序
 func (app *App) Start(ctx context.Context) error {
 	// 1. 启动 HTTP 服务器（非阻塞）
@@ -126,37 +183,165 @@ func (app *App) Start(ctx context.Context) error {
 		}
 	}()
 
-	// 2. 启动调度器（非阻塞）
+	// 2. 等待存储目录可写，期间 /readyz 返回未就绪
+	// 启用电能降级模式时存储不可用不退出，电能在内存中累计，/readyz 保持未就绪直到存储恢复可写
+	if err := storage.WaitWritable(ctx, app.Config.Storage, app.Logger); err != nil {
+		if ctx.Err() != nil || app.Config.Energy == nil || !app.Config.Energy.DegradedMode {
+			return fmt.Errorf("存储目录不可用: %w", err)
+		}
+		app.Logger.Warn("存储目录不可用，电能以内存降级模式继续启动", log.Err(err))
+		go app.watchStorageRecovery(ctx)
+	} else {
+		app.loadPersistedData(ctx)
+		if app.Health != nil {
+			app.Health.SetStorageReady(true)
+		}
+	}
+
+	// 3. 校验 WinPower 连接，在超时时间内按退避重试；收到退出信号时中止
+	if err := app.verifyWinPower(ctx); err != nil {
+		return err
+	}
+
+	// 4. 启动调度器（非阻塞）
 	if err := app.Scheduler.Start(ctx); err != nil {
 		return fmt.Errorf("启动调度器失败: %w", err)
 	}
 
+	// 5. 定时刷新运行时指标（未启用时不执行），ctx 取消时停止
+	if app.Metrics != nil {
+		app.Metrics.StartRuntimeMetrics(ctx)
+	}
+
+	// 6. 定期保存电能快照（未配置 storage.snapshot_interval 时不执行），ctx 取消时停止
+	if snapshotter, ok := app.Storage.(storage.Snapshotter); ok {
+		snapshotter.StartSnapshots(ctx)
+	}
+
 	return nil
 }
 
+// loadPersistedData 存储目录确认可写后、首次采集前读取已持久化的数据：
+// 重放上次中断的批量写入，再预加载设备电能，避免重启后电能指标短暂归零
+func (app *App) loadPersistedData(ctx context.Context) {
+	if recoverer, ok := app.Storage.(storage.JournalRecoverer); ok {
+		if err := recoverer.RecoverJournal(); err != nil {
+			app.Logger.Error("重放批量写入日志失败", log.Err(err))
+		}
+	}
+	if app.Metrics != nil {
+		preloadDeviceEnergy(ctx, app.Storage, app.Metrics, app.Logger)
+	}
+}
+
+// watchStorageRecovery 降级启动后按 storage.readiness_interval 在后台检查存储目录，
+// 恢复可写后将 /readyz 置为就绪；ctx 取消时停止
+func (app *App) watchStorageRecovery(ctx context.Context) {
+	// 每次只检查一次，重试间隔由本循环控制
+	cfg := *app.Config.Storage
+	cfg.ReadinessTimeout = 0
+	interval := cfg.ReadinessInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := storage.WaitWritable(ctx, &cfg, app.Logger); err != nil {
+			continue
+		}
+		app.Logger.Info("存储目录已恢复可写")
+		if app.Health != nil {
+			app.Health.SetStorageReady(true)
+		}
+		return
+	}
+}
+
+// verifyWinPower 启用 verify_on_start 时校验 WinPower 可达
+// 超时后按 startup_failure_mode 处理：fatal 返回错误，degraded 记录警告后继续启动
+func (app *App) verifyWinPower(ctx context.Context) error {
+	cfg := app.Config.WinPower
+	if app.WinPower == nil || !cfg.VerifyOnStart {
+		return nil
+	}
+
+	err := app.WinPower.WaitConnected(ctx)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+
+	if cfg.StartupFailureMode == winpower.StartupFailureDegraded {
+		app.Logger.Warn("WinPower 不可达，以降级模式继续启动，采集将持续重试",
+			log.Duration("startup_connect_timeout", cfg.StartupConnectTimeout),
+			log.Err(err))
+		return nil
+	}
+	return fmt.Errorf("连接 WinPower 失败: %w", err)
+}
+
 // Shutdown 优雅关闭应用程序
-func (app *App) Shutdown(ctx context.Context) error {
-	var errors []error
+// 各模块按 shutdown 配置中各自的期限关闭，超过期限的模块被放弃并记录日志与指标，
+// 不影响其余模块关闭
+func (app *App) Shutdown() error {
+	manager := shutdown.NewManager(app.Config.Shutdown, app.Logger)
+	if app.Metrics != nil {
+		manager.SetTimeoutRecorder(app.Metrics)
+	}
 
 	// 按相反顺序关闭模块
-	// 1. 停止调度器
+	var steps []shutdown.Step
+
+	// 1. 停止调度器（启动中止时调度器可能尚未运行）
 	if app.Scheduler != nil {
-		if err := app.Scheduler.Stop(ctx); err != nil {
-			errors = append(errors, fmt.Errorf("关闭调度器失败: %w", err))
-			app.Logger.Error("关闭调度器失败", log.Err(err))
-		}
+		steps = append(steps, shutdown.Step{Module: shutdown.ModuleScheduler, Stop: func(ctx context.Context) error {
+			if err := app.Scheduler.Stop(ctx); err != nil && err != scheduler.ErrNotRunning {
+				return fmt.Errorf("关闭调度器失败: %w", err)
+			}
+			return nil
+		}})
 	}
 
 	// 2. 停止服务器
 	if app.Server != nil {
-		if err := app.Server.Stop(ctx); err != nil {
-			errors = append(errors, fmt.Errorf("关闭服务器失败: %w", err))
-			app.Logger.Error("关闭服务器失败", log.Err(err))
-		}
+		steps = append(steps, shutdown.Step{Module: shutdown.ModuleServer, Stop: func(ctx context.Context) error {
+			if err := app.Server.Stop(ctx); err != nil {
+				return fmt.Errorf("关闭服务器失败: %w", err)
+			}
+			return nil
+		}})
+	}
+
+	// 3. 关闭 WinPower 客户端（停止后台 Token 刷新）
+	if app.WinPower != nil {
+		steps = append(steps, shutdown.Step{Module: shutdown.ModuleWinPower, Stop: func(context.Context) error {
+			if err := app.WinPower.Close(); err != nil {
+				return fmt.Errorf("关闭 WinPower 客户端失败: %w", err)
+			}
+			return nil
+		}})
+	}
+
+	// 4. 持久化因 storage.min_persist_interval 暂存在内存中的电能数据
+	if flusher, ok := app.Storage.(storage.Flusher); ok {
+		steps = append(steps, shutdown.Step{Module: shutdown.ModuleStorage, Stop: func(context.Context) error {
+			if err := flusher.Flush(); err != nil {
+				return fmt.Errorf("持久化电能数据失败: %w", err)
+			}
+			return nil
+		}})
 	}
 
-	if len(errors) > 0 {
-		return fmt.Errorf("关闭过程中发生 %d 个错误: %v", len(errors), errors)
+	if errs := manager.Run(steps); len(errs) > 0 {
+		return fmt.Errorf("关闭过程中发生 %d 个错误: %v", len(errs), errs)
 	}
 
 	return nil
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/config"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/shutdown"
+	"github.com/lay-g/winpower-g2-exporter/internal/storage"
+	"github.com/lay-g/winpower-g2-exporter/internal/winpower"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_verifyWinPower(t *testing.T) {
+	// WinPower is up but rejects every login
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	logger, err := log.NewLogger(log.DefaultConfig())
+	require.NoError(t, err)
+
+	newApp := func(mode string) *App {
+		cfg := winpower.DefaultConfig()
+		cfg.BaseURL = server.URL
+		cfg.Username = "admin"
+		cfg.Password = "secret"
+		cfg.BackgroundRefresh = false
+		cfg.VerifyOnStart = true
+		cfg.StartupFailureMode = mode
+
+		client, err := winpower.NewClient(cfg, logger)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = client.Close() })
+
+		return &App{
+			Config:   &config.Config{WinPower: cfg},
+			Logger:   logger,
+			WinPower: client,
+		}
+	}
+
+	t.Run("fatal", func(t *testing.T) {
+		err := newApp(winpower.StartupFailureFatal).verifyWinPower(context.Background())
+		assert.True(t, errors.Is(err, winpower.ErrStartupConnect), "unexpected error: %v", err)
+	})
+
+	t.Run("degraded", func(t *testing.T) {
+		assert.NoError(t, newApp(winpower.StartupFailureDegraded).verifyWinPower(context.Background()))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		app := newApp(winpower.StartupFailureFatal)
+		app.Config.WinPower.VerifyOnStart = false
+		assert.NoError(t, app.verifyWinPower(context.Background()))
+	})
+}
+
+func TestApp_watchStorageRecovery(t *testing.T) {
+	// 数据目录的父路径是普通文件，存储目录无法创建
+	blocker := filepath.Join(t.TempDir(), "blocker")
+	require.NoError(t, os.WriteFile(blocker, nil, 0600))
+
+	storageCfg := storage.DefaultConfig()
+	storageCfg.DataDir = filepath.Join(blocker, "data")
+	storageCfg.ReadinessInterval = 10 * time.Millisecond
+
+	health := NewHealthService(nil, log.NewTestLogger())
+	app := &App{
+		Config: &config.Config{Storage: storageCfg},
+		Logger: log.NewTestLogger(),
+		Health: health,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		app.watchStorageRecovery(ctx)
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	ready, _ := health.Ready(ctx)
+	assert.False(t, ready)
+
+	// 存储恢复后 /readyz 变为就绪
+	require.NoError(t, os.Remove(blocker))
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("storage recovery was not detected")
+	}
+	ready, _ = health.Ready(ctx)
+	assert.True(t, ready)
+}
+
+// stuckStorage 刷盘时一直阻塞的存储，模拟挂起的网络文件系统
+type stuckStorage struct {
+	storage.StorageManager
+	release chan struct{}
+}
+
+func (s *stuckStorage) Flush() error {
+	<-s.release
+	return nil
+}
+
+func TestApp_Shutdown_AbandonsStuckModule(t *testing.T) {
+	stuck := &stuckStorage{release: make(chan struct{})}
+	defer close(stuck.release)
+
+	shutdownCfg := shutdown.DefaultConfig()
+	shutdownCfg.ModuleTimeouts[shutdown.ModuleStorage] = 20 * time.Millisecond
+
+	app := &App{
+		Config:  &config.Config{Shutdown: shutdownCfg},
+		Logger:  log.NewTestLogger(),
+		Storage: stuck,
+	}
+
+	start := time.Now()
+	err := app.Shutdown()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), shutdown.ErrTimeout.Error())
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// ConfigValidationResult 配置校验结果
+// Checks 按规则列出校验结果，便于 CI 生成逐条注释；
+// 需要重启才能生效的变更以 warning 级别的 restart_required 规则列出
+type ConfigValidationResult struct {
+	Valid           bool                `json:"valid"`
+	ExitCode        int                 `json:"exit_code"`
+	Error           string              `json:"error,omitempty"`
+	ErrorCount      int                 `json:"error_count"`
+	WarningCount    int                 `json:"warning_count"`
+	Checks          []config.RuleResult `json:"checks"`
+	Changes         []config.Change     `json:"changes"`
+	RequiresRestart bool                `json:"requires_restart"`
+}
+
+// NewConfigCmd 创建 config 子命令
+func NewConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "配置文件工具",
+		Long:  `对配置文件进行离线检查。`,
+	}
+
+	cmd.AddCommand(newConfigValidateCmd())
+	cmd.AddCommand(newConfigSchemaCmd())
+
+	return cmd
+}
+
+// newConfigValidateCmd 创建 config validate 子命令
+func newConfigValidateCmd() *cobra.Command {
+	var (
+		currentFile string
+		format      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "validate <candidate-config>",
+		Short: "校验候选配置并列出与当前配置的差异",
+		Long: `加载并校验候选配置文件（dry-run，不会应用任何变更），
+并与运行实例当前使用的配置比较，列出将发生的变更以及需要重启才能生效的项。
+
+--current 指向运行实例的配置文件；未指定时按默认搜索路径查找。
+候选配置无效时以非零状态退出。`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigValidate(cmd.OutOrStdout(), currentFile, args[0], format)
+		},
+	}
+
+	cmd.Flags().StringVar(&currentFile, "current", "",
+		"运行实例当前使用的配置文件路径")
+	cmd.Flags().StringVarP(&format, "format", "f", "text",
+		"输出格式 (text|json)")
+
+	return cmd
+}
+
+// newConfigSchemaCmd 创建 config schema 子命令
+func newConfigSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "输出配置文件的 JSON Schema",
+		Long: `输出描述配置文件的 JSON Schema（draft-07），包含字段类型、默认值、必填字段和可选值，
+可用于编辑器的校验与自动补全。Schema 由配置结构体反射生成，始终与当前版本一致。
+
+在 VS Code（YAML 插件）中使用：
+  winpower-g2-exporter config schema > config.schema.json
+并在配置文件首行添加：
+  # yaml-language-server: $schema=./config.schema.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigSchema(cmd.OutOrStdout())
+		},
+	}
+}
+
+// runConfigSchema 输出配置文件的 JSON Schema
+func runConfigSchema(out io.Writer) error {
+	data, err := json.MarshalIndent(config.Schema(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化配置 Schema 失败: %w", err)
+	}
+	_, err = fmt.Fprintln(out, string(data))
+	return err
+}
+
+// runConfigValidate 执行配置校验逻辑
+func runConfigValidate(out io.Writer, currentFile, candidateFile, format string) error {
+	current, err := loadConfigFile(currentFile)
+	if err != nil {
+		return fmt.Errorf("加载当前配置失败: %w", err)
+	}
+
+	result := validateCandidate(current, candidateFile)
+
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("序列化校验结果失败: %w", err)
+		}
+		_, _ = fmt.Fprintln(out, string(data))
+	default:
+		writeValidationText(out, result)
+	}
+
+	if !result.Valid {
+		return fmt.Errorf("候选配置无效: %s", result.Error)
+	}
+	return nil
+}
+
+// validateCandidate 加载并校验候选配置，返回与当前配置的差异
+func validateCandidate(current *config.Config, candidateFile string) *ConfigValidationResult {
+	result := &ConfigValidationResult{
+		Checks:  []config.RuleResult{},
+		Changes: []config.Change{},
+	}
+
+	candidate, err := loadConfigFile(candidateFile)
+	if err != nil {
+		result.Error = err.Error()
+		result.addCheck(config.RuleResult{
+			Rule:     "load",
+			Severity: config.SeverityError,
+			Message:  err.Error(),
+		})
+		result.ExitCode = 1
+		return result
+	}
+
+	for _, check := range candidate.CheckRules() {
+		result.addCheck(check)
+	}
+	if err := candidate.Validate(); err != nil {
+		result.Error = err.Error()
+		result.ExitCode = 1
+		return result
+	}
+
+	result.Valid = true
+	result.Changes = config.Diff(current, candidate)
+	for _, change := range result.Changes {
+		if change.RequiresRestart {
+			result.RequiresRestart = true
+			result.addCheck(config.RuleResult{
+				Rule:     "restart_required",
+				Severity: config.SeverityWarning,
+				Message:  "change takes effect only after a restart",
+				Field:    change.Key,
+			})
+		}
+	}
+	return result
+}
+
+// addCheck 记录一条规则结果并更新错误与警告计数
+func (r *ConfigValidationResult) addCheck(check config.RuleResult) {
+	r.Checks = append(r.Checks, check)
+	switch check.Severity {
+	case config.SeverityError:
+		r.ErrorCount++
+	case config.SeverityWarning:
+		r.WarningCount++
+	}
+}
+
+// loadConfigFile 使用配置加载器加载指定文件，path 为空时使用默认搜索路径
+func loadConfigFile(path string) (*config.Config, error) {
+	return newConfigLoader([]string{path}, nil).Load()
+}
+
+// newConfigLoader 创建按顺序合并指定文件的配置加载器，未指定文件时使用默认搜索路径，
+// remote 非空时优先使用远程配置
+func newConfigLoader(paths []string, remote *config.RemoteSource) *config.Loader {
+	loader := config.NewLoader()
+	loader.SetConfigFiles(paths...)
+	loader.SetRemoteSource(remote)
+	return loader
+}
+
+// writeValidationText 以文本格式输出校验结果
+func writeValidationText(out io.Writer, result *ConfigValidationResult) {
+	if !result.Valid {
+		_, _ = fmt.Fprintf(out, "Invalid: %s\n", result.Error)
+		return
+	}
+
+	_, _ = fmt.Fprintln(out, "Valid")
+	if len(result.Changes) == 0 {
+		_, _ = fmt.Fprintln(out, "No changes")
+		return
+	}
+
+	for _, change := range result.Changes {
+		apply := "runtime"
+		if change.RequiresRestart {
+			apply = "restart"
+		}
+		_, _ = fmt.Fprintf(out, "  [%s] %s: %q -> %q\n", apply, change.Key, change.Old, change.New)
+	}
+}
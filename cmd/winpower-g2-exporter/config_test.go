@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestRunConfigValidate(t *testing.T) {
+	const base = `
+winpower:
+  base_url: "https://winpower.example.com"
+  username: "admin"
+  password: "secret"
+`
+	current := writeConfigFile(t, base)
+
+	t.Run("valid candidate lists changes", func(t *testing.T) {
+		candidate := writeConfigFile(t, base+`
+server:
+  port: 9191
+logging:
+  level: "debug"
+`)
+		var out bytes.Buffer
+		require.NoError(t, runConfigValidate(&out, current, candidate, "text"))
+
+		assert.Contains(t, out.String(), "Valid")
+		assert.Contains(t, out.String(), `[restart] server.port: "9090" -> "9191"`)
+		assert.Contains(t, out.String(), `[runtime] logging.level: "info" -> "debug"`)
+	})
+
+	t.Run("unchanged candidate", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, runConfigValidate(&out, current, current, "json"))
+		assert.Contains(t, out.String(), `"valid": true`)
+		assert.Contains(t, out.String(), `"requires_restart": false`)
+	})
+
+	t.Run("json lists per-rule results", func(t *testing.T) {
+		candidate := writeConfigFile(t, base+`
+server:
+  port: 9191
+`)
+		var out bytes.Buffer
+		require.NoError(t, runConfigValidate(&out, current, candidate, "json"))
+
+		var result ConfigValidationResult
+		require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+		assert.Equal(t, 0, result.ExitCode)
+		assert.Equal(t, 0, result.ErrorCount)
+		assert.Equal(t, 1, result.WarningCount)
+		assert.Contains(t, result.Checks, config.RuleResult{
+			Rule:     "restart_required",
+			Severity: config.SeverityWarning,
+			Message:  "change takes effect only after a restart",
+			Field:    "server.port",
+		})
+	})
+
+	t.Run("json reports the failing rule", func(t *testing.T) {
+		candidate := writeConfigFile(t, base+`
+scheduler:
+  collection_interval: "100ms"
+`)
+		var out bytes.Buffer
+		require.Error(t, runConfigValidate(&out, current, candidate, "json"))
+
+		var result ConfigValidationResult
+		require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+		assert.False(t, result.Valid)
+		assert.Equal(t, 1, result.ExitCode)
+		assert.Equal(t, 1, result.ErrorCount)
+
+		var failed []config.RuleResult
+		for _, check := range result.Checks {
+			if check.Severity == config.SeverityError {
+				failed = append(failed, check)
+			}
+		}
+		require.Len(t, failed, 1)
+		assert.Equal(t, "scheduler", failed[0].Rule)
+		assert.Equal(t, "scheduler", failed[0].Field)
+	})
+
+	t.Run("invalid candidate fails", func(t *testing.T) {
+		candidate := writeConfigFile(t, base+`
+scheduler:
+  collection_interval: "100ms"
+`)
+		var out bytes.Buffer
+		err := runConfigValidate(&out, current, candidate, "text")
+		assert.Error(t, err)
+		assert.Contains(t, out.String(), "Invalid")
+	})
+}
+
+func TestRunConfigSchema(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, runConfigSchema(&out))
+
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &schema))
+	assert.Equal(t, "http://json-schema.org/draft-07/schema#", schema["$schema"])
+	assert.Contains(t, schema["properties"], "winpower")
+}
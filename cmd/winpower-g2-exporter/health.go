@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/lay-g/winpower-g2-exporter/internal/collector"
@@ -12,6 +13,9 @@ import (
 type HealthService struct {
 	collector collector.CollectorInterface
 	logger    log.Logger
+
+	// storageReady 在存储目录确认可写后置为 true
+	storageReady atomic.Bool
 }
 
 // NewHealthService 创建健康检查服务
@@ -32,5 +36,28 @@ func (h *HealthService) Check(ctx context.Context) (status string, details map[s
 	status = "ok"
 	details["service"] = "running"
 
+	// 电能停滞仅作为诊断信息，不影响健康状态：重启无法修复字段映射等集成问题
+	if reporter, ok := h.collector.(collector.EnergyStallReporter); ok {
+		stalled := reporter.StalledEnergyDevices()
+		details["energy"] = map[string]any{
+			"stalled":         len(stalled) > 0,
+			"stalled_devices": stalled,
+		}
+	}
+
 	return status, details
 }
+
+// SetStorageReady 设置存储目录是否已确认可写
+func (h *HealthService) SetStorageReady(ready bool) {
+	h.storageReady.Store(ready)
+}
+
+// Ready 执行就绪检查，存储目录确认可写之前返回未就绪
+func (h *HealthService) Ready(ctx context.Context) (ready bool, details map[string]any) {
+	storageReady := h.storageReady.Load()
+	details = map[string]any{
+		"storage": storageReady,
+	}
+	return storageReady, details
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/config"
+)
+
+var (
+	// errStdinNotTerminal 标准输入不是终端（如 systemd、容器或管道），无法交互式读取密码
+	errStdinNotTerminal = errors.New("标准输入不是终端，无法交互式读取 WinPower 密码；" +
+		"非交互式启动请通过配置文件、环境变量 WINPOWER_EXPORTER_WINPOWER_PASSWORD 或 --winpower.password 提供密码")
+
+	// errEmptyPassword 交互式输入的密码为空
+	errEmptyPassword = errors.New("输入的 WinPower 密码为空")
+)
+
+// terminalPrompter 从终端读取密码，输入不回显
+type terminalPrompter struct {
+	in  *os.File  // 读取密码的终端，通常为 os.Stdin
+	out io.Writer // 输出提示信息，通常为 os.Stderr，避免混入标准输出
+}
+
+// isTerminal 标准输入是否为终端
+func (p *terminalPrompter) isTerminal() bool {
+	return term.IsTerminal(int(p.in.Fd()))
+}
+
+// readPassword 输出提示并读取一行密码；标准输入不是终端时立即返回 errStdinNotTerminal，不会阻塞
+func (p *terminalPrompter) readPassword(prompt string) (string, error) {
+	if !p.isTerminal() {
+		return "", errStdinNotTerminal
+	}
+
+	_, _ = fmt.Fprint(p.out, prompt)
+	password, err := term.ReadPassword(int(p.in.Fd()))
+	// 输入不回显，换行也不会回显，补一个换行保持后续输出整齐
+	_, _ = fmt.Fprintln(p.out)
+	if err != nil {
+		return "", fmt.Errorf("读取 WinPower 密码失败: %w", err)
+	}
+	if len(password) == 0 {
+		return "", errEmptyPassword
+	}
+	return string(password), nil
+}
+
+// promptPassword 按需交互式读取 WinPower 密码并写入 cfg，返回是否读取了密码
+// force 为 true（--winpower-password-stdin）时始终读取，覆盖已配置的密码，标准输入不是终端时返回错误；
+// 否则仅在未配置密码且标准输入为终端时读取。密码只保存在内存中
+func promptPassword(cfg *config.Config, prompter *terminalPrompter, force bool) (bool, error) {
+	if cfg.WinPower == nil {
+		return false, nil
+	}
+	if !force && (cfg.WinPower.Password != "" || !prompter.isTerminal()) {
+		return false, nil
+	}
+
+	prompt := "WinPower 密码: "
+	if cfg.WinPower.Username != "" {
+		prompt = fmt.Sprintf("WinPower 密码（用户 %s，%s）: ", cfg.WinPower.Username, cfg.WinPower.BaseURL)
+	}
+	password, err := prompter.readPassword(prompt)
+	if err != nil {
+		return false, err
+	}
+	cfg.WinPower.Password = password
+	return true, nil
+}
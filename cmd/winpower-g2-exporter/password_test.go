@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/config"
+	"github.com/lay-g/winpower-g2-exporter/internal/winpower"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromptPassword_NotTerminal(t *testing.T) {
+	// A pipe is never a terminal, like stdin under systemd or in a container
+	in, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer in.Close()
+	defer w.Close()
+
+	var out bytes.Buffer
+	prompter := &terminalPrompter{in: in, out: &out}
+
+	t.Run("forced prompt fails instead of waiting", func(t *testing.T) {
+		cfg := &config.Config{WinPower: &winpower.Config{Username: "admin", Password: "secret"}}
+		prompted, err := promptPassword(cfg, prompter, true)
+		assert.ErrorIs(t, err, errStdinNotTerminal)
+		assert.False(t, prompted)
+		assert.Equal(t, "secret", cfg.WinPower.Password)
+		assert.Empty(t, out.String(), "no prompt is shown")
+	})
+
+	t.Run("missing password is left to validation", func(t *testing.T) {
+		cfg := &config.Config{WinPower: &winpower.Config{Username: "admin"}}
+		prompted, err := promptPassword(cfg, prompter, false)
+		assert.NoError(t, err)
+		assert.False(t, prompted)
+		assert.Empty(t, cfg.WinPower.Password)
+	})
+
+	t.Run("configured password is kept", func(t *testing.T) {
+		cfg := &config.Config{WinPower: &winpower.Config{Username: "admin", Password: "secret"}}
+		prompted, err := promptPassword(cfg, prompter, false)
+		assert.NoError(t, err)
+		assert.False(t, prompted)
+		assert.Equal(t, "secret", cfg.WinPower.Password)
+	})
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/collector"
+	"github.com/lay-g/winpower-g2-exporter/internal/metrics"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/storage"
+)
+
+// preloadDeviceEnergy 将已持久化的设备累计电能预加载到指标模块，使电能指标在重启后从存储值延续
+// 存储支持设备元数据时，同时将设备标签的变化记录到元数据文件，供下次启动使用
+// 预加载失败不影响启动，电能指标在首次采集后恢复；读取超时或被取消时预加载已读取的部分设备
+func preloadDeviceEnergy(ctx context.Context, manager storage.StorageManager, metricsService *metrics.MetricsService, logger log.Logger) {
+	var metadata map[string]storage.DeviceMetadata
+	if metadataStorage, ok := manager.(storage.MetadataStorage); ok {
+		var err error
+		if metadata, err = metadataStorage.ReadMetadata(); err != nil {
+			logger.Warn("读取设备元数据失败，设备名称与类型将在首次采集时补全", log.Err(err))
+		}
+		metricsService.SetDeviceLabelRecorder(&DeviceMetadataAdapter{storage: metadataStorage})
+	}
+
+	all, err := manager.ReadAll(ctx)
+	if errors.Is(err, storage.ErrPartialRead) {
+		logger.Warn("读取设备电能未完成，仅预加载已读取的设备", log.Err(err))
+	} else if err != nil {
+		logger.Warn("读取设备电能失败，跳过预加载", log.Err(err))
+		return
+	}
+
+	metricsService.PreloadDevices(buildPreloadedDevices(all, metadata))
+}
+
+// buildPreloadedDevices 按设备 ID 排序生成预加载设备列表
+// 有元数据的设备使用记录的名称、类型和电能存储键；没有元数据的存储记录以存储键作为设备 ID，
+// 名称与类型在首次采集时补全。稳定标识对应的存储键无法确定设备 ID，不预加载
+func buildPreloadedDevices(all map[string]*storage.PowerData, metadata map[string]storage.DeviceMetadata) []metrics.PreloadedDevice {
+	devices := make([]metrics.PreloadedDevice, 0, len(all))
+	covered := make(map[string]bool, len(metadata))
+
+	for deviceID, device := range metadata {
+		key := device.EnergyKey
+		if key == "" {
+			key = deviceID
+		}
+		data, ok := all[key]
+		if !ok || covered[key] {
+			continue
+		}
+		covered[key] = true
+		devices = append(devices, metrics.PreloadedDevice{
+			DeviceLabels: metrics.DeviceLabels{
+				DeviceID:   deviceID,
+				DeviceName: device.DeviceName,
+				DeviceType: device.DeviceType,
+				EnergyKey:  device.EnergyKey,
+			},
+			EnergyWh:   data.EnergyWH,
+			ResetEpoch: data.ResetEpoch,
+		})
+	}
+
+	for key, data := range all {
+		if covered[key] || collector.IsStableEnergyKey(key) {
+			continue
+		}
+		devices = append(devices, metrics.PreloadedDevice{
+			DeviceLabels: metrics.DeviceLabels{DeviceID: key},
+			EnergyWh:     data.EnergyWH,
+			ResetEpoch:   data.ResetEpoch,
+		})
+	}
+
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].DeviceID < devices[j].DeviceID
+	})
+	return devices
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/metrics"
+	"github.com/lay-g/winpower-g2-exporter/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildPreloadedDevices(t *testing.T) {
+	devices := buildPreloadedDevices(
+		map[string]*storage.PowerData{
+			"ups-a":         {EnergyWH: 10},
+			"ups-b":         {EnergyWH: 20},
+			"serial-SN1":    {EnergyWH: 30},
+			"serial-orphan": {EnergyWH: 40},
+		},
+		map[string]storage.DeviceMetadata{
+			"ups-a":       {DeviceName: "UPS A", DeviceType: 1},
+			"ups-renamed": {DeviceName: "UPS C", DeviceType: 2, EnergyKey: "serial-SN1"},
+			"ups-deleted": {DeviceName: "UPS D", DeviceType: 1},
+		},
+	)
+
+	assert.Equal(t, []metrics.PreloadedDevice{
+		{DeviceLabels: metrics.DeviceLabels{DeviceID: "ups-a", DeviceName: "UPS A", DeviceType: 1}, EnergyWh: 10},
+		// 没有元数据的存储记录，名称与类型在首次采集时补全
+		{DeviceLabels: metrics.DeviceLabels{DeviceID: "ups-b"}, EnergyWh: 20},
+		{DeviceLabels: metrics.DeviceLabels{DeviceID: "ups-renamed", DeviceName: "UPS C", DeviceType: 2, EnergyKey: "serial-SN1"}, EnergyWh: 30},
+	}, devices)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/config"
+	"github.com/lay-g/winpower-g2-exporter/internal/metrics"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"go.uber.org/zap/zapcore"
+)
+
+// ReloadConfig 重新加载配置文件（由 SIGHUP 触发），多个配置文件按顺序合并
+// 只应用可在运行时生效的配置项，需要重启的变更记录警告后忽略；
+// 加载或校验失败时保持当前配置。结果记录到 config_reloads_total 指标
+func (a *App) ReloadConfig(cfgFiles ...string) error {
+	result, err := a.reloadConfig(cfgFiles...)
+	if a.Metrics != nil {
+		a.Metrics.RecordConfigReload(result)
+	}
+	if err != nil {
+		a.Logger.Error("配置重新加载失败",
+			log.String("result", result),
+			log.Err(err))
+		return err
+	}
+
+	a.Logger.Info("配置重新加载完成")
+	return nil
+}
+
+// reloadConfig 加载、校验并应用候选配置，返回 metrics.ConfigReload* 结果
+func (a *App) reloadConfig(cfgFiles ...string) (string, error) {
+	loader := newConfigLoader(cfgFiles, a.RemoteConfig)
+	candidate, err := loader.Load()
+	if err != nil {
+		return metrics.ConfigReloadError, fmt.Errorf("加载配置失败: %w", err)
+	}
+	if err := loader.RemoteError(); err != nil {
+		a.Logger.Warn("远程配置不可用，已回退到本地配置文件", log.Err(err))
+	}
+	// 交互式输入的密码只保存在内存中，重新加载的配置中没有该密码
+	if a.PasswordPrompted && candidate.WinPower != nil {
+		candidate.WinPower.Password = a.Config.WinPower.Password
+	}
+	if err := candidate.Validate(); err != nil {
+		return metrics.ConfigReloadValidationFailed, fmt.Errorf("配置校验失败: %w", err)
+	}
+
+	for _, change := range config.Diff(a.Config, candidate) {
+		if change.RequiresRestart {
+			a.Logger.Warn("配置变更需要重启才能生效",
+				log.String("key", change.Key),
+				log.String("old", change.Old),
+				log.String("new", change.New))
+			continue
+		}
+		if err := a.applyChange(candidate, change); err != nil {
+			return metrics.ConfigReloadError, err
+		}
+	}
+	return metrics.ConfigReloadSuccess, nil
+}
+
+// applyChange 应用单个可在运行时生效的配置变更（见 config.RequiresRestart）
+func (a *App) applyChange(candidate *config.Config, change config.Change) error {
+	switch change.Key {
+	case "logging.level":
+		lc, ok := a.Logger.(log.LevelController)
+		if !ok {
+			return fmt.Errorf("日志器不支持动态调整级别")
+		}
+		level, err := zapcore.ParseLevel(candidate.Logging.Level)
+		if err != nil {
+			return fmt.Errorf("解析日志级别失败: %w", err)
+		}
+		lc.SetLevel(level)
+		a.Config.Logging.Level = candidate.Logging.Level
+	default:
+		return fmt.Errorf("配置项 %s 不支持运行时生效", change.Key)
+	}
+
+	a.Logger.Info("配置变更已生效",
+		log.String("key", change.Key),
+		log.String("old", change.Old),
+		log.String("new", change.New))
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/config"
+	"github.com/lay-g/winpower-g2-exporter/internal/metrics"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestApp_ReloadConfig(t *testing.T) {
+	const base = `
+winpower:
+  base_url: "https://winpower.example.com"
+  username: "admin"
+  password: "secret"
+`
+	current, err := loadConfigFile(writeConfigFile(t, base))
+	require.NoError(t, err)
+
+	logger, err := log.NewLogger(log.DefaultConfig())
+	require.NoError(t, err)
+
+	app := &App{Config: current, Logger: logger}
+
+	t.Run("applies runtime changes", func(t *testing.T) {
+		candidate := writeConfigFile(t, base+`
+server:
+  port: 9191
+logging:
+  level: "debug"
+`)
+		result, err := app.reloadConfig(candidate)
+		require.NoError(t, err)
+		assert.Equal(t, metrics.ConfigReloadSuccess, result)
+
+		assert.Equal(t, zapcore.DebugLevel, logger.(log.LevelController).Level())
+		assert.Equal(t, "debug", app.Config.Logging.Level)
+		// Restart-only changes are not applied
+		assert.Equal(t, 9090, app.Config.Server.Port)
+	})
+
+	t.Run("keeps configuration on validation failure", func(t *testing.T) {
+		candidate := writeConfigFile(t, `
+winpower:
+  base_url: "ftp://winpower.example.com"
+  username: "admin"
+  password: "secret"
+logging:
+  level: "info"
+`)
+		result, err := app.reloadConfig(candidate)
+		require.Error(t, err)
+		assert.Equal(t, metrics.ConfigReloadValidationFailed, result)
+		assert.Equal(t, "debug", app.Config.Logging.Level)
+	})
+
+	t.Run("reports load errors", func(t *testing.T) {
+		result, err := app.reloadConfig(writeConfigFile(t, "winpower: [unclosed"))
+		require.Error(t, err)
+		assert.Equal(t, metrics.ConfigReloadError, result)
+	})
+
+	t.Run("works without metrics", func(t *testing.T) {
+		assert.NoError(t, app.ReloadConfig(writeConfigFile(t, base)))
+	})
+
+	t.Run("merges several config files", func(t *testing.T) {
+		overlay := writeConfigFile(t, "logging:\n  level: \"error\"\n")
+		result, err := app.reloadConfig(writeConfigFile(t, base), overlay)
+		require.NoError(t, err)
+		assert.Equal(t, metrics.ConfigReloadSuccess, result)
+		assert.Equal(t, "error", app.Config.Logging.Level)
+	})
+
+	t.Run("keeps the prompted password", func(t *testing.T) {
+		app.PasswordPrompted = true
+		defer func() { app.PasswordPrompted = false }()
+
+		candidate := writeConfigFile(t, `
+winpower:
+  base_url: "https://winpower.example.com"
+  username: "admin"
+`)
+		result, err := app.reloadConfig(candidate)
+		require.NoError(t, err)
+		assert.Equal(t, metrics.ConfigReloadSuccess, result)
+		assert.Equal(t, "secret", app.Config.WinPower.Password)
+	})
+
+	t.Run("prefers the remote config", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(base + "logging:\n  level: \"warn\"\n"))
+		}))
+		defer server.Close()
+
+		app.RemoteConfig = &config.RemoteSource{URL: server.URL}
+		defer func() { app.RemoteConfig = nil }()
+
+		result, err := app.reloadConfig(writeConfigFile(t, base))
+		require.NoError(t, err)
+		assert.Equal(t, metrics.ConfigReloadSuccess, result)
+		assert.Equal(t, "warn", app.Config.Logging.Level)
+	})
+}
@@ -39,6 +39,8 @@ func NewRootCmd() *RootCmd {
 	// 添加子命令
 	root.cmd.AddCommand(NewServerCmd())
 	root.cmd.AddCommand(NewVersionCmd())
+	root.cmd.AddCommand(NewConfigCmd())
+	root.cmd.AddCommand(NewStorageCmd())
 	// 注意：Cobra 会自动添加 help 命令，无需手动添加
 
 	return root
@@ -39,6 +39,8 @@ func TestRootCmdHasSubcommands(t *testing.T) {
 	// 验证必需的子命令存在
 	assert.Contains(t, commandNames, "server")
 	assert.Contains(t, commandNames, "version")
+	assert.Contains(t, commandNames, "config")
+	assert.Contains(t, commandNames, "storage")
 	// Cobra 会自动添加 help 和 completion 命令
 	assert.GreaterOrEqual(t, len(commandNames), 2, "应该至少有 server 和 version 两个子命令")
 }
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/config"
+	"github.com/lay-g/winpower-g2-exporter/internal/energy"
+	"github.com/lay-g/winpower-g2-exporter/internal/metrics"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSelfTest(t *testing.T) {
+	newConfig := func() *config.Config {
+		return &config.Config{
+			Storage: storage.DefaultConfig(),
+			Energy:  energy.DefaultConfig(),
+			Metrics: metrics.DefaultMetricsConfig(),
+		}
+	}
+
+	t.Run("passes", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, runSelfTest(context.Background(), &out, newConfig(), log.NewTestLogger()))
+		assert.Contains(t, out.String(), "PASS  energy accumulates")
+		assert.Contains(t, out.String(), "PASS  metric *_cumulative_energy")
+		assert.Contains(t, out.String(), "Self-test passed")
+		assert.NotContains(t, out.String(), "FAIL")
+	})
+
+	t.Run("device energy and type prefixes", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Energy.Source = energy.SourceDevice
+		cfg.Metrics.DeviceTypePrefixes = map[string]string{"1": "ups"}
+
+		var out bytes.Buffer
+		require.NoError(t, runSelfTest(context.Background(), &out, cfg, log.NewTestLogger()))
+		assert.Contains(t, out.String(), "winpower_ups_power_watts")
+	})
+
+	t.Run("fails when energy does not accumulate", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Energy.MinPowerWatts = 2 * selfTestPowerWatts
+
+		var out bytes.Buffer
+		err := runSelfTest(context.Background(), &out, cfg, log.NewTestLogger())
+		assert.ErrorContains(t, err, "自检失败")
+		assert.Contains(t, out.String(), "FAIL  energy accumulates")
+		assert.Contains(t, out.String(), "Self-test failed")
+	})
+}
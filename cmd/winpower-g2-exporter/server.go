@@ -4,54 +4,102 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
 
 	"github.com/lay-g/winpower-g2-exporter/internal/config"
 	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/signals"
 	"github.com/spf13/cobra"
+	"go.uber.org/zap/zapcore"
 )
 
 // NewServerCmd 创建 server 子命令
 func NewServerCmd() *cobra.Command {
-	var cfgFile string
+	var (
+		cfgFiles      []string
+		selfTest      bool
+		remoteConfig  config.RemoteSource
+		passwordStdin bool
+	)
 
 	cmd := &cobra.Command{
 		Use:   "server",
 		Short: "启动 HTTP 服务器",
 		Long: `启动 WinPower G2 Exporter HTTP 服务器
 
-使用 Ctrl+C 或发送 SIGTERM 信号可以优雅地关闭服务器。`,
+--config 可多次指定，如基础配置叠加环境配置：按顺序合并，后面文件中的配置项覆盖前面的，
+环境变量与命令行参数仍优先于所有文件，合并后的配置统一校验。
+
+默认使用 Ctrl+C 或发送 SIGTERM 信号可以优雅地关闭服务器；
+发送 SIGHUP 信号重新加载配置文件，仅运行时可生效的配置项（如 logging.level）会被应用；
+发送 SIGUSR2 信号在 info 与 debug 日志级别之间切换；
+发送 SIGUSR1 信号重新打开日志文件，配合 logrotate 等外部日志轮转使用。
+信号与动作的对应关系可通过配置项 signals.actions 修改。
+
+使用 --self-test 时不启动服务器，以合成设备数据驱动 采集器 → 电能 → 存储 → 指标
+完整链路运行若干周期，校验电能累计与指标导出后输出结果并退出，用于部署后的冒烟测试；
+自检不连接 WinPower，存储使用临时目录，任一检查未通过时以非零状态码退出。
+
+使用 --remote-config 时启动及 SIGHUP 重新加载时从指定 URL 获取配置（YAML 或 JSON），
+经过与本地配置文件相同的默认值、环境变量合并与校验；获取失败时回退到本地配置文件并记录警告，
+本地配置文件也不存在时启动失败，错误信息指明配置地址与 HTTP 状态码。
+认证头可通过 --remote-config-header 或环境变量 WINPOWER_EXPORTER_REMOTE_CONFIG_HEADER 设置。
+
+未在配置文件、环境变量或命令行参数中配置 WinPower 密码且标准输入为终端时，启动时提示输入密码（不回显）；
+使用 --winpower-password-stdin 时始终提示输入，覆盖已配置的密码。输入的密码只保存在内存中，
+SIGHUP 重新加载配置时沿用。标准输入不是终端时（如 systemd、容器）--winpower-password-stdin 直接报错退出，不会等待输入。`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runServer(cfgFile)
+			if selfTest {
+				return runSelfTestCmd(cmd.OutOrStdout(), cfgFiles)
+			}
+			prompter := &terminalPrompter{in: os.Stdin, out: cmd.ErrOrStderr()}
+			if remoteConfig.URL == "" {
+				return runServer(cfgFiles, nil, prompter, passwordStdin)
+			}
+			if remoteConfig.Header == "" {
+				remoteConfig.Header = os.Getenv(remoteConfigHeaderEnv)
+			}
+			return runServer(cfgFiles, &remoteConfig, prompter, passwordStdin)
 		},
 	}
 
 	// 添加命令行参数
-	cmd.Flags().StringVarP(&cfgFile, "config", "c", "",
-		"配置文件路径")
+	cmd.Flags().StringArrayVarP(&cfgFiles, "config", "c", nil,
+		"配置文件路径，可多次指定，按顺序合并，后面文件中的配置项覆盖前面的")
+	cmd.Flags().BoolVar(&selfTest, "self-test", false,
+		"使用合成设备数据自检完整采集链路后退出")
+	cmd.Flags().StringVar(&remoteConfig.URL, "remote-config", "",
+		"远程配置地址，获取失败时回退到本地配置文件")
+	cmd.Flags().StringVar(&remoteConfig.Header, "remote-config-header", "",
+		"获取远程配置时发送的认证头，格式为 \"Name: value\"")
+	cmd.Flags().BoolVar(&passwordStdin, "winpower-password-stdin", false,
+		"启动时从终端交互式输入 WinPower 密码（不回显），覆盖已配置的密码")
 
 	return cmd
 }
 
-// runServer 执行服务器启动逻辑
-func runServer(cfgFile string) error {
+// remoteConfigHeaderEnv 未指定 --remote-config-header 时读取认证头的环境变量，
+// 避免凭据出现在进程参数中
+const remoteConfigHeaderEnv = "WINPOWER_EXPORTER_REMOTE_CONFIG_HEADER"
+
+// runServer 执行服务器启动逻辑，cfgFiles 按顺序合并，remoteConfig 非空时优先使用远程配置
+// 未配置密码或 passwordStdin 为 true 时通过 prompter 交互式读取 WinPower 密码
+func runServer(cfgFiles []string, remoteConfig *config.RemoteSource, prompter *terminalPrompter, passwordStdin bool) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// 1. 加载配置
-	loader := config.NewLoader()
-	if cfgFile != "" {
-		// 如果指定了配置文件，优先使用
-		if err := initConfig(cfgFile); err != nil {
-			return fmt.Errorf("加载配置失败: %w", err)
-		}
-	}
-
+	// 1. 加载配置，多个配置文件合并后统一校验
+	loader := newConfigLoader(cfgFiles, remoteConfig)
 	cfg, err := loader.Load()
 	if err != nil {
 		return fmt.Errorf("加载配置失败: %w", err)
 	}
+	passwordPrompted, err := promptPassword(cfg, prompter, passwordStdin)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("配置校验失败: %w", err)
+	}
 
 	// 2. 初始化日志
 	logger, err := log.NewLogger(cfg.Logging)
@@ -66,30 +114,60 @@ func runServer(cfgFile string) error {
 		log.String("version", version),
 		log.String("build_time", buildTime),
 		log.String("commit_id", commitID))
+	if err := loader.RemoteError(); err != nil {
+		logger.Warn("远程配置不可用，已回退到本地配置文件", log.Err(err))
+	}
+	for _, warning := range cfg.Warnings() {
+		logger.Warn("配置存在风险",
+			log.String("rule", warning.Rule),
+			log.String("field", warning.Field),
+			log.String("message", warning.Message))
+	}
 
 	// 3. 初始化应用程序
-	app, err := initializeApp(ctx, cfg, logger)
+	app, err := initializeApp(cfg, logger)
 	if err != nil {
 		logger.Error("初始化应用失败", log.Err(err))
 		return fmt.Errorf("初始化应用失败: %w", err)
 	}
+	app.RemoteConfig = remoteConfig
+	app.PasswordPrompted = passwordPrompted
+
+	// 输出启动摘要，便于确认实例配置
+	logger.Info("启动配置摘要", buildStartupSummary(cfg).Fields()...)
 
 	// 4. 设置信号处理
-	setupSignalHandler(cancel, logger)
+	signalManager, err := newSignalManager(cfg.Signals, logger, signals.Handlers{
+		Shutdown: func(os.Signal) { cancel() },
+		Reload: func(os.Signal) {
+			_ = app.ReloadConfig(cfgFiles...)
+		},
+		ReopenLogfile:  func(sig os.Signal) { reopenLogFile(logger, sig) },
+		ToggleLogLevel: func(sig os.Signal) { toggleLogLevel(logger, sig) },
+	})
+	if err != nil {
+		logger.Error("初始化信号处理失败", log.Err(err))
+		return fmt.Errorf("初始化信号处理失败: %w", err)
+	}
+	signalManager.Start()
 
 	// 5. 启动应用
 	logger.Info("WinPower G2 Exporter 启动完成")
 	if err := app.Start(ctx); err != nil {
-		logger.Error("应用启动失败", log.Err(err))
-		return fmt.Errorf("应用启动失败: %w", err)
+		if ctx.Err() == nil {
+			logger.Error("应用启动失败", log.Err(err))
+			return fmt.Errorf("应用启动失败: %w", err)
+		}
+		// 启动过程中收到退出信号（如等待 WinPower 可达时），直接进入优雅关闭
+		logger.Info("启动过程中收到退出信号，中止启动")
 	}
 
 	// 6. 等待退出
 	<-ctx.Done()
 	logger.Info("收到退出信号，开始优雅关闭")
 
-	// 7. 优雅关闭
-	if err := app.Shutdown(ctx); err != nil {
+	// 7. 优雅关闭，各模块的关闭期限见 shutdown 配置
+	if err := app.Shutdown(); err != nil {
 		logger.Error("应用关闭失败", log.Err(err))
 		return fmt.Errorf("应用关闭失败: %w", err)
 	}
@@ -98,14 +176,48 @@ func runServer(cfgFile string) error {
 	return nil
 }
 
-// setupSignalHandler 设置信号处理
-func setupSignalHandler(cancel context.CancelFunc, logger log.Logger) {
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+// newSignalManager 根据配置创建信号管理器，未配置时使用默认的信号映射
+func newSignalManager(cfg *signals.Config, logger log.Logger, handlers signals.Handlers) (*signals.Manager, error) {
+	if cfg == nil {
+		cfg = signals.DefaultConfig()
+	}
+	return signals.NewManager(cfg, logger, handlers)
+}
+
+// toggleLogLevel 在 info 与 debug 级别之间切换日志级别
+func toggleLogLevel(logger log.Logger, sig os.Signal) {
+	lc, ok := logger.(log.LevelController)
+	if !ok {
+		logger.Warn("日志器不支持动态调整级别", log.String("signal", sig.String()))
+		return
+	}
 
-	go func() {
-		sig := <-sigChan
-		logger.Info("收到信号", log.String("signal", sig.String()))
-		cancel()
-	}()
+	from := lc.Level()
+	to := zapcore.DebugLevel
+	if from == zapcore.DebugLevel {
+		to = zapcore.InfoLevel
+	}
+	lc.SetLevel(to)
+
+	// 以 warn 级别记录，确保在任何级别下都可见
+	logger.Warn("日志级别已切换",
+		log.String("signal", sig.String()),
+		log.String("from", from.String()),
+		log.String("to", to.String()))
+}
+
+// reopenLogFile 重新打开日志文件，配合 logrotate 等外部日志轮转
+// 日志未输出到文件时为空操作
+func reopenLogFile(logger log.Logger, sig os.Signal) {
+	r, ok := logger.(log.Reopener)
+	if !ok {
+		logger.Warn("日志器不支持重新打开日志文件", log.String("signal", sig.String()))
+		return
+	}
+
+	if err := r.Reopen(); err != nil {
+		logger.Error("重新打开日志文件失败", log.String("signal", sig.String()), log.Err(err))
+		return
+	}
+	logger.Info("日志文件已重新打开", log.String("signal", sig.String()))
 }
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestToggleLogLevel(t *testing.T) {
+	logger, err := log.NewLogger(log.DefaultConfig())
+	require.NoError(t, err)
+
+	lc := logger.(log.LevelController)
+
+	toggleLogLevel(logger, syscall.SIGTERM)
+	assert.Equal(t, zapcore.DebugLevel, lc.Level())
+
+	toggleLogLevel(logger, syscall.SIGTERM)
+	assert.Equal(t, zapcore.InfoLevel, lc.Level())
+}
+
+func TestReopenLogFile(t *testing.T) {
+	logger := log.NewTestLogger()
+
+	// The test logger has no log file to reopen
+	reopenLogFile(logger, syscall.SIGTERM)
+	assert.Len(t, logger.EntriesByMessage("日志器不支持重新打开日志文件"), 1)
+
+	stdout, err := log.NewLogger(log.DefaultConfig())
+	require.NoError(t, err)
+	reopenLogFile(stdout, syscall.SIGTERM)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// SnapshotDiffRecord 两个电能快照之间单台设备的电能变化
+// 仅存在于其中一个快照的设备，另一侧的电能与差值为空
+type SnapshotDiffRecord struct {
+	DeviceID string   `json:"device_id"`
+	FromWH   *float64 `json:"from_wh,omitempty"`
+	ToWH     *float64 `json:"to_wh,omitempty"`
+	DeltaWH  *float64 `json:"delta_wh,omitempty"`
+}
+
+// newStorageSnapshotCmd 创建 storage snapshot 子命令
+func newStorageSnapshotCmd() *cobra.Command {
+	var cfgFile string
+
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "查看电能快照",
+		Long: `查看按 storage.snapshot_interval 定期保存在 <data_dir>/snapshots 中的电能快照，
+用于按月、按年等长周期比较设备用电量。
+
+该命令只读，可在 Exporter 运行时执行。`,
+	}
+
+	cmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "",
+		"配置文件路径")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "列出所有电能快照",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSnapshotList(cmd.OutOrStdout(), cfgFile)
+		},
+	})
+
+	var format string
+	diffCmd := &cobra.Command{
+		Use:   "diff <from-snapshot> <to-snapshot>",
+		Short: "比较两个电能快照之间各设备的用电量",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSnapshotDiff(cmd.OutOrStdout(), cfgFile, args[0], args[1], format)
+		},
+	}
+	diffCmd.Flags().StringVarP(&format, "format", "f", "text",
+		"输出格式 (text|json)")
+	cmd.AddCommand(diffCmd)
+
+	return cmd
+}
+
+// newSnapshotStorage 创建读取快照使用的存储管理器
+func newSnapshotStorage(cfgFile string) (*storage.FileStorageManager, error) {
+	cfg, err := loadConfigFile(cfgFile)
+	if err != nil {
+		return nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+
+	manager, err := storage.NewFileStorageManager(cfg.Storage, log.NewNoopLogger())
+	if err != nil {
+		return nil, fmt.Errorf("初始化存储失败: %w", err)
+	}
+	return manager.(*storage.FileStorageManager), nil
+}
+
+// runSnapshotList 列出所有电能快照
+func runSnapshotList(out io.Writer, cfgFile string) error {
+	manager, err := newSnapshotStorage(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	snapshots, err := manager.ListSnapshots()
+	if err != nil {
+		return fmt.Errorf("读取电能快照失败: %w", err)
+	}
+	if len(snapshots) == 0 {
+		_, _ = fmt.Fprintln(out, "No snapshots")
+		return nil
+	}
+	for _, snapshot := range snapshots {
+		_, _ = fmt.Fprintf(out, "%s\t%s\n", snapshot.Name, snapshot.Time.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// runSnapshotDiff 比较两个电能快照
+func runSnapshotDiff(out io.Writer, cfgFile, fromName, toName, format string) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("不支持的输出格式: %s", format)
+	}
+
+	manager, err := newSnapshotStorage(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	from, err := readSnapshot(manager, fromName)
+	if err != nil {
+		return err
+	}
+	to, err := readSnapshot(manager, toName)
+	if err != nil {
+		return err
+	}
+
+	records := diffSnapshots(from, to)
+	if format == "json" {
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return fmt.Errorf("序列化快照差异失败: %w", err)
+		}
+		_, _ = fmt.Fprintln(out, string(data))
+		return nil
+	}
+
+	writeSnapshotDiffText(out, from, to, records)
+	return nil
+}
+
+// readSnapshot 读取指定名称的电能快照
+func readSnapshot(manager *storage.FileStorageManager, name string) (*storage.EnergySnapshot, error) {
+	snapshot, err := manager.ReadSnapshot(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("电能快照不存在: %s", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取电能快照失败: %w", err)
+	}
+	return snapshot, nil
+}
+
+// diffSnapshots 计算两个快照之间各设备的电能变化，按设备 ID 排序
+func diffSnapshots(from, to *storage.EnergySnapshot) []SnapshotDiffRecord {
+	deviceIDs := make(map[string]bool, len(from.Devices)+len(to.Devices))
+	for deviceID := range from.Devices {
+		deviceIDs[deviceID] = true
+	}
+	for deviceID := range to.Devices {
+		deviceIDs[deviceID] = true
+	}
+
+	records := make([]SnapshotDiffRecord, 0, len(deviceIDs))
+	for deviceID := range deviceIDs {
+		record := SnapshotDiffRecord{DeviceID: deviceID}
+		if energy, ok := from.Devices[deviceID]; ok {
+			record.FromWH = &energy
+		}
+		if energy, ok := to.Devices[deviceID]; ok {
+			record.ToWH = &energy
+		}
+		if record.FromWH != nil && record.ToWH != nil {
+			delta := math.Round((*record.ToWH-*record.FromWH)*100) / 100
+			record.DeltaWH = &delta
+		}
+		records = append(records, record)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].DeviceID < records[j].DeviceID
+	})
+	return records
+}
+
+// writeSnapshotDiffText 以文本格式输出快照差异
+func writeSnapshotDiffText(out io.Writer, from, to *storage.EnergySnapshot, records []SnapshotDiffRecord) {
+	_, _ = fmt.Fprintf(out, "%s -> %s\n", from.Time.Format(time.RFC3339), to.Time.Format(time.RFC3339))
+	for _, record := range records {
+		switch {
+		case record.FromWH == nil:
+			_, _ = fmt.Fprintf(out, "  %s: added, %.2f Wh\n", record.DeviceID, *record.ToWH)
+		case record.ToWH == nil:
+			_, _ = fmt.Fprintf(out, "  %s: removed, was %.2f Wh\n", record.DeviceID, *record.FromWH)
+		default:
+			_, _ = fmt.Fprintf(out, "  %s: %.2f -> %.2f Wh (%+.2f Wh)\n",
+				record.DeviceID, *record.FromWH, *record.ToWH, *record.DeltaWH)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	from := &storage.EnergySnapshot{
+		Time:    time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+		Devices: map[string]float64{"ups-a": 100, "ups-b": 50.25, "ups-old": 10},
+	}
+	to := &storage.EnergySnapshot{
+		Time:    time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		Devices: map[string]float64{"ups-a": 350.5, "ups-b": 50.25, "ups-new": 5},
+	}
+
+	records := diffSnapshots(from, to)
+	require.Len(t, records, 4)
+	assert.Equal(t, []string{"ups-a", "ups-b", "ups-new", "ups-old"},
+		[]string{records[0].DeviceID, records[1].DeviceID, records[2].DeviceID, records[3].DeviceID})
+	assert.Equal(t, 250.5, *records[0].DeltaWH)
+	assert.Equal(t, 0.0, *records[1].DeltaWH)
+	assert.Nil(t, records[2].FromWH)
+	assert.Nil(t, records[2].DeltaWH)
+	assert.Nil(t, records[3].ToWH)
+
+	var out bytes.Buffer
+	writeSnapshotDiffText(&out, from, to, records)
+	assert.Equal(t,
+		"2026-09-01T00:00:00Z -> 2026-10-01T00:00:00Z\n"+
+			"  ups-a: 100.00 -> 350.50 Wh (+250.50 Wh)\n"+
+			"  ups-b: 50.25 -> 50.25 Wh (+0.00 Wh)\n"+
+			"  ups-new: added, 5.00 Wh\n"+
+			"  ups-old: removed, was 10.00 Wh\n",
+		out.String())
+}
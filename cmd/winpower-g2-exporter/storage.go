@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/config"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// EnergyRecord 导出的单台设备累计电能记录
+type EnergyRecord struct {
+	DeviceID  string  `json:"device_id"`
+	Timestamp string  `json:"timestamp"`
+	EnergyWH  float64 `json:"energy_wh"`
+}
+
+// NewStorageCmd 创建 storage 子命令
+func NewStorageCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "storage",
+		Short: "存储数据工具",
+		Long:  `对已持久化的设备数据进行只读查询和导出。`,
+	}
+
+	cmd.AddCommand(newStorageExportCmd())
+	cmd.AddCommand(newStorageSnapshotCmd())
+
+	return cmd
+}
+
+// newStorageExportCmd 创建 storage export 子命令
+func newStorageExportCmd() *cobra.Command {
+	var (
+		cfgFile    string
+		format     string
+		outputFile string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "导出所有设备的累计电能",
+		Long: `读取数据目录中所有设备的存储数据，按设备 ID 排序导出
+device_id、timestamp（RFC3339，UTC）、energy_wh。
+
+该命令只读，可在 Exporter 运行时执行。`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStorageExport(cmd, cfgFile, format, outputFile)
+		},
+	}
+
+	cmd.Flags().StringVarP(&cfgFile, "config", "c", "",
+		"配置文件路径")
+	cmd.Flags().StringVarP(&format, "format", "f", "csv",
+		"输出格式 (csv|json)")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "",
+		"输出文件路径（默认: 标准输出）")
+
+	return cmd
+}
+
+// runStorageExport 执行导出逻辑
+func runStorageExport(cmd *cobra.Command, cfgFile, format, outputFile string) error {
+	if format != "csv" && format != "json" {
+		return fmt.Errorf("不支持的输出格式: %s", format)
+	}
+
+	// 1. 加载配置
+	loader := config.NewLoader()
+	if cfgFile != "" {
+		loader.SetConfigFile(cfgFile)
+	}
+
+	cfg, err := loader.Load()
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+
+	// 2. 读取所有设备数据
+	manager, err := storage.NewFileStorageManager(cfg.Storage, log.NewNoopLogger())
+	if err != nil {
+		return fmt.Errorf("初始化存储失败: %w", err)
+	}
+
+	all, err := manager.ReadAll(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("读取设备数据失败: %w", err)
+	}
+
+	records := buildEnergyRecords(all)
+
+	// 3. 输出
+	out := cmd.OutOrStdout()
+	if outputFile != "" {
+		f, err := os.Create(outputFile)
+		if err != nil {
+			return fmt.Errorf("创建输出文件失败: %w", err)
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		out = f
+	}
+
+	if format == "json" {
+		return writeEnergyJSON(out, records)
+	}
+	return writeEnergyCSV(out, records)
+}
+
+// buildEnergyRecords 将存储数据转换为按设备 ID 排序的导出记录
+func buildEnergyRecords(all map[string]*storage.PowerData) []EnergyRecord {
+	records := make([]EnergyRecord, 0, len(all))
+	for deviceID, data := range all {
+		records = append(records, EnergyRecord{
+			DeviceID:  deviceID,
+			Timestamp: time.UnixMilli(data.Timestamp).UTC().Format(time.RFC3339),
+			EnergyWH:  data.EnergyWH,
+		})
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].DeviceID < records[j].DeviceID
+	})
+	return records
+}
+
+// writeEnergyCSV 以 CSV 格式输出导出记录
+func writeEnergyCSV(out io.Writer, records []EnergyRecord) error {
+	w := csv.NewWriter(out)
+	if err := w.Write([]string{"device_id", "timestamp", "energy_wh"}); err != nil {
+		return fmt.Errorf("写入 CSV 失败: %w", err)
+	}
+	for _, r := range records {
+		row := []string{r.DeviceID, r.Timestamp, strconv.FormatFloat(r.EnergyWH, 'f', 2, 64)}
+		if err := w.Write(row); err != nil {
+			return fmt.Errorf("写入 CSV 失败: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("写入 CSV 失败: %w", err)
+	}
+	return nil
+}
+
+// writeEnergyJSON 以 JSON 格式输出导出记录
+func writeEnergyJSON(out io.Writer, records []EnergyRecord) error {
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化导出记录失败: %w", err)
+	}
+	_, _ = fmt.Fprintln(out, string(data))
+	return nil
+}
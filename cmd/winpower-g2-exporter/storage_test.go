@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildEnergyRecords(t *testing.T) {
+	ts := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC).UnixMilli()
+	records := buildEnergyRecords(map[string]*storage.PowerData{
+		"ups-b": {Timestamp: ts, EnergyWH: 20.5},
+		"ups-a": {Timestamp: ts, EnergyWH: 1234.567},
+	})
+
+	require.Len(t, records, 2)
+	assert.Equal(t, "ups-a", records[0].DeviceID)
+	assert.Equal(t, "2024-01-15T10:00:00Z", records[0].Timestamp)
+	assert.Equal(t, "ups-b", records[1].DeviceID)
+
+	var csvOut bytes.Buffer
+	require.NoError(t, writeEnergyCSV(&csvOut, records))
+	assert.Equal(t,
+		"device_id,timestamp,energy_wh\n"+
+			"ups-a,2024-01-15T10:00:00Z,1234.57\n"+
+			"ups-b,2024-01-15T10:00:00Z,20.50\n",
+		csvOut.String())
+
+	var jsonOut bytes.Buffer
+	require.NoError(t, writeEnergyJSON(&jsonOut, records))
+	var decoded []EnergyRecord
+	require.NoError(t, json.Unmarshal(jsonOut.Bytes(), &decoded))
+	assert.Equal(t, records, decoded)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/config"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/tlsconfig"
+)
+
+// StartupSummary 启动摘要，汇总已加载配置中运维最关心的信息
+type StartupSummary struct {
+	WinPowerURLs       []string `json:"winpower_urls"`
+	CollectionInterval string   `json:"collection_interval"`
+	CollectionCron     string   `json:"collection_cron,omitempty"`
+	MetricCategories   []string `json:"metric_categories"`
+	StorageBackend     string   `json:"storage_backend"`
+	StorageDir         string   `json:"storage_dir"`
+	TLS                bool     `json:"tls"`
+	TLSMinVersion      string   `json:"tls_min_version,omitempty"`
+	AuthMode           string   `json:"auth_mode"`
+	Pprof              bool     `json:"pprof"`
+	Listeners          []string `json:"listeners"`
+}
+
+// buildStartupSummary 从已加载的配置构建启动摘要，不重新读取任何配置源
+func buildStartupSummary(cfg *config.Config) *StartupSummary {
+	summary := &StartupSummary{
+		AuthMode: "none",
+		// 存储目前仅支持文件后端
+		StorageBackend: "file",
+	}
+
+	if cfg.WinPower != nil {
+		summary.WinPowerURLs = []string{redactURL(cfg.WinPower.BaseURL)}
+	}
+
+	if cfg.Scheduler != nil {
+		summary.CollectionInterval = cfg.Scheduler.CollectionInterval.String()
+		summary.CollectionCron = cfg.Scheduler.Cron
+	}
+
+	summary.MetricCategories = []string{"exporter", "connection", "device", "energy"}
+	if cfg.Metrics != nil {
+		if cfg.Metrics.EnableMemoryMetrics {
+			summary.MetricCategories = append(summary.MetricCategories, "memory")
+		}
+		if cfg.Metrics.EnableDeviceUptime {
+			summary.MetricCategories = append(summary.MetricCategories, "device_uptime")
+		}
+		if cfg.Metrics.EnableDeviceTypeCode {
+			summary.MetricCategories = append(summary.MetricCategories, "device_type_code")
+		}
+		if cfg.Metrics.EnableAlarmTypes {
+			summary.MetricCategories = append(summary.MetricCategories, "alarm_types")
+		}
+	}
+
+	if cfg.Storage != nil {
+		summary.StorageDir = cfg.Storage.DataDir
+	}
+
+	if cfg.Server != nil {
+		summary.Pprof = cfg.Server.EnablePprof
+		summary.TLS = cfg.Server.TLSEnabled()
+		if summary.TLS {
+			summary.TLSMinVersion = cfg.Server.TLSMinVersion
+			if summary.TLSMinVersion == "" {
+				summary.TLSMinVersion = tlsconfig.DefaultMinVersion
+			}
+		}
+		// 配置 api_token 后 /api 与 /admin 端点要求 Bearer Token
+		if cfg.Server.APIToken != "" {
+			summary.AuthMode = "bearer"
+		}
+		if len(cfg.Server.Listeners) == 0 {
+			summary.Listeners = []string{net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port))}
+		}
+		for _, l := range cfg.Server.Listeners {
+			summary.Listeners = append(summary.Listeners, l.Address)
+		}
+	}
+
+	return summary
+}
+
+// Fields 返回用于日志输出的字段
+func (s *StartupSummary) Fields() []log.Field {
+	return []log.Field{
+		log.Any("winpower_urls", s.WinPowerURLs),
+		log.String("collection_interval", s.CollectionInterval),
+		log.String("collection_cron", s.CollectionCron),
+		log.Any("metric_categories", s.MetricCategories),
+		log.String("storage_backend", s.StorageBackend),
+		log.String("storage_dir", s.StorageDir),
+		log.Bool("tls", s.TLS),
+		log.String("tls_min_version", s.TLSMinVersion),
+		log.String("auth_mode", s.AuthMode),
+		log.Bool("pprof", s.Pprof),
+		log.Any("listeners", s.Listeners),
+	}
+}
+
+// redactURL 去除 URL 中可能携带的用户信息，避免凭据泄露到日志
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	u.User = nil
+	return u.String()
+}
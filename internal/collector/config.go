@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"fmt"
+	"time"
+)
+
+// Config holds the collector configuration
+type Config struct {
+	// DevicePriority lists device IDs whose energy is calculated first in
+	// each collection cycle, in order. When a slow cycle is cut short by its
+	// deadline, the listed devices are the last to be dropped. Devices not
+	// listed follow in the order WinPower reported them.
+	DevicePriority []string `json:"device_priority" yaml:"device_priority" mapstructure:"device_priority"`
+
+	// DeviceMaxAge is how long a device that WinPower stops reporting stays
+	// in the DeviceStore after its last successful collection before it is
+	// expired (0 = never expire).
+	DeviceMaxAge time.Duration `json:"device_max_age" yaml:"device_max_age" mapstructure:"device_max_age"`
+
+	// EnergyStallWindow is how long the energy of a device whose integrated
+	// power should advance it every collection may stay unchanged before
+	// the device is reported as stalled, which reveals an integration that silently adds nothing
+	// while collections succeed. It should span several collection
+	// intervals, and more than the time a coarse counter of energy source
+	// "device" takes to advance (0 = disabled, the default).
+	EnergyStallWindow time.Duration `json:"energy_stall_window" yaml:"energy_stall_window" mapstructure:"energy_stall_window"`
+}
+
+// DefaultConfig returns the default collector configuration
+func DefaultConfig() *Config {
+	return &Config{
+		DeviceMaxAge: 24 * time.Hour,
+	}
+}
+
+// Validate validates the collector configuration
+func (c *Config) Validate() error {
+	seen := make(map[string]bool, len(c.DevicePriority))
+	for _, deviceID := range c.DevicePriority {
+		if deviceID == "" {
+			return fmt.Errorf("device_priority must not contain empty device IDs")
+		}
+		if seen[deviceID] {
+			return fmt.Errorf("device_priority contains duplicate device ID: %q", deviceID)
+		}
+		seen[deviceID] = true
+	}
+	if c.DeviceMaxAge < 0 {
+		return fmt.Errorf("device_max_age must not be negative, got: %v", c.DeviceMaxAge)
+	}
+	if c.EnergyStallWindow < 0 {
+		return fmt.Errorf("energy_stall_window must not be negative, got: %v", c.EnergyStallWindow)
+	}
+	return nil
+}
+
+// priorityRanks maps each prioritized device ID to its position in the list
+func (c *Config) priorityRanks() map[string]int {
+	ranks := make(map[string]int, len(c.DevicePriority))
+	for i, deviceID := range c.DevicePriority {
+		ranks[deviceID] = i
+	}
+	return ranks
+}
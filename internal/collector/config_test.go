@@ -0,0 +1,38 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      *Config
+		errContains string
+	}{
+		{name: "Default config", config: DefaultConfig()},
+		{name: "Priority list", config: &Config{DevicePriority: []string{"ups-1", "ups-2"}}},
+		{name: "Empty device ID", config: &Config{DevicePriority: []string{"ups-1", ""}}, errContains: "empty"},
+		{name: "Duplicate device ID", config: &Config{DevicePriority: []string{"ups-1", "ups-1"}}, errContains: "duplicate"},
+		{name: "Negative device max age", config: &Config{DeviceMaxAge: -time.Hour}, errContains: "device_max_age"},
+		{name: "Stall detection disabled", config: &Config{EnergyStallWindow: 0}},
+		{name: "Negative stall window", config: &Config{EnergyStallWindow: -time.Minute}, errContains: "energy_stall_window"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.errContains == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("Expected error containing %q, got %v", tt.errContains, err)
+			}
+		})
+	}
+}
@@ -11,6 +11,10 @@ var (
 	// ErrWinPowerCollection indicates failure in WinPower data collection
 	ErrWinPowerCollection = errors.New("winpower data collection failed")
 
+	// ErrSourceMaintenance indicates WinPower reported maintenance mode and
+	// the collection was skipped
+	ErrSourceMaintenance = errors.New("winpower in maintenance mode")
+
 	// ErrEnergyCalculation indicates failure in energy calculation
 	ErrEnergyCalculation = errors.New("energy calculation failed")
 
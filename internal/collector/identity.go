@@ -0,0 +1,104 @@
+package collector
+
+import (
+	"strings"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/winpower"
+)
+
+// stableKeyPrefix distinguishes energy keys derived from a stable identity
+// from device IDs
+const stableKeyPrefix = "serial-"
+
+// StableEnergyKey returns the energy key of a device with the given stable
+// identity. Characters that cannot appear in a storage file name are
+// replaced with underscores.
+func StableEnergyKey(stableID string) string {
+	return stableKeyPrefix + strings.NewReplacer("/", "_", "\\", "_").Replace(stableID)
+}
+
+// IsStableEnergyKey reports whether an energy key was derived from a stable
+// identity rather than being a device ID
+func IsStableEnergyKey(key string) bool {
+	return strings.HasPrefix(key, stableKeyPrefix)
+}
+
+// EnergyKey returns the key the energy of a device is accumulated under:
+// the key of its stable identity once energy is tracked by it, otherwise
+// the device ID
+func (cs *CollectorService) EnergyKey(deviceID string) string {
+	state, ok := cs.store.Get(deviceID)
+	if !ok || state.Data.StableID == "" {
+		return deviceID
+	}
+
+	key := StableEnergyKey(state.Data.StableID)
+	cs.identityMu.Lock()
+	defer cs.identityMu.Unlock()
+	if _, tracked := cs.identities[key]; tracked {
+		return key
+	}
+	return deviceID
+}
+
+// sharedStableIDs returns the stable identities reported by more than one
+// device of a collection, e.g. when the identity field is not unique. Their
+// devices keep accumulating energy under their device IDs.
+func sharedStableIDs(devices []winpower.ParsedDeviceData) map[string]bool {
+	seen := make(map[string]bool)
+	shared := make(map[string]bool)
+	for _, device := range devices {
+		if device.StableID == "" {
+			continue
+		}
+		if seen[device.StableID] {
+			shared[device.StableID] = true
+		}
+		seen[device.StableID] = true
+	}
+	return shared
+}
+
+// resolveEnergyKey returns the energy key of a collected device. The first
+// time a stable key is used, energy stored under the device ID is adopted so
+// that enabling identity tracking keeps the accumulated totals; a device ID
+// change for a known key is logged. If the adoption fails, the device ID is
+// used for this collection and the adoption is retried on the next one.
+func (cs *CollectorService) resolveEnergyKey(device winpower.ParsedDeviceData) string {
+	if device.StableID == "" {
+		return device.DeviceID
+	}
+	key := StableEnergyKey(device.StableID)
+
+	cs.identityMu.Lock()
+	defer cs.identityMu.Unlock()
+
+	previousID, known := cs.identities[key]
+	if known {
+		if previousID != device.DeviceID {
+			cs.logger.Info("Device ID changed, energy follows the stable identity",
+				log.String("energy_key", key),
+				log.String("previous_device_id", previousID),
+				log.String("device_id", device.DeviceID))
+			cs.identities[key] = device.DeviceID
+		}
+		return key
+	}
+
+	if adopter, ok := cs.energyCalc.(EnergyAdopter); ok {
+		if _, err := adopter.Adopt(key, device.DeviceID); err != nil {
+			cs.logger.Warn("Failed to adopt energy for stable identity, using device ID",
+				log.String("energy_key", key),
+				log.String("device_id", device.DeviceID),
+				log.Err(err))
+			return device.DeviceID
+		}
+	}
+
+	if cs.identities == nil {
+		cs.identities = make(map[string]string)
+	}
+	cs.identities[key] = device.DeviceID
+	return key
+}
@@ -0,0 +1,107 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/winpower"
+)
+
+// adoptingEnergyCalculator records the keys energy is calculated and adopted for
+type adoptingEnergyCalculator struct {
+	MockEnergyCalculator
+	adoptErr   error
+	adoptions  [][2]string
+	calculated []string
+}
+
+func (c *adoptingEnergyCalculator) Adopt(key, deviceID string) (bool, error) {
+	if c.adoptErr != nil {
+		return false, c.adoptErr
+	}
+	c.adoptions = append(c.adoptions, [2]string{key, deviceID})
+	return true, nil
+}
+
+func (c *adoptingEnergyCalculator) Calculate(deviceID string, power float64) (float64, error) {
+	c.calculated = append(c.calculated, deviceID)
+	return 0, nil
+}
+
+func TestStableEnergyKey(t *testing.T) {
+	if got := StableEnergyKey("SN/001"); got != "serial-SN_001" {
+		t.Errorf("Expected serial-SN_001, got %q", got)
+	}
+	if !IsStableEnergyKey(StableEnergyKey("SN1")) || IsStableEnergyKey("ups-1") {
+		t.Error("Expected only stable keys to be reported as stable")
+	}
+}
+
+func TestCollectorService_StableIdentity(t *testing.T) {
+	devices := []winpower.ParsedDeviceData{{DeviceID: "ups-old", StableID: "SN1"}, {DeviceID: "ups-plain"}}
+	mockWinPower := &MockWinPowerClient{
+		CollectDeviceDataFunc: func(ctx context.Context) ([]winpower.ParsedDeviceData, error) {
+			return devices, nil
+		},
+	}
+	calc := &adoptingEnergyCalculator{adoptErr: errors.New("storage unavailable")}
+
+	service, err := NewCollectorService(mockWinPower, calc, log.NewTestLogger())
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	collect := func() *CollectionResult {
+		t.Helper()
+		calc.calculated = nil
+		result, err := service.CollectDeviceData(context.Background())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return result
+	}
+
+	// A failed adoption falls back to the device ID
+	collect()
+	if calc.calculated[0] != "ups-old" || service.EnergyKey("ups-old") != "ups-old" {
+		t.Errorf("Expected device ID while adoption fails, got %v", calc.calculated)
+	}
+
+	// The energy stored under the device ID is adopted once
+	calc.adoptErr = nil
+	collect()
+	collect()
+	if len(calc.adoptions) != 1 || calc.adoptions[0] != [2]string{"serial-SN1", "ups-old"} {
+		t.Errorf("Expected a single adoption from ups-old, got %v", calc.adoptions)
+	}
+	if calc.calculated[0] != "serial-SN1" || calc.calculated[1] != "ups-plain" {
+		t.Errorf("Expected stable key and plain device ID, got %v", calc.calculated)
+	}
+
+	// After a rename the energy follows the stable identity
+	devices[0].DeviceID = "ups-new"
+	result := collect()
+	if calc.calculated[0] != "serial-SN1" || len(calc.adoptions) != 1 {
+		t.Errorf("Expected renamed device to keep its key, got %v", calc.calculated)
+	}
+	if result.Devices["ups-new"].EnergyKey != "serial-SN1" || result.Devices["ups-plain"].EnergyKey != "" {
+		t.Errorf("Expected the energy key only for the tracked device, got %q and %q",
+			result.Devices["ups-new"].EnergyKey, result.Devices["ups-plain"].EnergyKey)
+	}
+	for _, id := range []string{"ups-new", "ups-old"} {
+		if key := service.EnergyKey(id); key != "serial-SN1" {
+			t.Errorf("Expected EnergyKey(%s) = serial-SN1, got %q", id, key)
+		}
+	}
+	if key := service.EnergyKey("ups-plain"); key != "ups-plain" {
+		t.Errorf("Expected device without identity to keep its ID, got %q", key)
+	}
+
+	// An identity reported by several devices is not used
+	devices = []winpower.ParsedDeviceData{{DeviceID: "ups-a", StableID: "SN2"}, {DeviceID: "ups-b", StableID: "SN2"}}
+	collect()
+	if calc.calculated[0] != "ups-a" || calc.calculated[1] != "ups-b" {
+		t.Errorf("Expected device IDs for a shared identity, got %v", calc.calculated)
+	}
+}
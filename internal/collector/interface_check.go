@@ -16,9 +16,39 @@ var (
 	// Verify that winpower.Client implements WinPowerClient interface
 	_ WinPowerClient = (*winpower.Client)(nil)
 
+	// Verify that winpower.Client reports its token refresh counts
+	_ TokenRefreshCounter = (*winpower.Client)(nil)
+
 	// Verify that energy.EnergyService implements EnergyCalculator interface
 	_ EnergyCalculator = (*energy.EnergyService)(nil)
 
+	// Verify that energy.EnergyService accepts the collection context
+	_ ContextEnergyCalculator = (*energy.EnergyService)(nil)
+
+	// Verify that energy.EnergyService can track device-reported energy
+	_ DeviceEnergyCalculator = (*energy.EnergyService)(nil)
+
+	// Verify that energy.EnergyService can prefer the reported average power
+	_ AveragePowerCalculator = (*energy.EnergyService)(nil)
+
+	// Verify that energy.EnergyService reports its minimum integration power
+	_ MinPowerCalculator = (*energy.EnergyService)(nil)
+
+	// Verify that energy.EnergyService can persist a collection in one batch
+	_ BatchEnergyCalculator = (*energy.EnergyService)(nil)
+
+	// Verify that energy.EnergyService can adopt energy for a stable key
+	_ EnergyAdopter = (*energy.EnergyService)(nil)
+
 	// Verify that CollectorService implements CollectorInterface
 	_ CollectorInterface = (*CollectorService)(nil)
+
+	// Verify that CollectorService exposes its device store
+	_ DeviceStateProvider = (*CollectorService)(nil)
+
+	// Verify that CollectorService publishes device snapshots
+	_ SnapshotProvider = (*CollectorService)(nil)
+
+	// Verify that CollectorService reports devices whose energy stalls
+	_ EnergyStallReporter = (*CollectorService)(nil)
 )
@@ -28,6 +28,63 @@ type WinPowerClient interface {
 	IsTokenValid() bool
 }
 
+// DeviceEnergyCalculator is optionally implemented by an EnergyCalculator
+// that can track the device-reported cumulative energy instead of integrating
+// power.
+type DeviceEnergyCalculator interface {
+	// UsesDeviceEnergy reports whether energy is taken from the device counter
+	UsesDeviceEnergy() bool
+	// CalculateFromDevice calculates cumulative energy from a device counter reading in Wh
+	CalculateFromDevice(ctx context.Context, deviceID string, deviceEnergyWh float64) (float64, error)
+}
+
+// AveragePowerCalculator is optionally implemented by an EnergyCalculator
+// that integrates the interval-average power reported by WinPower, when
+// available, instead of the instantaneous reading.
+type AveragePowerCalculator interface {
+	// UsesAveragePower reports whether the reported average power is preferred
+	UsesAveragePower() bool
+}
+
+// MinPowerCalculator is optionally implemented by an EnergyCalculator that
+// integrates readings below a minimum power as zero.
+type MinPowerCalculator interface {
+	// MinPowerWatts returns the minimum integrated power in watts, 0 when
+	// every reading is integrated
+	MinPowerWatts() float64
+}
+
+// TokenRefreshCounter is optionally implemented by a WinPowerClient that
+// counts its token refreshes.
+type TokenRefreshCounter interface {
+	// GetTokenRefreshCounts returns the cumulative successful and failed refreshes
+	GetTokenRefreshCounts() (successes, failures int64)
+}
+
+// BatchEnergyCalculator is optionally implemented by an EnergyCalculator
+// that can persist the energy of all devices of a collection together.
+type BatchEnergyCalculator interface {
+	// BeginBatch returns the context to calculate the devices of one
+	// collection with and a commit function persisting their energy.
+	// The commit function is nil when batching is disabled.
+	BeginBatch(ctx context.Context) (context.Context, func() error)
+}
+
+// DeviceStateProvider is optionally implemented by a CollectorInterface that
+// caches the last-known data of each device.
+type DeviceStateProvider interface {
+	// DeviceStore returns the device data cache
+	DeviceStore() *DeviceStore
+}
+
+// SnapshotProvider is optionally implemented by a CollectorInterface that
+// publishes an immutable Snapshot of the devices after each collection.
+type SnapshotProvider interface {
+	// Snapshot returns the latest snapshot, or nil before the first
+	// successful collection
+	Snapshot() *Snapshot
+}
+
 // EnergyCalculator defines the interface for energy calculation.
 // Following the same principle as WinPowerClient, this interface is defined here
 // to ensure the collector controls its own dependency contracts.
@@ -37,3 +94,32 @@ type EnergyCalculator interface {
 	// Get retrieves the latest energy value for a device
 	Get(deviceID string) (float64, error)
 }
+
+// EnergyAdopter is optionally implemented by an EnergyCalculator that can
+// carry energy stored under a device ID over to a stable energy key.
+type EnergyAdopter interface {
+	// Adopt copies the energy stored for deviceID to key unless key already
+	// has energy, and reports whether it did
+	Adopt(key, deviceID string) (bool, error)
+}
+
+// EnergyEpochReader is optionally implemented by an EnergyCalculator that
+// counts the deliberate resets of each device's energy.
+type EnergyEpochReader interface {
+	// ResetEpoch returns how many times the energy stored under key was reset
+	ResetEpoch(key string) uint64
+}
+
+// EnergyStallReporter is optionally implemented by a CollectorInterface that
+// detects active devices whose energy does not advance.
+type EnergyStallReporter interface {
+	// StalledEnergyDevices returns the IDs of the stalled devices
+	StalledEnergyDevices() []string
+}
+
+// ContextEnergyCalculator is optionally implemented by an EnergyCalculator
+// that accepts the collection context, e.g. to record stage timings.
+type ContextEnergyCalculator interface {
+	// CalculateContext calculates cumulative energy for a device
+	CalculateContext(ctx context.Context, deviceID string, power float64) (float64, error)
+}
@@ -1,11 +1,17 @@
 package collector
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/timing"
 	"github.com/lay-g/winpower-g2-exporter/internal/winpower"
 )
 
@@ -14,6 +20,31 @@ type CollectorService struct {
 	winpowerClient WinPowerClient
 	energyCalc     EnergyCalculator
 	logger         log.Logger
+
+	// store caches the latest data and freshness of each device
+	store *DeviceStore
+
+	// deviceMaxAge is how long an unreported device stays in store
+	// (0 = forever)
+	deviceMaxAge time.Duration
+
+	// priority maps prioritized device IDs to their rank
+	priority map[string]int
+
+	// snapshot is the latest published Snapshot; snapshotMu serializes
+	// publishing so that an older collection never replaces a newer one
+	snapshotMu sync.Mutex
+	snapshot   atomic.Pointer[Snapshot]
+
+	// identities maps the energy key of each stable device identity seen
+	// to the device ID it was last reported with
+	identityMu sync.Mutex
+	identities map[string]string
+
+	// stall detects active devices whose energy does not advance; nil when
+	// disabled
+	stall         *stallTracker
+	stallObserver EnergyStallObserver
 }
 
 // NewCollectorService creates a new collector service with dependency injection
@@ -21,6 +52,17 @@ func NewCollectorService(
 	winpowerClient WinPowerClient,
 	energyCalc EnergyCalculator,
 	logger log.Logger,
+) (*CollectorService, error) {
+	return NewCollectorServiceWithConfig(winpowerClient, energyCalc, logger, DefaultConfig())
+}
+
+// NewCollectorServiceWithConfig creates a new collector service with the
+// given configuration. A nil config uses the defaults.
+func NewCollectorServiceWithConfig(
+	winpowerClient WinPowerClient,
+	energyCalc EnergyCalculator,
+	logger log.Logger,
+	config *Config,
 ) (*CollectorService, error) {
 	// Validate dependencies
 	if winpowerClient == nil {
@@ -32,11 +74,18 @@ func NewCollectorService(
 	if logger == nil {
 		return nil, fmt.Errorf("%w: logger", ErrNilDependency)
 	}
+	if config == nil {
+		config = DefaultConfig()
+	}
 
 	return &CollectorService{
 		winpowerClient: winpowerClient,
 		energyCalc:     energyCalc,
 		logger:         logger,
+		store:          NewDeviceStore(),
+		deviceMaxAge:   config.DeviceMaxAge,
+		priority:       config.priorityRanks(),
+		stall:          newStallTracker(config.EnergyStallWindow, minPowerWatts(energyCalc)),
 	}, nil
 }
 
@@ -49,17 +98,39 @@ func (cs *CollectorService) CollectDeviceData(ctx context.Context) (*CollectionR
 	start := time.Now()
 
 	// Collect data from WinPower
-	devices, err := cs.collectFromWinPower(ctx)
+	devices, err := cs.winpowerClient.CollectDeviceData(ctx)
+	if errors.Is(err, winpower.ErrMaintenance) {
+		// The data is not trustworthy, skip the energy integration
+		cs.logger.Warn("Skipping collection, WinPower is in maintenance mode")
+		result := &CollectionResult{
+			Success:        false,
+			Devices:        make(map[string]*DeviceCollectionInfo),
+			CollectionTime: time.Now(),
+			Duration:       time.Since(start),
+			ErrorMessage:   ErrSourceMaintenance.Error(),
+			ErrorKind:      winpower.ErrorTypeMaintenance,
+			Maintenance:    true,
+		}
+		cs.setTokenRefreshCounts(result)
+		return result, ErrSourceMaintenance
+	}
 	if err != nil {
-		cs.logger.Error("Failed to collect data from WinPower", log.Err(err))
-		return &CollectionResult{
+		errorKind := winpower.ClassifyError(err)
+		err = fmt.Errorf("%w: %v", ErrWinPowerCollection, err)
+		cs.logger.Error("Failed to collect data from WinPower",
+			log.Err(err),
+			log.String("error_kind", errorKind))
+		result := &CollectionResult{
 			Success:        false,
 			DeviceCount:    0,
 			Devices:        make(map[string]*DeviceCollectionInfo),
 			CollectionTime: time.Now(),
 			Duration:       time.Since(start),
 			ErrorMessage:   err.Error(),
-		}, err
+			ErrorKind:      errorKind,
+		}
+		cs.setTokenRefreshCounts(result)
+		return result, err
 	}
 
 	// Process device data and trigger energy calculations
@@ -68,14 +139,11 @@ func (cs *CollectorService) CollectDeviceData(ctx context.Context) (*CollectionR
 	return result, nil
 }
 
-// collectFromWinPower collects device data from WinPower module
-func (cs *CollectorService) collectFromWinPower(ctx context.Context) ([]winpower.ParsedDeviceData, error) {
-	devices, err := cs.winpowerClient.CollectDeviceData(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrWinPowerCollection, err)
+// setTokenRefreshCounts copies the client's token refresh counts into the result
+func (cs *CollectorService) setTokenRefreshCounts(result *CollectionResult) {
+	if counter, ok := cs.winpowerClient.(TokenRefreshCounter); ok {
+		result.TokenRefreshes, result.TokenRefreshFailures = counter.GetTokenRefreshCounts()
 	}
-
-	return devices, nil
 }
 
 // processDeviceData processes each device and triggers energy calculation
@@ -94,11 +162,49 @@ func (cs *CollectorService) processDeviceData(
 		TokenExpiresAt: cs.winpowerClient.GetTokenExpiresAt(),
 	}
 
+	cs.setTokenRefreshCounts(result)
+
+	// Detect samples WinPower has not refreshed before the store is updated
+	duplicates := make(map[string]bool)
 	for _, device := range devices {
+		if cs.store.IsDuplicate(device) {
+			duplicates[device.DeviceID] = true
+		}
+	}
+
+	cs.expireDevices(result.CollectionTime)
+	firstSeen, states := cs.store.update(devices, result.CollectionTime)
+
+	ctx, commit := cs.beginEnergyBatch(ctx)
+	shared := sharedStableIDs(devices)
+	for stableID := range shared {
+		cs.logger.Warn("Stable identity reported by several devices, using their device IDs for energy",
+			log.String("stable_id", stableID))
+	}
+
+	powers := make(map[string]float64, len(devices))
+	for _, device := range cs.prioritize(devices) {
 		deviceInfo := cs.convertToDeviceInfo(device)
+		powers[device.DeviceID] = cs.integrationPower(device)
+		deviceInfo.FirstSeenTime = firstSeen[device.DeviceID]
+
+		// A repeated sample would only integrate the same power again
+		if duplicates[device.DeviceID] {
+			deviceInfo.Duplicate = true
+			cs.logDuplicate(device)
+			result.Devices[device.DeviceID] = deviceInfo
+			continue
+		}
 
 		// Trigger energy calculation for each device
-		if err := cs.calculateEnergy(device.DeviceID, device.Realtime.LoadTotalWatt, deviceInfo); err != nil {
+		key := device.DeviceID
+		if !shared[device.StableID] {
+			key = cs.resolveEnergyKey(device)
+		}
+		if key != device.DeviceID {
+			deviceInfo.EnergyKey = key
+		}
+		if err := cs.calculateEnergy(ctx, device, key, deviceInfo); err != nil {
 			cs.logger.Warn("Energy calculation failed for device",
 				log.String("device_id", device.DeviceID),
 				log.Err(err))
@@ -108,28 +214,188 @@ func (cs *CollectorService) processDeviceData(
 		result.Devices[device.DeviceID] = deviceInfo
 	}
 
+	cs.commitEnergyBatch(ctx, commit, result)
+	cs.publishSnapshot(result, states)
+	cs.trackEnergyStalls(result, powers)
+
 	result.Duration = time.Since(startTime)
 	return result
 }
 
-// calculateEnergy triggers energy calculation and updates device info
+// prioritize returns the devices with the prioritized ones first, in
+// priority order, followed by the rest in their reported order
+func (cs *CollectorService) prioritize(devices []winpower.ParsedDeviceData) []winpower.ParsedDeviceData {
+	if len(cs.priority) == 0 {
+		return devices
+	}
+
+	ordered := slices.Clone(devices)
+	slices.SortStableFunc(ordered, func(a, b winpower.ParsedDeviceData) int {
+		return cmp.Compare(cs.rank(a.DeviceID), cs.rank(b.DeviceID))
+	})
+	return ordered
+}
+
+// rank returns the priority rank of a device, ranking unlisted devices last
+func (cs *CollectorService) rank(deviceID string) int {
+	if rank, ok := cs.priority[deviceID]; ok {
+		return rank
+	}
+	return len(cs.priority)
+}
+
+// publishSnapshot publishes the snapshot of a collection unless a newer
+// collection has already published one
+func (cs *CollectorService) publishSnapshot(result *CollectionResult, states []DeviceState) {
+	cs.snapshotMu.Lock()
+	defer cs.snapshotMu.Unlock()
+
+	prev := cs.snapshot.Load()
+	if prev != nil && prev.CollectionTime.After(result.CollectionTime) {
+		return
+	}
+	cs.snapshot.Store(newSnapshot(result, states, prev))
+}
+
+// Snapshot returns the snapshot published by the latest successful
+// collection, or nil before the first one
+func (cs *CollectorService) Snapshot() *Snapshot {
+	return cs.snapshot.Load()
+}
+
+// logDuplicate notes a sample WinPower has not refreshed since the previous
+// collection, suggesting WinPower's observed refresh period as the minimum
+// collection interval once it is known
+func (cs *CollectorService) logDuplicate(device winpower.ParsedDeviceData) {
+	fields := []log.Field{
+		log.String("device_id", device.DeviceID),
+		log.Time("data_time", device.Realtime.DataTime),
+	}
+	if state, ok := cs.store.Get(device.DeviceID); ok && state.RefreshPeriod > 0 {
+		fields = append(fields, log.Duration("suggested_min_interval", state.RefreshPeriod))
+	}
+	cs.logger.Debug("Skipping duplicate WinPower sample", fields...)
+}
+
+// beginEnergyBatch starts a batch for the energy writes of this collection
+// if the energy calculator supports it. The returned commit is nil otherwise.
+func (cs *CollectorService) beginEnergyBatch(ctx context.Context) (context.Context, func() error) {
+	if calc, ok := cs.energyCalc.(BatchEnergyCalculator); ok {
+		return calc.BeginBatch(ctx)
+	}
+	return ctx, nil
+}
+
+// commitEnergyBatch persists the energy writes of this collection. When the
+// commit fails none of the energy values were stored, so every device that
+// calculated its energy is reported as failed.
+func (cs *CollectorService) commitEnergyBatch(ctx context.Context, commit func() error, result *CollectionResult) {
+	if commit == nil {
+		return
+	}
+
+	stop := timing.Track(ctx, timing.StageStorageWrite)
+	err := commit()
+	stop()
+	if err == nil {
+		return
+	}
+
+	cs.logger.Error("Failed to commit energy batch", log.Err(err))
+	for _, deviceInfo := range result.Devices {
+		if !deviceInfo.EnergyCalculated {
+			continue
+		}
+		deviceInfo.EnergyCalculated = false
+		deviceInfo.EnergyValue = 0
+		deviceInfo.ErrorMsg = fmt.Sprintf("energy batch commit failed: %v", err)
+		deviceInfo.ErrorType = DeviceErrorEnergy
+	}
+}
+
+// DeviceStore returns the cache of the last-known device data, which is
+// updated after each successful collection
+func (cs *CollectorService) DeviceStore() *DeviceStore {
+	return cs.store
+}
+
+// expireDevices removes devices not reported within deviceMaxAge from the
+// store
+func (cs *CollectorService) expireDevices(now time.Time) {
+	if cs.deviceMaxAge <= 0 {
+		return
+	}
+	for _, deviceID := range cs.store.Expire(now.Add(-cs.deviceMaxAge)) {
+		cs.logger.Info("Expired device no longer reported by WinPower",
+			log.String("device_id", deviceID),
+			log.Duration("device_max_age", cs.deviceMaxAge))
+	}
+}
+
+// calculateEnergy triggers energy calculation under the given energy key
+// and updates device info
 func (cs *CollectorService) calculateEnergy(
-	deviceID string,
-	power float64,
+	ctx context.Context,
+	device winpower.ParsedDeviceData,
+	key string,
 	deviceInfo *DeviceCollectionInfo,
 ) error {
-	energy, err := cs.energyCalc.Calculate(deviceID, power)
+	power := cs.integrationPower(device)
+
+	var energy float64
+	var err error
+	if calc, ok := cs.energyCalc.(DeviceEnergyCalculator); ok && calc.UsesDeviceEnergy() {
+		// A missing reading parses as 0, which would be taken for a counter
+		// reset; keep the stored total instead
+		if !device.Realtime.EnergyTotalReported {
+			deviceInfo.EnergyCalculated = false
+			deviceInfo.ErrorMsg = "energy calculation skipped: device did not report energy_total_wh"
+			deviceInfo.ErrorType = DeviceErrorEnergy
+			return fmt.Errorf("%w: device did not report energy_total_wh", ErrEnergyCalculation)
+		}
+		energy, err = calc.CalculateFromDevice(ctx, key, device.Realtime.EnergyTotalWh)
+	} else if calc, ok := cs.energyCalc.(ContextEnergyCalculator); ok {
+		// The calculator records its own calculation and storage stages
+		energy, err = calc.CalculateContext(ctx, key, power)
+	} else {
+		stop := timing.Track(ctx, timing.StageEnergy)
+		energy, err = cs.energyCalc.Calculate(key, power)
+		stop()
+	}
 	if err != nil {
 		deviceInfo.EnergyCalculated = false
 		deviceInfo.ErrorMsg = fmt.Sprintf("energy calculation failed: %v", err)
+		deviceInfo.ErrorType = DeviceErrorEnergy
 		return fmt.Errorf("%w: %v", ErrEnergyCalculation, err)
 	}
 
 	deviceInfo.EnergyCalculated = true
 	deviceInfo.EnergyValue = energy
+	if epochs, ok := cs.energyCalc.(EnergyEpochReader); ok {
+		deviceInfo.EnergyResetEpoch = epochs.ResetEpoch(key)
+	}
 	return nil
 }
 
+// integrationPower returns the power reading integrated into energy: the
+// interval-average power when the calculator prefers it and the device
+// reported it, otherwise the instantaneous power
+func (cs *CollectorService) integrationPower(device winpower.ParsedDeviceData) float64 {
+	if calc, ok := cs.energyCalc.(AveragePowerCalculator); ok && calc.UsesAveragePower() && device.Realtime.LoadAvgReported {
+		return device.Realtime.LoadAvgWatt
+	}
+	return device.Realtime.LoadTotalWatt
+}
+
+// minPowerWatts returns the minimum integrated power of the calculator, 0
+// when it integrates every reading
+func minPowerWatts(energyCalc EnergyCalculator) float64 {
+	if calc, ok := energyCalc.(MinPowerCalculator); ok {
+		return calc.MinPowerWatts()
+	}
+	return 0
+}
+
 // convertToDeviceInfo converts WinPower data to DeviceCollectionInfo
 func (cs *CollectorService) convertToDeviceInfo(device winpower.ParsedDeviceData) *DeviceCollectionInfo {
 	return &DeviceCollectionInfo{
@@ -168,10 +434,30 @@ func (cs *CollectorService) convertToDeviceInfo(device winpower.ParsedDeviceData
 		Status:         device.Realtime.Status,
 		TestStatus:     device.Realtime.TestStatus,
 		FaultCode:      device.Realtime.FaultCode,
+		ActiveAlarms:   device.ActiveAlarms,
+
+		// Parse information
+		MissingFields:         device.MissingFields,
+		MissingOptionalFields: device.MissingOptionalFields,
+		InvalidFields:         device.InvalidFields,
+
+		// Energy reported by WinPower, exported for cross-checking
+		ReportedEnergyWh: reportedEnergy(device.Realtime),
 
 		// Initialize energy fields (will be updated by calculateEnergy)
 		EnergyCalculated: false,
 		EnergyValue:      0,
 		ErrorMsg:         "",
+		ErrorType:        "",
+	}
+}
+
+// reportedEnergy returns the cumulative energy reported by WinPower, or nil
+// when the device does not report it
+func reportedEnergy(realtime winpower.RealtimeData) *float64 {
+	if !realtime.EnergyTotalReported {
+		return nil
 	}
+	energy := realtime.EnergyTotalWh
+	return &energy
 }
@@ -3,10 +3,14 @@ package collector
 import (
 	"context"
 	"errors"
+	"fmt"
+	"maps"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/timing"
 	"github.com/lay-g/winpower-g2-exporter/internal/winpower"
 )
 
@@ -190,6 +194,59 @@ func TestCollectorService_CollectDeviceData_WinPowerError(t *testing.T) {
 	}
 }
 
+func TestCollectorService_CollectDeviceData_ErrorKind(t *testing.T) {
+	logger := log.NewTestLogger()
+
+	mockWinPower := &MockWinPowerClient{
+		CollectDeviceDataFunc: func(ctx context.Context) ([]winpower.ParsedDeviceData, error) {
+			return nil, fmt.Errorf("data fetch failed: %w", &winpower.HTTPStatusError{StatusCode: 503})
+		},
+	}
+
+	service, err := NewCollectorService(mockWinPower, &MockEnergyCalculator{}, logger)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	result, err := service.CollectDeviceData(context.Background())
+	if !errors.Is(err, ErrWinPowerCollection) {
+		t.Errorf("Expected ErrWinPowerCollection, got %v", err)
+	}
+	if result.ErrorKind != winpower.ErrorTypeHTTPStatus {
+		t.Errorf("Expected error kind %q, got %q", winpower.ErrorTypeHTTPStatus, result.ErrorKind)
+	}
+}
+
+func TestCollectorService_CollectDeviceData_Maintenance(t *testing.T) {
+	mockWinPower := &MockWinPowerClient{
+		CollectDeviceDataFunc: func(ctx context.Context) ([]winpower.ParsedDeviceData, error) {
+			return nil, winpower.ErrMaintenance
+		},
+	}
+	mockEnergy := &MockEnergyCalculator{
+		CalculateFunc: func(deviceID string, power float64) (float64, error) {
+			t.Errorf("Expected no energy calculation during maintenance, got one for %s", deviceID)
+			return 0, nil
+		},
+	}
+
+	service, err := NewCollectorService(mockWinPower, mockEnergy, log.NewTestLogger())
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	result, err := service.CollectDeviceData(context.Background())
+	if !errors.Is(err, ErrSourceMaintenance) {
+		t.Errorf("Expected ErrSourceMaintenance, got %v", err)
+	}
+	if result == nil || !result.Maintenance || result.Success {
+		t.Fatalf("Expected an unsuccessful maintenance result, got %+v", result)
+	}
+	if result.ErrorKind != winpower.ErrorTypeMaintenance {
+		t.Errorf("Expected error kind %q, got %q", winpower.ErrorTypeMaintenance, result.ErrorKind)
+	}
+}
+
 func TestCollectorService_CollectDeviceData_EnergyCalculationError(t *testing.T) {
 	logger := log.NewTestLogger()
 
@@ -245,6 +302,9 @@ func TestCollectorService_CollectDeviceData_EnergyCalculationError(t *testing.T)
 	if device.ErrorMsg == "" {
 		t.Error("Expected error message to be set")
 	}
+	if device.ErrorType != DeviceErrorEnergy {
+		t.Errorf("Expected error type %q, got %q", DeviceErrorEnergy, device.ErrorType)
+	}
 }
 
 func TestCollectorService_CollectDeviceData_MultipleDevices(t *testing.T) {
@@ -319,6 +379,297 @@ func TestCollectorService_CollectDeviceData_MultipleDevices(t *testing.T) {
 	}
 }
 
+func TestCollectorService_CollectDeviceData_DevicePriority(t *testing.T) {
+	logger := log.NewTestLogger()
+
+	mockWinPower := &MockWinPowerClient{
+		CollectDeviceDataFunc: func(ctx context.Context) ([]winpower.ParsedDeviceData, error) {
+			return []winpower.ParsedDeviceData{
+				{DeviceID: "device1", Connected: true},
+				{DeviceID: "device2", Connected: true},
+				{DeviceID: "device3", Connected: true},
+				{DeviceID: "device4", Connected: true},
+			}, nil
+		},
+	}
+
+	var order []string
+	mockEnergy := &MockEnergyCalculator{
+		CalculateFunc: func(deviceID string, power float64) (float64, error) {
+			order = append(order, deviceID)
+			return 0, nil
+		},
+	}
+
+	config := &Config{DevicePriority: []string{"device3", "missing", "device2"}}
+	service, err := NewCollectorServiceWithConfig(mockWinPower, mockEnergy, logger, config)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	result, err := service.CollectDeviceData(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result.Devices) != 4 {
+		t.Errorf("Expected 4 devices, got %d", len(result.Devices))
+	}
+
+	// Prioritized devices come first, the rest keep their reported order
+	want := []string{"device3", "device2", "device1", "device4"}
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected calculation order %v, got %v", want, order)
+	}
+}
+
+func TestCollectorService_CollectDeviceData_FirstSeenResetsOnDisappear(t *testing.T) {
+	present := true
+	mockWinPower := &MockWinPowerClient{
+		CollectDeviceDataFunc: func(ctx context.Context) ([]winpower.ParsedDeviceData, error) {
+			if !present {
+				return []winpower.ParsedDeviceData{}, nil
+			}
+			return []winpower.ParsedDeviceData{{DeviceID: "device1", CollectedAt: time.Now()}}, nil
+		},
+	}
+	mockEnergy := &MockEnergyCalculator{
+		CalculateFunc: func(deviceID string, power float64) (float64, error) {
+			return 0, nil
+		},
+	}
+
+	service, err := NewCollectorService(mockWinPower, mockEnergy, log.NewTestLogger())
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	first, _ := service.CollectDeviceData(context.Background())
+	firstSeen := first.Devices["device1"].FirstSeenTime
+	if firstSeen.IsZero() {
+		t.Fatal("Expected first seen time to be set")
+	}
+
+	second, _ := service.CollectDeviceData(context.Background())
+	if !second.Devices["device1"].FirstSeenTime.Equal(firstSeen) {
+		t.Error("Expected first seen time to be kept while device keeps reporting")
+	}
+
+	present = false
+	_, _ = service.CollectDeviceData(context.Background())
+	present = true
+
+	third, _ := service.CollectDeviceData(context.Background())
+	if !third.Devices["device1"].FirstSeenTime.After(firstSeen) {
+		t.Error("Expected first seen time to reset after device disappeared")
+	}
+}
+
+func TestCollectorService_CollectDeviceData_SkipsDuplicateSamples(t *testing.T) {
+	dataTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockWinPower := &MockWinPowerClient{
+		CollectDeviceDataFunc: func(ctx context.Context) ([]winpower.ParsedDeviceData, error) {
+			return []winpower.ParsedDeviceData{{
+				DeviceID:    "device1",
+				CollectedAt: time.Now(),
+				Realtime:    winpower.RealtimeData{LoadTotalWatt: 500, DataTime: dataTime},
+			}}, nil
+		},
+	}
+	calculations := 0
+	mockEnergy := &MockEnergyCalculator{
+		CalculateFunc: func(deviceID string, power float64) (float64, error) {
+			calculations++
+			return 100, nil
+		},
+	}
+
+	service, err := NewCollectorService(mockWinPower, mockEnergy, log.NewTestLogger())
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	first, _ := service.CollectDeviceData(context.Background())
+	if first.Devices["device1"].Duplicate || !first.Devices["device1"].EnergyCalculated {
+		t.Errorf("Expected first sample to be calculated, got %+v", first.Devices["device1"])
+	}
+
+	second, _ := service.CollectDeviceData(context.Background())
+	device := second.Devices["device1"]
+	if !device.Duplicate || device.EnergyCalculated {
+		t.Errorf("Expected repeated sample to be skipped, got %+v", device)
+	}
+	if calculations != 1 {
+		t.Errorf("Expected 1 energy calculation, got %d", calculations)
+	}
+
+	dataTime = dataTime.Add(time.Minute)
+	third, _ := service.CollectDeviceData(context.Background())
+	if third.Devices["device1"].Duplicate || calculations != 2 {
+		t.Errorf("Expected refreshed sample to be calculated, got %+v after %d calculations", third.Devices["device1"], calculations)
+	}
+}
+
+// averagePowerCalculator is a MockEnergyCalculator that prefers the
+// reported average power
+type averagePowerCalculator struct {
+	MockEnergyCalculator
+}
+
+func (a *averagePowerCalculator) UsesAveragePower() bool {
+	return true
+}
+
+func TestCollectorService_CollectDeviceData_AveragePower(t *testing.T) {
+	devices := []winpower.ParsedDeviceData{
+		{DeviceID: "reported", Realtime: winpower.RealtimeData{LoadTotalWatt: 500, LoadAvgWatt: 650, LoadAvgReported: true}},
+		{DeviceID: "unreported", Realtime: winpower.RealtimeData{LoadTotalWatt: 500}},
+	}
+	mockWinPower := &MockWinPowerClient{
+		CollectDeviceDataFunc: func(ctx context.Context) ([]winpower.ParsedDeviceData, error) {
+			return devices, nil
+		},
+	}
+
+	integrated := make(map[string]float64)
+	record := func(deviceID string, power float64) (float64, error) {
+		integrated[deviceID] = power
+		return 0, nil
+	}
+
+	tests := []struct {
+		name       string
+		calculator EnergyCalculator
+		want       map[string]float64
+	}{
+		{
+			name:       "instantaneous power by default",
+			calculator: &MockEnergyCalculator{CalculateFunc: record},
+			want:       map[string]float64{"reported": 500, "unreported": 500},
+		},
+		{
+			name:       "average power when preferred and reported",
+			calculator: &averagePowerCalculator{MockEnergyCalculator{CalculateFunc: record}},
+			want:       map[string]float64{"reported": 650, "unreported": 500},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clear(integrated)
+			service, err := NewCollectorService(mockWinPower, tt.calculator, log.NewTestLogger())
+			if err != nil {
+				t.Fatalf("Failed to create service: %v", err)
+			}
+
+			if _, err := service.CollectDeviceData(context.Background()); err != nil {
+				t.Fatalf("Collection failed: %v", err)
+			}
+			if !maps.Equal(integrated, tt.want) {
+				t.Errorf("Expected integrated power %v, got %v", tt.want, integrated)
+			}
+		})
+	}
+}
+
+// deviceEnergyCalculator is a MockEnergyCalculator that tracks the device
+// energy counter like the energy service: a reading below the last one is a
+// counter reset and becomes the new baseline
+type deviceEnergyCalculator struct {
+	MockEnergyCalculator
+	total    float64
+	baseline *float64
+}
+
+func (d *deviceEnergyCalculator) UsesDeviceEnergy() bool {
+	return true
+}
+
+func (d *deviceEnergyCalculator) CalculateFromDevice(ctx context.Context, deviceID string, deviceEnergyWh float64) (float64, error) {
+	if d.baseline != nil && deviceEnergyWh >= *d.baseline {
+		d.total += deviceEnergyWh - *d.baseline
+	}
+	d.baseline = &deviceEnergyWh
+	return d.total, nil
+}
+
+func TestCollectorService_CollectDeviceData_MissingDeviceEnergy(t *testing.T) {
+	readings := []winpower.RealtimeData{
+		{EnergyTotalWh: 10000, EnergyTotalReported: true},
+		{EnergyTotalWh: 10050, EnergyTotalReported: true},
+		{}, // energy_total_wh missing or unparseable
+		{EnergyTotalWh: 10100, EnergyTotalReported: true},
+	}
+	var reading int
+	mockWinPower := &MockWinPowerClient{
+		CollectDeviceDataFunc: func(ctx context.Context) ([]winpower.ParsedDeviceData, error) {
+			return []winpower.ParsedDeviceData{{DeviceID: "device1", Realtime: readings[reading]}}, nil
+		},
+	}
+	calculator := &deviceEnergyCalculator{}
+
+	service, err := NewCollectorService(mockWinPower, calculator, log.NewTestLogger())
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	var results []*DeviceCollectionInfo
+	for reading = range readings {
+		result, err := service.CollectDeviceData(context.Background())
+		if err != nil {
+			t.Fatalf("Collection %d failed: %v", reading, err)
+		}
+		results = append(results, result.Devices["device1"])
+	}
+
+	if missing := results[2]; missing.EnergyCalculated || missing.ErrorType != DeviceErrorEnergy {
+		t.Errorf("Expected the missing reading to skip the calculation, got %+v", missing)
+	}
+	if energy := results[1].EnergyValue; energy != 50 {
+		t.Errorf("Expected energy 50 before the missing reading, got %v", energy)
+	}
+	if energy := results[3].EnergyValue; energy != 100 {
+		t.Errorf("Expected energy 100 after the missing reading, got %v", energy)
+	}
+}
+
+// epochEnergyCalculator is a MockEnergyCalculator that reports reset epochs
+type epochEnergyCalculator struct {
+	MockEnergyCalculator
+	epochs map[string]uint64
+}
+
+func (e *epochEnergyCalculator) ResetEpoch(key string) uint64 {
+	return e.epochs[key]
+}
+
+func TestCollectorService_CollectDeviceData_ResetEpoch(t *testing.T) {
+	mockWinPower := &MockWinPowerClient{
+		CollectDeviceDataFunc: func(ctx context.Context) ([]winpower.ParsedDeviceData, error) {
+			return []winpower.ParsedDeviceData{
+				{DeviceID: "reset", Realtime: winpower.RealtimeData{LoadTotalWatt: 500}},
+				{DeviceID: "never-reset", Realtime: winpower.RealtimeData{LoadTotalWatt: 500}},
+			}, nil
+		},
+	}
+	calculator := &epochEnergyCalculator{epochs: map[string]uint64{"reset": 3}}
+
+	service, err := NewCollectorService(mockWinPower, calculator, log.NewTestLogger())
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	result, err := service.CollectDeviceData(context.Background())
+	if err != nil {
+		t.Fatalf("Collection failed: %v", err)
+	}
+	if epoch := result.Devices["reset"].EnergyResetEpoch; epoch != 3 {
+		t.Errorf("Expected reset epoch 3, got %d", epoch)
+	}
+	if epoch := result.Devices["never-reset"].EnergyResetEpoch; epoch != 0 {
+		t.Errorf("Expected reset epoch 0, got %d", epoch)
+	}
+}
+
 func TestCollectorService_CollectDeviceData_NilContext(t *testing.T) {
 	logger := log.NewTestLogger()
 	mockWinPower := &MockWinPowerClient{}
@@ -375,7 +726,8 @@ func TestCollectorService_ConvertToDeviceInfo(t *testing.T) {
 			TestStatus:     "passed",
 			FaultCode:      "",
 		},
-		CollectedAt: now,
+		CollectedAt:  now,
+		ActiveAlarms: []string{"overload"},
 	}
 
 	info := service.convertToDeviceInfo(device)
@@ -404,6 +756,11 @@ func TestCollectorService_ConvertToDeviceInfo(t *testing.T) {
 		t.Errorf("Expected battery capacity 100.0, got %f", info.BatCapacity)
 	}
 
+	// Alarms
+	if len(info.ActiveAlarms) != 1 || info.ActiveAlarms[0] != "overload" {
+		t.Errorf("Expected active alarms [overload], got %v", info.ActiveAlarms)
+	}
+
 	// Initial energy state
 	if info.EnergyCalculated {
 		t.Error("Expected initial energy calculated to be false")
@@ -427,3 +784,120 @@ func containsRecursive(s, substr string) bool {
 	}
 	return containsRecursive(s[1:], substr)
 }
+
+func TestCollectorService_CollectDeviceData_RecordsEnergyStage(t *testing.T) {
+	mockWinPower := &MockWinPowerClient{
+		CollectDeviceDataFunc: func(ctx context.Context) ([]winpower.ParsedDeviceData, error) {
+			return []winpower.ParsedDeviceData{
+				{DeviceID: "device1", CollectedAt: time.Now()},
+				{DeviceID: "device2", CollectedAt: time.Now()},
+			}, nil
+		},
+	}
+
+	service, err := NewCollectorService(mockWinPower, &MockEnergyCalculator{}, log.NewTestLogger())
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	recorder := timing.NewRecorder()
+	if _, err := service.CollectDeviceData(timing.WithRecorder(context.Background(), recorder)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	stages := recorder.Stages()
+	if len(stages) != 1 || stages[0].Name != timing.StageEnergy || stages[0].Calls != 2 {
+		t.Errorf("Expected energy stage recorded once per device, got %+v", stages)
+	}
+}
+
+// batchEnergyCalculator is a MockEnergyCalculator that stages its results
+// in a batch committed by the collector
+type batchEnergyCalculator struct {
+	MockEnergyCalculator
+	commitErr error
+	staged    []string
+	committed []string
+}
+
+type batchKey struct{}
+
+func (b *batchEnergyCalculator) BeginBatch(ctx context.Context) (context.Context, func() error) {
+	ctx = context.WithValue(ctx, batchKey{}, true)
+	return ctx, func() error {
+		if b.commitErr != nil {
+			return b.commitErr
+		}
+		b.committed = append(b.committed, b.staged...)
+		return nil
+	}
+}
+
+func (b *batchEnergyCalculator) CalculateContext(ctx context.Context, deviceID string, power float64) (float64, error) {
+	if ctx.Value(batchKey{}) == nil {
+		return 0, errors.New("calculated outside the batch")
+	}
+	b.staged = append(b.staged, deviceID)
+	return power, nil
+}
+
+func TestCollectorService_CollectDeviceData_EnergyBatch(t *testing.T) {
+	mockWinPower := &MockWinPowerClient{
+		CollectDeviceDataFunc: func(ctx context.Context) ([]winpower.ParsedDeviceData, error) {
+			return []winpower.ParsedDeviceData{
+				{DeviceID: "device1", CollectedAt: time.Now(), Realtime: winpower.RealtimeData{LoadTotalWatt: 100}},
+				{DeviceID: "device2", CollectedAt: time.Now(), Realtime: winpower.RealtimeData{LoadTotalWatt: 200}},
+			}, nil
+		},
+	}
+
+	t.Run("commit", func(t *testing.T) {
+		calc := &batchEnergyCalculator{}
+		service, err := NewCollectorService(mockWinPower, calc, log.NewTestLogger())
+		if err != nil {
+			t.Fatalf("Failed to create service: %v", err)
+		}
+
+		recorder := timing.NewRecorder()
+		result, err := service.CollectDeviceData(timing.WithRecorder(context.Background(), recorder))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if len(calc.committed) != 2 {
+			t.Errorf("Expected both devices committed, got %v", calc.committed)
+		}
+		for id, info := range result.Devices {
+			if !info.EnergyCalculated {
+				t.Errorf("Expected energy calculated for %s, got error %q", id, info.ErrorMsg)
+			}
+		}
+
+		stages := recorder.Stages()
+		if len(stages) != 1 || stages[0].Name != timing.StageStorageWrite || stages[0].Calls != 1 {
+			t.Errorf("Expected one storage write stage for the commit, got %+v", stages)
+		}
+	})
+
+	t.Run("commit failure", func(t *testing.T) {
+		calc := &batchEnergyCalculator{commitErr: errors.New("disk full")}
+		service, err := NewCollectorService(mockWinPower, calc, log.NewTestLogger())
+		if err != nil {
+			t.Fatalf("Failed to create service: %v", err)
+		}
+
+		result, err := service.CollectDeviceData(context.Background())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		for id, info := range result.Devices {
+			if info.EnergyCalculated || info.EnergyValue != 0 {
+				t.Errorf("Expected energy of %s to be reported as not stored, got %+v", id, info)
+			}
+			if info.ErrorType != DeviceErrorEnergy || !strings.Contains(info.ErrorMsg, "disk full") {
+				t.Errorf("Expected energy commit error for %s, got %q (%s)", id, info.ErrorMsg, info.ErrorType)
+			}
+		}
+	})
+}
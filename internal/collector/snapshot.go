@@ -0,0 +1,88 @@
+package collector
+
+import (
+	"sort"
+	"time"
+)
+
+// Snapshot is an immutable view of every known device, published at the end
+// of each successful collection. All values of a snapshot come from the same
+// collection, so consumers reading several values together, e.g. power and
+// energy, get a consistent view instead of reading live gauges that a
+// concurrent collection may update mid-read.
+//
+// A snapshot and the data it references must not be modified.
+type Snapshot struct {
+	// CollectionTime is the time of the collection that published the snapshot
+	CollectionTime time.Time `json:"collection_time"`
+
+	// Devices are the known devices ordered by device ID
+	Devices []DeviceSnapshot `json:"devices"`
+}
+
+// DeviceSnapshot is the cached state of a device together with the values
+// exported for it as metrics
+type DeviceSnapshot struct {
+	DeviceState
+
+	// Metrics holds the values exported for the device. Like the metrics,
+	// it keeps the previous values of a duplicate sample and the previous
+	// energy when the energy calculation failed. Nil until the device has
+	// been collected once.
+	Metrics *DeviceCollectionInfo `json:"metrics,omitempty"`
+}
+
+// Device returns the snapshot of a device and whether it is known
+func (s *Snapshot) Device(deviceID string) (DeviceSnapshot, bool) {
+	i := sort.Search(len(s.Devices), func(i int) bool {
+		return s.Devices[i].Data.DeviceID >= deviceID
+	})
+	if i < len(s.Devices) && s.Devices[i].Data.DeviceID == deviceID {
+		return s.Devices[i], true
+	}
+	return DeviceSnapshot{}, false
+}
+
+// newSnapshot builds the snapshot of a collection from the device states
+// recorded by it and its result, carrying values over from the previous
+// snapshot where the metrics keep their previous values
+func newSnapshot(result *CollectionResult, states []DeviceState, prev *Snapshot) *Snapshot {
+	snapshot := &Snapshot{
+		CollectionTime: result.CollectionTime,
+		Devices:        make([]DeviceSnapshot, 0, len(states)),
+	}
+
+	for _, state := range states {
+		var previous *DeviceCollectionInfo
+		if prev != nil {
+			if device, ok := prev.Device(state.Data.DeviceID); ok {
+				previous = device.Metrics
+			}
+		}
+
+		snapshot.Devices = append(snapshot.Devices, DeviceSnapshot{
+			DeviceState: state,
+			Metrics:     snapshotMetrics(result.Devices[state.Data.DeviceID], previous),
+		})
+	}
+
+	return snapshot
+}
+
+// snapshotMetrics returns a copy of the device info of this collection,
+// falling back to the previous values the metrics keep
+func snapshotMetrics(info, previous *DeviceCollectionInfo) *DeviceCollectionInfo {
+	if info == nil || (info.Duplicate && previous != nil) {
+		return previous
+	}
+
+	metrics := *info
+	metrics.MissingFields = append([]string(nil), info.MissingFields...)
+	metrics.MissingOptionalFields = append([]string(nil), info.MissingOptionalFields...)
+	metrics.InvalidFields = append([]string(nil), info.InvalidFields...)
+	if !metrics.EnergyCalculated && previous != nil && previous.EnergyCalculated {
+		metrics.EnergyCalculated = true
+		metrics.EnergyValue = previous.EnergyValue
+	}
+	return &metrics
+}
@@ -0,0 +1,114 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/winpower"
+)
+
+func TestCollectorService_Snapshot(t *testing.T) {
+	dataTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	power := 500.0
+	devices := []string{"device2", "device1"}
+	mockWinPower := &MockWinPowerClient{
+		CollectDeviceDataFunc: func(ctx context.Context) ([]winpower.ParsedDeviceData, error) {
+			var data []winpower.ParsedDeviceData
+			for _, id := range devices {
+				data = append(data, winpower.ParsedDeviceData{
+					DeviceID: id,
+					Realtime: winpower.RealtimeData{LoadTotalWatt: power, DataTime: dataTime},
+				})
+			}
+			return data, nil
+		},
+	}
+	energy := 100.0
+	var energyErr error
+	mockEnergy := &MockEnergyCalculator{
+		CalculateFunc: func(deviceID string, power float64) (float64, error) {
+			return energy, energyErr
+		},
+	}
+
+	service, err := NewCollectorService(mockWinPower, mockEnergy, log.NewTestLogger())
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	if service.Snapshot() != nil {
+		t.Fatal("Expected no snapshot before the first collection")
+	}
+
+	first, _ := service.CollectDeviceData(context.Background())
+	snapshot := service.Snapshot()
+	if snapshot == nil || !snapshot.CollectionTime.Equal(first.CollectionTime) {
+		t.Fatalf("Expected snapshot of the first collection, got %+v", snapshot)
+	}
+	if len(snapshot.Devices) != 2 || snapshot.Devices[0].Data.DeviceID != "device1" {
+		t.Fatalf("Expected 2 devices ordered by ID, got %+v", snapshot.Devices)
+	}
+	device, ok := snapshot.Device("device1")
+	if !ok || device.Metrics.LoadTotalWatt != 500 || device.Metrics.EnergyValue != 100 {
+		t.Errorf("Unexpected device snapshot: %+v", device)
+	}
+
+	// The published snapshot is not affected by later collections
+	dataTime = dataTime.Add(time.Minute)
+	power, energy = 600, 110
+	_, _ = service.CollectDeviceData(context.Background())
+	if device.Metrics.LoadTotalWatt != 500 || device.Metrics.EnergyValue != 100 {
+		t.Errorf("Expected published snapshot to stay unchanged, got %+v", device.Metrics)
+	}
+	if device, _ := service.Snapshot().Device("device1"); device.Metrics.LoadTotalWatt != 600 || device.Metrics.EnergyValue != 110 {
+		t.Errorf("Expected values of the second collection, got %+v", device.Metrics)
+	}
+
+	// Like the metrics, a failed energy calculation keeps the previous
+	// energy and a duplicate sample keeps all previous values
+	dataTime = dataTime.Add(time.Minute)
+	power, energyErr = 700, errors.New("calculation failed")
+	_, _ = service.CollectDeviceData(context.Background())
+	device, _ = service.Snapshot().Device("device1")
+	if device.Metrics.LoadTotalWatt != 700 || !device.Metrics.EnergyCalculated || device.Metrics.EnergyValue != 110 {
+		t.Errorf("Expected previous energy with the new power, got %+v", device.Metrics)
+	}
+
+	power = 800
+	_, _ = service.CollectDeviceData(context.Background())
+	device, _ = service.Snapshot().Device("device1")
+	if device.Metrics.LoadTotalWatt != 700 || device.Metrics.Duplicate {
+		t.Errorf("Expected duplicate sample to keep the previous values, got %+v", device.Metrics)
+	}
+
+	// Devices missing from a collection keep their last values
+	devices = []string{"device2"}
+	dataTime = dataTime.Add(time.Minute)
+	_, _ = service.CollectDeviceData(context.Background())
+	device, ok = service.Snapshot().Device("device1")
+	if !ok || device.Present || device.Metrics.LoadTotalWatt != 700 {
+		t.Errorf("Expected absent device with its last values, got %+v", device)
+	}
+	if _, ok := service.Snapshot().Device("unknown"); ok {
+		t.Error("Expected unknown device to be missing from the snapshot")
+	}
+}
+
+func TestCollectorService_SnapshotIgnoresOlderCollections(t *testing.T) {
+	service, err := NewCollectorService(&MockWinPowerClient{}, &MockEnergyCalculator{}, log.NewTestLogger())
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	now := time.Now()
+	newer := &CollectionResult{CollectionTime: now, Devices: map[string]*DeviceCollectionInfo{}}
+	older := &CollectionResult{CollectionTime: now.Add(-time.Second), Devices: map[string]*DeviceCollectionInfo{}}
+
+	service.publishSnapshot(newer, nil)
+	service.publishSnapshot(older, nil)
+	if got := service.Snapshot().CollectionTime; !got.Equal(now) {
+		t.Errorf("Expected the newer snapshot to be kept, got %v", got)
+	}
+}
@@ -0,0 +1,158 @@
+package collector
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+// EnergyStallObserver is notified after each collection of the number of
+// active devices whose energy has stopped advancing
+type EnergyStallObserver interface {
+	SetEnergyStalled(devices int)
+}
+
+// energyResolutionWh is the step the energy calculator rounds the
+// cumulative energy to
+const energyResolutionWh = 0.01
+
+// energyProgress is the last energy value of a device, when the device was
+// last seen either advancing its energy or not expected to, and when it was
+// last observed
+type energyProgress struct {
+	energy float64
+	since  time.Time
+	seen   time.Time
+}
+
+// stallTracker detects devices that draw power but whose energy does not
+// advance, e.g. because a field mapping bug parses the power as zero for
+// the integration while collections keep succeeding.
+//
+// A device is stalled once its energy has not changed for the whole window
+// although every collection was expected to advance it: the integrated
+// power was at least the minimum integration power and, over the time since
+// the previous collection, adds at least the rounding step. Any other
+// reading, a changed energy value (including a reset) or a new device
+// restart the window. Devices whose energy was not calculated, e.g. after a
+// calculation error or for a repeated WinPower sample, keep their state.
+type stallTracker struct {
+	window   time.Duration
+	minPower float64
+
+	mu       sync.Mutex
+	progress map[string]*energyProgress
+	stalled  map[string]bool
+}
+
+// newStallTracker returns a tracker for the given window and minimum
+// integration power, nil when stall detection is disabled
+func newStallTracker(window time.Duration, minPower float64) *stallTracker {
+	if window <= 0 {
+		return nil
+	}
+	return &stallTracker{
+		window:   window,
+		minPower: minPower,
+		progress: make(map[string]*energyProgress),
+		stalled:  make(map[string]bool),
+	}
+}
+
+// expectsGain reports whether integrating power over elapsed should advance
+// the energy
+func (t *stallTracker) expectsGain(power float64, elapsed time.Duration) bool {
+	if power <= 0 || math.Abs(power) < t.minPower {
+		return false
+	}
+	return power*elapsed.Hours() >= energyResolutionWh
+}
+
+// observe updates the tracker from a successful collection, given the power
+// integrated for each device, and returns the number of stalled devices
+func (t *stallTracker) observe(result *CollectionResult, powers map[string]float64, logger log.Logger) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := result.CollectionTime
+	for deviceID := range t.progress {
+		if _, ok := result.Devices[deviceID]; !ok {
+			delete(t.progress, deviceID)
+			delete(t.stalled, deviceID)
+		}
+	}
+
+	for deviceID, info := range result.Devices {
+		if info.Duplicate || !info.EnergyCalculated {
+			continue
+		}
+
+		power := powers[deviceID]
+		progress, ok := t.progress[deviceID]
+		if !ok || !t.expectsGain(power, now.Sub(progress.seen)) || info.EnergyValue != progress.energy {
+			t.progress[deviceID] = &energyProgress{energy: info.EnergyValue, since: now, seen: now}
+			if t.stalled[deviceID] {
+				delete(t.stalled, deviceID)
+				logger.Info("Device energy is advancing again",
+					log.String("device_id", deviceID),
+					log.Float64("energy_wh", info.EnergyValue))
+			}
+			continue
+		}
+		progress.seen = now
+
+		if stalledFor := now.Sub(progress.since); stalledFor >= t.window && !t.stalled[deviceID] {
+			t.stalled[deviceID] = true
+			logger.Warn("Device draws power but its energy is not advancing",
+				log.String("device_id", deviceID),
+				log.Float64("power_watts", power),
+				log.Float64("energy_wh", info.EnergyValue),
+				log.Duration("stalled_for", stalledFor))
+		}
+	}
+	return len(t.stalled)
+}
+
+// devices returns the IDs of the stalled devices, sorted
+func (t *stallTracker) devices() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	devices := make([]string, 0, len(t.stalled))
+	for deviceID := range t.stalled {
+		devices = append(devices, deviceID)
+	}
+	slices.Sort(devices)
+	return devices
+}
+
+// SetEnergyStallObserver sets the observer notified of the number of stalled
+// devices after each collection
+func (cs *CollectorService) SetEnergyStallObserver(observer EnergyStallObserver) {
+	cs.stallObserver = observer
+}
+
+// StalledEnergyDevices returns the IDs of the devices that draw power but
+// whose energy has not advanced within the stall window, sorted. It is empty
+// when stall detection is disabled.
+func (cs *CollectorService) StalledEnergyDevices() []string {
+	if cs.stall == nil {
+		return []string{}
+	}
+	return cs.stall.devices()
+}
+
+// trackEnergyStalls updates the stall detection from a collection and the
+// power integrated for each device
+func (cs *CollectorService) trackEnergyStalls(result *CollectionResult, powers map[string]float64) {
+	if cs.stall == nil {
+		return
+	}
+	stalled := cs.stall.observe(result, powers, cs.logger)
+	if cs.stallObserver != nil {
+		cs.stallObserver.SetEnergyStalled(stalled)
+	}
+}
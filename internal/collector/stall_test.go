@@ -0,0 +1,170 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+type recordingStallObserver struct {
+	stalled []int
+}
+
+func (o *recordingStallObserver) SetEnergyStalled(devices int) {
+	o.stalled = append(o.stalled, devices)
+}
+
+func stallResult(at time.Time, devices map[string]*DeviceCollectionInfo) *CollectionResult {
+	return &CollectionResult{Success: true, CollectionTime: at, Devices: devices}
+}
+
+// stallPowers returns the instantaneous power of each device, as integrated
+// by default
+func stallPowers(result *CollectionResult) map[string]float64 {
+	powers := make(map[string]float64, len(result.Devices))
+	for deviceID, info := range result.Devices {
+		powers[deviceID] = info.LoadTotalWatt
+	}
+	return powers
+}
+
+func TestStallTracker_Observe(t *testing.T) {
+	logger := log.NewTestLogger()
+	tracker := newStallTracker(10*time.Minute, 0)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	active := func(energy float64) *DeviceCollectionInfo {
+		return &DeviceCollectionInfo{LoadTotalWatt: 500, EnergyValue: energy, EnergyCalculated: true}
+	}
+	idle := &DeviceCollectionInfo{LoadTotalWatt: 0, EnergyValue: 7, EnergyCalculated: true}
+
+	steps := []struct {
+		name    string
+		offset  time.Duration
+		devices map[string]*DeviceCollectionInfo
+		want    int
+	}{
+		{"first sample", 0, map[string]*DeviceCollectionInfo{"ups-1": active(100), "ups-2": idle}, 0},
+		{"within window", 5 * time.Minute, map[string]*DeviceCollectionInfo{"ups-1": active(100), "ups-2": idle}, 0},
+		// A failed calculation keeps the state instead of restarting the window
+		{"not calculated", 8 * time.Minute, map[string]*DeviceCollectionInfo{"ups-1": {LoadTotalWatt: 500}, "ups-2": idle}, 0},
+		{"window elapsed", 10 * time.Minute, map[string]*DeviceCollectionInfo{"ups-1": active(100), "ups-2": idle}, 1},
+		{"still stalled", 15 * time.Minute, map[string]*DeviceCollectionInfo{"ups-1": active(100), "ups-2": idle}, 1},
+		{"advancing again", 16 * time.Minute, map[string]*DeviceCollectionInfo{"ups-1": active(101), "ups-2": idle}, 0},
+		{"window restarted", 25 * time.Minute, map[string]*DeviceCollectionInfo{"ups-1": active(101), "ups-2": idle}, 0},
+		{"stalled again", 26 * time.Minute, map[string]*DeviceCollectionInfo{"ups-1": active(101)}, 1},
+		{"device gone", 27 * time.Minute, map[string]*DeviceCollectionInfo{"ups-2": idle}, 0},
+	}
+
+	for _, step := range steps {
+		result := stallResult(start.Add(step.offset), step.devices)
+		got := tracker.observe(result, stallPowers(result), logger)
+		if got != step.want {
+			t.Errorf("%s: expected %d stalled devices, got %d", step.name, step.want, got)
+		}
+	}
+}
+
+func TestStallTracker_IdleDeviceNeverStalls(t *testing.T) {
+	tracker := newStallTracker(time.Minute, 0)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := range 5 {
+		result := stallResult(start.Add(time.Duration(i)*time.Minute), map[string]*DeviceCollectionInfo{
+			"ups-1": {LoadTotalWatt: 0, EnergyValue: 42, EnergyCalculated: true},
+			"ups-2": {LoadTotalWatt: 300, EnergyValue: 42, EnergyCalculated: true, Duplicate: true},
+		})
+		if got := tracker.observe(result, stallPowers(result), log.NewTestLogger()); got != 0 {
+			t.Fatalf("cycle %d: expected no stalled devices, got %d", i, got)
+		}
+	}
+}
+
+func TestStallTracker_PowerBelowMinimumNeverStalls(t *testing.T) {
+	// The calculator integrates readings below min_power_watts as zero
+	tracker := newStallTracker(time.Minute, 10)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := range 5 {
+		result := stallResult(start.Add(time.Duration(i)*time.Minute), map[string]*DeviceCollectionInfo{
+			"ups-1": {LoadTotalWatt: 5, EnergyValue: 42, EnergyCalculated: true},
+		})
+		if got := tracker.observe(result, stallPowers(result), log.NewTestLogger()); got != 0 {
+			t.Fatalf("cycle %d: expected no stalled devices, got %d", i, got)
+		}
+	}
+}
+
+func TestStallTracker_GainBelowRoundingStepNeverStalls(t *testing.T) {
+	// 0.3 W over 1 s adds about 0.00008 Wh per cycle, which rounds away
+	tracker := newStallTracker(time.Minute, 0)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := range 120 {
+		result := stallResult(start.Add(time.Duration(i)*time.Second), map[string]*DeviceCollectionInfo{
+			"ups-1": {LoadTotalWatt: 0.3, EnergyValue: 42, EnergyCalculated: true},
+		})
+		if got := tracker.observe(result, stallPowers(result), log.NewTestLogger()); got != 0 {
+			t.Fatalf("cycle %d: expected no stalled devices, got %d", i, got)
+		}
+	}
+}
+
+func TestStallTracker_UsesIntegratedPower(t *testing.T) {
+	tracker := newStallTracker(time.Minute, 0)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// The instantaneous power is above zero but the integrated average
+	// power is not, so the unchanged energy is expected
+	for i := range 5 {
+		result := stallResult(start.Add(time.Duration(i)*time.Minute), map[string]*DeviceCollectionInfo{
+			"ups-1": {LoadTotalWatt: 500, EnergyValue: 42, EnergyCalculated: true},
+		})
+		if got := tracker.observe(result, map[string]float64{"ups-1": 0}, log.NewTestLogger()); got != 0 {
+			t.Fatalf("cycle %d: expected no stalled devices, got %d", i, got)
+		}
+	}
+}
+
+func TestCollectorService_StalledEnergyDevices(t *testing.T) {
+	service := &CollectorService{
+		logger: log.NewTestLogger(),
+		stall:  newStallTracker(time.Minute, 0),
+	}
+	observer := &recordingStallObserver{}
+	service.SetEnergyStallObserver(observer)
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	devices := map[string]*DeviceCollectionInfo{
+		"ups-b": {LoadTotalWatt: 200, EnergyValue: 5, EnergyCalculated: true},
+		"ups-a": {LoadTotalWatt: 100, EnergyValue: 3, EnergyCalculated: true},
+	}
+	first := stallResult(start, devices)
+	service.trackEnergyStalls(first, stallPowers(first))
+	second := stallResult(start.Add(time.Minute), devices)
+	service.trackEnergyStalls(second, stallPowers(second))
+
+	stalled := service.StalledEnergyDevices()
+	if len(stalled) != 2 || stalled[0] != "ups-a" || stalled[1] != "ups-b" {
+		t.Errorf("Expected sorted stalled devices [ups-a ups-b], got %v", stalled)
+	}
+	if len(observer.stalled) != 2 || observer.stalled[0] != 0 || observer.stalled[1] != 2 {
+		t.Errorf("Expected observer to receive [0 2], got %v", observer.stalled)
+	}
+}
+
+func TestCollectorService_StalledEnergyDevices_Disabled(t *testing.T) {
+	if tracker := newStallTracker(0, 0); tracker != nil {
+		t.Fatal("Expected a zero window to disable stall detection")
+	}
+
+	service, err := NewCollectorServiceWithConfig(&MockWinPowerClient{}, &MockEnergyCalculator{}, log.NewTestLogger(),
+		&Config{EnergyStallWindow: 0})
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	if stalled := service.StalledEnergyDevices(); len(stalled) != 0 {
+		t.Errorf("Expected no stalled devices when disabled, got %v", stalled)
+	}
+}
@@ -0,0 +1,170 @@
+package collector
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/winpower"
+)
+
+// DeviceState is the last-known data of a device together with its
+// freshness metadata
+type DeviceState struct {
+	// Data is the most recent parsed data reported for the device
+	Data winpower.ParsedDeviceData `json:"data"`
+
+	// LastUpdated is when Data was last refreshed by a successful collection
+	LastUpdated time.Time `json:"last_updated"`
+
+	// FirstSeen is when the device was first seen in its current continuous
+	// run of successful collections
+	FirstSeen time.Time `json:"first_seen"`
+
+	// Present reports whether the device was included in the latest
+	// successful collection
+	Present bool `json:"present"`
+
+	// RefreshPeriod is the interval between the last two distinct WinPower
+	// data times of the device, zero until observed
+	RefreshPeriod time.Duration `json:"refresh_period,omitempty"`
+}
+
+// Age returns how long ago the state was last updated
+func (s DeviceState) Age(now time.Time) time.Duration {
+	return now.Sub(s.LastUpdated)
+}
+
+// DeviceStore caches the latest data of each device reported by WinPower.
+// It is updated by the collector after each successful collection and can
+// be read concurrently, e.g. by the debug endpoints. The metrics module
+// keeps its own per-device series and does not read the store.
+// Devices missing from a collection keep their last data until expired
+// (see Config.DeviceMaxAge).
+type DeviceStore struct {
+	mu      sync.RWMutex
+	devices map[string]*DeviceState
+}
+
+// NewDeviceStore creates an empty device store
+func NewDeviceStore() *DeviceStore {
+	return &DeviceStore{
+		devices: make(map[string]*DeviceState),
+	}
+}
+
+// Update records the devices of a successful collection at the given time.
+// Devices not in the list are marked as not present, and their first-seen
+// time resets when they reappear. It returns the first-seen times of the
+// given devices.
+func (s *DeviceStore) Update(devices []winpower.ParsedDeviceData, now time.Time) map[string]time.Time {
+	firstSeen, _ := s.update(devices, now)
+	return firstSeen
+}
+
+// update is Update that additionally returns the states of all known devices
+// right after the update, as Snapshot would
+func (s *DeviceStore) update(devices []winpower.ParsedDeviceData, now time.Time) (map[string]time.Time, []DeviceState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	firstSeen := make(map[string]time.Time, len(devices))
+	for _, device := range devices {
+		state, ok := s.devices[device.DeviceID]
+		if !ok {
+			state = &DeviceState{}
+			s.devices[device.DeviceID] = state
+		}
+		if !state.Present {
+			state.FirstSeen = now
+		}
+		if prev, cur := state.Data.Realtime.DataTime, device.Realtime.DataTime; !prev.IsZero() && cur.After(prev) {
+			state.RefreshPeriod = cur.Sub(prev)
+		}
+		state.Data = device
+		state.LastUpdated = now
+		state.Present = true
+		firstSeen[device.DeviceID] = state.FirstSeen
+	}
+
+	for id, state := range s.devices {
+		if _, ok := firstSeen[id]; !ok {
+			state.Present = false
+		}
+	}
+
+	return firstSeen, s.snapshotLocked()
+}
+
+// IsDuplicate reports whether the device data carries the same WinPower data
+// time as the stored data of the device, i.e. WinPower has not refreshed it
+// since the previous collection. Data without a data time is never a
+// duplicate.
+func (s *DeviceStore) IsDuplicate(device winpower.ParsedDeviceData) bool {
+	if device.Realtime.DataTime.IsZero() {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state, ok := s.devices[device.DeviceID]
+	return ok && state.Data.Realtime.DataTime.Equal(device.Realtime.DataTime)
+}
+
+// Get returns the state of a device and whether it is known
+func (s *DeviceStore) Get(deviceID string) (DeviceState, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state, ok := s.devices[deviceID]
+	if !ok {
+		return DeviceState{}, false
+	}
+	return *state, true
+}
+
+// Snapshot returns the states of all known devices ordered by device ID.
+// The returned data shares its slices and maps with the store and must not
+// be modified.
+func (s *DeviceStore) Snapshot() []DeviceState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.snapshotLocked()
+}
+
+// snapshotLocked implements Snapshot. The caller must hold s.mu.
+func (s *DeviceStore) snapshotLocked() []DeviceState {
+	states := make([]DeviceState, 0, len(s.devices))
+	for _, state := range s.devices {
+		states = append(states, *state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Data.DeviceID < states[j].Data.DeviceID
+	})
+	return states
+}
+
+// Len returns the number of known devices
+func (s *DeviceStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.devices)
+}
+
+// Expire removes devices last updated before the cutoff and returns their
+// IDs in sorted order
+func (s *DeviceStore) Expire(cutoff time.Time) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []string
+	for id, state := range s.devices {
+		if state.LastUpdated.Before(cutoff) {
+			delete(s.devices, id)
+			expired = append(expired, id)
+		}
+	}
+	sort.Strings(expired)
+	return expired
+}
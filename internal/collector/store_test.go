@@ -0,0 +1,205 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/winpower"
+)
+
+func TestDeviceStore_Update(t *testing.T) {
+	store := NewDeviceStore()
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	firstSeen := store.Update([]winpower.ParsedDeviceData{
+		{DeviceID: "b", Realtime: winpower.RealtimeData{LoadTotalWatt: 100}},
+		{DeviceID: "a"},
+	}, t0)
+	if !firstSeen["a"].Equal(t0) || !firstSeen["b"].Equal(t0) {
+		t.Fatalf("Expected first seen %v, got %v", t0, firstSeen)
+	}
+
+	// Device b keeps reporting, device a disappears
+	t1 := t0.Add(time.Minute)
+	firstSeen = store.Update([]winpower.ParsedDeviceData{
+		{DeviceID: "b", Realtime: winpower.RealtimeData{LoadTotalWatt: 200}},
+	}, t1)
+	if !firstSeen["b"].Equal(t0) {
+		t.Errorf("Expected first seen of b to be kept, got %v", firstSeen["b"])
+	}
+
+	b, ok := store.Get("b")
+	if !ok {
+		t.Fatal("Expected device b to be known")
+	}
+	if b.Data.Realtime.LoadTotalWatt != 200 {
+		t.Errorf("Expected latest data, got load %v", b.Data.Realtime.LoadTotalWatt)
+	}
+	if !b.LastUpdated.Equal(t1) || !b.Present {
+		t.Errorf("Expected b updated at %v and present, got %+v", t1, b)
+	}
+	if age := b.Age(t1.Add(30 * time.Second)); age != 30*time.Second {
+		t.Errorf("Expected age 30s, got %v", age)
+	}
+
+	a, ok := store.Get("a")
+	if !ok {
+		t.Fatal("Expected device a to keep its last data")
+	}
+	if a.Present || !a.LastUpdated.Equal(t0) {
+		t.Errorf("Expected a not present and last updated at %v, got %+v", t0, a)
+	}
+
+	// Device a reappears with a new first-seen time
+	t2 := t1.Add(time.Minute)
+	firstSeen = store.Update([]winpower.ParsedDeviceData{{DeviceID: "a"}, {DeviceID: "b"}}, t2)
+	if !firstSeen["a"].Equal(t2) {
+		t.Errorf("Expected first seen of a to reset to %v, got %v", t2, firstSeen["a"])
+	}
+
+	if _, ok := store.Get("missing"); ok {
+		t.Error("Expected unknown device not to be found")
+	}
+}
+
+func TestDeviceStore_IsDuplicate(t *testing.T) {
+	store := NewDeviceStore()
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sample := func(dataTime time.Time) winpower.ParsedDeviceData {
+		return winpower.ParsedDeviceData{DeviceID: "a", Realtime: winpower.RealtimeData{DataTime: dataTime}}
+	}
+
+	if store.IsDuplicate(sample(t0)) {
+		t.Error("Expected unknown device not to be a duplicate")
+	}
+	store.Update([]winpower.ParsedDeviceData{sample(t0)}, t0)
+
+	if !store.IsDuplicate(sample(t0)) {
+		t.Error("Expected same data time to be a duplicate")
+	}
+	if store.IsDuplicate(sample(time.Time{})) {
+		t.Error("Expected data without a data time never to be a duplicate")
+	}
+
+	t1 := t0.Add(30 * time.Second)
+	if store.IsDuplicate(sample(t1)) {
+		t.Error("Expected new data time not to be a duplicate")
+	}
+	store.Update([]winpower.ParsedDeviceData{sample(t1)}, t1)
+
+	state, _ := store.Get("a")
+	if state.RefreshPeriod != 30*time.Second {
+		t.Errorf("Expected refresh period 30s, got %v", state.RefreshPeriod)
+	}
+
+	// A repeated data time keeps the observed refresh period
+	store.Update([]winpower.ParsedDeviceData{sample(t1)}, t1.Add(10*time.Second))
+	state, _ = store.Get("a")
+	if state.RefreshPeriod != 30*time.Second {
+		t.Errorf("Expected refresh period to be kept, got %v", state.RefreshPeriod)
+	}
+}
+
+func TestDeviceStore_SnapshotAndExpire(t *testing.T) {
+	store := NewDeviceStore()
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store.Update([]winpower.ParsedDeviceData{{DeviceID: "c"}, {DeviceID: "a"}}, t0)
+	store.Update([]winpower.ParsedDeviceData{{DeviceID: "b"}}, t0.Add(time.Minute))
+
+	snapshot := store.Snapshot()
+	if len(snapshot) != 3 {
+		t.Fatalf("Expected 3 devices, got %d", len(snapshot))
+	}
+	for i, id := range []string{"a", "b", "c"} {
+		if snapshot[i].Data.DeviceID != id {
+			t.Errorf("Expected device %q at index %d, got %q", id, i, snapshot[i].Data.DeviceID)
+		}
+	}
+
+	expired := store.Expire(t0.Add(30 * time.Second))
+	if len(expired) != 2 || expired[0] != "a" || expired[1] != "c" {
+		t.Errorf("Expected [a c] to expire, got %v", expired)
+	}
+	if store.Len() != 1 {
+		t.Errorf("Expected 1 device left, got %d", store.Len())
+	}
+	if _, ok := store.Get("b"); !ok {
+		t.Error("Expected fresh device b to be kept")
+	}
+}
+
+func TestCollectorService_DeviceStore(t *testing.T) {
+	fail := false
+	mockWinPower := &MockWinPowerClient{
+		CollectDeviceDataFunc: func(ctx context.Context) ([]winpower.ParsedDeviceData, error) {
+			if fail {
+				return nil, ErrWinPowerCollection
+			}
+			return []winpower.ParsedDeviceData{{DeviceID: "device1", CollectedAt: time.Now()}}, nil
+		},
+	}
+	mockEnergy := &MockEnergyCalculator{
+		CalculateFunc: func(deviceID string, power float64) (float64, error) {
+			return 0, nil
+		},
+	}
+
+	service, err := NewCollectorService(mockWinPower, mockEnergy, log.NewTestLogger())
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	result, _ := service.CollectDeviceData(context.Background())
+	state, ok := service.DeviceStore().Get("device1")
+	if !ok {
+		t.Fatal("Expected device1 in the store after collection")
+	}
+	if !state.LastUpdated.Equal(result.CollectionTime) {
+		t.Errorf("Expected last updated %v, got %v", result.CollectionTime, state.LastUpdated)
+	}
+
+	// A failed collection leaves the cached data untouched
+	fail = true
+	_, _ = service.CollectDeviceData(context.Background())
+	after, ok := service.DeviceStore().Get("device1")
+	if !ok || !after.Present || !after.LastUpdated.Equal(state.LastUpdated) {
+		t.Errorf("Expected cached state to be kept after a failed collection, got %+v", after)
+	}
+}
+
+func TestCollectorService_ExpiresUnreportedDevices(t *testing.T) {
+	mockWinPower := &MockWinPowerClient{
+		CollectDeviceDataFunc: func(ctx context.Context) ([]winpower.ParsedDeviceData, error) {
+			return []winpower.ParsedDeviceData{{DeviceID: "device1", CollectedAt: time.Now()}}, nil
+		},
+	}
+	mockEnergy := &MockEnergyCalculator{
+		CalculateFunc: func(deviceID string, power float64) (float64, error) {
+			return 0, nil
+		},
+	}
+
+	service, err := NewCollectorServiceWithConfig(mockWinPower, mockEnergy, log.NewTestLogger(),
+		&Config{DeviceMaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	service.DeviceStore().Update([]winpower.ParsedDeviceData{{DeviceID: "gone"}, {DeviceID: "recent"}}, time.Now().Add(-2*time.Hour))
+	service.DeviceStore().Update([]winpower.ParsedDeviceData{{DeviceID: "recent"}}, time.Now().Add(-time.Minute))
+
+	if _, err := service.CollectDeviceData(context.Background()); err != nil {
+		t.Fatalf("Collection failed: %v", err)
+	}
+
+	if _, ok := service.DeviceStore().Get("gone"); ok {
+		t.Error("Expected device not reported within device_max_age to be expired")
+	}
+	for _, id := range []string{"recent", "device1"} {
+		if _, ok := service.DeviceStore().Get(id); !ok {
+			t.Errorf("Expected device %q to be kept", id)
+		}
+	}
+}
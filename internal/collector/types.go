@@ -13,9 +13,22 @@ type CollectionResult struct {
 	Duration       time.Duration                    `json:"duration"`
 	ErrorMessage   string                           `json:"error_message,omitempty"`
 
+	// ErrorKind classifies a failed collection's network or response error
+	// (e.g. "dns", "tls", "http_status", "error_response", see
+	// winpower.ClassifyError); empty otherwise
+	ErrorKind string `json:"error_kind,omitempty"`
+
+	// Maintenance is set when the collection was skipped because WinPower
+	// reported maintenance mode; no energy was integrated
+	Maintenance bool `json:"maintenance,omitempty"`
+
 	// Token information
 	TokenValid     bool      `json:"token_valid"`
 	TokenExpiresAt time.Time `json:"token_expires_at"`
+
+	// Cumulative token refresh counts, zero when the client does not count them
+	TokenRefreshes       int64 `json:"token_refreshes"`
+	TokenRefreshFailures int64 `json:"token_refresh_failures"`
 }
 
 // DeviceCollectionInfo contains comprehensive information about a collected device
@@ -27,6 +40,7 @@ type DeviceCollectionInfo struct {
 	DeviceModel    string    `json:"device_model"`
 	Connected      bool      `json:"connected"`
 	LastUpdateTime time.Time `json:"last_update_time"`
+	FirstSeenTime  time.Time `json:"first_seen_time"` // Start of the device's current continuous reporting run
 
 	// Electrical parameters
 	InputVolt1        float64 `json:"input_volt_1"`
@@ -57,10 +71,42 @@ type DeviceCollectionInfo struct {
 	TestStatus     string  `json:"test_status"`
 	FaultCode      string  `json:"fault_code"`
 
+	// ActiveAlarms lists the type of each active WinPower alarm of the device
+	ActiveAlarms []string `json:"active_alarms,omitempty"`
+
 	// Energy calculation result
 	EnergyCalculated bool    `json:"energy_calculated"`
 	EnergyValue      float64 `json:"energy_value"` // Cumulative energy in Wh
 
+	// EnergyKey is the key the device's energy is stored under when it is
+	// not the device ID, i.e. when the device is tracked by a stable identity
+	EnergyKey string `json:"energy_key,omitempty"`
+
+	// EnergyResetEpoch counts the deliberate resets of the device's energy,
+	// zero when the calculator does not track resets
+	EnergyResetEpoch uint64 `json:"energy_reset_epoch,omitempty"`
+
+	// ReportedEnergyWh is the cumulative energy reported by WinPower itself,
+	// nil when the device does not report it
+	ReportedEnergyWh *float64 `json:"reported_energy_wh,omitempty"`
+
+	// Parse information
+	MissingFields         []string `json:"missing_fields,omitempty"`          // Mapped fields absent from the WinPower response
+	MissingOptionalFields []string `json:"missing_optional_fields,omitempty"` // Mapped optional fields absent from the WinPower response
+	InvalidFields         []string `json:"invalid_fields,omitempty"`          // Measurements that were NaN or infinite, left at zero
+
+	// Duplicate reports that WinPower returned the same data time as in the
+	// previous collection; energy was not calculated and the device metrics
+	// keep their previous values
+	Duplicate bool `json:"duplicate,omitempty"`
+
 	// Error information
-	ErrorMsg string `json:"error_msg,omitempty"`
+	ErrorMsg  string `json:"error_msg,omitempty"`
+	ErrorType string `json:"error_type,omitempty"` // Classification of ErrorMsg, e.g. DeviceErrorEnergy
 }
+
+// Per-device error types reported in DeviceCollectionInfo.ErrorType
+const (
+	// DeviceErrorEnergy indicates the energy calculation failed for the device
+	DeviceErrorEnergy = "energy_calculation"
+)
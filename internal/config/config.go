@@ -1,7 +1,16 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/collector"
+	"github.com/lay-g/winpower-g2-exporter/internal/energy"
+	"github.com/lay-g/winpower-g2-exporter/internal/metrics"
 	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/shutdown"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/signals"
 	"github.com/lay-g/winpower-g2-exporter/internal/scheduler"
 	"github.com/lay-g/winpower-g2-exporter/internal/server"
 	"github.com/lay-g/winpower-g2-exporter/internal/storage"
@@ -61,55 +70,295 @@ type Config struct {
 
 	// Logging 日志配置
 	Logging *log.Config `yaml:"logging" mapstructure:"logging"`
+
+	// Metrics 指标配置
+	Metrics *metrics.MetricsConfig `yaml:"metrics" mapstructure:"metrics"`
+
+	// Energy 电能计算配置
+	Energy *energy.Config `yaml:"energy" mapstructure:"energy"`
+
+	// Collector 采集器配置
+	Collector *collector.Config `yaml:"collector" mapstructure:"collector"`
+
+	// Signals 信号处理配置
+	Signals *signals.Config `yaml:"signals" mapstructure:"signals"`
+
+	// Shutdown 关闭配置
+	Shutdown *shutdown.Config `yaml:"shutdown" mapstructure:"shutdown"`
 }
 
 // Validate 验证完整配置
+// 按规则顺序返回第一个校验失败的错误；警告规则不影响校验结果
 func (c *Config) Validate() error {
-	// 验证各个模块的配置（跳过 nil 配置）
-	if c.Server != nil {
-		if err := c.Server.Validate(); err != nil {
-			return &ConfigError{
-				Message: "server validation failed",
-				Err:     err,
-			}
+	for _, rule := range c.validationRules() {
+		if rule.warning {
+			continue
+		}
+		if err := rule.check(); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	if c.WinPower != nil {
-		if err := c.WinPower.Validate(); err != nil {
-			return &ConfigError{
-				Message: "winpower validation failed",
-				Err:     err,
-			}
+// 校验结果的严重级别
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// RuleResult 单条校验规则的结果
+type RuleResult struct {
+	// Rule 规则名称，如 "winpower"、"energy.device_source"
+	Rule string `json:"rule"`
+
+	// Severity 严重级别: error、warning 或 info（通过）
+	Severity string `json:"severity"`
+
+	// Message 结果说明
+	Message string `json:"message"`
+
+	// Field 相关的配置字段路径，如 "winpower.base_url"
+	Field string `json:"field,omitempty"`
+}
+
+// CheckRules 执行全部校验规则并返回每条规则的结果
+// 与 Validate 不同，某条规则失败后仍继续执行其余规则；未配置的模块不产生结果；
+// 警告规则未通过时以 warning 级别列出
+func (c *Config) CheckRules() []RuleResult {
+	rules := c.validationRules()
+	results := make([]RuleResult, 0, len(rules))
+	for _, rule := range rules {
+		err := rule.check()
+		if err == nil {
+			results = append(results, RuleResult{
+				Rule:     rule.name,
+				Severity: SeverityInfo,
+				Message:  "ok",
+				Field:    rule.section,
+			})
+			continue
 		}
-	}
 
-	if c.Storage != nil {
-		if err := c.Storage.Validate(); err != nil {
-			return &ConfigError{
-				Message: "storage validation failed",
-				Err:     err,
-			}
+		severity := SeverityError
+		if rule.warning {
+			severity = SeverityWarning
 		}
+		results = append(results, RuleResult{
+			Rule:     rule.name,
+			Severity: severity,
+			Message:  ruleMessage(err),
+			Field:    ruleField(rule.section, err),
+		})
 	}
+	return results
+}
 
-	if c.Scheduler != nil {
-		if err := c.Scheduler.Validate(); err != nil {
-			return &ConfigError{
-				Message: "scheduler validation failed",
-				Err:     err,
-			}
+// Warnings 返回未通过的警告规则，用于在启动时提示不影响运行的配置风险
+func (c *Config) Warnings() []RuleResult {
+	var warnings []RuleResult
+	for _, result := range c.CheckRules() {
+		if result.Severity == SeverityWarning {
+			warnings = append(warnings, result)
 		}
 	}
+	return warnings
+}
+
+// validationRule 一条配置校验规则
+type validationRule struct {
+	name    string
+	section string
+	check   func() error
+
+	// warning 规则未通过时只产生警告，不使配置无效
+	warning bool
+}
+
+// validationRules 返回适用于当前配置的校验规则（跳过 nil 配置）
+func (c *Config) validationRules() []validationRule {
+	var rules []validationRule
+
+	// section 校验模块配置，失败时包装为 ConfigError
+	section := func(name string, validator ConfigValidator) {
+		rules = append(rules, validationRule{
+			name:    name,
+			section: name,
+			check: func() error {
+				if err := validator.Validate(); err != nil {
+					return &ConfigError{
+						Message: name + " validation failed",
+						Err:     err,
+					}
+				}
+				return nil
+			},
+		})
+	}
 
+	if c.Server != nil {
+		section("server", c.Server)
+	}
+	if c.WinPower != nil {
+		section("winpower", c.WinPower)
+	}
+	if c.Storage != nil {
+		section("storage", c.Storage)
+	}
+	if c.Scheduler != nil {
+		section("scheduler", c.Scheduler)
+	}
+	if c.Storage != nil && c.Scheduler != nil {
+		// 持久化间隔跨越多个采集周期时，崩溃会丢失内存中尚未持久化的电能
+		rules = append(rules, validationRule{
+			name:    "storage.persist_window",
+			section: "storage",
+			warning: true,
+			check: func() error {
+				// cron 表达式精确到分钟，按最短可能的调度间隔比较
+				interval := c.Scheduler.CollectionInterval
+				if c.Scheduler.Cron != "" {
+					interval = time.Minute
+				}
+				window := c.Storage.MinPersistInterval
+				if window <= 0 || window <= interval {
+					return nil
+				}
+				return &ConfigError{
+					Field: "storage.min_persist_interval",
+					Message: fmt.Sprintf("energy collected within min_persist_interval (%v) of a device's last persist is kept in memory only; "+
+						"a crash or kill loses up to %v of energy per device", window, window),
+				}
+			},
+		})
+	}
 	if c.Logging != nil {
-		if err := c.Logging.Validate(); err != nil {
-			return &ConfigError{
-				Message: "logging validation failed",
-				Err:     err,
-			}
+		section("logging", c.Logging)
+	}
+	if c.Metrics != nil {
+		section("metrics", c.Metrics)
+	}
+	if c.Energy != nil {
+		section("energy", c.Energy)
+
+		// 设备电能来源需要映射设备上报的累计电能字段
+		rules = append(rules, validationRule{
+			name:    "energy.device_source",
+			section: "energy",
+			check: func() error {
+				if c.Energy.Source == energy.SourceDevice && c.WinPower != nil && c.WinPower.FieldMap["energy_total_wh"] == "" {
+					return &ConfigError{
+						Field:   "energy.source",
+						Message: "source \"device\" requires winpower.field_map.energy_total_wh",
+					}
+				}
+				return nil
+			},
+		})
+
+		// 最大积分间隔不超过采集间隔时每次积分都会被跳过
+		rules = append(rules, validationRule{
+			name:    "energy.max_integration_gap",
+			section: "energy",
+			check: func() error {
+				if c.Energy.MaxIntegrationGap <= 0 || c.Scheduler == nil || c.Scheduler.Cron != "" {
+					return nil
+				}
+				interval := c.Scheduler.CollectionInterval
+				if c.Scheduler.Adaptive {
+					interval = c.Scheduler.AdaptiveMaxInterval
+				}
+				if c.Energy.MaxIntegrationGap <= interval {
+					return &ConfigError{
+						Field: "energy.max_integration_gap",
+						Message: fmt.Sprintf("max_integration_gap (%v) must exceed the collection interval (%v), otherwise every integration is skipped",
+							c.Energy.MaxIntegrationGap, interval),
+					}
+				}
+				return nil
+			},
+		})
+
+		// 按平均功率积分需要映射设备上报的区间平均功率字段
+		rules = append(rules, validationRule{
+			name:    "energy.average_power",
+			section: "energy",
+			check: func() error {
+				if c.Energy.PowerReading == energy.PowerReadingAverage && c.WinPower != nil && c.WinPower.FieldMap["load_avg_watt"] == "" {
+					return &ConfigError{
+						Field:   "energy.power_reading",
+						Message: "power_reading \"average\" requires winpower.field_map.load_avg_watt",
+					}
+				}
+				return nil
+			},
+		})
+	}
+
+	if c.Collector != nil {
+		section("collector", c.Collector)
+	}
+	if c.Signals != nil {
+		section("signals", c.Signals)
+	}
+	if c.Shutdown != nil {
+		section("shutdown", c.Shutdown)
+
+		// 模块的关闭期限短于其自身的关闭超时时，模块会在完成关闭前被放弃
+		rules = append(rules, validationRule{
+			name:    "shutdown.module_deadlines",
+			section: "shutdown",
+			warning: true,
+			check: func() error {
+				limits := make(map[string]time.Duration, 2)
+				if c.Scheduler != nil {
+					limits[shutdown.ModuleScheduler] = c.Scheduler.GracefulShutdownTimeout
+				}
+				if c.Server != nil {
+					limits[shutdown.ModuleServer] = c.Server.ShutdownTimeout
+				}
+				for _, module := range shutdown.Modules {
+					limit, ok := limits[module]
+					if !ok || c.Shutdown.Timeout(module) >= limit {
+						continue
+					}
+					return &ConfigError{
+						Field: "shutdown.module_timeouts." + module,
+						Message: fmt.Sprintf("shutdown deadline of %s (%v) is shorter than its own shutdown timeout (%v); "+
+							"it may be abandoned before it finishes", module, c.Shutdown.Timeout(module), limit),
+					}
+				}
+				return nil
+			},
+		})
+	}
+
+	return rules
+}
+
+// ruleMessage 返回规则失败的说明，去掉 ConfigError 的通用前缀
+func ruleMessage(err error) string {
+	var cfgErr *ConfigError
+	if errors.As(err, &cfgErr) {
+		if cfgErr.Field != "" || cfgErr.Err == nil {
+			return cfgErr.Message
 		}
+		return cfgErr.Err.Error()
 	}
+	return err.Error()
+}
 
-	return nil
+// ruleField 返回规则失败对应的配置字段路径，无法定位到具体字段时返回模块名
+func ruleField(section string, err error) string {
+	var wpErr *winpower.ConfigError
+	if errors.As(err, &wpErr) && wpErr.Field != "" {
+		return section + "." + wpErr.Field
+	}
+
+	var cfgErr *ConfigError
+	if errors.As(err, &cfgErr) && cfgErr.Field != "" {
+		return cfgErr.Field
+	}
+	return section
 }
@@ -2,8 +2,11 @@ package config
 
 import (
 	"testing"
+	"time"
 
+	"github.com/lay-g/winpower-g2-exporter/internal/energy"
 	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/shutdown"
 	"github.com/lay-g/winpower-g2-exporter/internal/scheduler"
 	"github.com/lay-g/winpower-g2-exporter/internal/server"
 	"github.com/lay-g/winpower-g2-exporter/internal/storage"
@@ -100,6 +103,26 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "device energy source without mapped field",
+			config: &Config{
+				WinPower: validWinPowerConfig(),
+				Energy:   &energy.Config{Source: energy.SourceDevice},
+			},
+			wantErr: true,
+		},
+		{
+			name: "device energy source with mapped field",
+			config: &Config{
+				WinPower: func() *winpower.Config {
+					cfg := validWinPowerConfig()
+					cfg.FieldMap = map[string]string{"energy_total_wh": "totalEnergy"}
+					return cfg
+				}(),
+				Energy: &energy.Config{Source: energy.SourceDevice},
+			},
+			wantErr: false,
+		},
 		{
 			name: "nil module configs are allowed",
 			config: &Config{
@@ -125,6 +148,141 @@ func TestConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestConfig_CheckRules(t *testing.T) {
+	cfg := &Config{
+		Server: &server.Config{
+			Port: -1, // Invalid port
+			Host: "0.0.0.0",
+		},
+		WinPower: &winpower.Config{
+			BaseURL:  "", // Empty URL
+			Username: "test",
+			Password: "test",
+		},
+		Storage: storage.DefaultConfig(),
+		Energy:  &energy.Config{Source: energy.SourceDevice, PowerReading: energy.PowerReadingAverage},
+	}
+
+	results := cfg.CheckRules()
+
+	byRule := make(map[string]RuleResult, len(results))
+	for _, result := range results {
+		byRule[result.Rule] = result
+	}
+	require.Len(t, byRule, len(results), "rule names must be unique")
+
+	// Every failing rule is reported, not only the first
+	assert.Equal(t, SeverityError, byRule["server"].Severity)
+	assert.Equal(t, "server", byRule["server"].Field)
+
+	assert.Equal(t, SeverityError, byRule["winpower"].Severity)
+	assert.Equal(t, "winpower.base_url", byRule["winpower"].Field)
+	assert.Contains(t, byRule["winpower"].Message, "cannot be empty")
+
+	assert.Equal(t, SeverityInfo, byRule["storage"].Severity)
+
+	assert.Equal(t, SeverityError, byRule["energy.device_source"].Severity)
+	assert.Equal(t, "energy.source", byRule["energy.device_source"].Field)
+
+	assert.Equal(t, SeverityError, byRule["energy.average_power"].Severity)
+	assert.Equal(t, "energy.power_reading", byRule["energy.average_power"].Field)
+
+	// Sections that are not configured produce no results
+	assert.NotContains(t, byRule, "scheduler")
+
+	// Validate still reports the first failure
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server validation failed")
+}
+
+func TestConfig_PersistWindowWarning(t *testing.T) {
+	newConfig := func(persist, interval time.Duration, cron string) *Config {
+		storageCfg := storage.DefaultConfig()
+		storageCfg.MinPersistInterval = persist
+		schedulerCfg := scheduler.DefaultConfig()
+		schedulerCfg.CollectionInterval = interval
+		schedulerCfg.Cron = cron
+		return &Config{Storage: storageCfg, Scheduler: schedulerCfg}
+	}
+
+	tests := []struct {
+		name string
+		cfg  *Config
+		warn bool
+	}{
+		{"disabled", newConfig(0, 5*time.Second, ""), false},
+		{"within interval", newConfig(5*time.Second, 5*time.Second, ""), false},
+		{"longer than interval", newConfig(time.Minute, 5*time.Second, ""), true},
+		{"within cron minute", newConfig(time.Minute, 5*time.Second, "*/5 * * * *"), false},
+		{"longer than cron minute", newConfig(2*time.Minute, 5*time.Second, "*/5 * * * *"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := tt.cfg.Warnings()
+			if !tt.warn {
+				assert.Empty(t, warnings)
+				return
+			}
+			require.Len(t, warnings, 1)
+			assert.Equal(t, "storage.persist_window", warnings[0].Rule)
+			assert.Equal(t, "storage.min_persist_interval", warnings[0].Field)
+			assert.Contains(t, warnings[0].Message, "kept in memory only")
+
+			// Warnings never make the config invalid
+			assert.NoError(t, tt.cfg.Validate())
+		})
+	}
+}
+
+func TestConfig_ShutdownDeadlineWarning(t *testing.T) {
+	newConfig := func(serverDeadline time.Duration) *Config {
+		shutdownCfg := shutdown.DefaultConfig()
+		shutdownCfg.ModuleTimeouts[shutdown.ModuleServer] = serverDeadline
+		return &Config{
+			Server:    server.DefaultConfig(),
+			Scheduler: scheduler.DefaultConfig(),
+			Shutdown:  shutdownCfg,
+		}
+	}
+
+	assert.Empty(t, newConfig(35*time.Second).Warnings())
+
+	// The server's own shutdown timeout defaults to 30s
+	cfg := newConfig(20 * time.Second)
+	warnings := cfg.Warnings()
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "shutdown.module_deadlines", warnings[0].Rule)
+	assert.Equal(t, "shutdown.module_timeouts.server", warnings[0].Field)
+	assert.Contains(t, warnings[0].Message, "may be abandoned")
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestConfig_MaxIntegrationGap(t *testing.T) {
+	newConfig := func(gap time.Duration) *Config {
+		schedulerCfg := scheduler.DefaultConfig()
+		schedulerCfg.CollectionInterval = 5 * time.Second
+		return &Config{
+			Scheduler: schedulerCfg,
+			Energy:    &energy.Config{MaxIntegrationGap: gap},
+		}
+	}
+
+	assert.NoError(t, newConfig(0).Validate())
+	assert.NoError(t, newConfig(15*time.Second).Validate())
+
+	err := newConfig(5 * time.Second).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must exceed the collection interval")
+
+	// In adaptive mode the gap is compared with the longest interval
+	cfg := newConfig(15 * time.Second)
+	cfg.Scheduler.Adaptive = true
+	cfg.Scheduler.AdaptiveMaxInterval = time.Minute
+	require.Error(t, cfg.Validate())
+}
+
 func TestConfigValidator_Interface(t *testing.T) {
 	// Verify that all module configs implement ConfigValidator
 	var _ ConfigValidator = (*server.Config)(nil)
@@ -132,6 +290,7 @@ func TestConfigValidator_Interface(t *testing.T) {
 	var _ ConfigValidator = (*storage.Config)(nil)
 	var _ ConfigValidator = (*scheduler.Config)(nil)
 	var _ ConfigValidator = (*log.Config)(nil)
+	var _ ConfigValidator = (*energy.Config)(nil)
 }
 
 func TestConfigManager_Interface(t *testing.T) {
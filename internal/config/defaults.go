@@ -1,6 +1,11 @@
 package config
 
-import "time"
+import (
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/shutdown"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/signals"
+)
 
 // setDefaults 设置默认配置值
 func (l *Loader) setDefaults() {
@@ -12,21 +17,86 @@ func (l *Loader) setDefaults() {
 	l.viper.SetDefault("server.write_timeout", 10*time.Second)
 	l.viper.SetDefault("server.idle_timeout", 60*time.Second)
 	l.viper.SetDefault("server.enable_pprof", false)
+	l.viper.SetDefault("server.enable_debug_info", false)
+	l.viper.SetDefault("server.enable_debug_collect", false)
+	l.viper.SetDefault("server.enable_admin", false)
+	l.viper.SetDefault("server.max_concurrent_admin_operations", 1)
+	l.viper.SetDefault("server.record_scrape_duration", true)
+	l.viper.SetDefault("server.split_metrics_endpoints", false)
+	l.viper.SetDefault("server.api_token", "")
+	l.viper.SetDefault("server.tls_cert_file", "")
+	l.viper.SetDefault("server.tls_key_file", "")
+	l.viper.SetDefault("server.tls_min_version", "1.2")
+	l.viper.SetDefault("server.tls_cipher_suites", []string{})
 	l.viper.SetDefault("server.shutdown_timeout", 30*time.Second)
 
 	// WinPower 默认配置
+	l.viper.SetDefault("winpower.login_path", "/api/v1/auth/login")
+	l.viper.SetDefault("winpower.device_data_path", "/api/v1/deviceData/detail/list")
 	l.viper.SetDefault("winpower.timeout", 15*time.Second)
 	l.viper.SetDefault("winpower.skip_ssl_verify", false)
+	l.viper.SetDefault("winpower.tls_min_version", "1.2")
+	l.viper.SetDefault("winpower.tls_cipher_suites", []string{})
 	l.viper.SetDefault("winpower.refresh_threshold", 5*time.Minute)
+	l.viper.SetDefault("winpower.background_refresh", true)
+	l.viper.SetDefault("winpower.refresh_retries", 3)
+	l.viper.SetDefault("winpower.max_retries", 0)
+	l.viper.SetDefault("winpower.retry_interval", time.Second)
+	l.viper.SetDefault("winpower.retryable_status_codes", []int{})
+	l.viper.SetDefault("winpower.permanent_status_codes", []int{})
+	l.viper.SetDefault("winpower.refresh_retry_interval", 10*time.Second)
+	l.viper.SetDefault("winpower.auth_timeout", "0s")
 	l.viper.SetDefault("winpower.user_agent", "Mozilla/5.0 (compatible; WinPower-Exporter/1.0)")
+	l.viper.SetDefault("winpower.idle_close_timeout", 0)
+	l.viper.SetDefault("winpower.follow_redirects", true)
+	l.viper.SetDefault("winpower.max_redirects", 10)
+	l.viper.SetDefault("winpower.allow_cross_host_redirects", false)
+	l.viper.SetDefault("winpower.verify_on_start", false)
+	l.viper.SetDefault("winpower.startup_connect_timeout", 0)
+	l.viper.SetDefault("winpower.startup_failure_mode", "fatal")
+	l.viper.SetDefault("winpower.identity_field", "")
+	l.viper.SetDefault("winpower.time_layout", "")
+	l.viper.SetDefault("winpower.time_zone", "")
+	l.viper.SetDefault("winpower.maintenance_field", "")
+	l.viper.SetDefault("winpower.device_shortfall_limit", 0)
+	l.viper.SetDefault("winpower.redact_fields", []string{"Authorization", "Cookie", "Set-Cookie", "password", "token"})
+	l.viper.SetDefault("winpower.raw_response_max_bytes", 0)
+	l.viper.SetDefault("winpower.signing.enabled", false)
+	l.viper.SetDefault("winpower.signing.secret", "")
+	l.viper.SetDefault("winpower.signing.secret_file", "")
+	l.viper.SetDefault("winpower.signing.algorithm", "hmac-sha256")
+	l.viper.SetDefault("winpower.signing.signature_header", "X-Signature")
+	l.viper.SetDefault("winpower.signing.timestamp_header", "X-Timestamp")
 
 	// Storage 默认配置
 	l.viper.SetDefault("storage.data_dir", "./data")
 	l.viper.SetDefault("storage.file_permissions", 0644)
+	l.viper.SetDefault("storage.dir_permissions", 0755)
+	l.viper.SetDefault("storage.write_retries", 3)
+	l.viper.SetDefault("storage.readiness_timeout", "30s")
+	l.viper.SetDefault("storage.readiness_interval", "1s")
+	l.viper.SetDefault("storage.batch_write", false)
+	l.viper.SetDefault("storage.future_tolerance", "24h")
+	l.viper.SetDefault("storage.hash_long_device_ids", false)
+	l.viper.SetDefault("storage.min_persist_interval", "0s")
+	l.viper.SetDefault("storage.snapshot_interval", "0s")
+	l.viper.SetDefault("storage.read_all_timeout", "0s")
 
 	// Scheduler 默认配置
 	l.viper.SetDefault("scheduler.collection_interval", 5*time.Second)
 	l.viper.SetDefault("scheduler.graceful_shutdown_timeout", 5*time.Second)
+	l.viper.SetDefault("scheduler.overrun_cooldown", 5*time.Second)
+	l.viper.SetDefault("scheduler.cron", "")
+	l.viper.SetDefault("scheduler.adaptive", false)
+	l.viper.SetDefault("scheduler.adaptive_min_interval", 5*time.Second)
+	l.viper.SetDefault("scheduler.adaptive_max_interval", time.Minute)
+	l.viper.SetDefault("scheduler.adaptive_stable_cycles", 3)
+	l.viper.SetDefault("scheduler.adaptive_change_percent", 5.0)
+	l.viper.SetDefault("scheduler.warmup_duration", 0)
+	l.viper.SetDefault("scheduler.warmup_interval", time.Second)
+	l.viper.SetDefault("scheduler.health_window", 10)
+	l.viper.SetDefault("scheduler.health_degraded_ratio", 0.8)
+	l.viper.SetDefault("scheduler.health_saturated_ratio", 0.95)
 
 	// Logging 默认配置
 	l.viper.SetDefault("logging.level", "info")
@@ -40,4 +110,54 @@ func (l *Loader) setDefaults() {
 	l.viper.SetDefault("logging.development", false)
 	l.viper.SetDefault("logging.enable_caller", false)
 	l.viper.SetDefault("logging.enable_stacktrace", false)
+	l.viper.SetDefault("logging.sampling.enabled", false)
+	l.viper.SetDefault("logging.sampling.initial", 100)
+	l.viper.SetDefault("logging.sampling.thereafter", 100)
+
+	// Metrics 默认配置
+	l.viper.SetDefault("metrics.enable_memory_metrics", true)
+	l.viper.SetDefault("metrics.enable_device_uptime", false)
+	l.viper.SetDefault("metrics.enable_reported_energy", false)
+	l.viper.SetDefault("metrics.enable_energy_reset_epoch", false)
+	l.viper.SetDefault("metrics.enable_device_type_code", false)
+	l.viper.SetDefault("metrics.device_type_default_code", -1)
+	l.viper.SetDefault("metrics.enable_alarm_types", false)
+	l.viper.SetDefault("metrics.device_group_default", "ungrouped")
+	l.viper.SetDefault("metrics.enable_runtime_metrics", false)
+	l.viper.SetDefault("metrics.runtime_metrics_interval", 30*time.Second)
+	l.viper.SetDefault("metrics.max_concurrent_collections", 0)
+	l.viper.SetDefault("metrics.collection_wait_timeout", 2*time.Second)
+	l.viper.SetDefault("metrics.stale_max_age", 0)
+	l.viper.SetDefault("metrics.max_devices", 1000)
+	l.viper.SetDefault("metrics.max_label_value_length", 128)
+	l.viper.SetDefault("metrics.battery_runtime_low_minutes", 10.0)
+	l.viper.SetDefault("metrics.batch_device_updates", false)
+	l.viper.SetDefault("metrics.invalid_value_mode", "skip")
+	l.viper.SetDefault("metrics.pushgateway_url", "")
+	l.viper.SetDefault("metrics.push_job", "winpower_exporter")
+	l.viper.SetDefault("metrics.push_interval", 30*time.Second)
+
+	// Energy 默认配置
+	l.viper.SetDefault("energy.source", "power")
+	l.viper.SetDefault("energy.min_power_watts", 0.0)
+	l.viper.SetDefault("energy.power_reading", "instant")
+	l.viper.SetDefault("energy.skip_resume_gap", false)
+	l.viper.SetDefault("energy.degraded_mode", false)
+	l.viper.SetDefault("energy.max_integration_gap", 0)
+
+	// Collector 默认配置
+	l.viper.SetDefault("collector.device_priority", []string{})
+	l.viper.SetDefault("collector.device_max_age", 24*time.Hour)
+	l.viper.SetDefault("collector.energy_stall_window", 0)
+
+	// Signals 默认配置，逐个信号设置以便配置文件只覆盖需要修改的信号
+	for name, action := range signals.DefaultActions() {
+		l.viper.SetDefault("signals.actions."+name, action)
+	}
+
+	// Shutdown 默认配置，逐个模块设置以便配置文件只覆盖需要修改的模块
+	l.viper.SetDefault("shutdown.module_timeout", 10*time.Second)
+	for module, timeout := range shutdown.DefaultModuleTimeouts() {
+		l.viper.SetDefault("shutdown.module_timeouts."+module, timeout)
+	}
 }
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// reloadableKeys 可在运行时生效、无需重启的配置项
+// logging.level 可通过日志级别控制器（LevelController）在运行时调整
+var reloadableKeys = map[string]bool{
+	"logging.level": true,
+}
+
+// Change 描述两份配置之间单个配置项的变更
+type Change struct {
+	// Key 配置项路径，如 "server.port"
+	Key string `json:"key"`
+
+	// Old 变更前的值
+	Old string `json:"old"`
+
+	// New 变更后的值
+	New string `json:"new"`
+
+	// RequiresRestart 该变更是否需要重启才能生效
+	RequiresRestart bool `json:"requires_restart"`
+}
+
+// RequiresRestart 判断配置项变更是否需要重启
+func RequiresRestart(key string) bool {
+	return !reloadableKeys[key]
+}
+
+// Diff 比较两份配置，返回按配置项路径排序的变更列表
+// 敏感配置项（如密码）的值会被脱敏
+func Diff(current, candidate *Config) []Change {
+	oldValues := make(map[string]interface{})
+	newValues := make(map[string]interface{})
+	flatten("", reflect.ValueOf(current), oldValues)
+	flatten("", reflect.ValueOf(candidate), newValues)
+
+	keys := make(map[string]bool, len(oldValues)+len(newValues))
+	for key := range oldValues {
+		keys[key] = true
+	}
+	for key := range newValues {
+		keys[key] = true
+	}
+
+	var changes []Change
+	for key := range keys {
+		oldValue, newValue := oldValues[key], newValues[key]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		changes = append(changes, Change{
+			Key:             key,
+			Old:             formatValue(key, oldValue),
+			New:             formatValue(key, newValue),
+			RequiresRestart: RequiresRestart(key),
+		})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// flatten 按 yaml 标签将配置结构体展开为 "a.b.c" 形式的键值对
+func flatten(prefix string, v reflect.Value, out map[string]interface{}) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		out[prefix] = v.Interface()
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := yamlName(field)
+		if name == "" {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		flatten(key, v.Field(i), out)
+	}
+}
+
+// yamlName 返回字段在配置文件中的名称，未导出或标记为 "-" 的字段返回空字符串
+func yamlName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// formatValue 格式化配置值用于展示，敏感配置项只显示是否设置
+func formatValue(key string, value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if strings.HasSuffix(key, "password") || strings.HasSuffix(key, "token") || strings.HasSuffix(key, "secret") {
+		if reflect.ValueOf(value).IsZero() {
+			return ""
+		}
+		return "******"
+	}
+	return fmt.Sprintf("%v", value)
+}
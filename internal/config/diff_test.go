@@ -0,0 +1,78 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	newConfig := func() *Config {
+		return &Config{
+			Server:   server.DefaultConfig(),
+			WinPower: validWinPowerConfig(),
+			Logging:  log.DefaultConfig(),
+		}
+	}
+
+	t.Run("identical configs have no changes", func(t *testing.T) {
+		assert.Empty(t, Diff(newConfig(), newConfig()))
+	})
+
+	t.Run("changes are sorted and categorized", func(t *testing.T) {
+		current := newConfig()
+		candidate := newConfig()
+		candidate.Server.Port = 9191
+		candidate.Logging.Level = "debug"
+
+		changes := Diff(current, candidate)
+		require.Len(t, changes, 2)
+
+		assert.Equal(t, "logging.level", changes[0].Key)
+		assert.Equal(t, "info", changes[0].Old)
+		assert.Equal(t, "debug", changes[0].New)
+		assert.False(t, changes[0].RequiresRestart)
+
+		assert.Equal(t, "server.port", changes[1].Key)
+		assert.Equal(t, "9090", changes[1].Old)
+		assert.Equal(t, "9191", changes[1].New)
+		assert.True(t, changes[1].RequiresRestart)
+	})
+
+	t.Run("passwords are redacted", func(t *testing.T) {
+		candidate := newConfig()
+		candidate.WinPower.Password = "new-secret"
+
+		changes := Diff(newConfig(), candidate)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "winpower.password", changes[0].Key)
+		assert.Equal(t, "******", changes[0].Old)
+		assert.Equal(t, "******", changes[0].New)
+	})
+
+	t.Run("API tokens are redacted", func(t *testing.T) {
+		candidate := newConfig()
+		candidate.Server.APIToken = "new-token"
+
+		changes := Diff(newConfig(), candidate)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "server.api_token", changes[0].Key)
+		assert.Equal(t, "", changes[0].Old)
+		assert.Equal(t, "******", changes[0].New)
+	})
+
+	t.Run("sections missing on one side are reported", func(t *testing.T) {
+		current := newConfig()
+		current.Logging = nil
+
+		changes := Diff(current, newConfig())
+		assert.NotEmpty(t, changes)
+		for _, change := range changes {
+			assert.Contains(t, change.Key, "logging.")
+			assert.Empty(t, change.Old)
+		}
+	})
+}
@@ -20,24 +20,88 @@ func (l *Loader) bindFlags() error {
 	flags.Duration("server.write-timeout", 10*time.Second, "HTTP write timeout")
 	flags.Duration("server.idle-timeout", 60*time.Second, "HTTP idle timeout")
 	flags.Bool("server.enable-pprof", false, "Enable pprof debug endpoints")
+	flags.Bool("server.enable-debug-info", false, "Enable /debug/info startup summary endpoint")
+	flags.Bool("server.enable-debug-collect", false, "Enable /debug/collect one-off collection timing, /debug/devices and /debug/winpower/raw endpoints")
+	flags.Bool("server.enable-admin", false, "Enable /admin endpoints for pausing and resuming collection")
+	flags.Int("server.max-concurrent-admin-operations", 1, "Max state-changing /admin requests handled at once; more are rejected with 409 (0 = unlimited)")
+	flags.Bool("server.record-scrape-duration", true, "Record how long serving /metrics takes")
+	flags.Bool("server.split-metrics-endpoints", false, "Also serve self-monitoring metrics on /metrics/exporter and device metrics on /metrics/devices")
+	flags.String("server.api-token", "", "Bearer token required on the /api endpoints (empty disables authentication)")
+	flags.String("server.tls-cert-file", "", "TLS certificate file; with server.tls-key-file serves HTTPS")
+	flags.String("server.tls-key-file", "", "TLS private key file; with server.tls-cert-file serves HTTPS")
+	flags.String("server.tls-min-version", "1.2", "Minimum TLS version accepted over HTTPS (1.0|1.1|1.2|1.3)")
+	flags.StringSlice("server.tls-cipher-suites", nil, "TLS 1.2 cipher suites accepted over HTTPS (empty = Go defaults)")
 	flags.Duration("server.shutdown-timeout", 30*time.Second, "Graceful shutdown timeout")
 
 	// WinPower 配置
 	flags.String("winpower.base-url", "", "WinPower service base URL")
 	flags.String("winpower.username", "", "WinPower username")
 	flags.String("winpower.password", "", "WinPower password")
+	flags.String("winpower.login-path", "/api/v1/auth/login", "WinPower login endpoint path appended to the base URL")
+	flags.String("winpower.device-data-path", "/api/v1/deviceData/detail/list", "WinPower device data endpoint path appended to the base URL")
 	flags.Duration("winpower.timeout", 15*time.Second, "WinPower request timeout")
 	flags.Bool("winpower.skip-ssl-verify", false, "Skip SSL certificate verification")
+	flags.String("winpower.tls-min-version", "1.2", "Minimum TLS version for WinPower connections (1.0|1.1|1.2|1.3)")
+	flags.StringSlice("winpower.tls-cipher-suites", nil, "TLS 1.2 cipher suites offered to WinPower (empty = Go defaults)")
 	flags.Duration("winpower.refresh-threshold", 5*time.Minute, "Token refresh threshold")
+	flags.Bool("winpower.background-refresh", true, "Refresh the token in the background ahead of expiry")
+	flags.Int("winpower.refresh-retries", 3, "Additional attempts for a failed background token refresh")
+	flags.Duration("winpower.refresh-retry-interval", 10*time.Second, "Delay between background token refresh attempts")
+	flags.Duration("winpower.auth-timeout", 0, "Authenticate with its own timeout before each scheduled collection starts its deadline (0 = authenticate within the collection)")
+	flags.Int("winpower.max-retries", 0, "Additional attempts for a device data request that failed with a retryable error (0 = no retries)")
+	flags.Duration("winpower.retry-interval", time.Second, "Delay between device data request attempts")
 	flags.String("winpower.user-agent", "Mozilla/5.0 (compatible; WinPower-Exporter/1.0)", "HTTP User-Agent")
+	flags.Bool("winpower.follow-redirects", true, "Follow HTTP redirects from WinPower")
+	flags.Int("winpower.max-redirects", 10, "Max redirects followed per WinPower request")
+	flags.Bool("winpower.allow-cross-host-redirects", false, "Follow redirects to another host (Authorization is never forwarded)")
+	flags.Duration("winpower.idle-close-timeout", 0, "Close idle WinPower connections after this long without requests (0 = disabled)")
+	flags.Bool("winpower.verify-on-start", false, "Log in to WinPower before starting collection")
+	flags.Duration("winpower.startup-connect-timeout", 0, "How long to retry the startup WinPower login with backoff (0 = single attempt)")
+	flags.String("winpower.startup-failure-mode", "fatal", "What to do when WinPower is unreachable at startup (fatal|degraded)")
+	flags.String("winpower.maintenance-field", "", "Dot-separated path of the WinPower maintenance indicator in the device data response; data is discarded while it is set")
+	flags.Int("winpower.device-shortfall-limit", 0, "Fail a collection whose response is missing at least this many of the devices WinPower reports (0 = only log and count mismatches)")
+	flags.String("winpower.time-layout", "", "Go time layout of WinPower string timestamps (empty = RFC3339 and common WinPower formats)")
+	flags.String("winpower.time-zone", "", "IANA time zone of WinPower timestamps without an offset (empty = UTC)")
+	flags.String("winpower.identity-field", "", "Device data field holding a stable hardware identity (e.g. serial number) used to track energy across device ID changes")
+	flags.IntSlice("winpower.retryable-status-codes", nil, "HTTP status codes retried in addition to the defaults (401, 408, 425, 429, 5xx)")
+	flags.IntSlice("winpower.permanent-status-codes", nil, "HTTP status codes never retried, overriding the defaults")
+	flags.StringSlice("winpower.redact-fields", nil, "Header, query parameter and JSON field names redacted from request logging (default Authorization,Cookie,Set-Cookie,password,token)")
+	flags.Int("winpower.raw-response-max-bytes", 0, "Keep the last device data response up to this size for /debug/winpower/raw (0 = disabled)")
+	flags.Bool("winpower.signing.enabled", false, "Sign every WinPower request with an HMAC of the timestamp and body")
+	flags.String("winpower.signing.secret-file", "", "File containing the shared request signing secret")
+	flags.String("winpower.signing.algorithm", "hmac-sha256", "Request signing algorithm (hmac-sha256|hmac-sha512)")
+	flags.String("winpower.signing.signature-header", "X-Signature", "Request header carrying the signature")
+	flags.String("winpower.signing.timestamp-header", "X-Timestamp", "Request header carrying the signed timestamp")
 
 	// Storage 配置
 	flags.String("storage.data-dir", "./data", "Data directory path")
 	flags.Int("storage.file-permissions", 0644, "File permissions (octal)")
+	flags.Int("storage.dir-permissions", 0755, "Permissions of a created data directory (octal)")
+	flags.Int("storage.write-retries", 3, "Retries for transient storage write errors")
+	flags.Duration("storage.readiness-timeout", 30*time.Second, "How long to wait for the data directory to become writable at startup")
+	flags.Duration("storage.readiness-interval", time.Second, "Delay between storage readiness checks")
+	flags.Bool("storage.batch-write", false, "Persist the energy of all devices of a collection cycle together")
+	flags.Duration("storage.future-tolerance", 24*time.Hour, "How far in the future a stored timestamp may be before it is rejected")
+	flags.Bool("storage.hash-long-device-ids", false, "Store device IDs longer than 200 bytes under a fixed-length hashed file name")
+	flags.Duration("storage.min-persist-interval", 0, "Minimum time between two persists of the same device's energy (0 = persist every write)")
+	flags.Duration("storage.snapshot-interval", 0, "Time between two energy snapshots kept under <data_dir>/snapshots, e.g. 24h (0 = disabled)")
+	flags.Duration("storage.read-all-timeout", 0, "Time limit for reading all stored devices; on expiry only the devices read so far are used (0 = no limit)")
 
 	// Scheduler 配置
 	flags.Duration("scheduler.collection-interval", 5*time.Second, "Data collection interval")
 	flags.Duration("scheduler.graceful-shutdown-timeout", 5*time.Second, "Graceful shutdown timeout")
+	flags.String("scheduler.cron", "", "Five-field cron expression for collection times, overrides the collection interval")
+	flags.Duration("scheduler.overrun-cooldown", 5*time.Second, "Extra delay before the next collection after a cycle exceeds the interval")
+	flags.Bool("scheduler.adaptive", false, "Tune the collection interval from the change rate of the device power readings")
+	flags.Duration("scheduler.adaptive-min-interval", 5*time.Second, "Shortest collection interval in adaptive mode")
+	flags.Duration("scheduler.adaptive-max-interval", time.Minute, "Longest collection interval in adaptive mode")
+	flags.Int("scheduler.adaptive-stable-cycles", 3, "Consecutive stable readings after which a device's interval is doubled")
+	flags.Float64("scheduler.adaptive-change-percent", 5, "Largest power change in percent that counts as stable in adaptive mode")
+	flags.Duration("scheduler.warmup-duration", 0, "How long after startup collections run at the warmup interval (0 = disabled)")
+	flags.Duration("scheduler.warmup-interval", time.Second, "Collection interval during the warmup")
+	flags.Int("scheduler.health-window", 10, "Number of recent collection cycles the collection health is derived from")
+	flags.Float64("scheduler.health-degraded-ratio", 0.8, "Mean fraction of the interval used by recent cycles from which collection health is degraded")
+	flags.Float64("scheduler.health-saturated-ratio", 0.95, "Mean fraction of the interval used by recent cycles from which collection health is saturated")
 
 	// Logging 配置
 	flags.String("logging.level", "info", "Log level (debug|info|warn|error|fatal)")
@@ -51,6 +115,55 @@ func (l *Loader) bindFlags() error {
 	flags.Bool("logging.development", false, "Enable development mode")
 	flags.Bool("logging.enable-caller", false, "Enable caller logging")
 	flags.Bool("logging.enable-stacktrace", false, "Enable stacktrace logging")
+	flags.Bool("logging.sampling.enabled", false, "Enable log sampling for entries below error level")
+	flags.Int("logging.sampling.initial", 100, "Entries with the same level and message logged per second before sampling")
+	flags.Int("logging.sampling.thereafter", 100, "After the initial entries, log one in every N per second (0 = drop)")
+
+	// Metrics 配置
+	flags.Bool("metrics.enable-memory-metrics", true, "Enable exporter memory usage metrics")
+	flags.Bool("metrics.enable-device-uptime", false, "Enable per-device uptime metrics")
+	flags.Bool("metrics.enable-reported-energy", false, "Export the cumulative energy reported by WinPower (requires energy_total_wh in the field map)")
+	flags.Bool("metrics.enable-runtime-metrics", false, "Enable exporter goroutine and heap metrics")
+	flags.Duration("metrics.runtime-metrics-interval", 30*time.Second, "How often the goroutine and heap metrics are refreshed")
+	flags.Int("metrics.max-concurrent-collections", 1, "Max concurrent on-scrape collections (0 = unlimited)")
+	flags.Duration("metrics.collection-wait-timeout", 2*time.Second, "Wait for a free collection slot before serving cached metrics")
+	flags.Duration("metrics.stale-max-age", 0, "Serve the last successful collection up to this old when an on-scrape collection fails (0 = fail the scrape)")
+	flags.Int("metrics.max-devices", 1000, "Max devices with exported series before evicting the least recently updated (0 = unlimited)")
+	flags.Int("metrics.max-label-value-length", 128, "Max length of device label values after sanitization (0 = unlimited)")
+	flags.Float64("metrics.battery-runtime-low-minutes", 10, "Battery runtime (minutes) below which battery_runtime_low is 1 (0 = never)")
+	flags.Bool("metrics.batch-device-updates", false, "Publish device metrics as one snapshot per collection cycle to reduce lock contention")
+	flags.String("metrics.invalid-value-mode", "skip", "Handling of NaN or infinite device measurements (skip = withhold the series, zero = export 0)")
+	flags.StringToString("metrics.device-type-prefixes", nil, "Device type to metric name prefix, e.g. 1=ups (empty = label-based names)")
+	flags.Bool("metrics.enable-device-type-code", false, "Export winpower_device_type_code, the device type as a numeric code")
+	flags.StringToInt("metrics.device-type-codes", nil, "Device type to numeric code exported by winpower_device_type_code, e.g. 1=1")
+	flags.Int("metrics.device-type-default-code", -1, "Code exported by winpower_device_type_code for unmapped device types")
+	flags.StringToString("metrics.device-groups", nil, "Device ID to group name exported as the device_group label, e.g. ups-1=rack-a (empty = no label)")
+	flags.String("metrics.device-group-default", "ungrouped", "device_group label of devices missing from metrics.device-groups")
+	flags.Bool("metrics.enable-alarm-types", false, "Export winpower_device_alarm_type_active, the active alarms per alarm type")
+	flags.String("metrics.pushgateway-url", "", "Pushgateway to push the metrics to after each scheduled collection (empty = disabled)")
+	flags.String("metrics.push-job", "winpower_exporter", "Job label of the metrics pushed to the Pushgateway")
+	flags.StringToString("metrics.push-grouping", nil, "Additional grouping key labels of the pushed metrics, e.g. instance=site-a")
+	flags.Duration("metrics.push-interval", 30*time.Second, "Minimum time between two pushes to the Pushgateway (0 = after every collection)")
+	flags.StringToString("metrics.help-overrides", nil, "Metric name to HELP text override, e.g. winpower_power_watts=\"Instantaneous power in watts\"")
+
+	// Energy 配置
+	flags.String("energy.source", "power", "Energy source (power = integrate power, device = device-reported counter)")
+	flags.Float64("energy.min-power-watts", 0, "Power below this value (W) is treated as zero for energy integration")
+	flags.String("energy.power-reading", "instant", "Power integrated into energy (instant, average = WinPower's interval-average power when reported, requires load_avg_watt in the field map)")
+	flags.Bool("energy.skip-resume-gap", false, "Skip energy integration across a paused collection interval")
+	flags.Duration("energy.max-integration-gap", 0, "Skip integration and rebaseline when a device's last sample is older than this, e.g. after reconnecting (0 = unlimited)")
+	flags.Bool("energy.degraded-mode", false, "Keep accumulating energy in memory while storage is unavailable")
+
+	// Collector 配置
+	flags.StringSlice("collector.device-priority", nil, "Device IDs whose energy is calculated first in each collection cycle, in order (unlisted devices follow in reported order)")
+	flags.Duration("collector.energy-stall-window", 15*time.Minute, "Report a device as stalled when it draws power but its energy has not advanced for this long (0 = disabled)")
+
+	// Signals 配置
+	flags.StringToString("signals.actions", nil, "Signal to action mapping, e.g. sigint=ignore (actions: shutdown, reload, reopen-logfile, toggle-log-level, ignore)")
+
+	// Shutdown 配置
+	flags.Duration("shutdown.module-timeout", 10*time.Second, "Shutdown deadline of each module not listed in shutdown.module-timeouts; a module still stopping is abandoned")
+	flags.StringToString("shutdown.module-timeouts", nil, "Module to shutdown deadline mapping, e.g. storage=30s (modules: scheduler, server, winpower, storage)")
 
 	// 绑定到 viper（转换短横线为下划线）
 	// Parse command line arguments first
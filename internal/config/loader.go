@@ -1,12 +1,20 @@
 package config
 
 import (
+	"bytes"
+	"context"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/go-viper/mapstructure/v2"
+	"github.com/lay-g/winpower-g2-exporter/internal/collector"
+	"github.com/lay-g/winpower-g2-exporter/internal/energy"
+	"github.com/lay-g/winpower-g2-exporter/internal/metrics"
 	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/shutdown"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/signals"
 	"github.com/lay-g/winpower-g2-exporter/internal/scheduler"
 	"github.com/lay-g/winpower-g2-exporter/internal/server"
 	"github.com/lay-g/winpower-g2-exporter/internal/storage"
@@ -20,6 +28,14 @@ type Loader struct {
 	viper       *viper.Viper
 	flags       *pflag.FlagSet
 	searchPaths []string
+
+	// overlays 依次合并到主配置文件之上的配置文件
+	overlays []string
+
+	// remote 远程配置来源，nil 时只读取本地配置文件
+	remote *RemoteSource
+	// remoteErr 回退到本地配置文件时的远程配置获取错误
+	remoteErr error
 }
 
 // NewLoader 创建新的配置加载器
@@ -33,6 +49,11 @@ func NewLoader() *Loader {
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
 
+	// AutomaticEnv 只覆盖已知的配置项，没有默认值的模块关闭期限需要显式绑定环境变量
+	for _, module := range shutdown.Modules {
+		_ = v.BindEnv("shutdown.module_timeouts." + module)
+	}
+
 	// 定义搜索路径
 	searchPaths := []string{
 		".",        // 工作目录
@@ -51,6 +72,41 @@ func NewLoader() *Loader {
 	}
 }
 
+// SetConfigFile 指定配置文件路径，替代默认搜索路径
+func (l *Loader) SetConfigFile(path string) {
+	l.viper.SetConfigFile(path)
+}
+
+// SetConfigFiles 指定多个配置文件，替代默认搜索路径。第一个文件为主配置文件，
+// 其余文件按顺序合并到其上，后面文件中的配置项覆盖前面的同名配置项，
+// 环境变量与命令行参数仍优先于所有文件。空路径被忽略，全部为空时使用默认搜索路径
+func (l *Loader) SetConfigFiles(paths ...string) {
+	var files []string
+	for _, path := range paths {
+		if path != "" {
+			files = append(files, path)
+		}
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	l.viper.SetConfigFile(files[0])
+	l.overlays = files[1:]
+}
+
+// SetRemoteSource 设置远程配置来源，Load 时优先使用远程配置，
+// 获取失败时回退到本地配置文件；source 为 nil 时只读取本地配置文件
+func (l *Loader) SetRemoteSource(source *RemoteSource) {
+	l.remote = source
+}
+
+// RemoteError 返回最近一次 Load 回退到本地配置文件时的远程配置获取错误，
+// 未回退时返回 nil
+func (l *Loader) RemoteError() error {
+	return l.remoteErr
+}
+
 // Load 加载配置
 func (l *Loader) Load() (*Config, error) {
 	// 设置默认值
@@ -64,15 +120,9 @@ func (l *Loader) Load() (*Config, error) {
 		}
 	}
 
-	// 读取配置文件
-	if err := l.viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, &ConfigError{
-				Message: "failed to read config file",
-				Err:     err,
-			}
-		}
-		// 配置文件不存在是允许的，使用默认配置和环境变量
+	// 读取配置：优先使用远程配置，不可用时回退到本地配置文件
+	if err := l.readConfig(); err != nil {
+		return nil, err
 	}
 
 	// 解析到配置结构体
@@ -85,12 +135,19 @@ func (l *Loader) Load() (*Config, error) {
 	config.Storage = &storage.Config{}
 	config.Scheduler = &scheduler.Config{}
 	config.Logging = &log.Config{}
+	config.Metrics = &metrics.MetricsConfig{}
+	config.Energy = &energy.Config{}
+	config.Collector = &collector.Config{}
+	config.Signals = &signals.Config{}
+	config.Shutdown = &shutdown.Config{}
 
 	// Use Unmarshal with custom decode hooks for time.Duration
 	opts := viper.DecodeHook(
 		mapstructure.ComposeDecodeHookFunc(
 			mapstructure.StringToTimeDurationHookFunc(),
 			mapstructure.StringToSliceHookFunc(","),
+			// 逗号分隔的环境变量同样解析为非字符串切片，如 []int 状态码列表
+			mapstructure.StringToWeakSliceHookFunc(","),
 		),
 	)
 
@@ -146,9 +203,82 @@ func (l *Loader) Load() (*Config, error) {
 		config.Scheduler.GracefulShutdownTimeout = l.viper.GetDuration("scheduler.graceful_shutdown_timeout")
 	}
 
+	if config.Metrics.CollectionWaitTimeout == 0 {
+		config.Metrics.CollectionWaitTimeout = l.viper.GetDuration("metrics.collection_wait_timeout")
+	}
+
 	return &config, nil
 }
 
+// readConfig 读取主配置内容，再依次合并 SetConfigFiles 指定的其余配置文件
+func (l *Loader) readConfig() error {
+	if err := l.readBaseConfig(); err != nil {
+		return err
+	}
+	return l.mergeOverlays()
+}
+
+// mergeOverlays 依次将其余配置文件合并到已读取的配置之上，
+// 每个文件单独解析，出错时指明文件路径
+func (l *Loader) mergeOverlays() error {
+	for _, path := range l.overlays {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return &ConfigError{
+				Message: "failed to read config file " + path,
+				Err:     err,
+			}
+		}
+		if err := l.viper.MergeConfig(bytes.NewReader(content)); err != nil {
+			return &ConfigError{
+				Message: "failed to parse config file " + path,
+				Err:     err,
+			}
+		}
+	}
+	return nil
+}
+
+// readBaseConfig 读取主配置内容。设置了远程配置来源时先获取远程配置，
+// 获取失败则回退到本地配置文件；本地配置文件也不存在时返回远程配置错误
+func (l *Loader) readBaseConfig() error {
+	l.remoteErr = nil
+	if l.remote != nil {
+		client := &http.Client{Timeout: RemoteTimeout}
+		content, err := fetchRemoteConfig(context.Background(), client, l.remote)
+		if err == nil {
+			if err := l.viper.ReadConfig(bytes.NewReader(content)); err != nil {
+				return &ConfigError{
+					Message: "failed to parse remote config",
+					Err:     err,
+				}
+			}
+			return nil
+		}
+		l.remoteErr = err
+	}
+
+	if err := l.viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return &ConfigError{
+				Message: "failed to read config file",
+				Err:     err,
+			}
+		}
+		// 远程配置与本地配置文件均不可用时报告远程配置错误
+		if l.remoteErr != nil {
+			err := l.remoteErr
+			l.remoteErr = nil
+			return &ConfigError{
+				Message: "failed to load remote config",
+				Err:     err,
+			}
+		}
+		// 配置文件不存在是允许的，使用默认配置和环境变量
+	}
+	return nil
+}
+
 // Get 获取配置值
 func (l *Loader) Get(key string) interface{} {
 	return l.viper.Get(key)
@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -50,6 +52,105 @@ func TestLoader_Load_WithEnvironmentVariables(t *testing.T) {
 	assert.Equal(t, "debug", cfg.Logging.Level)
 }
 
+func TestLoader_Load_TLSSettings(t *testing.T) {
+	t.Setenv("WINPOWER_EXPORTER_SERVER_TLS_CIPHER_SUITES", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384")
+	t.Setenv("WINPOWER_EXPORTER_WINPOWER_TLS_MIN_VERSION", "1.3")
+
+	cfg, err := NewLoader().Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.2", cfg.Server.TLSMinVersion)
+	assert.Equal(t, []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"}, cfg.Server.TLSCipherSuites)
+	assert.Equal(t, "1.3", cfg.WinPower.TLSMinVersion)
+	assert.Empty(t, cfg.WinPower.TLSCipherSuites)
+}
+
+func TestLoader_Load_RetryStatusCodes(t *testing.T) {
+	t.Setenv("WINPOWER_EXPORTER_WINPOWER_RETRYABLE_STATUS_CODES", "420,421")
+
+	cfg, err := NewLoader().Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{420, 421}, cfg.WinPower.RetryableStatusCodes)
+	assert.Empty(t, cfg.WinPower.PermanentStatusCodes)
+}
+
+func TestLoader_Load_SignalActions(t *testing.T) {
+	t.Setenv("WINPOWER_EXPORTER_SIGNALS_ACTIONS_SIGINT", "ignore")
+
+	cfg, err := NewLoader().Load()
+	require.NoError(t, err)
+
+	// Overriding one signal keeps the defaults of the others
+	assert.Equal(t, map[string]string{
+		"sigint":  "ignore",
+		"sigterm": "shutdown",
+		"sighup":  "reload",
+		"sigusr1": "reopen-logfile",
+		"sigusr2": "toggle-log-level",
+	}, cfg.Signals.Actions)
+}
+
+func TestLoader_Load_ShutdownTimeouts(t *testing.T) {
+	t.Setenv("WINPOWER_EXPORTER_SHUTDOWN_MODULE_TIMEOUTS_STORAGE", "30s")
+
+	cfg, err := NewLoader().Load()
+	require.NoError(t, err)
+
+	// Overriding one module keeps the defaults of the others
+	assert.Equal(t, 10*time.Second, cfg.Shutdown.ModuleTimeout)
+	assert.Equal(t, 30*time.Second, cfg.Shutdown.Timeout("storage"))
+	assert.Equal(t, 35*time.Second, cfg.Shutdown.Timeout("server"))
+	assert.Equal(t, 10*time.Second, cfg.Shutdown.Timeout("winpower"))
+}
+
+func TestLoader_Load_MultipleConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+	base := write("base.yaml", "server:\n  port: 9191\n  host: 127.0.0.1\nlogging:\n  level: warn\n")
+	overlay := write("prod.yaml", "server:\n  port: 9292\nwinpower:\n  base_url: https://winpower.example.com\n")
+
+	t.Run("later files override earlier ones", func(t *testing.T) {
+		t.Setenv("WINPOWER_EXPORTER_LOGGING_LEVEL", "debug")
+
+		loader := NewLoader()
+		loader.SetConfigFiles(base, "", overlay)
+		cfg, err := loader.Load()
+		require.NoError(t, err)
+
+		assert.Equal(t, 9292, cfg.Server.Port)
+		// Keys missing from the overlay keep the value of the base file
+		assert.Equal(t, "127.0.0.1", cfg.Server.Host)
+		assert.Equal(t, "https://winpower.example.com", cfg.WinPower.BaseURL)
+		// Environment variables override every file
+		assert.Equal(t, "debug", cfg.Logging.Level)
+	})
+
+	t.Run("reports the file that fails to parse", func(t *testing.T) {
+		broken := write("broken.yaml", "server: [unclosed")
+
+		loader := NewLoader()
+		loader.SetConfigFiles(base, broken)
+		_, err := loader.Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), broken)
+	})
+
+	t.Run("reports a missing file", func(t *testing.T) {
+		missing := filepath.Join(dir, "missing.yaml")
+
+		loader := NewLoader()
+		loader.SetConfigFiles(base, missing)
+		_, err := loader.Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), missing)
+	})
+}
+
 func TestLoader_Get(t *testing.T) {
 	loader := NewLoader()
 	loader.Set("test.key", "test_value")
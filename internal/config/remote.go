@@ -0,0 +1,98 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RemoteTimeout 获取远程配置的超时时间
+const RemoteTimeout = 10 * time.Second
+
+// maxRemoteConfigBytes 远程配置的最大长度，防止异常响应占用过多内存
+const maxRemoteConfigBytes = 1 << 20
+
+// RemoteSource 远程配置来源
+type RemoteSource struct {
+	// URL 配置服务地址，以 GET 请求获取 YAML（或 JSON）格式的配置
+	URL string
+
+	// Header 随请求发送的认证头，格式为 "Name: value"（如 "Authorization: Bearer xxx"），为空时不发送
+	Header string
+}
+
+// RemoteError 远程配置获取失败，指明配置地址以及 HTTP 状态码（请求未得到响应时为 0）
+type RemoteError struct {
+	// URL 配置地址，其中的用户密码已隐藏
+	URL string
+
+	// StatusCode 配置服务返回的非 2xx 状态码
+	StatusCode int
+
+	// Err 底层错误
+	Err error
+}
+
+// Error 实现 error 接口
+func (e *RemoteError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("fetch remote config from %s: unexpected status %d %s",
+			e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("fetch remote config from %s: %v", e.URL, e.Err)
+}
+
+// Unwrap 返回底层错误，支持 errors.Is 和 errors.As
+func (e *RemoteError) Unwrap() error {
+	return e.Err
+}
+
+// fetchRemoteConfig 从远程配置来源获取配置内容
+func fetchRemoteConfig(ctx context.Context, client *http.Client, source *RemoteSource) ([]byte, error) {
+	display := source.URL
+	if u, err := url.Parse(source.URL); err == nil {
+		display = u.Redacted()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, RemoteTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source.URL, nil)
+	if err != nil {
+		return nil, &RemoteError{URL: display, Err: err}
+	}
+	if source.Header != "" {
+		name, value, ok := strings.Cut(source.Header, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, &RemoteError{URL: display, Err: errors.New(`invalid header, expected "Name: value"`)}
+		}
+		req.Header.Set(name, strings.TrimSpace(value))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &RemoteError{URL: display, Err: err}
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &RemoteError{URL: display, StatusCode: resp.StatusCode}
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteConfigBytes+1))
+	if err != nil {
+		return nil, &RemoteError{URL: display, Err: err}
+	}
+	if len(content) > maxRemoteConfigBytes {
+		return nil, &RemoteError{URL: display, Err: fmt.Errorf("config exceeds %d bytes", maxRemoteConfigBytes)}
+	}
+	return content, nil
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoader_Load_RemoteConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("server:\n  port: 9191\nwinpower:\n  base_url: https://winpower.example.com\n"))
+	}))
+	defer server.Close()
+
+	t.Setenv("WINPOWER_EXPORTER_LOGGING_LEVEL", "debug")
+
+	loader := NewLoader()
+	loader.SetRemoteSource(&RemoteSource{URL: server.URL, Header: "Authorization: Bearer secret"})
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+	assert.NoError(t, loader.RemoteError())
+
+	// The remote config is merged with defaults and environment variables
+	assert.Equal(t, 9191, cfg.Server.Port)
+	assert.Equal(t, "https://winpower.example.com", cfg.WinPower.BaseURL)
+	assert.Equal(t, "debug", cfg.Logging.Level)
+	assert.Equal(t, "0.0.0.0", cfg.Server.Host)
+}
+
+func TestLoader_Load_RemoteConfigFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: 9292\n"), 0600))
+
+	loader := NewLoader()
+	loader.SetConfigFile(path)
+	loader.SetRemoteSource(&RemoteSource{URL: server.URL})
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 9292, cfg.Server.Port)
+
+	var remoteErr *RemoteError
+	require.True(t, errors.As(loader.RemoteError(), &remoteErr))
+	assert.Equal(t, http.StatusServiceUnavailable, remoteErr.StatusCode)
+	assert.Contains(t, remoteErr.Error(), server.URL)
+	assert.Contains(t, remoteErr.Error(), "503 Service Unavailable")
+}
+
+func TestLoader_Load_RemoteConfigUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	// Without a local config file the remote error is returned
+	loader := NewLoader()
+	loader.SetRemoteSource(&RemoteSource{URL: server.URL + "/exporter.yaml"})
+	_, err := loader.Load()
+	require.Error(t, err)
+
+	var remoteErr *RemoteError
+	require.True(t, errors.As(err, &remoteErr))
+	assert.Equal(t, http.StatusNotFound, remoteErr.StatusCode)
+	assert.Contains(t, err.Error(), server.URL+"/exporter.yaml")
+	assert.NoError(t, loader.RemoteError())
+}
+
+func TestFetchRemoteConfig_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("server: {}\n"))
+	}))
+	defer server.Close()
+	client := server.Client()
+
+	_, err := fetchRemoteConfig(t.Context(), client, &RemoteSource{URL: server.URL, Header: "Bearer secret"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid header")
+	assert.NotContains(t, err.Error(), "secret")
+
+	// Credentials in the URL are not reported
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	_, err = fetchRemoteConfig(t.Context(), client, &RemoteSource{URL: "http://user:pass@" + closed.Listener.Addr().String()})
+	require.Error(t, err)
+	var remoteErr *RemoteError
+	require.True(t, errors.As(err, &remoteErr))
+	assert.Zero(t, remoteErr.StatusCode)
+	assert.NotContains(t, remoteErr.URL, "pass")
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/energy"
+	"github.com/lay-g/winpower-g2-exporter/internal/metrics"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/signals"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/tlsconfig"
+	"github.com/lay-g/winpower-g2-exporter/internal/server"
+	"github.com/lay-g/winpower-g2-exporter/internal/winpower"
+	"github.com/spf13/viper"
+)
+
+// durationPattern 匹配 time.ParseDuration 可解析的时长字符串，如 "5s"、"1m30s"
+const durationPattern = `^(0|-?([0-9]+(\.[0-9]*)?(ns|us|µs|ms|s|m|h))+)$`
+
+// schemaRequired 配置文件中必须提供的字段，按所在对象的路径索引
+var schemaRequired = map[string][]string{
+	"":         {"winpower"},
+	"winpower": {"base_url", "username", "password"},
+}
+
+// schemaEnums 返回各配置项的可选值，与模块校验逻辑使用同一来源
+// 对数组类型的配置项，可选值作用于数组元素
+func schemaEnums() map[string][]string {
+	return map[string][]string{
+		"logging.level":                 log.Levels,
+		"logging.format":                log.Formats,
+		"logging.output":                log.Outputs,
+		"energy.source":                 energy.Sources,
+		"energy.power_reading":          energy.PowerReadings,
+		"metrics.invalid_value_mode":    metrics.InvalidValueModes,
+		"winpower.startup_failure_mode": winpower.StartupFailureModes,
+		"server.tls_min_version":        tlsconfig.VersionNames(),
+		"server.tls_cipher_suites":      tlsconfig.CipherSuiteNames(),
+		"server.listeners.routes":       server.RouteNames(),
+		"winpower.tls_min_version":      tlsconfig.VersionNames(),
+		"winpower.tls_cipher_suites":    tlsconfig.CipherSuiteNames(),
+		"winpower.signing.algorithm":    winpower.SigningAlgorithms(),
+		"signals.actions":               signals.Actions,
+	}
+}
+
+// Schema 通过反射 Config 结构体生成描述配置文件的 JSON Schema（draft-07），
+// 供编辑器进行校验与自动补全。字段名取自 yaml 标签，默认值取自加载器的默认配置，
+// 可选值来自各模块校验使用的取值列表以及 validate 标签（oneof、min、max）
+func Schema() map[string]interface{} {
+	// 使用独立的 viper 实例读取默认值，避免环境变量影响输出
+	defaults := &Loader{viper: viper.New()}
+	defaults.setDefaults()
+
+	g := &schemaGenerator{
+		defaults: defaults.viper,
+		enums:    schemaEnums(),
+	}
+
+	schema := g.object("", reflect.TypeOf(Config{}))
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "WinPower G2 Exporter configuration"
+	return schema
+}
+
+// schemaGenerator 按类型递归生成 JSON Schema
+type schemaGenerator struct {
+	defaults *viper.Viper
+	enums    map[string][]string
+}
+
+// object 生成结构体对应的 object schema
+func (g *schemaGenerator) object(path string, t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := yamlName(field)
+		if name == "" {
+			continue
+		}
+		key := name
+		if path != "" {
+			key = path + "." + name
+		}
+
+		property := g.property(key, field.Type)
+		applyValidateTag(property, field.Tag.Get("validate"))
+		if _, nested := property["properties"]; !nested && g.defaults.IsSet(key) {
+			if value := schemaDefault(field.Type, g.defaults.Get(key)); value != nil {
+				property["default"] = value
+			}
+		}
+		properties[name] = property
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if required, ok := schemaRequired[path]; ok {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// property 生成单个配置项的 schema
+func (g *schemaGenerator) property(path string, t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return map[string]interface{}{
+			"type":    "string",
+			"pattern": durationPattern,
+		}
+	}
+
+	var schema map[string]interface{}
+	switch t.Kind() {
+	case reflect.Struct:
+		return g.object(path, t)
+	case reflect.Slice, reflect.Array:
+		items := g.property(path, t.Elem())
+		if enum, ok := g.enums[path]; ok {
+			items["enum"] = enum
+		}
+		return map[string]interface{}{
+			"type":  "array",
+			"items": items,
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": g.property(path, t.Elem()),
+		}
+	case reflect.Bool:
+		schema = map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		schema = map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema = map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		schema = map[string]interface{}{"type": "number"}
+	default:
+		schema = map[string]interface{}{"type": "string"}
+	}
+
+	if enum, ok := g.enums[path]; ok {
+		schema["enum"] = enum
+	}
+	return schema
+}
+
+// applyValidateTag 将 validate 标签中的 oneof、min、max 约束转换为 schema 约束
+// 仅对字符串（oneof）和整数（min、max）生效，时长等其他约束忽略
+func applyValidateTag(schema map[string]interface{}, tag string) {
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, ok := strings.Cut(rule, "=")
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "oneof" && schema["type"] == "string":
+			schema["enum"] = strings.Fields(arg)
+		case (name == "min" || name == "max") && schema["type"] == "integer":
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				continue
+			}
+			if name == "min" {
+				schema["minimum"] = n
+			} else {
+				schema["maximum"] = n
+			}
+		}
+	}
+}
+
+// schemaDefault 将默认值转换为配置文件中的写法，时长使用字符串形式
+func schemaDefault(t reflect.Type, value interface{}) interface{} {
+	if t != reflect.TypeOf(time.Duration(0)) {
+		return value
+	}
+	switch v := value.(type) {
+	case time.Duration:
+		return v.String()
+	case int:
+		return time.Duration(v).String()
+	}
+	return value
+}
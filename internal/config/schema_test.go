@@ -0,0 +1,152 @@
+package config
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// schemaProperty 按 "a.b.c" 路径查找 schema 中的配置项
+func schemaProperty(t *testing.T, schema map[string]interface{}, path string) map[string]interface{} {
+	t.Helper()
+	current := schema
+	for _, name := range strings.Split(path, ".") {
+		properties, ok := current["properties"].(map[string]interface{})
+		require.True(t, ok, "%s: parent of %q is not an object", path, name)
+		current, ok = properties[name].(map[string]interface{})
+		require.True(t, ok, "%s: property %q not found", path, name)
+	}
+	return current
+}
+
+func TestSchema(t *testing.T) {
+	t.Setenv("WINPOWER_EXPORTER_SERVER_PORT", "9191")
+	schema := Schema()
+
+	// The schema is valid JSON
+	_, err := json.Marshal(schema)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"winpower"}, schema["required"])
+	assert.Equal(t, []string{"base_url", "username", "password"}, schemaProperty(t, schema, "winpower")["required"])
+
+	tests := []struct {
+		path string
+		want map[string]interface{}
+	}{
+		{"server.port", map[string]interface{}{"type": "integer", "default": 9090, "minimum": 1, "maximum": 65535}},
+		{"server.mode", map[string]interface{}{"type": "string", "default": "release", "enum": []string{"debug", "release", "test"}}},
+		{"logging.level", map[string]interface{}{"type": "string", "default": "info", "enum": []string{"debug", "info", "warn", "error", "fatal"}}},
+		{"energy.source", map[string]interface{}{"type": "string", "default": "power", "enum": []string{"power", "device"}}},
+		{"scheduler.collection_interval", map[string]interface{}{"type": "string", "default": "5s", "pattern": durationPattern}},
+		{"winpower.idle_close_timeout", map[string]interface{}{"type": "string", "default": "0s", "pattern": durationPattern}},
+		{"winpower.signing.algorithm", map[string]interface{}{"type": "string", "default": "hmac-sha256", "enum": []string{"hmac-sha256", "hmac-sha512"}}},
+		{"winpower.field_map", map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, schemaProperty(t, schema, tt.path))
+		})
+	}
+
+	t.Run("array items", func(t *testing.T) {
+		routes := schemaProperty(t, schema, "server.listeners")["items"].(map[string]interface{})
+		items := schemaProperty(t, routes, "routes")["items"].(map[string]interface{})
+		assert.Contains(t, items["enum"], "metrics")
+
+		suites := schemaProperty(t, schema, "winpower.tls_cipher_suites")["items"].(map[string]interface{})
+		assert.Contains(t, suites["enum"], "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256")
+	})
+}
+
+func TestSchema_CoversExampleConfig(t *testing.T) {
+	v := viper.New()
+	v.SetConfigFile("../../config/config.example.yaml")
+	require.NoError(t, v.ReadInConfig())
+
+	schema := Schema()
+	for _, key := range v.AllKeys() {
+		// Keys inside map-valued options (e.g. field_map) are free-form
+		if strings.HasPrefix(key, "winpower.field_map.") || strings.HasPrefix(key, "metrics.device_type_prefixes.") ||
+			strings.HasPrefix(key, "signals.actions.") || strings.HasPrefix(key, "shutdown.module_timeouts.") {
+			continue
+		}
+		schemaProperty(t, schema, key)
+	}
+}
+
+// schemaStringFields 返回 schema 中既无 enum 也无 pattern 的字符串配置项路径
+// （数组配置项按元素类型判断），不含数组对象内部的配置项
+func schemaStringFields(path string, schema map[string]interface{}, fields map[string]bool) {
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		for name, property := range properties {
+			key := name
+			if path != "" {
+				key = path + "." + name
+			}
+			schemaStringFields(key, property.(map[string]interface{}), fields)
+		}
+		return
+	}
+
+	array := schema["type"] == "array"
+	if array {
+		schema = schema["items"].(map[string]interface{})
+		if _, ok := schema["properties"]; ok {
+			return
+		}
+	}
+	_, hasEnum := schema["enum"]
+	_, hasPattern := schema["pattern"]
+	if schema["type"] == "string" && !hasEnum && !hasPattern {
+		fields[path] = array
+	}
+}
+
+// TestSchema_ValidatedStringsHaveEnum 任一字符串配置项在取任意值时校验失败，
+// 说明其取值受限，schema 中必须给出可选值；按格式而非取值列表校验的配置项在此列出
+func TestSchema_ValidatedStringsHaveEnum(t *testing.T) {
+	freeForm := map[string]string{
+		"winpower.base_url":         "http(s) URL",
+		"winpower.login_path":       "URL path",
+		"winpower.device_data_path": "URL path",
+		"winpower.time_zone":        "IANA time zone name",
+		"winpower.time_layout":      "Go time layout",
+		"metrics.pushgateway_url":   "http(s) URL",
+		"scheduler.cron":            "cron expression",
+		"server.tls_cert_file":      "file path, set together with tls_key_file",
+		"server.tls_key_file":       "file path, set together with tls_cert_file",
+	}
+
+	fields := make(map[string]bool)
+	schemaStringFields("", Schema(), fields)
+	require.NotEmpty(t, fields)
+
+	for path, array := range fields {
+		t.Run(path, func(t *testing.T) {
+			loader := NewLoader()
+			loader.viper.SetConfigFile(filepath.Join("fixtures", "valid_config.yaml"))
+			var value interface{} = "not-a-valid-value"
+			if array {
+				value = []string{"not-a-valid-value"}
+			}
+			loader.Set(path, value)
+
+			cfg, err := loader.Load()
+			if err == nil {
+				err = cfg.Validate()
+			}
+			if err == nil {
+				return
+			}
+			if _, ok := freeForm[path]; !ok {
+				t.Errorf("%s rejects arbitrary values (%v) but has no enum in schemaEnums", path, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package energy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 电能数据来源
+const (
+	// SourcePower 按功率对时间积分计算电能（默认）
+	SourcePower = "power"
+	// SourceDevice 直接使用设备上报的累计电能，并识别计数器复位
+	SourceDevice = "device"
+)
+
+// Sources 可选的电能数据来源
+var Sources = []string{SourcePower, SourceDevice}
+
+// 电能积分使用的功率读数
+const (
+	// PowerReadingInstant 使用瞬时功率（默认）
+	PowerReadingInstant = "instant"
+	// PowerReadingAverage 优先使用 WinPower 上报的采样区间平均功率，未上报时使用瞬时功率
+	PowerReadingAverage = "average"
+)
+
+// PowerReadings 可选的电能积分功率读数
+var PowerReadings = []string{PowerReadingInstant, PowerReadingAverage}
+
+// Config 电能模块配置
+type Config struct {
+	// Source 电能数据来源: power 或 device
+	// device 需要在 winpower.field_map 中映射 energy_total_wh 字段，
+	// 设备计数器回退（如设备重启）时以新读数作为基准继续累计，导出值保持单调递增
+	// 默认: power
+	Source string `yaml:"source" mapstructure:"source"`
+
+	// MinPowerWatts 最小积分功率(W)，仅在 source 为 power 时生效
+	// 低于该值的功率读数在电能积分时视为0，用于过滤待机噪声
+	// 仅影响电能累计，不影响功率指标
+	// 默认: 0（不过滤）
+	MinPowerWatts float64 `yaml:"min_power_watts" mapstructure:"min_power_watts"`
+
+	// PowerReading 电能积分使用的功率读数: instant 或 average，仅在 source 为 power 时生效
+	// average 需要在 winpower.field_map 中映射 load_avg_watt 字段，设备上报区间平均功率时
+	// 按平均功率积分，更准确地反映波动负载；未上报该值的采样仍使用瞬时功率
+	// 默认: instant
+	PowerReading string `yaml:"power_reading" mapstructure:"power_reading"`
+
+	// SkipResumeGap 暂停采集恢复后，跳过每台设备的首次积分
+	// 启用时恢复后的首次计算只重置时间基准，不累计暂停期间的电能
+	// 默认: false（按实际经过时间积分，使用恢复后的首个功率读数）
+	SkipResumeGap bool `yaml:"skip_resume_gap" mapstructure:"skip_resume_gap"`
+
+	// MaxIntegrationGap 单次积分允许的最大时间间隔，仅在 source 为 power 时生效
+	// 距设备上次保存的时间超过该值（如设备离线后重新上报）时跳过本次积分，
+	// 只重置时间基准，避免以当前功率累计离线期间并未消耗的电能；建议设为采集间隔的数倍
+	// 默认: 0（不限制）
+	MaxIntegrationGap time.Duration `yaml:"max_integration_gap" mapstructure:"max_integration_gap"`
+
+	// DegradedMode 存储不可用时以内存降级模式继续累计电能
+	// 启用时存储读写失败不再导致计算失败，电能在内存中继续累计（启动时存储
+	// 不可用则从 0 开始），存储恢复后将降级期间的增量合并到已保存的电能上
+	// 默认: false（存储读写失败时计算失败，启动时存储不可写则退出）
+	DegradedMode bool `yaml:"degraded_mode" mapstructure:"degraded_mode"`
+}
+
+// DefaultConfig 返回默认配置
+func DefaultConfig() *Config {
+	return &Config{
+		Source:        SourcePower,
+		MinPowerWatts: 0,
+		PowerReading:  PowerReadingInstant,
+	}
+}
+
+// Validate 验证配置
+func (c *Config) Validate() error {
+	if c.MaxIntegrationGap < 0 {
+		return fmt.Errorf("max_integration_gap must be non-negative, got: %v", c.MaxIntegrationGap)
+	}
+	if c.MinPowerWatts < 0 {
+		return fmt.Errorf("min_power_watts must be non-negative, got: %v", c.MinPowerWatts)
+	}
+	if c.Source != "" && !slices.Contains(Sources, c.Source) {
+		return fmt.Errorf("source must be one of %s, got: %q", strings.Join(Sources, ", "), c.Source)
+	}
+	if c.PowerReading != "" && !slices.Contains(PowerReadings, c.PowerReading) {
+		return fmt.Errorf("power_reading must be one of %s, got: %q", strings.Join(PowerReadings, ", "), c.PowerReading)
+	}
+	return nil
+}
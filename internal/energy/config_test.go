@@ -0,0 +1,33 @@
+package energy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *Config
+		wantErr bool
+	}{
+		{name: "default config", config: DefaultConfig(), wantErr: false},
+		{name: "positive threshold", config: &Config{MinPowerWatts: 5}, wantErr: false},
+		{name: "negative threshold", config: &Config{MinPowerWatts: -1}, wantErr: true},
+		{name: "device source", config: &Config{Source: SourceDevice}, wantErr: false},
+		{name: "unknown source", config: &Config{Source: "meter"}, wantErr: true},
+		{name: "average power reading", config: &Config{PowerReading: PowerReadingAverage}, wantErr: false},
+		{name: "unknown power reading", config: &Config{PowerReading: "peak"}, wantErr: true},
+		{name: "max integration gap", config: &Config{MaxIntegrationGap: time.Minute}, wantErr: false},
+		{name: "negative max integration gap", config: &Config{MaxIntegrationGap: -time.Minute}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
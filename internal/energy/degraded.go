@@ -0,0 +1,159 @@
+package energy
+
+import (
+	"math"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/storage"
+)
+
+// DegradedObserver 接收降级状态变化（由指标模块实现）
+type DegradedObserver interface {
+	// SetEnergyDegraded 设置是否有设备的电能仅保存在内存中
+	SetEnergyDegraded(degraded bool)
+}
+
+// degradedDevice 存储不可用期间在内存中累计的设备电能
+type degradedDevice struct {
+	data  *storage.PowerData // 内存中的最新数据，作为下一次计算的历史数据
+	delta float64            // 相对已保存电能的增量(Wh)，存储恢复后加到已保存的电能上
+}
+
+// batchEntry 批次中暂存的设备数据，提交失败时转入降级状态
+type batchEntry struct {
+	historyEnergy float64            // 计算所用历史数据的电能(Wh)
+	data          *storage.PowerData // 暂存的数据
+}
+
+// SetDegradedObserver 设置降级状态观察者，nil 表示不上报
+func (es *EnergyService) SetDegradedObserver(observer DegradedObserver) {
+	es.degradedMu.Lock()
+	defer es.degradedMu.Unlock()
+	es.observer = observer
+}
+
+// IsDegraded 是否有设备的电能仅保存在内存中（启用 DegradedMode 且存储不可用）
+func (es *EnergyService) IsDegraded() bool {
+	es.degradedMu.Lock()
+	defer es.degradedMu.Unlock()
+	return len(es.degraded) > 0
+}
+
+// memoryHistory 存储读取失败时使用的历史数据：降级中的内存数据，
+// 否则为最近一次成功保存的数据；都没有时返回 nil，从 0 开始累计
+func (es *EnergyService) memoryHistory(deviceID string) *storage.PowerData {
+	es.degradedMu.Lock()
+	defer es.degradedMu.Unlock()
+
+	if entry, ok := es.degraded[deviceID]; ok {
+		return entry.data
+	}
+	return es.known[deviceID]
+}
+
+// memoryData 返回降级中设备的内存数据
+func (es *EnergyService) memoryData(deviceID string) (*storage.PowerData, bool) {
+	es.degradedMu.Lock()
+	defer es.degradedMu.Unlock()
+
+	entry, ok := es.degraded[deviceID]
+	if !ok {
+		return nil, false
+	}
+	return entry.data, true
+}
+
+// reconcile 存储恢复读取后，将降级期间的增量合并到已保存的电能上，
+// 返回用于本次计算的历史数据；设备未降级时原样返回
+func (es *EnergyService) reconcile(deviceID string, persisted *storage.PowerData) *storage.PowerData {
+	es.degradedMu.Lock()
+	defer es.degradedMu.Unlock()
+
+	entry, ok := es.degraded[deviceID]
+	if !ok {
+		return persisted
+	}
+
+	base := 0.0
+	if persisted != nil {
+		base = persisted.EnergyWH
+	}
+	return &storage.PowerData{
+		Timestamp:      entry.data.Timestamp,
+		EnergyWH:       math.Round((base+entry.delta)*100) / 100,
+		DeviceEnergyWH: entry.data.DeviceEnergyWH,
+		ResetEpoch:     entry.data.ResetEpoch,
+	}
+}
+
+// markDegraded 保存失败时将设备数据保存在内存中，累计相对已保存电能的增量
+func (es *EnergyService) markDegraded(deviceID string, historyEnergy float64, data *storage.PowerData) {
+	es.degradedMu.Lock()
+	defer es.degradedMu.Unlock()
+
+	entry, ok := es.degraded[deviceID]
+	if !ok {
+		if es.degraded == nil {
+			es.degraded = make(map[string]*degradedDevice)
+		}
+		entry = &degradedDevice{}
+		es.degraded[deviceID] = entry
+		es.logger.Warn("Storage unavailable, accumulating energy in memory",
+			log.String("device_id", deviceID))
+	}
+	entry.delta += data.EnergyWH - historyEnergy
+	entry.data = data
+
+	if !ok && len(es.degraded) == 1 && es.observer != nil {
+		es.observer.SetEnergyDegraded(true)
+	}
+}
+
+// markPersisted 记录成功保存的数据；设备处于降级状态时结束降级
+func (es *EnergyService) markPersisted(deviceID string, data *storage.PowerData) {
+	if !es.config.DegradedMode {
+		return
+	}
+
+	es.degradedMu.Lock()
+	defer es.degradedMu.Unlock()
+
+	if es.known == nil {
+		es.known = make(map[string]*storage.PowerData)
+	}
+	es.known[deviceID] = data
+
+	if _, ok := es.degraded[deviceID]; !ok {
+		return
+	}
+	delete(es.degraded, deviceID)
+	es.logger.Info("Storage recovered, energy reconciled with persisted data",
+		log.String("device_id", deviceID),
+		log.Float64("energy_wh", data.EnergyWH))
+
+	if len(es.degraded) == 0 && es.observer != nil {
+		es.observer.SetEnergyDegraded(false)
+	}
+}
+
+// forgetDegraded 丢弃设备的降级状态（如电能清零后）
+func (es *EnergyService) forgetDegraded(deviceID string) {
+	es.degradedMu.Lock()
+	defer es.degradedMu.Unlock()
+
+	if _, ok := es.degraded[deviceID]; !ok {
+		return
+	}
+	delete(es.degraded, deviceID)
+	if len(es.degraded) == 0 && es.observer != nil {
+		es.observer.SetEnergyDegraded(false)
+	}
+}
+
+// historyEnergy 返回历史数据的电能，无历史数据时为 0
+func historyEnergy(historyData *storage.PowerData) float64 {
+	if historyData == nil {
+		return 0
+	}
+	return historyData.EnergyWH
+}
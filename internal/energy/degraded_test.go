@@ -0,0 +1,172 @@
+package energy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/energy/mocks"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/storage"
+)
+
+// degradedRecorder 记录降级状态变化
+type degradedRecorder struct {
+	states []bool
+}
+
+func (r *degradedRecorder) SetEnergyDegraded(degraded bool) {
+	r.states = append(r.states, degraded)
+}
+
+// failingStorage 使存储读写失败，返回恢复函数
+func failingStorage(m *mocks.MockStorage) (restore func()) {
+	storageErr := errors.New("disk unavailable")
+	m.ReadFunc = func(string) (*storage.PowerData, error) { return nil, storageErr }
+	m.WriteFunc = func(string, *storage.PowerData) error { return storageErr }
+	return func() {
+		m.ReadFunc = nil
+		m.WriteFunc = nil
+	}
+}
+
+func TestEnergyService_DegradedMode(t *testing.T) {
+	ctx := context.Background()
+	deviceID := "ups-001"
+
+	calculate := func(t *testing.T, service *EnergyService, reading, want float64) {
+		t.Helper()
+		energy, err := service.CalculateFromDevice(ctx, deviceID, reading)
+		if err != nil {
+			t.Fatalf("Reading %v: unexpected error: %v", reading, err)
+		}
+		if energy != want {
+			t.Errorf("Reading %v: expected energy %v, got %v", reading, want, energy)
+		}
+	}
+
+	t.Run("Continues from the last saved energy and reconciles", func(t *testing.T) {
+		mockStorage := mocks.NewMockStorage()
+		service := NewEnergyServiceWithConfig(mockStorage, log.NewTestLogger(),
+			&Config{Source: SourceDevice, DegradedMode: true})
+		recorder := &degradedRecorder{}
+		service.SetDegradedObserver(recorder)
+
+		calculate(t, service, 1000, 0)
+		calculate(t, service, 1010, 10)
+
+		restore := failingStorage(mockStorage)
+		calculate(t, service, 1030, 30)
+		calculate(t, service, 1050, 50)
+		if !service.IsDegraded() {
+			t.Error("Expected service to be degraded")
+		}
+		if energy, err := service.Get(deviceID); err != nil || energy != 50 {
+			t.Errorf("Expected in-memory energy 50, got %v (%v)", energy, err)
+		}
+
+		restore()
+		calculate(t, service, 1060, 60)
+		if service.IsDegraded() {
+			t.Error("Expected service to recover")
+		}
+		if data := mockStorage.GetData()[deviceID]; data.EnergyWH != 60 {
+			t.Errorf("Expected reconciled energy 60 to be saved, got %v", data.EnergyWH)
+		}
+		if len(recorder.states) != 2 || !recorder.states[0] || recorder.states[1] {
+			t.Errorf("Expected degraded then recovered, got %v", recorder.states)
+		}
+	})
+
+	t.Run("Starts from zero and adds to the persisted energy", func(t *testing.T) {
+		mockStorage := mocks.NewMockStorage()
+		service := NewEnergyServiceWithConfig(mockStorage, log.NewTestLogger(),
+			&Config{Source: SourceDevice, DegradedMode: true})
+
+		restore := failingStorage(mockStorage)
+		calculate(t, service, 1000, 0)
+		calculate(t, service, 1005, 5)
+
+		// Storage comes back with energy saved before the outage
+		restore()
+		reading := 900.0
+		_ = mockStorage.Write(deviceID, &storage.PowerData{EnergyWH: 100, DeviceEnergyWH: &reading})
+		calculate(t, service, 1007, 107)
+	})
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		mockStorage := mocks.NewMockStorage()
+		service := NewEnergyService(mockStorage, log.NewTestLogger())
+		failingStorage(mockStorage)
+
+		if _, err := service.Calculate(deviceID, 100); !errors.Is(err, ErrStorageRead) {
+			t.Errorf("Expected ErrStorageRead, got %v", err)
+		}
+		if service.IsDegraded() {
+			t.Error("Expected service not to be degraded")
+		}
+	})
+}
+
+func TestEnergyService_DegradedMode_BatchCommit(t *testing.T) {
+	logger := log.NewTestLogger()
+	dataDir := t.TempDir()
+	store, err := storage.NewFileStorageManager(&storage.Config{
+		DataDir:         dataDir,
+		FilePermissions: 0644,
+		BatchWrite:      true,
+	}, logger)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	service := NewEnergyServiceWithConfig(store, logger, &Config{Source: SourceDevice, DegradedMode: true})
+
+	collect := func(reading float64) error {
+		ctx, commit := service.BeginBatch(context.Background())
+		if _, err := service.CalculateFromDevice(ctx, "ups-001", reading); err != nil {
+			return err
+		}
+		return commit()
+	}
+
+	if err := collect(1000); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Replace the data directory with a file so that reads and writes fail
+	if err := os.RemoveAll(dataDir); err != nil {
+		t.Fatalf("Failed to remove data dir: %v", err)
+	}
+	if err := os.WriteFile(dataDir, nil, 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	if err := collect(1020); err != nil {
+		t.Fatalf("Expected failed commit to be absorbed, got %v", err)
+	}
+	if !service.IsDegraded() {
+		t.Fatal("Expected service to be degraded after a failed commit")
+	}
+	if data, err := service.GetData("ups-001"); err != nil || data.EnergyWH != 20 {
+		t.Errorf("Expected in-memory energy 20, got %+v (%v)", data, err)
+	}
+
+	// Restore the directory, emptied by the outage
+	if err := os.Remove(dataDir); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+	if err := os.Mkdir(dataDir, 0755); err != nil {
+		t.Fatalf("Failed to restore data dir: %v", err)
+	}
+
+	if err := collect(1030); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if service.IsDegraded() {
+		t.Error("Expected service to recover after a successful commit")
+	}
+	if energy, err := service.Get("ups-001"); err != nil || energy != 30 {
+		t.Errorf("Expected reconciled energy 30, got %v (%v)", energy, err)
+	}
+}
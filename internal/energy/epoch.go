@@ -0,0 +1,30 @@
+package energy
+
+import "github.com/lay-g/winpower-g2-exporter/internal/storage"
+
+// ResetEpoch 返回设备电能的清零纪元，即电能被 Reset 清零的次数
+// 纪元与电能一同保存，随计算、清零和 Adopt 更新；本进程中尚未计算过的设备返回 0
+func (es *EnergyService) ResetEpoch(deviceID string) uint64 {
+	es.epochMu.RLock()
+	defer es.epochMu.RUnlock()
+	return es.epochs[deviceID]
+}
+
+// setEpoch 记录设备最近一次保存的清零纪元
+func (es *EnergyService) setEpoch(deviceID string, epoch uint64) {
+	es.epochMu.Lock()
+	defer es.epochMu.Unlock()
+
+	if es.epochs == nil {
+		es.epochs = make(map[string]uint64)
+	}
+	es.epochs[deviceID] = epoch
+}
+
+// historyEpoch 返回历史数据的清零纪元，无历史数据时为 0
+func historyEpoch(historyData *storage.PowerData) uint64 {
+	if historyData == nil {
+		return 0
+	}
+	return historyData.ResetEpoch
+}
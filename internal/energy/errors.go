@@ -9,6 +9,12 @@ var (
 	// ErrInvalidPower 功率值无效
 	ErrInvalidPower = errors.New("invalid power value")
 
+	// ErrInvalidDeviceEnergy 设备上报的累计电能无效
+	ErrInvalidDeviceEnergy = errors.New("invalid device energy value")
+
+	// ErrDeviceNotFound 设备没有已保存的电能数据
+	ErrDeviceNotFound = errors.New("device not found")
+
 	// ErrStorageRead 存储读取失败
 	ErrStorageRead = errors.New("failed to read data from storage")
 
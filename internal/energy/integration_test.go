@@ -1,6 +1,7 @@
 package energy
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -269,3 +270,63 @@ func TestEnergyService_MultiDevice(t *testing.T) {
 		}
 	})
 }
+
+func TestEnergyService_Integration_BatchWrite(t *testing.T) {
+	tempDir := t.TempDir()
+	logger := log.NewTestLogger()
+
+	storageManager, err := storage.NewFileStorageManager(&storage.Config{
+		DataDir:         tempDir,
+		FilePermissions: 0644,
+		BatchWrite:      true,
+	}, logger)
+	if err != nil {
+		t.Fatalf("Failed to create storage manager: %v", err)
+	}
+
+	service := NewEnergyService(storageManager, logger)
+
+	ctx, commit := service.BeginBatch(context.Background())
+	if commit == nil {
+		t.Fatal("Expected a commit function with batch writes enabled")
+	}
+
+	for _, deviceID := range []string{"ups-1", "ups-2"} {
+		if _, err := service.CalculateContext(ctx, deviceID, 1000); err != nil {
+			t.Fatalf("Calculate %s failed: %v", deviceID, err)
+		}
+	}
+
+	// Nothing is persisted until the batch is committed
+	for _, deviceID := range []string{"ups-1", "ups-2"} {
+		if _, err := os.Stat(filepath.Join(tempDir, deviceID+".txt")); !os.IsNotExist(err) {
+			t.Errorf("Expected no file for %s before commit, got %v", deviceID, err)
+		}
+	}
+
+	if err := commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	for _, deviceID := range []string{"ups-1", "ups-2"} {
+		if _, err := os.Stat(filepath.Join(tempDir, deviceID+".txt")); err != nil {
+			t.Errorf("Expected file for %s after commit, got %v", deviceID, err)
+		}
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		plain, err := storage.NewFileStorageManager(&storage.Config{
+			DataDir:         t.TempDir(),
+			FilePermissions: 0644,
+		}, logger)
+		if err != nil {
+			t.Fatalf("Failed to create storage manager: %v", err)
+		}
+
+		ctx := context.Background()
+		batchCtx, commit := NewEnergyService(plain, logger).BeginBatch(ctx)
+		if commit != nil || batchCtx != ctx {
+			t.Error("Expected no batch when batch writes are disabled")
+		}
+	})
+}
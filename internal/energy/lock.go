@@ -0,0 +1,74 @@
+package energy
+
+import "sync"
+
+// deviceLocks 按设备ID加锁
+// 同一设备的计算串行执行，不同设备的计算互不阻塞；
+// 锁按引用计数管理，无人持有或等待时从表中移除，避免设备ID无限增长
+type deviceLocks struct {
+	mu    sync.Mutex
+	locks map[string]*deviceLock
+}
+
+// deviceLock 单个设备的读写锁及其引用计数（受 deviceLocks.mu 保护）
+type deviceLock struct {
+	sync.RWMutex
+	refs int
+}
+
+// newDeviceLocks 创建设备锁表
+func newDeviceLocks() *deviceLocks {
+	return &deviceLocks{locks: make(map[string]*deviceLock)}
+}
+
+// Lock 获取设备写锁，返回解锁函数
+func (l *deviceLocks) Lock(deviceID string) func() {
+	lock := l.acquire(deviceID)
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		l.release(deviceID, lock)
+	}
+}
+
+// RLock 获取设备读锁，返回解锁函数
+func (l *deviceLocks) RLock(deviceID string) func() {
+	lock := l.acquire(deviceID)
+	lock.RLock()
+	return func() {
+		lock.RUnlock()
+		l.release(deviceID, lock)
+	}
+}
+
+// acquire 取得（必要时创建）设备锁并增加引用计数
+func (l *deviceLocks) acquire(deviceID string) *deviceLock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock, ok := l.locks[deviceID]
+	if !ok {
+		lock = &deviceLock{}
+		l.locks[deviceID] = lock
+	}
+	lock.refs++
+	return lock
+}
+
+// release 减少引用计数，无人使用时移除设备锁
+func (l *deviceLocks) release(deviceID string, lock *deviceLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock.refs--
+	if lock.refs == 0 {
+		delete(l.locks, deviceID)
+	}
+}
+
+// size 返回当前表中的设备锁数量（用于测试）
+func (l *deviceLocks) size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.locks)
+}
@@ -0,0 +1,75 @@
+package energy
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDeviceLocks_SameDeviceSerialized(t *testing.T) {
+	locks := newDeviceLocks()
+
+	unlock := locks.Lock("ups-001")
+
+	acquired := make(chan struct{})
+	go func() {
+		defer close(acquired)
+		locks.Lock("ups-001")()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Expected second lock of the same device to wait")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("Expected second lock to be acquired after unlock")
+	}
+}
+
+func TestDeviceLocks_DifferentDevicesIndependent(t *testing.T) {
+	locks := newDeviceLocks()
+
+	unlock := locks.Lock("ups-001")
+	defer unlock()
+
+	acquired := make(chan struct{})
+	go func() {
+		defer close(acquired)
+		locks.Lock("ups-002")()
+		locks.RLock("ups-003")()
+	}()
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("Expected locks of other devices not to wait")
+	}
+}
+
+func TestDeviceLocks_Cleanup(t *testing.T) {
+	locks := newDeviceLocks()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			deviceID := []string{"ups-001", "ups-002", "ups-003"}[i%3]
+			if i%2 == 0 {
+				locks.Lock(deviceID)()
+			} else {
+				locks.RLock(deviceID)()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if n := locks.size(); n != 0 {
+		t.Errorf("Expected no locks left after release, got %d", n)
+	}
+}
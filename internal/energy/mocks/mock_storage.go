@@ -2,6 +2,7 @@
 package mocks
 
 import (
+	"context"
 	"sync"
 
 	"github.com/lay-g/winpower-g2-exporter/internal/storage"
@@ -34,14 +35,17 @@ func (m *MockStorage) Write(deviceID string, data *storage.PowerData) error {
 	defer m.mutex.Unlock()
 
 	// 复制数据以避免外部修改
-	m.data[deviceID] = &storage.PowerData{
-		Timestamp: data.Timestamp,
-		EnergyWH:  data.EnergyWH,
-	}
+	copied := *data
+	m.data[deviceID] = &copied
 
 	return nil
 }
 
+// ReadAll 读取所有设备电能数据
+func (m *MockStorage) ReadAll(ctx context.Context) (map[string]*storage.PowerData, error) {
+	return m.GetData(), nil
+}
+
 // Read 读取设备电能数据
 func (m *MockStorage) Read(deviceID string) (*storage.PowerData, error) {
 	if m.ReadFunc != nil {
@@ -57,10 +61,8 @@ func (m *MockStorage) Read(deviceID string) (*storage.PowerData, error) {
 	}
 
 	// 返回数据副本
-	return &storage.PowerData{
-		Timestamp: data.Timestamp,
-		EnergyWH:  data.EnergyWH,
-	}, nil
+	copied := *data
+	return &copied, nil
 }
 
 // GetData 获取所有存储的数据（用于测试验证）
@@ -70,10 +72,8 @@ func (m *MockStorage) GetData() map[string]*storage.PowerData {
 
 	result := make(map[string]*storage.PowerData)
 	for k, v := range m.data {
-		result[k] = &storage.PowerData{
-			Timestamp: v.Timestamp,
-			EnergyWH:  v.EnergyWH,
-		}
+		copied := *v
+		result[k] = &copied
 	}
 
 	return result
@@ -1,12 +1,14 @@
 package energy
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"sync"
 	"time"
 
 	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/timing"
 	"github.com/lay-g/winpower-g2-exporter/internal/storage"
 )
 
@@ -14,58 +16,325 @@ import (
 type EnergyService struct {
 	storage storage.StorageManager // 存储接口
 	logger  log.Logger             // 日志器
-	mutex   sync.RWMutex           // 全局读写锁，确保串行执行
+	config  *Config                // 模块配置
+	locks   *deviceLocks           // 按设备加锁，同一设备串行执行，不同设备并发执行
 	stats   *Stats                 // 统计信息
+
+	resumeMu  sync.RWMutex // 保护 resumedAt
+	resumedAt time.Time    // 最近一次采集恢复的时间，启用 SkipResumeGap 时使用
+
+	batchMu sync.Mutex                               // 保护 batches
+	batches map[*storage.Batch]map[string]batchEntry // 进行中（尚未提交）的采集批次及其暂存数据，供 Reset 覆盖暂存数据
+
+	degradedMu sync.Mutex                    // 保护以下降级状态
+	degraded   map[string]*degradedDevice    // 存储不可用期间仅保存在内存中的设备，启用 DegradedMode 时使用
+	known      map[string]*storage.PowerData // 最近一次成功保存的数据，降级开始时作为内存累计的起点
+	observer   DegradedObserver              // 降级状态观察者，可为 nil
+
+	epochMu sync.RWMutex      // 保护 epochs
+	epochs  map[string]uint64 // 各设备最近一次保存的清零纪元
 }
 
-// NewEnergyService 创建电能服务
+// NewEnergyService 创建电能服务（使用默认配置）
 func NewEnergyService(storage storage.StorageManager, logger log.Logger) *EnergyService {
+	return NewEnergyServiceWithConfig(storage, logger, DefaultConfig())
+}
+
+// NewEnergyServiceWithConfig 使用指定配置创建电能服务
+// cfg 为 nil 时使用默认配置
+func NewEnergyServiceWithConfig(storage storage.StorageManager, logger log.Logger, cfg *Config) *EnergyService {
 	if storage == nil {
 		panic("storage manager cannot be nil")
 	}
 	if logger == nil {
 		panic("logger cannot be nil")
 	}
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
 
 	return &EnergyService{
 		storage: storage,
 		logger:  logger,
+		config:  cfg,
+		locks:   newDeviceLocks(),
 		stats: &Stats{
 			LastUpdateTime: time.Now(),
 		},
 	}
 }
 
-// Calculate 计算电能（对外接口，串行执行）
+// Calculate 计算电能（对外接口，同一设备串行执行）
 func (es *EnergyService) Calculate(deviceID string, power float64) (float64, error) {
+	return es.CalculateContext(context.Background(), deviceID, power)
+}
+
+// CalculateContext 计算电能，并将计算与存储写入耗时记录到 ctx 中的 timing.Recorder
+func (es *EnergyService) CalculateContext(ctx context.Context, deviceID string, power float64) (float64, error) {
+	return es.calculate(ctx, deviceID, log.Float64("power", power),
+		func(historyData *storage.PowerData) (float64, *float64, error) {
+			totalEnergy, err := es.calculateTotalEnergy(deviceID, historyData, power, time.Now())
+			return totalEnergy, nil, err
+		})
+}
+
+// UsesDeviceEnergy 是否使用设备上报的累计电能（source 为 device）
+func (es *EnergyService) UsesDeviceEnergy() bool {
+	return es.config.Source == SourceDevice
+}
+
+// UsesAveragePower 是否优先使用设备上报的区间平均功率积分（source 为 power 且 power_reading 为 average）
+func (es *EnergyService) UsesAveragePower() bool {
+	return es.config.Source != SourceDevice && es.config.PowerReading == PowerReadingAverage
+}
+
+// MinPowerWatts 返回最小积分功率(W)，低于该值的读数按0积分；source 为 device 时不生效，返回0
+func (es *EnergyService) MinPowerWatts() float64 {
+	if es.config.Source == SourceDevice {
+		return 0
+	}
+	return es.config.MinPowerWatts
+}
+
+// CalculateFromDevice 根据设备上报的累计电能读数(Wh)计算导出电能
+// 读数小于上次读数时视为设备计数器复位，新读数作为继续累计的基准
+func (es *EnergyService) CalculateFromDevice(ctx context.Context, deviceID string, deviceEnergyWh float64) (float64, error) {
+	if math.IsNaN(deviceEnergyWh) || math.IsInf(deviceEnergyWh, 0) || deviceEnergyWh < 0 {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidDeviceEnergy, deviceEnergyWh)
+	}
+
+	return es.calculate(ctx, deviceID, log.Float64("device_energy_wh", deviceEnergyWh),
+		func(historyData *storage.PowerData) (float64, *float64, error) {
+			return es.calculateDeviceEnergy(deviceID, historyData, deviceEnergyWh), &deviceEnergyWh, nil
+		})
+}
+
+// BeginBatch 开始一个采集周期的批量写入
+// 返回的 ctx 传给本周期的电能计算，写入会暂存到批次中，调用 commit 后统一持久化
+// 存储未启用批量写入时返回原 ctx，commit 为 nil
+func (es *EnergyService) BeginBatch(ctx context.Context) (context.Context, func() error) {
+	batchStorage, ok := es.storage.(storage.BatchStorage)
+	if !ok {
+		return ctx, nil
+	}
+
+	batch := batchStorage.NewBatch()
+	if batch == nil {
+		return ctx, nil
+	}
+
+	// 登记进行中的批次，提交（无论成功与否）后移除
+	es.batchMu.Lock()
+	if es.batches == nil {
+		es.batches = make(map[*storage.Batch]map[string]batchEntry)
+	}
+	es.batches[batch] = make(map[string]batchEntry)
+	es.batchMu.Unlock()
+
+	commit := func() error {
+		err := batch.Commit()
+
+		es.batchMu.Lock()
+		entries := es.batches[batch]
+		delete(es.batches, batch)
+		es.batchMu.Unlock()
+
+		if err == nil {
+			for deviceID, entry := range entries {
+				es.markPersisted(deviceID, entry.data)
+			}
+			return nil
+		}
+		if !es.config.DegradedMode {
+			return err
+		}
+
+		// 降级模式：提交失败的数据保存在内存中，存储恢复后再合并
+		es.logger.Warn("Failed to commit energy batch, keeping energy in memory",
+			log.Int("devices", len(entries)),
+			log.Err(err))
+		for deviceID, entry := range entries {
+			es.markDegraded(deviceID, entry.historyEnergy, entry.data)
+		}
+		return nil
+	}
+
+	return storage.WithBatch(ctx, batch), commit
+}
+
+// Reset 将设备的累计电能清零（如更换硬件后），返回清零前后的数据
+// 持有设备写锁，与该设备的计算串行执行；设备从未保存过电能数据时返回 ErrDeviceNotFound
+//
+// 清零值会先写入进行中的采集批次，再直接写入存储，避免批次提交时
+// 用清零前计算出的暂存值覆盖清零结果。设备上报电能的读数基准保持不变，
+// 下一次计算从清零后的值继续累计。每次清零将设备的清零纪元加 1，
+// 纪元与电能一同保存，供指标区分有意清零与数据回退
+func (es *EnergyService) Reset(deviceID string) (before, after *storage.PowerData, err error) {
+	if deviceID == "" {
+		return nil, nil, ErrInvalidDeviceID
+	}
+
+	unlock := es.locks.Lock(deviceID)
+	defer unlock()
+
+	// 区分未知设备与零电能设备，未知设备不创建数据
+	if checker, ok := es.storage.(storage.DeviceChecker); ok {
+		exists, err := checker.Exists(deviceID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrStorageRead, err)
+		}
+		if !exists {
+			return nil, nil, ErrDeviceNotFound
+		}
+	}
+
+	before, err = es.loadHistoryData(deviceID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrStorageRead, err)
+	}
+	if before == nil {
+		return nil, nil, ErrDeviceNotFound
+	}
+
+	after = &storage.PowerData{
+		Timestamp:      time.Now().UnixMilli(),
+		EnergyWH:       0,
+		DeviceEnergyWH: before.DeviceEnergyWH,
+		ResetEpoch:     before.ResetEpoch + 1,
+	}
+
+	// 先覆盖进行中批次的暂存数据：批次若正在提交，Write 会等待提交完成，
+	// 随后的直接写入保证存储中最终为清零值
+	es.batchMu.Lock()
+	for batch, entries := range es.batches {
+		if err := batch.Write(deviceID, after); err != nil {
+			es.batchMu.Unlock()
+			return nil, nil, fmt.Errorf("%w: %w", ErrStorageWrite, err)
+		}
+		delete(entries, deviceID)
+	}
+	es.batchMu.Unlock()
+
+	if err := es.storage.Write(deviceID, after); err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrStorageWrite, err)
+	}
+	es.forgetDegraded(deviceID)
+	es.markPersisted(deviceID, after)
+	es.setEpoch(deviceID, after.ResetEpoch)
+
+	es.logger.Info("Energy reset",
+		log.String("device_id", deviceID),
+		log.Float64("before_wh", before.EnergyWH),
+		log.Any("reset_epoch", after.ResetEpoch),
+	)
+
+	return before, after, nil
+}
+
+// Adopt 在 key 尚无电能数据时，将设备以 deviceID 保存的电能复制到 key 下，
+// 用于改为按稳定标识（如硬件序列号）累计电能时延续已有的累计值；返回是否发生复制
+// key 已有数据或 deviceID 无数据时不做任何操作。deviceID 下的原数据保留不删除
+func (es *EnergyService) Adopt(key, deviceID string) (bool, error) {
+	if key == "" || deviceID == "" {
+		return false, ErrInvalidDeviceID
+	}
+	if key == deviceID {
+		return false, nil
+	}
+
+	unlock := es.locks.Lock(key)
+	defer unlock()
+
+	current, err := es.storedData(key)
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrStorageRead, err)
+	}
+	if current != nil {
+		return false, nil
+	}
+
+	previous, err := es.storedData(deviceID)
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrStorageRead, err)
+	}
+	if previous == nil {
+		return false, nil
+	}
+
+	if err := es.storage.Write(key, previous); err != nil {
+		return false, fmt.Errorf("%w: %w", ErrStorageWrite, err)
+	}
+	es.markPersisted(key, previous)
+	es.setEpoch(key, previous.ResetEpoch)
+
+	es.logger.Info("Energy adopted",
+		log.String("key", key),
+		log.String("device_id", deviceID),
+		log.Float64("energy_wh", previous.EnergyWH),
+	)
+
+	return true, nil
+}
+
+// storedData 返回设备已保存的数据，从未保存过时返回 nil（内部方法）
+// 存储支持 storage.DeviceChecker 时据此区分未知设备，否则依据读取结果
+func (es *EnergyService) storedData(deviceID string) (*storage.PowerData, error) {
+	if checker, ok := es.storage.(storage.DeviceChecker); ok {
+		exists, err := checker.Exists(deviceID)
+		if err != nil || !exists {
+			return nil, err
+		}
+	}
+	return es.loadHistoryData(deviceID)
+}
+
+// calculate 加载历史数据、计算累计电能并保存（内部方法）
+// 持有设备写锁，同一设备的读取-计算-保存过程不会交错
+// compute 返回新的累计电能以及需要保存的设备读数基准
+func (es *EnergyService) calculate(
+	ctx context.Context,
+	deviceID string,
+	input log.Field,
+	compute func(historyData *storage.PowerData) (float64, *float64, error),
+) (float64, error) {
 	// 参数验证
 	if deviceID == "" {
 		return 0, ErrInvalidDeviceID
 	}
 
-	// 获取全局写锁（确保串行执行）
-	es.mutex.Lock()
-	defer es.mutex.Unlock()
+	// 获取设备写锁（同一设备串行执行）
+	unlock := es.locks.Lock(deviceID)
+	defer unlock()
 
 	start := time.Now()
 	logger := es.logger.With(
 		log.String("device_id", deviceID),
-		log.Float64("power", power),
+		input,
 	)
 
 	logger.Debug("Starting energy calculation")
 
+	stopCalc := timing.Track(ctx, timing.StageEnergy)
+
 	// 加载历史数据
 	historyData, err := es.loadHistoryData(deviceID)
-	if err != nil {
+	switch {
+	case err != nil && es.config.DegradedMode:
+		// 降级模式：使用内存中的数据继续累计
+		logger.Warn("Failed to load history data, using in-memory energy", log.Err(err))
+		historyData = es.memoryHistory(deviceID)
+	case err != nil:
+		stopCalc()
 		es.updateStats(false, time.Since(start))
 		logger.Error("Failed to load history data", log.Err(err))
 		return 0, fmt.Errorf("%w: %v", ErrStorageRead, err)
+	case es.config.DegradedMode:
+		historyData = es.reconcile(deviceID, historyData)
 	}
 
 	// 计算累计电能
-	currentTime := time.Now()
-	totalEnergy, err := es.calculateTotalEnergy(historyData, power, currentTime)
+	totalEnergy, deviceEnergy, err := compute(historyData)
+	stopCalc()
 	if err != nil {
 		es.updateStats(false, time.Since(start))
 		logger.Error("Failed to calculate energy", log.Err(err))
@@ -73,11 +342,23 @@ func (es *EnergyService) Calculate(deviceID string, power float64) (float64, err
 	}
 
 	// 保存数据到storage
-	if err := es.saveData(deviceID, totalEnergy); err != nil {
+	stopWrite := timing.Track(ctx, timing.StageStorageWrite)
+	data, err := es.saveData(ctx, deviceID, totalEnergy, deviceEnergy, historyEpoch(historyData))
+	stopWrite()
+	switch {
+	case err != nil && es.config.DegradedMode:
+		// 降级模式：数据保存在内存中，存储恢复后再合并
+		es.markDegraded(deviceID, historyEnergy(historyData), data)
+	case err != nil:
 		es.updateStats(false, time.Since(start))
 		logger.Error("Failed to save data", log.Err(err))
 		return 0, fmt.Errorf("%w: %v", ErrStorageWrite, err)
+	case storage.BatchFromContext(ctx) != nil:
+		es.trackBatchEntry(storage.BatchFromContext(ctx), deviceID, historyEnergy(historyData), data)
+	default:
+		es.markPersisted(deviceID, data)
 	}
+	es.setEpoch(deviceID, data.ResetEpoch)
 
 	// 更新统计信息
 	duration := time.Since(start)
@@ -93,9 +374,14 @@ func (es *EnergyService) Get(deviceID string) (float64, error) {
 		return 0, ErrInvalidDeviceID
 	}
 
-	// 获取读锁（允许并发读取）
-	es.mutex.RLock()
-	defer es.mutex.RUnlock()
+	// 获取设备读锁（允许并发读取，等待该设备进行中的计算完成）
+	unlock := es.locks.RLock(deviceID)
+	defer unlock()
+
+	// 降级中的设备以内存数据为准
+	if data, ok := es.memoryData(deviceID); ok {
+		return data.EnergyWH, nil
+	}
 
 	// 从storage读取设备数据
 	data, err := es.storage.Read(deviceID)
@@ -106,13 +392,71 @@ func (es *EnergyService) Get(deviceID string) (float64, error) {
 	return data.EnergyWH, nil
 }
 
+// GetData 获取最新电能数据及其时间戳
+// 与 Get 不同，存储支持 storage.DeviceChecker 时，从未保存过数据的设备
+// 返回 ErrDeviceNotFound，而不是默认的零值数据
+func (es *EnergyService) GetData(deviceID string) (*storage.PowerData, error) {
+	// 参数验证
+	if deviceID == "" {
+		return nil, ErrInvalidDeviceID
+	}
+
+	// 获取设备读锁（允许并发读取，等待该设备进行中的计算完成）
+	unlock := es.locks.RLock(deviceID)
+	defer unlock()
+
+	// 降级中的设备以内存数据为准
+	if data, ok := es.memoryData(deviceID); ok {
+		return data, nil
+	}
+
+	// 区分未知设备与零电能设备
+	if checker, ok := es.storage.(storage.DeviceChecker); ok {
+		exists, err := checker.Exists(deviceID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrStorageRead, err)
+		}
+		if !exists {
+			return nil, ErrDeviceNotFound
+		}
+	}
+
+	// 从storage读取设备数据
+	data, err := es.storage.Read(deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrStorageRead, err)
+	}
+
+	return data, nil
+}
+
+// MarkResumed 标记采集已从暂停中恢复
+// 启用 SkipResumeGap 时，恢复前保存的数据在下一次计算中只作为时间基准，
+// 暂停期间的电能不做积分；未启用时不产生任何影响
+func (es *EnergyService) MarkResumed() {
+	if !es.config.SkipResumeGap {
+		return
+	}
+
+	es.resumeMu.Lock()
+	defer es.resumeMu.Unlock()
+	es.resumedAt = time.Now()
+}
+
+// resumedAtTime 返回最近一次采集恢复的时间
+func (es *EnergyService) resumedAtTime() time.Time {
+	es.resumeMu.RLock()
+	defer es.resumeMu.RUnlock()
+	return es.resumedAt
+}
+
 // GetStats 获取统计信息
 func (es *EnergyService) GetStats() *Stats {
 	return es.stats
 }
 
 // calculateTotalEnergy 计算累计电能（内部方法）
-func (es *EnergyService) calculateTotalEnergy(historyData *storage.PowerData, currentPower float64, currentTime time.Time) (float64, error) {
+func (es *EnergyService) calculateTotalEnergy(deviceID string, historyData *storage.PowerData, currentPower float64, currentTime time.Time) (float64, error) {
 	// 首次计算，从0开始
 	if historyData == nil {
 		return 0, nil
@@ -122,9 +466,36 @@ func (es *EnergyService) calculateTotalEnergy(historyData *storage.PowerData, cu
 	lastTime := time.UnixMilli(historyData.Timestamp)
 	timeIntervalHours := currentTime.Sub(lastTime).Hours()
 
+	// 低于最小积分功率的读数视为0（过滤待机噪声）
+	if math.Abs(currentPower) < es.config.MinPowerWatts {
+		currentPower = 0
+	}
+
 	// 计算间隔电能 = 功率 × 时间间隔
 	intervalEnergy := currentPower * timeIntervalHours
 
+	// 数据保存于暂停期间之前时跳过本次积分，仅更新时间基准
+	// 时间戳以毫秒存储，按毫秒精度比较
+	if resumedAt := es.resumedAtTime(); !resumedAt.IsZero() && historyData.Timestamp < resumedAt.UnixMilli() {
+		es.logger.Debug("Skipping integration across paused interval",
+			log.Time("last_time", lastTime),
+			log.Time("resumed_at", resumedAt),
+		)
+		intervalEnergy = 0
+	}
+
+	// 设备离线较长时间后重新上报时，当前功率不能代表离线期间的用电，
+	// 跳过本次积分，仅更新时间基准
+	if gap := currentTime.Sub(lastTime); es.config.MaxIntegrationGap > 0 && gap > es.config.MaxIntegrationGap {
+		es.logger.Info("Skipping integration across device absence",
+			log.String("device_id", deviceID),
+			log.Time("last_time", lastTime),
+			log.Duration("gap", gap),
+			log.Duration("max_integration_gap", es.config.MaxIntegrationGap),
+		)
+		intervalEnergy = 0
+	}
+
 	// 计算新的累计电能 = 历史电能 + 间隔电能
 	totalEnergy := historyData.EnergyWH + intervalEnergy
 
@@ -134,6 +505,32 @@ func (es *EnergyService) calculateTotalEnergy(historyData *storage.PowerData, cu
 	return totalEnergy, nil
 }
 
+// calculateDeviceEnergy 根据设备累计电能读数计算导出电能（内部方法）
+func (es *EnergyService) calculateDeviceEnergy(deviceID string, historyData *storage.PowerData, deviceEnergyWh float64) float64 {
+	// 首次读数（或由 power 模式切换而来）只建立基准，导出值从已保存的电能继续
+	if historyData == nil {
+		return 0
+	}
+	if historyData.DeviceEnergyWH == nil {
+		return historyData.EnergyWH
+	}
+
+	lastReading := *historyData.DeviceEnergyWH
+	if deviceEnergyWh < lastReading {
+		// 计数器复位（如设备重启）：不做减法，以新读数作为继续累计的基准
+		es.logger.Warn("Device energy counter reset detected",
+			log.String("device_id", deviceID),
+			log.Float64("last_reading_wh", lastReading),
+			log.Float64("reading_wh", deviceEnergyWh),
+		)
+		return historyData.EnergyWH
+	}
+
+	// 新的累计电能 = 历史电能 + 读数增量，精度保留2位小数
+	totalEnergy := historyData.EnergyWH + (deviceEnergyWh - lastReading)
+	return math.Round(totalEnergy*100) / 100
+}
+
 // loadHistoryData 加载历史数据（内部方法）
 func (es *EnergyService) loadHistoryData(deviceID string) (*storage.PowerData, error) {
 	// 调用storage.Read读取历史数据
@@ -151,19 +548,42 @@ func (es *EnergyService) loadHistoryData(deviceID string) (*storage.PowerData, e
 }
 
 // saveData 保存数据（内部方法）
-func (es *EnergyService) saveData(deviceID string, energy float64) error {
+// deviceEnergy 为设备读数基准，按功率积分时为 nil；epoch 为沿用的清零纪元
+// ctx 中携带批量写入时暂存到批次中，由 BeginBatch 返回的 commit 统一提交
+// 返回保存（或尝试保存）的数据
+func (es *EnergyService) saveData(ctx context.Context, deviceID string, energy float64, deviceEnergy *float64, epoch uint64) (*storage.PowerData, error) {
 	// 创建新的PowerData结构
 	data := &storage.PowerData{
-		Timestamp: time.Now().UnixMilli(), // 毫秒时间戳
-		EnergyWH:  energy,                 // 累计电能(Wh)
+		Timestamp:      time.Now().UnixMilli(), // 毫秒时间戳
+		EnergyWH:       energy,                 // 累计电能(Wh)
+		DeviceEnergyWH: deviceEnergy,           // 设备累计电能读数(Wh)
+		ResetEpoch:     epoch,                  // 清零纪元
+	}
+
+	if batch := storage.BatchFromContext(ctx); batch != nil {
+		return data, batch.Write(deviceID, data)
 	}
 
 	// 调用storage.Write保存数据
 	if err := es.storage.Write(deviceID, data); err != nil {
-		return err
+		return data, err
+	}
+
+	return data, nil
+}
+
+// trackBatchEntry 记录批次中暂存的数据，提交后据此结束或进入降级状态
+// 仅在启用 DegradedMode 时记录
+func (es *EnergyService) trackBatchEntry(batch *storage.Batch, deviceID string, historyEnergy float64, data *storage.PowerData) {
+	if !es.config.DegradedMode {
+		return
 	}
 
-	return nil
+	es.batchMu.Lock()
+	defer es.batchMu.Unlock()
+	if entries, ok := es.batches[batch]; ok {
+		entries[deviceID] = batchEntry{historyEnergy: historyEnergy, data: data}
+	}
 }
 
 // updateStats 更新统计信息（内部方法）
@@ -1,6 +1,7 @@
 package energy
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"testing"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/lay-g/winpower-g2-exporter/internal/energy/mocks"
 	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/timing"
 	"github.com/lay-g/winpower-g2-exporter/internal/storage"
 )
 
@@ -232,6 +234,57 @@ func TestEnergyService_Calculate(t *testing.T) {
 	})
 }
 
+func TestEnergyService_MinPowerWatts(t *testing.T) {
+	logger := log.NewTestLogger()
+	deviceID := "ups-idle"
+	history := &storage.PowerData{
+		Timestamp: time.Now().Add(-time.Hour).UnixMilli(),
+		EnergyWH:  100,
+	}
+
+	t.Run("Power below threshold is not integrated", func(t *testing.T) {
+		mockStorage := mocks.NewMockStorage()
+		_ = mockStorage.Write(deviceID, history)
+		service := NewEnergyServiceWithConfig(mockStorage, logger, &Config{MinPowerWatts: 5})
+
+		energy, err := service.Calculate(deviceID, 3)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if energy != 100 {
+			t.Errorf("Expected energy = 100, got %v", energy)
+		}
+	})
+
+	t.Run("Power at or above threshold is integrated", func(t *testing.T) {
+		mockStorage := mocks.NewMockStorage()
+		_ = mockStorage.Write(deviceID, history)
+		service := NewEnergyServiceWithConfig(mockStorage, logger, &Config{MinPowerWatts: 5})
+
+		energy, err := service.Calculate(deviceID, 10)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if energy <= 109 || energy > 111 {
+			t.Errorf("Expected energy ~110, got %v", energy)
+		}
+	})
+
+	t.Run("Nil config uses defaults", func(t *testing.T) {
+		mockStorage := mocks.NewMockStorage()
+		_ = mockStorage.Write(deviceID, history)
+		service := NewEnergyServiceWithConfig(mockStorage, logger, nil)
+
+		energy, err := service.Calculate(deviceID, 3)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if energy <= 102 || energy > 104 {
+			t.Errorf("Expected energy ~103, got %v", energy)
+		}
+	})
+}
+
 func TestEnergyService_Get(t *testing.T) {
 	logger := log.NewTestLogger()
 
@@ -289,6 +342,165 @@ func TestEnergyService_Get(t *testing.T) {
 	})
 }
 
+func TestEnergyService_GetData(t *testing.T) {
+	logger := log.NewTestLogger()
+	store, err := storage.NewFileStorageManager(&storage.Config{
+		DataDir:         t.TempDir(),
+		FilePermissions: 0644,
+	}, logger)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	service := NewEnergyService(store, logger)
+
+	// Unknown devices are reported instead of returning default data
+	if _, err := service.GetData("ups-001"); !errors.Is(err, ErrDeviceNotFound) {
+		t.Errorf("Expected ErrDeviceNotFound, got %v", err)
+	}
+
+	timestamp := time.Now().UnixMilli()
+	if err := store.Write("ups-001", &storage.PowerData{Timestamp: timestamp, EnergyWH: 0}); err != nil {
+		t.Fatalf("Failed to write test data: %v", err)
+	}
+
+	data, err := service.GetData("ups-001")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data.EnergyWH != 0 || data.Timestamp != timestamp {
+		t.Errorf("Expected energy 0 at %d, got %+v", timestamp, data)
+	}
+
+	if _, err := service.GetData(""); !errors.Is(err, ErrInvalidDeviceID) {
+		t.Errorf("Expected ErrInvalidDeviceID, got %v", err)
+	}
+}
+
+func TestEnergyService_Reset(t *testing.T) {
+	logger := log.NewTestLogger()
+	store, err := storage.NewFileStorageManager(&storage.Config{
+		DataDir:         t.TempDir(),
+		FilePermissions: 0644,
+		BatchWrite:      true,
+	}, logger)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	service := NewEnergyService(store, logger)
+
+	// Unknown devices are not created
+	if _, _, err := service.Reset("ups-001"); !errors.Is(err, ErrDeviceNotFound) {
+		t.Errorf("Expected ErrDeviceNotFound, got %v", err)
+	}
+	if _, _, err := service.Reset(""); !errors.Is(err, ErrInvalidDeviceID) {
+		t.Errorf("Expected ErrInvalidDeviceID, got %v", err)
+	}
+
+	deviceEnergy := 500.0
+	if err := store.Write("ups-001", &storage.PowerData{
+		Timestamp:      time.Now().Add(-time.Hour).UnixMilli(),
+		EnergyWH:       1234.5,
+		DeviceEnergyWH: &deviceEnergy,
+	}); err != nil {
+		t.Fatalf("Failed to write test data: %v", err)
+	}
+
+	before, after, err := service.Reset("ups-001")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if before.EnergyWH != 1234.5 || after.EnergyWH != 0 {
+		t.Errorf("Expected 1234.5 -> 0, got %v -> %v", before.EnergyWH, after.EnergyWH)
+	}
+	if after.DeviceEnergyWH == nil || *after.DeviceEnergyWH != deviceEnergy {
+		t.Errorf("Expected device reading baseline to be kept, got %v", after.DeviceEnergyWH)
+	}
+
+	// A reset during a collection is not overwritten by the energy the
+	// collection staged before the reset
+	if err := store.Write("ups-001", &storage.PowerData{
+		Timestamp: time.Now().Add(-time.Hour).UnixMilli(),
+		EnergyWH:  1000,
+	}); err != nil {
+		t.Fatalf("Failed to write test data: %v", err)
+	}
+	ctx, commit := service.BeginBatch(context.Background())
+	if commit == nil {
+		t.Fatal("Expected batch writes to be enabled")
+	}
+	if _, err := service.CalculateContext(ctx, "ups-001", 100); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, _, err := service.Reset("ups-001"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := commit(); err != nil {
+		t.Fatalf("Failed to commit batch: %v", err)
+	}
+
+	energy, err := service.Get("ups-001")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if energy != 0 {
+		t.Errorf("Expected reset energy to survive the batch commit, got %v", energy)
+	}
+}
+
+func TestEnergyService_ResetEpoch(t *testing.T) {
+	logger := log.NewTestLogger()
+	store, err := storage.NewFileStorageManager(&storage.Config{
+		DataDir:         t.TempDir(),
+		FilePermissions: 0644,
+	}, logger)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	service := NewEnergyService(store, logger)
+
+	if _, err := service.Calculate("ups-001", 100); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if epoch := service.ResetEpoch("ups-001"); epoch != 0 {
+		t.Errorf("Expected epoch 0 before any reset, got %d", epoch)
+	}
+
+	// Every reset increments the epoch
+	for want := uint64(1); want <= 2; want++ {
+		_, after, err := service.Reset("ups-001")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if after.ResetEpoch != want {
+			t.Errorf("Expected reset epoch %d, got %d", want, after.ResetEpoch)
+		}
+		if epoch := service.ResetEpoch("ups-001"); epoch != want {
+			t.Errorf("Expected ResetEpoch() = %d, got %d", want, epoch)
+		}
+	}
+
+	// Later calculations keep the epoch, which is stored with the energy
+	if _, err := service.Calculate("ups-001", 100); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, err := store.Read("ups-001")
+	if err != nil {
+		t.Fatalf("Failed to read data: %v", err)
+	}
+	if data.ResetEpoch != 2 {
+		t.Errorf("Expected stored epoch 2, got %d", data.ResetEpoch)
+	}
+
+	// A restarted service picks the epoch up from storage
+	restarted := NewEnergyService(store, logger)
+	if _, err := restarted.Calculate("ups-001", 100); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if epoch := restarted.ResetEpoch("ups-001"); epoch != 2 {
+		t.Errorf("Expected epoch 2 after a restart, got %d", epoch)
+	}
+}
+
 func TestEnergyService_GetStats(t *testing.T) {
 	logger := log.NewTestLogger()
 	mockStorage := mocks.NewMockStorage()
@@ -360,6 +572,66 @@ func TestEnergyService_ConcurrentAccess(t *testing.T) {
 	}
 }
 
+func TestEnergyService_ConcurrentDevices(t *testing.T) {
+	logger := log.NewTestLogger()
+	mockStorage := mocks.NewMockStorage()
+	service := NewEnergyService(mockStorage, logger)
+
+	// Block the history read of one device to hold its lock
+	blocked := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	mockStorage.ReadFunc = func(deviceID string) (*storage.PowerData, error) {
+		if deviceID == "ups-slow" {
+			once.Do(func() {
+				close(blocked)
+				<-release
+			})
+		}
+		return nil, storage.ErrFileNotFound
+	}
+
+	slowDone := make(chan struct{})
+	go func() {
+		defer close(slowDone)
+		_, _ = service.Calculate("ups-slow", 1000)
+	}()
+	<-blocked
+
+	// Other devices are not blocked by the in-flight calculation
+	fastDone := make(chan error, 1)
+	go func() {
+		_, err := service.Calculate("ups-fast", 1000)
+		fastDone <- err
+	}()
+
+	select {
+	case err := <-fastDone:
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected calculation of another device not to wait")
+	}
+
+	// The same device waits for the in-flight calculation
+	sameDone := make(chan struct{})
+	go func() {
+		defer close(sameDone)
+		_, _ = service.Get("ups-slow")
+	}()
+
+	select {
+	case <-sameDone:
+		t.Fatal("Expected read of the same device to wait for the calculation")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	<-slowDone
+	<-sameDone
+}
+
 func TestEnergyService_SequentialCalculations(t *testing.T) {
 	logger := log.NewTestLogger()
 	mockStorage := mocks.NewMockStorage()
@@ -393,3 +665,278 @@ func TestEnergyService_SequentialCalculations(t *testing.T) {
 		t.Logf("Iteration %d: Power=%vW, Energy=%vWh", i, power, energy)
 	}
 }
+
+func TestEnergyService_CalculateContext_RecordsStages(t *testing.T) {
+	service := NewEnergyService(mocks.NewMockStorage(), log.NewTestLogger())
+
+	recorder := timing.NewRecorder()
+	ctx := timing.WithRecorder(context.Background(), recorder)
+	if _, err := service.CalculateContext(ctx, "ups-001", 500); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	stages := recorder.Stages()
+	if len(stages) != 2 {
+		t.Fatalf("Expected 2 stages, got %+v", stages)
+	}
+	if stages[0].Name != timing.StageEnergy || stages[1].Name != timing.StageStorageWrite {
+		t.Errorf("Unexpected stages: %+v", stages)
+	}
+}
+
+func TestEnergyService_SkipResumeGap(t *testing.T) {
+	logger := log.NewTestLogger()
+	deviceID := "ups-paused"
+	history := &storage.PowerData{
+		Timestamp: time.Now().Add(-time.Hour).UnixMilli(),
+		EnergyWH:  100,
+	}
+
+	t.Run("Gap is integrated by default", func(t *testing.T) {
+		mockStorage := mocks.NewMockStorage()
+		_ = mockStorage.Write(deviceID, history)
+		service := NewEnergyService(mockStorage, logger)
+		service.MarkResumed()
+
+		energy, err := service.Calculate(deviceID, 10)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if energy <= 109 || energy > 111 {
+			t.Errorf("Expected energy ~110, got %v", energy)
+		}
+	})
+
+	t.Run("First integration after resume is skipped", func(t *testing.T) {
+		mockStorage := mocks.NewMockStorage()
+		_ = mockStorage.Write(deviceID, history)
+		service := NewEnergyServiceWithConfig(mockStorage, logger, &Config{SkipResumeGap: true})
+		service.MarkResumed()
+
+		energy, err := service.Calculate(deviceID, 10)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if energy != 100 {
+			t.Errorf("Expected energy = 100, got %v", energy)
+		}
+
+		// The baseline was reset after resume, so the next calculation
+		// integrates again (3.6 MW over >=10ms is at least 10 Wh)
+		time.Sleep(10 * time.Millisecond)
+		energy, err = service.Calculate(deviceID, 3_600_000)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if energy < 110 {
+			t.Errorf("Expected energy >= 110, got %v", energy)
+		}
+	})
+}
+
+func TestEnergyService_MaxIntegrationGap(t *testing.T) {
+	logger := log.NewTestLogger()
+	deviceID := "ups-reconnected"
+	config := &Config{MaxIntegrationGap: 15 * time.Second}
+
+	t.Run("Gap within the limit is integrated", func(t *testing.T) {
+		mockStorage := mocks.NewMockStorage()
+		_ = mockStorage.Write(deviceID, &storage.PowerData{
+			Timestamp: time.Now().Add(-10 * time.Second).UnixMilli(),
+			EnergyWH:  100,
+		})
+		service := NewEnergyServiceWithConfig(mockStorage, logger, config)
+
+		// 3.6 kW over 10s is 10 Wh
+		energy, err := service.Calculate(deviceID, 3600)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if energy < 109.9 || energy > 110.1 {
+			t.Errorf("Expected energy ~110, got %v", energy)
+		}
+	})
+
+	t.Run("Gap beyond the limit only rebaselines", func(t *testing.T) {
+		mockStorage := mocks.NewMockStorage()
+		_ = mockStorage.Write(deviceID, &storage.PowerData{
+			Timestamp: time.Now().Add(-time.Hour).UnixMilli(),
+			EnergyWH:  100,
+		})
+		service := NewEnergyServiceWithConfig(mockStorage, logger, config)
+
+		energy, err := service.Calculate(deviceID, 3600)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if energy != 100 {
+			t.Errorf("Expected energy = 100, got %v", energy)
+		}
+
+		// The baseline was reset, so the next calculation integrates again
+		data, err := mockStorage.Read(deviceID)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if time.Since(time.UnixMilli(data.Timestamp)) > time.Second {
+			t.Errorf("Expected the timestamp to be rebaselined, got %v", time.UnixMilli(data.Timestamp))
+		}
+	})
+}
+
+func TestEnergyService_UsesAveragePower(t *testing.T) {
+	logger := log.NewTestLogger()
+
+	tests := []struct {
+		name   string
+		config *Config
+		want   bool
+	}{
+		{name: "default", config: DefaultConfig(), want: false},
+		{name: "average", config: &Config{PowerReading: PowerReadingAverage}, want: true},
+		{name: "average with device source", config: &Config{Source: SourceDevice, PowerReading: PowerReadingAverage}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewEnergyServiceWithConfig(mocks.NewMockStorage(), logger, tt.config)
+			if got := service.UsesAveragePower(); got != tt.want {
+				t.Errorf("UsesAveragePower() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnergyService_MinPowerWattsAccessor(t *testing.T) {
+	logger := log.NewTestLogger()
+
+	tests := []struct {
+		name   string
+		config *Config
+		want   float64
+	}{
+		{name: "default", config: DefaultConfig(), want: 0},
+		{name: "configured", config: &Config{MinPowerWatts: 5}, want: 5},
+		{name: "ignored with device source", config: &Config{Source: SourceDevice, MinPowerWatts: 5}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewEnergyServiceWithConfig(mocks.NewMockStorage(), logger, tt.config)
+			if got := service.MinPowerWatts(); got != tt.want {
+				t.Errorf("MinPowerWatts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnergyService_CalculateFromDevice(t *testing.T) {
+	logger := log.NewTestLogger()
+	ctx := context.Background()
+	deviceID := "ups-counter"
+
+	t.Run("Counter deltas accumulate and resets continue from new baseline", func(t *testing.T) {
+		mockStorage := mocks.NewMockStorage()
+		service := NewEnergyServiceWithConfig(mockStorage, logger, &Config{Source: SourceDevice})
+		if !service.UsesDeviceEnergy() {
+			t.Fatal("Expected device energy source")
+		}
+
+		steps := []struct {
+			reading float64
+			want    float64
+		}{
+			{reading: 5000, want: 0},    // first reading only sets the baseline
+			{reading: 5010, want: 10},   // +10
+			{reading: 5010, want: 10},   // unchanged
+			{reading: 3, want: 10},      // device reset: new baseline, no subtraction
+			{reading: 8, want: 15},      // +5 from the new baseline
+			{reading: 20.5, want: 27.5}, // +12.5
+		}
+
+		for i, step := range steps {
+			energy, err := service.CalculateFromDevice(ctx, deviceID, step.reading)
+			if err != nil {
+				t.Fatalf("Step %d: unexpected error: %v", i, err)
+			}
+			if energy != step.want {
+				t.Errorf("Step %d: expected energy %v, got %v", i, step.want, energy)
+			}
+		}
+
+		data := mockStorage.GetData()[deviceID]
+		if data.DeviceEnergyWH == nil || *data.DeviceEnergyWH != 20.5 {
+			t.Errorf("Expected stored baseline 20.5, got %v", data.DeviceEnergyWH)
+		}
+	})
+
+	t.Run("Switching from power continues the stored total", func(t *testing.T) {
+		mockStorage := mocks.NewMockStorage()
+		_ = mockStorage.Write(deviceID, &storage.PowerData{
+			Timestamp: time.Now().UnixMilli(),
+			EnergyWH:  100,
+		})
+		service := NewEnergyServiceWithConfig(mockStorage, logger, &Config{Source: SourceDevice})
+
+		energy, err := service.CalculateFromDevice(ctx, deviceID, 7000)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if energy != 100 {
+			t.Errorf("Expected energy = 100, got %v", energy)
+		}
+	})
+
+	t.Run("Invalid reading", func(t *testing.T) {
+		service := NewEnergyServiceWithConfig(mocks.NewMockStorage(), logger, &Config{Source: SourceDevice})
+
+		if _, err := service.CalculateFromDevice(ctx, deviceID, -1); !errors.Is(err, ErrInvalidDeviceEnergy) {
+			t.Errorf("Expected ErrInvalidDeviceEnergy, got %v", err)
+		}
+	})
+}
+
+func TestEnergyService_Adopt(t *testing.T) {
+	logger := log.NewTestLogger()
+	store, err := storage.NewFileStorageManager(&storage.Config{
+		DataDir:         t.TempDir(),
+		FilePermissions: 0644,
+	}, logger)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	service := NewEnergyService(store, logger)
+
+	// Nothing to adopt from a device without data
+	if adopted, err := service.Adopt("serial-SN1", "ups-001"); err != nil || adopted {
+		t.Errorf("Expected nothing to adopt, got %v (%v)", adopted, err)
+	}
+	if _, err := service.GetData("serial-SN1"); !errors.Is(err, ErrDeviceNotFound) {
+		t.Errorf("Expected no data to be created, got %v", err)
+	}
+
+	if err := store.Write("ups-001", &storage.PowerData{Timestamp: time.Now().UnixMilli(), EnergyWH: 1234.5}); err != nil {
+		t.Fatalf("Failed to write test data: %v", err)
+	}
+	if adopted, err := service.Adopt("serial-SN1", "ups-001"); err != nil || !adopted {
+		t.Fatalf("Expected energy to be adopted, got %v (%v)", adopted, err)
+	}
+	if energy, err := service.Get("serial-SN1"); err != nil || energy != 1234.5 {
+		t.Errorf("Expected adopted energy 1234.5, got %v (%v)", energy, err)
+	}
+
+	// An identity that already has energy keeps it
+	if err := store.Write("ups-002", &storage.PowerData{Timestamp: time.Now().UnixMilli(), EnergyWH: 1}); err != nil {
+		t.Fatalf("Failed to write test data: %v", err)
+	}
+	if adopted, err := service.Adopt("serial-SN1", "ups-002"); err != nil || adopted {
+		t.Errorf("Expected existing energy to be kept, got %v (%v)", adopted, err)
+	}
+	if energy, _ := service.Get("serial-SN1"); energy != 1234.5 {
+		t.Errorf("Expected energy 1234.5, got %v", energy)
+	}
+
+	if _, err := service.Adopt("", "ups-001"); !errors.Is(err, ErrInvalidDeviceID) {
+		t.Errorf("Expected ErrInvalidDeviceID, got %v", err)
+	}
+}
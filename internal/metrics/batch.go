@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// deviceBatchCollector serves the device series published by the last
+// update cycle when device updates are batched. The per-device gauges are
+// then not registered individually: an update cycle sets them under m.mu as
+// usual and publishDeviceMetrics freezes their values in a single pass, so
+// scrapes read an immutable snapshot instead of the live gauges.
+type deviceBatchCollector struct {
+	service *MetricsService
+}
+
+// Describe implements prometheus.Collector. It sends no descriptors, making
+// the collector unchecked, because the set of devices changes at runtime.
+func (c *deviceBatchCollector) Describe(chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector
+func (c *deviceBatchCollector) Collect(ch chan<- prometheus.Metric) {
+	snapshot := c.service.deviceSnapshot.Load()
+	if snapshot == nil {
+		return
+	}
+	for _, metric := range *snapshot {
+		ch <- metric
+	}
+}
+
+// frozenMetric is a device series value captured by publishDeviceMetrics
+type frozenMetric struct {
+	desc   *prometheus.Desc
+	metric *dto.Metric
+}
+
+// Desc implements prometheus.Metric
+func (f *frozenMetric) Desc() *prometheus.Desc {
+	return f.desc
+}
+
+// Write implements prometheus.Metric. The captured value is never modified,
+// so it is shared by concurrent scrapes.
+func (f *frozenMetric) Write(out *dto.Metric) error {
+	out.Label = f.metric.Label
+	out.Gauge = f.metric.Gauge
+	out.Counter = f.metric.Counter
+	return nil
+}
+
+// publishDeviceMetrics captures the current values of every device series
+// and replaces the snapshot served by deviceBatchCollector. It does nothing
+// unless device updates are batched. The caller must hold m.mu.
+func (m *MetricsService) publishDeviceMetrics() {
+	if !m.batchUpdates {
+		return
+	}
+
+	ch := make(chan prometheus.Metric, 64)
+	go func() {
+		for _, dm := range m.deviceMetrics {
+			for _, c := range dm.collectors() {
+				c.Collect(ch)
+			}
+		}
+		close(ch)
+	}()
+
+	snapshot := make([]prometheus.Metric, 0, len(m.deviceMetrics)*32)
+	for metric := range ch {
+		pb := &dto.Metric{}
+		if err := metric.Write(pb); err != nil {
+			// Gauges and counters never fail to write
+			continue
+		}
+		snapshot = append(snapshot, &frozenMetric{desc: metric.Desc(), metric: pb})
+	}
+	m.deviceSnapshot.Store(&snapshot)
+}
@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/collector"
+	"github.com/lay-g/winpower-g2-exporter/internal/metrics/mocks"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+// deviceExposition returns the text exposition of the device series
+func deviceExposition(t *testing.T, g prometheus.Gatherer) string {
+	t.Helper()
+	families, err := g.Gather()
+	require.NoError(t, err)
+
+	var buf strings.Builder
+	for _, family := range families {
+		var metrics []*dto.Metric
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == labelDeviceID {
+					metrics = append(metrics, metric)
+					break
+				}
+			}
+		}
+		if len(metrics) == 0 {
+			continue
+		}
+		family.Metric = metrics
+		_, err := expfmt.MetricFamilyToText(&buf, family)
+		require.NoError(t, err)
+	}
+	return buf.String()
+}
+
+func newBatchTestServices(t *testing.T, configure func(*MetricsConfig)) (plain, batched *MetricsService) {
+	t.Helper()
+	for _, batch := range []bool{false, true} {
+		config := DefaultMetricsConfig()
+		config.BatchDeviceUpdates = batch
+		if configure != nil {
+			configure(config)
+		}
+		service, err := NewMetricsService(mocks.NewMockCollectorWithDevices(), log.NewTestLogger(), config)
+		require.NoError(t, err)
+		if batch {
+			batched = service
+		} else {
+			plain = service
+		}
+	}
+	return plain, batched
+}
+
+func TestMetricsService_BatchDeviceUpdates(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Exposes the same device series", func(t *testing.T) {
+		plain, batched := newBatchTestServices(t, func(c *MetricsConfig) {
+			c.EnableDeviceUptime = true
+			c.DeviceTypePrefixes = map[string]string{"1": "ups"}
+		})
+		result, err := plain.collector.CollectDeviceData(ctx)
+		require.NoError(t, err)
+		result.Devices["device2"].FaultCode = "E01"
+		result.Devices["device2"].ErrorType = collector.DeviceErrorEnergy
+
+		for _, service := range []*MetricsService{plain, batched} {
+			require.NoError(t, service.updateMetrics(result))
+		}
+
+		want := deviceExposition(t, plain.registry)
+		require.NotEmpty(t, want)
+		assert.Equal(t, want, deviceExposition(t, batched.registry))
+	})
+
+	t.Run("Publishes once per update cycle", func(t *testing.T) {
+		_, service := newBatchTestServices(t, nil)
+		result, err := service.collector.CollectDeviceData(ctx)
+		require.NoError(t, err)
+		require.NoError(t, service.updateMetrics(result))
+		before := deviceExposition(t, service.registry)
+
+		// A change outside an update cycle is not visible to scrapes
+		service.deviceMetrics["device1"].loadTotalWatt.Set(42)
+		assert.Equal(t, before, deviceExposition(t, service.registry))
+
+		service.handleCollectionError(nil, errors.New("connection refused"))
+		after := deviceExposition(t, service.registry)
+		assert.Contains(t, after, "winpower_device_load_total_watts{device_id=\"device1\",device_name=\"UPS-01\",device_type=\"1\",winpower_host=\"localhost\"} 42")
+		assert.NotContains(t, after, "winpower_device_up{device_id=\"device1\",device_name=\"UPS-01\",device_type=\"1\",winpower_host=\"localhost\"} 1")
+	})
+
+	t.Run("Removes expired devices", func(t *testing.T) {
+		_, service := newBatchTestServices(t, nil)
+		result, err := service.collector.CollectDeviceData(ctx)
+		require.NoError(t, err)
+		require.NoError(t, service.updateMetrics(result))
+
+		require.NoError(t, service.updateMetrics(&collector.CollectionResult{
+			Success: true,
+			Devices: map[string]*collector.DeviceCollectionInfo{},
+		}))
+		assert.Empty(t, deviceExposition(t, service.registry))
+	})
+
+	t.Run("Concurrent updates and scrapes", func(t *testing.T) {
+		_, service := newBatchTestServices(t, nil)
+		result, err := service.collector.CollectDeviceData(ctx)
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					assert.NoError(t, service.updateMetrics(result))
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					_, err := service.registry.Gather()
+					assert.NoError(t, err)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// MetricCategory groups the metric families served on separate endpoints
+type MetricCategory string
+
+const (
+	// CategoryExporter is the exporter's self-monitoring: collection,
+	// scheduler, WinPower connection and runtime metrics
+	CategoryExporter MetricCategory = "exporter"
+
+	// CategoryDevices is the per-device metrics, including energy
+	CategoryDevices MetricCategory = "devices"
+)
+
+// exporterFamilies lists the metric families of CategoryExporter. Every
+// other family is a device metric; device families are not listed since
+// their names depend on the configured device type prefixes. A new
+// self-monitoring metric must be added here, whatever its labels.
+var exporterFamilies = map[string]struct{}{
+	"winpower_exporter_up":                                   {},
+	"winpower_exporter_requests_total":                       {},
+	"winpower_exporter_request_duration_seconds":             {},
+	"winpower_exporter_scrape_handler_duration_seconds":      {},
+	"winpower_exporter_collection_duration_seconds":          {},
+	"winpower_exporter_scrape_errors_total":                  {},
+	"winpower_exporter_token_refresh_total":                  {},
+	"winpower_exporter_device_count":                         {},
+	"winpower_exporter_last_collection_time_seconds":         {},
+	"winpower_exporter_last_collection_timestamp_seconds":    {},
+	"winpower_exporter_devices_evicted_total":                {},
+	"winpower_exporter_invalid_value_total":                  {},
+	"winpower_exporter_missing_optional_fields":              {},
+	"winpower_exporter_scheduler_paused":                     {},
+	"winpower_exporter_scheduler_overruns_total":             {},
+	"winpower_exporter_collection_health":                    {},
+	"winpower_exporter_scheduler_interval_seconds":           {},
+	"winpower_exporter_scheduler_tick_interval_seconds":      {},
+	"winpower_exporter_energy_degraded":                      {},
+	"winpower_exporter_energy_stalled":                       {},
+	"winpower_exporter_collections_throttled_total":          {},
+	"winpower_exporter_metrics_staleness_seconds":            {},
+	"winpower_exporter_source_maintenance":                   {},
+	"winpower_exporter_config_reloads_total":                 {},
+	"winpower_exporter_config_last_reload_timestamp_seconds": {},
+	"winpower_exporter_shutdown_timeouts_total":              {},
+	"winpower_exporter_memory_bytes":                         {},
+	"winpower_exporter_pushgateway_pushes_total":             {},
+	"winpower_exporter_goroutines":                           {},
+	"winpower_exporter_heap_bytes":                           {},
+	"winpower_exporter_response_bytes":                       {},
+	"winpower_exporter_parse_duration_seconds":               {},
+	"winpower_exporter_request_retries":                      {},
+	"winpower_exporter_device_count_mismatches_total":        {},
+	"winpower_exporter_invalid_timestamps_total":             {},
+	"winpower_connection_status":                             {},
+	"winpower_auth_status":                                   {},
+	"winpower_api_response_time_seconds":                     {},
+	"winpower_token_expiry_seconds":                          {},
+	"winpower_token_valid":                                   {},
+}
+
+// familyCategory returns the category of a metric family by its name
+func familyCategory(family *dto.MetricFamily) MetricCategory {
+	if _, ok := exporterFamilies[family.GetName()]; ok {
+		return CategoryExporter
+	}
+	return CategoryDevices
+}
+
+// categoryGatherer serves the metric families of a single category from
+// another gatherer
+type categoryGatherer struct {
+	gatherer prometheus.Gatherer
+	category MetricCategory
+}
+
+// Gather implements prometheus.Gatherer
+func (g *categoryGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.gatherer.Gather()
+	filtered := families[:0]
+	for _, family := range families {
+		if familyCategory(family) == g.category {
+			filtered = append(filtered, family)
+		}
+	}
+	return filtered, err
+}
+
+// HandleDeviceMetrics is the Gin handler for the /metrics/devices endpoint.
+// It triggers data collection like HandleMetrics but serves the device
+// metrics only.
+func (m *MetricsService) HandleDeviceMetrics(c *gin.Context) {
+	m.handleMetrics(c, &categoryGatherer{gatherer: &partialGatherer{service: m}, category: CategoryDevices})
+}
+
+// HandleExporterMetrics is the Gin handler for the /metrics/exporter
+// endpoint. It serves the self-monitoring metrics without triggering a
+// collection, so that they can be scraped more often than WinPower should
+// be queried.
+func (m *MetricsService) HandleExporterMetrics(c *gin.Context) {
+	m.requestsTotal.WithLabelValues().Inc()
+	handler := promhttp.HandlerFor(&categoryGatherer{gatherer: &partialGatherer{service: m}, category: CategoryExporter}, promhttp.HandlerOpts{
+		ErrorLog:      &promhttpLogger{logger: m.logger},
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+	handler.ServeHTTP(c.Writer, c.Request)
+}
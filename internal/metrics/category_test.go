@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/metrics/mocks"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+func TestMetricsService_CategoryHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, batch := range []bool{false, true} {
+		config := DefaultMetricsConfig()
+		config.BatchDeviceUpdates = batch
+		config.DeviceTypePrefixes = map[string]string{"1": "ups"}
+		service, err := NewMetricsService(mocks.NewMockCollectorWithDevices(), log.NewTestLogger(), config)
+		require.NoError(t, err)
+
+		serve := func(handler gin.HandlerFunc, path string) string {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, path, nil)
+			handler(c)
+			require.Equal(t, http.StatusOK, w.Code, "batch=%v path=%s", batch, path)
+			return w.Body.String()
+		}
+
+		// The device endpoint collects, like /metrics
+		devices := serve(service.HandleDeviceMetrics, "/metrics/devices")
+		assert.Contains(t, devices, "winpower_ups_up", "batch=%v", batch)
+		assert.Contains(t, devices, "_cumulative_energy", "batch=%v", batch)
+		assert.NotContains(t, devices, "winpower_exporter_", "batch=%v", batch)
+		assert.NotContains(t, devices, "winpower_connection_status", "batch=%v", batch)
+
+		exporter := serve(service.HandleExporterMetrics, "/metrics/exporter")
+		assert.Contains(t, exporter, "winpower_exporter_up", "batch=%v", batch)
+		assert.Contains(t, exporter, "winpower_exporter_device_count", "batch=%v", batch)
+		assert.Contains(t, exporter, "winpower_connection_status", "batch=%v", batch)
+		assert.NotContains(t, exporter, "device_id=", "batch=%v", batch)
+
+		// /metrics keeps serving both categories
+		combined := serve(service.HandleMetrics, "/metrics")
+		assert.Contains(t, combined, "winpower_exporter_up", "batch=%v", batch)
+		assert.Contains(t, combined, "winpower_ups_up", "batch=%v", batch)
+	}
+}
+
+func TestFamilyCategory(t *testing.T) {
+	family := func(name string, labels ...string) *dto.MetricFamily {
+		value := "v"
+		metric := &dto.Metric{}
+		for _, label := range labels {
+			metric.Label = append(metric.Label, &dto.LabelPair{Name: &label, Value: &value})
+		}
+		return &dto.MetricFamily{Name: &name, Metric: []*dto.Metric{metric}}
+	}
+
+	// Self-monitoring families stay on the exporter endpoint even with a
+	// device_id label
+	assert.Equal(t, CategoryExporter, familyCategory(family("winpower_exporter_invalid_value_total", labelDeviceID)))
+	assert.Equal(t, CategoryExporter, familyCategory(family("winpower_token_valid")))
+	assert.Equal(t, CategoryDevices, familyCategory(family("winpower_ups_up", labelDeviceID)))
+	assert.Equal(t, CategoryDevices, familyCategory(family("winpower_power_watts")))
+
+	// Every non-device metric with HELP text is listed
+	for name := range defaultHelp {
+		if strings.HasPrefix(name, "winpower_exporter_") {
+			assert.Contains(t, exporterFamilies, name)
+		}
+	}
+	for name := range exporterFamilies {
+		assert.Contains(t, defaultHelp, name)
+	}
+}
+
+func TestMetricsService_HandleExporterMetricsCountsRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service, err := NewMetricsService(mocks.NewMockCollectorWithDevices(), log.NewTestLogger(), DefaultMetricsConfig())
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/metrics/exporter", nil)
+	service.HandleExporterMetrics(c)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(service.requestsTotal))
+}
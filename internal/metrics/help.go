@@ -0,0 +1,118 @@
+package metrics
+
+import "fmt"
+
+// defaultHelp holds the HELP text of every metric, keyed by its fully
+// qualified name. Device metrics exported under a device type prefix (e.g.
+// winpower_ups_power_watts) use the entry of their label-based name (e.g.
+// winpower_power_watts). HELP text states the unit of the value unless the
+// name already carries it.
+var defaultHelp = map[string]string{
+	// Exporter self-monitoring metrics
+	"winpower_exporter_up":                                   "Whether the WinPower exporter is running (1 = up, 0 = down)",
+	"winpower_exporter_requests_total":                       "Total number of HTTP requests to the /metrics endpoint",
+	"winpower_exporter_request_duration_seconds":             "HTTP request duration in seconds",
+	"winpower_exporter_scrape_handler_duration_seconds":      "Duration in seconds of serving a /metrics request, measured by the HTTP server around the handler",
+	"winpower_exporter_collection_duration_seconds":          "Data collection and calculation duration in seconds",
+	"winpower_exporter_scrape_errors_total":                  "Total number of data collection errors",
+	"winpower_exporter_token_refresh_total":                  "Total number of token refreshes by result (success, failure)",
+	"winpower_exporter_device_count":                         "Number of discovered devices",
+	"winpower_exporter_last_collection_time_seconds":         "Unix timestamp of the last successful collection",
+	"winpower_exporter_last_collection_timestamp_seconds":    "Unix timestamp of the last successful collection cycle (unchanged on failure)",
+	"winpower_exporter_devices_evicted_total":                "Total number of devices whose series were evicted because the tracked device limit was reached",
+	"winpower_exporter_invalid_value_total":                  "Total number of NaN or infinite device measurements reported by WinPower, by field",
+	"winpower_exporter_missing_optional_fields":              "Number of devices whose last WinPower response lacked a mapped optional field, by field",
+	"winpower_exporter_scheduler_paused":                     "Whether collection is paused, e.g. for a WinPower maintenance window (1 = paused, 0 = running)",
+	"winpower_exporter_scheduler_overruns_total":             "Total number of scheduled collection cycles that exceeded the collection interval and hit their deadline",
+	"winpower_exporter_collection_health":                    "Whether scheduled collections keep up with the interval, from recent cycle durations (0=healthy, 1=degraded, 2=saturated)",
+	"winpower_exporter_scheduler_interval_seconds":           "Configured scheduler collection interval in seconds, as tuned in adaptive mode (0 with a cron schedule)",
+	"winpower_exporter_scheduler_tick_interval_seconds":      "Observed time between consecutive scheduler ticks in seconds",
+	"winpower_exporter_energy_degraded":                      "Whether energy is accumulated in memory only because storage is unavailable (1 = degraded, 0 = persisted)",
+	"winpower_exporter_energy_stalled":                       "Number of devices reporting power above zero whose energy has not advanced within the stall window",
+	"winpower_exporter_collections_throttled_total":          "Total number of /metrics requests served from the cached result because the collection limit was reached",
+	"winpower_exporter_metrics_staleness_seconds":            "Age in seconds of the collection the last /metrics response was served from (0 when it collected fresh data)",
+	"winpower_exporter_source_maintenance":                   "Whether WinPower reported maintenance mode in the last collection, whose data was discarded (1 = maintenance, 0 = normal)",
+	"winpower_exporter_config_reloads_total":                 "Total number of configuration reloads by result (success, validation_failed, error)",
+	"winpower_exporter_config_last_reload_timestamp_seconds": "Unix timestamp of the last successful configuration reload",
+	"winpower_exporter_shutdown_timeouts_total":              "Total number of modules abandoned during shutdown because they exceeded their shutdown deadline, by module",
+	"winpower_exporter_memory_bytes":                         "Memory usage in bytes",
+	"winpower_exporter_pushgateway_pushes_total":             "Total number of pushes to the Pushgateway by result (success, failure)",
+	"winpower_exporter_goroutines":                           "Number of goroutines of the exporter process",
+	"winpower_exporter_heap_bytes":                           "Bytes of allocated heap objects of the exporter process",
+
+	// WinPower connection and authentication metrics
+	"winpower_connection_status":         "WinPower connection status (1 = connected, 0 = disconnected)",
+	"winpower_auth_status":               "WinPower authentication status (1 = authenticated, 0 = not authenticated)",
+	"winpower_api_response_time_seconds": "WinPower API response time in seconds",
+
+	// Exporter self-monitoring metrics
+	"winpower_exporter_response_bytes":                "Size of WinPower API response bodies in bytes",
+	"winpower_exporter_parse_duration_seconds":        "Time spent parsing WinPower device data responses in seconds",
+	"winpower_exporter_request_retries":               "Number of retries WinPower device data requests needed before succeeding or giving up",
+	"winpower_exporter_device_count_mismatches_total": "Total number of WinPower device data responses whose reported device total differed from the devices they contained",
+	"winpower_exporter_invalid_timestamps_total":      "Total number of WinPower device timestamps that could not be parsed and were left unset",
+
+	// WinPower connection and authentication metrics
+	"winpower_token_expiry_seconds": "Remaining time until token expiry in seconds",
+	"winpower_token_valid":          "Whether the current token is valid (1 = valid, 0 = invalid)",
+
+	// Device metrics, keyed by their label-based name
+	"winpower_device_up":                          "Whether the last collection for the device succeeded (1 = success, 0 = failure)",
+	"winpower_device_scrape_errors_total":         "Total number of per-device collection errors",
+	"winpower_device_connected":                   "Device connection status (1 = connected, 0 = disconnected)",
+	"winpower_device_last_update_timestamp":       "Unix timestamp in seconds of the last device update",
+	"winpower_device_last_seen_timestamp_seconds": "Unix timestamp when the device was last reported by WinPower",
+	"winpower_device_input_voltage":               "Input voltage in volts",
+	"winpower_device_input_frequency":             "Input frequency in hertz",
+	"winpower_device_output_voltage":              "Output voltage in volts",
+	"winpower_device_output_current":              "Output current in amperes",
+	"winpower_device_output_frequency":            "Output frequency in hertz",
+	"winpower_device_output_voltage_type":         "Output voltage type (encoded as numeric value)",
+	"winpower_device_load_percent":                "Device load in percent",
+	"winpower_device_load_total_watts":            "Total load active power in watts (core metric for energy calculation)",
+	"winpower_device_load_total_va":               "Total load apparent power in volt-amperes",
+	"winpower_device_load_watts_phase1":           "Phase 1 active power in watts",
+	"winpower_device_load_va_phase1":              "Phase 1 apparent power in volt-amperes",
+	"winpower_power_watts":                        "Instantaneous power in watts (same as load_total_watts)",
+	"winpower_device_battery_charging":            "Battery charging status (1 = charging, 0 = not charging)",
+	"winpower_device_battery_voltage_percent":     "Battery voltage in percent",
+	"winpower_device_battery_capacity":            "Battery capacity in percent",
+	"winpower_device_battery_remain_seconds":      "Battery remaining time in seconds",
+	"winpower_device_battery_runtime_minutes":     "Estimated battery runtime remaining in minutes",
+	"winpower_device_battery_runtime_low":         "Whether the battery runtime is below the configured threshold (1 = low, 0 = ok)",
+	"winpower_device_battery_status":              "Battery status code (encoded as numeric value)",
+	"winpower_device_ups_temperature":             "UPS temperature in degrees Celsius",
+	"winpower_device_ups_mode":                    "UPS operating mode (encoded as numeric value)",
+	"winpower_device_ups_status":                  "UPS status code (encoded as numeric value)",
+	"winpower_device_ups_test_status":             "UPS test status code (encoded as numeric value)",
+	"winpower_device_ups_fault_code":              "UPS fault code (with fault_code label for aggregation)",
+	"winpower_device_cumulative_energy":           "Cumulative energy consumption in watt-hours",
+	"winpower_device_uptime_seconds":              "Seconds the device has been continuously reported (resets when the device disappears)",
+	"winpower_device_active_alarms":               "Number of alarms currently active on the device (0 = no alarms)",
+	"winpower_device_alarm_type_active":           "Number of alarms currently active on the device, by alarm type",
+	"winpower_device_type_code":                   "Device type as a numeric code from metrics.device_type_codes",
+	"winpower_device_reported_energy_wh":          "Cumulative energy in watt-hours as reported by WinPower, for cross-checking device_cumulative_energy",
+}
+
+// help returns the HELP text of the named metric: the configured override,
+// if any, otherwise the default
+func (m *MetricsService) help(name string) string {
+	if text, ok := m.helpOverrides[name]; ok {
+		return text
+	}
+	return defaultHelp[name]
+}
+
+// validateHelpOverrides checks that every override names a known metric and
+// has non-empty text
+func validateHelpOverrides(overrides map[string]string) error {
+	for name, text := range overrides {
+		if _, ok := defaultHelp[name]; !ok {
+			return fmt.Errorf("help_overrides: unknown metric %q", name)
+		}
+		if text == "" {
+			return fmt.Errorf("help_overrides[%s] must not be empty", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/collector"
+	"github.com/lay-g/winpower-g2-exporter/internal/metrics/mocks"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatherHelp returns the HELP text of every gathered metric family by name
+func gatherHelp(t *testing.T, service *MetricsService) map[string]string {
+	t.Helper()
+	families, err := service.registry.Gather()
+	require.NoError(t, err)
+
+	help := make(map[string]string, len(families))
+	for _, family := range families {
+		help[family.GetName()] = family.GetHelp()
+	}
+	return help
+}
+
+func TestMetricsService_help(t *testing.T) {
+	reported := 12.5
+
+	t.Run("every metric has default help", func(t *testing.T) {
+		config := DefaultMetricsConfig()
+		config.EnableRuntimeMetrics = true
+		config.EnableDeviceUptime = true
+		config.EnableReportedEnergy = true
+		config.EnableAlarmTypes = true
+		service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), config)
+		require.NoError(t, err)
+		require.NoError(t, service.updateDeviceMetrics("ups-1", &collector.DeviceCollectionInfo{
+			DeviceID:         "ups-1",
+			ReportedEnergyWh: &reported,
+			ActiveAlarms:     []string{"overload"},
+		}))
+
+		for name, help := range gatherHelp(t, service) {
+			assert.Contains(t, defaultHelp, name, "metric without default help")
+			assert.Equal(t, defaultHelp[name], help, name)
+		}
+	})
+
+	t.Run("overrides replace the default help", func(t *testing.T) {
+		config := DefaultMetricsConfig()
+		config.DeviceTypePrefixes = map[string]string{"1": "ups"}
+		config.HelpOverrides = map[string]string{
+			"winpower_exporter_up": "Exporter läuft (1 = ja, 0 = nein)",
+			"winpower_power_watts": "Momentanleistung in Watt",
+		}
+		require.NoError(t, config.Validate())
+		service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), config)
+		require.NoError(t, err)
+		require.NoError(t, service.updateDeviceMetrics("ups-1", &collector.DeviceCollectionInfo{DeviceType: 1}))
+		require.NoError(t, service.updateDeviceMetrics("other-1", &collector.DeviceCollectionInfo{DeviceType: 7}))
+
+		help := gatherHelp(t, service)
+		assert.Equal(t, "Exporter läuft (1 = ja, 0 = nein)", help["winpower_exporter_up"])
+		assert.Equal(t, "Momentanleistung in Watt", help["winpower_power_watts"])
+		assert.Equal(t, "Momentanleistung in Watt", help["winpower_ups_power_watts"], "prefixed name uses the label-based override")
+		assert.Equal(t, defaultHelp["winpower_device_input_voltage"], help["winpower_device_input_voltage"])
+	})
+
+	t.Run("overrides are validated", func(t *testing.T) {
+		config := DefaultMetricsConfig()
+		config.HelpOverrides = map[string]string{"winpower_ups_power_watts": "Power"}
+		assert.ErrorContains(t, config.Validate(), "unknown metric")
+
+		config.HelpOverrides = map[string]string{"winpower_power_watts": ""}
+		assert.ErrorContains(t, config.Validate(), "must not be empty")
+	})
+}
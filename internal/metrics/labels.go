@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+// sanitizeLabelValue makes a value safe to use as a Prometheus label value.
+// Invalid UTF-8 is replaced with U+FFFD, control characters (newlines, tabs,
+// etc.) are replaced with spaces, surrounding whitespace is trimmed and the
+// result is truncated to maxLen runes (0 = unlimited). It reports whether
+// the value was modified.
+func sanitizeLabelValue(value string, maxLen int) (string, bool) {
+	sanitized := strings.ToValidUTF8(value, string(utf8.RuneError))
+	sanitized = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, sanitized)
+	sanitized = strings.TrimSpace(sanitized)
+
+	if maxLen > 0 && utf8.RuneCountInString(sanitized) > maxLen {
+		sanitized = string([]rune(sanitized)[:maxLen])
+	}
+
+	return sanitized, sanitized != value
+}
+
+// labelValue sanitizes a label value and logs when it had to be modified
+func (m *MetricsService) labelValue(label, value string) string {
+	sanitized, modified := sanitizeLabelValue(value, m.maxLabelLength)
+	if modified {
+		m.logger.Warn("Sanitized metric label value",
+			log.String("label", label),
+			log.String("original", value),
+			log.String("sanitized", sanitized),
+		)
+	}
+	return sanitized
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/collector"
+	"github.com/lay-g/winpower-g2-exporter/internal/metrics/mocks"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+func TestSanitizeLabelValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		maxLen   int
+		want     string
+		modified bool
+	}{
+		{name: "clean value", value: "UPS-01", maxLen: 128, want: "UPS-01"},
+		{name: "newline replaced", value: "UPS\n01", maxLen: 128, want: "UPS 01", modified: true},
+		{name: "control characters", value: "UPS\t\x00\x1b01", maxLen: 128, want: "UPS   01", modified: true},
+		{name: "surrounding whitespace trimmed", value: " UPS-01\r\n", maxLen: 128, want: "UPS-01", modified: true},
+		{name: "invalid utf-8", value: "UPS\xff", maxLen: 128, want: "UPS�", modified: true},
+		{name: "truncated by rune", value: "机房一号UPS", maxLen: 4, want: "机房一号", modified: true},
+		{name: "unlimited length", value: "UPS-0000001", maxLen: 0, want: "UPS-0000001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, modified := sanitizeLabelValue(tt.value, tt.maxLen)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.modified, modified)
+		})
+	}
+}
+
+func TestMetricsService_sanitizesDeviceLabels(t *testing.T) {
+	config := DefaultMetricsConfig()
+	config.MaxLabelValueLength = 8
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), config)
+	require.NoError(t, err)
+
+	err = service.updateDeviceMetrics("ups-1", &collector.DeviceCollectionInfo{
+		DeviceName: "Server\nRoom UPS",
+		FaultCode:  "E01\n",
+	})
+	require.NoError(t, err)
+
+	families, err := service.registry.Gather()
+	require.NoError(t, err)
+
+	found := false
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				switch label.GetName() {
+				case labelDeviceName:
+					found = true
+					assert.Equal(t, "Server R", label.GetValue())
+				case labelFaultCode:
+					assert.Equal(t, "E01", label.GetValue())
+				}
+			}
+		}
+	}
+	assert.True(t, found, "expected device_name label")
+}
@@ -1,6 +1,10 @@
 package metrics
 
 import (
+	"maps"
+	"strconv"
+	"strings"
+
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -14,9 +18,30 @@ const (
 	labelDeviceID     = "device_id"
 	labelDeviceName   = "device_name"
 	labelDeviceType   = "device_type"
+	labelDeviceGroup  = "device_group"
 	labelFaultCode    = "fault_code"
 	labelMemoryType   = "type"
 	labelErrorType    = "error_type"
+	labelResult       = "result"
+	labelField        = "field"
+	labelModule       = "module"
+	labelAlarmType    = "alarm_type"
+	labelResetEpoch   = "reset_epoch"
+)
+
+// Results of a configuration reload, used as the result label of
+// winpower_exporter_config_reloads_total
+const (
+	// ConfigReloadSuccess indicates the reloaded configuration was applied
+	ConfigReloadSuccess = "success"
+
+	// ConfigReloadValidationFailed indicates the configuration was loaded but
+	// rejected by validation; the running configuration is kept
+	ConfigReloadValidationFailed = "validation_failed"
+
+	// ConfigReloadError indicates the configuration could not be loaded or
+	// applied
+	ConfigReloadError = "error"
 )
 
 var (
@@ -24,6 +49,14 @@ var (
 	durationBuckets = []float64{0.05, 0.1, 0.2, 0.5, 1, 2, 5}
 	// API response time buckets (shorter range)
 	apiResponseBuckets = []float64{0.05, 0.1, 0.2, 0.5, 1}
+	// Response body size buckets, 1 KiB to 16 MiB
+	responseBytesBuckets = prometheus.ExponentialBuckets(1024, 4, 8)
+	// Parse duration buckets (parsing is usually well below a millisecond)
+	parseDurationBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5}
+	// Request retry buckets; +Inf collects three or more retries
+	requestRetriesBuckets = []float64{0, 1, 2}
+	// Scheduler tick interval buckets, covering the allowed intervals of 1s to 1h
+	tickIntervalBuckets = []float64{1, 2, 5, 10, 15, 30, 60, 120, 300, 600, 1800, 3600}
 )
 
 // initExporterMetrics initializes exporter self-monitoring metrics
@@ -34,7 +67,7 @@ func (m *MetricsService) initExporterMetrics(config *MetricsConfig) {
 		Namespace:   namespace,
 		Subsystem:   subsystem,
 		Name:        "up",
-		Help:        "Whether the WinPower exporter is running (1 = up, 0 = down)",
+		Help:        m.help("winpower_exporter_up"),
 		ConstLabels: labels,
 	})
 
@@ -42,7 +75,7 @@ func (m *MetricsService) initExporterMetrics(config *MetricsConfig) {
 		Namespace:   namespace,
 		Subsystem:   subsystem,
 		Name:        "requests_total",
-		Help:        "Total number of HTTP requests to the /metrics endpoint",
+		Help:        m.help("winpower_exporter_requests_total"),
 		ConstLabels: labels,
 	}, []string{})
 
@@ -50,16 +83,25 @@ func (m *MetricsService) initExporterMetrics(config *MetricsConfig) {
 		Namespace:   namespace,
 		Subsystem:   subsystem,
 		Name:        "request_duration_seconds",
-		Help:        "HTTP request duration in seconds",
+		Help:        m.help("winpower_exporter_request_duration_seconds"),
 		Buckets:     durationBuckets,
 		ConstLabels: labels,
 	}, []string{})
 
+	m.scrapeHandlerDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "scrape_handler_duration_seconds",
+		Help:        m.help("winpower_exporter_scrape_handler_duration_seconds"),
+		Buckets:     durationBuckets,
+		ConstLabels: labels,
+	})
+
 	m.collectionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace:   namespace,
 		Subsystem:   subsystem,
 		Name:        "collection_duration_seconds",
-		Help:        "Data collection and calculation duration in seconds",
+		Help:        m.help("winpower_exporter_collection_duration_seconds"),
 		Buckets:     durationBuckets,
 		ConstLabels: labels,
 	}, []string{})
@@ -68,7 +110,7 @@ func (m *MetricsService) initExporterMetrics(config *MetricsConfig) {
 		Namespace:   namespace,
 		Subsystem:   subsystem,
 		Name:        "scrape_errors_total",
-		Help:        "Total number of data collection errors",
+		Help:        m.help("winpower_exporter_scrape_errors_total"),
 		ConstLabels: labels,
 	}, []string{labelErrorType})
 
@@ -76,15 +118,18 @@ func (m *MetricsService) initExporterMetrics(config *MetricsConfig) {
 		Namespace:   namespace,
 		Subsystem:   subsystem,
 		Name:        "token_refresh_total",
-		Help:        "Total number of token refreshes",
+		Help:        m.help("winpower_exporter_token_refresh_total"),
 		ConstLabels: labels,
-	}, []string{})
+	}, []string{labelResult})
+	// Export both series from the start so that failures can be rated at once
+	m.tokenRefreshTotal.WithLabelValues("success")
+	m.tokenRefreshTotal.WithLabelValues("failure")
 
 	m.deviceCount = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace:   namespace,
 		Subsystem:   subsystem,
 		Name:        "device_count",
-		Help:        "Number of discovered devices",
+		Help:        m.help("winpower_exporter_device_count"),
 		ConstLabels: labels,
 	})
 
@@ -92,19 +137,190 @@ func (m *MetricsService) initExporterMetrics(config *MetricsConfig) {
 		Namespace:   namespace,
 		Subsystem:   subsystem,
 		Name:        "last_collection_time_seconds",
-		Help:        "Unix timestamp of the last successful collection",
+		Help:        m.help("winpower_exporter_last_collection_time_seconds"),
+		ConstLabels: labels,
+	})
+
+	m.lastCollectionTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "last_collection_timestamp_seconds",
+		Help:        m.help("winpower_exporter_last_collection_timestamp_seconds"),
+		ConstLabels: labels,
+	})
+
+	m.devicesEvicted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "devices_evicted_total",
+		Help:        m.help("winpower_exporter_devices_evicted_total"),
+		ConstLabels: labels,
+	})
+
+	m.invalidValuesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "invalid_value_total",
+		Help:        m.help("winpower_exporter_invalid_value_total"),
+		ConstLabels: labels,
+	}, []string{labelField})
+
+	m.missingOptionalFields = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "missing_optional_fields",
+		Help:        m.help("winpower_exporter_missing_optional_fields"),
+		ConstLabels: labels,
+	}, []string{labelField})
+
+	m.schedulerPaused = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "scheduler_paused",
+		Help:        m.help("winpower_exporter_scheduler_paused"),
+		ConstLabels: labels,
+	})
+
+	m.schedulerOverruns = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "scheduler_overruns_total",
+		Help:        m.help("winpower_exporter_scheduler_overruns_total"),
+		ConstLabels: labels,
+	})
+
+	m.schedulerInterval = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "scheduler_interval_seconds",
+		Help:        m.help("winpower_exporter_scheduler_interval_seconds"),
+		ConstLabels: labels,
+	})
+
+	m.collectionHealth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "collection_health",
+		Help:        m.help("winpower_exporter_collection_health"),
 		ConstLabels: labels,
 	})
 
+	m.schedulerTickInterval = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "scheduler_tick_interval_seconds",
+		Help:        m.help("winpower_exporter_scheduler_tick_interval_seconds"),
+		Buckets:     tickIntervalBuckets,
+		ConstLabels: labels,
+	})
+
+	m.energyDegraded = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "energy_degraded",
+		Help:        m.help("winpower_exporter_energy_degraded"),
+		ConstLabels: labels,
+	})
+
+	m.energyStalled = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "energy_stalled",
+		Help:        m.help("winpower_exporter_energy_stalled"),
+		ConstLabels: labels,
+	})
+
+	m.collectionsThrottled = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "collections_throttled_total",
+		Help:        m.help("winpower_exporter_collections_throttled_total"),
+		ConstLabels: labels,
+	})
+
+	m.metricsStaleness = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "metrics_staleness_seconds",
+		Help:        m.help("winpower_exporter_metrics_staleness_seconds"),
+		ConstLabels: labels,
+	})
+
+	m.sourceMaintenance = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "source_maintenance",
+		Help:        m.help("winpower_exporter_source_maintenance"),
+		ConstLabels: labels,
+	})
+
+	m.configReloadsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "config_reloads_total",
+		Help:        m.help("winpower_exporter_config_reloads_total"),
+		ConstLabels: labels,
+	}, []string{labelResult})
+	// Export every result from the start so that rejected reloads can be alerted on
+	for _, result := range []string{ConfigReloadSuccess, ConfigReloadValidationFailed, ConfigReloadError} {
+		m.configReloadsTotal.WithLabelValues(result)
+	}
+
+	m.shutdownTimeoutsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "shutdown_timeouts_total",
+		Help:        m.help("winpower_exporter_shutdown_timeouts_total"),
+		ConstLabels: labels,
+	}, []string{labelModule})
+
+	m.configLastReload = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "config_last_reload_timestamp_seconds",
+		Help:        m.help("winpower_exporter_config_last_reload_timestamp_seconds"),
+		ConstLabels: labels,
+	})
+
+	if m.pusher != nil {
+		m.pushesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   subsystem,
+			Name:        "pushgateway_pushes_total",
+			Help:        m.help("winpower_exporter_pushgateway_pushes_total"),
+			ConstLabels: labels,
+		}, []string{labelResult})
+		for _, result := range []string{PushSuccess, PushFailure} {
+			m.pushesTotal.WithLabelValues(result)
+		}
+	}
+
 	if config.EnableMemoryMetrics {
 		m.memoryBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 			Namespace:   namespace,
 			Subsystem:   subsystem,
 			Name:        "memory_bytes",
-			Help:        "Memory usage in bytes",
+			Help:        m.help("winpower_exporter_memory_bytes"),
 			ConstLabels: labels,
 		}, []string{labelMemoryType})
 	}
+
+	if config.EnableRuntimeMetrics {
+		m.goroutines = prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Subsystem:   subsystem,
+			Name:        "goroutines",
+			Help:        m.help("winpower_exporter_goroutines"),
+			ConstLabels: labels,
+		})
+		m.heapBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Subsystem:   subsystem,
+			Name:        "heap_bytes",
+			Help:        m.help("winpower_exporter_heap_bytes"),
+			ConstLabels: labels,
+		})
+	}
 }
 
 // initConnectionMetrics initializes WinPower connection and authentication metrics
@@ -114,36 +330,79 @@ func (m *MetricsService) initConnectionMetrics(config *MetricsConfig) {
 	m.connectionStatus = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace:   namespace,
 		Name:        "connection_status",
-		Help:        "WinPower connection status (1 = connected, 0 = disconnected)",
+		Help:        m.help("winpower_connection_status"),
 		ConstLabels: labels,
 	})
 
 	m.authStatus = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace:   namespace,
 		Name:        "auth_status",
-		Help:        "WinPower authentication status (1 = authenticated, 0 = not authenticated)",
+		Help:        m.help("winpower_auth_status"),
 		ConstLabels: labels,
 	})
 
 	m.apiResponseTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace:   namespace,
 		Name:        "api_response_time_seconds",
-		Help:        "WinPower API response time in seconds",
+		Help:        m.help("winpower_api_response_time_seconds"),
 		Buckets:     apiResponseBuckets,
 		ConstLabels: labels,
 	}, []string{})
 
+	m.responseBytes = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "response_bytes",
+		Help:        m.help("winpower_exporter_response_bytes"),
+		Buckets:     responseBytesBuckets,
+		ConstLabels: labels,
+	})
+
+	m.parseDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "parse_duration_seconds",
+		Help:        m.help("winpower_exporter_parse_duration_seconds"),
+		Buckets:     parseDurationBuckets,
+		ConstLabels: labels,
+	})
+
+	m.requestRetries = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "request_retries",
+		Help:        m.help("winpower_exporter_request_retries"),
+		Buckets:     requestRetriesBuckets,
+		ConstLabels: labels,
+	})
+
+	m.countMismatches = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "device_count_mismatches_total",
+		Help:        m.help("winpower_exporter_device_count_mismatches_total"),
+		ConstLabels: labels,
+	})
+
+	m.invalidTimestamps = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        "invalid_timestamps_total",
+		Help:        m.help("winpower_exporter_invalid_timestamps_total"),
+		ConstLabels: labels,
+	})
+
 	m.tokenExpirySeconds = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace:   namespace,
 		Name:        "token_expiry_seconds",
-		Help:        "Remaining time until token expiry in seconds",
+		Help:        m.help("winpower_token_expiry_seconds"),
 		ConstLabels: labels,
 	})
 
 	m.tokenValid = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace:   namespace,
 		Name:        "token_valid",
-		Help:        "Whether the current token is valid (1 = valid, 0 = invalid)",
+		Help:        m.help("winpower_token_valid"),
 		ConstLabels: labels,
 	})
 }
@@ -154,20 +413,53 @@ func (m *MetricsService) registerMetrics() {
 	m.registry.MustRegister(m.exporterUp)
 	m.registry.MustRegister(m.requestsTotal)
 	m.registry.MustRegister(m.requestDuration)
+	m.registry.MustRegister(m.scrapeHandlerDuration)
 	m.registry.MustRegister(m.collectionDuration)
 	m.registry.MustRegister(m.scrapeErrorsTotal)
 	m.registry.MustRegister(m.tokenRefreshTotal)
 	m.registry.MustRegister(m.deviceCount)
 	m.registry.MustRegister(m.lastCollectionTimeSeconds)
+	m.registry.MustRegister(m.lastCollectionTimestamp)
+	m.registry.MustRegister(m.collectionsThrottled)
+	m.registry.MustRegister(m.metricsStaleness)
+	m.registry.MustRegister(m.sourceMaintenance)
+	m.registry.MustRegister(m.devicesEvicted)
+	m.registry.MustRegister(m.invalidValuesTotal)
+	m.registry.MustRegister(m.missingOptionalFields)
+	m.registry.MustRegister(m.schedulerPaused)
+	m.registry.MustRegister(m.schedulerOverruns)
+	m.registry.MustRegister(m.schedulerInterval)
+	m.registry.MustRegister(m.collectionHealth)
+	m.registry.MustRegister(m.schedulerTickInterval)
+	m.registry.MustRegister(m.energyDegraded)
+	m.registry.MustRegister(m.energyStalled)
+	m.registry.MustRegister(m.configReloadsTotal)
+	m.registry.MustRegister(m.configLastReload)
+	m.registry.MustRegister(m.shutdownTimeoutsTotal)
 
 	if m.memoryBytes != nil {
 		m.registry.MustRegister(m.memoryBytes)
 	}
+	if m.goroutines != nil {
+		m.registry.MustRegister(m.goroutines, m.heapBytes)
+	}
+	if m.pushesTotal != nil {
+		m.registry.MustRegister(m.pushesTotal)
+	}
+
+	if m.batchUpdates {
+		m.registry.MustRegister(&deviceBatchCollector{service: m})
+	}
 
 	// Register connection metrics
 	m.registry.MustRegister(m.connectionStatus)
 	m.registry.MustRegister(m.authStatus)
 	m.registry.MustRegister(m.apiResponseTime)
+	m.registry.MustRegister(m.responseBytes)
+	m.registry.MustRegister(m.parseDuration)
+	m.registry.MustRegister(m.requestRetries)
+	m.registry.MustRegister(m.countMismatches)
+	m.registry.MustRegister(m.invalidTimestamps)
 	m.registry.MustRegister(m.tokenExpirySeconds)
 	m.registry.MustRegister(m.tokenValid)
 
@@ -177,205 +469,429 @@ func (m *MetricsService) registerMetrics() {
 
 // createDeviceMetrics creates a new DeviceMetrics instance for a device
 func (m *MetricsService) createDeviceMetrics(deviceID, deviceName, deviceType, winpowerHost string) *DeviceMetrics {
+	// Optional device-type-specific names, e.g. winpower_ups_power_watts
+	prefix := m.deviceTypePrefixes[deviceType]
+	name := func(base string) string {
+		return deviceMetricName(prefix, base)
+	}
+
 	labels := prometheus.Labels{
 		labelWinPowerHost: winpowerHost,
 		labelDeviceID:     deviceID,
 		labelDeviceName:   deviceName,
 		labelDeviceType:   deviceType,
 	}
+	if m.deviceGroups != nil {
+		labels[labelDeviceGroup] = m.deviceGroupOf(deviceID)
+	}
 
 	dm := &DeviceMetrics{
 		// Device status
+		up: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        name("device_up"),
+			Help:        m.help("winpower_device_up"),
+			ConstLabels: labels,
+		}),
+		scrapeErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        name("device_scrape_errors_total"),
+			Help:        m.help("winpower_device_scrape_errors_total"),
+			ConstLabels: labels,
+		}, []string{labelErrorType}),
 		connected: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_connected",
-			Help:        "Device connection status (1 = connected, 0 = disconnected)",
+			Name:        name("device_connected"),
+			Help:        m.help("winpower_device_connected"),
 			ConstLabels: labels,
 		}),
 		lastUpdateTimestamp: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_last_update_timestamp",
-			Help:        "Unix timestamp of the last device update",
+			Name:        name("device_last_update_timestamp"),
+			Help:        m.help("winpower_device_last_update_timestamp"),
+			ConstLabels: labels,
+		}),
+		lastSeenTimestamp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        name("device_last_seen_timestamp_seconds"),
+			Help:        m.help("winpower_device_last_seen_timestamp_seconds"),
 			ConstLabels: labels,
 		}),
 
 		// Input electrical parameters
 		inputVoltage: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_input_voltage",
-			Help:        "Input voltage in volts",
+			Name:        name("device_input_voltage"),
+			Help:        m.help("winpower_device_input_voltage"),
 			ConstLabels: labels,
 		}),
 		inputFrequency: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_input_frequency",
-			Help:        "Input frequency in hertz",
+			Name:        name("device_input_frequency"),
+			Help:        m.help("winpower_device_input_frequency"),
 			ConstLabels: labels,
 		}),
 
 		// Output electrical parameters
 		outputVoltage: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_output_voltage",
-			Help:        "Output voltage in volts",
+			Name:        name("device_output_voltage"),
+			Help:        m.help("winpower_device_output_voltage"),
 			ConstLabels: labels,
 		}),
 		outputCurrent: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_output_current",
-			Help:        "Output current in amperes",
+			Name:        name("device_output_current"),
+			Help:        m.help("winpower_device_output_current"),
 			ConstLabels: labels,
 		}),
 		outputFrequency: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_output_frequency",
-			Help:        "Output frequency in hertz",
+			Name:        name("device_output_frequency"),
+			Help:        m.help("winpower_device_output_frequency"),
 			ConstLabels: labels,
 		}),
 		outputVoltageType: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_output_voltage_type",
-			Help:        "Output voltage type (encoded as numeric value)",
+			Name:        name("device_output_voltage_type"),
+			Help:        m.help("winpower_device_output_voltage_type"),
 			ConstLabels: labels,
 		}),
 
 		// Load and power
 		loadPercent: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_load_percent",
-			Help:        "Device load percentage",
+			Name:        name("device_load_percent"),
+			Help:        m.help("winpower_device_load_percent"),
 			ConstLabels: labels,
 		}),
 		loadTotalWatt: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_load_total_watts",
-			Help:        "Total load active power in watts (core metric for energy calculation)",
+			Name:        name("device_load_total_watts"),
+			Help:        m.help("winpower_device_load_total_watts"),
 			ConstLabels: labels,
 		}),
 		loadTotalVa: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_load_total_va",
-			Help:        "Total load apparent power in volt-amperes",
+			Name:        name("device_load_total_va"),
+			Help:        m.help("winpower_device_load_total_va"),
 			ConstLabels: labels,
 		}),
 		loadWattPhase1: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_load_watts_phase1",
-			Help:        "Phase 1 active power in watts",
+			Name:        name("device_load_watts_phase1"),
+			Help:        m.help("winpower_device_load_watts_phase1"),
 			ConstLabels: labels,
 		}),
 		loadVaPhase1: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_load_va_phase1",
-			Help:        "Phase 1 apparent power in volt-amperes",
+			Name:        name("device_load_va_phase1"),
+			Help:        m.help("winpower_device_load_va_phase1"),
 			ConstLabels: labels,
 		}),
 		powerWatts: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "power_watts",
-			Help:        "Instantaneous power in watts (same as load_total_watts)",
+			Name:        name("power_watts"),
+			Help:        m.help("winpower_power_watts"),
 			ConstLabels: labels,
 		}),
 
 		// Battery parameters
 		batteryCharging: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_battery_charging",
-			Help:        "Battery charging status (1 = charging, 0 = not charging)",
+			Name:        name("device_battery_charging"),
+			Help:        m.help("winpower_device_battery_charging"),
 			ConstLabels: labels,
 		}),
 		batteryVoltagePercent: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_battery_voltage_percent",
-			Help:        "Battery voltage percentage",
+			Name:        name("device_battery_voltage_percent"),
+			Help:        m.help("winpower_device_battery_voltage_percent"),
 			ConstLabels: labels,
 		}),
 		batteryCapacity: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_battery_capacity",
-			Help:        "Battery capacity percentage",
+			Name:        name("device_battery_capacity"),
+			Help:        m.help("winpower_device_battery_capacity"),
 			ConstLabels: labels,
 		}),
 		batteryRemainSeconds: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_battery_remain_seconds",
-			Help:        "Battery remaining time in seconds",
+			Name:        name("device_battery_remain_seconds"),
+			Help:        m.help("winpower_device_battery_remain_seconds"),
+			ConstLabels: labels,
+		}),
+		batteryRuntimeMinutes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        name("device_battery_runtime_minutes"),
+			Help:        m.help("winpower_device_battery_runtime_minutes"),
+			ConstLabels: labels,
+		}),
+		batteryRuntimeLow: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        name("device_battery_runtime_low"),
+			Help:        m.help("winpower_device_battery_runtime_low"),
 			ConstLabels: labels,
 		}),
 		batteryStatus: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_battery_status",
-			Help:        "Battery status code (encoded as numeric value)",
+			Name:        name("device_battery_status"),
+			Help:        m.help("winpower_device_battery_status"),
 			ConstLabels: labels,
 		}),
 
 		// UPS status
 		upsTemperature: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_ups_temperature",
-			Help:        "UPS temperature in Celsius",
+			Name:        name("device_ups_temperature"),
+			Help:        m.help("winpower_device_ups_temperature"),
 			ConstLabels: labels,
 		}),
 		upsMode: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_ups_mode",
-			Help:        "UPS operating mode (encoded as numeric value)",
+			Name:        name("device_ups_mode"),
+			Help:        m.help("winpower_device_ups_mode"),
 			ConstLabels: labels,
 		}),
 		upsStatus: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_ups_status",
-			Help:        "UPS status code (encoded as numeric value)",
+			Name:        name("device_ups_status"),
+			Help:        m.help("winpower_device_ups_status"),
 			ConstLabels: labels,
 		}),
 		upsTestStatus: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_ups_test_status",
-			Help:        "UPS test status code (encoded as numeric value)",
+			Name:        name("device_ups_test_status"),
+			Help:        m.help("winpower_device_ups_test_status"),
 			ConstLabels: labels,
 		}),
 		upsFaultCode: prometheus.NewGaugeVec(prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_ups_fault_code",
-			Help:        "UPS fault code (with fault_code label for aggregation)",
+			Name:        name("device_ups_fault_code"),
+			Help:        m.help("winpower_device_ups_fault_code"),
 			ConstLabels: labels,
 		}, []string{labelFaultCode}),
+		activeAlarms: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        name("device_active_alarms"),
+			Help:        m.help("winpower_device_active_alarms"),
+			ConstLabels: labels,
+		}),
 
 		// Energy
-		cumulativeEnergy: prometheus.NewGauge(prometheus.GaugeOpts{
+		energyOpts: prometheus.GaugeOpts{
 			Namespace:   namespace,
-			Name:        "device_cumulative_energy",
-			Help:        "Cumulative energy consumption in watt-hours",
+			Name:        name("device_cumulative_energy"),
+			Help:        m.help("winpower_device_cumulative_energy"),
 			ConstLabels: labels,
-		}),
+		},
+	}
+	dm.cumulativeEnergy = m.newEnergyGauge(dm)
+
+	if m.deviceUptime {
+		dm.uptimeSeconds = prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        name("device_uptime_seconds"),
+			Help:        m.help("winpower_device_uptime_seconds"),
+			ConstLabels: labels,
+		})
+	}
+
+	if m.alarmTypes {
+		dm.alarmTypes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        name("device_alarm_type_active"),
+			Help:        m.help("winpower_device_alarm_type_active"),
+			ConstLabels: labels,
+		}, []string{labelAlarmType})
 	}
 
-	// Register all device metrics
-	m.registry.MustRegister(dm.connected)
-	m.registry.MustRegister(dm.lastUpdateTimestamp)
-	m.registry.MustRegister(dm.inputVoltage)
-	m.registry.MustRegister(dm.inputFrequency)
-	m.registry.MustRegister(dm.outputVoltage)
-	m.registry.MustRegister(dm.outputCurrent)
-	m.registry.MustRegister(dm.outputFrequency)
-	m.registry.MustRegister(dm.outputVoltageType)
-	m.registry.MustRegister(dm.loadPercent)
-	m.registry.MustRegister(dm.loadTotalWatt)
-	m.registry.MustRegister(dm.loadTotalVa)
-	m.registry.MustRegister(dm.loadWattPhase1)
-	m.registry.MustRegister(dm.loadVaPhase1)
-	m.registry.MustRegister(dm.powerWatts)
-	m.registry.MustRegister(dm.batteryCharging)
-	m.registry.MustRegister(dm.batteryVoltagePercent)
-	m.registry.MustRegister(dm.batteryCapacity)
-	m.registry.MustRegister(dm.batteryRemainSeconds)
-	m.registry.MustRegister(dm.batteryStatus)
-	m.registry.MustRegister(dm.upsTemperature)
-	m.registry.MustRegister(dm.upsMode)
-	m.registry.MustRegister(dm.upsStatus)
-	m.registry.MustRegister(dm.upsTestStatus)
-	m.registry.MustRegister(dm.upsFaultCode)
-	m.registry.MustRegister(dm.cumulativeEnergy)
+	if m.deviceTypeCode {
+		// The type is already a label; the code is exported without it so
+		// that the series is keyed by the device alone
+		codeLabels := prometheus.Labels{
+			labelWinPowerHost: winpowerHost,
+			labelDeviceID:     deviceID,
+			labelDeviceName:   deviceName,
+		}
+		if group, ok := labels[labelDeviceGroup]; ok {
+			codeLabels[labelDeviceGroup] = group
+		}
+		dm.typeCode = prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        name("device_type_code"),
+			Help:        m.help("winpower_device_type_code"),
+			ConstLabels: codeLabels,
+		})
+		dm.typeCode.Set(float64(m.deviceTypeCodeOf(deviceType)))
+	}
+
+	if m.reportedEnergy {
+		// Registered by updateReportedEnergy once the device reports energy
+		dm.reportedEnergy = prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        name("device_reported_energy_wh"),
+			Help:        m.help("winpower_device_reported_energy_wh"),
+			ConstLabels: labels,
+		})
+	}
 
 	return dm
 }
+
+// newEnergyGauge creates the cumulative energy gauge of a device, labeled
+// with its reset epoch when enabled
+func (m *MetricsService) newEnergyGauge(dm *DeviceMetrics) prometheus.Gauge {
+	opts := dm.energyOpts
+	if m.energyResetEpoch {
+		opts.ConstLabels = make(prometheus.Labels, len(dm.energyOpts.ConstLabels)+1)
+		maps.Copy(opts.ConstLabels, dm.energyOpts.ConstLabels)
+		opts.ConstLabels[labelResetEpoch] = strconv.FormatUint(dm.resetEpoch, 10)
+	}
+	return prometheus.NewGauge(opts)
+}
+
+// registerDeviceMetrics registers the series of a device; batched updates
+// serve them from snapshots instead
+func (m *MetricsService) registerDeviceMetrics(dm *DeviceMetrics) {
+	if m.batchUpdates {
+		return
+	}
+	for _, c := range dm.collectors() {
+		m.registry.MustRegister(c)
+	}
+}
+
+// deviceTypeCodeOf returns the code of a device type, or the default code
+// for unmapped types
+func (m *MetricsService) deviceTypeCodeOf(deviceType string) int {
+	if code, ok := m.typeCodes[deviceType]; ok {
+		return code
+	}
+	return m.defaultTypeCode
+}
+
+// deviceGroupOf returns the group of a device, or the default group for
+// unmapped devices
+func (m *MetricsService) deviceGroupOf(deviceID string) string {
+	if group, ok := m.deviceGroups[strings.ToLower(deviceID)]; ok {
+		return group
+	}
+	return m.defaultGroup
+}
+
+// deviceMetricName returns the name of a device metric. With a device type
+// prefix the leading "device_" is replaced by the prefix, so that
+// device_input_voltage becomes ups_input_voltage and power_watts becomes
+// ups_power_watts. Names already starting with the prefix are kept as is.
+func deviceMetricName(prefix, base string) string {
+	if prefix == "" {
+		return base
+	}
+	base = strings.TrimPrefix(base, "device_")
+	if strings.HasPrefix(base, prefix+"_") {
+		return base
+	}
+	return prefix + "_" + base
+}
+
+// collectors returns every exported Prometheus collector owned by the
+// device. A preloaded device only exports its cumulative energy.
+func (dm *DeviceMetrics) collectors() []prometheus.Collector {
+	if dm.preloaded {
+		return []prometheus.Collector{dm.cumulativeEnergy}
+	}
+
+	collectors := []prometheus.Collector{
+		dm.up,
+		dm.scrapeErrors,
+		dm.connected,
+		dm.lastUpdateTimestamp,
+		dm.lastSeenTimestamp,
+		dm.inputVoltage,
+		dm.inputFrequency,
+		dm.outputVoltage,
+		dm.outputCurrent,
+		dm.outputFrequency,
+		dm.outputVoltageType,
+		dm.loadPercent,
+		dm.loadTotalWatt,
+		dm.loadTotalVa,
+		dm.loadWattPhase1,
+		dm.loadVaPhase1,
+		dm.powerWatts,
+		dm.batteryCharging,
+		dm.batteryVoltagePercent,
+		dm.batteryCapacity,
+		dm.batteryRemainSeconds,
+		dm.batteryRuntimeMinutes,
+		dm.batteryRuntimeLow,
+		dm.batteryStatus,
+		dm.upsTemperature,
+		dm.upsMode,
+		dm.upsStatus,
+		dm.upsTestStatus,
+		dm.upsFaultCode,
+		dm.activeAlarms,
+		dm.cumulativeEnergy,
+	}
+	if dm.uptimeSeconds != nil {
+		collectors = append(collectors, dm.uptimeSeconds)
+	}
+	if dm.typeCode != nil {
+		collectors = append(collectors, dm.typeCode)
+	}
+	if dm.alarmTypes != nil {
+		collectors = append(collectors, dm.alarmTypes)
+	}
+	if dm.reportsEnergy {
+		collectors = append(collectors, dm.reportedEnergy)
+	}
+
+	if len(dm.suppressed) == 0 {
+		return collectors
+	}
+	exported := collectors[:0]
+	for _, c := range collectors {
+		if !dm.suppressed[c] {
+			exported = append(exported, c)
+		}
+	}
+	return exported
+}
+
+// fieldCollectors returns the series exporting a canonical WinPower realtime
+// field
+func (dm *DeviceMetrics) fieldCollectors(field string) []prometheus.Collector {
+	switch field {
+	case "input_volt_1":
+		return []prometheus.Collector{dm.inputVoltage}
+	case "input_freq":
+		return []prometheus.Collector{dm.inputFrequency}
+	case "output_volt_1":
+		return []prometheus.Collector{dm.outputVoltage}
+	case "output_current_1":
+		return []prometheus.Collector{dm.outputCurrent}
+	case "output_freq":
+		return []prometheus.Collector{dm.outputFrequency}
+	case "load_percent":
+		return []prometheus.Collector{dm.loadPercent}
+	case "load_total_watt":
+		return []prometheus.Collector{dm.loadTotalWatt, dm.powerWatts}
+	case "load_total_va":
+		return []prometheus.Collector{dm.loadTotalVa}
+	case "load_watt_1":
+		return []prometheus.Collector{dm.loadWattPhase1}
+	case "load_va_1":
+		return []prometheus.Collector{dm.loadVaPhase1}
+	case "bat_volt_p":
+		return []prometheus.Collector{dm.batteryVoltagePercent}
+	case "bat_capacity":
+		return []prometheus.Collector{dm.batteryCapacity}
+	case "ups_temperature":
+		return []prometheus.Collector{dm.upsTemperature}
+	}
+	// energy_total_wh is not reported at all when it is invalid, and
+	// load_avg_watt only feeds the energy calculation
+	return nil
+}
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+// PreloadedDevice is a device whose persisted energy is exported before it
+// is first collected
+type PreloadedDevice struct {
+	DeviceLabels
+	EnergyWh float64
+
+	// ResetEpoch is the persisted reset epoch of the energy
+	ResetEpoch uint64
+}
+
+// SetDeviceLabelRecorder sets the recorder that persists the labels of the
+// tracked devices. The labels are recorded at the end of every update cycle
+// in which a device was added or removed or its energy key changed.
+func (m *MetricsService) SetDeviceLabelRecorder(recorder DeviceLabelRecorder) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.labelRecorder = recorder
+}
+
+// PreloadDevices exports the persisted cumulative energy of devices before
+// they are first collected, so that winpower_device_cumulative_energy
+// continues from the stored value after a restart instead of dropping to 0
+// until the first collection completes.
+//
+// Only the energy series of a preloaded device is exported. When the device
+// is collected with the preloaded name and type its other series are added;
+// with other labels the series are recreated with the reported ones. A
+// preloaded device that a successful collection does not report is removed.
+// Devices that are already tracked, or that exceed the device cap, are not
+// preloaded.
+func (m *MetricsService) PreloadDevices(devices []PreloadedDevice) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	preloaded := 0
+	for _, device := range devices {
+		if _, exists := m.deviceMetrics[device.DeviceID]; exists {
+			continue
+		}
+		if m.maxDevices > 0 && len(m.deviceMetrics) >= m.maxDevices {
+			break
+		}
+
+		dm := m.createDeviceMetrics(
+			m.labelValue(labelDeviceID, device.DeviceID),
+			m.labelValue(labelDeviceName, device.DeviceName),
+			strconv.Itoa(device.DeviceType),
+			m.winpowerHost,
+		)
+		// lastUpdated stays zero, so preloaded devices are evicted first
+		dm.labels = device.DeviceLabels
+		dm.preloaded = true
+		m.updateResetEpoch(dm, device.ResetEpoch)
+		dm.cumulativeEnergy.Set(device.EnergyWh)
+		m.registerDeviceMetrics(dm)
+		m.deviceMetrics[device.DeviceID] = dm
+		preloaded++
+	}
+	m.publishDeviceMetrics()
+
+	m.logger.Info("Preloaded device energy",
+		log.Int("device_count", preloaded),
+	)
+}
+
+// completePreloadedDevice starts exporting every series of a preloaded
+// device once it is collected. The caller must hold m.mu.
+func (m *MetricsService) completePreloadedDevice(dm *DeviceMetrics) {
+	dm.preloaded = false
+	if m.batchUpdates {
+		return
+	}
+	// The energy series is already registered
+	for _, c := range dm.collectors() {
+		if c != dm.cumulativeEnergy {
+			m.registry.MustRegister(c)
+		}
+	}
+}
+
+// recordDeviceLabels passes the labels of the tracked devices to the label
+// recorder when they changed. A failed recording is retried in the next
+// update cycle. The caller must hold m.mu.
+func (m *MetricsService) recordDeviceLabels() {
+	if m.labelRecorder == nil || !m.labelsChanged {
+		return
+	}
+
+	devices := make([]DeviceLabels, 0, len(m.deviceMetrics))
+	for _, dm := range m.deviceMetrics {
+		devices = append(devices, dm.labels)
+	}
+	if err := m.labelRecorder.RecordDeviceLabels(devices); err != nil {
+		m.logger.Warn("Failed to record device labels", log.Err(err))
+		return
+	}
+	m.labelsChanged = false
+}
+
+// gaugeValue returns the current value of a gauge
+func gaugeValue(g prometheus.Gauge) float64 {
+	pb := &dto.Metric{}
+	if err := g.Write(pb); err != nil {
+		return 0
+	}
+	return pb.GetGauge().GetValue()
+}
@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/collector"
+	"github.com/lay-g/winpower-g2-exporter/internal/metrics/mocks"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+// labelRecorder records the labels passed to RecordDeviceLabels
+type labelRecorder struct {
+	calls  int
+	labels map[string]DeviceLabels
+	err    error
+}
+
+func (r *labelRecorder) RecordDeviceLabels(devices []DeviceLabels) error {
+	r.calls++
+	if r.err != nil {
+		return r.err
+	}
+	r.labels = make(map[string]DeviceLabels)
+	for _, device := range devices {
+		r.labels[device.DeviceID] = device
+	}
+	return nil
+}
+
+func TestMetricsService_PreloadDevices(t *testing.T) {
+	for _, batch := range []bool{false, true} {
+		config := DefaultMetricsConfig()
+		config.BatchDeviceUpdates = batch
+		service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), config)
+		require.NoError(t, err)
+
+		count := func(name string) int {
+			n, err := testutil.GatherAndCount(service.registry, name)
+			require.NoError(t, err)
+			return n
+		}
+
+		service.PreloadDevices([]PreloadedDevice{
+			{DeviceLabels: DeviceLabels{DeviceID: "ups-1", DeviceName: "UPS 1", DeviceType: 1}, EnergyWh: 1000},
+			{DeviceLabels: DeviceLabels{DeviceID: "ups-2", DeviceName: "UPS 2", DeviceType: 1}, EnergyWh: 2000},
+			{DeviceLabels: DeviceLabels{DeviceID: "ups-gone"}, EnergyWh: 3000},
+		})
+
+		// Only the energy series is exported before the first collection
+		assert.Equal(t, 3, count("winpower_device_cumulative_energy"), "batch=%v", batch)
+		assert.Equal(t, 0, count("winpower_device_up"), "batch=%v", batch)
+		assert.Equal(t, 1000.0, testutil.ToFloat64(service.deviceMetrics["ups-1"].cumulativeEnergy))
+
+		// ups-1 is collected with its preloaded labels, ups-2 is renamed
+		// before its energy is calculated and ups-gone is not reported
+		require.NoError(t, service.updateMetrics(&collector.CollectionResult{
+			Success: true,
+			Devices: map[string]*collector.DeviceCollectionInfo{
+				"ups-1": {DeviceID: "ups-1", DeviceName: "UPS 1", DeviceType: 1, EnergyCalculated: true, EnergyValue: 1001},
+				"ups-2": {DeviceID: "ups-2", DeviceName: "UPS 2b", DeviceType: 1},
+			},
+		}))
+
+		assert.Equal(t, 2, count("winpower_device_cumulative_energy"), "batch=%v", batch)
+		assert.Equal(t, 2, count("winpower_device_up"), "batch=%v", batch)
+		assert.NotContains(t, service.deviceMetrics, "ups-gone")
+		assert.False(t, service.deviceMetrics["ups-1"].preloaded)
+		assert.Equal(t, 1001.0, testutil.ToFloat64(service.deviceMetrics["ups-1"].cumulativeEnergy))
+		assert.Equal(t, 2000.0, testutil.ToFloat64(service.deviceMetrics["ups-2"].cumulativeEnergy),
+			"the preloaded energy is kept when the labels change")
+		assert.Equal(t, "UPS 2b", service.deviceMetrics["ups-2"].labels.DeviceName)
+
+		// Tracked devices are not preloaded again
+		service.PreloadDevices([]PreloadedDevice{
+			{DeviceLabels: DeviceLabels{DeviceID: "ups-1", DeviceName: "UPS 1", DeviceType: 1}, EnergyWh: 1},
+		})
+		assert.False(t, service.deviceMetrics["ups-1"].preloaded)
+		assert.Equal(t, 1001.0, testutil.ToFloat64(service.deviceMetrics["ups-1"].cumulativeEnergy))
+	}
+}
+
+func TestMetricsService_PreloadDevices_FailedCollection(t *testing.T) {
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+
+	service.PreloadDevices([]PreloadedDevice{
+		{DeviceLabels: DeviceLabels{DeviceID: "ups-1", DeviceName: "UPS 1", DeviceType: 1}, EnergyWh: 1000},
+	})
+
+	// A failed collection does not tell whether the device still exists
+	require.NoError(t, service.updateMetrics(&collector.CollectionResult{Success: false}))
+	require.Contains(t, service.deviceMetrics, "ups-1")
+	assert.True(t, service.deviceMetrics["ups-1"].preloaded)
+}
+
+func TestMetricsService_SetDeviceLabelRecorder(t *testing.T) {
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+	recorder := &labelRecorder{}
+	service.SetDeviceLabelRecorder(recorder)
+
+	update := func(devices map[string]*collector.DeviceCollectionInfo) {
+		t.Helper()
+		require.NoError(t, service.updateMetrics(&collector.CollectionResult{Success: true, Devices: devices}))
+	}
+	device := func(id, key string) *collector.DeviceCollectionInfo {
+		return &collector.DeviceCollectionInfo{DeviceID: id, DeviceName: "UPS " + id, DeviceType: 1, EnergyKey: key}
+	}
+
+	// New devices are recorded
+	update(map[string]*collector.DeviceCollectionInfo{"a": device("a", ""), "b": device("b", "")})
+	assert.Equal(t, 1, recorder.calls)
+	assert.Equal(t, DeviceLabels{DeviceID: "a", DeviceName: "UPS a", DeviceType: 1}, recorder.labels["a"])
+
+	// Unchanged devices are not recorded again
+	update(map[string]*collector.DeviceCollectionInfo{"a": device("a", ""), "b": device("b", "")})
+	assert.Equal(t, 1, recorder.calls)
+
+	// A changed energy key is recorded
+	update(map[string]*collector.DeviceCollectionInfo{"a": device("a", "serial-SN1"), "b": device("b", "")})
+	assert.Equal(t, 2, recorder.calls)
+	assert.Equal(t, "serial-SN1", recorder.labels["a"].EnergyKey)
+
+	// Removed devices are recorded, failures are retried
+	recorder.err = errors.New("disk full")
+	update(map[string]*collector.DeviceCollectionInfo{})
+	assert.Equal(t, 3, recorder.calls)
+	recorder.err = nil
+	update(map[string]*collector.DeviceCollectionInfo{})
+	assert.Equal(t, 4, recorder.calls)
+	assert.Empty(t, recorder.labels)
+}
@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/push"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/collector"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+// pushTimeout bounds a single push to the Pushgateway
+const pushTimeout = 10 * time.Second
+
+// Results of a Pushgateway push, used as the result label of
+// winpower_exporter_pushgateway_pushes_total
+const (
+	PushSuccess = "success"
+	PushFailure = "failure"
+)
+
+// pusher pushes the service registry to a Pushgateway, at most once per
+// interval and never more than one push at a time
+type pusher struct {
+	url      string
+	job      string
+	grouping map[string]string
+	interval time.Duration
+	client   *http.Client
+
+	mu       sync.Mutex
+	lastPush time.Time
+	inFlight atomic.Bool
+}
+
+// newPusher returns the pusher for config, or nil when push mode is disabled
+func newPusher(config *MetricsConfig) *pusher {
+	if config.PushgatewayURL == "" {
+		return nil
+	}
+	return &pusher{
+		url:      config.PushgatewayURL,
+		job:      config.PushJob,
+		grouping: config.PushGrouping,
+		interval: config.PushInterval,
+		client:   &http.Client{Timeout: pushTimeout},
+	}
+}
+
+// due reports whether a push may start now and, if so, claims it
+func (p *pusher) due(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if now.Sub(p.lastPush) < p.interval || !p.inFlight.CompareAndSwap(false, true) {
+		return false
+	}
+	p.lastPush = now
+	return true
+}
+
+// RecordCollection publishes the result of a scheduled collection in push
+// mode: the metrics are updated from the result and pushed to the
+// Pushgateway in the background, at most once per push interval. It is a
+// no-op without a Pushgateway, where the metrics are updated by scrapes.
+// Push failures are logged and counted but never reported to the caller.
+func (m *MetricsService) RecordCollection(result *collector.CollectionResult) {
+	if m.pusher == nil || result == nil {
+		return
+	}
+
+	m.recordMaintenance(result)
+	if result.Success {
+		m.lastResult.Store(result)
+		if err := m.updateMetrics(result); err != nil {
+			m.logger.Warn("Failed to update metrics for push", log.Err(err))
+		}
+		m.updateSelfMetrics(result)
+	}
+
+	if !m.pusher.due(time.Now()) {
+		return
+	}
+	go func() {
+		defer m.pusher.inFlight.Store(false)
+		m.push()
+	}()
+}
+
+// push pushes the registry to the Pushgateway, replacing the metrics
+// previously pushed with the same job and grouping key
+func (m *MetricsService) push() {
+	ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+	defer cancel()
+
+	pusher := push.New(m.pusher.url, m.pusher.job).
+		Gatherer(&partialGatherer{service: m}).
+		Client(m.pusher.client)
+
+	// Sorted so that the pushed URL is stable
+	names := make([]string, 0, len(m.pusher.grouping))
+	for name := range m.pusher.grouping {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pusher = pusher.Grouping(name, m.pusher.grouping[name])
+	}
+
+	if err := pusher.PushContext(ctx); err != nil {
+		m.pushesTotal.WithLabelValues(PushFailure).Inc()
+		m.logger.Warn("Failed to push metrics to Pushgateway",
+			log.String("job", m.pusher.job),
+			log.Err(err),
+		)
+		return
+	}
+	m.pushesTotal.WithLabelValues(PushSuccess).Inc()
+	m.logger.Debug("Pushed metrics to Pushgateway", log.String("job", m.pusher.job))
+}
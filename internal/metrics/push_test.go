@@ -0,0 +1,163 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/collector"
+	"github.com/lay-g/winpower-g2-exporter/internal/metrics/mocks"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+// pushRequest is a request received by the fake Pushgateway
+type pushRequest struct {
+	method string
+	path   string
+	body   string
+}
+
+// newFakePushgateway returns a Pushgateway answering with status and the
+// channel receiving its requests
+func newFakePushgateway(t *testing.T, status int) (*httptest.Server, chan pushRequest) {
+	requests := make(chan pushRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- pushRequest{method: r.Method, path: r.URL.Path, body: string(body)}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func newPushService(t *testing.T, url string, interval time.Duration) *MetricsService {
+	config := DefaultMetricsConfig()
+	config.PushgatewayURL = url
+	config.PushGrouping = map[string]string{"instance": "site-a"}
+	config.PushInterval = interval
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), config)
+	require.NoError(t, err)
+	return service
+}
+
+func waitForPush(t *testing.T, service *MetricsService, result string, want float64) {
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(service.pushesTotal.WithLabelValues(result)) == want &&
+			!service.pusher.inFlight.Load()
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestMetricsService_RecordCollection_Push(t *testing.T) {
+	server, requests := newFakePushgateway(t, http.StatusOK)
+	service := newPushService(t, server.URL, time.Hour)
+
+	service.RecordCollection(&collector.CollectionResult{
+		Success:        true,
+		DeviceCount:    3,
+		CollectionTime: time.Now(),
+		Devices:        map[string]*collector.DeviceCollectionInfo{},
+	})
+
+	select {
+	case req := <-requests:
+		assert.Equal(t, http.MethodPut, req.method)
+		assert.Equal(t, "/metrics/job/winpower_exporter/instance/site-a", req.path)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a push to the Pushgateway")
+	}
+	waitForPush(t, service, PushSuccess, 1)
+
+	// The metrics were updated from the collection before the push
+	assert.Equal(t, float64(3), testutil.ToFloat64(service.deviceCount))
+
+	// Collections within the push interval are not pushed
+	service.RecordCollection(&collector.CollectionResult{Success: true, CollectionTime: time.Now()})
+	select {
+	case <-requests:
+		t.Fatal("Expected no push within the push interval")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestMetricsService_RecordCollection_PushFailure(t *testing.T) {
+	server, requests := newFakePushgateway(t, http.StatusInternalServerError)
+	service := newPushService(t, server.URL, 0)
+
+	service.RecordCollection(&collector.CollectionResult{Success: true, CollectionTime: time.Now()})
+	<-requests
+	waitForPush(t, service, PushFailure, 1)
+	assert.Equal(t, float64(0), testutil.ToFloat64(service.pushesTotal.WithLabelValues(PushSuccess)))
+
+	// A failed push does not hold back the next one
+	service.RecordCollection(&collector.CollectionResult{Success: true, CollectionTime: time.Now()})
+	<-requests
+	waitForPush(t, service, PushFailure, 2)
+}
+
+func TestMetricsService_RecordCollection_PushDisabled(t *testing.T) {
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+
+	// Without a Pushgateway scheduled collections leave the metrics to scrapes
+	service.RecordCollection(&collector.CollectionResult{Success: true, DeviceCount: 3, CollectionTime: time.Now()})
+	assert.Equal(t, float64(0), testutil.ToFloat64(service.deviceCount))
+	assert.Nil(t, service.pushesTotal)
+
+	families, err := service.registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		assert.False(t, strings.HasPrefix(family.GetName(), "winpower_exporter_pushgateway"))
+	}
+}
+
+func TestMetricsConfig_ValidatePush(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*MetricsConfig)
+		wantErr string
+	}{
+		{name: "disabled", modify: func(c *MetricsConfig) {}},
+		{name: "valid", modify: func(c *MetricsConfig) {
+			c.PushgatewayURL = "http://pushgateway:9091"
+			c.PushGrouping = map[string]string{"instance": "site-a"}
+		}},
+		{name: "invalid url", modify: func(c *MetricsConfig) { c.PushgatewayURL = "pushgateway:9091" }, wantErr: "pushgateway_url"},
+		{name: "empty job", modify: func(c *MetricsConfig) {
+			c.PushgatewayURL = "http://pushgateway:9091"
+			c.PushJob = ""
+		}, wantErr: "push_job"},
+		{name: "negative interval", modify: func(c *MetricsConfig) {
+			c.PushgatewayURL = "http://pushgateway:9091"
+			c.PushInterval = -time.Second
+		}, wantErr: "push_interval"},
+		{name: "job grouping label", modify: func(c *MetricsConfig) {
+			c.PushgatewayURL = "http://pushgateway:9091"
+			c.PushGrouping = map[string]string{"job": "other"}
+		}, wantErr: "push_grouping"},
+		{name: "invalid grouping label", modify: func(c *MetricsConfig) {
+			c.PushgatewayURL = "http://pushgateway:9091"
+			c.PushGrouping = map[string]string{"site-name": "a"}
+		}, wantErr: "push_grouping"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultMetricsConfig()
+			tt.modify(config)
+			err := config.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
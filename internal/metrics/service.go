@@ -7,14 +7,18 @@ import (
 	"net/http"
 	"runtime"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
 
 	"github.com/lay-g/winpower-g2-exporter/internal/collector"
 	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/timing"
 )
 
 // NewMetricsService creates a new MetricsService instance
@@ -49,11 +53,39 @@ func NewMetricsService(
 
 	// Create service instance
 	m := &MetricsService{
-		registry:      registry,
-		collector:     coll,
-		logger:        logger,
-		winpowerHost:  config.WinPowerHost,
-		deviceMetrics: make(map[string]*DeviceMetrics),
+		registry:           registry,
+		collector:          coll,
+		logger:             logger,
+		winpowerHost:       config.WinPowerHost,
+		deviceUptime:       config.EnableDeviceUptime,
+		reportedEnergy:     config.EnableReportedEnergy,
+		energyResetEpoch:   config.EnableEnergyResetEpoch,
+		skipInvalidValues:  config.InvalidValueMode != InvalidValueZero,
+		maxDevices:         config.MaxDevices,
+		maxLabelLength:     config.MaxLabelValueLength,
+		batteryRuntimeLow:  config.BatteryRuntimeLowMinutes,
+		deviceTypePrefixes: config.DeviceTypePrefixes,
+		deviceTypeCode:     config.EnableDeviceTypeCode,
+		typeCodes:          config.DeviceTypeCodes,
+		defaultTypeCode:    config.DeviceTypeDefaultCode,
+		alarmTypes:         config.EnableAlarmTypes,
+		helpOverrides:      config.HelpOverrides,
+		collectWait:        config.CollectionWaitTimeout,
+		staleMaxAge:        config.StaleMaxAge,
+		runtimeInterval:    config.RuntimeMetricsInterval,
+		batchUpdates:       config.BatchDeviceUpdates,
+		deviceMetrics:      make(map[string]*DeviceMetrics),
+		pusher:             newPusher(config),
+	}
+	if len(config.DeviceGroups) > 0 {
+		m.deviceGroups = make(map[string]string, len(config.DeviceGroups))
+		for deviceID, group := range config.DeviceGroups {
+			m.deviceGroups[strings.ToLower(deviceID)] = group
+		}
+		m.defaultGroup = config.DeviceGroupDefault
+	}
+	if config.MaxConcurrentCollections > 0 {
+		m.collectSem = make(chan struct{}, config.MaxConcurrentCollections)
 	}
 
 	// Initialize metrics
@@ -68,6 +100,20 @@ func NewMetricsService(
 		log.String("subsystem", config.Subsystem),
 		log.String("winpower_host", config.WinPowerHost),
 		log.Bool("memory_metrics_enabled", config.EnableMemoryMetrics),
+		log.Bool("runtime_metrics_enabled", config.EnableRuntimeMetrics),
+		log.Bool("batch_device_updates", config.BatchDeviceUpdates),
+		log.Bool("reported_energy_enabled", config.EnableReportedEnergy),
+		log.Bool("energy_reset_epoch_enabled", config.EnableEnergyResetEpoch),
+		log.String("invalid_value_mode", config.InvalidValueMode),
+		log.Int("max_concurrent_collections", config.MaxConcurrentCollections),
+		log.Int("max_devices", config.MaxDevices),
+		log.Int("max_label_value_length", config.MaxLabelValueLength),
+		log.Any("device_type_prefixes", config.DeviceTypePrefixes),
+		log.Bool("device_type_code_enabled", config.EnableDeviceTypeCode),
+		log.Bool("alarm_types_enabled", config.EnableAlarmTypes),
+		log.Int("device_groups", len(config.DeviceGroups)),
+		log.Float64("battery_runtime_low_minutes", config.BatteryRuntimeLowMinutes),
+		log.Bool("push_enabled", m.pusher != nil),
 	)
 
 	return m, nil
@@ -76,6 +122,11 @@ func NewMetricsService(
 // HandleMetrics is the Gin handler for the /metrics endpoint
 // It triggers data collection and serves Prometheus-formatted metrics
 func (m *MetricsService) HandleMetrics(c *gin.Context) {
+	m.handleMetrics(c, &partialGatherer{service: m})
+}
+
+// handleMetrics triggers data collection and serves the metrics of gatherer
+func (m *MetricsService) handleMetrics(c *gin.Context, gatherer prometheus.Gatherer) {
 	startTime := time.Now()
 
 	// Increment request counter
@@ -87,32 +138,58 @@ func (m *MetricsService) HandleMetrics(c *gin.Context) {
 		log.String("user_agent", c.Request.UserAgent()),
 	)
 
-	// Trigger data collection
-	collectionResult, err := m.collector.CollectDeviceData(c.Request.Context())
+	// Trigger data collection, bounded by the concurrency limit. The slot is
+	// held until the metrics are updated, so that a scrape waiting for this
+	// collection is served its result.
+	collectionResult, cached, release, err := m.collect(c.Request.Context())
+	defer release()
 	if err != nil {
-		m.handleCollectionError(err)
-		m.logger.Error("Failed to collect device data",
-			log.Err(err),
-			log.Duration("elapsed", time.Since(startTime)),
-		)
-		c.String(http.StatusInternalServerError, "Failed to collect metrics: %v", err)
-		return
-	}
+		if m.recordMaintenance(collectionResult) {
+			// Not a failure: WinPower is reachable but its data was discarded,
+			// so the last known values are kept regardless of their age
+			m.logger.Warn("WinPower is in maintenance mode, serving last known metrics")
+			collectionResult, cached = m.pausedResult(), true
+		} else {
+			m.handleCollectionError(collectionResult, err)
 
-	// Update metrics based on collection result
-	if err := m.updateMetrics(collectionResult); err != nil {
-		m.logger.Error("Failed to update metrics",
-			log.Err(err),
-			log.Duration("elapsed", time.Since(startTime)),
-		)
-		// Don't return error - still serve existing metrics
+			stale, age, ok := m.staleResult()
+			if !ok {
+				m.logger.Error("Failed to collect device data",
+					log.Err(err),
+					log.Duration("elapsed", time.Since(startTime)),
+				)
+				c.String(http.StatusInternalServerError, "Failed to collect metrics: %v", err)
+				return
+			}
+
+			m.logger.Warn("Failed to collect device data, serving metrics of the last successful collection",
+				log.Err(err),
+				log.Duration("age", age),
+				log.Duration("stale_max_age", m.staleMaxAge),
+			)
+			collectionResult, cached = stale, true
+		}
 	}
+	m.updateStaleness(collectionResult, cached)
+
+	if !cached {
+		// Update metrics based on collection result
+		if err := m.updateMetrics(collectionResult); err != nil {
+			m.logger.Error("Failed to update metrics",
+				log.Err(err),
+				log.Duration("elapsed", time.Since(startTime)),
+			)
+			// Don't return error - still serve existing metrics
+		}
 
-	// Update self-monitoring metrics
-	m.updateSelfMetrics(collectionResult)
+		// Update self-monitoring metrics
+		m.updateSelfMetrics(collectionResult)
+	}
+	release()
 
-	// Serve metrics in Prometheus format
-	handler := promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+	// Serve metrics in Prometheus format. Gather errors are logged by the
+	// partial gatherer so that successfully gathered families are still served.
+	handler := promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{
 		ErrorLog:      &promhttpLogger{logger: m.logger},
 		ErrorHandling: promhttp.ContinueOnError,
 	})
@@ -126,7 +203,343 @@ func (m *MetricsService) HandleMetrics(c *gin.Context) {
 		log.Duration("duration", time.Since(startTime)),
 		log.Int("device_count", collectionResult.DeviceCount),
 		log.Bool("success", collectionResult.Success),
+		log.Bool("cached", cached),
+	)
+}
+
+// HandleDebugCollect is the Gin handler for the /debug/collect endpoint.
+// It runs a single collection outside the concurrency limit and the cached
+// result, updates the metrics and returns the per-stage timing breakdown.
+func (m *MetricsService) HandleDebugCollect(c *gin.Context) {
+	recorder := timing.NewRecorder()
+	ctx := timing.WithRecorder(c.Request.Context(), recorder)
+	startTime := time.Now()
+
+	result, err := m.collector.CollectDeviceData(ctx)
+	if err != nil {
+		m.logger.Warn("Debug collection failed",
+			log.Err(err),
+			log.Duration("elapsed", time.Since(startTime)),
+		)
+		c.JSON(http.StatusInternalServerError, &DebugCollectResult{
+			Success: false,
+			Error:   err.Error(),
+			TotalMs: durationMs(time.Since(startTime)),
+			Stages:  recorder.Stages(),
+		})
+		return
+	}
+
+	stopEmit := timing.Track(ctx, timing.StageMetricEmit)
+	if err := m.updateMetrics(result); err != nil {
+		m.logger.Warn("Failed to update metrics after debug collection", log.Err(err))
+	}
+	stopEmit()
+
+	total := time.Since(startTime)
+	m.logger.Info("Debug collection completed",
+		log.Duration("duration", total),
+		log.Int("device_count", result.DeviceCount),
+	)
+
+	c.JSON(http.StatusOK, &DebugCollectResult{
+		Success:     result.Success,
+		DeviceCount: result.DeviceCount,
+		TotalMs:     durationMs(total),
+		Stages:      recorder.Stages(),
+	})
+}
+
+// HandleDebugDevices is the Gin handler for the /debug/devices endpoint.
+// It returns the last-known data of each device without triggering a
+// collection. When the collector publishes snapshots, the device states and
+// metric values all come from the latest snapshot and are consistent with
+// each other; otherwise the live device store is served.
+func (m *MetricsService) HandleDebugDevices(c *gin.Context) {
+	if provider, ok := m.collector.(collector.SnapshotProvider); ok {
+		if snapshot := provider.Snapshot(); snapshot != nil {
+			c.JSON(http.StatusOK, debugDevicesFromSnapshot(snapshot, time.Now()))
+			return
+		}
+	}
+
+	provider, ok := m.collector.(collector.DeviceStateProvider)
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, &DebugDevicesResult{
+			Devices: []DebugDeviceState{},
+			Error:   "device store not available",
+		})
+		return
+	}
+
+	now := time.Now()
+	states := provider.DeviceStore().Snapshot()
+	devices := make([]DebugDeviceState, 0, len(states))
+	for _, state := range states {
+		devices = append(devices, DebugDeviceState{
+			DeviceState: state,
+			AgeSeconds:  state.Age(now).Seconds(),
+		})
+	}
+
+	c.JSON(http.StatusOK, &DebugDevicesResult{
+		DeviceCount: len(devices),
+		Devices:     devices,
+	})
+}
+
+// debugDevicesFromSnapshot builds the /debug/devices response from a snapshot
+func debugDevicesFromSnapshot(snapshot *collector.Snapshot, now time.Time) *DebugDevicesResult {
+	devices := make([]DebugDeviceState, 0, len(snapshot.Devices))
+	for _, device := range snapshot.Devices {
+		devices = append(devices, DebugDeviceState{
+			DeviceState: device.DeviceState,
+			Metrics:     device.Metrics,
+			AgeSeconds:  device.Age(now).Seconds(),
+		})
+	}
+
+	collectionTime := snapshot.CollectionTime
+	return &DebugDevicesResult{
+		CollectionTime: &collectionTime,
+		DeviceCount:    len(devices),
+		Devices:        devices,
+	}
+}
+
+// durationMs converts a duration to fractional milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// SetCollectionPaused pauses or resumes on-scrape collection. While paused,
+// /metrics serves the last-known metrics without contacting WinPower and
+// winpower_exporter_scheduler_paused is set to 1.
+func (m *MetricsService) SetCollectionPaused(paused bool) {
+	m.paused.Store(paused)
+	if paused {
+		m.schedulerPaused.Set(1)
+	} else {
+		m.schedulerPaused.Set(0)
+	}
+}
+
+// RecordSchedulerOverrun counts a scheduled collection cycle that hit its
+// deadline. It implements scheduler.OverrunRecorder.
+func (m *MetricsService) RecordSchedulerOverrun() {
+	m.schedulerOverruns.Inc()
+}
+
+// SetSchedulerInterval sets winpower_exporter_scheduler_interval_seconds to
+// the configured collection interval. It implements scheduler.TickRecorder.
+func (m *MetricsService) SetSchedulerInterval(interval time.Duration) {
+	m.schedulerInterval.Set(interval.Seconds())
+}
+
+// SetCollectionHealth sets winpower_exporter_collection_health to the
+// collection health state. It implements scheduler.HealthRecorder.
+func (m *MetricsService) SetCollectionHealth(state int) {
+	m.collectionHealth.Set(float64(state))
+}
+
+// ObserveSchedulerTick records the observed time between two scheduler ticks
+// in winpower_exporter_scheduler_tick_interval_seconds. It implements
+// scheduler.TickRecorder.
+func (m *MetricsService) ObserveSchedulerTick(gap time.Duration) {
+	m.schedulerTickInterval.Observe(gap.Seconds())
+}
+
+// SetEnergyDegraded sets winpower_exporter_energy_degraded while energy is
+// accumulated in memory because storage is unavailable. It implements
+// energy.DegradedObserver.
+func (m *MetricsService) SetEnergyDegraded(degraded bool) {
+	if degraded {
+		m.energyDegraded.Set(1)
+	} else {
+		m.energyDegraded.Set(0)
+	}
+}
+
+// SetEnergyStalled sets winpower_exporter_energy_stalled to the number of
+// devices drawing power whose energy does not advance. It implements
+// collector.EnergyStallObserver.
+func (m *MetricsService) SetEnergyStalled(devices int) {
+	m.energyStalled.Set(float64(devices))
+}
+
+// ObserveResponseBytes records the size of a WinPower response body. It
+// implements winpower.ResponseObserver.
+func (m *MetricsService) ObserveResponseBytes(bytes int) {
+	m.responseBytes.Observe(float64(bytes))
+}
+
+// ObserveParseDuration records the time spent parsing a WinPower device data
+// response. It implements winpower.ResponseObserver.
+func (m *MetricsService) ObserveParseDuration(duration time.Duration) {
+	m.parseDuration.Observe(duration.Seconds())
+}
+
+// ObserveRequestRetries records how many retries a WinPower device data
+// request made. It implements winpower.ResponseObserver.
+func (m *MetricsService) ObserveRequestRetries(retries int) {
+	m.requestRetries.Observe(float64(retries))
+}
+
+// ObserveScrapeDuration records how long the HTTP server took to serve a
+// /metrics request. It implements server.ScrapeRecorder.
+func (m *MetricsService) ObserveScrapeDuration(duration time.Duration) {
+	m.scrapeHandlerDuration.Observe(duration.Seconds())
+}
+
+// ObserveDeviceCountMismatch counts a WinPower device data response whose
+// reported device total differs from the devices it contains. It implements
+// winpower.ResponseObserver.
+func (m *MetricsService) ObserveDeviceCountMismatch() {
+	m.countMismatches.Inc()
+}
+
+// ObserveInvalidTimestamp counts a WinPower device timestamp that could not
+// be parsed. It implements winpower.ResponseObserver.
+func (m *MetricsService) ObserveInvalidTimestamp() {
+	m.invalidTimestamps.Inc()
+}
+
+// RecordConfigReload counts a configuration reload with the given result,
+// one of ConfigReloadSuccess, ConfigReloadValidationFailed or
+// ConfigReloadError. Successful reloads also advance
+// winpower_exporter_config_last_reload_timestamp_seconds.
+func (m *MetricsService) RecordConfigReload(result string) {
+	m.configReloadsTotal.WithLabelValues(result).Inc()
+	if result == ConfigReloadSuccess {
+		m.configLastReload.SetToCurrentTime()
+	}
+}
+
+// RecordShutdownTimeout counts a module abandoned during shutdown after it
+// exceeded its shutdown deadline. It implements shutdown.TimeoutRecorder.
+func (m *MetricsService) RecordShutdownTimeout(module string) {
+	m.shutdownTimeoutsTotal.WithLabelValues(module).Inc()
+}
+
+// collect triggers a collection if a collection slot is free and returns
+// the function releasing the slot, which the caller must call once the
+// metrics are updated. When the concurrency limit is reached it waits up to
+// collectWait for a slot and then falls back to the last collection result,
+// reporting cached=true. Before any result exists it keeps waiting for the
+// collections in flight and is served the result of the first to succeed.
+// While collection is paused the last result is served without collecting.
+func (m *MetricsService) collect(ctx context.Context) (result *collector.CollectionResult, cached bool, release func(), err error) {
+	release = func() {}
+	if m.paused.Load() {
+		return m.pausedResult(), true, release, nil
+	}
+
+	if m.collectSem != nil {
+		timer := time.NewTimer(m.collectWait)
+		defer timer.Stop()
+
+		select {
+		case m.collectSem <- struct{}{}:
+		case <-timer.C:
+			if result, ok := m.cachedResult(); ok {
+				return result, true, release, nil
+			}
+			// The collection in flight updates the metrics before freeing its
+			// slot, so its result is cached once the slot is acquired
+			select {
+			case m.collectSem <- struct{}{}:
+			case <-ctx.Done():
+				return nil, false, release, ctx.Err()
+			}
+			if result, ok := m.cachedResult(); ok {
+				<-m.collectSem
+				return result, true, release, nil
+			}
+		case <-ctx.Done():
+			return nil, false, release, ctx.Err()
+		}
+		release = sync.OnceFunc(func() { <-m.collectSem })
+	}
+
+	result, err = m.collector.CollectDeviceData(ctx)
+	if err != nil {
+		if result != nil {
+			// Refresh failures matter most while collection is failing
+			m.updateTokenRefreshMetrics(result)
+		}
+		return result, false, release, err
+	}
+	m.lastResult.Store(result)
+
+	return result, false, release, nil
+}
+
+// cachedResult returns the last collection result for a throttled request,
+// if any
+func (m *MetricsService) cachedResult() (*collector.CollectionResult, bool) {
+	result := m.lastResult.Load()
+	if result == nil {
+		return nil, false
+	}
+
+	m.collectionsThrottled.Inc()
+	m.logger.Debug("Collection limit reached, serving cached metrics",
+		log.Time("collection_time", result.CollectionTime),
 	)
+	return result, true
+}
+
+// staleResult returns the last successful collection result and its age when
+// it may be served in place of a failed collection, i.e. stale serving is
+// enabled and the result is at most staleMaxAge old
+func (m *MetricsService) staleResult() (*collector.CollectionResult, time.Duration, bool) {
+	if m.staleMaxAge <= 0 {
+		return nil, 0, false
+	}
+
+	result := m.lastResult.Load()
+	if result == nil {
+		return nil, 0, false
+	}
+
+	age := time.Since(result.CollectionTime)
+	if age > m.staleMaxAge {
+		m.logger.Debug("Last successful collection too old to serve",
+			log.Duration("age", age),
+			log.Duration("stale_max_age", m.staleMaxAge),
+		)
+		return nil, age, false
+	}
+	return result, age, true
+}
+
+// recordMaintenance sets the source maintenance gauge when WinPower reported
+// maintenance mode in result and reports whether it did
+func (m *MetricsService) recordMaintenance(result *collector.CollectionResult) bool {
+	if result == nil || !result.Maintenance {
+		return false
+	}
+	m.sourceMaintenance.Set(1)
+	return true
+}
+
+// updateStaleness sets the staleness gauge to the age of the collection
+// result being served; a fresh collection has age 0
+func (m *MetricsService) updateStaleness(result *collector.CollectionResult, cached bool) {
+	age := 0.0
+	if cached && !result.CollectionTime.IsZero() {
+		age = time.Since(result.CollectionTime).Seconds()
+	}
+	m.metricsStaleness.Set(age)
+}
+
+// pausedResult returns the last collection result, or an empty result when
+// nothing has been collected yet, so that paused scrapes never fail
+func (m *MetricsService) pausedResult() *collector.CollectionResult {
+	if result := m.lastResult.Load(); result != nil {
+		return result
+	}
+	return &collector.CollectionResult{Devices: map[string]*collector.DeviceCollectionInfo{}}
 }
 
 // updateMetrics updates all metrics based on the collection result
@@ -135,8 +548,12 @@ func (m *MetricsService) updateMetrics(result *collector.CollectionResult) error
 		return ErrInvalidCollectionResult
 	}
 
+	m.updateTokenRefreshMetrics(result)
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	defer m.publishDeviceMetrics()
+	defer m.recordDeviceLabels()
 
 	// Update collection timestamp
 	m.lastCollectionTimeSeconds.Set(float64(result.CollectionTime.Unix()))
@@ -146,6 +563,10 @@ func (m *MetricsService) updateMetrics(result *collector.CollectionResult) error
 
 	// Update connection status based on collection success
 	if result.Success {
+		// Only successful cycles advance the staleness timestamp so that
+		// time() - timestamp keeps growing during an outage
+		m.lastCollectionTimestamp.Set(float64(result.CollectionTime.Unix()))
+		m.sourceMaintenance.Set(0)
 		m.connectionStatus.Set(1)
 		// Authentication is successful if we can collect data
 		m.authStatus.Set(1)
@@ -181,9 +602,63 @@ func (m *MetricsService) updateMetrics(result *collector.CollectionResult) error
 		}
 	}
 
+	if result.Success {
+		m.updateMissingOptionalFields(result)
+	}
+
+	// WinPower reporting no devices at all is a successful collection; the
+	// series of previously seen devices are removed instead of left stale
+	if result.Success && len(result.Devices) == 0 {
+		m.expireAllDevices()
+		return nil
+	}
+
+	// Known devices missing from this collection were not collected
+	for deviceID, dm := range m.deviceMetrics {
+		if _, ok := result.Devices[deviceID]; ok {
+			continue
+		}
+		// A preloaded device that WinPower no longer reports is dropped
+		if dm.preloaded && result.Success {
+			m.removeDeviceMetrics(deviceID)
+			continue
+		}
+		dm.up.Set(0)
+	}
+
 	return nil
 }
 
+// updateMissingOptionalFields sets, for each mapped optional field, the
+// number of devices of the collection that did not report it. Firmware that
+// never sends an optional field keeps a constant value instead of counting
+// parse errors forever. The caller must hold m.mu.
+func (m *MetricsService) updateMissingOptionalFields(result *collector.CollectionResult) {
+	m.missingOptionalFields.Reset()
+	for _, info := range result.Devices {
+		for _, field := range info.MissingOptionalFields {
+			m.missingOptionalFields.WithLabelValues(field).Inc()
+		}
+	}
+}
+
+// updateTokenRefreshMetrics advances token_refresh_total by the refreshes
+// reported since the previous result. The collector reports cumulative counts.
+func (m *MetricsService) updateTokenRefreshMetrics(result *collector.CollectionResult) {
+	m.tokenRefreshMu.Lock()
+	defer m.tokenRefreshMu.Unlock()
+
+	// Lower counts come from results without refresh information and are ignored
+	if delta := result.TokenRefreshes - m.tokenRefreshes; delta > 0 {
+		m.tokenRefreshTotal.WithLabelValues("success").Add(float64(delta))
+		m.tokenRefreshes = result.TokenRefreshes
+	}
+	if delta := result.TokenRefreshFailures - m.tokenRefreshFailures; delta > 0 {
+		m.tokenRefreshTotal.WithLabelValues("failure").Add(float64(delta))
+		m.tokenRefreshFailures = result.TokenRefreshFailures
+	}
+}
+
 // updateDeviceMetrics updates metrics for a single device
 func (m *MetricsService) updateDeviceMetrics(deviceID string, info *collector.DeviceCollectionInfo) error {
 	if info == nil {
@@ -192,21 +667,70 @@ func (m *MetricsService) updateDeviceMetrics(deviceID string, info *collector.De
 
 	// Get or create device metrics
 	dm, exists := m.deviceMetrics[deviceID]
+	preloadedEnergy, preloadedEpoch := 0.0, uint64(0)
+	if exists && dm.preloaded {
+		if dm.labels.DeviceName == info.DeviceName && dm.labels.DeviceType == info.DeviceType {
+			m.completePreloadedDevice(dm)
+		} else {
+			// Preloaded with other labels: recreate the series with the
+			// reported ones, keeping the preloaded energy
+			preloadedEnergy, preloadedEpoch = gaugeValue(dm.cumulativeEnergy), dm.resetEpoch
+			m.removeDeviceMetrics(deviceID)
+			exists = false
+		}
+	}
 	if !exists {
+		// Make room for the new device if the cap is reached
+		if m.maxDevices > 0 && len(m.deviceMetrics) >= m.maxDevices {
+			m.evictOldestDevice()
+		}
+
 		// Create new device metrics
 		dm = m.createDeviceMetrics(
-			deviceID,
-			info.DeviceName,
+			m.labelValue(labelDeviceID, deviceID),
+			m.labelValue(labelDeviceName, info.DeviceName),
 			strconv.Itoa(info.DeviceType),
 			m.winpowerHost,
 		)
+		dm.labels = DeviceLabels{DeviceID: deviceID, DeviceName: info.DeviceName, DeviceType: info.DeviceType}
+		m.updateResetEpoch(dm, preloadedEpoch)
+		dm.cumulativeEnergy.Set(preloadedEnergy)
+		m.registerDeviceMetrics(dm)
 		m.deviceMetrics[deviceID] = dm
+		m.labelsChanged = true
 		m.logger.Info("Created metrics for new device",
 			log.String("device_id", deviceID),
 			log.String("device_name", info.DeviceName),
 		)
 	}
 
+	dm.lastUpdated = time.Now()
+
+	// Built-in fields missing from the WinPower response count as parse
+	// errors; optional ones are counted by updateMissingOptionalFields
+	if len(info.MissingFields) > 0 {
+		m.scrapeErrorsTotal.WithLabelValues("parse").Add(float64(len(info.MissingFields)))
+	}
+
+	// Per-device collection outcome
+	if info.ErrorType != "" {
+		dm.up.Set(0)
+		dm.scrapeErrors.WithLabelValues(info.ErrorType).Inc()
+	} else {
+		dm.up.Set(1)
+	}
+
+	// WinPower has not refreshed the device's data since the previous
+	// collection, so the gauges already hold these values
+	if info.Duplicate {
+		return nil
+	}
+
+	if info.EnergyKey != dm.labels.EnergyKey {
+		dm.labels.EnergyKey = info.EnergyKey
+		m.labelsChanged = true
+	}
+
 	// Update device status
 	if info.Connected {
 		dm.connected.Set(1)
@@ -214,6 +738,10 @@ func (m *MetricsService) updateDeviceMetrics(deviceID string, info *collector.De
 		dm.connected.Set(0)
 	}
 	dm.lastUpdateTimestamp.Set(float64(info.LastUpdateTime.Unix()))
+	dm.lastSeenTimestamp.Set(float64(info.LastUpdateTime.Unix()))
+	if dm.uptimeSeconds != nil && !info.FirstSeenTime.IsZero() {
+		dm.uptimeSeconds.Set(info.LastUpdateTime.Sub(info.FirstSeenTime).Seconds())
+	}
 
 	// Update input parameters
 	dm.inputVoltage.Set(info.InputVolt1)
@@ -244,6 +772,13 @@ func (m *MetricsService) updateDeviceMetrics(deviceID string, info *collector.De
 	dm.batteryVoltagePercent.Set(info.BatVoltP)
 	dm.batteryCapacity.Set(info.BatCapacity)
 	dm.batteryRemainSeconds.Set(float64(info.BatRemainTime))
+	runtimeMinutes := float64(info.BatRemainTime) / 60
+	dm.batteryRuntimeMinutes.Set(runtimeMinutes)
+	if runtimeMinutes < m.batteryRuntimeLow {
+		dm.batteryRuntimeLow.Set(1)
+	} else {
+		dm.batteryRuntimeLow.Set(0)
+	}
 	dm.batteryStatus.Set(encodeBatteryStatus(info.BatteryStatus))
 
 	// Update UPS status
@@ -254,19 +789,173 @@ func (m *MetricsService) updateDeviceMetrics(deviceID string, info *collector.De
 
 	// Update fault code with label
 	if info.FaultCode != "" {
-		dm.upsFaultCode.WithLabelValues(info.FaultCode).Set(1)
+		dm.upsFaultCode.WithLabelValues(m.labelValue(labelFaultCode, info.FaultCode)).Set(1)
 	} else {
 		dm.upsFaultCode.WithLabelValues("none").Set(0)
 	}
+	m.updateAlarms(dm, info.ActiveAlarms)
 
 	// Update energy if calculated
 	if info.EnergyCalculated {
+		m.updateResetEpoch(dm, info.EnergyResetEpoch)
 		dm.cumulativeEnergy.Set(info.EnergyValue)
 	}
+	if dm.reportedEnergy != nil {
+		m.updateReportedEnergy(dm, info.ReportedEnergyWh)
+	}
+
+	m.updateInvalidFields(dm, info.InvalidFields)
 
 	return nil
 }
 
+// updateAlarms exports the number of active alarms of a device, in total and
+// per alarm type when enabled. Alarm types that cleared since the previous
+// collection are removed. The caller must hold m.mu.
+func (m *MetricsService) updateAlarms(dm *DeviceMetrics, alarms []string) {
+	dm.activeAlarms.Set(float64(len(alarms)))
+	if dm.alarmTypes == nil {
+		return
+	}
+
+	dm.alarmTypes.Reset()
+	for _, alarmType := range alarms {
+		dm.alarmTypes.WithLabelValues(m.labelValue(labelAlarmType, alarmType)).Inc()
+	}
+}
+
+// updateInvalidFields counts the measurements WinPower reported as NaN or
+// infinite. Unless they are exported as 0, their series are withheld until
+// the device reports a valid value again. The caller must hold m.mu.
+func (m *MetricsService) updateInvalidFields(dm *DeviceMetrics, fields []string) {
+	for _, field := range fields {
+		m.invalidValuesTotal.WithLabelValues(field).Inc()
+	}
+	if !m.skipInvalidValues || (len(fields) == 0 && len(dm.suppressed) == 0) {
+		return
+	}
+
+	suppressed := make(map[prometheus.Collector]bool)
+	for _, field := range fields {
+		for _, c := range dm.fieldCollectors(field) {
+			suppressed[c] = true
+		}
+	}
+
+	if !m.batchUpdates {
+		for c := range dm.suppressed {
+			if !suppressed[c] {
+				m.registry.MustRegister(c)
+			}
+		}
+		for c := range suppressed {
+			if !dm.suppressed[c] {
+				m.registry.Unregister(c)
+			}
+		}
+	}
+	dm.suppressed = suppressed
+}
+
+// updateReportedEnergy exports the energy reported by WinPower while the
+// device reports it and removes the series when it stops doing so.
+// The caller must hold m.mu.
+func (m *MetricsService) updateReportedEnergy(dm *DeviceMetrics, energy *float64) {
+	if energy == nil {
+		if dm.reportsEnergy && !m.batchUpdates {
+			m.registry.Unregister(dm.reportedEnergy)
+		}
+		dm.reportsEnergy = false
+		return
+	}
+
+	dm.reportedEnergy.Set(*energy)
+	if !dm.reportsEnergy && !m.batchUpdates {
+		m.registry.MustRegister(dm.reportedEnergy)
+	}
+	dm.reportsEnergy = true
+}
+
+// updateResetEpoch replaces the cumulative energy series of a device by one
+// labeled with the new reset epoch when the epoch changed. The caller must
+// hold m.mu.
+func (m *MetricsService) updateResetEpoch(dm *DeviceMetrics, epoch uint64) {
+	if !m.energyResetEpoch || epoch == dm.resetEpoch {
+		return
+	}
+
+	previous := dm.cumulativeEnergy
+	dm.resetEpoch = epoch
+	dm.cumulativeEnergy = m.newEnergyGauge(dm)
+	dm.cumulativeEnergy.Set(gaugeValue(previous))
+	if !m.batchUpdates && m.registry.Unregister(previous) {
+		m.registry.MustRegister(dm.cumulativeEnergy)
+	}
+
+	m.logger.Debug("Device energy reset epoch changed",
+		log.String("device_id", dm.labels.DeviceID),
+		log.Any("reset_epoch", epoch),
+	)
+}
+
+// evictOldestDevice unregisters the series of the least recently updated
+// device. It guards against unbounded cardinality when device IDs churn.
+// The caller must hold m.mu.
+func (m *MetricsService) evictOldestDevice() {
+	var oldestID string
+	var oldest *DeviceMetrics
+	for deviceID, dm := range m.deviceMetrics {
+		if oldest == nil || dm.lastUpdated.Before(oldest.lastUpdated) {
+			oldestID, oldest = deviceID, dm
+		}
+	}
+	if oldest == nil {
+		return
+	}
+
+	m.removeDeviceMetrics(oldestID)
+	m.devicesEvicted.Inc()
+
+	m.logger.Warn("Tracked device limit reached, evicted least recently updated device",
+		log.String("device_id", oldestID),
+		log.Time("last_updated", oldest.lastUpdated),
+		log.Int("max_devices", m.maxDevices),
+	)
+}
+
+// expireAllDevices unregisters the series of every tracked device after a
+// successful collection reported no devices. The caller must hold m.mu.
+func (m *MetricsService) expireAllDevices() {
+	if len(m.deviceMetrics) == 0 {
+		return
+	}
+
+	expired := len(m.deviceMetrics)
+	for deviceID := range m.deviceMetrics {
+		m.removeDeviceMetrics(deviceID)
+	}
+
+	m.logger.Info("WinPower reported no devices, expired device metrics",
+		log.Int("expired_devices", expired),
+	)
+}
+
+// removeDeviceMetrics unregisters and forgets the series of a device.
+// The caller must hold m.mu.
+func (m *MetricsService) removeDeviceMetrics(deviceID string) {
+	dm, ok := m.deviceMetrics[deviceID]
+	if !ok {
+		return
+	}
+	if !m.batchUpdates {
+		for _, c := range dm.collectors() {
+			m.registry.Unregister(c)
+		}
+	}
+	delete(m.deviceMetrics, deviceID)
+	m.labelsChanged = true
+}
+
 // updateSelfMetrics updates exporter self-monitoring metrics
 func (m *MetricsService) updateSelfMetrics(result *collector.CollectionResult) {
 	// Record collection duration
@@ -288,11 +977,48 @@ func (m *MetricsService) updateMemoryMetrics() {
 	m.memoryBytes.WithLabelValues("heap").Set(float64(memStats.HeapAlloc))
 }
 
-// handleCollectionError handles collection errors and updates error metrics
-func (m *MetricsService) handleCollectionError(err error) {
+// StartRuntimeMetrics refreshes winpower_exporter_goroutines and
+// winpower_exporter_heap_bytes every RuntimeMetricsInterval until ctx is
+// cancelled. The metrics are set once before it returns; it is a no-op when
+// runtime metrics are disabled.
+func (m *MetricsService) StartRuntimeMetrics(ctx context.Context) {
+	if m.goroutines == nil {
+		return
+	}
+
+	m.updateRuntimeMetrics()
+	go func() {
+		ticker := time.NewTicker(m.runtimeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.updateRuntimeMetrics()
+			}
+		}
+	}()
+}
+
+// updateRuntimeMetrics updates the goroutine and heap metrics
+func (m *MetricsService) updateRuntimeMetrics() {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	m.goroutines.Set(float64(runtime.NumGoroutine()))
+	m.heapBytes.Set(float64(memStats.HeapAlloc))
+}
+
+// handleCollectionError handles collection errors and updates error metrics.
+// The network error kind reported in the result, if any, is used as the
+// error type; otherwise the error itself is classified.
+func (m *MetricsService) handleCollectionError(result *collector.CollectionResult, err error) {
 	// Classify error type
 	errorType := "unknown"
-	if err != nil {
+	if result != nil && result.ErrorKind != "" {
+		errorType = result.ErrorKind
+	} else if err != nil {
 		// Classify error by type using type switch
 		switch {
 		case errors.Is(err, context.DeadlineExceeded):
@@ -311,6 +1037,14 @@ func (m *MetricsService) handleCollectionError(err error) {
 	m.connectionStatus.Set(0)
 	// Set auth status to down when collection fails
 	m.authStatus.Set(0)
+
+	// No device was collected, so every known device is down
+	m.mu.Lock()
+	for _, dm := range m.deviceMetrics {
+		dm.up.Set(0)
+	}
+	m.publishDeviceMetrics()
+	m.mu.Unlock()
 }
 
 // Encoding functions for string values to numeric codes
@@ -444,6 +1178,37 @@ func encodeTestStatus(testStatus string) float64 {
 	}
 }
 
+// partialGatherer wraps the service registry and degrades gracefully on
+// gather errors: the failing collectors are logged and counted, while the
+// metric families that were gathered successfully are still returned.
+type partialGatherer struct {
+	service *MetricsService
+}
+
+// Gather implements prometheus.Gatherer
+func (g *partialGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.service.registry.Gather()
+	if err == nil {
+		return families, nil
+	}
+
+	// Registry.Gather unwraps a single error, so normalize to a list
+	gatherErrs := prometheus.MultiError{err}
+	var multiErr prometheus.MultiError
+	if errors.As(err, &multiErr) {
+		gatherErrs = multiErr
+	}
+	for _, gatherErr := range gatherErrs {
+		g.service.logger.Warn("Failed to gather metric family, serving partial metrics",
+			log.Err(gatherErr),
+		)
+	}
+
+	g.service.scrapeErrorsTotal.WithLabelValues("gather").Inc()
+
+	return families, nil
+}
+
 // promhttpLogger wraps log.Logger to implement promhttp.Logger interface
 type promhttpLogger struct {
 	logger log.Logger
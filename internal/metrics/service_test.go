@@ -2,16 +2,28 @@ package metrics
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/lay-g/winpower-g2-exporter/internal/collector"
 	"github.com/lay-g/winpower-g2-exporter/internal/metrics/mocks"
 	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/timing"
+	"github.com/lay-g/winpower-g2-exporter/internal/winpower"
 )
 
 func TestNewMetricsService(t *testing.T) {
@@ -153,6 +165,851 @@ func TestMetricsService_updateMetrics(t *testing.T) {
 	}
 }
 
+func TestMetricsService_lastCollectionTimestamp(t *testing.T) {
+	logger := log.NewTestLogger()
+	service, err := NewMetricsService(mocks.NewMockCollector(), logger, nil)
+	require.NoError(t, err)
+
+	successTime := time.Unix(1700000000, 0)
+	require.NoError(t, service.updateMetrics(&collector.CollectionResult{
+		Success:        true,
+		CollectionTime: successTime,
+		Devices:        make(map[string]*collector.DeviceCollectionInfo),
+	}))
+	assert.Equal(t, float64(successTime.Unix()), testutil.ToFloat64(service.lastCollectionTimestamp))
+
+	// A failed cycle must leave the timestamp unchanged
+	require.NoError(t, service.updateMetrics(&collector.CollectionResult{
+		Success:        false,
+		CollectionTime: successTime.Add(time.Minute),
+		Devices:        make(map[string]*collector.DeviceCollectionInfo),
+	}))
+	assert.Equal(t, float64(successTime.Unix()), testutil.ToFloat64(service.lastCollectionTimestamp))
+}
+
+func TestMetricsService_tokenRefreshTotal(t *testing.T) {
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+
+	update := func(successes, failures int64) {
+		require.NoError(t, service.updateMetrics(&collector.CollectionResult{
+			Devices:              make(map[string]*collector.DeviceCollectionInfo),
+			TokenRefreshes:       successes,
+			TokenRefreshFailures: failures,
+		}))
+	}
+
+	update(1, 0)
+	update(2, 3)
+	// Results without refresh information must not reset the counters
+	update(0, 0)
+	update(2, 4)
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(service.tokenRefreshTotal.WithLabelValues("success")))
+	assert.Equal(t, float64(4), testutil.ToFloat64(service.tokenRefreshTotal.WithLabelValues("failure")))
+}
+
+func TestMetricsService_missingFieldsCountAsParseErrors(t *testing.T) {
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+
+	require.NoError(t, service.updateDeviceMetrics("dev-1", &collector.DeviceCollectionInfo{
+		DeviceID:      "dev-1",
+		MissingFields: []string{"input_volt_1", "load_percent"},
+	}))
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(service.scrapeErrorsTotal.WithLabelValues("parse")))
+}
+
+func TestMetricsService_missingOptionalFields(t *testing.T) {
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+
+	update := func(devices map[string]*collector.DeviceCollectionInfo) {
+		require.NoError(t, service.updateMetrics(&collector.CollectionResult{Success: true, Devices: devices}))
+	}
+
+	// Firmware that never sends an optional field keeps a constant gauge
+	// instead of counting parse errors on every collection
+	for range 3 {
+		update(map[string]*collector.DeviceCollectionInfo{
+			"dev-1": {DeviceID: "dev-1", MissingOptionalFields: []string{"energy_total_wh", "load_avg_watt"}},
+			"dev-2": {DeviceID: "dev-2", MissingOptionalFields: []string{"energy_total_wh"}},
+		})
+	}
+	assert.Equal(t, float64(2), testutil.ToFloat64(service.missingOptionalFields.WithLabelValues("energy_total_wh")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(service.missingOptionalFields.WithLabelValues("load_avg_watt")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(service.scrapeErrorsTotal.WithLabelValues("parse")))
+
+	// A field reported again is removed
+	update(map[string]*collector.DeviceCollectionInfo{
+		"dev-1": {DeviceID: "dev-1", MissingOptionalFields: []string{"energy_total_wh"}},
+		"dev-2": {DeviceID: "dev-2"},
+	})
+	assert.Equal(t, 1, testutil.CollectAndCount(service.missingOptionalFields))
+	assert.Equal(t, float64(1), testutil.ToFloat64(service.missingOptionalFields.WithLabelValues("energy_total_wh")))
+}
+
+func TestMetricsService_deviceUpAndErrors(t *testing.T) {
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+
+	require.NoError(t, service.updateMetrics(&collector.CollectionResult{
+		Success: true,
+		Devices: map[string]*collector.DeviceCollectionInfo{
+			"dev-ok":  {DeviceID: "dev-ok"},
+			"dev-bad": {DeviceID: "dev-bad", ErrorType: collector.DeviceErrorEnergy},
+		},
+	}))
+
+	ok := service.deviceMetrics["dev-ok"]
+	bad := service.deviceMetrics["dev-bad"]
+	assert.Equal(t, float64(1), testutil.ToFloat64(ok.up))
+	assert.Equal(t, float64(0), testutil.ToFloat64(bad.up))
+	assert.Equal(t, float64(1), testutil.ToFloat64(bad.scrapeErrors.WithLabelValues(collector.DeviceErrorEnergy)))
+
+	// A device missing from a later collection is reported down
+	require.NoError(t, service.updateMetrics(&collector.CollectionResult{
+		Success: true,
+		Devices: map[string]*collector.DeviceCollectionInfo{
+			"dev-bad": {DeviceID: "dev-bad"},
+		},
+	}))
+	assert.Equal(t, float64(0), testutil.ToFloat64(ok.up))
+	assert.Equal(t, float64(1), testutil.ToFloat64(bad.up))
+
+	// A total collection failure marks every known device down
+	service.handleCollectionError(nil, errors.New("winpower unreachable"))
+	assert.Equal(t, float64(0), testutil.ToFloat64(bad.up))
+}
+
+func TestMetricsService_duplicateSampleKeepsValues(t *testing.T) {
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+
+	require.NoError(t, service.updateDeviceMetrics("dev-1", &collector.DeviceCollectionInfo{
+		DeviceID:         "dev-1",
+		LoadTotalWatt:    500,
+		EnergyCalculated: true,
+		EnergyValue:      1200,
+	}))
+
+	// A duplicate sample carries no energy and must not reset the gauges
+	require.NoError(t, service.updateDeviceMetrics("dev-1", &collector.DeviceCollectionInfo{
+		DeviceID:      "dev-1",
+		LoadTotalWatt: 0,
+		Duplicate:     true,
+	}))
+
+	dm := service.deviceMetrics["dev-1"]
+	assert.Equal(t, float64(1), testutil.ToFloat64(dm.up))
+	assert.Equal(t, float64(500), testutil.ToFloat64(dm.loadTotalWatt))
+	assert.Equal(t, float64(1200), testutil.ToFloat64(dm.cumulativeEnergy))
+}
+
+func TestMetricsService_RecordConfigReload(t *testing.T) {
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+
+	// Every result is exported before the first reload
+	assert.Equal(t, float64(0), testutil.ToFloat64(service.configReloadsTotal.WithLabelValues(ConfigReloadValidationFailed)))
+
+	service.RecordConfigReload(ConfigReloadValidationFailed)
+	assert.Equal(t, float64(1), testutil.ToFloat64(service.configReloadsTotal.WithLabelValues(ConfigReloadValidationFailed)))
+	assert.Equal(t, float64(0), testutil.ToFloat64(service.configLastReload))
+
+	before := time.Now().Unix()
+	service.RecordConfigReload(ConfigReloadSuccess)
+	assert.Equal(t, float64(1), testutil.ToFloat64(service.configReloadsTotal.WithLabelValues(ConfigReloadSuccess)))
+	assert.GreaterOrEqual(t, testutil.ToFloat64(service.configLastReload), float64(before))
+}
+
+func TestMetricsService_RecordSchedulerOverrun(t *testing.T) {
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+
+	service.RecordSchedulerOverrun()
+	service.RecordSchedulerOverrun()
+	assert.Equal(t, float64(2), testutil.ToFloat64(service.schedulerOverruns))
+}
+
+func TestMetricsService_SetCollectionHealth(t *testing.T) {
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, float64(0), testutil.ToFloat64(service.collectionHealth))
+	service.SetCollectionHealth(2)
+	assert.Equal(t, float64(2), testutil.ToFloat64(service.collectionHealth))
+}
+
+func TestMetricsService_ObserveScrapeDuration(t *testing.T) {
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+
+	service.ObserveScrapeDuration(150 * time.Millisecond)
+	service.ObserveScrapeDuration(3 * time.Second)
+
+	families, err := service.registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == "winpower_exporter_scrape_handler_duration_seconds" {
+			h := family.GetMetric()[0].GetHistogram()
+			assert.Equal(t, uint64(2), h.GetSampleCount())
+			assert.InDelta(t, 3.15, h.GetSampleSum(), 1e-9)
+			return
+		}
+	}
+	t.Fatal("scrape handler duration histogram not registered")
+}
+
+func TestMetricsService_RecordShutdownTimeout(t *testing.T) {
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+
+	service.RecordShutdownTimeout("storage")
+	assert.Equal(t, float64(1), testutil.ToFloat64(service.shutdownTimeoutsTotal.WithLabelValues("storage")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(service.shutdownTimeoutsTotal.WithLabelValues("server")))
+}
+
+func TestMetricsService_SchedulerTicks(t *testing.T) {
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+
+	service.SetSchedulerInterval(5 * time.Second)
+	service.ObserveSchedulerTick(5 * time.Second)
+	service.ObserveSchedulerTick(7 * time.Second)
+	assert.Equal(t, float64(5), testutil.ToFloat64(service.schedulerInterval))
+
+	families, err := service.registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == "winpower_exporter_scheduler_tick_interval_seconds" {
+			histogram := family.GetMetric()[0].GetHistogram()
+			assert.Equal(t, uint64(2), histogram.GetSampleCount())
+			assert.Equal(t, float64(12), histogram.GetSampleSum())
+			return
+		}
+	}
+	t.Fatal("Expected winpower_exporter_scheduler_tick_interval_seconds to be registered")
+}
+
+func TestMetricsService_SetEnergyDegraded(t *testing.T) {
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+
+	service.SetEnergyDegraded(true)
+	assert.Equal(t, float64(1), testutil.ToFloat64(service.energyDegraded))
+	service.SetEnergyDegraded(false)
+	assert.Equal(t, float64(0), testutil.ToFloat64(service.energyDegraded))
+}
+
+func TestMetricsService_SetEnergyStalled(t *testing.T) {
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+
+	service.SetEnergyStalled(2)
+	assert.Equal(t, float64(2), testutil.ToFloat64(service.energyStalled))
+	service.SetEnergyStalled(0)
+	assert.Equal(t, float64(0), testutil.ToFloat64(service.energyStalled))
+}
+
+func TestMetricsService_ResponseObserver(t *testing.T) {
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+
+	service.ObserveResponseBytes(2048)
+	service.ObserveResponseBytes(8192)
+	service.ObserveParseDuration(3 * time.Millisecond)
+	service.ObserveRequestRetries(0)
+	service.ObserveRequestRetries(1)
+	service.ObserveRequestRetries(5)
+	service.ObserveDeviceCountMismatch()
+	service.ObserveInvalidTimestamp()
+
+	families, err := service.registry.Gather()
+	require.NoError(t, err)
+	counts := make(map[string]uint64)
+	var retryBuckets []uint64
+	for _, family := range families {
+		if h := family.GetMetric()[0].GetHistogram(); h != nil {
+			counts[family.GetName()] = h.GetSampleCount()
+			if family.GetName() == "winpower_exporter_request_retries" {
+				for _, bucket := range h.GetBucket() {
+					retryBuckets = append(retryBuckets, bucket.GetCumulativeCount())
+				}
+			}
+		}
+	}
+	assert.Equal(t, uint64(2), counts["winpower_exporter_response_bytes"])
+	assert.Equal(t, uint64(1), counts["winpower_exporter_parse_duration_seconds"])
+
+	// Buckets 0, 1 and 2; the request that needed 5 retries only lands in +Inf
+	assert.Equal(t, uint64(3), counts["winpower_exporter_request_retries"])
+	assert.Equal(t, []uint64{1, 2, 2}, retryBuckets)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(service.countMismatches))
+	assert.Equal(t, float64(1), testutil.ToFloat64(service.invalidTimestamps))
+}
+
+func TestMetricsService_maxDevicesEviction(t *testing.T) {
+	config := DefaultMetricsConfig()
+	config.MaxDevices = 2
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), config)
+	require.NoError(t, err)
+
+	for _, id := range []string{"dev-1", "dev-2"} {
+		require.NoError(t, service.updateDeviceMetrics(id, &collector.DeviceCollectionInfo{DeviceID: id}))
+	}
+	// Refresh dev-1 so dev-2 becomes the least recently updated
+	service.deviceMetrics["dev-2"].lastUpdated = time.Now().Add(-time.Minute)
+	require.NoError(t, service.updateDeviceMetrics("dev-1", &collector.DeviceCollectionInfo{DeviceID: "dev-1"}))
+
+	require.NoError(t, service.updateDeviceMetrics("dev-3", &collector.DeviceCollectionInfo{DeviceID: "dev-3"}))
+
+	assert.Len(t, service.deviceMetrics, 2)
+	assert.Contains(t, service.deviceMetrics, "dev-1")
+	assert.Contains(t, service.deviceMetrics, "dev-3")
+	assert.NotContains(t, service.deviceMetrics, "dev-2")
+	assert.Equal(t, float64(1), testutil.ToFloat64(service.devicesEvicted))
+
+	// Evicted series are gone from the registry
+	families, err := service.registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == labelDeviceID {
+					assert.NotEqual(t, "dev-2", label.GetValue(), family.GetName())
+				}
+			}
+		}
+	}
+
+	// An evicted device that reappears can be registered again
+	require.NoError(t, service.updateDeviceMetrics("dev-2", &collector.DeviceCollectionInfo{DeviceID: "dev-2"}))
+	assert.Contains(t, service.deviceMetrics, "dev-2")
+}
+
+func TestMetricsService_deviceUptime(t *testing.T) {
+	config := DefaultMetricsConfig()
+	config.EnableDeviceUptime = true
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), config)
+	require.NoError(t, err)
+
+	firstSeen := time.Unix(1700000000, 0)
+	require.NoError(t, service.updateDeviceMetrics("dev-1", &collector.DeviceCollectionInfo{
+		DeviceID:       "dev-1",
+		FirstSeenTime:  firstSeen,
+		LastUpdateTime: firstSeen.Add(90 * time.Second),
+	}))
+
+	dm := service.deviceMetrics["dev-1"]
+	require.NotNil(t, dm.uptimeSeconds)
+	assert.Equal(t, float64(90), testutil.ToFloat64(dm.uptimeSeconds))
+	assert.Equal(t, float64(1700000090), testutil.ToFloat64(dm.lastSeenTimestamp))
+
+	// Uptime metrics are not created unless enabled
+	service, err = NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+	require.NoError(t, service.updateDeviceMetrics("dev-1", &collector.DeviceCollectionInfo{DeviceID: "dev-1"}))
+	assert.Nil(t, service.deviceMetrics["dev-1"].uptimeSeconds)
+}
+
+func TestMetricsService_reportedEnergy(t *testing.T) {
+	reported := 5000.5
+	for _, batch := range []bool{false, true} {
+		config := DefaultMetricsConfig()
+		config.EnableReportedEnergy = true
+		config.BatchDeviceUpdates = batch
+		service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), config)
+		require.NoError(t, err)
+
+		update := func(energy *float64) {
+			require.NoError(t, service.updateMetrics(&collector.CollectionResult{
+				Success: true,
+				Devices: map[string]*collector.DeviceCollectionInfo{
+					"dev-1": {DeviceID: "dev-1", ReportedEnergyWh: energy},
+					"dev-2": {DeviceID: "dev-2"},
+				},
+			}))
+		}
+		count := func() int {
+			n, err := testutil.GatherAndCount(service.registry, "winpower_device_reported_energy_wh")
+			require.NoError(t, err)
+			return n
+		}
+
+		// Only devices reporting energy have the series
+		update(&reported)
+		assert.Equal(t, 1, count(), "batch=%v", batch)
+		assert.Equal(t, reported, testutil.ToFloat64(service.deviceMetrics["dev-1"].reportedEnergy))
+
+		// The series is removed when the device stops reporting it
+		update(nil)
+		assert.Equal(t, 0, count(), "batch=%v", batch)
+
+		update(&reported)
+		assert.Equal(t, 1, count(), "batch=%v", batch)
+	}
+
+	// Reported energy metrics are not created unless enabled
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+	require.NoError(t, service.updateDeviceMetrics("dev-1", &collector.DeviceCollectionInfo{DeviceID: "dev-1", ReportedEnergyWh: &reported}))
+	assert.Nil(t, service.deviceMetrics["dev-1"].reportedEnergy)
+}
+
+func TestMetricsService_EnergyResetEpoch(t *testing.T) {
+	for _, batch := range []bool{false, true} {
+		config := DefaultMetricsConfig()
+		config.EnableEnergyResetEpoch = true
+		config.BatchDeviceUpdates = batch
+		service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), config)
+		require.NoError(t, err)
+
+		update := func(energy float64, epoch uint64) {
+			require.NoError(t, service.updateMetrics(&collector.CollectionResult{
+				Success: true,
+				Devices: map[string]*collector.DeviceCollectionInfo{
+					"dev-1": {DeviceID: "dev-1", EnergyCalculated: true, EnergyValue: energy, EnergyResetEpoch: epoch},
+				},
+			}))
+		}
+		// epochs returns the reset_epoch label of every exported energy series
+		epochs := func() []string {
+			families, err := service.registry.Gather()
+			require.NoError(t, err)
+			var values []string
+			for _, family := range families {
+				if family.GetName() != "winpower_device_cumulative_energy" {
+					continue
+				}
+				for _, metric := range family.GetMetric() {
+					for _, label := range metric.GetLabel() {
+						if label.GetName() == labelResetEpoch {
+							values = append(values, label.GetValue())
+						}
+					}
+				}
+			}
+			return values
+		}
+
+		update(1500, 0)
+		assert.Equal(t, []string{"0"}, epochs(), "batch=%v", batch)
+
+		// A reset replaces the series by one with the new epoch
+		update(0, 1)
+		assert.Equal(t, []string{"1"}, epochs(), "batch=%v", batch)
+		assert.Equal(t, 0.0, testutil.ToFloat64(service.deviceMetrics["dev-1"].cumulativeEnergy))
+
+		update(12, 1)
+		assert.Equal(t, []string{"1"}, epochs(), "batch=%v", batch)
+		assert.Equal(t, 12.0, testutil.ToFloat64(service.deviceMetrics["dev-1"].cumulativeEnergy))
+	}
+
+	// Preloaded devices are exported with their persisted epoch
+	config := DefaultMetricsConfig()
+	config.EnableEnergyResetEpoch = true
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), config)
+	require.NoError(t, err)
+	service.PreloadDevices([]PreloadedDevice{
+		{DeviceLabels: DeviceLabels{DeviceID: "dev-1"}, EnergyWh: 20, ResetEpoch: 4},
+	})
+	assert.Equal(t, uint64(4), service.deviceMetrics["dev-1"].resetEpoch)
+	assert.Equal(t, 20.0, testutil.ToFloat64(service.deviceMetrics["dev-1"].cumulativeEnergy))
+
+	// The label is not added unless enabled
+	service, err = NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+	require.NoError(t, service.updateDeviceMetrics("dev-1", &collector.DeviceCollectionInfo{
+		DeviceID: "dev-1", EnergyCalculated: true, EnergyValue: 5, EnergyResetEpoch: 2,
+	}))
+	desc := service.deviceMetrics["dev-1"].cumulativeEnergy.Desc().String()
+	assert.NotContains(t, desc, labelResetEpoch)
+}
+
+func TestMetricsService_invalidValues(t *testing.T) {
+	for _, mode := range []string{InvalidValueSkip, InvalidValueZero} {
+		for _, batch := range []bool{false, true} {
+			config := DefaultMetricsConfig()
+			config.InvalidValueMode = mode
+			config.BatchDeviceUpdates = batch
+			service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), config)
+			require.NoError(t, err)
+
+			// The parser reports NaN voltages as invalid fields left at zero
+			update := func(invalid ...string) {
+				require.NoError(t, service.updateMetrics(&collector.CollectionResult{
+					Success: true,
+					Devices: map[string]*collector.DeviceCollectionInfo{
+						"dev-1": {DeviceID: "dev-1", InputVolt1: 0, OutputVolt1: 0, OutputCurrent1: 4.2, InvalidFields: invalid},
+					},
+				}))
+			}
+			count := func(name string) int {
+				n, err := testutil.GatherAndCount(service.registry, name)
+				require.NoError(t, err)
+				return n
+			}
+			desc := fmt.Sprintf("mode=%s batch=%v", mode, batch)
+
+			update("input_volt_1", "output_volt_1")
+			assert.Equal(t, 1.0, testutil.ToFloat64(service.invalidValuesTotal.WithLabelValues("input_volt_1")), desc)
+			assert.Equal(t, 1.0, testutil.ToFloat64(service.invalidValuesTotal.WithLabelValues("output_volt_1")), desc)
+			assert.Equal(t, 1, count("winpower_device_output_current"), desc)
+			if mode == InvalidValueSkip {
+				assert.Equal(t, 0, count("winpower_device_input_voltage"), desc)
+				assert.Equal(t, 0, count("winpower_device_output_voltage"), desc)
+			} else {
+				assert.Equal(t, 1, count("winpower_device_input_voltage"), desc)
+			}
+
+			// Series return once the values are valid again
+			update("output_volt_1")
+			assert.Equal(t, 1, count("winpower_device_input_voltage"), desc)
+			assert.Equal(t, 2.0, testutil.ToFloat64(service.invalidValuesTotal.WithLabelValues("output_volt_1")), desc)
+			update()
+			assert.Equal(t, 1, count("winpower_device_output_voltage"), desc)
+
+			// A device removed while a series is withheld is removed completely
+			update("load_total_watt")
+			require.NoError(t, service.updateMetrics(&collector.CollectionResult{Success: true}))
+			assert.Equal(t, 0, count("winpower_device_connected"), desc)
+		}
+	}
+}
+
+func TestMetricsService_batteryRuntime(t *testing.T) {
+	config := DefaultMetricsConfig()
+	config.BatteryRuntimeLowMinutes = 15
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), config)
+	require.NoError(t, err)
+
+	require.NoError(t, service.updateDeviceMetrics("dev-1", &collector.DeviceCollectionInfo{
+		DeviceID:      "dev-1",
+		BatRemainTime: 1200,
+	}))
+	dm := service.deviceMetrics["dev-1"]
+	assert.Equal(t, float64(20), testutil.ToFloat64(dm.batteryRuntimeMinutes))
+	assert.Equal(t, float64(0), testutil.ToFloat64(dm.batteryRuntimeLow))
+
+	require.NoError(t, service.updateDeviceMetrics("dev-1", &collector.DeviceCollectionInfo{
+		DeviceID:      "dev-1",
+		BatRemainTime: 600,
+	}))
+	assert.Equal(t, float64(10), testutil.ToFloat64(dm.batteryRuntimeMinutes))
+	assert.Equal(t, float64(1), testutil.ToFloat64(dm.batteryRuntimeLow))
+
+	// A zero threshold never reports low runtime
+	config.BatteryRuntimeLowMinutes = 0
+	service, err = NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), config)
+	require.NoError(t, err)
+	require.NoError(t, service.updateDeviceMetrics("dev-1", &collector.DeviceCollectionInfo{DeviceID: "dev-1"}))
+	assert.Equal(t, float64(0), testutil.ToFloat64(service.deviceMetrics["dev-1"].batteryRuntimeLow))
+
+	config.BatteryRuntimeLowMinutes = -1
+	assert.Error(t, config.Validate())
+}
+
+func TestMetricsService_HandleMetrics_ConcurrencyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	calls := 0
+	var mu sync.Mutex
+	mockCollector := &mocks.MockCollector{
+		CollectDeviceDataFunc: func(ctx context.Context) (*collector.CollectionResult, error) {
+			mu.Lock()
+			calls++
+			first := calls == 1
+			mu.Unlock()
+			if !first {
+				started <- struct{}{}
+				<-release
+			}
+			return &collector.CollectionResult{Success: true, CollectionTime: time.Now()}, nil
+		},
+	}
+
+	config := DefaultMetricsConfig()
+	config.MaxConcurrentCollections = 1
+	config.CollectionWaitTimeout = 10 * time.Millisecond
+	service, err := NewMetricsService(mockCollector, log.NewTestLogger(), config)
+	require.NoError(t, err)
+
+	serve := func() int {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		service.HandleMetrics(c)
+		return w.Code
+	}
+
+	// Prime the cache
+	require.Equal(t, http.StatusOK, serve())
+
+	// Hold the only collection slot
+	done := make(chan int)
+	go func() { done <- serve() }()
+	<-started
+
+	// A concurrent scrape is served from the cache without collecting
+	assert.Equal(t, http.StatusOK, serve())
+	assert.Equal(t, float64(1), testutil.ToFloat64(service.collectionsThrottled))
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-done)
+	mu.Lock()
+	assert.Equal(t, 2, calls)
+	mu.Unlock()
+}
+
+func TestMetricsService_HandleMetrics_ThrottledWithoutCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var calls atomic.Int32
+	mockCollector := &mocks.MockCollector{
+		CollectDeviceDataFunc: func(ctx context.Context) (*collector.CollectionResult, error) {
+			calls.Add(1)
+			return &collector.CollectionResult{Success: true, CollectionTime: time.Now()}, nil
+		},
+	}
+
+	newService := func() *MetricsService {
+		config := DefaultMetricsConfig()
+		config.MaxConcurrentCollections = 1
+		config.CollectionWaitTimeout = time.Millisecond
+		service, err := NewMetricsService(mockCollector, log.NewTestLogger(), config)
+		require.NoError(t, err)
+		return service
+	}
+
+	serve := func(service *MetricsService) <-chan int {
+		code := make(chan int, 1)
+		go func() {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			service.HandleMetrics(c)
+			code <- w.Code
+		}()
+		return code
+	}
+
+	t.Run("waits for the collection in flight and serves its result", func(t *testing.T) {
+		calls.Store(0)
+		service := newService()
+
+		// Occupy the only slot as a collection in flight would
+		service.collectSem <- struct{}{}
+		code := serve(service)
+
+		select {
+		case got := <-code:
+			t.Fatalf("Expected the scrape to wait for the collection in flight, got status %d", got)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		service.lastResult.Store(&collector.CollectionResult{Success: true, CollectionTime: time.Now()})
+		<-service.collectSem
+
+		assert.Equal(t, http.StatusOK, <-code)
+		assert.Equal(t, int32(0), calls.Load())
+		assert.Equal(t, float64(1), testutil.ToFloat64(service.collectionsThrottled))
+	})
+
+	t.Run("collects when the collection in flight failed", func(t *testing.T) {
+		calls.Store(0)
+		service := newService()
+
+		service.collectSem <- struct{}{}
+		code := serve(service)
+		time.Sleep(10 * time.Millisecond)
+		<-service.collectSem
+
+		assert.Equal(t, http.StatusOK, <-code)
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Equal(t, float64(0), testutil.ToFloat64(service.collectionsThrottled))
+	})
+}
+
+func TestMetricsService_HandleMetrics_StaleMaxAge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var collectErr error
+	collectionTime := time.Now()
+	mockCollector := &mocks.MockCollector{
+		CollectDeviceDataFunc: func(ctx context.Context) (*collector.CollectionResult, error) {
+			if collectErr != nil {
+				return nil, collectErr
+			}
+			return &collector.CollectionResult{
+				Success:        true,
+				DeviceCount:    1,
+				CollectionTime: collectionTime,
+				Devices: map[string]*collector.DeviceCollectionInfo{
+					"ups-1": {DeviceID: "ups-1", Connected: true, LoadTotalWatt: 500},
+				},
+			}, nil
+		},
+	}
+
+	newService := func(staleMaxAge time.Duration) (*MetricsService, *log.TestLogger) {
+		logger := log.NewTestLogger()
+		config := DefaultMetricsConfig()
+		config.StaleMaxAge = staleMaxAge
+		service, err := NewMetricsService(mockCollector, logger, config)
+		require.NoError(t, err)
+		return service, logger
+	}
+
+	serve := func(service *MetricsService) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		service.HandleMetrics(c)
+		return w
+	}
+
+	t.Run("failed collection serves recent metrics", func(t *testing.T) {
+		collectErr = nil
+		service, logger := newService(time.Minute)
+		require.Equal(t, http.StatusOK, serve(service).Code)
+		assert.Equal(t, 0.0, testutil.ToFloat64(service.metricsStaleness))
+
+		collectErr = errors.New("winpower unreachable")
+		collectionTime = time.Now().Add(-10 * time.Second)
+		service.lastResult.Load().CollectionTime = collectionTime
+
+		w := serve(service)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "winpower_power_watts")
+		assert.InDelta(t, 10, testutil.ToFloat64(service.metricsStaleness), 1)
+		assert.Equal(t, 0.0, testutil.ToFloat64(service.connectionStatus))
+		assert.Equal(t, 1.0, testutil.ToFloat64(service.scrapeErrorsTotal.WithLabelValues("collection_failed")))
+		assert.NotEmpty(t, logger.EntriesByMessage("Failed to collect device data, serving metrics of the last successful collection"))
+	})
+
+	t.Run("failed collection beyond the limit fails the scrape", func(t *testing.T) {
+		collectErr = nil
+		collectionTime = time.Now().Add(-2 * time.Minute)
+		service, _ := newService(time.Minute)
+		require.Equal(t, http.StatusOK, serve(service).Code)
+
+		collectErr = errors.New("winpower unreachable")
+		assert.Equal(t, http.StatusInternalServerError, serve(service).Code)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		collectErr = nil
+		collectionTime = time.Now()
+		service, _ := newService(0)
+		require.Equal(t, http.StatusOK, serve(service).Code)
+
+		collectErr = errors.New("winpower unreachable")
+		assert.Equal(t, http.StatusInternalServerError, serve(service).Code)
+	})
+
+	t.Run("failed collection without a previous result fails the scrape", func(t *testing.T) {
+		collectErr = errors.New("winpower unreachable")
+		service, _ := newService(time.Minute)
+		assert.Equal(t, http.StatusInternalServerError, serve(service).Code)
+	})
+}
+
+func TestMetricsService_HandleMetrics_SourceMaintenance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	maintenance := false
+	mockCollector := &mocks.MockCollector{
+		CollectDeviceDataFunc: func(ctx context.Context) (*collector.CollectionResult, error) {
+			if maintenance {
+				return &collector.CollectionResult{
+					Devices:     map[string]*collector.DeviceCollectionInfo{},
+					Maintenance: true,
+				}, collector.ErrSourceMaintenance
+			}
+			return &collector.CollectionResult{
+				Success:        true,
+				DeviceCount:    1,
+				CollectionTime: time.Now(),
+				Devices: map[string]*collector.DeviceCollectionInfo{
+					"ups-1": {DeviceID: "ups-1", Connected: true, LoadTotalWatt: 500},
+				},
+			}, nil
+		},
+	}
+
+	// Stale serving is disabled; maintenance keeps the last values anyway
+	service, err := NewMetricsService(mockCollector, log.NewTestLogger(), DefaultMetricsConfig())
+	require.NoError(t, err)
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		service.HandleMetrics(c)
+		return w
+	}
+
+	require.Equal(t, http.StatusOK, serve().Code)
+	assert.Equal(t, 0.0, testutil.ToFloat64(service.sourceMaintenance))
+
+	maintenance = true
+	w := serve()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `winpower_power_watts{device_id="ups-1"`)
+	assert.Contains(t, w.Body.String(), "winpower_exporter_source_maintenance")
+	assert.Equal(t, 1.0, testutil.ToFloat64(service.sourceMaintenance))
+	assert.Equal(t, 0, testutil.CollectAndCount(service.scrapeErrorsTotal))
+
+	maintenance = false
+	require.Equal(t, http.StatusOK, serve().Code)
+	assert.Equal(t, 0.0, testutil.ToFloat64(service.sourceMaintenance))
+}
+
+// failingCollector is a prometheus.Collector whose Collect always reports an error
+type failingCollector struct {
+	desc *prometheus.Desc
+}
+
+func (f *failingCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- f.desc
+}
+
+func (f *failingCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.NewInvalidMetric(f.desc, errors.New("simulated gather failure"))
+}
+
+func TestMetricsService_HandleMetrics_PartialGather(t *testing.T) {
+	logger := log.NewTestLogger()
+	service, err := NewMetricsService(mocks.NewMockCollectorWithDevices(), logger, nil)
+	require.NoError(t, err)
+
+	service.registry.MustRegister(&failingCollector{
+		desc: prometheus.NewDesc("test_failing_metric", "Always fails", nil, nil),
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/metrics", service.HandleMetrics)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, "winpower_exporter_up")
+	assert.Contains(t, body, "winpower_device_connected")
+	assert.NotContains(t, body, "test_failing_metric")
+
+	assert.NotEmpty(t, logger.EntriesByMessage("Failed to gather metric family, serving partial metrics"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(service.scrapeErrorsTotal.WithLabelValues("gather")))
+}
+
 func TestMetricsService_updateDeviceMetrics(t *testing.T) {
 	logger := log.NewTestLogger()
 	mockCollector := mocks.NewMockCollector()
@@ -230,7 +1087,7 @@ func TestMetricsService_handleCollectionError(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			service.handleCollectionError(tt.err)
+			service.handleCollectionError(nil, tt.err)
 			// Verify that error counter was incremented
 			// Note: We can't easily verify counter values without using testutil
 			// but we can verify the function doesn't panic
@@ -238,6 +1095,22 @@ func TestMetricsService_handleCollectionError(t *testing.T) {
 	}
 }
 
+func TestMetricsService_handleCollectionError_ErrorKind(t *testing.T) {
+	logger := log.NewTestLogger()
+	mockCollector := mocks.NewMockCollector()
+	service, err := NewMetricsService(mockCollector, logger, nil)
+	require.NoError(t, err)
+
+	result := &collector.CollectionResult{ErrorKind: "dns"}
+	service.handleCollectionError(result, errors.New("lookup winpower: no such host"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(service.scrapeErrorsTotal.WithLabelValues("dns")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(service.scrapeErrorsTotal.WithLabelValues("collection_failed")))
+
+	// Without an error kind the error itself is classified
+	service.handleCollectionError(&collector.CollectionResult{}, context.DeadlineExceeded)
+	assert.Equal(t, float64(1), testutil.ToFloat64(service.scrapeErrorsTotal.WithLabelValues("timeout")))
+}
+
 func TestMetricsService_updateSelfMetrics(t *testing.T) {
 	logger := log.NewTestLogger()
 	mockCollector := mocks.NewMockCollector()
@@ -269,6 +1142,39 @@ func TestMetricsService_updateMemoryMetrics(t *testing.T) {
 	// Verify that the function doesn't panic
 }
 
+func TestMetricsService_StartRuntimeMetrics(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+		require.NoError(t, err)
+
+		service.StartRuntimeMetrics(context.Background())
+		assert.Nil(t, service.goroutines)
+		assert.Nil(t, service.heapBytes)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		config := DefaultMetricsConfig()
+		config.EnableRuntimeMetrics = true
+		config.RuntimeMetricsInterval = 10 * time.Millisecond
+		service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), config)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		service.StartRuntimeMetrics(ctx)
+
+		// Set before StartRuntimeMetrics returns
+		assert.GreaterOrEqual(t, testutil.ToFloat64(service.goroutines), float64(1))
+		assert.Greater(t, testutil.ToFloat64(service.heapBytes), float64(0))
+
+		// Refreshed by the timer
+		service.goroutines.Set(0)
+		assert.Eventually(t, func() bool {
+			return testutil.ToFloat64(service.goroutines) >= 1
+		}, time.Second, 5*time.Millisecond)
+	})
+}
+
 func TestEncodingFunctions(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -323,6 +1229,7 @@ func TestDefaultMetricsConfig(t *testing.T) {
 	assert.Equal(t, "exporter", config.Subsystem)
 	assert.Equal(t, "localhost", config.WinPowerHost)
 	assert.True(t, config.EnableMemoryMetrics)
+	assert.Equal(t, 1000, config.MaxDevices)
 }
 
 func TestPromhttpLogger(t *testing.T) {
@@ -377,3 +1284,372 @@ func TestMetricsService_concurrentAccess(t *testing.T) {
 		<-done
 	}
 }
+
+func TestMetricsService_HandleDebugCollect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(service *MetricsService) (*httptest.ResponseRecorder, DebugCollectResult) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/debug/collect", nil)
+		service.HandleDebugCollect(c)
+
+		var body DebugCollectResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w, body
+	}
+
+	t.Run("reports stage timings", func(t *testing.T) {
+		mockCollector := mocks.NewMockCollectorWithDevices()
+		inner := mockCollector.CollectDeviceDataFunc
+		mockCollector.CollectDeviceDataFunc = func(ctx context.Context) (*collector.CollectionResult, error) {
+			// Stand in for the stages recorded by the collector chain
+			timing.Track(ctx, timing.StageFetch)()
+			return inner(ctx)
+		}
+		service, err := NewMetricsService(mockCollector, log.NewTestLogger(), nil)
+		require.NoError(t, err)
+
+		w, body := serve(service)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, body.Success)
+		assert.Equal(t, 2, body.DeviceCount)
+
+		var names []string
+		for _, stage := range body.Stages {
+			names = append(names, stage.Name)
+		}
+		assert.Equal(t, []string{timing.StageFetch, timing.StageMetricEmit}, names)
+		assert.Len(t, service.deviceMetrics, 2)
+	})
+
+	t.Run("collection failure", func(t *testing.T) {
+		mockCollector := &mocks.MockCollector{
+			CollectDeviceDataFunc: func(ctx context.Context) (*collector.CollectionResult, error) {
+				timing.Track(ctx, timing.StageAuth)()
+				return nil, errors.New("login failed")
+			},
+		}
+		service, err := NewMetricsService(mockCollector, log.NewTestLogger(), nil)
+		require.NoError(t, err)
+
+		w, body := serve(service)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.False(t, body.Success)
+		assert.Equal(t, "login failed", body.Error)
+		require.Len(t, body.Stages, 1)
+		assert.Equal(t, timing.StageAuth, body.Stages[0].Name)
+	})
+}
+
+// storeCollector is a MockCollector that exposes a device store
+type storeCollector struct {
+	*mocks.MockCollector
+	store *collector.DeviceStore
+}
+
+func (s *storeCollector) DeviceStore() *collector.DeviceStore {
+	return s.store
+}
+
+// snapshotCollector is a storeCollector that also publishes snapshots
+type snapshotCollector struct {
+	storeCollector
+	snapshot *collector.Snapshot
+}
+
+func (s *snapshotCollector) Snapshot() *collector.Snapshot {
+	return s.snapshot
+}
+
+func TestMetricsService_HandleDebugDevices(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(service *MetricsService) (*httptest.ResponseRecorder, DebugDevicesResult) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/debug/devices", nil)
+		service.HandleDebugDevices(c)
+
+		var body DebugDevicesResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w, body
+	}
+
+	t.Run("returns cached device states", func(t *testing.T) {
+		store := collector.NewDeviceStore()
+		updated := time.Now().Add(-time.Minute)
+		store.Update([]winpower.ParsedDeviceData{
+			{DeviceID: "ups-2"},
+			{DeviceID: "ups-1", Realtime: winpower.RealtimeData{LoadTotalWatt: 120}},
+		}, updated)
+
+		calls := 0
+		mockCollector := &mocks.MockCollector{
+			CollectDeviceDataFunc: func(ctx context.Context) (*collector.CollectionResult, error) {
+				calls++
+				return nil, errors.New("unexpected collection")
+			},
+		}
+		service, err := NewMetricsService(&storeCollector{MockCollector: mockCollector, store: store}, log.NewTestLogger(), nil)
+		require.NoError(t, err)
+
+		w, body := serve(service)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 0, calls)
+		assert.Equal(t, 2, body.DeviceCount)
+		require.Len(t, body.Devices, 2)
+		assert.Equal(t, "ups-1", body.Devices[0].Data.DeviceID)
+		assert.Equal(t, 120.0, body.Devices[0].Data.Realtime.LoadTotalWatt)
+		assert.True(t, body.Devices[0].Present)
+		assert.True(t, body.Devices[0].LastUpdated.Equal(updated))
+		assert.GreaterOrEqual(t, body.Devices[0].AgeSeconds, 60.0)
+	})
+
+	t.Run("serves the latest snapshot", func(t *testing.T) {
+		collectionTime := time.Now().Add(-time.Minute)
+		source := &snapshotCollector{
+			storeCollector: storeCollector{MockCollector: mocks.NewMockCollector(), store: collector.NewDeviceStore()},
+		}
+		service, err := NewMetricsService(source, log.NewTestLogger(), nil)
+		require.NoError(t, err)
+
+		// Without a snapshot yet the live store is served
+		w, body := serve(service)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Nil(t, body.CollectionTime)
+
+		source.snapshot = &collector.Snapshot{
+			CollectionTime: collectionTime,
+			Devices: []collector.DeviceSnapshot{{
+				DeviceState: collector.DeviceState{
+					Data:        winpower.ParsedDeviceData{DeviceID: "ups-1"},
+					LastUpdated: collectionTime,
+					Present:     true,
+				},
+				Metrics: &collector.DeviceCollectionInfo{
+					DeviceID:         "ups-1",
+					LoadTotalWatt:    120,
+					EnergyCalculated: true,
+					EnergyValue:      1234.5,
+				},
+			}},
+		}
+
+		w, body = serve(service)
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.NotNil(t, body.CollectionTime)
+		assert.True(t, body.CollectionTime.Equal(collectionTime))
+		require.Len(t, body.Devices, 1)
+		require.NotNil(t, body.Devices[0].Metrics)
+		assert.Equal(t, 120.0, body.Devices[0].Metrics.LoadTotalWatt)
+		assert.Equal(t, 1234.5, body.Devices[0].Metrics.EnergyValue)
+		assert.GreaterOrEqual(t, body.Devices[0].AgeSeconds, 60.0)
+	})
+
+	t.Run("collector without store", func(t *testing.T) {
+		service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+		require.NoError(t, err)
+
+		w, body := serve(service)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.NotEmpty(t, body.Error)
+		assert.Empty(t, body.Devices)
+	})
+}
+
+func TestMetricsService_CollectionPaused(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	calls := 0
+	mockCollector := &mocks.MockCollector{
+		CollectDeviceDataFunc: func(ctx context.Context) (*collector.CollectionResult, error) {
+			calls++
+			return &collector.CollectionResult{Success: true, CollectionTime: time.Now()}, nil
+		},
+	}
+	service, err := NewMetricsService(mockCollector, log.NewTestLogger(), nil)
+	require.NoError(t, err)
+
+	serve := func() int {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		service.HandleMetrics(c)
+		return w.Code
+	}
+
+	// Paused before any collection: served without error or collecting
+	service.SetCollectionPaused(true)
+	assert.Equal(t, http.StatusOK, serve())
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, float64(1), testutil.ToFloat64(service.schedulerPaused))
+	assert.Equal(t, float64(0), testutil.ToFloat64(service.collectionsThrottled))
+
+	service.SetCollectionPaused(false)
+	assert.Equal(t, http.StatusOK, serve())
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, float64(0), testutil.ToFloat64(service.schedulerPaused))
+}
+
+func TestMetricsService_deviceTypePrefixes(t *testing.T) {
+	config := DefaultMetricsConfig()
+	config.DeviceTypePrefixes = map[string]string{"1": "ups"}
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), config)
+	require.NoError(t, err)
+
+	require.NoError(t, service.updateDeviceMetrics("ups-1", &collector.DeviceCollectionInfo{DeviceType: 1}))
+	require.NoError(t, service.updateDeviceMetrics("other-1", &collector.DeviceCollectionInfo{DeviceType: 7}))
+
+	families, err := service.registry.Gather()
+	require.NoError(t, err)
+	names := make(map[string]bool)
+	for _, family := range families {
+		names[family.GetName()] = true
+	}
+
+	// Mapped type uses type-specific names
+	assert.True(t, names["winpower_ups_power_watts"])
+	assert.True(t, names["winpower_ups_input_voltage"])
+	assert.True(t, names["winpower_ups_temperature"])
+	// Unmapped type keeps the default names
+	assert.True(t, names["winpower_power_watts"])
+	assert.True(t, names["winpower_device_input_voltage"])
+}
+
+func TestMetricsService_deviceTypeCode(t *testing.T) {
+	config := DefaultMetricsConfig()
+	config.EnableDeviceTypeCode = true
+	config.DeviceTypeCodes = map[string]int{"1": 10, "2": 20}
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), config)
+	require.NoError(t, err)
+
+	require.NoError(t, service.updateDeviceMetrics("ups-1", &collector.DeviceCollectionInfo{DeviceType: 1}))
+	require.NoError(t, service.updateDeviceMetrics("other-1", &collector.DeviceCollectionInfo{DeviceType: 7}))
+
+	assert.Equal(t, float64(10), testutil.ToFloat64(service.deviceMetrics["ups-1"].typeCode))
+	// Unmapped types get the default code
+	assert.Equal(t, float64(-1), testutil.ToFloat64(service.deviceMetrics["other-1"].typeCode))
+
+	// The code is exported without the device_type label
+	families, err := service.registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != "winpower_device_type_code" {
+			continue
+		}
+		require.Len(t, family.GetMetric(), 2)
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				assert.NotEqual(t, labelDeviceType, label.GetName())
+			}
+		}
+		return
+	}
+	t.Fatal("winpower_device_type_code not exported")
+}
+
+func TestMetricsService_deviceTypeCodeDisabled(t *testing.T) {
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+
+	require.NoError(t, service.updateDeviceMetrics("ups-1", &collector.DeviceCollectionInfo{DeviceType: 1}))
+	assert.Nil(t, service.deviceMetrics["ups-1"].typeCode)
+}
+
+func TestMetricsService_deviceGroups(t *testing.T) {
+	config := DefaultMetricsConfig()
+	config.EnableDeviceTypeCode = true
+	// Viper lowercases map keys, so IDs are matched case-insensitively
+	config.DeviceGroups = map[string]string{"ups-a1": "rack-a"}
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), config)
+	require.NoError(t, err)
+
+	require.NoError(t, service.updateDeviceMetrics("UPS-A1", &collector.DeviceCollectionInfo{}))
+	require.NoError(t, service.updateDeviceMetrics("ups-2", &collector.DeviceCollectionInfo{}))
+
+	groups := map[string]string{}
+	families, err := service.registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if !strings.HasPrefix(family.GetName(), "winpower_device_") {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			var deviceID, group string
+			for _, label := range metric.GetLabel() {
+				switch label.GetName() {
+				case labelDeviceID:
+					deviceID = label.GetValue()
+				case labelDeviceGroup:
+					group = label.GetValue()
+				}
+			}
+			assert.NotEmpty(t, group, "%s without device_group", family.GetName())
+			groups[deviceID] = group
+		}
+	}
+	assert.Equal(t, map[string]string{"UPS-A1": "rack-a", "ups-2": "ungrouped"}, groups)
+}
+
+func TestMetricsService_deviceGroupsDisabled(t *testing.T) {
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+	require.NoError(t, service.updateDeviceMetrics("ups-1", &collector.DeviceCollectionInfo{}))
+
+	families, err := service.registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				assert.NotEqual(t, labelDeviceGroup, label.GetName())
+			}
+		}
+	}
+}
+
+func TestMetricsService_activeAlarms(t *testing.T) {
+	config := DefaultMetricsConfig()
+	config.EnableAlarmTypes = true
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), config)
+	require.NoError(t, err)
+
+	require.NoError(t, service.updateDeviceMetrics("ups-1", &collector.DeviceCollectionInfo{
+		ActiveAlarms: []string{"overload", "on_battery", "overload"},
+	}))
+	require.NoError(t, service.updateDeviceMetrics("ups-2", &collector.DeviceCollectionInfo{}))
+
+	dm := service.deviceMetrics["ups-1"]
+	assert.Equal(t, float64(3), testutil.ToFloat64(dm.activeAlarms))
+	assert.Equal(t, float64(2), testutil.ToFloat64(dm.alarmTypes.WithLabelValues("overload")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(dm.alarmTypes.WithLabelValues("on_battery")))
+
+	// Devices without alarms report 0
+	assert.Equal(t, float64(0), testutil.ToFloat64(service.deviceMetrics["ups-2"].activeAlarms))
+
+	// Cleared alarm types are removed
+	require.NoError(t, service.updateDeviceMetrics("ups-1", &collector.DeviceCollectionInfo{
+		ActiveAlarms: []string{"on_battery"},
+	}))
+	assert.Equal(t, float64(1), testutil.ToFloat64(dm.activeAlarms))
+	assert.Equal(t, 1, testutil.CollectAndCount(dm.alarmTypes))
+}
+
+func TestMetricsService_alarmTypesDisabled(t *testing.T) {
+	service, err := NewMetricsService(mocks.NewMockCollector(), log.NewTestLogger(), nil)
+	require.NoError(t, err)
+
+	require.NoError(t, service.updateDeviceMetrics("ups-1", &collector.DeviceCollectionInfo{
+		ActiveAlarms: []string{"overload"},
+	}))
+	assert.Equal(t, float64(1), testutil.ToFloat64(service.deviceMetrics["ups-1"].activeAlarms))
+	assert.Nil(t, service.deviceMetrics["ups-1"].alarmTypes)
+}
+
+func TestDeviceMetricName(t *testing.T) {
+	assert.Equal(t, "device_input_voltage", deviceMetricName("", "device_input_voltage"))
+	assert.Equal(t, "ups_input_voltage", deviceMetricName("ups", "device_input_voltage"))
+	assert.Equal(t, "ups_power_watts", deviceMetricName("ups", "power_watts"))
+	assert.Equal(t, "ups_temperature", deviceMetricName("ups", "device_ups_temperature"))
+	assert.Equal(t, "pdu_ups_temperature", deviceMetricName("pdu", "device_ups_temperature"))
+}
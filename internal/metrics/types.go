@@ -1,12 +1,20 @@
 package metrics
 
 import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/lay-g/winpower-g2-exporter/internal/collector"
 	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/timing"
 )
 
 // MetricsService manages Prometheus metrics and provides HTTP handler for /metrics endpoint
@@ -15,35 +23,137 @@ type MetricsService struct {
 	collector    collector.CollectorInterface
 	logger       log.Logger
 	winpowerHost string // Configuration value for WinPower host label
+	deviceUptime bool   // Whether per-device uptime metrics are exported
+	maxDevices   int    // Maximum number of tracked devices (0 = unlimited)
+
+	reportedEnergy bool // Whether WinPower-reported device energy is exported
+
+	energyResetEpoch bool // Whether the cumulative energy carries the reset_epoch label
+
+	skipInvalidValues bool // Whether series of NaN or infinite values are withheld instead of exported as 0
+
+	maxLabelLength int // Maximum label value length in runes (0 = unlimited)
+
+	batteryRuntimeLow float64 // Battery runtime in minutes below which runtime_low is 1 (0 = never)
+
+	deviceTypePrefixes map[string]string // Device type -> metric name prefix (empty = label-based names)
+
+	// Device type codes exported by winpower_device_type_code
+	deviceTypeCode  bool
+	typeCodes       map[string]int
+	defaultTypeCode int
+
+	alarmTypes bool // Whether active alarms are also exported per alarm type
+
+	// Device groups exported as the device_group label, keyed by lowercase
+	// device ID; nil when devices are not grouped
+	deviceGroups map[string]string
+	defaultGroup string
+
+	helpOverrides map[string]string // Metric name -> HELP text replacing the default
 
 	// Exporter self-monitoring metrics
 	exporterUp                prometheus.Gauge
 	requestsTotal             *prometheus.CounterVec
 	requestDuration           *prometheus.HistogramVec
+	scrapeHandlerDuration     prometheus.Histogram
 	collectionDuration        *prometheus.HistogramVec
 	scrapeErrorsTotal         *prometheus.CounterVec
 	tokenRefreshTotal         *prometheus.CounterVec
 	deviceCount               prometheus.Gauge
 	memoryBytes               *prometheus.GaugeVec
+	goroutines                prometheus.Gauge // nil when runtime metrics are disabled
+	heapBytes                 prometheus.Gauge // nil when runtime metrics are disabled
+	runtimeInterval           time.Duration
 	lastCollectionTimeSeconds prometheus.Gauge
+	lastCollectionTimestamp   prometheus.Gauge
+	collectionsThrottled      prometheus.Counter
+	metricsStaleness          prometheus.Gauge
+	sourceMaintenance         prometheus.Gauge
+	devicesEvicted            prometheus.Counter
+	invalidValuesTotal        *prometheus.CounterVec
+	missingOptionalFields     *prometheus.GaugeVec
+	schedulerPaused           prometheus.Gauge
+	schedulerOverruns         prometheus.Counter
+	schedulerInterval         prometheus.Gauge
+	schedulerTickInterval     prometheus.Histogram
+	collectionHealth          prometheus.Gauge
+	energyDegraded            prometheus.Gauge
+	energyStalled             prometheus.Gauge
+	configReloadsTotal        *prometheus.CounterVec
+	configLastReload          prometheus.Gauge
+	shutdownTimeoutsTotal     *prometheus.CounterVec
+	pushesTotal               *prometheus.CounterVec // nil when push mode is disabled
 
 	// WinPower connection/auth metrics
 	connectionStatus   prometheus.Gauge
 	authStatus         prometheus.Gauge
 	apiResponseTime    *prometheus.HistogramVec
+	responseBytes      prometheus.Histogram
+	parseDuration      prometheus.Histogram
+	requestRetries     prometheus.Histogram
+	countMismatches    prometheus.Counter
+	invalidTimestamps  prometheus.Counter
 	tokenExpirySeconds prometheus.Gauge
 	tokenValid         prometheus.Gauge
 
+	// On-scrape collection limiting
+	collectSem  chan struct{}                              // nil when concurrency is unlimited
+	collectWait time.Duration                              // How long a scrape waits for a free slot
+	lastResult  atomic.Pointer[collector.CollectionResult] // Last collection result, served when throttled or stale
+	staleMaxAge time.Duration                              // Max age of the last result served when a collection fails (0 = fail)
+	paused      atomic.Bool                                // Serve last-known metrics without collecting
+
+	// Push mode: scheduled collections are pushed to a Pushgateway
+	pusher *pusher // nil when push mode is disabled
+
+	// Token refresh counts already added to tokenRefreshTotal
+	tokenRefreshes       int64
+	tokenRefreshFailures int64
+	tokenRefreshMu       sync.Mutex
+
 	// Device metrics - dynamically created per device
 	deviceMetrics map[string]*DeviceMetrics
 	mu            sync.RWMutex // Protects deviceMetrics map
+
+	// Batched device updates: the device series are served from a snapshot
+	// published once per update cycle instead of being registered
+	batchUpdates   bool
+	deviceSnapshot atomic.Pointer[[]prometheus.Metric]
+
+	// Labels of the tracked devices, persisted so that their energy can be
+	// preloaded after a restart
+	labelRecorder DeviceLabelRecorder // nil when labels are not persisted
+	labelsChanged bool                // The tracked devices changed since they were last recorded
+}
+
+// DeviceLabels identifies a device's series and the key its energy is
+// stored under
+type DeviceLabels struct {
+	DeviceID   string
+	DeviceName string
+	DeviceType int
+	EnergyKey  string // Empty when the energy is stored under the device ID
+}
+
+// DeviceLabelRecorder persists the labels of the tracked devices. It is
+// implemented by the application.
+type DeviceLabelRecorder interface {
+	// RecordDeviceLabels replaces the recorded labels with the given devices
+	RecordDeviceLabels(devices []DeviceLabels) error
 }
 
 // DeviceMetrics holds all Prometheus metrics for a single device
 type DeviceMetrics struct {
 	// Device status
+	up                  prometheus.Gauge       // 1 if the device's last collection succeeded
+	scrapeErrors        *prometheus.CounterVec // Has error_type label
 	connected           prometheus.Gauge
 	lastUpdateTimestamp prometheus.Gauge
+	lastSeenTimestamp   prometheus.Gauge
+	uptimeSeconds       prometheus.Gauge // nil unless device uptime metrics are enabled
+	typeCode            prometheus.Gauge // nil unless device type codes are enabled
+	lastUpdated         time.Time        // When the device's series were last updated, used for eviction
 
 	// Electrical parameters - Input
 	inputVoltage   prometheus.Gauge
@@ -68,6 +178,8 @@ type DeviceMetrics struct {
 	batteryVoltagePercent prometheus.Gauge
 	batteryCapacity       prometheus.Gauge
 	batteryRemainSeconds  prometheus.Gauge
+	batteryRuntimeMinutes prometheus.Gauge
+	batteryRuntimeLow     prometheus.Gauge // 1 when the runtime is below the configured threshold
 	batteryStatus         prometheus.Gauge
 
 	// UPS status
@@ -77,31 +189,295 @@ type DeviceMetrics struct {
 	upsTestStatus  prometheus.Gauge
 	upsFaultCode   *prometheus.GaugeVec // Has fault_code label
 
+	// Alarms
+	activeAlarms prometheus.Gauge     // Number of active alarms, 0 without alarms
+	alarmTypes   *prometheus.GaugeVec // Has alarm_type label; nil unless alarm types are enabled
+
 	// Energy
 	cumulativeEnergy prometheus.Gauge
+	reportedEnergy   prometheus.Gauge // nil unless reported energy metrics are enabled
+	reportsEnergy    bool             // Whether reportedEnergy is exported, i.e. the device reports its energy
+
+	// Reset epoch label of cumulativeEnergy, which is recreated when it changes
+	energyOpts prometheus.GaugeOpts
+	resetEpoch uint64
+
+	// Restoring energy after a restart
+	labels    DeviceLabels // Labels as reported by WinPower, recorded for the next preload
+	preloaded bool         // Only cumulativeEnergy is exported until the device is collected
+
+	suppressed map[prometheus.Collector]bool // Series withheld because their value is NaN or infinite
+}
+
+// DebugCollectResult is the JSON response of the /debug/collect endpoint
+type DebugCollectResult struct {
+	Success     bool           `json:"success"`
+	DeviceCount int            `json:"device_count"`
+	TotalMs     float64        `json:"total_ms"`
+	Stages      []timing.Stage `json:"stages"`
+	Error       string         `json:"error,omitempty"`
+}
+
+// DebugDevicesResult is the JSON response of the /debug/devices endpoint
+type DebugDevicesResult struct {
+	// CollectionTime is the time of the collection whose snapshot is served,
+	// nil when the live device store is served
+	CollectionTime *time.Time `json:"collection_time,omitempty"`
+
+	DeviceCount int                `json:"device_count"`
+	Devices     []DebugDeviceState `json:"devices"`
+	Error       string             `json:"error,omitempty"`
 }
 
+// DebugDeviceState is the cached state of a device with its age at the time
+// of the request, and the values exported for it when served from a snapshot
+type DebugDeviceState struct {
+	collector.DeviceState
+	Metrics    *collector.DeviceCollectionInfo `json:"metrics,omitempty"`
+	AgeSeconds float64                         `json:"age_seconds"`
+}
+
+// Handling of NaN and infinite values reported by WinPower, e.g. after a
+// sensor fault
+const (
+	// InvalidValueSkip withholds the affected series until the value is valid
+	InvalidValueSkip = "skip"
+
+	// InvalidValueZero exports the affected series as 0
+	InvalidValueZero = "zero"
+)
+
+// InvalidValueModes lists the valid invalid value modes
+var InvalidValueModes = []string{InvalidValueSkip, InvalidValueZero}
+
+// metricPrefixPattern restricts device type prefixes to metric name characters
+var metricPrefixPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// labelNamePattern matches valid Prometheus label names
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
 // MetricsConfig holds configuration for the metrics service
 type MetricsConfig struct {
 	// Namespace is the Prometheus namespace for all metrics (default: "winpower")
-	Namespace string
+	Namespace string `yaml:"-" mapstructure:"-"`
 
 	// Subsystem is the Prometheus subsystem for exporter metrics (default: "exporter")
-	Subsystem string
+	Subsystem string `yaml:"-" mapstructure:"-"`
 
 	// WinPowerHost is the label value for winpower_host
-	WinPowerHost string
+	WinPowerHost string `yaml:"-" mapstructure:"-"`
 
 	// EnableMemoryMetrics enables memory usage monitoring
-	EnableMemoryMetrics bool
+	EnableMemoryMetrics bool `yaml:"enable_memory_metrics" mapstructure:"enable_memory_metrics"`
+
+	// EnableRuntimeMetrics exports winpower_exporter_goroutines and
+	// winpower_exporter_heap_bytes, refreshed every RuntimeMetricsInterval by
+	// StartRuntimeMetrics. Off by default so deployments that already run a
+	// process or node exporter do not get duplicate series.
+	EnableRuntimeMetrics bool `yaml:"enable_runtime_metrics" mapstructure:"enable_runtime_metrics"`
+
+	// RuntimeMetricsInterval is how often the runtime metrics are refreshed
+	RuntimeMetricsInterval time.Duration `yaml:"runtime_metrics_interval" mapstructure:"runtime_metrics_interval"`
+
+	// EnableDeviceUptime enables the per-device uptime metric
+	EnableDeviceUptime bool `yaml:"enable_device_uptime" mapstructure:"enable_device_uptime"`
+
+	// EnableReportedEnergy exports the cumulative energy reported by WinPower
+	// as winpower_device_reported_energy_wh, next to the integrated
+	// winpower_device_cumulative_energy, to cross-check the integration.
+	// Requires energy_total_wh in the WinPower field map; devices that do not
+	// report the value have no series.
+	EnableReportedEnergy bool `yaml:"enable_reported_energy" mapstructure:"enable_reported_energy"`
+
+	// EnableEnergyResetEpoch adds a reset_epoch label to
+	// winpower_device_cumulative_energy, counting the deliberate resets of
+	// the device's energy. A reset starts a new series, so queries can tell
+	// a reset apart from a rollback of the value.
+	EnableEnergyResetEpoch bool `yaml:"enable_energy_reset_epoch" mapstructure:"enable_energy_reset_epoch"`
+
+	// MaxConcurrentCollections limits how many /metrics requests may trigger a
+	// WinPower collection at the same time (0 = unlimited)
+	MaxConcurrentCollections int `yaml:"max_concurrent_collections" mapstructure:"max_concurrent_collections"`
+
+	// CollectionWaitTimeout is how long a request waits for a free collection
+	// slot before being served from the last cached result. Before any
+	// result exists the request keeps waiting for the collection in flight.
+	CollectionWaitTimeout time.Duration `yaml:"collection_wait_timeout" mapstructure:"collection_wait_timeout"`
+
+	// StaleMaxAge lets a /metrics request whose collection fails be served
+	// the metrics of the last successful collection, as long as that
+	// collection is at most this old, instead of failing the scrape. Older
+	// results fail the scrape as before (0 = always fail).
+	StaleMaxAge time.Duration `yaml:"stale_max_age" mapstructure:"stale_max_age"`
+
+	// MaxDevices caps the number of devices with exported series; when a new
+	// device exceeds the cap, the least recently updated device is evicted
+	// (0 = unlimited)
+	MaxDevices int `yaml:"max_devices" mapstructure:"max_devices"`
+
+	// MaxLabelValueLength truncates device label values (e.g. device names)
+	// to this many characters after control characters are replaced
+	// (0 = unlimited)
+	MaxLabelValueLength int `yaml:"max_label_value_length" mapstructure:"max_label_value_length"`
+
+	// DeviceTypePrefixes maps a device type (as reported in the device_type
+	// label, e.g. "1") to a metric name prefix. Devices of a mapped type get
+	// type-specific metric names such as winpower_ups_power_watts instead of
+	// winpower_power_watts. Unmapped types keep the default names (empty =
+	// label-based scheme only).
+	DeviceTypePrefixes map[string]string `yaml:"device_type_prefixes" mapstructure:"device_type_prefixes"`
+
+	// EnableDeviceTypeCode exports winpower_device_type_code, the device
+	// type as a stable integer code, for consumers that filter numerically
+	// rather than on the device_type label
+	EnableDeviceTypeCode bool `yaml:"enable_device_type_code" mapstructure:"enable_device_type_code"`
+
+	// DeviceTypeCodes maps a device type (as reported in the device_type
+	// label, e.g. "1") to the code exported by winpower_device_type_code
+	DeviceTypeCodes map[string]int `yaml:"device_type_codes" mapstructure:"device_type_codes"`
+
+	// DeviceTypeDefaultCode is the code exported for device types missing
+	// from DeviceTypeCodes
+	DeviceTypeDefaultCode int `yaml:"device_type_default_code" mapstructure:"device_type_default_code"`
+
+	// DeviceGroups maps a device ID to a group name, e.g. a rack or a
+	// customer, exported as the device_group label of every device metric.
+	// Device IDs are matched case-insensitively. Empty leaves device metrics
+	// without the label.
+	DeviceGroups map[string]string `yaml:"device_groups" mapstructure:"device_groups"`
+
+	// DeviceGroupDefault is the group of devices missing from DeviceGroups
+	DeviceGroupDefault string `yaml:"device_group_default" mapstructure:"device_group_default"`
+
+	// EnableAlarmTypes exports winpower_device_alarm_type_active, the number
+	// of active alarms of each alarm type, in addition to the total in
+	// winpower_device_active_alarms. Alarm types are reported by WinPower, so
+	// the number of series grows with the alarm types seen on a device.
+	EnableAlarmTypes bool `yaml:"enable_alarm_types" mapstructure:"enable_alarm_types"`
+
+	// BatchDeviceUpdates applies the device metric updates of a collection
+	// cycle in a single pass: the device series are published as a snapshot
+	// at the end of the cycle and scrapes read that snapshot through one
+	// collector, instead of every series being a registered gauge that
+	// scrapes read while it is being updated. Intended for large fleets.
+	BatchDeviceUpdates bool `yaml:"batch_device_updates" mapstructure:"batch_device_updates"`
+
+	// InvalidValueMode selects how measurements WinPower reports as NaN or
+	// infinite are exported: InvalidValueSkip withholds the affected series
+	// and InvalidValueZero exports them as 0. Either way they are counted in
+	// winpower_exporter_invalid_value_total.
+	InvalidValueMode string `yaml:"invalid_value_mode" mapstructure:"invalid_value_mode"`
+
+	// HelpOverrides replaces the HELP text of metrics, e.g. to localize it,
+	// keyed by the fully qualified label-based metric name (e.g.
+	// "winpower_power_watts"). Metrics not listed keep their default text.
+	HelpOverrides map[string]string `yaml:"help_overrides" mapstructure:"help_overrides"`
+
+	// BatteryRuntimeLowMinutes is the remaining battery runtime, in minutes,
+	// below which the battery_runtime_low metric is 1 (0 = never low)
+	BatteryRuntimeLowMinutes float64 `yaml:"battery_runtime_low_minutes" mapstructure:"battery_runtime_low_minutes"`
+
+	// PushgatewayURL enables push mode: after each scheduled collection the
+	// metrics are updated and the registry served on /metrics is pushed to
+	// this Pushgateway (e.g. "http://pushgateway:9091"). The scrape endpoint
+	// keeps working (empty = disabled).
+	PushgatewayURL string `yaml:"pushgateway_url" mapstructure:"pushgateway_url"`
+
+	// PushJob is the job label of the pushed metrics
+	PushJob string `yaml:"push_job" mapstructure:"push_job"`
+
+	// PushGrouping holds additional grouping key labels of the pushed
+	// metrics, e.g. {"instance": "site-a"}
+	PushGrouping map[string]string `yaml:"push_grouping" mapstructure:"push_grouping"`
+
+	// PushInterval is the minimum time between two pushes; collections
+	// within the interval of the last push are not pushed (0 = push after
+	// every collection)
+	PushInterval time.Duration `yaml:"push_interval" mapstructure:"push_interval"`
 }
 
 // DefaultMetricsConfig returns default configuration
 func DefaultMetricsConfig() *MetricsConfig {
 	return &MetricsConfig{
-		Namespace:           "winpower",
-		Subsystem:           "exporter",
-		WinPowerHost:        "localhost",
-		EnableMemoryMetrics: true,
+		Namespace:                "winpower",
+		Subsystem:                "exporter",
+		WinPowerHost:             "localhost",
+		EnableMemoryMetrics:      true,
+		EnableDeviceUptime:       false,
+		RuntimeMetricsInterval:   30 * time.Second,
+		MaxConcurrentCollections: 0,
+		CollectionWaitTimeout:    2 * time.Second,
+		MaxDevices:               1000,
+		MaxLabelValueLength:      128,
+		BatteryRuntimeLowMinutes: 10,
+		DeviceTypeDefaultCode:    -1,
+		DeviceGroupDefault:       "ungrouped",
+		InvalidValueMode:         InvalidValueSkip,
+		PushJob:                  "winpower_exporter",
+		PushInterval:             30 * time.Second,
+	}
+}
+
+// Validate validates the metrics configuration
+func (c *MetricsConfig) Validate() error {
+	if c.MaxConcurrentCollections < 0 {
+		return fmt.Errorf("max_concurrent_collections must be >= 0, got %d", c.MaxConcurrentCollections)
+	}
+	if c.MaxDevices < 0 {
+		return fmt.Errorf("max_devices must be >= 0, got %d", c.MaxDevices)
+	}
+	if c.MaxLabelValueLength < 0 {
+		return fmt.Errorf("max_label_value_length must be >= 0, got %d", c.MaxLabelValueLength)
+	}
+	for deviceType, prefix := range c.DeviceTypePrefixes {
+		if !metricPrefixPattern.MatchString(prefix) {
+			return fmt.Errorf("device_type_prefixes[%s] must match %s, got %q",
+				deviceType, metricPrefixPattern, prefix)
+		}
+	}
+	if len(c.DeviceGroups) > 0 && c.DeviceGroupDefault == "" {
+		return fmt.Errorf("device_group_default must not be empty when device_groups is set")
+	}
+	if c.EnableRuntimeMetrics && c.RuntimeMetricsInterval <= 0 {
+		return fmt.Errorf("runtime_metrics_interval must be > 0 when runtime metrics are enabled, got %v", c.RuntimeMetricsInterval)
+	}
+	if c.CollectionWaitTimeout < 0 {
+		return fmt.Errorf("collection_wait_timeout must be >= 0, got %v", c.CollectionWaitTimeout)
+	}
+	if c.StaleMaxAge < 0 {
+		return fmt.Errorf("stale_max_age must be >= 0, got %v", c.StaleMaxAge)
+	}
+	if err := validateHelpOverrides(c.HelpOverrides); err != nil {
+		return err
+	}
+	if !slices.Contains(InvalidValueModes, c.InvalidValueMode) {
+		return fmt.Errorf("invalid_value_mode must be one of %s, got %q", strings.Join(InvalidValueModes, ", "), c.InvalidValueMode)
+	}
+	if c.BatteryRuntimeLowMinutes < 0 {
+		return fmt.Errorf("battery_runtime_low_minutes must be >= 0, got %v", c.BatteryRuntimeLowMinutes)
+	}
+	return c.validatePush()
+}
+
+// validatePush validates the push mode settings
+func (c *MetricsConfig) validatePush() error {
+	if c.PushgatewayURL == "" {
+		return nil
+	}
+	u, err := url.Parse(c.PushgatewayURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("pushgateway_url must be an http or https URL, got %q", c.PushgatewayURL)
+	}
+	if c.PushJob == "" {
+		return fmt.Errorf("push_job must not be empty when pushgateway_url is set")
+	}
+	if c.PushInterval < 0 {
+		return fmt.Errorf("push_interval must be >= 0, got %v", c.PushInterval)
+	}
+	for name := range c.PushGrouping {
+		if name == "job" || !labelNamePattern.MatchString(name) {
+			return fmt.Errorf("push_grouping[%s] must be a valid label name other than job", name)
+		}
 	}
+	return nil
 }
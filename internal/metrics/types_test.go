@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -29,6 +30,57 @@ func TestMetricsConfig(t *testing.T) {
 		assert.Equal(t, "test-host", config.WinPowerHost)
 		assert.False(t, config.EnableMemoryMetrics)
 	})
+
+	t.Run("device type prefixes are validated", func(t *testing.T) {
+		config := DefaultMetricsConfig()
+		config.DeviceTypePrefixes = map[string]string{"1": "ups", "2": "pdu_2"}
+		assert.NoError(t, config.Validate())
+
+		config.DeviceTypePrefixes = map[string]string{"1": "UPS-1"}
+		assert.Error(t, config.Validate())
+	})
+
+	t.Run("device group default is required with device groups", func(t *testing.T) {
+		config := DefaultMetricsConfig()
+		config.DeviceGroupDefault = ""
+		assert.NoError(t, config.Validate())
+
+		config.DeviceGroups = map[string]string{"ups-1": "rack-a"}
+		assert.Error(t, config.Validate())
+
+		config.DeviceGroupDefault = "ungrouped"
+		assert.NoError(t, config.Validate())
+	})
+
+	t.Run("runtime metrics interval is validated when enabled", func(t *testing.T) {
+		config := DefaultMetricsConfig()
+		assert.False(t, config.EnableRuntimeMetrics)
+		config.RuntimeMetricsInterval = 0
+		assert.NoError(t, config.Validate())
+
+		config.EnableRuntimeMetrics = true
+		assert.Error(t, config.Validate())
+	})
+
+	t.Run("invalid value mode is validated", func(t *testing.T) {
+		config := DefaultMetricsConfig()
+		assert.Equal(t, InvalidValueSkip, config.InvalidValueMode)
+		config.InvalidValueMode = InvalidValueZero
+		assert.NoError(t, config.Validate())
+
+		config.InvalidValueMode = "drop"
+		assert.Error(t, config.Validate())
+	})
+
+	t.Run("stale max age is validated", func(t *testing.T) {
+		config := DefaultMetricsConfig()
+		assert.Zero(t, config.StaleMaxAge)
+		config.StaleMaxAge = time.Minute
+		assert.NoError(t, config.Validate())
+
+		config.StaleMaxAge = -time.Second
+		assert.Error(t, config.Validate())
+	})
 }
 
 func TestMetricsServiceStructure(t *testing.T) {
@@ -2,9 +2,17 @@ package log
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 )
 
+// 可选的日志级别、输出格式与输出目标
+var (
+	Levels  = []string{"debug", "info", "warn", "error", "fatal"}
+	Formats = []string{"json", "console"}
+	Outputs = []string{"stdout", "stderr", "file", "both"}
+)
+
 // Config 日志配置结构
 type Config struct {
 	// Level 日志级别: debug, info, warn, error, fatal
@@ -39,6 +47,32 @@ type Config struct {
 
 	// EnableStacktrace 是否记录堆栈跟踪
 	EnableStacktrace bool `json:"enable_stacktrace" yaml:"enable_stacktrace" mapstructure:"enable_stacktrace"`
+
+	// Sampling 日志采样配置，用于限制高频日志的输出量
+	Sampling SamplingConfig `json:"sampling" yaml:"sampling" mapstructure:"sampling"`
+}
+
+// SamplingConfig 日志采样配置（对应 zap 的 SamplerConfig）
+// 每秒内相同级别、相同消息的日志先输出前 Initial 条，之后每 Thereafter 条输出 1 条；
+// error 及以上级别的日志不采样，始终输出
+type SamplingConfig struct {
+	// Enabled 是否启用采样，默认关闭
+	Enabled bool `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+
+	// Initial 每秒内每条消息完整输出的条数
+	Initial int `json:"initial" yaml:"initial" mapstructure:"initial"`
+
+	// Thereafter 超出 Initial 后每多少条输出 1 条，0 表示丢弃超出的日志
+	Thereafter int `json:"thereafter" yaml:"thereafter" mapstructure:"thereafter"`
+}
+
+// DefaultSamplingConfig 返回默认的采样配置（关闭，启用后与 zap 生产配置一致）
+func DefaultSamplingConfig() SamplingConfig {
+	return SamplingConfig{
+		Enabled:    false,
+		Initial:    100,
+		Thereafter: 100,
+	}
 }
 
 // DefaultConfig 返回生产环境的默认配置
@@ -55,6 +89,7 @@ func DefaultConfig() *Config {
 		Development:      false,
 		EnableCaller:     false,
 		EnableStacktrace: false, // 仅 error 级别及以上启用
+		Sampling:         DefaultSamplingConfig(),
 	}
 }
 
@@ -72,46 +107,30 @@ func DevelopmentDefaults() *Config {
 		Development:      true,
 		EnableCaller:     true,
 		EnableStacktrace: true, // error 和 fatal 级别启用
+		Sampling:         DefaultSamplingConfig(),
 	}
 }
 
 // Validate 验证配置的有效性
 func (c *Config) Validate() error {
 	// 验证日志级别
-	validLevels := map[string]bool{
-		"debug": true,
-		"info":  true,
-		"warn":  true,
-		"error": true,
-		"fatal": true,
-	}
 	level := strings.ToLower(c.Level)
-	if !validLevels[level] {
-		return fmt.Errorf("invalid log level: %s (must be one of: debug, info, warn, error, fatal)", c.Level)
+	if !slices.Contains(Levels, level) {
+		return fmt.Errorf("invalid log level: %s (must be one of: %s)", c.Level, strings.Join(Levels, ", "))
 	}
 	c.Level = level // 标准化为小写
 
 	// 验证输出格式
-	validFormats := map[string]bool{
-		"json":    true,
-		"console": true,
-	}
 	format := strings.ToLower(c.Format)
-	if !validFormats[format] {
-		return fmt.Errorf("invalid log format: %s (must be one of: json, console)", c.Format)
+	if !slices.Contains(Formats, format) {
+		return fmt.Errorf("invalid log format: %s (must be one of: %s)", c.Format, strings.Join(Formats, ", "))
 	}
 	c.Format = format // 标准化为小写
 
 	// 验证输出目标
-	validOutputs := map[string]bool{
-		"stdout": true,
-		"stderr": true,
-		"file":   true,
-		"both":   true,
-	}
 	output := strings.ToLower(c.Output)
-	if !validOutputs[output] {
-		return fmt.Errorf("invalid log output: %s (must be one of: stdout, stderr, file, both)", c.Output)
+	if !slices.Contains(Outputs, output) {
+		return fmt.Errorf("invalid log output: %s (must be one of: %s)", c.Output, strings.Join(Outputs, ", "))
 	}
 	c.Output = output // 标准化为小写
 
@@ -131,5 +150,15 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("max_backups must be non-negative")
 	}
 
+	// 验证采样配置
+	if c.Sampling.Enabled {
+		if c.Sampling.Initial < 1 {
+			return fmt.Errorf("sampling.initial must be at least 1 when sampling is enabled")
+		}
+		if c.Sampling.Thereafter < 0 {
+			return fmt.Errorf("sampling.thereafter must be non-negative")
+		}
+	}
+
 	return nil
 }
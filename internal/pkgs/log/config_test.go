@@ -196,6 +196,38 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantError: false,
 		},
+		{
+			name: "invalid sampling initial",
+			config: &Config{
+				Level:    "info",
+				Format:   "json",
+				Output:   "stdout",
+				Sampling: SamplingConfig{Enabled: true, Initial: 0, Thereafter: 100},
+			},
+			wantError: true,
+			errorMsg:  "sampling.initial",
+		},
+		{
+			name: "invalid sampling thereafter",
+			config: &Config{
+				Level:    "info",
+				Format:   "json",
+				Output:   "stdout",
+				Sampling: SamplingConfig{Enabled: true, Initial: 100, Thereafter: -1},
+			},
+			wantError: true,
+			errorMsg:  "sampling.thereafter",
+		},
+		{
+			name: "disabled sampling is not validated",
+			config: &Config{
+				Level:    "info",
+				Format:   "json",
+				Output:   "stdout",
+				Sampling: SamplingConfig{Initial: 0, Thereafter: -1},
+			},
+			wantError: false,
+		},
 		{
 			name: "case insensitive format",
 			config: &Config{
@@ -3,6 +3,7 @@ package log
 import (
 	"context"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -41,13 +42,37 @@ type Logger interface {
 	Core() zapcore.Core
 }
 
+// LevelController 支持运行时动态调整日志级别的日志器
+// 由 NewLogger 创建的日志器实现此接口，子日志器与父日志器共享同一级别
+type LevelController interface {
+	// Level 返回当前日志级别
+	Level() zapcore.Level
+
+	// SetLevel 设置日志级别，立即生效
+	SetLevel(level zapcore.Level)
+}
+
+// Reopener 支持重新打开日志文件的日志器，用于配合 logrotate 等外部日志轮转
+// 由 NewLogger 创建的日志器实现此接口，子日志器与父日志器共享同一日志文件
+type Reopener interface {
+	// Reopen 关闭并按配置的路径重新打开日志文件
+	// 仅在 output 为 file 或 both 时生效，输出到 stdout/stderr 时为空操作
+	Reopen() error
+}
+
 // zapLogger 是基于 zap 的 Logger 实现
 type zapLogger struct {
 	logger *zap.Logger
+	level  zap.AtomicLevel
+	writer *WriterCloser // 日志输出，nil 表示不支持重新打开
 }
 
-// 确保 zapLogger 实现了 Logger 接口
-var _ Logger = (*zapLogger)(nil)
+// 确保 zapLogger 实现了 Logger、LevelController 和 Reopener 接口
+var (
+	_ Logger          = (*zapLogger)(nil)
+	_ LevelController = (*zapLogger)(nil)
+	_ Reopener        = (*zapLogger)(nil)
+)
 
 // Debug 实现 Logger.Debug
 func (l *zapLogger) Debug(msg string, fields ...Field) {
@@ -78,6 +103,8 @@ func (l *zapLogger) Fatal(msg string, fields ...Field) {
 func (l *zapLogger) With(fields ...Field) Logger {
 	return &zapLogger{
 		logger: l.logger.With(fields...),
+		level:  l.level,
+		writer: l.writer,
 	}
 }
 
@@ -100,6 +127,24 @@ func (l *zapLogger) Core() zapcore.Core {
 	return l.logger.Core()
 }
 
+// Level 实现 LevelController.Level
+func (l *zapLogger) Level() zapcore.Level {
+	return l.level.Level()
+}
+
+// SetLevel 实现 LevelController.SetLevel
+func (l *zapLogger) SetLevel(level zapcore.Level) {
+	l.level.SetLevel(level)
+}
+
+// Reopen 实现 Reopener.Reopen
+func (l *zapLogger) Reopen() error {
+	if l.writer == nil {
+		return nil
+	}
+	return l.writer.Reopen()
+}
+
 // ZapLogger 返回底层的 zap.Logger（用于需要 *zap.Logger 的场景）
 func (l *zapLogger) ZapLogger() *zap.Logger {
 	return l.logger
@@ -182,8 +227,8 @@ func NewLogger(config *Config) (Logger, error) {
 	}
 
 	// 构建 core
-	level := parseLevel(config.Level)
-	core := zapcore.NewCore(encoder, writerCloser, level)
+	level := zap.NewAtomicLevelAt(parseLevel(config.Level))
+	core := buildCore(encoder, writerCloser, level, config.Sampling)
 
 	// 构建选项
 	opts := buildOptions(config)
@@ -191,7 +236,30 @@ func NewLogger(config *Config) (Logger, error) {
 	// 创建 zap logger
 	zapLog := zap.New(core, opts...)
 
-	return &zapLogger{logger: zapLog}, nil
+	return &zapLogger{logger: zapLog, level: level, writer: writerCloser}, nil
+}
+
+// buildCore 构建 zap core，启用采样时 error 以下级别的日志经过采样，
+// error 及以上级别的日志直接输出
+func buildCore(encoder zapcore.Encoder, writer zapcore.WriteSyncer, level zap.AtomicLevel, sampling SamplingConfig) zapcore.Core {
+	if !sampling.Enabled {
+		return zapcore.NewCore(encoder, writer, level)
+	}
+
+	belowError := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return l < zapcore.ErrorLevel && level.Enabled(l)
+	})
+	atLeastError := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return l >= zapcore.ErrorLevel && level.Enabled(l)
+	})
+
+	sampled := zapcore.NewSamplerWithOptions(
+		zapcore.NewCore(encoder, writer, belowError),
+		time.Second,
+		sampling.Initial,
+		sampling.Thereafter,
+	)
+	return zapcore.NewTee(sampled, zapcore.NewCore(encoder, writer, atLeastError))
 }
 
 // buildOptions 构建 zap 选项
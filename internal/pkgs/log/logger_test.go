@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"go.uber.org/zap/zapcore"
@@ -56,6 +58,117 @@ func TestNewLogger(t *testing.T) {
 	}
 }
 
+func TestLoggerSetLevel(t *testing.T) {
+	logger, err := NewLogger(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lc, ok := logger.(LevelController)
+	if !ok {
+		t.Fatal("Expected logger to implement LevelController")
+	}
+	if lc.Level() != zapcore.InfoLevel {
+		t.Errorf("Expected info level, got %v", lc.Level())
+	}
+
+	child := logger.With(String("component", "test"))
+	lc.SetLevel(zapcore.DebugLevel)
+
+	if !child.Core().Enabled(zapcore.DebugLevel) {
+		t.Error("Expected child logger to follow parent level change")
+	}
+}
+
+func TestLoggerReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exporter.log")
+	config := DefaultConfig()
+	config.Output = "file"
+	config.FilePath = path
+
+	logger, err := NewLogger(config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	child := logger.With(String("component", "test"))
+	child.Info("before rotation")
+
+	// Simulate logrotate renaming the file
+	rotated := path + ".1"
+	if err := os.Rename(path, rotated); err != nil {
+		t.Fatalf("Failed to rotate log file: %v", err)
+	}
+
+	r, ok := logger.(Reopener)
+	if !ok {
+		t.Fatal("Expected logger to implement Reopener")
+	}
+	if err := r.Reopen(); err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	child.Info("after rotation")
+
+	old, _ := os.ReadFile(rotated)
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected log file to be recreated: %v", err)
+	}
+	if !strings.Contains(string(old), "before rotation") || strings.Contains(string(old), "after rotation") {
+		t.Errorf("Unexpected rotated file content: %s", old)
+	}
+	if !strings.Contains(string(current), "after rotation") {
+		t.Errorf("Expected new entries in the reopened file, got: %s", current)
+	}
+
+	// Reopening a stdout logger is a no-op
+	stdout, err := NewLogger(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := stdout.(Reopener).Reopen(); err != nil {
+		t.Errorf("Expected no error for stdout logger, got %v", err)
+	}
+}
+
+func TestLoggerSampling(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exporter.log")
+	config := DefaultConfig()
+	config.Level = "debug"
+	config.Output = "file"
+	config.FilePath = path
+	config.Sampling = SamplingConfig{Enabled: true, Initial: 2, Thereafter: 0}
+
+	logger, err := NewLogger(config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		logger.Debug("high frequency")
+		logger.Error("always emitted")
+	}
+	_ = logger.Sync()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if got := strings.Count(string(content), "high frequency"); got != 2 {
+		t.Errorf("Expected 2 sampled debug entries, got %d", got)
+	}
+	if got := strings.Count(string(content), "always emitted"); got != 10 {
+		t.Errorf("Expected all 10 error entries, got %d", got)
+	}
+
+	// Level changes still apply to sampled entries
+	logger.(LevelController).SetLevel(zapcore.InfoLevel)
+	logger.Debug("filtered by level")
+	_ = logger.Sync()
+	content, _ = os.ReadFile(path)
+	if strings.Contains(string(content), "filtered by level") {
+		t.Error("Expected debug entry to be filtered after raising the level")
+	}
+}
+
 func TestLoggerInterface(t *testing.T) {
 	// 测试日志器是否实现了接口
 	config := DefaultConfig()
@@ -42,6 +42,7 @@ func NewTestLogger() *TestLogger {
 func NewNoopLogger() Logger {
 	return &zapLogger{
 		logger: zap.NewNop(),
+		level:  zap.NewAtomicLevel(),
 	}
 }
 
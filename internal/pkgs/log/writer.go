@@ -25,6 +25,14 @@ func (w *WriterCloser) Close() error {
 	return lastErr
 }
 
+// Reopen 关闭日志文件，下次写入时按配置的路径重新打开
+// 用于外部日志轮转（如 logrotate 重命名日志文件）后切换到新文件；
+// 未输出到文件（stdout、stderr）时为空操作
+func (w *WriterCloser) Reopen() error {
+	// closers 只包含文件写入器，lumberjack 在关闭后的首次写入时重新打开文件
+	return w.Close()
+}
+
 // BuildWriter 根据配置构建写入器
 func BuildWriter(config *Config) (*WriterCloser, error) {
 	if err := config.Validate(); err != nil {
@@ -0,0 +1,85 @@
+// Package shutdown 按顺序关闭各模块，并为每个模块单独限定关闭期限。
+//
+// 某个模块卡住时（如存储刷盘卡在挂起的 NFS 挂载上），超过其自身期限后放弃等待并记录日志，
+// 其余模块仍按各自的期限继续关闭，不会因一个模块耗尽全部关闭时间。
+// 被放弃的模块在后台继续执行，随进程退出而终止。
+package shutdown
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 可单独配置关闭期限的模块，按关闭顺序排列
+const (
+	ModuleScheduler = "scheduler"
+	ModuleServer    = "server"
+	ModuleWinPower  = "winpower"
+	ModuleStorage   = "storage"
+)
+
+// Modules 可单独配置关闭期限的模块名称
+var Modules = []string{ModuleScheduler, ModuleServer, ModuleWinPower, ModuleStorage}
+
+// Config 关闭配置
+type Config struct {
+	// ModuleTimeout 未在 ModuleTimeouts 中配置的模块的关闭期限
+	ModuleTimeout time.Duration `json:"module_timeout" yaml:"module_timeout" mapstructure:"module_timeout"`
+
+	// ModuleTimeouts 按模块名称覆盖关闭期限，如 {"storage": "30s"}
+	ModuleTimeouts map[string]time.Duration `json:"module_timeouts" yaml:"module_timeouts" mapstructure:"module_timeouts"`
+}
+
+// DefaultModuleTimeouts 返回默认的模块关闭期限：
+// HTTP 服务器需要留出 server.shutdown_timeout（默认 30s）等待请求完成，其余模块使用 ModuleTimeout
+func DefaultModuleTimeouts() map[string]time.Duration {
+	return map[string]time.Duration{
+		ModuleServer: 35 * time.Second,
+	}
+}
+
+// DefaultConfig 返回默认配置
+func DefaultConfig() *Config {
+	return &Config{
+		ModuleTimeout:  10 * time.Second,
+		ModuleTimeouts: DefaultModuleTimeouts(),
+	}
+}
+
+// Validate 校验关闭期限为正数且模块名称有效
+func (c *Config) Validate() error {
+	if c == nil {
+		return fmt.Errorf("config cannot be nil")
+	}
+
+	if c.ModuleTimeout <= 0 {
+		return fmt.Errorf("module_timeout must be positive, got: %v", c.ModuleTimeout)
+	}
+
+	modules := make([]string, 0, len(c.ModuleTimeouts))
+	for module := range c.ModuleTimeouts {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+	for _, module := range modules {
+		if !slices.Contains(Modules, module) {
+			return fmt.Errorf("invalid module %q in module_timeouts, must be one of: %s",
+				module, strings.Join(Modules, ", "))
+		}
+		if timeout := c.ModuleTimeouts[module]; timeout <= 0 {
+			return fmt.Errorf("module_timeouts.%s must be positive, got: %v", module, timeout)
+		}
+	}
+	return nil
+}
+
+// Timeout 返回模块的关闭期限
+func (c *Config) Timeout(module string) time.Duration {
+	if timeout, ok := c.ModuleTimeouts[module]; ok {
+		return timeout
+	}
+	return c.ModuleTimeout
+}
@@ -0,0 +1,66 @@
+package shutdown
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeout  time.Duration
+		timeouts map[string]time.Duration
+		wantErr  string
+	}{
+		{name: "defaults", timeout: 10 * time.Second, timeouts: DefaultModuleTimeouts()},
+		{name: "no overrides", timeout: time.Second},
+		{
+			name:    "non-positive module timeout",
+			timeout: 0,
+			wantErr: "module_timeout must be positive",
+		},
+		{
+			name:     "unknown module",
+			timeout:  time.Second,
+			timeouts: map[string]time.Duration{"collector": time.Second},
+			wantErr:  "invalid module",
+		},
+		{
+			name:     "non-positive override",
+			timeout:  time.Second,
+			timeouts: map[string]time.Duration{ModuleStorage: 0},
+			wantErr:  "module_timeouts.storage must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Config{ModuleTimeout: tt.timeout, ModuleTimeouts: tt.timeouts}).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestConfig_Timeout(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ModuleTimeouts[ModuleStorage] = 30 * time.Second
+
+	if got := cfg.Timeout(ModuleStorage); got != 30*time.Second {
+		t.Errorf("storage timeout = %v, want 30s", got)
+	}
+	if got := cfg.Timeout(ModuleServer); got != 35*time.Second {
+		t.Errorf("server timeout = %v, want 35s", got)
+	}
+	if got := cfg.Timeout(ModuleWinPower); got != cfg.ModuleTimeout {
+		t.Errorf("winpower timeout = %v, want module_timeout %v", got, cfg.ModuleTimeout)
+	}
+}
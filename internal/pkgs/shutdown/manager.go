@@ -0,0 +1,95 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+// ErrTimeout 模块未在关闭期限内完成关闭，已放弃等待
+var ErrTimeout = errors.New("module shutdown timed out")
+
+// Step 一个待关闭的模块
+type Step struct {
+	// Module 模块名称，用于查找关闭期限以及记录日志和指标
+	Module string
+
+	// Stop 关闭模块；ctx 在模块的关闭期限到达时取消
+	Stop func(ctx context.Context) error
+}
+
+// TimeoutRecorder 记录关闭超时的模块，由指标模块实现
+type TimeoutRecorder interface {
+	RecordShutdownTimeout(module string)
+}
+
+// Manager 按顺序关闭各模块，每个模块使用各自的关闭期限
+type Manager struct {
+	config   *Config
+	logger   log.Logger
+	recorder TimeoutRecorder
+}
+
+// NewManager 创建关闭管理器，config 为 nil 时使用默认配置
+func NewManager(config *Config, logger log.Logger) *Manager {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &Manager{config: config, logger: logger}
+}
+
+// SetTimeoutRecorder 设置关闭超时的记录器，nil 时不记录
+func (m *Manager) SetTimeoutRecorder(recorder TimeoutRecorder) {
+	m.recorder = recorder
+}
+
+// Run 按顺序关闭各模块并返回关闭失败的错误，超时的模块返回包装 ErrTimeout 的错误。
+// 每个模块的期限从其开始关闭时计算，与调用方的 context 无关：
+// 收到退出信号后调用方的 context 通常已取消，而各模块仍需要时间完成关闭。
+func (m *Manager) Run(steps []Step) []error {
+	var errs []error
+	for _, step := range steps {
+		if err := m.stop(step); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// stop 在模块的关闭期限内关闭模块，超时后放弃等待
+func (m *Manager) stop(step Step) error {
+	timeout := m.config.Timeout(step.Module)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- step.Stop(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			m.logger.Error("模块关闭失败",
+				log.String("module", step.Module),
+				log.Err(err))
+			return err
+		}
+		m.logger.Debug("模块已关闭",
+			log.String("module", step.Module),
+			log.Duration("elapsed", time.Since(start)))
+		return nil
+	case <-ctx.Done():
+		m.logger.Error("模块关闭超时，放弃等待并继续关闭其余模块",
+			log.String("module", step.Module),
+			log.Duration("timeout", timeout))
+		if m.recorder != nil {
+			m.recorder.RecordShutdownTimeout(step.Module)
+		}
+		return fmt.Errorf("%s: %w after %v", step.Module, ErrTimeout, timeout)
+	}
+}
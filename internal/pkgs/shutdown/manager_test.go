@@ -0,0 +1,95 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+// timeoutRecorder 记录超时模块的测试实现
+type timeoutRecorder struct {
+	modules []string
+}
+
+func (r *timeoutRecorder) RecordShutdownTimeout(module string) {
+	r.modules = append(r.modules, module)
+}
+
+func TestManager_Run(t *testing.T) {
+	cfg := &Config{
+		ModuleTimeout:  time.Second,
+		ModuleTimeouts: map[string]time.Duration{ModuleStorage: 20 * time.Millisecond},
+	}
+	manager := NewManager(cfg, log.NewTestLogger())
+	recorder := &timeoutRecorder{}
+	manager.SetTimeoutRecorder(recorder)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	var order []string
+	stopErr := errors.New("close failed")
+	steps := []Step{
+		{Module: ModuleScheduler, Stop: func(ctx context.Context) error {
+			order = append(order, ModuleScheduler)
+			return nil
+		}},
+		{Module: ModuleStorage, Stop: func(ctx context.Context) error {
+			// 卡住且不响应 ctx 的模块
+			<-release
+			return nil
+		}},
+		{Module: ModuleWinPower, Stop: func(ctx context.Context) error {
+			order = append(order, ModuleWinPower)
+			return stopErr
+		}},
+	}
+
+	start := time.Now()
+	errs := manager.Run(steps)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Run took %v, the stuck module should be abandoned after its own deadline", elapsed)
+	}
+
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+	if !errors.Is(errs[0], ErrTimeout) {
+		t.Errorf("expected timeout error for storage, got %v", errs[0])
+	}
+	if !errors.Is(errs[1], stopErr) {
+		t.Errorf("expected stop error for winpower, got %v", errs[1])
+	}
+
+	// 被放弃的模块不影响后续模块关闭
+	if len(order) != 2 || order[1] != ModuleWinPower {
+		t.Errorf("unexpected stop order: %v", order)
+	}
+	if len(recorder.modules) != 1 || recorder.modules[0] != ModuleStorage {
+		t.Errorf("expected storage timeout to be recorded, got %v", recorder.modules)
+	}
+}
+
+func TestManager_RunContextDeadline(t *testing.T) {
+	manager := NewManager(&Config{ModuleTimeout: 50 * time.Millisecond}, log.NewTestLogger())
+
+	var remaining time.Duration
+	errs := manager.Run([]Step{{Module: ModuleServer, Stop: func(ctx context.Context) error {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			t.Error("expected the stop context to carry the module deadline")
+		}
+		remaining = time.Until(deadline)
+		return nil
+	}}})
+
+	if len(errs) != 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+	if remaining <= 0 || remaining > 50*time.Millisecond {
+		t.Errorf("unexpected remaining time %v", remaining)
+	}
+}
@@ -0,0 +1,121 @@
+// Package signals 按配置将进程信号映射为动作（关闭、重新加载配置、重新打开日志文件、
+// 切换日志级别或忽略），取代固定的信号处理逻辑。
+//
+// 信号名称不区分大小写，可省略 SIG 前缀，如 "SIGTERM"、"sigterm"、"term" 等价。
+// 当前平台不支持的信号（如 Windows 上的 SIGHUP）会被跳过。
+package signals
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// 信号可映射的动作
+const (
+	// ActionShutdown 优雅关闭
+	ActionShutdown = "shutdown"
+	// ActionReload 重新加载配置文件
+	ActionReload = "reload"
+	// ActionReopenLogfile 重新打开日志文件，配合 logrotate 等外部日志轮转使用
+	ActionReopenLogfile = "reopen-logfile"
+	// ActionToggleLogLevel 在 info 与 debug 日志级别之间切换
+	ActionToggleLogLevel = "toggle-log-level"
+	// ActionIgnore 忽略该信号（包括其默认行为，如 SIGINT 终止进程）
+	ActionIgnore = "ignore"
+)
+
+// Actions 可选的信号动作
+var Actions = []string{ActionShutdown, ActionReload, ActionReopenLogfile, ActionToggleLogLevel, ActionIgnore}
+
+// Names 可配置的信号名称（不含 SIG 前缀，小写）
+var Names = []string{"hup", "int", "quit", "term", "usr1", "usr2"}
+
+// Config 信号处理配置
+type Config struct {
+	// Actions 信号名称到动作的映射，如 {"sigterm": "shutdown", "sigint": "ignore"}
+	// 未配置的信号保持 Go 运行时的默认行为
+	Actions map[string]string `json:"actions" yaml:"actions" mapstructure:"actions"`
+}
+
+// DefaultActions 返回默认的信号动作映射：
+// SIGINT/SIGTERM 优雅关闭，SIGHUP 重新加载配置，SIGUSR1 重新打开日志文件，
+// SIGUSR2 切换日志级别
+func DefaultActions() map[string]string {
+	return map[string]string{
+		"sigint":  ActionShutdown,
+		"sigterm": ActionShutdown,
+		"sighup":  ActionReload,
+		"sigusr1": ActionReopenLogfile,
+		"sigusr2": ActionToggleLogLevel,
+	}
+}
+
+// DefaultConfig 返回默认配置
+func DefaultConfig() *Config {
+	return &Config{Actions: DefaultActions()}
+}
+
+// Validate 校验信号名称与动作，且至少有一个信号触发关闭
+func (c *Config) Validate() error {
+	if c == nil {
+		return fmt.Errorf("config cannot be nil")
+	}
+
+	shutdown := false
+	seen := make(map[string]string, len(c.Actions))
+	for _, name := range sortedKeys(c.Actions) {
+		action := c.Actions[name]
+		canonical, err := normalizeName(name)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(Actions, action) {
+			return fmt.Errorf("invalid action %q for signal %s, must be one of: %s",
+				action, name, strings.Join(Actions, ", "))
+		}
+		if previous, ok := seen[canonical]; ok {
+			return fmt.Errorf("signal %s is configured more than once (%s, %s)", canonical, previous, name)
+		}
+		seen[canonical] = name
+		if action == ActionShutdown {
+			shutdown = true
+		}
+	}
+
+	// 没有任何关闭信号时只能通过 SIGKILL 停止进程
+	if !shutdown {
+		return fmt.Errorf("at least one signal must map to %q", ActionShutdown)
+	}
+	return nil
+}
+
+// Clone 返回配置的深拷贝
+func (c *Config) Clone() *Config {
+	if c == nil {
+		return nil
+	}
+	return &Config{Actions: maps.Clone(c.Actions)}
+}
+
+// normalizeName 将信号名称规范化为 "sigxxx" 形式，未知名称返回错误
+func normalizeName(name string) (string, error) {
+	short := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(name)), "sig")
+	if !slices.Contains(Names, short) {
+		return "", fmt.Errorf("unknown signal %q, must be one of: SIG%s",
+			name, strings.ToUpper(strings.Join(Names, ", SIG")))
+	}
+	return "sig" + short, nil
+}
+
+// sortedKeys 返回按名称排序的键，使校验与日志输出顺序稳定
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,65 @@
+package signals
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		actions map[string]string
+		wantErr string
+	}{
+		{name: "defaults", actions: DefaultActions()},
+		{
+			name:    "names are case-insensitive and the prefix is optional",
+			actions: map[string]string{"TERM": ActionShutdown, "SigInt": ActionIgnore, "usr1": ActionReopenLogfile},
+		},
+		{
+			name:    "unknown signal",
+			actions: map[string]string{"sigterm": ActionShutdown, "sigkill": ActionIgnore},
+			wantErr: "unknown signal",
+		},
+		{
+			name:    "unknown action",
+			actions: map[string]string{"sigterm": "restart"},
+			wantErr: "invalid action",
+		},
+		{
+			name:    "duplicate signal",
+			actions: map[string]string{"sigterm": ActionShutdown, "term": ActionIgnore},
+			wantErr: "more than once",
+		},
+		{
+			name:    "no shutdown signal",
+			actions: map[string]string{"sigterm": ActionIgnore, "sigint": ActionIgnore},
+			wantErr: "at least one signal",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Config{Actions: tt.actions}).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestConfig_Clone(t *testing.T) {
+	cfg := DefaultConfig()
+	clone := cfg.Clone()
+	clone.Actions["sigint"] = ActionIgnore
+
+	if cfg.Actions["sigint"] != ActionShutdown {
+		t.Errorf("modifying the clone changed the original: %v", cfg.Actions)
+	}
+}
@@ -0,0 +1,134 @@
+package signals
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+// Handlers 各动作的处理函数，参数为收到的信号
+// 未设置处理函数的动作在收到信号时记录警告后忽略
+type Handlers struct {
+	Shutdown       func(os.Signal)
+	Reload         func(os.Signal)
+	ReopenLogfile  func(os.Signal)
+	ToggleLogLevel func(os.Signal)
+}
+
+// Manager 按配置监听信号并分发到对应的处理函数
+type Manager struct {
+	logger   log.Logger
+	handlers Handlers
+
+	actions map[os.Signal]string // 需要处理的信号及其动作
+	ignored []os.Signal          // 配置为 ignore 的信号
+
+	sigChan  chan os.Signal
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewManager 根据配置创建信号管理器，当前平台不支持的信号会被跳过
+func NewManager(cfg *Config, logger log.Logger, handlers Handlers) (*Manager, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid signal config: %w", err)
+	}
+
+	m := &Manager{
+		logger:   logger,
+		handlers: handlers,
+		actions:  make(map[os.Signal]string),
+		sigChan:  make(chan os.Signal, 1),
+		done:     make(chan struct{}),
+	}
+
+	for _, name := range sortedKeys(cfg.Actions) {
+		action := cfg.Actions[name]
+		canonical, _ := normalizeName(name)
+		sig, ok := platformSignals[canonical]
+		if !ok {
+			logger.Debug("当前平台不支持该信号，已跳过",
+				log.String("signal", canonical),
+				log.String("action", action))
+			continue
+		}
+		if action == ActionIgnore {
+			m.ignored = append(m.ignored, sig)
+			continue
+		}
+		m.actions[sig] = action
+	}
+
+	return m, nil
+}
+
+// Start 开始监听信号
+// 收到关闭信号后停止分发，之后的信号被接收但不再处理，避免关闭过程中被重复触发
+func (m *Manager) Start() {
+	if len(m.ignored) > 0 {
+		signal.Ignore(m.ignored...)
+	}
+
+	signals := make([]os.Signal, 0, len(m.actions))
+	for sig := range m.actions {
+		signals = append(signals, sig)
+	}
+	if len(signals) > 0 {
+		signal.Notify(m.sigChan, signals...)
+	}
+
+	go func() {
+		for {
+			select {
+			case sig := <-m.sigChan:
+				if m.dispatch(sig) {
+					return
+				}
+			case <-m.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止监听信号，被监听的信号恢复默认行为
+func (m *Manager) Stop() {
+	m.stopOnce.Do(func() {
+		signal.Stop(m.sigChan)
+		close(m.done)
+	})
+}
+
+// dispatch 执行信号对应的动作，返回是否为关闭动作
+func (m *Manager) dispatch(sig os.Signal) bool {
+	action := m.actions[sig]
+
+	var handler func(os.Signal)
+	switch action {
+	case ActionShutdown:
+		handler = m.handlers.Shutdown
+	case ActionReload:
+		handler = m.handlers.Reload
+	case ActionReopenLogfile:
+		handler = m.handlers.ReopenLogfile
+	case ActionToggleLogLevel:
+		handler = m.handlers.ToggleLogLevel
+	}
+
+	m.logger.Info("收到信号",
+		log.String("signal", sig.String()),
+		log.String("action", action))
+
+	if handler == nil {
+		m.logger.Warn("信号动作未实现，已忽略",
+			log.String("signal", sig.String()),
+			log.String("action", action))
+	} else {
+		handler(sig)
+	}
+
+	return action == ActionShutdown
+}
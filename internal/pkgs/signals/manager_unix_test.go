@@ -0,0 +1,86 @@
+//go:build !windows
+
+package signals
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+func TestManager(t *testing.T) {
+	received := make(chan string, 4)
+	record := func(action string) func(os.Signal) {
+		return func(os.Signal) { received <- action }
+	}
+
+	cfg := &Config{Actions: map[string]string{
+		"sigterm": ActionShutdown,
+		"sigusr1": ActionReload,
+		"sigusr2": ActionToggleLogLevel,
+		"sighup":  ActionIgnore,
+	}}
+	manager, err := NewManager(cfg, log.NewTestLogger(), Handlers{
+		Shutdown:       record(ActionShutdown),
+		Reload:         record(ActionReload),
+		ToggleLogLevel: record(ActionToggleLogLevel),
+	})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	manager.Start()
+	defer manager.Stop()
+
+	expect := func(sig syscall.Signal, want string) {
+		t.Helper()
+		if err := syscall.Kill(os.Getpid(), sig); err != nil {
+			t.Fatalf("failed to send %v: %v", sig, err)
+		}
+		select {
+		case got := <-received:
+			if got != want {
+				t.Errorf("%v triggered %q, want %q", sig, got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%v did not trigger %q", sig, want)
+		}
+	}
+
+	expect(syscall.SIGUSR1, ActionReload)
+	expect(syscall.SIGUSR2, ActionToggleLogLevel)
+
+	// An ignored SIGHUP neither terminates the process nor runs a handler
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("failed to send SIGHUP: %v", err)
+	}
+
+	expect(syscall.SIGTERM, ActionShutdown)
+
+	select {
+	case got := <-received:
+		t.Errorf("unexpected action %q", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestManager_UnimplementedAction(t *testing.T) {
+	logger := log.NewTestLogger()
+	cfg := &Config{Actions: map[string]string{"sigterm": ActionShutdown, "sigusr1": ActionReopenLogfile}}
+	manager, err := NewManager(cfg, logger, Handlers{})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	if manager.dispatch(syscall.SIGUSR1) {
+		t.Error("reopen-logfile must not stop dispatching")
+	}
+	if len(logger.EntriesByMessage("信号动作未实现，已忽略")) != 1 {
+		t.Error("expected a warning for the missing handler")
+	}
+	if !manager.dispatch(syscall.SIGTERM) {
+		t.Error("shutdown must stop dispatching")
+	}
+}
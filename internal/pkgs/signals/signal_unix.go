@@ -0,0 +1,18 @@
+//go:build !windows
+
+package signals
+
+import (
+	"os"
+	"syscall"
+)
+
+// platformSignals 当前平台支持的信号
+var platformSignals = map[string]os.Signal{
+	"sighup":  syscall.SIGHUP,
+	"sigint":  syscall.SIGINT,
+	"sigquit": syscall.SIGQUIT,
+	"sigterm": syscall.SIGTERM,
+	"sigusr1": syscall.SIGUSR1,
+	"sigusr2": syscall.SIGUSR2,
+}
@@ -0,0 +1,14 @@
+//go:build windows
+
+package signals
+
+import (
+	"os"
+	"syscall"
+)
+
+// platformSignals 当前平台支持的信号（Windows 仅能接收中断与终止信号）
+var platformSignals = map[string]os.Signal{
+	"sigint":  os.Interrupt,
+	"sigterm": syscall.SIGTERM,
+}
@@ -0,0 +1,122 @@
+// Package timing 提供按阶段记录耗时的工具，用于诊断单次采集的时间分布。
+//
+// Recorder 通过 context 在模块间传递；context 中没有 Recorder 时，
+// Track 返回空操作，不会给常规采集带来额外开销。
+package timing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 采集流程中的阶段名称
+const (
+	// StageAuth 获取认证 Token
+	StageAuth = "auth"
+
+	// StageFetch 请求 WinPower 设备数据
+	StageFetch = "http_fetch"
+
+	// StageParse 解析设备数据
+	StageParse = "parse"
+
+	// StageEnergy 电能计算（含读取历史数据，不含写入存储）
+	StageEnergy = "energy_calc"
+
+	// StageStorageWrite 写入存储
+	StageStorageWrite = "storage_write"
+
+	// StageMetricEmit 更新 Prometheus 指标
+	StageMetricEmit = "metric_emit"
+)
+
+// contextKey Recorder 在 context 中的键类型
+type contextKey struct{}
+
+// Stage 单个阶段的累计耗时
+type Stage struct {
+	// Name 阶段名称
+	Name string `json:"name"`
+
+	// DurationMs 累计耗时（毫秒）
+	DurationMs float64 `json:"duration_ms"`
+
+	// Calls 该阶段被记录的次数（如每台设备一次电能计算）
+	Calls int `json:"calls"`
+}
+
+// Recorder 按阶段累计耗时，并发安全
+type Recorder struct {
+	mu     sync.Mutex
+	order  []string
+	totals map[string]time.Duration
+	calls  map[string]int
+}
+
+// NewRecorder 创建阶段耗时记录器
+func NewRecorder() *Recorder {
+	return &Recorder{
+		totals: make(map[string]time.Duration),
+		calls:  make(map[string]int),
+	}
+}
+
+// Add 累加阶段耗时
+func (r *Recorder) Add(stage string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.totals[stage]; !ok {
+		r.order = append(r.order, stage)
+	}
+	r.totals[stage] += d
+	r.calls[stage]++
+}
+
+// Stages 按首次记录的顺序返回各阶段耗时
+func (r *Recorder) Stages() []Stage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stages := make([]Stage, 0, len(r.order))
+	for _, name := range r.order {
+		stages = append(stages, Stage{
+			Name:       name,
+			DurationMs: float64(r.totals[name]) / float64(time.Millisecond),
+			Calls:      r.calls[name],
+		})
+	}
+	return stages
+}
+
+// WithRecorder 在上下文中设置 Recorder
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, contextKey{}, r)
+}
+
+// FromContext 从上下文中获取 Recorder，不存在时返回 nil
+func FromContext(ctx context.Context) *Recorder {
+	if ctx == nil {
+		return nil
+	}
+	r, _ := ctx.Value(contextKey{}).(*Recorder)
+	return r
+}
+
+// Track 开始记录一个阶段，返回的函数在阶段结束时调用
+//
+// 示例：
+//
+//	defer timing.Track(ctx, timing.StageFetch)()
+func Track(ctx context.Context, stage string) func() {
+	r := FromContext(ctx)
+	if r == nil {
+		return func() {}
+	}
+
+	start := time.Now()
+	return func() {
+		r.Add(stage, time.Since(start))
+	}
+}
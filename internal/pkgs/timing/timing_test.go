@@ -0,0 +1,50 @@
+package timing
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRecorder_Stages(t *testing.T) {
+	r := NewRecorder()
+	r.Add(StageFetch, 3*time.Millisecond)
+	r.Add(StageEnergy, time.Millisecond)
+	r.Add(StageEnergy, 2*time.Millisecond)
+
+	stages := r.Stages()
+	if len(stages) != 2 {
+		t.Fatalf("Expected 2 stages, got %d", len(stages))
+	}
+
+	if stages[0].Name != StageFetch || stages[0].DurationMs != 3 || stages[0].Calls != 1 {
+		t.Errorf("Unexpected first stage: %+v", stages[0])
+	}
+	if stages[1].Name != StageEnergy || stages[1].DurationMs != 3 || stages[1].Calls != 2 {
+		t.Errorf("Unexpected second stage: %+v", stages[1])
+	}
+}
+
+func TestTrack(t *testing.T) {
+	t.Run("records into context recorder", func(t *testing.T) {
+		r := NewRecorder()
+		ctx := WithRecorder(context.Background(), r)
+
+		Track(ctx, StageAuth)()
+
+		if FromContext(ctx) != r {
+			t.Fatal("Expected recorder from context")
+		}
+		stages := r.Stages()
+		if len(stages) != 1 || stages[0].Name != StageAuth || stages[0].Calls != 1 {
+			t.Errorf("Unexpected stages: %+v", stages)
+		}
+	})
+
+	t.Run("no recorder is a no-op", func(t *testing.T) {
+		if FromContext(context.Background()) != nil {
+			t.Fatal("Expected no recorder")
+		}
+		Track(context.Background(), StageAuth)()
+	})
+}
@@ -0,0 +1,111 @@
+// Package tlsconfig 解析 TLS 最低版本与密码套件名称，供 HTTPS 服务端和
+// WinPower 客户端共用同一套配置格式。
+//
+// 版本使用 "1.0"、"1.1"、"1.2"、"1.3" 表示，未配置时默认为 TLS 1.2；
+// 密码套件使用 Go crypto/tls 中的标准名称（如 TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256），
+// 仅接受支持 TLS 1.2 及以下版本的安全套件。TLS 1.3 的套件由 Go 固定，不可配置。
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultMinVersion 默认的 TLS 最低版本
+const DefaultMinVersion = "1.2"
+
+// versions 版本名称到 crypto/tls 常量的映射
+var versions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// VersionNames 返回支持的版本名称（按版本排序）
+func VersionNames() []string {
+	names := make([]string, 0, len(versions))
+	for name := range versions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CipherSuiteNames 返回可配置的密码套件名称（按名称排序）
+func CipherSuiteNames() []string {
+	var names []string
+	for _, suite := range tls.CipherSuites() {
+		if supportsTLS12(suite) {
+			names = append(names, suite.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseVersion 解析 TLS 版本名称，空字符串返回 DefaultMinVersion 对应的版本
+func ParseVersion(name string) (uint16, error) {
+	if name == "" {
+		name = DefaultMinVersion
+	}
+	version, ok := versions[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown TLS version %q, valid versions: %s",
+			name, strings.Join(VersionNames(), ", "))
+	}
+	return version, nil
+}
+
+// ParseCipherSuites 解析密码套件名称列表，空列表返回 nil（使用 Go 默认套件）
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		if supportsTLS12(suite) {
+			known[suite.Name] = suite.ID
+		}
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure TLS cipher suite %q, valid cipher suites: %s",
+				name, strings.Join(CipherSuiteNames(), ", "))
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// New 根据版本名称和密码套件名称创建 tls.Config
+func New(minVersion string, cipherSuites []string) (*tls.Config, error) {
+	version, err := ParseVersion(minVersion)
+	if err != nil {
+		return nil, err
+	}
+	suites, err := ParseCipherSuites(cipherSuites)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion:   version,
+		CipherSuites: suites,
+	}, nil
+}
+
+// supportsTLS12 判断密码套件是否可用于 TLS 1.2 及以下版本（即可配置）
+func supportsTLS12(suite *tls.CipherSuite) bool {
+	for _, v := range suite.SupportedVersions {
+		if v <= tls.VersionTLS12 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"strings"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    uint16
+		wantErr bool
+	}{
+		{name: "", want: tls.VersionTLS12},
+		{name: "1.2", want: tls.VersionTLS12},
+		{name: "1.3", want: tls.VersionTLS13},
+		{name: "TLS1.2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseVersion(tt.name)
+		if tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "1.0, 1.1, 1.2, 1.3") {
+				t.Errorf("ParseVersion(%q) error = %v, want error listing valid versions", tt.name, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseVersion(%q) = %v, %v, want %v", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseCipherSuites(t *testing.T) {
+	suites, err := ParseCipherSuites(nil)
+	if err != nil || suites != nil {
+		t.Errorf("Expected nil suites for empty list, got %v, %v", suites, err)
+	}
+
+	suites, err = ParseCipherSuites([]string{
+		"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+		" TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384}
+	if len(suites) != len(want) || suites[0] != want[0] || suites[1] != want[1] {
+		t.Errorf("Expected %v, got %v", want, suites)
+	}
+
+	// Insecure, TLS 1.3-only and unknown names are rejected
+	for _, name := range []string{"TLS_RSA_WITH_RC4_128_SHA", "TLS_AES_128_GCM_SHA256", "bogus"} {
+		_, err := ParseCipherSuites([]string{name})
+		if err == nil || !strings.Contains(err.Error(), "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256") {
+			t.Errorf("ParseCipherSuites(%q) error = %v, want error listing valid suites", name, err)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	cfg, err := New("", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.MinVersion != tls.VersionTLS12 || cfg.CipherSuites != nil {
+		t.Errorf("Expected TLS 1.2 minimum with default suites, got %+v", cfg)
+	}
+
+	if _, err := New("1.2", []string{"bogus"}); err == nil {
+		t.Error("Expected error for unknown cipher suite")
+	}
+}
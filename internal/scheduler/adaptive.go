@@ -0,0 +1,97 @@
+package scheduler
+
+import (
+	"math"
+	"time"
+)
+
+// adaptiveInterval tunes the collection interval from the change rate of the
+// devices' power readings.
+//
+// Each device has its own interval. It is doubled after StableCycles
+// consecutive readings that changed by at most AdaptiveChangePercent, and
+// halved by a reading that changed by more, within the configured bounds.
+// WinPower reports all devices in one request, so collections run at the
+// shortest device interval: idle devices slow collection down only while no
+// device is active.
+//
+// It is used by the collection loop goroutine only.
+type adaptiveInterval struct {
+	min           time.Duration
+	max           time.Duration
+	stableCycles  int
+	changePercent float64
+
+	// initial is the interval a newly seen device starts at
+	initial time.Duration
+	current time.Duration
+	devices map[string]*adaptiveDevice
+}
+
+// adaptiveDevice is the interval state of one device
+type adaptiveDevice struct {
+	interval time.Duration
+	power    float64
+	stable   int
+}
+
+// newAdaptiveInterval returns the interval tuner for config, starting at the
+// collection interval clamped to the adaptive bounds
+func newAdaptiveInterval(config *Config) *adaptiveInterval {
+	initial := min(max(config.CollectionInterval, config.AdaptiveMinInterval), config.AdaptiveMaxInterval)
+	return &adaptiveInterval{
+		min:           config.AdaptiveMinInterval,
+		max:           config.AdaptiveMaxInterval,
+		stableCycles:  config.AdaptiveStableCycles,
+		changePercent: config.AdaptiveChangePercent,
+		initial:       initial,
+		current:       initial,
+		devices:       make(map[string]*adaptiveDevice),
+	}
+}
+
+// update adjusts the device intervals to the power readings of a collection
+// cycle and returns the new collection interval. Devices missing from power
+// are forgotten; without any device the interval is kept.
+func (a *adaptiveInterval) update(power map[string]float64) time.Duration {
+	for deviceID := range a.devices {
+		if _, ok := power[deviceID]; !ok {
+			delete(a.devices, deviceID)
+		}
+	}
+
+	for deviceID, watts := range power {
+		device, ok := a.devices[deviceID]
+		if !ok {
+			a.devices[deviceID] = &adaptiveDevice{interval: a.initial, power: watts}
+			continue
+		}
+
+		if a.changed(device.power, watts) {
+			device.interval = max(device.interval/2, a.min)
+			device.stable = 0
+		} else if device.stable++; device.stable >= a.stableCycles {
+			device.interval = min(device.interval*2, a.max)
+			device.stable = 0
+		}
+		device.power = watts
+	}
+
+	if len(a.devices) == 0 {
+		return a.current
+	}
+
+	shortest := a.max
+	for _, device := range a.devices {
+		shortest = min(shortest, device.interval)
+	}
+	a.current = shortest
+	return a.current
+}
+
+// changed reports whether a reading changed by more than changePercent of
+// the larger of the two readings
+func (a *adaptiveInterval) changed(previous, current float64) bool {
+	delta := math.Abs(current - previous)
+	return delta > math.Max(math.Abs(previous), math.Abs(current))*a.changePercent/100
+}
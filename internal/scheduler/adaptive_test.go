@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestAdaptive() *adaptiveInterval {
+	return newAdaptiveInterval(&Config{
+		CollectionInterval:    5 * time.Second,
+		AdaptiveMinInterval:   5 * time.Second,
+		AdaptiveMaxInterval:   40 * time.Second,
+		AdaptiveStableCycles:  2,
+		AdaptiveChangePercent: 10,
+	})
+}
+
+func TestAdaptiveInterval_Update(t *testing.T) {
+	a := newTestAdaptive()
+
+	steps := []struct {
+		name  string
+		power map[string]float64
+		want  time.Duration
+	}{
+		{"first reading", map[string]float64{"ups-1": 100, "ups-2": 500}, 5 * time.Second},
+		{"one stable cycle", map[string]float64{"ups-1": 105, "ups-2": 500}, 5 * time.Second},
+		{"stable cycles double the interval", map[string]float64{"ups-1": 100, "ups-2": 500}, 10 * time.Second},
+		{"stable again", map[string]float64{"ups-1": 100, "ups-2": 500}, 10 * time.Second},
+		{"doubled again", map[string]float64{"ups-1": 100, "ups-2": 500}, 20 * time.Second},
+		{"change of one device halves its interval", map[string]float64{"ups-1": 100, "ups-2": 800}, 10 * time.Second},
+		{"no devices keeps the interval", map[string]float64{}, 10 * time.Second},
+		{"new device starts at the initial interval", map[string]float64{"ups-3": 0}, 5 * time.Second},
+	}
+
+	for _, step := range steps {
+		if got := a.update(step.power); got != step.want {
+			t.Fatalf("%s: update() = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func TestAdaptiveInterval_Bounds(t *testing.T) {
+	a := newTestAdaptive()
+	power := map[string]float64{"ups-1": 100}
+
+	for i := 0; i < 20; i++ {
+		a.update(power)
+	}
+	if a.current != 40*time.Second {
+		t.Errorf("Expected the interval capped at the maximum, got %v", a.current)
+	}
+
+	for i := 0; i < 10; i++ {
+		power["ups-1"] *= 2
+		a.update(power)
+	}
+	if a.current != 5*time.Second {
+		t.Errorf("Expected the interval floored at the minimum, got %v", a.current)
+	}
+
+	// The collection interval is clamped into the adaptive bounds
+	a = newAdaptiveInterval(&Config{
+		CollectionInterval:   time.Second,
+		AdaptiveMinInterval:  5 * time.Second,
+		AdaptiveMaxInterval:  time.Minute,
+		AdaptiveStableCycles: 1,
+	})
+	if a.current != 5*time.Second {
+		t.Errorf("Expected the initial interval clamped to 5s, got %v", a.current)
+	}
+}
@@ -14,6 +14,78 @@ type Config struct {
 	// GracefulShutdownTimeout is the maximum time to wait for graceful shutdown.
 	// Default: 5 seconds
 	GracefulShutdownTimeout time.Duration `yaml:"graceful_shutdown_timeout" json:"graceful_shutdown_timeout"`
+
+	// OverrunCooldown is the extra delay added before the next cycle when a
+	// cycle hits its deadline, giving WinPower time to recover instead of
+	// being hit again immediately. Zero only drops the missed ticks.
+	// Default: 5 seconds
+	OverrunCooldown time.Duration `yaml:"overrun_cooldown" json:"overrun_cooldown" mapstructure:"overrun_cooldown"`
+
+	// Cron is an optional five-field cron expression, evaluated in local
+	// time (e.g. "* * * * *" for every minute on the minute). When set it
+	// overrides CollectionInterval and collections fire on the schedule;
+	// each cycle must finish before the next fire time.
+	// Default: "" (fixed interval)
+	Cron string `yaml:"cron" json:"cron" mapstructure:"cron"`
+
+	// Adaptive tunes the collection interval from the devices' power
+	// readings: a device's interval is lengthened while its readings are
+	// stable and shortened when they change, within AdaptiveMinInterval and
+	// AdaptiveMaxInterval, and collections run at the shortest device
+	// interval. Energy is integrated over the actual time between
+	// collections. Cannot be combined with Cron.
+	// Default: false
+	Adaptive bool `yaml:"adaptive" json:"adaptive" mapstructure:"adaptive"`
+
+	// AdaptiveMinInterval is the shortest interval in adaptive mode.
+	// Default: 5 seconds
+	AdaptiveMinInterval time.Duration `yaml:"adaptive_min_interval" json:"adaptive_min_interval" mapstructure:"adaptive_min_interval"`
+
+	// AdaptiveMaxInterval is the longest interval in adaptive mode.
+	// Default: 1 minute
+	AdaptiveMaxInterval time.Duration `yaml:"adaptive_max_interval" json:"adaptive_max_interval" mapstructure:"adaptive_max_interval"`
+
+	// AdaptiveStableCycles is the number of consecutive stable readings
+	// after which a device's interval is doubled.
+	// Default: 3
+	AdaptiveStableCycles int `yaml:"adaptive_stable_cycles" json:"adaptive_stable_cycles" mapstructure:"adaptive_stable_cycles"`
+
+	// AdaptiveChangePercent is the largest change of a power reading, in
+	// percent of the reading, that still counts as stable; a larger change
+	// halves the device's interval.
+	// Default: 5
+	AdaptiveChangePercent float64 `yaml:"adaptive_change_percent" json:"adaptive_change_percent" mapstructure:"adaptive_change_percent"`
+
+	// WarmupDuration is how long after Start collections run at
+	// WarmupInterval, populating metrics and establishing baselines quickly
+	// after a restart before relaxing to the regular interval. Energy is
+	// integrated over the actual time between collections, so the shorter
+	// warmup interval does not distort it. Cannot be combined with Cron.
+	// Default: 0 (disabled)
+	WarmupDuration time.Duration `yaml:"warmup_duration" json:"warmup_duration" mapstructure:"warmup_duration"`
+
+	// WarmupInterval is the collection interval during the warmup. It must
+	// not exceed CollectionInterval; in adaptive mode the shorter of the two
+	// intervals is used.
+	// Default: 1 second
+	WarmupInterval time.Duration `yaml:"warmup_interval" json:"warmup_interval" mapstructure:"warmup_interval"`
+
+	// HealthWindow is the number of recent cycles the collection health is
+	// derived from. Each cycle's utilization is its duration as a fraction of
+	// its deadline; an overrun cycle counts as 1. Zero uses the default.
+	// Default: 10
+	HealthWindow int `yaml:"health_window" json:"health_window" mapstructure:"health_window"`
+
+	// HealthDegradedRatio is the mean utilization from which the collection
+	// health is degraded. Zero uses the default.
+	// Default: 0.8
+	HealthDegradedRatio float64 `yaml:"health_degraded_ratio" json:"health_degraded_ratio" mapstructure:"health_degraded_ratio"`
+
+	// HealthSaturatedRatio is the mean utilization from which the collection
+	// health is saturated, i.e. the exporter cannot keep up with the interval.
+	// Zero uses the default.
+	// Default: 0.95
+	HealthSaturatedRatio float64 `yaml:"health_saturated_ratio" json:"health_saturated_ratio" mapstructure:"health_saturated_ratio"`
 }
 
 // DefaultConfig returns a Config with default values.
@@ -21,6 +93,15 @@ func DefaultConfig() *Config {
 	return &Config{
 		CollectionInterval:      5 * time.Second,
 		GracefulShutdownTimeout: 5 * time.Second,
+		OverrunCooldown:         5 * time.Second,
+		AdaptiveMinInterval:     5 * time.Second,
+		AdaptiveMaxInterval:     time.Minute,
+		AdaptiveStableCycles:    3,
+		AdaptiveChangePercent:   5,
+		WarmupInterval:          time.Second,
+		HealthWindow:            defaultHealthWindow,
+		HealthDegradedRatio:     defaultHealthDegradedRatio,
+		HealthSaturatedRatio:    defaultHealthSaturatedRatio,
 	}
 }
 
@@ -34,6 +115,10 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("graceful_shutdown_timeout must be positive, got: %v", c.GracefulShutdownTimeout)
 	}
 
+	if c.OverrunCooldown < 0 {
+		return fmt.Errorf("overrun_cooldown must not be negative, got: %v", c.OverrunCooldown)
+	}
+
 	// Minimum interval constraint (prevent too frequent collections)
 	minInterval := 1 * time.Second
 	if c.CollectionInterval < minInterval {
@@ -46,5 +131,113 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("collection_interval must not exceed %v, got: %v", maxInterval, c.CollectionInterval)
 	}
 
+	if c.OverrunCooldown > maxInterval {
+		return fmt.Errorf("overrun_cooldown must not exceed %v, got: %v", maxInterval, c.OverrunCooldown)
+	}
+
+	if c.Cron != "" {
+		if _, err := parseCron(c.Cron); err != nil {
+			return fmt.Errorf("invalid cron expression %q: %w", c.Cron, err)
+		}
+	}
+
+	if c.Adaptive {
+		if err := c.validateAdaptive(minInterval, maxInterval); err != nil {
+			return err
+		}
+	}
+
+	if c.WarmupDuration != 0 {
+		if err := c.validateWarmup(minInterval, maxInterval); err != nil {
+			return err
+		}
+	}
+
+	return c.validateHealth()
+}
+
+// validateWarmup validates the warmup settings against the allowed interval
+// range
+func (c *Config) validateWarmup(minInterval, maxInterval time.Duration) error {
+	if c.WarmupDuration < 0 || c.WarmupDuration > maxInterval {
+		return fmt.Errorf("warmup_duration must be between 0 and %v, got: %v", maxInterval, c.WarmupDuration)
+	}
+	if c.Cron != "" {
+		return fmt.Errorf("warmup cannot be combined with cron")
+	}
+	if c.WarmupInterval < minInterval {
+		return fmt.Errorf("warmup_interval must be at least %v, got: %v", minInterval, c.WarmupInterval)
+	}
+	if c.WarmupInterval > c.CollectionInterval {
+		return fmt.Errorf("warmup_interval must not exceed collection_interval %v, got: %v", c.CollectionInterval, c.WarmupInterval)
+	}
+	return nil
+}
+
+// validateHealth validates the collection health settings
+func (c *Config) validateHealth() error {
+	if c.HealthWindow < 0 || c.HealthWindow > 1000 {
+		return fmt.Errorf("health_window must be between 0 and 1000, got: %d", c.HealthWindow)
+	}
+	if c.HealthDegradedRatio < 0 || c.HealthDegradedRatio > 1 {
+		return fmt.Errorf("health_degraded_ratio must be between 0 and 1, got: %v", c.HealthDegradedRatio)
+	}
+	degraded, saturated := c.healthRatios()
+	if saturated < degraded || saturated > 1 {
+		return fmt.Errorf("health_saturated_ratio must be between health_degraded_ratio %v and 1, got: %v", degraded, saturated)
+	}
 	return nil
 }
+
+// validateAdaptive validates the adaptive mode settings against the allowed
+// interval range
+func (c *Config) validateAdaptive(minInterval, maxInterval time.Duration) error {
+	if c.Cron != "" {
+		return fmt.Errorf("adaptive cannot be combined with cron")
+	}
+	if c.AdaptiveMinInterval < minInterval {
+		return fmt.Errorf("adaptive_min_interval must be at least %v, got: %v", minInterval, c.AdaptiveMinInterval)
+	}
+	if c.AdaptiveMaxInterval < c.AdaptiveMinInterval {
+		return fmt.Errorf("adaptive_max_interval must not be less than adaptive_min_interval %v, got: %v", c.AdaptiveMinInterval, c.AdaptiveMaxInterval)
+	}
+	if c.AdaptiveMaxInterval > maxInterval {
+		return fmt.Errorf("adaptive_max_interval must not exceed %v, got: %v", maxInterval, c.AdaptiveMaxInterval)
+	}
+	if c.AdaptiveStableCycles < 1 {
+		return fmt.Errorf("adaptive_stable_cycles must be at least 1, got: %d", c.AdaptiveStableCycles)
+	}
+	if c.AdaptiveChangePercent < 0 {
+		return fmt.Errorf("adaptive_change_percent must not be negative, got: %v", c.AdaptiveChangePercent)
+	}
+	return nil
+}
+
+// Defaults of the collection health settings
+const (
+	defaultHealthWindow         = 10
+	defaultHealthDegradedRatio  = 0.8
+	defaultHealthSaturatedRatio = 0.95
+)
+
+// healthWindow returns the configured health window, falling back to the
+// default when unset
+func (c *Config) healthWindow() int {
+	if c.HealthWindow == 0 {
+		return defaultHealthWindow
+	}
+	return c.HealthWindow
+}
+
+// healthRatios returns the configured degraded and saturated ratios,
+// falling back to the defaults when unset
+func (c *Config) healthRatios() (degraded, saturated float64) {
+	degraded, saturated = c.HealthDegradedRatio, c.HealthSaturatedRatio
+	if degraded == 0 {
+		degraded = defaultHealthDegradedRatio
+	}
+	if saturated == 0 {
+		saturated = defaultHealthSaturatedRatio
+	}
+	return degraded, saturated
+}
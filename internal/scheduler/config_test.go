@@ -19,6 +19,10 @@ func TestDefaultConfig(t *testing.T) {
 	if config.GracefulShutdownTimeout != 5*time.Second {
 		t.Errorf("expected GracefulShutdownTimeout to be 5s, got %v", config.GracefulShutdownTimeout)
 	}
+
+	if config.OverrunCooldown != 5*time.Second {
+		t.Errorf("expected OverrunCooldown to be 5s, got %v", config.OverrunCooldown)
+	}
 }
 
 func TestConfig_Validate(t *testing.T) {
@@ -41,6 +45,16 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "negative overrun cooldown",
+			config: &Config{
+				CollectionInterval:      5 * time.Second,
+				GracefulShutdownTimeout: 5 * time.Second,
+				OverrunCooldown:         -1 * time.Second,
+			},
+			wantErr: true,
+			errMsg:  "overrun_cooldown must not be negative",
+		},
 		{
 			name: "zero collection interval",
 			config: &Config{
@@ -103,6 +117,118 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid cron expression",
+			config: &Config{
+				CollectionInterval:      5 * time.Second,
+				GracefulShutdownTimeout: 5 * time.Second,
+				Cron:                    "*/5 * * * *",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid cron expression",
+			config: &Config{
+				CollectionInterval:      5 * time.Second,
+				GracefulShutdownTimeout: 5 * time.Second,
+				Cron:                    "61 * * * *",
+			},
+			wantErr: true,
+			errMsg:  `invalid cron expression "61 * * * *": minute: value 61 out of range 0-59`,
+		},
+		{
+			name: "warmup with cron",
+			config: &Config{
+				CollectionInterval:      5 * time.Second,
+				GracefulShutdownTimeout: 5 * time.Second,
+				Cron:                    "* * * * *",
+				WarmupDuration:          time.Minute,
+				WarmupInterval:          time.Second,
+			},
+			wantErr: true,
+			errMsg:  "warmup cannot be combined with cron",
+		},
+		{
+			name: "warmup interval above collection interval",
+			config: &Config{
+				CollectionInterval:      5 * time.Second,
+				GracefulShutdownTimeout: 5 * time.Second,
+				WarmupDuration:          time.Minute,
+				WarmupInterval:          10 * time.Second,
+			},
+			wantErr: true,
+			errMsg:  "warmup_interval must not exceed collection_interval 5s, got: 10s",
+		},
+		{
+			name: "warmup interval below minimum",
+			config: &Config{
+				CollectionInterval:      5 * time.Second,
+				GracefulShutdownTimeout: 5 * time.Second,
+				WarmupDuration:          time.Minute,
+			},
+			wantErr: true,
+			errMsg:  "warmup_interval must be at least 1s, got: 0s",
+		},
+		{
+			name: "adaptive with cron",
+			config: &Config{
+				CollectionInterval:      5 * time.Second,
+				GracefulShutdownTimeout: 5 * time.Second,
+				Cron:                    "* * * * *",
+				Adaptive:                true,
+				AdaptiveMinInterval:     5 * time.Second,
+				AdaptiveMaxInterval:     time.Minute,
+				AdaptiveStableCycles:    3,
+			},
+			wantErr: true,
+			errMsg:  "adaptive cannot be combined with cron",
+		},
+		{
+			name: "adaptive max interval below min interval",
+			config: &Config{
+				CollectionInterval:      5 * time.Second,
+				GracefulShutdownTimeout: 5 * time.Second,
+				Adaptive:                true,
+				AdaptiveMinInterval:     10 * time.Second,
+				AdaptiveMaxInterval:     5 * time.Second,
+				AdaptiveStableCycles:    3,
+			},
+			wantErr: true,
+			errMsg:  "adaptive_max_interval must not be less than adaptive_min_interval 10s, got: 5s",
+		},
+		{
+			name: "adaptive without stable cycles",
+			config: &Config{
+				CollectionInterval:      5 * time.Second,
+				GracefulShutdownTimeout: 5 * time.Second,
+				Adaptive:                true,
+				AdaptiveMinInterval:     5 * time.Second,
+				AdaptiveMaxInterval:     time.Minute,
+			},
+			wantErr: true,
+			errMsg:  "adaptive_stable_cycles must be at least 1, got: 0",
+		},
+		{
+			name: "saturated ratio below degraded ratio",
+			config: &Config{
+				CollectionInterval:      5 * time.Second,
+				GracefulShutdownTimeout: 5 * time.Second,
+				HealthDegradedRatio:     0.9,
+				HealthSaturatedRatio:    0.5,
+			},
+			wantErr: true,
+			errMsg:  "health_saturated_ratio must be between health_degraded_ratio 0.9 and 1, got: 0.5",
+		},
+		{
+			name: "negative health window",
+			config: &Config{
+				CollectionInterval:      5 * time.Second,
+				GracefulShutdownTimeout: 5 * time.Second,
+				HealthWindow:            -1,
+			},
+			wantErr: true,
+			errMsg:  "health_window must be between 0 and 1000, got: -1",
+		},
 		{
 			name: "maximum valid collection interval",
 			config: &Config{
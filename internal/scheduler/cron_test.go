@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr   string
+		errMsg string
+	}{
+		{expr: "* * * * *"},
+		{expr: "0,30 8-18/2 * 1-12 1-5"},
+		{expr: "5/15 0 1 * 7"},
+		{expr: "* * *", errMsg: "expected 5 fields"},
+		{expr: "60 * * * *", errMsg: "minute: value 60 out of range 0-59"},
+		{expr: "* 24 * * *", errMsg: "hour: value 24 out of range 0-23"},
+		{expr: "* * 0 * *", errMsg: "day of month: value 0 out of range 1-31"},
+		{expr: "*/0 * * * *", errMsg: "minute: invalid step"},
+		{expr: "* * * JAN *", errMsg: `month: invalid value "JAN"`},
+		{expr: "10-5 * * * *", errMsg: `minute: range "10-5" is reversed`},
+		{expr: "0 0 30 2 *", errMsg: "never matches"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := parseCron(tt.expr)
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("parseCron() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("parseCron() error = %v, want error containing %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestCronSchedule_Next(t *testing.T) {
+	at := func(s string) time.Time {
+		t.Helper()
+		v, err := time.Parse("2006-01-02 15:04:05", s)
+		if err != nil {
+			t.Fatalf("bad time %q: %v", s, err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		expr string
+		from string
+		want string
+	}{
+		{"* * * * *", "2025-01-01 10:00:30", "2025-01-01 10:01:00"},
+		{"* * * * *", "2025-01-01 10:00:00", "2025-01-01 10:01:00"},
+		{"*/15 * * * *", "2025-01-01 10:16:00", "2025-01-01 10:30:00"},
+		{"0 */6 * * *", "2025-01-01 19:00:00", "2025-01-02 00:00:00"},
+		{"30 9 * * 1-5", "2025-01-03 10:00:00", "2025-01-06 09:30:00"}, // Friday to Monday
+		{"0 0 * * 0", "2025-01-01 00:00:00", "2025-01-05 00:00:00"},
+		{"0 0 * * 7", "2025-01-01 00:00:00", "2025-01-05 00:00:00"},
+		{"0 0 29 2 *", "2025-01-01 00:00:00", "2028-02-29 00:00:00"},
+		// Both day fields restricted: either matches
+		{"0 0 15 * 1", "2025-01-01 00:00:00", "2025-01-06 00:00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr+" from "+tt.from, func(t *testing.T) {
+			schedule, err := parseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("parseCron() error = %v", err)
+			}
+			if got := schedule.next(at(tt.from)); !got.Equal(at(tt.want)) {
+				t.Errorf("next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
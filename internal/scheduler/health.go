@@ -0,0 +1,91 @@
+package scheduler
+
+import "time"
+
+// CollectionHealth tells whether the scheduler keeps up with its interval.
+type CollectionHealth int
+
+const (
+	// CollectionHealthy means recent cycles finish well within their deadline
+	CollectionHealthy CollectionHealth = iota
+
+	// CollectionDegraded means recent cycles use most of their deadline
+	CollectionDegraded
+
+	// CollectionSaturated means recent cycles use their whole deadline or
+	// overrun it: the exporter cannot keep up with the interval
+	CollectionSaturated
+)
+
+// String returns the name of the health state
+func (h CollectionHealth) String() string {
+	switch h {
+	case CollectionHealthy:
+		return "healthy"
+	case CollectionDegraded:
+		return "degraded"
+	case CollectionSaturated:
+		return "saturated"
+	default:
+		return "unknown"
+	}
+}
+
+// healthTracker derives the collection health from the utilization of the
+// last HealthWindow cycles, i.e. each cycle's duration as a fraction of its
+// deadline. An overrun cycle counts as fully utilized. The mean utilization
+// is compared with HealthDegradedRatio and HealthSaturatedRatio.
+//
+// It is used by the collection loop goroutine only.
+type healthTracker struct {
+	degraded  float64
+	saturated float64
+
+	samples []float64 // ring buffer of the recent utilizations
+	next    int
+	count   int
+	state   CollectionHealth
+}
+
+// newHealthTracker returns the health tracker for config
+func newHealthTracker(config *Config) *healthTracker {
+	degraded, saturated := config.healthRatios()
+	return &healthTracker{
+		degraded:  degraded,
+		saturated: saturated,
+		samples:   make([]float64, config.healthWindow()),
+	}
+}
+
+// observe records a cycle of the given duration and deadline and returns
+// the resulting health and whether it changed.
+func (h *healthTracker) observe(duration, deadline time.Duration, overrun bool) (CollectionHealth, bool) {
+	utilization := 1.0
+	if !overrun && deadline > 0 {
+		utilization = min(duration.Seconds()/deadline.Seconds(), 1)
+	}
+
+	h.samples[h.next] = utilization
+	h.next = (h.next + 1) % len(h.samples)
+	if h.count < len(h.samples) {
+		h.count++
+	}
+
+	var sum float64
+	for _, sample := range h.samples[:h.count] {
+		sum += sample
+	}
+	mean := sum / float64(h.count)
+
+	state := CollectionHealthy
+	switch {
+	case mean >= h.saturated:
+		state = CollectionSaturated
+	case mean >= h.degraded:
+		state = CollectionDegraded
+	}
+
+	changed := state != h.state
+	h.state = state
+	return state, changed
+}
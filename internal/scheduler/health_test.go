@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHealthTracker_Observe(t *testing.T) {
+	h := newHealthTracker(&Config{
+		HealthWindow:         3,
+		HealthDegradedRatio:  0.5,
+		HealthSaturatedRatio: 0.9,
+	})
+
+	steps := []struct {
+		name        string
+		duration    time.Duration
+		overrun     bool
+		want        CollectionHealth
+		wantChanged bool
+	}{
+		{"fast cycle", 1 * time.Second, false, CollectionHealthy, false},
+		{"slow cycle degrades", 9 * time.Second, false, CollectionDegraded, true},
+		{"overrun", 10 * time.Second, true, CollectionDegraded, false},
+		{"second overrun saturates", 10 * time.Second, true, CollectionSaturated, true},
+		{"still saturated", 10 * time.Second, true, CollectionSaturated, false},
+		{"fast cycle recovers partly", 1 * time.Second, false, CollectionDegraded, true},
+		{"fast cycles recover", 1 * time.Second, false, CollectionHealthy, true},
+		{"still healthy", 1 * time.Second, false, CollectionHealthy, false},
+	}
+
+	for _, step := range steps {
+		got, changed := h.observe(step.duration, 10*time.Second, step.overrun)
+		if got != step.want || changed != step.wantChanged {
+			t.Fatalf("%s: observe() = %v, %v; want %v, %v", step.name, got, changed, step.want, step.wantChanged)
+		}
+	}
+}
+
+func TestHealthTracker_Defaults(t *testing.T) {
+	h := newHealthTracker(&Config{})
+	if len(h.samples) != defaultHealthWindow || h.degraded != defaultHealthDegradedRatio || h.saturated != defaultHealthSaturatedRatio {
+		t.Errorf("newHealthTracker() = window %d, ratios %v/%v; want the defaults", len(h.samples), h.degraded, h.saturated)
+	}
+}
+
+func TestCollectionHealth_String(t *testing.T) {
+	for health, want := range map[CollectionHealth]string{
+		CollectionHealthy:    "healthy",
+		CollectionDegraded:   "degraded",
+		CollectionSaturated:  "saturated",
+		CollectionHealth(-1): "unknown",
+	} {
+		if got := health.String(); got != want {
+			t.Errorf("CollectionHealth(%d).String() = %q, want %q", health, got, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"context"
+	"time"
 )
 
 // Scheduler defines the interface for scheduling periodic data collection.
@@ -15,6 +16,17 @@ type Scheduler interface {
 	// It returns an error if the scheduler is not running or if graceful shutdown times out.
 	// The provided context can be used to set a deadline for the stop operation.
 	Stop(ctx context.Context) error
+
+	// Pause makes the scheduler skip collection ticks without stopping it.
+	// It reports whether the scheduler was previously running unpaused.
+	Pause() bool
+
+	// Resume resumes collection after Pause.
+	// It reports whether the scheduler was previously paused.
+	Resume() bool
+
+	// IsPaused returns whether the scheduler is paused.
+	IsPaused() bool
 }
 
 // CollectorInterface defines the interface for data collection operations.
@@ -24,12 +36,53 @@ type CollectorInterface interface {
 	CollectDeviceData(ctx context.Context) (*CollectionResult, error)
 }
 
+// Authenticator is optionally implemented by a CollectorInterface that can
+// authenticate with the data source ahead of a collection. The scheduler
+// calls Authenticate before starting a cycle's deadline, so slow
+// authentication does not shorten the time left for fetching data. An
+// implementation bounds Authenticate with its own timeout.
+type Authenticator interface {
+	// Authenticate makes sure the next collection can use a valid session.
+	// The collection runs even when it fails and reports the failure itself.
+	Authenticate(ctx context.Context) error
+}
+
 // CollectionResult represents the result of a data collection operation.
 // This is a simplified version for scheduler's needs.
 type CollectionResult struct {
 	Success      bool   `json:"success"`
 	DeviceCount  int    `json:"device_count"`
 	ErrorMessage string `json:"error_message,omitempty"`
+
+	// DevicePower holds the power reading in watts of each successfully
+	// collected device, keyed by device ID. It drives the adaptive interval.
+	DevicePower map[string]float64 `json:"device_power,omitempty"`
+}
+
+// OverrunRecorder records collection cycles that exceeded their deadline.
+// It is implemented by the metrics module.
+type OverrunRecorder interface {
+	// RecordSchedulerOverrun counts one overrun cycle.
+	RecordSchedulerOverrun()
+}
+
+// TickRecorder records the configured collection interval and the observed
+// time between consecutive ticks, so that scheduler drift can be detected.
+// It is implemented by the metrics module.
+type TickRecorder interface {
+	// SetSchedulerInterval records the configured collection interval.
+	SetSchedulerInterval(interval time.Duration)
+
+	// ObserveSchedulerTick records the time elapsed since the previous tick.
+	ObserveSchedulerTick(gap time.Duration)
+}
+
+// HealthRecorder records the collection health derived from recent cycles.
+// It is implemented by the metrics module.
+type HealthRecorder interface {
+	// SetCollectionHealth records the health state: 0 (CollectionHealthy),
+	// 1 (CollectionDegraded) or 2 (CollectionSaturated).
+	SetCollectionHealth(state int)
 }
 
 // Logger defines the interface for structured logging.
@@ -2,23 +2,54 @@ package scheduler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// DefaultScheduler implements the Scheduler interface with a simple fixed-interval design.
+// DefaultScheduler implements the Scheduler interface with a simple fixed-interval design,
+// or fires on a cron schedule when Config.Cron is set.
 type DefaultScheduler struct {
 	config    *Config
 	collector CollectorInterface
 	logger    Logger
+	cron      *cronSchedule // nil when collecting at a fixed interval
 
-	// Runtime state
+	// adaptive tunes the interval from the devices' power readings; nil
+	// unless Config.Adaptive is set
+	adaptive *adaptiveInterval
+
+	// warmupUntil is the end of the warmup started by Start, during which
+	// collections run at WarmupInterval; zero without a warmup
+	warmupUntil time.Time
+
+	// Runtime state, guarded by mu. Each Start hands its context, ticker
+	// and done channel to its own loop goroutine, so a loop that is still
+	// finishing after Stop never picks up the state of a later Start.
 	ticker  *time.Ticker
-	ctx     context.Context
 	cancel  context.CancelFunc
-	wg      sync.WaitGroup
+	done    chan struct{} // closed when the loop of the last Start exits
 	running bool
 	mu      sync.RWMutex
+
+	// paused makes the loop skip collection ticks without stopping
+	paused atomic.Bool
+
+	// overruns receives cycles that hit their deadline; nil disables recording
+	overruns OverrunRecorder
+
+	// ticks receives the configured interval and observed tick gaps; nil
+	// disables recording
+	ticks TickRecorder
+
+	// health tracks whether recent cycles keep up with their deadline
+	health *healthTracker
+
+	// healthRecorder receives changes of the collection health; nil
+	// disables recording
+	healthRecorder HealthRecorder
 }
 
 // NewDefaultScheduler creates a new DefaultScheduler with the given configuration and dependencies.
@@ -37,14 +68,50 @@ func NewDefaultScheduler(config *Config, collector CollectorInterface, logger Lo
 		return nil, err
 	}
 
+	var cron *cronSchedule
+	if config.Cron != "" {
+		// Already validated by config.Validate
+		cron, _ = parseCron(config.Cron)
+	}
+
+	var adaptive *adaptiveInterval
+	if config.Adaptive {
+		adaptive = newAdaptiveInterval(config)
+	}
+
 	return &DefaultScheduler{
 		config:    config,
 		collector: collector,
 		logger:    logger,
+		cron:      cron,
+		adaptive:  adaptive,
+		health:    newHealthTracker(config),
 	}, nil
 }
 
+// SetOverrunRecorder sets the recorder notified of cycles that exceed their
+// deadline. It must be called before Start.
+func (s *DefaultScheduler) SetOverrunRecorder(recorder OverrunRecorder) {
+	s.overruns = recorder
+}
+
+// SetTickRecorder sets the recorder of the configured interval and the
+// observed time between ticks. It must be called before Start.
+func (s *DefaultScheduler) SetTickRecorder(recorder TickRecorder) {
+	s.ticks = recorder
+}
+
+// SetHealthRecorder sets the recorder notified when the collection health
+// changes. It must be called before Start.
+func (s *DefaultScheduler) SetHealthRecorder(recorder HealthRecorder) {
+	s.healthRecorder = recorder
+}
+
 // Start starts the scheduler and begins triggering data collection at configured intervals.
+//
+// It returns ErrAlreadyRunning when the scheduler is running, or when the
+// loop of a previous run has not exited yet because Stop timed out while a
+// cycle was in progress; two loops never collect at the same time.
 func (s *DefaultScheduler) Start(ctx context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -52,28 +119,60 @@ func (s *DefaultScheduler) Start(ctx context.Context) error {
 	if s.running {
 		return ErrAlreadyRunning
 	}
+	if s.done != nil {
+		select {
+		case <-s.done:
+		default:
+			return fmt.Errorf("%w: the previous collection loop is still stopping", ErrAlreadyRunning)
+		}
+	}
 
 	// Create a cancellable context
-	s.ctx, s.cancel = context.WithCancel(ctx)
-
-	// Create ticker with configured interval
-	s.ticker = time.NewTicker(s.config.CollectionInterval)
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+	s.ticker = nil
 
 	// Mark as running
 	s.running = true
 
+	if s.cron != nil {
+		go s.cronLoop(ctx, s.done)
+
+		s.logger.Info("scheduler started",
+			"cron", s.config.Cron,
+			"next", s.cron.next(time.Now()),
+		)
+		return nil
+	}
+
+	s.warmupUntil = time.Time{}
+	if s.config.WarmupDuration > 0 {
+		s.warmupUntil = time.Now().Add(s.config.WarmupDuration)
+	}
+
+	interval := s.interval()
+	if s.ticks != nil {
+		s.ticks.SetSchedulerInterval(interval)
+	}
+
+	// Create ticker with configured interval
+	s.ticker = time.NewTicker(interval)
+
 	// Start the collection loop in a goroutine
-	s.wg.Add(1)
-	go s.collectionLoop()
+	go s.collectionLoop(ctx, s.ticker, s.done)
 
 	s.logger.Info("scheduler started",
-		"interval", s.config.CollectionInterval,
+		"interval", interval,
+		"adaptive", s.adaptive != nil,
+		"warmup", s.config.WarmupDuration,
 	)
 
 	return nil
 }
 
-// Stop stops the scheduler gracefully.
+// Stop stops the scheduler gracefully. It returns ErrNotRunning when the
+// scheduler is not running, including when another Stop call got there
+// first, so every Start is stopped exactly once.
 func (s *DefaultScheduler) Stop(ctx context.Context) error {
 	s.mu.Lock()
 
@@ -92,17 +191,14 @@ func (s *DefaultScheduler) Stop(ctx context.Context) error {
 
 	// Mark as not running
 	s.running = false
+	done := s.done
 	s.mu.Unlock()
 
-	// Wait for goroutine to finish with timeout
-	done := make(chan struct{})
-	go func() {
-		s.wg.Wait()
-		close(done)
-	}()
-
 	// Determine timeout from context or config
 	timeout := s.config.GracefulShutdownTimeout
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	if deadline, ok := ctx.Deadline(); ok {
 		if remaining := time.Until(deadline); remaining < timeout {
 			timeout = remaining
@@ -122,49 +218,228 @@ func (s *DefaultScheduler) Stop(ctx context.Context) error {
 }
 
 // collectionLoop runs the periodic collection in a separate goroutine.
-func (s *DefaultScheduler) collectionLoop() {
-	defer s.wg.Done()
+//
+// When a cycle overruns its deadline the ticker is reset to fire after the
+// interval plus OverrunCooldown, so the tick missed during the long cycle is
+// dropped rather than firing immediately, and WinPower gets time to recover.
+// The regular interval is restored on the following tick.
+//
+// In adaptive mode the interval is retuned after every successful cycle and
+// takes effect from the next tick. Likewise the regular interval replaces
+// the warmup interval from the first tick after the warmup ends.
+func (s *DefaultScheduler) collectionLoop(ctx context.Context, ticker *time.Ticker, done chan struct{}) {
+	defer close(done)
+
+	s.logger.Debug("collection loop started")
+
+	interval := s.interval()
+	warmup := s.warmingUp()
+	coolingDown := false
+	lastTick := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Debug("collection loop stopped")
+			return
+
+		case <-ticker.C:
+			lastTick = s.observeTick(lastTick)
+			if coolingDown {
+				ticker.Reset(interval)
+				coolingDown = false
+			}
+			if s.paused.Load() {
+				s.logger.Debug("scheduler paused, skipping collection")
+				continue
+			}
+
+			overrun := s.runCollection(ctx, interval)
+			if warmup && !s.warmingUp() {
+				s.logger.Info("warmup finished, relaxing to the regular interval")
+				warmup = false
+			}
+			next := s.interval()
+			if next != interval {
+				s.logger.Info("collection interval adjusted",
+					"from", interval,
+					"to", next,
+				)
+				if s.ticks != nil {
+					s.ticks.SetSchedulerInterval(next)
+				}
+				interval = next
+				if !overrun {
+					ticker.Reset(interval)
+				}
+			}
+			if overrun {
+				ticker.Reset(interval + s.config.OverrunCooldown)
+				coolingDown = true
+			}
+		}
+	}
+}
+
+// interval returns the current collection interval, which is tuned by the
+// device readings in adaptive mode and shortened to WarmupInterval during
+// the warmup
+func (s *DefaultScheduler) interval() time.Duration {
+	interval := s.config.CollectionInterval
+	if s.adaptive != nil {
+		interval = s.adaptive.current
+	}
+	if s.warmingUp() {
+		return min(interval, s.config.WarmupInterval)
+	}
+	return interval
+}
+
+// warmingUp reports whether the warmup started by Start is still running
+func (s *DefaultScheduler) warmingUp() bool {
+	return time.Now().Before(s.warmupUntil)
+}
+
+// cronLoop runs collections at the fire times of the cron schedule.
+//
+// Each cycle must finish before the next fire time. Fire times that pass
+// while a cycle runs are skipped, and after an overrun the fire times within
+// OverrunCooldown are skipped as well. Energy is integrated over the actual
+// time between collections, so skipped fire times do not distort it.
+func (s *DefaultScheduler) cronLoop(ctx context.Context, done chan struct{}) {
+	defer close(done)
 
 	s.logger.Debug("collection loop started")
 
+	fireAt := s.cron.next(time.Now())
+	timer := time.NewTimer(time.Until(fireAt))
+	defer timer.Stop()
+
+	var lastTick time.Time
 	for {
 		select {
-		case <-s.ctx.Done():
+		case <-ctx.Done():
 			s.logger.Debug("collection loop stopped")
 			return
 
-		case <-s.ticker.C:
-			s.runCollection()
+		case <-timer.C:
+			// The gap to the first fire time depends on when the scheduler
+			// started, so only gaps between fire times are observed
+			if lastTick.IsZero() {
+				lastTick = time.Now()
+			} else {
+				lastTick = s.observeTick(lastTick)
+			}
+
+			// The timer may fire marginally before fireAt if the wall clock
+			// was adjusted; never fire twice for the same time
+			now := time.Now()
+			if now.Before(fireAt) {
+				now = fireAt
+			}
+			next := s.cron.next(now)
+
+			if s.paused.Load() {
+				s.logger.Debug("scheduler paused, skipping collection")
+			} else {
+				if s.runCollection(ctx, next.Sub(now)) {
+					next = s.cron.next(time.Now().Add(s.config.OverrunCooldown))
+				} else if !time.Now().Before(next) {
+					next = s.cron.next(time.Now())
+				}
+			}
+
+			fireAt = next
+			timer.Reset(time.Until(fireAt))
 		}
 	}
 }
 
-// runCollection executes a single collection cycle.
-func (s *DefaultScheduler) runCollection() {
+// observeTick records the time elapsed since the previous tick and returns
+// the time of this tick.
+//
+// Paused ticks are observed too: the gap measures whether the scheduler fires
+// on time, independent of whether it collects.
+func (s *DefaultScheduler) observeTick(lastTick time.Time) time.Time {
+	now := time.Now()
+	if s.ticks != nil {
+		s.ticks.ObserveSchedulerTick(now.Sub(lastTick))
+	}
+	return now
+}
+
+// runCollection executes a single collection cycle with the given deadline.
+// It reports whether the cycle overran, i.e. was cut short by the deadline.
+//
+// When the collector implements Authenticator, authentication runs first
+// under its own timeout and the deadline starts once it completes. Both are
+// derived from ctx, the loop's context, so that Stop cancels a cycle in
+// progress instead of waiting for its deadline.
+func (s *DefaultScheduler) runCollection(ctx context.Context, timeout time.Duration) (overrun bool) {
+	if auth, ok := s.collector.(Authenticator); ok {
+		if err := auth.Authenticate(ctx); err != nil {
+			s.logger.Debug("collecting after failed authentication",
+				"error", err,
+			)
+		}
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+
 	start := time.Now()
 
 	// Create a context with timeout for this collection cycle
-	ctx, cancel := context.WithTimeout(context.Background(), s.config.CollectionInterval)
+	cycleCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	// Execute collection
-	result, err := s.collector.CollectDeviceData(ctx)
+	result, err := s.collector.CollectDeviceData(cycleCtx)
 
 	duration := time.Since(start)
 
+	// A cycle cancelled by Stop is neither a failure nor an overrun
+	if ctx.Err() != nil {
+		s.logger.Debug("collection cancelled by scheduler stop",
+			"duration", duration,
+		)
+		return false
+	}
+
+	// Only the cycle's own deadline counts as an overrun; a deadline error from
+	// a shorter inner timeout (e.g. the HTTP client) is a genuine failure
+	overrun = err != nil && errors.Is(cycleCtx.Err(), context.DeadlineExceeded)
+	s.observeHealth(duration, timeout, overrun)
+
+	if overrun {
+		s.logger.Warn("collection cycle exceeded interval, cooling down",
+			"interval", timeout,
+			"duration", duration,
+			"cooldown", s.config.OverrunCooldown,
+			"error", err,
+		)
+		if s.overruns != nil {
+			s.overruns.RecordSchedulerOverrun()
+		}
+		return true
+	}
+
 	if err != nil {
 		s.logger.Error("collection failed",
 			"error", err,
 			"duration", duration,
 		)
-		return
+		return false
 	}
 
 	if result == nil {
 		s.logger.Warn("collection returned nil result",
 			"duration", duration,
 		)
-		return
+		return false
+	}
+
+	if s.adaptive != nil && result.Success {
+		s.adaptive.update(result.DevicePower)
 	}
 
 	// Log collection result
@@ -180,6 +455,57 @@ func (s *DefaultScheduler) runCollection() {
 			"duration", duration,
 		)
 	}
+	return false
+}
+
+// observeHealth updates the collection health with a finished cycle and
+// reports a change of state to the log and the health recorder.
+func (s *DefaultScheduler) observeHealth(duration, timeout time.Duration, overrun bool) {
+	state, changed := s.health.observe(duration, timeout, overrun)
+	if !changed {
+		return
+	}
+
+	if s.healthRecorder != nil {
+		s.healthRecorder.SetCollectionHealth(int(state))
+	}
+	if state == CollectionHealthy {
+		s.logger.Info("collection health recovered",
+			"health", state.String(),
+		)
+		return
+	}
+	s.logger.Warn("collection is falling behind the interval",
+		"health", state.String(),
+		"window", len(s.health.samples),
+		"duration", duration,
+		"interval", timeout,
+	)
+}
+
+// Pause makes the scheduler skip collection ticks until Resume is called.
+// A collection cycle already in progress is allowed to complete. It reports
+// whether the state changed.
+func (s *DefaultScheduler) Pause() bool {
+	if !s.paused.CompareAndSwap(false, true) {
+		return false
+	}
+	s.logger.Info("scheduler paused")
+	return true
+}
+
+// Resume resumes collection on the next tick. It reports whether the state changed.
+func (s *DefaultScheduler) Resume() bool {
+	if !s.paused.CompareAndSwap(true, false) {
+		return false
+	}
+	s.logger.Info("scheduler resumed")
+	return true
+}
+
+// IsPaused returns whether collection ticks are currently skipped.
+func (s *DefaultScheduler) IsPaused() bool {
+	return s.paused.Load()
 }
 
 // IsRunning returns whether the scheduler is currently running.
@@ -301,6 +301,35 @@ func TestDefaultScheduler_Start(t *testing.T) {
 		// Clean up
 		_ = scheduler.Stop(context.Background())
 	})
+
+	t.Run("cron schedule", func(t *testing.T) {
+		config := DefaultConfig()
+		config.Cron = "* * * * *"
+		collector := &MockCollector{}
+		logger := &MockLogger{}
+
+		scheduler, err := NewDefaultScheduler(config, collector, logger)
+		if err != nil {
+			t.Fatalf("NewDefaultScheduler() error = %v", err)
+		}
+		if scheduler.cron == nil {
+			t.Fatal("NewDefaultScheduler() should parse the cron expression")
+		}
+
+		if err := scheduler.Start(context.Background()); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		if scheduler.ticker != nil {
+			t.Error("Start() should not create a ticker for a cron schedule")
+		}
+		if !logger.HasInfoLog("scheduler started") {
+			t.Error("Start() should log 'scheduler started'")
+		}
+
+		if err := scheduler.Stop(context.Background()); err != nil {
+			t.Errorf("Stop() error = %v", err)
+		}
+	})
 }
 
 func TestDefaultScheduler_Stop(t *testing.T) {
@@ -400,6 +429,200 @@ func TestDefaultScheduler_Stop(t *testing.T) {
 	})
 }
 
+// blockingCollector blocks in CollectDeviceData, and in Authenticate when
+// blockAuth is set, until its context is done
+type blockingCollector struct {
+	blockAuth bool
+	started   chan struct{}
+	cancelled chan error
+}
+
+func (c *blockingCollector) Authenticate(ctx context.Context) error {
+	if !c.blockAuth {
+		return nil
+	}
+	close(c.started)
+	<-ctx.Done()
+	c.cancelled <- ctx.Err()
+	return ctx.Err()
+}
+
+func (c *blockingCollector) CollectDeviceData(ctx context.Context) (*CollectionResult, error) {
+	if !c.blockAuth {
+		close(c.started)
+		<-ctx.Done()
+		c.cancelled <- ctx.Err()
+	}
+	return nil, ctx.Err()
+}
+
+func TestDefaultScheduler_StopCancelsRunningCycle(t *testing.T) {
+	for _, blockAuth := range []bool{false, true} {
+		name := "collection blocks"
+		if blockAuth {
+			name = "authentication blocks"
+		}
+		t.Run(name, func(t *testing.T) {
+			config := &Config{
+				CollectionInterval:      2 * time.Second,
+				GracefulShutdownTimeout: 5 * time.Second,
+			}
+			collector := &blockingCollector{
+				blockAuth: blockAuth,
+				started:   make(chan struct{}),
+				cancelled: make(chan error, 1),
+			}
+
+			scheduler, err := NewDefaultScheduler(config, collector, &MockLogger{})
+			if err != nil {
+				t.Fatalf("NewDefaultScheduler() error = %v", err)
+			}
+			if err := scheduler.Start(context.Background()); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			select {
+			case <-collector.started:
+			case <-time.After(5 * time.Second):
+				t.Fatal("collection cycle did not start")
+			}
+
+			// Stop must not wait for the cycle's 2s deadline
+			start := time.Now()
+			if err := scheduler.Stop(context.Background()); err != nil {
+				t.Fatalf("Stop() error = %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Stop() took %v, want the running cycle to be cancelled", elapsed)
+			}
+			if err := <-collector.cancelled; !errors.Is(err, context.Canceled) {
+				t.Errorf("cycle context error = %v, want %v", err, context.Canceled)
+			}
+		})
+	}
+}
+
+func TestDefaultScheduler_ConcurrentStartStop(t *testing.T) {
+	t.Run("concurrent start and stop succeed once", func(t *testing.T) {
+		scheduler, err := NewDefaultScheduler(DefaultConfig(), &MockCollector{}, &MockLogger{})
+		if err != nil {
+			t.Fatalf("NewDefaultScheduler() error = %v", err)
+		}
+
+		count := func(call func() error, want error) int {
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			succeeded := 0
+			for range 10 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					err := call()
+					if err != nil && !errors.Is(err, want) {
+						t.Errorf("error = %v, want %v", err, want)
+					}
+					mu.Lock()
+					defer mu.Unlock()
+					if err == nil {
+						succeeded++
+					}
+				}()
+			}
+			wg.Wait()
+			return succeeded
+		}
+
+		ctx := context.Background()
+		if n := count(func() error { return scheduler.Start(ctx) }, ErrAlreadyRunning); n != 1 {
+			t.Errorf("Expected exactly one Start to succeed, got %d", n)
+		}
+		if n := count(func() error { return scheduler.Stop(ctx) }, ErrNotRunning); n != 1 {
+			t.Errorf("Expected exactly one Stop to succeed, got %d", n)
+		}
+		if scheduler.IsRunning() {
+			t.Error("scheduler should not be running")
+		}
+	})
+
+	t.Run("rapid restarts", func(t *testing.T) {
+		config := DefaultConfig()
+		config.CollectionInterval = time.Second
+		collector := &MockCollector{}
+		scheduler, err := NewDefaultScheduler(config, collector, &MockLogger{})
+		if err != nil {
+			t.Fatalf("NewDefaultScheduler() error = %v", err)
+		}
+
+		ctx := context.Background()
+		for i := range 50 {
+			if err := scheduler.Start(ctx); err != nil {
+				t.Fatalf("Start() #%d error = %v", i, err)
+			}
+			if err := scheduler.Stop(ctx); err != nil {
+				t.Fatalf("Stop() #%d error = %v", i, err)
+			}
+		}
+
+		// No loop of a stopped run keeps collecting
+		time.Sleep(1500 * time.Millisecond)
+		if calls := collector.GetCallCount(); calls != 0 {
+			t.Errorf("Expected no collections after the restarts, got %d", calls)
+		}
+	})
+
+	t.Run("start while the previous loop is still stopping", func(t *testing.T) {
+		config := &Config{
+			CollectionInterval:      1 * time.Second,
+			GracefulShutdownTimeout: 100 * time.Millisecond,
+		}
+		release := make(chan struct{})
+		collector := &MockCollector{
+			CollectDeviceDataFunc: func(ctx context.Context) (*CollectionResult, error) {
+				<-release
+				return &CollectionResult{Success: true}, nil
+			},
+		}
+		scheduler, err := NewDefaultScheduler(config, collector, &MockLogger{})
+		if err != nil {
+			t.Fatalf("NewDefaultScheduler() error = %v", err)
+		}
+
+		ctx := context.Background()
+		if err := scheduler.Start(ctx); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		// Stop while the first cycle is blocked
+		time.Sleep(1200 * time.Millisecond)
+		if err := scheduler.Stop(ctx); !errors.Is(err, ErrShutdownTimeout) {
+			t.Fatalf("Stop() error = %v, want %v", err, ErrShutdownTimeout)
+		}
+
+		if err := scheduler.Start(ctx); !errors.Is(err, ErrAlreadyRunning) {
+			t.Fatalf("Start() error = %v, want %v", err, ErrAlreadyRunning)
+		}
+		if scheduler.IsRunning() {
+			t.Error("a rejected Start should not mark the scheduler running")
+		}
+
+		// Once the cycle completes the old loop exits and Start succeeds
+		close(release)
+		deadline := time.Now().Add(time.Second)
+		for {
+			err := scheduler.Start(ctx)
+			if err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Start() error = %v after the previous loop exited", err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if err := scheduler.Stop(ctx); err != nil {
+			t.Errorf("Stop() error = %v", err)
+		}
+	})
+}
+
 func TestDefaultScheduler_Collection(t *testing.T) {
 	t.Run("collects data at interval", func(t *testing.T) {
 		config := &Config{
@@ -628,3 +851,345 @@ func TestDefaultScheduler_IsRunning(t *testing.T) {
 		t.Error("IsRunning() should be false after Stop()")
 	}
 }
+
+func TestDefaultScheduler_PauseResume(t *testing.T) {
+	config := &Config{
+		CollectionInterval:      1 * time.Second,
+		GracefulShutdownTimeout: 5 * time.Second,
+	}
+	collector := &MockCollector{}
+	logger := &MockLogger{}
+
+	scheduler, err := NewDefaultScheduler(config, collector, logger)
+	if err != nil {
+		t.Fatalf("NewDefaultScheduler() error = %v", err)
+	}
+
+	if !scheduler.Pause() {
+		t.Error("Pause() should report a state change")
+	}
+	if scheduler.Pause() {
+		t.Error("Pause() on a paused scheduler should be a no-op")
+	}
+	if !scheduler.IsPaused() {
+		t.Error("IsPaused() should be true after Pause()")
+	}
+
+	if err := scheduler.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer func() { _ = scheduler.Stop(context.Background()) }()
+
+	// Ticks are skipped while paused
+	time.Sleep(1500 * time.Millisecond)
+	if count := collector.GetCallCount(); count != 0 {
+		t.Errorf("Expected no collections while paused, got %d", count)
+	}
+
+	if !scheduler.Resume() {
+		t.Error("Resume() should report a state change")
+	}
+	if scheduler.Resume() {
+		t.Error("Resume() on a running scheduler should be a no-op")
+	}
+
+	time.Sleep(1200 * time.Millisecond)
+	if count := collector.GetCallCount(); count < 1 {
+		t.Errorf("Expected collections after Resume(), got %d", count)
+	}
+}
+
+// mockOverrunRecorder counts recorded overruns.
+type mockOverrunRecorder struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (m *mockOverrunRecorder) RecordSchedulerOverrun() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.count++
+}
+
+func (m *mockOverrunRecorder) Count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.count
+}
+
+func TestDefaultScheduler_Overrun(t *testing.T) {
+	config := &Config{
+		CollectionInterval:      1 * time.Second,
+		GracefulShutdownTimeout: 5 * time.Second,
+		OverrunCooldown:         1 * time.Second,
+	}
+
+	type cycle struct{ start, end time.Time }
+	cycles := make(chan cycle, 4)
+	first := true
+
+	// The first cycle is slower than the interval and runs into its deadline
+	collector := &MockCollector{
+		CollectDeviceDataFunc: func(ctx context.Context) (*CollectionResult, error) {
+			start := time.Now()
+			if first {
+				first = false
+				<-ctx.Done()
+				cycles <- cycle{start, time.Now()}
+				return nil, errors.New("winpower request failed: " + ctx.Err().Error())
+			}
+			cycles <- cycle{start, time.Now()}
+			return &CollectionResult{Success: true}, nil
+		},
+	}
+	logger := &MockLogger{}
+	recorder := &mockOverrunRecorder{}
+
+	scheduler, err := NewDefaultScheduler(config, collector, logger)
+	if err != nil {
+		t.Fatalf("NewDefaultScheduler() error = %v", err)
+	}
+	scheduler.SetOverrunRecorder(recorder)
+
+	if err := scheduler.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer func() { _ = scheduler.Stop(context.Background()) }()
+
+	var got []cycle
+	timeout := time.After(6 * time.Second)
+	for len(got) < 2 {
+		select {
+		case c := <-cycles:
+			got = append(got, c)
+		case <-timeout:
+			t.Fatalf("Expected 2 collection cycles, got %d", len(got))
+		}
+	}
+
+	// The tick missed during the slow cycle is dropped and the cooldown applies
+	if gap := got[1].start.Sub(got[0].end); gap < 1500*time.Millisecond {
+		t.Errorf("Expected next cycle after interval plus cooldown, started %v after overrun", gap)
+	}
+
+	if count := recorder.Count(); count != 1 {
+		t.Errorf("Expected 1 recorded overrun, got %d", count)
+	}
+	if !logger.HasWarnLog("collection cycle exceeded interval, cooling down") {
+		t.Error("Expected overrun warning log")
+	}
+	if logger.HasErrorLog("collection failed") {
+		t.Error("Overrun should not be logged as a collection failure")
+	}
+}
+
+// authenticatingCollector is a MockCollector that also implements
+// Authenticator.
+type authenticatingCollector struct {
+	MockCollector
+	authDelay time.Duration
+	authErr   error
+	authCalls int
+}
+
+func (c *authenticatingCollector) Authenticate(ctx context.Context) error {
+	c.authCalls++
+	time.Sleep(c.authDelay)
+	return c.authErr
+}
+
+func TestDefaultScheduler_AuthenticateBeforeDeadline(t *testing.T) {
+	tests := []struct {
+		name    string
+		authErr error
+	}{
+		{name: "authentication succeeds"},
+		{name: "collection still runs after failed authentication", authErr: errors.New("login failed")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var remaining time.Duration
+			collector := &authenticatingCollector{authDelay: 300 * time.Millisecond, authErr: tt.authErr}
+			collector.CollectDeviceDataFunc = func(ctx context.Context) (*CollectionResult, error) {
+				deadline, _ := ctx.Deadline()
+				remaining = time.Until(deadline)
+				return &CollectionResult{Success: true}, nil
+			}
+
+			scheduler, err := NewDefaultScheduler(DefaultConfig(), collector, &MockLogger{})
+			if err != nil {
+				t.Fatalf("NewDefaultScheduler() error = %v", err)
+			}
+
+			if overrun := scheduler.runCollection(context.Background(), 500*time.Millisecond); overrun {
+				t.Error("runCollection() overrun = true, want false")
+			}
+			if collector.authCalls != 1 || collector.GetCallCount() != 1 {
+				t.Fatalf("Authenticate calls = %d, collections = %d, want 1 each", collector.authCalls, collector.GetCallCount())
+			}
+			// The slow authentication must not have consumed the deadline
+			if remaining < 400*time.Millisecond {
+				t.Errorf("collection started with %v left, want the deadline to start after authentication", remaining)
+			}
+		})
+	}
+}
+
+// mockTickRecorder records the configured interval and observed tick gaps.
+type mockTickRecorder struct {
+	mu       sync.Mutex
+	interval time.Duration
+	gaps     []time.Duration
+}
+
+func (m *mockTickRecorder) SetSchedulerInterval(interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.interval = interval
+}
+
+func (m *mockTickRecorder) ObserveSchedulerTick(gap time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gaps = append(m.gaps, gap)
+}
+
+func (m *mockTickRecorder) Snapshot() (time.Duration, []time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.interval, append([]time.Duration(nil), m.gaps...)
+}
+
+func TestDefaultScheduler_TickRecorder(t *testing.T) {
+	config := &Config{
+		CollectionInterval:      1 * time.Second,
+		GracefulShutdownTimeout: 5 * time.Second,
+	}
+	recorder := &mockTickRecorder{}
+
+	scheduler, err := NewDefaultScheduler(config, &MockCollector{}, &MockLogger{})
+	if err != nil {
+		t.Fatalf("NewDefaultScheduler() error = %v", err)
+	}
+	scheduler.SetTickRecorder(recorder)
+
+	if err := scheduler.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	// Paused ticks are observed as well
+	scheduler.Pause()
+	time.Sleep(2500 * time.Millisecond)
+	if err := scheduler.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	interval, gaps := recorder.Snapshot()
+	if interval != config.CollectionInterval {
+		t.Errorf("Expected configured interval %v, got %v", config.CollectionInterval, interval)
+	}
+	if len(gaps) != 2 {
+		t.Fatalf("Expected 2 observed ticks, got %d", len(gaps))
+	}
+	for _, gap := range gaps {
+		if gap < 900*time.Millisecond || gap > 1500*time.Millisecond {
+			t.Errorf("Expected tick gap close to the interval, got %v", gap)
+		}
+	}
+}
+
+func TestDefaultScheduler_Adaptive(t *testing.T) {
+	config := &Config{
+		CollectionInterval:      1 * time.Second,
+		GracefulShutdownTimeout: 5 * time.Second,
+		Adaptive:                true,
+		AdaptiveMinInterval:     1 * time.Second,
+		AdaptiveMaxInterval:     2 * time.Second,
+		AdaptiveStableCycles:    1,
+		AdaptiveChangePercent:   5,
+	}
+	collector := &MockCollector{
+		CollectDeviceDataFunc: func(ctx context.Context) (*CollectionResult, error) {
+			return &CollectionResult{
+				Success:     true,
+				DeviceCount: 1,
+				DevicePower: map[string]float64{"ups-1": 100},
+			}, nil
+		},
+	}
+	logger := &MockLogger{}
+	recorder := &mockTickRecorder{}
+
+	scheduler, err := NewDefaultScheduler(config, collector, logger)
+	if err != nil {
+		t.Fatalf("NewDefaultScheduler() error = %v", err)
+	}
+	scheduler.SetTickRecorder(recorder)
+
+	if err := scheduler.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	// The stable reading lengthens the interval after the second cycle
+	time.Sleep(4500 * time.Millisecond)
+	if err := scheduler.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	interval, gaps := recorder.Snapshot()
+	if interval != 2*time.Second {
+		t.Errorf("Expected the interval lengthened to 2s, got %v", interval)
+	}
+	if len(gaps) == 0 || gaps[len(gaps)-1] < 1900*time.Millisecond {
+		t.Errorf("Expected the last tick gap at the lengthened interval, got %v", gaps)
+	}
+	if !logger.HasInfoLog("collection interval adjusted") {
+		t.Error("Expected interval adjustment log")
+	}
+}
+
+func TestDefaultScheduler_Warmup(t *testing.T) {
+	config := &Config{
+		CollectionInterval:      3 * time.Second,
+		GracefulShutdownTimeout: 5 * time.Second,
+		WarmupDuration:          2500 * time.Millisecond,
+		WarmupInterval:          1 * time.Second,
+	}
+	collector := &MockCollector{}
+	logger := &MockLogger{}
+	recorder := &mockTickRecorder{}
+
+	scheduler, err := NewDefaultScheduler(config, collector, logger)
+	if err != nil {
+		t.Fatalf("NewDefaultScheduler() error = %v", err)
+	}
+	scheduler.SetTickRecorder(recorder)
+
+	if err := scheduler.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	// Ticks at 1s, 2s and 3s; the warmup ends before the third, after which
+	// the next tick is due at 6s
+	time.Sleep(4500 * time.Millisecond)
+	if err := scheduler.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	interval, gaps := recorder.Snapshot()
+	if interval != config.CollectionInterval {
+		t.Errorf("Expected the interval relaxed to %v, got %v", config.CollectionInterval, interval)
+	}
+	if len(gaps) != 3 {
+		t.Fatalf("Expected 3 ticks at the warmup interval, got %v", gaps)
+	}
+	for _, gap := range gaps {
+		if gap < 900*time.Millisecond || gap > 1500*time.Millisecond {
+			t.Errorf("Expected tick gap close to the warmup interval, got %v", gap)
+		}
+	}
+	if collector.GetCallCount() != 3 {
+		t.Errorf("Expected 3 collections, got %d", collector.GetCallCount())
+	}
+	if !logger.HasInfoLog("warmup finished, relaxing to the regular interval") {
+		t.Error("Expected warmup finished log")
+	}
+}
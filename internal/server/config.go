@@ -1,9 +1,55 @@
 package server
 
 import (
+	"fmt"
+	"net"
+	"sort"
 	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/tlsconfig"
+)
+
+// Route group names that can be assigned to a listener
+const (
+	RouteHealth  = "health"
+	RouteMetrics = "metrics"
+	RoutePprof   = "pprof"
+	RouteInfo    = "info"
+	RouteCollect = "collect"
+	RouteAdmin   = "admin"
+	RouteAPI     = "api"
 )
 
+// knownRoutes lists every route group a listener may serve
+var knownRoutes = map[string]bool{
+	RouteHealth:  true,
+	RouteMetrics: true,
+	RoutePprof:   true,
+	RouteInfo:    true,
+	RouteCollect: true,
+	RouteAdmin:   true,
+	RouteAPI:     true,
+}
+
+// RouteNames returns the route group names a listener may serve, sorted
+func RouteNames() []string {
+	names := make([]string, 0, len(knownRoutes))
+	for route := range knownRoutes {
+		names = append(names, route)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ListenerConfig describes a listener serving a subset of the routes
+type ListenerConfig struct {
+	// Address is the host:port to bind, e.g. "127.0.0.1:9091"
+	Address string `yaml:"address"`
+
+	// Routes lists the route groups served on this listener (health, metrics, pprof, info, collect, admin, api)
+	Routes []string `yaml:"routes"`
+}
+
 // Config holds the configuration for the HTTP server
 type Config struct {
 	// Port is the port number the server listens on (1-65535)
@@ -27,8 +73,57 @@ type Config struct {
 	// EnablePprof enables the /debug/pprof endpoints for profiling
 	EnablePprof bool `yaml:"enable_pprof"`
 
+	// EnableDebugInfo enables the /debug/info endpoint with the startup summary
+	EnableDebugInfo bool `yaml:"enable_debug_info" mapstructure:"enable_debug_info"`
+
+	// EnableDebugCollect enables the /debug/collect endpoint that runs a
+	// one-off collection and returns its per-stage timing breakdown, along
+	// with /debug/devices and /debug/winpower/raw
+	EnableDebugCollect bool `yaml:"enable_debug_collect" mapstructure:"enable_debug_collect"`
+
+	// EnableAdmin enables the /admin endpoints, e.g. pausing and resuming
+	// the collection scheduler. Requires APIToken.
+	EnableAdmin bool `yaml:"enable_admin" mapstructure:"enable_admin"`
+
+	// RecordScrapeDuration records how long the /metrics handler takes to
+	// serve each scrape when the MetricsService implements ScrapeRecorder
+	RecordScrapeDuration bool `yaml:"record_scrape_duration" mapstructure:"record_scrape_duration"`
+
+	// SplitMetricsEndpoints additionally serves the exporter self-monitoring
+	// metrics on /metrics/exporter and the device metrics on
+	// /metrics/devices when the MetricsService implements
+	// CategoryMetricsHandler, so that they can be scraped at different
+	// intervals. /metrics keeps serving all metrics.
+	SplitMetricsEndpoints bool `yaml:"split_metrics_endpoints" mapstructure:"split_metrics_endpoints"`
+
+	// MaxConcurrentAdminOperations limits the /admin requests that change
+	// state (pausing or resuming the scheduler, resetting device energy)
+	// handled at the same time. Requests beyond the limit are rejected with
+	// 409 Conflict; read-only admin requests are not limited (0 = unlimited).
+	MaxConcurrentAdminOperations int `yaml:"max_concurrent_admin_operations" mapstructure:"max_concurrent_admin_operations"`
+
+	// APIToken, when set, is required as a bearer token on the /api and
+	// /admin endpoints. Empty leaves /api unauthenticated.
+	APIToken string `yaml:"api_token" mapstructure:"api_token"`
+
+	// TLSCertFile and TLSKeyFile enable HTTPS on every listener when both are set
+	TLSCertFile string `yaml:"tls_cert_file" mapstructure:"tls_cert_file"`
+	TLSKeyFile  string `yaml:"tls_key_file" mapstructure:"tls_key_file"`
+
+	// TLSMinVersion is the minimum TLS version accepted over HTTPS
+	// ("1.0", "1.1", "1.2" or "1.3"). Empty means "1.2".
+	TLSMinVersion string `yaml:"tls_min_version" mapstructure:"tls_min_version"`
+
+	// TLSCipherSuites restricts the TLS 1.2 cipher suites accepted over
+	// HTTPS, by Go cipher suite name. Empty uses Go's defaults.
+	TLSCipherSuites []string `yaml:"tls_cipher_suites" mapstructure:"tls_cipher_suites"`
+
 	// ShutdownTimeout is the maximum duration to wait for graceful shutdown
 	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" validate:"min=1s"`
+
+	// Listeners optionally replaces the single Host:Port listener with several
+	// listeners, each serving a subset of the routes
+	Listeners []ListenerConfig `yaml:"listeners"`
 }
 
 // DefaultConfig returns the default server configuration
@@ -41,10 +136,19 @@ func DefaultConfig() *Config {
 		WriteTimeout:    10 * time.Second,
 		IdleTimeout:     60 * time.Second,
 		EnablePprof:     false,
+		TLSMinVersion:   tlsconfig.DefaultMinVersion,
 		ShutdownTimeout: 30 * time.Second,
+
+		MaxConcurrentAdminOperations: 1,
+		RecordScrapeDuration:         true,
 	}
 }
 
+// TLSEnabled reports whether the listeners serve HTTPS
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
 // Validate validates the configuration
 func (c *Config) Validate() error {
 	if c.Port < 1 || c.Port > 65535 {
@@ -68,5 +172,30 @@ func (c *Config) Validate() error {
 	if c.ShutdownTimeout < time.Second {
 		return ErrInvalidConfig
 	}
+	if c.MaxConcurrentAdminOperations < 0 {
+		return fmt.Errorf("%w: max_concurrent_admin_operations must be >= 0", ErrInvalidConfig)
+	}
+	if c.EnableAdmin && c.APIToken == "" {
+		return fmt.Errorf("%w: enable_admin requires api_token", ErrInvalidConfig)
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return fmt.Errorf("%w: tls_cert_file and tls_key_file must be set together", ErrInvalidConfig)
+	}
+	if _, err := tlsconfig.New(c.TLSMinVersion, c.TLSCipherSuites); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	for _, l := range c.Listeners {
+		if _, _, err := net.SplitHostPort(l.Address); err != nil {
+			return ErrInvalidConfig
+		}
+		if len(l.Routes) == 0 {
+			return ErrInvalidConfig
+		}
+		for _, route := range l.Routes {
+			if !knownRoutes[route] {
+				return ErrInvalidConfig
+			}
+		}
+	}
 	return nil
 }
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"errors"
+	"strings"
 	"testing"
 	"time"
 )
@@ -176,6 +178,51 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid listeners",
+			config: &Config{
+				Port:            9090,
+				Host:            "0.0.0.0",
+				Mode:            "release",
+				ReadTimeout:     10 * time.Second,
+				WriteTimeout:    10 * time.Second,
+				IdleTimeout:     60 * time.Second,
+				ShutdownTimeout: 30 * time.Second,
+				Listeners: []ListenerConfig{
+					{Address: "0.0.0.0:9090", Routes: []string{RouteMetrics}},
+					{Address: "127.0.0.1:9091", Routes: []string{RouteHealth, RoutePprof}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "listener with invalid address",
+			config: &Config{
+				Port:            9090,
+				Host:            "0.0.0.0",
+				Mode:            "release",
+				ReadTimeout:     10 * time.Second,
+				WriteTimeout:    10 * time.Second,
+				IdleTimeout:     60 * time.Second,
+				ShutdownTimeout: 30 * time.Second,
+				Listeners:       []ListenerConfig{{Address: "9091", Routes: []string{RouteHealth}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "listener with unknown route",
+			config: &Config{
+				Port:            9090,
+				Host:            "0.0.0.0",
+				Mode:            "release",
+				ReadTimeout:     10 * time.Second,
+				WriteTimeout:    10 * time.Second,
+				IdleTimeout:     60 * time.Second,
+				ShutdownTimeout: 30 * time.Second,
+				Listeners:       []ListenerConfig{{Address: ":9091", Routes: []string{"unknown"}}},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -190,3 +237,70 @@ func TestConfig_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_ValidateTLS(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{
+			name: "certificate and key",
+			modify: func(c *Config) {
+				c.TLSCertFile, c.TLSKeyFile = "server.crt", "server.key"
+			},
+		},
+		{
+			name: "restricted cipher suites",
+			modify: func(c *Config) {
+				c.TLSMinVersion = "1.3"
+				c.TLSCipherSuites = []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}
+			},
+		},
+		{
+			name:    "certificate without key",
+			modify:  func(c *Config) { c.TLSCertFile = "server.crt" },
+			wantErr: "tls_key_file",
+		},
+		{
+			name:    "unknown version",
+			modify:  func(c *Config) { c.TLSMinVersion = "1.4" },
+			wantErr: "valid versions: 1.0, 1.1, 1.2, 1.3",
+		},
+		{
+			name:    "unknown cipher suite",
+			modify:  func(c *Config) { c.TLSCipherSuites = []string{"TLS_RSA_WITH_RC4_128_SHA"} },
+			wantErr: "valid cipher suites",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Config.Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Config.Validate() error = %v, want ErrInvalidConfig mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateAdmin(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.EnableAdmin = true
+	if err := cfg.Validate(); !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), "api_token") {
+		t.Errorf("Config.Validate() error = %v, want ErrInvalidConfig mentioning api_token", err)
+	}
+
+	cfg.APIToken = "s3cret"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Config.Validate() error = %v, want nil", err)
+	}
+}
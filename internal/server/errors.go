@@ -1,6 +1,9 @@
 package server
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+)
 
 var (
 	// ErrInvalidConfig indicates the configuration is invalid
@@ -20,4 +23,74 @@ var (
 
 	// ErrLoggerNil indicates the logger is nil
 	ErrLoggerNil = errors.New("logger cannot be nil")
+
+	// ErrSchedulerUnavailable indicates no scheduler controller has been set
+	ErrSchedulerUnavailable = errors.New("scheduler controller not available")
+
+	// ErrEnergyUnavailable indicates no device energy reader has been set
+	ErrEnergyUnavailable = errors.New("device energy reader not available")
+
+	// ErrRawResponseUnavailable indicates no raw WinPower response is retained
+	ErrRawResponseUnavailable = errors.New("raw WinPower response not available")
+
+	// ErrDeviceRequired indicates a request is missing the device parameter
+	ErrDeviceRequired = errors.New("device parameter required")
+
+	// ErrDeviceNotFound indicates the requested device is unknown
+	ErrDeviceNotFound = errors.New("device not found")
+
+	// ErrAdminOperationInProgress indicates the limit of concurrent admin
+	// operations is reached
+	ErrAdminOperationInProgress = errors.New("admin operation in progress")
+
+	// ErrUnauthorized indicates a missing or invalid API token
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrRouteNotFound indicates no endpoint is served at the requested path
+	ErrRouteNotFound = errors.New("route not found")
+
+	// ErrInternal indicates an unexpected failure while handling a request
+	ErrInternal = errors.New("internal server error")
 )
+
+// errorCodes maps the errors returned to API clients to the stable,
+// machine-readable code of their ErrorResponse
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrSchedulerUnavailable, "scheduler_unavailable"},
+	{ErrEnergyUnavailable, "energy_unavailable"},
+	{ErrRawResponseUnavailable, "raw_response_unavailable"},
+	{ErrDeviceRequired, "device_required"},
+	{ErrDeviceNotFound, "device_not_found"},
+	{ErrAdminOperationInProgress, "admin_operation_in_progress"},
+	{ErrUnauthorized, "unauthorized"},
+	{ErrRouteNotFound, "route_not_found"},
+	{ErrInternal, "internal_error"},
+}
+
+// classifyError returns the known error err wraps and its code, or nil and
+// a code derived from the HTTP status for any other error
+func classifyError(err error, status int) (error, string) {
+	for _, known := range errorCodes {
+		if errors.Is(err, known.err) {
+			return known.err, known.code
+		}
+	}
+
+	switch status {
+	case http.StatusBadRequest:
+		return nil, "bad_request"
+	case http.StatusUnauthorized:
+		return nil, "unauthorized"
+	case http.StatusNotFound:
+		return nil, "not_found"
+	case http.StatusConflict:
+		return nil, "conflict"
+	case http.StatusServiceUnavailable:
+		return nil, "unavailable"
+	default:
+		return nil, "internal_error"
+	}
+}
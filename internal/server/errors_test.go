@@ -38,6 +38,11 @@ func TestErrors(t *testing.T) {
 			err:  ErrLoggerNil,
 			want: "logger cannot be nil",
 		},
+		{
+			name: "ErrSchedulerUnavailable",
+			err:  ErrSchedulerUnavailable,
+			want: "scheduler controller not available",
+		},
 	}
 
 	for _, tt := range tests {
@@ -2,6 +2,8 @@ package server
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,12 +23,114 @@ type MetricsService interface {
 	HandleMetrics(c *gin.Context)
 }
 
+// CollectionDebugger is optionally implemented by a MetricsService to serve
+// /debug/collect, which runs a one-off collection with stage timings
+type CollectionDebugger interface {
+	// HandleDebugCollect is the Gin handler for the /debug/collect endpoint
+	HandleDebugCollect(c *gin.Context)
+}
+
+// DeviceStateDebugger is optionally implemented by a MetricsService to serve
+// /debug/devices, which returns the cached last-known data of each device
+type DeviceStateDebugger interface {
+	// HandleDebugDevices is the Gin handler for the /debug/devices endpoint
+	HandleDebugDevices(c *gin.Context)
+}
+
+// ScrapeRecorder is optionally implemented by a MetricsService to record
+// how long the /metrics handler takes to serve a scrape, distinct from the
+// duration of the WinPower requests it makes
+type ScrapeRecorder interface {
+	// ObserveScrapeDuration records the duration of a /metrics request
+	ObserveScrapeDuration(duration time.Duration)
+}
+
+// CategoryMetricsHandler is optionally implemented by a MetricsService that
+// can serve the exporter self-monitoring and the device metrics separately
+type CategoryMetricsHandler interface {
+	// HandleExporterMetrics serves the self-monitoring metrics on /metrics/exporter
+	HandleExporterMetrics(c *gin.Context)
+	// HandleDeviceMetrics serves the device and energy metrics on /metrics/devices
+	HandleDeviceMetrics(c *gin.Context)
+}
+
 // HealthService defines the interface for health check
 type HealthService interface {
 	// Check performs health check and returns status and details
 	Check(ctx context.Context) (status string, details map[string]any)
 }
 
+// ReadinessChecker is optionally implemented by a HealthService to report
+// whether the service is ready to serve traffic on /readyz. Services that
+// do not implement it are considered ready as soon as the server starts.
+type ReadinessChecker interface {
+	// Ready reports readiness and optional details
+	Ready(ctx context.Context) (ready bool, details map[string]any)
+}
+
+// SchedulerController controls the collection scheduler from the /admin endpoints
+type SchedulerController interface {
+	// Pause stops collection until Resume, reporting whether the state changed
+	Pause() bool
+
+	// Resume resumes collection, reporting whether the state changed
+	Resume() bool
+
+	// IsPaused returns whether collection is paused
+	IsPaused() bool
+}
+
+// DeviceEnergy is the accumulated energy of a device served on
+// /api/v1/devices/{id}/energy
+type DeviceEnergy struct {
+	DeviceID  string    `json:"device_id"`
+	EnergyWH  float64   `json:"energy_wh"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// DeviceEnergyReader provides the accumulated energy of a device for the
+// /api endpoints
+type DeviceEnergyReader interface {
+	// GetDeviceEnergy returns the current energy total of a device, or an
+	// error wrapping ErrDeviceNotFound if the device is unknown
+	GetDeviceEnergy(deviceID string) (*DeviceEnergy, error)
+}
+
+// DeviceEnergyReset is the result of resetting a device's energy on
+// /admin/devices/{id}/energy/reset
+type DeviceEnergyReset struct {
+	DeviceID  string    `json:"device_id"`
+	BeforeWH  float64   `json:"before_wh"`
+	AfterWH   float64   `json:"after_wh"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// DeviceEnergyResetter is optionally implemented by a DeviceEnergyReader to
+// serve /admin/devices/{id}/energy/reset
+type DeviceEnergyResetter interface {
+	// ResetDeviceEnergy resets the energy total of a device, or returns an
+	// error wrapping ErrDeviceNotFound if the device is unknown
+	ResetDeviceEnergy(deviceID string) (*DeviceEnergyReset, error)
+}
+
+// RawDeviceResponse is the unparsed WinPower data of a device served on
+// /debug/winpower/raw
+type RawDeviceResponse struct {
+	DeviceID   string          `json:"device_id"`
+	ReceivedAt time.Time       `json:"received_at"`
+	Response   json.RawMessage `json:"response"`
+}
+
+// RawResponseProvider provides the raw WinPower data of a device from the
+// last collection for /debug/winpower/raw
+type RawResponseProvider interface {
+	// RawDeviceResponse returns the device's entry of the last WinPower
+	// response with sensitive fields redacted, an error wrapping
+	// ErrDeviceNotFound if the device is not in it, or an error wrapping
+	// ErrRawResponseUnavailable if no response is retained
+	RawDeviceResponse(deviceID string) (*RawDeviceResponse, error)
+}
+
 // Logger defines the minimal logging interface required by the server
 type Logger interface {
 	// Info logs an informational message
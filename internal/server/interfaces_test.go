@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,6 +22,10 @@ func TestInterfaces(t *testing.T) {
 		var _ HealthService = (*mockHealthService)(nil)
 	})
 
+	t.Run("DeviceEnergyReader interface", func(t *testing.T) {
+		var _ DeviceEnergyReader = (*mockEnergyReader)(nil)
+	})
+
 	t.Run("Logger interface", func(t *testing.T) {
 		var _ Logger = (*mockLogger)(nil)
 	})
@@ -63,6 +68,142 @@ func (m *mockHealthService) Check(ctx context.Context) (string, map[string]any)
 	return m.status, m.details
 }
 
+// mockDebugMetricsService additionally implements CollectionDebugger and
+// DeviceStateDebugger
+type mockDebugMetricsService struct {
+	mockMetricsService
+	collectCalled bool
+	devicesCalled bool
+}
+
+func (m *mockDebugMetricsService) HandleDebugCollect(c *gin.Context) {
+	m.collectCalled = true
+	c.JSON(200, map[string]any{"success": true})
+}
+
+func (m *mockDebugMetricsService) HandleDebugDevices(c *gin.Context) {
+	m.devicesCalled = true
+	c.JSON(200, map[string]any{"device_count": 0})
+}
+
+// mockScrapeMetricsService additionally implements ScrapeRecorder
+type mockScrapeMetricsService struct {
+	mockMetricsService
+	scrapes []time.Duration
+}
+
+func (m *mockScrapeMetricsService) ObserveScrapeDuration(duration time.Duration) {
+	m.scrapes = append(m.scrapes, duration)
+}
+
+// mockCategoryMetricsService additionally implements CategoryMetricsHandler
+// and ScrapeRecorder
+type mockCategoryMetricsService struct {
+	mockScrapeMetricsService
+	exporterCalled bool
+	devicesCalled  bool
+}
+
+func (m *mockCategoryMetricsService) HandleExporterMetrics(c *gin.Context) {
+	m.exporterCalled = true
+	c.String(200, "exporter_metric 1\n")
+}
+
+func (m *mockCategoryMetricsService) HandleDeviceMetrics(c *gin.Context) {
+	m.devicesCalled = true
+	c.String(200, "device_metric 1\n")
+}
+
+// mockSchedulerController implements SchedulerController
+type mockSchedulerController struct {
+	paused bool
+}
+
+func (m *mockSchedulerController) Pause() bool {
+	changed := !m.paused
+	m.paused = true
+	return changed
+}
+
+func (m *mockSchedulerController) Resume() bool {
+	changed := m.paused
+	m.paused = false
+	return changed
+}
+
+func (m *mockSchedulerController) IsPaused() bool {
+	return m.paused
+}
+
+// mockEnergyReader implements DeviceEnergyReader
+type mockEnergyReader struct {
+	devices map[string]*DeviceEnergy
+}
+
+func (m *mockEnergyReader) GetDeviceEnergy(deviceID string) (*DeviceEnergy, error) {
+	energy, ok := m.devices[deviceID]
+	if !ok {
+		return nil, ErrDeviceNotFound
+	}
+	return energy, nil
+}
+
+// mockEnergyResetter additionally implements DeviceEnergyResetter
+type mockEnergyResetter struct {
+	mockEnergyReader
+}
+
+// mockRawResponseProvider serves raw responses keyed by device ID; a nil map
+// means nothing is retained
+type mockRawResponseProvider struct {
+	responses map[string]*RawDeviceResponse
+}
+
+func (m *mockRawResponseProvider) RawDeviceResponse(deviceID string) (*RawDeviceResponse, error) {
+	if m.responses == nil {
+		return nil, ErrRawResponseUnavailable
+	}
+	response, ok := m.responses[deviceID]
+	if !ok {
+		return nil, ErrDeviceNotFound
+	}
+	return response, nil
+}
+
+func (m *mockEnergyResetter) ResetDeviceEnergy(deviceID string) (*DeviceEnergyReset, error) {
+	energy, ok := m.devices[deviceID]
+	if !ok {
+		return nil, ErrDeviceNotFound
+	}
+	result := &DeviceEnergyReset{DeviceID: deviceID, BeforeWH: energy.EnergyWH, AfterWH: 0}
+	energy.EnergyWH = 0
+	return result, nil
+}
+
+// mockBlockingResetter blocks every reset until release is closed,
+// signalling started once a reset is in progress
+type mockBlockingResetter struct {
+	mockEnergyReader
+	started chan struct{}
+	release chan struct{}
+}
+
+func (m *mockBlockingResetter) ResetDeviceEnergy(deviceID string) (*DeviceEnergyReset, error) {
+	m.started <- struct{}{}
+	<-m.release
+	return &DeviceEnergyReset{DeviceID: deviceID}, nil
+}
+
+// mockReadyHealthService additionally implements ReadinessChecker
+type mockReadyHealthService struct {
+	mockHealthService
+	ready bool
+}
+
+func (m *mockReadyHealthService) Ready(ctx context.Context) (bool, map[string]any) {
+	return m.ready, map[string]any{"storage": m.ready}
+}
+
 type mockLogger struct {
 	infoCalled  bool
 	errorCalled bool
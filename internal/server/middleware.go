@@ -1,26 +1,58 @@
 package server
 
 import (
-	"fmt"
+	"crypto/subtle"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// ErrorResponse represents a standard error response
+// ErrorResponse represents the JSON error response of every endpoint other
+// than /metrics
 type ErrorResponse struct {
+	// Error is a short, stable description of the error
 	Error string `json:"error"`
-	Path  string `json:"path"`
-	Time  string `json:"ts"`
+
+	// Code identifies the error for programmatic handling, e.g.
+	// device_not_found
+	Code string `json:"code"`
+
+	// Detail is the full error message when it adds context to Error, such
+	// as the requested device ID
+	Detail string `json:"detail,omitempty"`
+
+	Path string `json:"path"`
+	Time string `json:"ts"`
 }
 
-// NewErrorResponse creates a new error response
+// NewErrorResponse creates a new error response for an internal server error
 func NewErrorResponse(err error, path string) *ErrorResponse {
-	return &ErrorResponse{
+	return newErrorResponse(err, http.StatusInternalServerError, path)
+}
+
+// newErrorResponse creates the error response for err answered with status.
+// Errors wrapping a known error are reported as that error with the full
+// message as detail; unknown errors keep their message.
+func newErrorResponse(err error, status int, path string) *ErrorResponse {
+	resp := &ErrorResponse{
 		Error: err.Error(),
 		Path:  path,
 		Time:  time.Now().Format(time.RFC3339),
 	}
+
+	var known error
+	known, resp.Code = classifyError(err, status)
+	if known != nil && known.Error() != resp.Error {
+		resp.Error, resp.Detail = known.Error(), resp.Error
+	}
+	return resp
+}
+
+// writeError aborts the request with the JSON error response for err
+func writeError(c *gin.Context, status int, err error) {
+	c.AbortWithStatusJSON(status, newErrorResponse(err, status, c.Request.URL.Path))
 }
 
 // loggerMiddleware creates a Gin middleware for structured logging
@@ -57,6 +89,17 @@ func (s *HTTPServer) loggerMiddleware() gin.HandlerFunc {
 	}
 }
 
+// scrapeDurationMiddleware records how long the /metrics handler takes,
+// including an on-scrape collection. A scrape is recorded after its
+// response is written, so it shows up from the next scrape on.
+func scrapeDurationMiddleware(recorder ScrapeRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		recorder.ObserveScrapeDuration(time.Since(start))
+	}
+}
+
 // recoveryMiddleware creates a Gin middleware for panic recovery
 func (s *HTTPServer) recoveryMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -70,16 +113,57 @@ func (s *HTTPServer) recoveryMiddleware() gin.HandlerFunc {
 				)
 
 				// Return error response
-				c.JSON(500, NewErrorResponse(
-					fmt.Errorf("internal server error"),
-					c.Request.URL.Path,
-				))
-
-				// Abort the request
-				c.Abort()
+				writeError(c, http.StatusInternalServerError, ErrInternal)
 			}
 		}()
 
 		c.Next()
 	}
 }
+
+// authMiddleware requires the configured API token as a bearer token. When
+// no token is configured requests pass through unauthenticated.
+func (s *HTTPServer) authMiddleware() gin.HandlerFunc {
+	token := s.cfg.APIToken
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="winpower-exporter"`)
+			writeError(c, http.StatusUnauthorized, ErrUnauthorized)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// adminOperationMiddleware limits the admin operations that change state
+// handled at the same time, rejecting requests beyond the limit with 409
+// Conflict instead of queueing them
+func (s *HTTPServer) adminOperationMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.adminSem == nil {
+			c.Next()
+			return
+		}
+
+		select {
+		case s.adminSem <- struct{}{}:
+			defer func() { <-s.adminSem }()
+		default:
+			s.log.Warn("Rejected admin operation while another is in progress",
+				"path", c.Request.URL.Path,
+				"remote_addr", c.ClientIP(),
+			)
+			writeError(c, http.StatusConflict, ErrAdminOperationInProgress)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"errors"
+	"fmt"
 	"net/http/httptest"
 	"testing"
 
@@ -80,4 +82,35 @@ func TestErrorResponse(t *testing.T) {
 			t.Error("Expected timestamp to be set")
 		}
 	})
+
+	t.Run("wrapped known error is reported with detail", func(t *testing.T) {
+		resp := newErrorResponse(fmt.Errorf("%w: ups-9", ErrDeviceNotFound), 404, "/api/v1/devices/ups-9/energy")
+
+		if resp.Error != "device not found" {
+			t.Errorf("Expected error %q, got %q", "device not found", resp.Error)
+		}
+		if resp.Code != "device_not_found" {
+			t.Errorf("Expected code device_not_found, got %q", resp.Code)
+		}
+		if resp.Detail != "device not found: ups-9" {
+			t.Errorf("Expected detail with device ID, got %q", resp.Detail)
+		}
+	})
+
+	t.Run("unknown error code follows status", func(t *testing.T) {
+		tests := []struct {
+			status int
+			code   string
+		}{
+			{400, "bad_request"},
+			{503, "unavailable"},
+			{500, "internal_error"},
+		}
+		for _, tt := range tests {
+			resp := newErrorResponse(errors.New("boom"), tt.status, "/test")
+			if resp.Code != tt.code || resp.Error != "boom" || resp.Detail != "" {
+				t.Errorf("status %d: unexpected response %+v", tt.status, resp)
+			}
+		}
+	})
 }
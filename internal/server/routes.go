@@ -1,25 +1,81 @@
 package server
 
 import (
+	"errors"
+	"net/http"
 	"net/http/pprof"
 
 	"github.com/gin-gonic/gin"
 )
 
-// setupRoutes configures all HTTP routes
-func (s *HTTPServer) setupRoutes() {
+// allRoutes returns the route set served by the default listener
+func allRoutes() map[string]bool {
+	routes := make(map[string]bool, len(knownRoutes))
+	for route := range knownRoutes {
+		routes[route] = true
+	}
+	return routes
+}
+
+// setupRoutes configures the given route groups on an engine
+func (s *HTTPServer) setupRoutes(engine *gin.Engine, routes map[string]bool) {
 	// Health check endpoint
-	s.engine.GET("/health", s.handleHealth)
+	if routes[RouteHealth] {
+		engine.GET("/health", s.handleHealth)
+		engine.GET("/readyz", s.handleReady)
+	}
 
 	// Metrics endpoint - delegate to metrics service
-	s.engine.GET("/metrics", s.metrics.HandleMetrics)
+	if routes[RouteMetrics] {
+		var middleware []gin.HandlerFunc
+		if recorder, ok := s.metrics.(ScrapeRecorder); ok && s.cfg.RecordScrapeDuration {
+			middleware = append(middleware, scrapeDurationMiddleware(recorder))
+		}
+		handlers := func(handler gin.HandlerFunc) []gin.HandlerFunc {
+			return append(append([]gin.HandlerFunc{}, middleware...), handler)
+		}
+		engine.GET("/metrics", handlers(s.metrics.HandleMetrics)...)
+
+		// Optional per-category endpoints over the same registry, measured
+		// like /metrics
+		if handler, ok := s.metrics.(CategoryMetricsHandler); ok && s.cfg.SplitMetricsEndpoints {
+			engine.GET("/metrics/exporter", handlers(handler.HandleExporterMetrics)...)
+			engine.GET("/metrics/devices", handlers(handler.HandleDeviceMetrics)...)
+		}
+	}
 
 	// 404 handler
-	s.engine.NoRoute(s.handleNotFound)
+	engine.NoRoute(s.handleNotFound)
 
 	// Optional pprof endpoints
-	if s.cfg.EnablePprof {
-		s.setupPprofRoutes()
+	if routes[RoutePprof] && s.cfg.EnablePprof {
+		s.setupPprofRoutes(engine)
+	}
+
+	// Optional startup summary endpoint
+	if routes[RouteInfo] && s.cfg.EnableDebugInfo {
+		engine.GET("/debug/info", s.handleDebugInfo)
+	}
+
+	// Optional one-off collection and cached device state endpoints
+	if routes[RouteCollect] && s.cfg.EnableDebugCollect {
+		if debugger, ok := s.metrics.(CollectionDebugger); ok {
+			engine.GET("/debug/collect", debugger.HandleDebugCollect)
+		}
+		if debugger, ok := s.metrics.(DeviceStateDebugger); ok {
+			engine.GET("/debug/devices", debugger.HandleDebugDevices)
+		}
+		engine.GET("/debug/winpower/raw", s.handleRawResponse)
+	}
+
+	// Optional admin endpoints
+	if routes[RouteAdmin] && s.cfg.EnableAdmin {
+		s.setupAdminRoutes(engine)
+	}
+
+	// Device query API
+	if routes[RouteAPI] {
+		s.setupAPIRoutes(engine)
 	}
 }
 
@@ -42,17 +98,202 @@ func (s *HTTPServer) handleHealth(c *gin.Context) {
 	c.JSON(httpStatus, response)
 }
 
+// handleReady handles readiness check requests
+func (s *HTTPServer) handleReady(c *gin.Context) {
+	ready, details := true, map[string]any{}
+	if rc, ok := s.health.(ReadinessChecker); ok {
+		ready, details = rc.Ready(c.Request.Context())
+	}
+
+	httpStatus, status := 200, "ready"
+	if !ready {
+		httpStatus, status = 503, "not_ready"
+	}
+
+	c.JSON(httpStatus, map[string]any{
+		"status":  status,
+		"details": details,
+	})
+}
+
+// handleDebugInfo serves the startup summary set via SetDebugInfo
+func (s *HTTPServer) handleDebugInfo(c *gin.Context) {
+	s.infoMu.RLock()
+	info := s.debugInfo
+	s.infoMu.RUnlock()
+
+	if info == nil {
+		info = map[string]any{}
+	}
+	c.JSON(200, info)
+}
+
+// handleRawResponse serves a device's entry of the last WinPower response
+func (s *HTTPServer) handleRawResponse(c *gin.Context) {
+	s.rawMu.RLock()
+	provider := s.rawResponse
+	s.rawMu.RUnlock()
+
+	if provider == nil {
+		writeError(c, http.StatusServiceUnavailable, ErrRawResponseUnavailable)
+		return
+	}
+
+	deviceID := c.Query("device")
+	if deviceID == "" {
+		writeError(c, http.StatusBadRequest, ErrDeviceRequired)
+		return
+	}
+
+	response, err := provider.RawDeviceResponse(deviceID)
+	switch {
+	case errors.Is(err, ErrDeviceNotFound):
+		writeError(c, http.StatusNotFound, err)
+		return
+	case errors.Is(err, ErrRawResponseUnavailable):
+		writeError(c, http.StatusServiceUnavailable, err)
+		return
+	case err != nil:
+		s.log.Error("Failed to read raw WinPower response", "device_id", deviceID, "error", err)
+		writeError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(200, response)
+}
+
+// setupAdminRoutes sets up the scheduler and device admin routes
+func (s *HTTPServer) setupAdminRoutes(engine *gin.Engine) {
+	adminGroup := engine.Group("/admin", s.authMiddleware())
+	{
+		adminGroup.GET("/scheduler", s.handleSchedulerStatus)
+		adminGroup.POST("/scheduler/pause", s.adminOperationMiddleware(), s.handleSchedulerPause)
+		adminGroup.POST("/scheduler/resume", s.adminOperationMiddleware(), s.handleSchedulerResume)
+		adminGroup.POST("/devices/:id/energy/reset", s.adminOperationMiddleware(), s.handleDeviceEnergyReset)
+	}
+
+	s.log.Info("Admin endpoints enabled", "prefix", "/admin")
+}
+
+// schedulerController returns the scheduler set via SetSchedulerController,
+// responding with 503 when none is available
+func (s *HTTPServer) schedulerController(c *gin.Context) (SchedulerController, bool) {
+	s.schedulerMu.RLock()
+	ctrl := s.scheduler
+	s.schedulerMu.RUnlock()
+
+	if ctrl == nil {
+		writeError(c, http.StatusServiceUnavailable, ErrSchedulerUnavailable)
+		return nil, false
+	}
+	return ctrl, true
+}
+
+// handleSchedulerStatus reports whether the scheduler is paused
+func (s *HTTPServer) handleSchedulerStatus(c *gin.Context) {
+	ctrl, ok := s.schedulerController(c)
+	if !ok {
+		return
+	}
+	c.JSON(200, map[string]any{"paused": ctrl.IsPaused()})
+}
+
+// handleSchedulerPause pauses the scheduler
+func (s *HTTPServer) handleSchedulerPause(c *gin.Context) {
+	ctrl, ok := s.schedulerController(c)
+	if !ok {
+		return
+	}
+	changed := ctrl.Pause()
+	s.log.Info("Scheduler pause requested", "remote_addr", c.ClientIP(), "changed", changed)
+	c.JSON(200, map[string]any{"paused": true, "changed": changed})
+}
+
+// handleSchedulerResume resumes the scheduler
+func (s *HTTPServer) handleSchedulerResume(c *gin.Context) {
+	ctrl, ok := s.schedulerController(c)
+	if !ok {
+		return
+	}
+	changed := ctrl.Resume()
+	s.log.Info("Scheduler resume requested", "remote_addr", c.ClientIP(), "changed", changed)
+	c.JSON(200, map[string]any{"paused": false, "changed": changed})
+}
+
+// handleDeviceEnergyReset resets the accumulated energy of a single device
+func (s *HTTPServer) handleDeviceEnergyReset(c *gin.Context) {
+	s.energyMu.RLock()
+	resetter, ok := s.energy.(DeviceEnergyResetter)
+	s.energyMu.RUnlock()
+
+	if !ok {
+		writeError(c, http.StatusServiceUnavailable, ErrEnergyUnavailable)
+		return
+	}
+
+	deviceID := c.Param("id")
+	result, err := resetter.ResetDeviceEnergy(deviceID)
+	if errors.Is(err, ErrDeviceNotFound) {
+		writeError(c, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		s.log.Error("Failed to reset device energy", "device_id", deviceID, "remote_addr", c.ClientIP(), "error", err)
+		writeError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.log.Info("Device energy reset requested",
+		"device_id", deviceID,
+		"remote_addr", c.ClientIP(),
+		"before_wh", result.BeforeWH,
+		"after_wh", result.AfterWH,
+	)
+	c.JSON(200, result)
+}
+
+// setupAPIRoutes sets up the device query API routes
+func (s *HTTPServer) setupAPIRoutes(engine *gin.Engine) {
+	apiGroup := engine.Group("/api/v1", s.authMiddleware())
+	{
+		apiGroup.GET("/devices/:id/energy", s.handleDeviceEnergy)
+	}
+}
+
+// handleDeviceEnergy returns the accumulated energy of a single device
+func (s *HTTPServer) handleDeviceEnergy(c *gin.Context) {
+	s.energyMu.RLock()
+	reader := s.energy
+	s.energyMu.RUnlock()
+
+	if reader == nil {
+		writeError(c, http.StatusServiceUnavailable, ErrEnergyUnavailable)
+		return
+	}
+
+	deviceID := c.Param("id")
+	energy, err := reader.GetDeviceEnergy(deviceID)
+	if errors.Is(err, ErrDeviceNotFound) {
+		writeError(c, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		s.log.Error("Failed to read device energy", "device_id", deviceID, "error", err)
+		writeError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(200, energy)
+}
+
 // handleNotFound handles 404 errors
 func (s *HTTPServer) handleNotFound(c *gin.Context) {
-	c.JSON(404, NewErrorResponse(
-		ErrServerNotStarted, // placeholder error
-		c.Request.URL.Path,
-	))
+	writeError(c, http.StatusNotFound, ErrRouteNotFound)
 }
 
 // setupPprofRoutes sets up pprof profiling routes
-func (s *HTTPServer) setupPprofRoutes() {
-	pprofGroup := s.engine.Group("/debug/pprof")
+func (s *HTTPServer) setupPprofRoutes(engine *gin.Engine) {
+	pprofGroup := engine.Group("/debug/pprof")
 	{
 		pprofGroup.GET("/", gin.WrapF(pprof.Index))
 		pprofGroup.GET("/cmdline", gin.WrapF(pprof.Cmdline))
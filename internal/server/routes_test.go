@@ -2,8 +2,12 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -68,6 +72,46 @@ func TestRoutes(t *testing.T) {
 		}
 	})
 
+	t.Run("handleReady without readiness checker", func(t *testing.T) {
+		srv, err := NewHTTPServer(DefaultConfig(), &mockLogger{}, &mockMetricsService{}, &mockHealthService{})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+
+		req := httptest.NewRequest("GET", "/readyz", nil)
+		w := httptest.NewRecorder()
+		srv.engine.ServeHTTP(w, req)
+
+		if w.Code != 200 {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("handleReady reflects readiness checker", func(t *testing.T) {
+		health := &mockReadyHealthService{}
+		srv, err := NewHTTPServer(DefaultConfig(), &mockLogger{}, &mockMetricsService{}, health)
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+
+		req := httptest.NewRequest("GET", "/readyz", nil)
+		w := httptest.NewRecorder()
+		srv.engine.ServeHTTP(w, req)
+		if w.Code != 503 {
+			t.Errorf("Expected status 503 before ready, got %d", w.Code)
+		}
+		if !strings.Contains(w.Body.String(), "not_ready") {
+			t.Errorf("Expected not_ready status, got %s", w.Body.String())
+		}
+
+		health.ready = true
+		w = httptest.NewRecorder()
+		srv.engine.ServeHTTP(w, req)
+		if w.Code != 200 {
+			t.Errorf("Expected status 200 once ready, got %d", w.Code)
+		}
+	})
+
 	t.Run("handleNotFound returns 404", func(t *testing.T) {
 		cfg := DefaultConfig()
 		mockLog := &mockLogger{}
@@ -159,4 +203,483 @@ func TestRoutes(t *testing.T) {
 			}
 		}
 	})
+	t.Run("debug info endpoint serves configured summary", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.EnableDebugInfo = true
+		srv, err := NewHTTPServer(cfg, &mockLogger{}, &mockMetricsService{}, &mockHealthService{})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+		srv.SetDebugInfo(map[string]any{"pprof": false})
+
+		req := httptest.NewRequest("GET", "/debug/info", nil)
+		w := httptest.NewRecorder()
+		srv.engine.ServeHTTP(w, req)
+
+		if w.Code != 200 {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+		if !strings.Contains(w.Body.String(), `"pprof":false`) {
+			t.Errorf("Unexpected body: %s", w.Body.String())
+		}
+	})
+
+	t.Run("debug info endpoint disabled by default", func(t *testing.T) {
+		srv, err := NewHTTPServer(DefaultConfig(), &mockLogger{}, &mockMetricsService{}, &mockHealthService{})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+
+		req := httptest.NewRequest("GET", "/debug/info", nil)
+		w := httptest.NewRecorder()
+		srv.engine.ServeHTTP(w, req)
+
+		if w.Code != 404 {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("metrics endpoint records scrape duration", func(t *testing.T) {
+		metrics := &mockScrapeMetricsService{}
+		metrics.handleMetricsFunc = func(c *gin.Context) {
+			time.Sleep(5 * time.Millisecond)
+			c.String(200, "test_metric 1\n")
+		}
+		srv, err := NewHTTPServer(DefaultConfig(), &mockLogger{}, metrics, &mockHealthService{})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+
+		w := httptest.NewRecorder()
+		srv.engine.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+		if w.Code != 200 {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+		if len(metrics.scrapes) != 1 {
+			t.Fatalf("Expected 1 recorded scrape, got %d", len(metrics.scrapes))
+		}
+		if metrics.scrapes[0] < 5*time.Millisecond {
+			t.Errorf("Expected scrape duration of at least 5ms, got %v", metrics.scrapes[0])
+		}
+	})
+
+	t.Run("scrape duration recording disabled", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.RecordScrapeDuration = false
+		metrics := &mockScrapeMetricsService{}
+		srv, err := NewHTTPServer(cfg, &mockLogger{}, metrics, &mockHealthService{})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+
+		w := httptest.NewRecorder()
+		srv.engine.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+		if !metrics.handleMetricsCalled {
+			t.Error("Expected HandleMetrics to be called")
+		}
+		if len(metrics.scrapes) != 0 {
+			t.Errorf("Expected no recorded scrape, got %d", len(metrics.scrapes))
+		}
+	})
+
+	t.Run("split metrics endpoints", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.SplitMetricsEndpoints = true
+		metrics := &mockCategoryMetricsService{}
+		srv, err := NewHTTPServer(cfg, &mockLogger{}, metrics, &mockHealthService{})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+
+		for _, path := range []string{"/metrics/exporter", "/metrics/devices", "/metrics"} {
+			w := httptest.NewRecorder()
+			srv.engine.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+			if w.Code != 200 {
+				t.Errorf("Expected status 200 for %s, got %d", path, w.Code)
+			}
+		}
+		if !metrics.exporterCalled || !metrics.devicesCalled || !metrics.handleMetricsCalled {
+			t.Errorf("Expected every metrics handler to be called, got exporter=%v devices=%v combined=%v",
+				metrics.exporterCalled, metrics.devicesCalled, metrics.handleMetricsCalled)
+		}
+		if len(metrics.scrapes) != 3 {
+			t.Errorf("Expected a recorded scrape per metrics endpoint, got %d", len(metrics.scrapes))
+		}
+	})
+
+	t.Run("split metrics endpoints disabled by default", func(t *testing.T) {
+		metrics := &mockCategoryMetricsService{}
+		srv, err := NewHTTPServer(DefaultConfig(), &mockLogger{}, metrics, &mockHealthService{})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+
+		w := httptest.NewRecorder()
+		srv.engine.ServeHTTP(w, httptest.NewRequest("GET", "/metrics/devices", nil))
+		if w.Code != 404 {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("debug collect endpoint", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.EnableDebugCollect = true
+		metrics := &mockDebugMetricsService{}
+		srv, err := NewHTTPServer(cfg, &mockLogger{}, metrics, &mockHealthService{})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+
+		req := httptest.NewRequest("GET", "/debug/collect", nil)
+		w := httptest.NewRecorder()
+		srv.engine.ServeHTTP(w, req)
+
+		if w.Code != 200 {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+		if !metrics.collectCalled {
+			t.Error("Expected HandleDebugCollect to be called")
+		}
+	})
+
+	t.Run("debug devices endpoint", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.EnableDebugCollect = true
+		metrics := &mockDebugMetricsService{}
+		srv, err := NewHTTPServer(cfg, &mockLogger{}, metrics, &mockHealthService{})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+
+		req := httptest.NewRequest("GET", "/debug/devices", nil)
+		w := httptest.NewRecorder()
+		srv.engine.ServeHTTP(w, req)
+
+		if w.Code != 200 {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+		if !metrics.devicesCalled {
+			t.Error("Expected HandleDebugDevices to be called")
+		}
+	})
+
+	t.Run("debug raw WinPower response endpoint", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.EnableDebugCollect = true
+		srv, err := NewHTTPServer(cfg, &mockLogger{}, &mockMetricsService{}, &mockHealthService{})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+
+		serve := func(path string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			srv.engine.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+			return w
+		}
+
+		// No provider set yet
+		if w := serve("/debug/winpower/raw?device=ups-1"); w.Code != 503 {
+			t.Errorf("Expected status 503 without provider, got %d", w.Code)
+		}
+
+		// Nothing retained yet
+		provider := &mockRawResponseProvider{}
+		srv.SetRawResponseProvider(provider)
+		if w := serve("/debug/winpower/raw?device=ups-1"); w.Code != 503 {
+			t.Errorf("Expected status 503 without retained response, got %d", w.Code)
+		}
+
+		provider.responses = map[string]*RawDeviceResponse{
+			"ups-1": {DeviceID: "ups-1", Response: json.RawMessage(`{"assetDevice":{"id":"ups-1"},"realtime":{"inputVolt1":"NaN"}}`)},
+		}
+
+		if w := serve("/debug/winpower/raw"); w.Code != 400 {
+			t.Errorf("Expected status 400 without device, got %d", w.Code)
+		}
+		if w := serve("/debug/winpower/raw?device=unknown"); w.Code != 404 {
+			t.Errorf("Expected status 404 for unknown device, got %d", w.Code)
+		}
+
+		w := serve("/debug/winpower/raw?device=ups-1")
+		if w.Code != 200 {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if !strings.Contains(w.Body.String(), `"response":{"assetDevice":{"id":"ups-1"},"realtime":{"inputVolt1":"NaN"}}`) {
+			t.Errorf("Expected the raw fragment in the response, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("debug collect endpoint disabled by default", func(t *testing.T) {
+		metrics := &mockDebugMetricsService{}
+		srv, err := NewHTTPServer(DefaultConfig(), &mockLogger{}, metrics, &mockHealthService{})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+		srv.SetRawResponseProvider(&mockRawResponseProvider{})
+
+		for _, path := range []string{"/debug/collect", "/debug/devices", "/debug/winpower/raw?device=ups-1"} {
+			req := httptest.NewRequest("GET", path, nil)
+			w := httptest.NewRecorder()
+			srv.engine.ServeHTTP(w, req)
+
+			if w.Code != 404 {
+				t.Errorf("Expected status 404 for %s, got %d", path, w.Code)
+			}
+		}
+	})
+
+	t.Run("admin scheduler endpoints", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.EnableAdmin = true
+		cfg.APIToken = "s3cret"
+		srv, err := NewHTTPServer(cfg, &mockLogger{}, &mockMetricsService{}, &mockHealthService{})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+
+		serve := func(method, path string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, nil)
+			req.Header.Set("Authorization", "Bearer s3cret")
+			w := httptest.NewRecorder()
+			srv.engine.ServeHTTP(w, req)
+			return w
+		}
+
+		// No controller set yet
+		if w := serve("POST", "/admin/scheduler/pause"); w.Code != 503 {
+			t.Errorf("Expected status 503 without controller, got %d", w.Code)
+		}
+
+		ctrl := &mockSchedulerController{}
+		srv.SetSchedulerController(ctrl)
+
+		// Scheduler endpoints share the admin token
+		for _, path := range []string{"/admin/scheduler/pause", "/admin/scheduler/resume"} {
+			w := httptest.NewRecorder()
+			srv.engine.ServeHTTP(w, httptest.NewRequest("POST", path, nil))
+			if w.Code != 401 || ctrl.paused {
+				t.Errorf("Expected status 401 without token for %s, got %d", path, w.Code)
+			}
+		}
+
+		if w := serve("POST", "/admin/scheduler/pause"); w.Code != 200 || !ctrl.paused {
+			t.Errorf("Expected pause to succeed, got %d (paused=%v)", w.Code, ctrl.paused)
+		}
+		if w := serve("GET", "/admin/scheduler"); !strings.Contains(w.Body.String(), `"paused":true`) {
+			t.Errorf("Unexpected status body: %s", w.Body.String())
+		}
+		if w := serve("POST", "/admin/scheduler/resume"); w.Code != 200 || ctrl.paused {
+			t.Errorf("Expected resume to succeed, got %d (paused=%v)", w.Code, ctrl.paused)
+		}
+	})
+
+	t.Run("admin device energy reset endpoint", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.EnableAdmin = true
+		cfg.APIToken = "s3cret"
+		logger := &mockLogger{}
+		srv, err := NewHTTPServer(cfg, logger, &mockMetricsService{}, &mockHealthService{})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+
+		serve := func(path, token string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", path, nil)
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			w := httptest.NewRecorder()
+			srv.engine.ServeHTTP(w, req)
+			return w
+		}
+
+		// A reader without reset support is not enough
+		srv.SetEnergyReader(&mockEnergyReader{})
+		if w := serve("/admin/devices/ups-1/energy/reset", "s3cret"); w.Code != 503 {
+			t.Errorf("Expected status 503 without resetter, got %d", w.Code)
+		}
+
+		srv.SetEnergyReader(&mockEnergyResetter{mockEnergyReader{devices: map[string]*DeviceEnergy{
+			"ups-1": {DeviceID: "ups-1", EnergyWH: 1234.5},
+		}}})
+
+		if w := serve("/admin/devices/ups-1/energy/reset", ""); w.Code != 401 {
+			t.Errorf("Expected status 401 without token, got %d", w.Code)
+		}
+
+		w := serve("/admin/devices/ups-1/energy/reset", "s3cret")
+		if w.Code != 200 {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var got DeviceEnergyReset
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if got.DeviceID != "ups-1" || got.BeforeWH != 1234.5 || got.AfterWH != 0 {
+			t.Errorf("Unexpected response: %+v", got)
+		}
+		if !slices.Contains(logger.messages, "Device energy reset requested") {
+			t.Errorf("Expected reset to be audited, got %v", logger.messages)
+		}
+
+		if w := serve("/admin/devices/unknown/energy/reset", "s3cret"); w.Code != 404 {
+			t.Errorf("Expected status 404 for unknown device, got %d", w.Code)
+		}
+	})
+
+	t.Run("concurrent admin operations are rejected", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.EnableAdmin = true
+		cfg.APIToken = "s3cret"
+		srv, err := NewHTTPServer(cfg, &mockLogger{}, &mockMetricsService{}, &mockHealthService{})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+		ctrl := &mockSchedulerController{}
+		srv.SetSchedulerController(ctrl)
+		resetter := &mockBlockingResetter{started: make(chan struct{}), release: make(chan struct{})}
+		srv.SetEnergyReader(resetter)
+
+		serve := func(method, path string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, nil)
+			req.Header.Set("Authorization", "Bearer s3cret")
+			w := httptest.NewRecorder()
+			srv.engine.ServeHTTP(w, req)
+			return w
+		}
+
+		done := make(chan int)
+		go func() {
+			done <- serve("POST", "/admin/devices/ups-1/energy/reset").Code
+		}()
+		<-resetter.started
+
+		w := serve("POST", "/admin/devices/ups-1/energy/reset")
+		if w.Code != 409 || !strings.Contains(w.Body.String(), "admin_operation_in_progress") {
+			t.Errorf("Expected status 409 during a reset, got %d: %s", w.Code, w.Body.String())
+		}
+		if w := serve("POST", "/admin/scheduler/pause"); w.Code != 409 || ctrl.paused {
+			t.Errorf("Expected pause to be rejected during a reset, got %d", w.Code)
+		}
+		// Read-only admin endpoints are not limited
+		if w := serve("GET", "/admin/scheduler"); w.Code != 200 {
+			t.Errorf("Expected status 200 for the scheduler status, got %d", w.Code)
+		}
+
+		close(resetter.release)
+		if code := <-done; code != 200 {
+			t.Errorf("Expected the first reset to succeed, got %d", code)
+		}
+		if w := serve("POST", "/admin/scheduler/pause"); w.Code != 200 {
+			t.Errorf("Expected pause to succeed after the reset, got %d", w.Code)
+		}
+	})
+
+	t.Run("admin endpoints disabled by default", func(t *testing.T) {
+		srv, err := NewHTTPServer(DefaultConfig(), &mockLogger{}, &mockMetricsService{}, &mockHealthService{})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+		srv.SetSchedulerController(&mockSchedulerController{})
+
+		w := httptest.NewRecorder()
+		srv.engine.ServeHTTP(w, httptest.NewRequest("POST", "/admin/scheduler/pause", nil))
+		if w.Code != 404 {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("device energy endpoint", func(t *testing.T) {
+		srv, err := NewHTTPServer(DefaultConfig(), &mockLogger{}, &mockMetricsService{}, &mockHealthService{})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+
+		serve := func(path string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			srv.engine.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+			return w
+		}
+
+		// No reader set yet
+		if w := serve("/api/v1/devices/ups-1/energy"); w.Code != 503 {
+			t.Errorf("Expected status 503 without reader, got %d", w.Code)
+		}
+
+		ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+		srv.SetEnergyReader(&mockEnergyReader{devices: map[string]*DeviceEnergy{
+			"ups-1": {DeviceID: "ups-1", EnergyWH: 1234.5, Timestamp: ts},
+		}})
+
+		w := serve("/api/v1/devices/ups-1/energy")
+		if w.Code != 200 {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var got DeviceEnergy
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if got.DeviceID != "ups-1" || got.EnergyWH != 1234.5 || !got.Timestamp.Equal(ts) {
+			t.Errorf("Unexpected response: %+v", got)
+		}
+
+		if w := serve("/api/v1/devices/unknown/energy"); w.Code != 404 {
+			t.Errorf("Expected status 404 for unknown device, got %d", w.Code)
+		}
+
+		// Errors are structured JSON
+		w = serve("/api/v1/devices/unknown/energy")
+		var errResp ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &errResp); err != nil {
+			t.Fatalf("Failed to decode error response: %v", err)
+		}
+		if errResp.Code != "device_not_found" || errResp.Error != "device not found" {
+			t.Errorf("Unexpected error response: %+v", errResp)
+		}
+	})
+
+	t.Run("device energy endpoint requires API token", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.APIToken = "s3cret"
+		srv, err := NewHTTPServer(cfg, &mockLogger{}, &mockMetricsService{}, &mockHealthService{})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+		srv.SetEnergyReader(&mockEnergyReader{devices: map[string]*DeviceEnergy{
+			"ups-1": {DeviceID: "ups-1", EnergyWH: 1},
+		}})
+
+		tests := []struct {
+			name   string
+			header string
+			want   int
+		}{
+			{name: "missing token", header: "", want: 401},
+			{name: "wrong token", header: "Bearer wrong", want: 401},
+			{name: "wrong scheme", header: "Basic s3cret", want: 401},
+			{name: "valid token", header: "Bearer s3cret", want: 200},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				req := httptest.NewRequest("GET", "/api/v1/devices/ups-1/energy", nil)
+				if tt.header != "" {
+					req.Header.Set("Authorization", tt.header)
+				}
+				w := httptest.NewRecorder()
+				srv.engine.ServeHTTP(w, req)
+				if w.Code != tt.want {
+					t.Errorf("Expected status %d, got %d", tt.want, w.Code)
+				}
+			})
+		}
+
+		// Other endpoints are not affected by the API token
+		w := httptest.NewRecorder()
+		srv.engine.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+		if w.Code != 200 {
+			t.Errorf("Expected /health to stay unauthenticated, got %d", w.Code)
+		}
+	})
 }
@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/tlsconfig"
 )
 
 // HTTPServer implements the Server interface using Gin framework
@@ -15,10 +16,29 @@ type HTTPServer struct {
 	cfg     *Config
 	log     Logger
 	engine  *gin.Engine
-	srv     *http.Server
+	servers []*http.Server
 	metrics MetricsService
 	health  HealthService
 
+	// Startup summary served on /debug/info
+	infoMu    sync.RWMutex
+	debugInfo any
+
+	// Scheduler controlled by the /admin endpoints
+	schedulerMu sync.RWMutex
+	scheduler   SchedulerController
+
+	// Admin operations in progress, nil when unlimited
+	adminSem chan struct{}
+
+	// Device energy served on the /api endpoints
+	energyMu sync.RWMutex
+	energy   DeviceEnergyReader
+
+	// Raw WinPower responses served on /debug/winpower/raw
+	rawMu       sync.RWMutex
+	rawResponse RawResponseProvider
+
 	// Server state management
 	mu      sync.Mutex
 	running bool
@@ -51,32 +71,34 @@ func NewHTTPServer(
 	// Set Gin mode
 	gin.SetMode(config.Mode)
 
-	// Create Gin engine without default middleware
-	engine := gin.New()
-
 	// Create server instance
 	server := &HTTPServer{
 		cfg:     config,
 		log:     log,
-		engine:  engine,
 		metrics: metrics,
 		health:  health,
 		running: false,
 	}
+	if config.MaxConcurrentAdminOperations > 0 {
+		server.adminSem = make(chan struct{}, config.MaxConcurrentAdminOperations)
+	}
 
-	// Setup middleware
-	server.setupGlobalMiddleware()
-
-	// Setup routes
-	server.setupRoutes()
+	// The primary engine serves every route
+	server.engine = server.newEngine(allRoutes())
 
-	// Create http.Server
-	server.srv = &http.Server{
-		Addr:         fmt.Sprintf("%s:%d", config.Host, config.Port),
-		Handler:      engine,
-		ReadTimeout:  config.ReadTimeout,
-		WriteTimeout: config.WriteTimeout,
-		IdleTimeout:  config.IdleTimeout,
+	if len(config.Listeners) == 0 {
+		// Single listener on Host:Port (default)
+		addr := fmt.Sprintf("%s:%d", config.Host, config.Port)
+		server.servers = []*http.Server{server.newHTTPServer(addr, server.engine)}
+	} else {
+		// One listener per configured address, each with its own route subset
+		for _, l := range config.Listeners {
+			routes := make(map[string]bool, len(l.Routes))
+			for _, route := range l.Routes {
+				routes[route] = true
+			}
+			server.servers = append(server.servers, server.newHTTPServer(l.Address, server.newEngine(routes)))
+		}
 	}
 
 	log.Info("HTTP server initialized",
@@ -84,11 +106,81 @@ func NewHTTPServer(
 		"port", config.Port,
 		"mode", config.Mode,
 		"pprof_enabled", config.EnablePprof,
+		"debug_info_enabled", config.EnableDebugInfo,
+		"debug_collect_enabled", config.EnableDebugCollect,
+		"admin_enabled", config.EnableAdmin,
+		"api_auth_enabled", config.APIToken != "",
+		"tls_enabled", config.TLSEnabled(),
+		"listeners", len(server.servers),
 	)
 
 	return server, nil
 }
 
+// SetDebugInfo sets the value served as JSON on /debug/info
+func (s *HTTPServer) SetDebugInfo(info any) {
+	s.infoMu.Lock()
+	defer s.infoMu.Unlock()
+	s.debugInfo = info
+}
+
+// SetSchedulerController sets the scheduler controlled by the /admin endpoints
+func (s *HTTPServer) SetSchedulerController(ctrl SchedulerController) {
+	s.schedulerMu.Lock()
+	defer s.schedulerMu.Unlock()
+	s.scheduler = ctrl
+}
+
+// SetEnergyReader sets the device energy source served on the /api endpoints
+func (s *HTTPServer) SetEnergyReader(reader DeviceEnergyReader) {
+	s.energyMu.Lock()
+	defer s.energyMu.Unlock()
+	s.energy = reader
+}
+
+// SetRawResponseProvider sets the source of the raw WinPower responses
+// served on /debug/winpower/raw
+func (s *HTTPServer) SetRawResponseProvider(provider RawResponseProvider) {
+	s.rawMu.Lock()
+	defer s.rawMu.Unlock()
+	s.rawResponse = provider
+}
+
+// newEngine creates a Gin engine with global middleware and the given route groups
+func (s *HTTPServer) newEngine(routes map[string]bool) *gin.Engine {
+	// Create Gin engine without default middleware
+	engine := gin.New()
+
+	s.setupGlobalMiddleware(engine)
+	s.setupRoutes(engine, routes)
+
+	return engine
+}
+
+// newHTTPServer creates an http.Server for the given address and handler
+func (s *HTTPServer) newHTTPServer(addr string, handler http.Handler) *http.Server {
+	srv := &http.Server{
+		Addr:         addr,
+		Handler:      handler,
+		ReadTimeout:  s.cfg.ReadTimeout,
+		WriteTimeout: s.cfg.WriteTimeout,
+		IdleTimeout:  s.cfg.IdleTimeout,
+	}
+	if s.cfg.TLSEnabled() {
+		// The TLS settings were checked by Config.Validate
+		srv.TLSConfig, _ = tlsconfig.New(s.cfg.TLSMinVersion, s.cfg.TLSCipherSuites)
+	}
+	return srv
+}
+
+// serve runs a listener until it is shut down, over HTTPS when configured
+func (s *HTTPServer) serve(srv *http.Server) error {
+	if s.cfg.TLSEnabled() {
+		return srv.ListenAndServeTLS(s.cfg.TLSCertFile, s.cfg.TLSKeyFile)
+	}
+	return srv.ListenAndServe()
+}
+
 // Start starts the HTTP server
 func (s *HTTPServer) Start() error {
 	s.mu.Lock()
@@ -99,18 +191,21 @@ func (s *HTTPServer) Start() error {
 	s.running = true
 	s.mu.Unlock()
 
-	// Start server in a goroutine
-	go func() {
-		if err := s.srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			s.log.Error("HTTP server error",
-				"error", err,
-			)
-		}
-	}()
+	// Start each listener in a goroutine
+	for _, srv := range s.servers {
+		go func(srv *http.Server) {
+			if err := s.serve(srv); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				s.log.Error("HTTP server error",
+					"addr", srv.Addr,
+					"error", err,
+				)
+			}
+		}(srv)
 
-	s.log.Info("HTTP server started",
-		"addr", s.srv.Addr,
-	)
+		s.log.Info("HTTP server started",
+			"addr", srv.Addr,
+		)
+	}
 
 	return nil
 }
@@ -136,11 +231,25 @@ func (s *HTTPServer) Stop(ctx context.Context) error {
 		defer cancel()
 	}
 
-	// Shutdown server
-	if err := s.srv.Shutdown(shutdownCtx); err != nil {
-		s.log.Error("HTTP server shutdown error",
-			"error", err,
-		)
+	// Shutdown all listeners concurrently so they share the shutdown deadline
+	errs := make([]error, len(s.servers))
+	var wg sync.WaitGroup
+	for i, srv := range s.servers {
+		wg.Add(1)
+		go func(i int, srv *http.Server) {
+			defer wg.Done()
+			if err := srv.Shutdown(shutdownCtx); err != nil {
+				s.log.Error("HTTP server shutdown error",
+					"addr", srv.Addr,
+					"error", err,
+				)
+				errs[i] = err
+			}
+		}(i, srv)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
 		return err
 	}
 
@@ -153,10 +262,10 @@ func (s *HTTPServer) Stop(ctx context.Context) error {
 }
 
 // setupGlobalMiddleware sets up global middleware
-func (s *HTTPServer) setupGlobalMiddleware() {
+func (s *HTTPServer) setupGlobalMiddleware(engine *gin.Engine) {
 	// Recovery middleware (must be first to catch panics from other middleware)
-	s.engine.Use(s.recoveryMiddleware())
+	engine.Use(s.recoveryMiddleware())
 
 	// Logger middleware
-	s.engine.Use(s.loggerMiddleware())
+	engine.Use(s.loggerMiddleware())
 }
@@ -2,8 +2,20 @@ package server
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -409,3 +421,161 @@ func TestHTTPServer_HealthStatuses(t *testing.T) {
 		})
 	}
 }
+
+func TestHTTPServer_MultipleListeners(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := DefaultConfig()
+	cfg.Listeners = []ListenerConfig{
+		{Address: "127.0.0.1:0", Routes: []string{RouteMetrics}},
+		{Address: "127.0.0.1:0", Routes: []string{RouteHealth}},
+	}
+
+	srv, err := NewHTTPServer(cfg, &mockLogger{}, &mockMetricsService{}, &mockHealthService{status: "ok"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(srv.servers) != 2 {
+		t.Fatalf("Expected 2 listeners, got %d", len(srv.servers))
+	}
+
+	tests := []struct {
+		listener int
+		path     string
+		want     int
+	}{
+		{0, "/metrics", http.StatusOK},
+		{0, "/health", http.StatusNotFound},
+		{1, "/health", http.StatusOK},
+		{1, "/metrics", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		srv.servers[tt.listener].Handler.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("listener %d %s: expected status %d, got %d", tt.listener, tt.path, tt.want, w.Code)
+		}
+	}
+
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Expected no error starting server, got %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Stop(ctx); err != nil {
+		t.Errorf("Expected no error stopping server, got %v", err)
+	}
+}
+
+// writeSelfSignedCert writes a self-signed certificate for 127.0.0.1 and its
+// key to dir, returning the file paths
+func writeSelfSignedCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	certFile = filepath.Join(dir, "server.crt")
+	keyFile = filepath.Join(dir, "server.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	return certFile, keyFile
+}
+
+func TestHTTPServer_TLS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Reserve a free port for the listener
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve port: %v", err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	cfg := DefaultConfig()
+	cfg.TLSCertFile, cfg.TLSKeyFile = writeSelfSignedCert(t, t.TempDir())
+	cfg.TLSCipherSuites = []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"}
+	cfg.Listeners = []ListenerConfig{{Address: addr, Routes: []string{RouteHealth}}}
+
+	srv, err := NewHTTPServer(cfg, &mockLogger{}, &mockMetricsService{}, &mockHealthService{status: "ok"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := srv.servers[0].TLSConfig; got == nil || got.MinVersion != tls.VersionTLS12 {
+		t.Fatalf("Expected TLS 1.2 minimum on the listener, got %+v", got)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Expected no error starting server, got %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Stop(ctx)
+	}()
+
+	dial := func(clientCfg *tls.Config) (*tls.Conn, error) {
+		var conn *tls.Conn
+		var err error
+		for i := 0; i < 50; i++ {
+			conn, err = tls.Dial("tcp", addr, clientCfg)
+			var opErr *net.OpError
+			if err == nil || !errors.As(err, &opErr) || opErr.Op != "dial" {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return conn, err
+	}
+
+	conn, err := dial(&tls.Config{InsecureSkipVerify: true}) //nolint:gosec // self-signed test certificate
+	if err != nil {
+		t.Fatalf("Expected TLS handshake to succeed, got %v", err)
+	}
+	state := conn.ConnectionState()
+	_ = conn.Close()
+	if state.Version < tls.VersionTLS12 {
+		t.Errorf("Expected at least TLS 1.2, got %x", state.Version)
+	}
+
+	// Clients limited to TLS 1.1 are rejected
+	conn, err = dial(&tls.Config{InsecureSkipVerify: true, MaxVersion: tls.VersionTLS11}) //nolint:gosec // self-signed test certificate
+	if err == nil {
+		_ = conn.Close()
+		t.Error("Expected TLS 1.1 handshake to fail")
+	}
+
+	// Clients without an allowed TLS 1.2 cipher suite are rejected
+	conn, err = dial(&tls.Config{
+		InsecureSkipVerify: true, //nolint:gosec // self-signed test certificate
+		MaxVersion:         tls.VersionTLS12,
+		CipherSuites:       []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384},
+	})
+	if err == nil {
+		_ = conn.Close()
+		t.Error("Expected handshake with a disallowed cipher suite to fail")
+	}
+}
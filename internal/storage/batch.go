@@ -0,0 +1,279 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+// journalFileName is the name of the batch journal in the data directory.
+// The leading dot keeps it apart from device files, whose IDs cannot start
+// with a dot.
+const journalFileName = ".batch.journal"
+
+// batchJournal is the on-disk format of a committed batch
+type batchJournal struct {
+	Devices map[string]*PowerData `json:"devices"`
+}
+
+// Batch stages the device writes of one collection cycle and persists them
+// together on Commit.
+//
+// Commit first writes all staged data to a journal file atomically, then
+// updates the device files and removes the journal. If the process stops
+// while the device files are being updated, the journal is replayed the next
+// time a batch is started (or the manager is created), so either all or none
+// of the writes of a cycle become visible.
+//
+// Staged data is not visible to Read until the batch is committed.
+//
+// Example:
+//
+//	batch := manager.NewBatch()
+//	_ = batch.Write("device-001", data1)
+//	_ = batch.Write("device-002", data2)
+//	if err := batch.Commit(); err != nil {
+//	    log.Printf("failed to commit: %v", err)
+//	}
+type Batch struct {
+	manager *FileStorageManager
+
+	mu      sync.Mutex
+	entries map[string]*PowerData
+}
+
+// NewBatch starts a batch of device writes. It returns nil when batch writes
+// are disabled, in which case writes go directly through Write.
+//
+// A journal left behind by an interrupted commit is replayed first.
+func (m *FileStorageManager) NewBatch() *Batch {
+	if !m.config.BatchWrite {
+		return nil
+	}
+
+	if err := m.recoverJournal(); err != nil {
+		m.logger.Error("failed to replay batch journal", log.Err(err))
+	}
+
+	return &Batch{
+		manager: m,
+		entries: make(map[string]*PowerData),
+	}
+}
+
+// Write stages power data for a device. A later write for the same device
+// replaces the staged data.
+func (b *Batch) Write(deviceID string, data *PowerData) error {
+	if err := validateDeviceID(deviceID); err != nil {
+		return err
+	}
+	if err := data.ValidateWithFutureTolerance(b.manager.config.futureTolerance()); err != nil {
+		return err
+	}
+
+	staged := *data
+	if data.DeviceEnergyWH != nil {
+		deviceEnergy := *data.DeviceEnergyWH
+		staged.DeviceEnergyWH = &deviceEnergy
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[deviceID] = &staged
+	return nil
+}
+
+// Len returns the number of staged devices
+func (b *Batch) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.entries)
+}
+
+// Commit persists all staged writes. An empty batch is a no-op. Writes within
+// Config.MinPersistInterval of the device's last persist are deferred as by
+// FileStorageManager.Write instead of being journaled.
+func (b *Batch) Commit() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	deviceIDs := make([]string, 0, len(b.entries))
+	for deviceID := range b.entries {
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	unlock := b.manager.lockDevices(deviceIDs...)
+	defer unlock()
+
+	// Devices within MinPersistInterval of their last persist are held back
+	entries := make(map[string]*PowerData, len(b.entries))
+	for deviceID, data := range b.entries {
+		if !b.manager.deferWrite(deviceID, data) {
+			entries[deviceID] = data
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := b.manager.commitBatch(entries); err != nil {
+		return err
+	}
+	for deviceID := range entries {
+		b.manager.markPersisted(deviceID)
+	}
+	return nil
+}
+
+// commitBatch journals the entries and applies them to the device files
+func (m *FileStorageManager) commitBatch(entries map[string]*PowerData) error {
+	m.batchMu.Lock()
+	defer m.batchMu.Unlock()
+
+	journalPath := m.journalPath()
+	content, err := json.Marshal(&batchJournal{Devices: entries})
+	if err != nil {
+		return NewStorageError("commit", journalPath, err)
+	}
+
+	if err := m.writeAtomic(journalPath, content); err != nil {
+		m.logger.Error("failed to write batch journal",
+			log.String("path", journalPath),
+			log.Err(err))
+		return NewStorageError("commit", journalPath, wrapFSError(err))
+	}
+
+	// The batch is durable from here on; a failure below leaves the journal
+	// in place to be replayed
+	if err := m.applyJournal(journalPath, entries); err != nil {
+		return err
+	}
+
+	m.logger.Debug("committed storage batch",
+		log.Int("device_count", len(entries)))
+	return nil
+}
+
+// RecoverJournal completes a batch interrupted by a previous shutdown by
+// replaying its journal. It is a no-op when batch writes are disabled or no
+// journal is left. Call it once the data directory is known to be ready,
+// before reading device data; NewBatch also calls it before each batch.
+func (m *FileStorageManager) RecoverJournal() error {
+	if !m.config.BatchWrite {
+		return nil
+	}
+	return m.recoverJournal()
+}
+
+// recoverJournal replays a journal left behind by an interrupted commit
+func (m *FileStorageManager) recoverJournal() error {
+	m.batchMu.Lock()
+	defer m.batchMu.Unlock()
+
+	journalPath := m.journalPath()
+	content, err := os.ReadFile(journalPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return NewStorageError("recover", journalPath, err)
+	}
+
+	var journal batchJournal
+	if err := json.Unmarshal(content, &journal); err != nil {
+		return NewStorageError("recover", journalPath,
+			fmt.Errorf("%w: invalid batch journal: %v", ErrInvalidFormat, err))
+	}
+
+	m.logger.Warn("replaying interrupted storage batch",
+		log.String("path", journalPath),
+		log.Int("device_count", len(journal.Devices)))
+
+	return m.applyJournal(journalPath, journal.Devices)
+}
+
+// applyJournal writes the journaled entries to the device files and removes
+// the journal once all of them are written. The caller must hold batchMu.
+func (m *FileStorageManager) applyJournal(journalPath string, entries map[string]*PowerData) error {
+	deviceIDs := make([]string, 0, len(entries))
+	for deviceID := range entries {
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	sort.Strings(deviceIDs)
+
+	for _, deviceID := range deviceIDs {
+		if err := m.writeDevice(deviceID, entries[deviceID]); err != nil {
+			m.logger.Error("failed to apply batch journal",
+				log.String("device_id", deviceID),
+				log.Err(err))
+			return err
+		}
+	}
+
+	if err := os.Remove(journalPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return NewStorageError("commit", journalPath, err)
+	}
+	return nil
+}
+
+// writeAtomic writes a file in the data directory atomically (temp file,
+// fsync, rename), such as the batch journal
+func (m *FileStorageManager) writeAtomic(path string, content []byte) error {
+	if err := ensureDir(osFileSystem{}, m.config.DataDir, m.config.dirPermissions()); err != nil {
+		return err
+	}
+
+	tempPath := path + ".tmp"
+	file, err := os.OpenFile(tempPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, m.config.FilePermissions)
+	if err != nil {
+		return err
+	}
+
+	// Apply the configured mode regardless of umask
+	err = file.Chmod(m.config.FilePermissions)
+	if err == nil {
+		_, err = file.Write(content)
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tempPath, path)
+	}
+	if err != nil {
+		_ = os.Remove(tempPath)
+	}
+	return err
+}
+
+// journalPath returns the path of the batch journal
+func (m *FileStorageManager) journalPath() string {
+	return filepath.Join(m.config.DataDir, journalFileName)
+}
+
+// batchContextKey is the context key of the current batch
+type batchContextKey struct{}
+
+// WithBatch returns a context carrying the batch, so that writes made on
+// behalf of the current collection cycle can be staged in it
+func WithBatch(ctx context.Context, batch *Batch) context.Context {
+	return context.WithValue(ctx, batchContextKey{}, batch)
+}
+
+// BatchFromContext returns the batch carried by ctx, or nil if there is none
+func BatchFromContext(ctx context.Context) *Batch {
+	if ctx == nil {
+		return nil
+	}
+	batch, _ := ctx.Value(batchContextKey{}).(*Batch)
+	return batch
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+func newBatchManager(t *testing.T, batchWrite bool) (*FileStorageManager, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	manager, err := NewFileStorageManager(&Config{
+		DataDir:         dir,
+		FilePermissions: 0644,
+		BatchWrite:      batchWrite,
+	}, log.NewTestLogger())
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	return manager.(*FileStorageManager), dir
+}
+
+func TestBatch_Disabled(t *testing.T) {
+	manager, _ := newBatchManager(t, false)
+
+	if batch := manager.NewBatch(); batch != nil {
+		t.Error("Expected no batch when batch writes are disabled")
+	}
+}
+
+func TestBatch_Commit(t *testing.T) {
+	manager, dir := newBatchManager(t, true)
+	now := time.Now().UnixMilli()
+
+	batch := manager.NewBatch()
+	if batch == nil {
+		t.Fatal("Expected a batch when batch writes are enabled")
+	}
+
+	if err := batch.Write("device-1", &PowerData{Timestamp: now, EnergyWH: 100}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := batch.Write("device-2", &PowerData{Timestamp: now, EnergyWH: 200}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if batch.Len() != 2 {
+		t.Errorf("Expected 2 staged devices, got %d", batch.Len())
+	}
+
+	// Staged data is not visible before commit
+	if _, err := os.Stat(filepath.Join(dir, "device-1.txt")); !os.IsNotExist(err) {
+		t.Fatalf("Expected no device file before commit, got %v", err)
+	}
+
+	if err := batch.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	for deviceID, want := range map[string]float64{"device-1": 100, "device-2": 200} {
+		data, err := manager.Read(deviceID)
+		if err != nil {
+			t.Fatalf("Read %s failed: %v", deviceID, err)
+		}
+		if data.EnergyWH != want || data.Timestamp != now {
+			t.Errorf("Expected %s energy %v at %d, got %+v", deviceID, want, now, data)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, journalFileName)); !os.IsNotExist(err) {
+		t.Errorf("Expected journal to be removed after commit, got %v", err)
+	}
+}
+
+func TestBatch_WriteValidation(t *testing.T) {
+	manager, _ := newBatchManager(t, true)
+	batch := manager.NewBatch()
+
+	if err := batch.Write("../escape", &PowerData{}); !errors.Is(err, ErrInvalidDeviceID) {
+		t.Errorf("Expected ErrInvalidDeviceID, got %v", err)
+	}
+	if err := batch.Write("device-1", &PowerData{EnergyWH: -1}); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("Expected ErrInvalidData, got %v", err)
+	}
+	if batch.Len() != 0 {
+		t.Errorf("Expected invalid writes not to be staged, got %d", batch.Len())
+	}
+
+	// Committing an empty batch writes nothing
+	if err := batch.Commit(); err != nil {
+		t.Errorf("Expected empty commit to succeed, got %v", err)
+	}
+}
+
+func TestBatch_ReplaysInterruptedCommit(t *testing.T) {
+	manager, dir := newBatchManager(t, true)
+	now := time.Now().UnixMilli()
+
+	// Simulate a crash after the journal was written but before the
+	// device files were updated
+	if err := manager.Write("device-1", &PowerData{Timestamp: now, EnergyWH: 10}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	deviceEnergy := 42.0
+	content, err := json.Marshal(&batchJournal{Devices: map[string]*PowerData{
+		"device-1": {Timestamp: now, EnergyWH: 110},
+		"device-2": {Timestamp: now, EnergyWH: 220, DeviceEnergyWH: &deviceEnergy},
+	}})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, journalFileName), content, 0644); err != nil {
+		t.Fatalf("Failed to write journal: %v", err)
+	}
+
+	t.Run("on new batch", func(t *testing.T) {
+		if batch := manager.NewBatch(); batch == nil {
+			t.Fatal("Expected a batch")
+		}
+
+		data, err := manager.Read("device-1")
+		if err != nil || data.EnergyWH != 110 {
+			t.Errorf("Expected replayed energy 110, got %+v (err %v)", data, err)
+		}
+		data, err = manager.Read("device-2")
+		if err != nil || data.DeviceEnergyWH == nil || *data.DeviceEnergyWH != 42 {
+			t.Errorf("Expected replayed device energy 42, got %+v (err %v)", data, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, journalFileName)); !os.IsNotExist(err) {
+			t.Errorf("Expected journal to be removed after replay, got %v", err)
+		}
+	})
+
+	t.Run("on startup", func(t *testing.T) {
+		content, _ := json.Marshal(&batchJournal{Devices: map[string]*PowerData{
+			"device-1": {Timestamp: now, EnergyWH: 150},
+		}})
+		if err := os.WriteFile(filepath.Join(dir, journalFileName), content, 0644); err != nil {
+			t.Fatalf("Failed to write journal: %v", err)
+		}
+
+		restarted, err := NewFileStorageManager(&Config{
+			DataDir:         dir,
+			FilePermissions: 0644,
+			BatchWrite:      true,
+		}, log.NewTestLogger())
+		if err != nil {
+			t.Fatalf("Failed to create manager: %v", err)
+		}
+		if err := restarted.(JournalRecoverer).RecoverJournal(); err != nil {
+			t.Fatalf("Failed to recover journal: %v", err)
+		}
+
+		data, err := restarted.Read("device-1")
+		if err != nil || data.EnergyWH != 150 {
+			t.Errorf("Expected replayed energy 150, got %+v (err %v)", data, err)
+		}
+	})
+}
+
+func TestBatchContext(t *testing.T) {
+	if BatchFromContext(context.Background()) != nil {
+		t.Error("Expected no batch in a plain context")
+	}
+
+	manager, _ := newBatchManager(t, true)
+	batch := manager.NewBatch()
+	ctx := WithBatch(context.Background(), batch)
+	if BatchFromContext(ctx) != batch {
+		t.Error("Expected the batch to be carried by the context")
+	}
+}
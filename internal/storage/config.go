@@ -3,6 +3,7 @@ package storage
 import (
 	"fmt"
 	"os"
+	"time"
 )
 
 // Config holds configuration for the storage module.
@@ -24,15 +25,85 @@ type Config struct {
 	// DataDir is the directory where device data files are stored
 	DataDir string `json:"data_dir" yaml:"data_dir" mapstructure:"data_dir"`
 
-	// FilePermissions defines the permission bits for created files (e.g., 0644)
+	// FilePermissions defines the permission bits for created files (e.g., 0644).
+	// The mode is applied with an explicit chmod, so the process umask
+	// cannot strip bits from it.
 	FilePermissions os.FileMode `json:"file_permissions" yaml:"file_permissions" mapstructure:"file_permissions"`
+
+	// DirPermissions defines the permission bits used when creating DataDir
+	// (e.g., 0775 for group-shared access). Like FilePermissions it is applied
+	// regardless of umask. Existing directories are left unchanged. Zero uses
+	// DefaultDirPermissions.
+	DirPermissions os.FileMode `json:"dir_permissions" yaml:"dir_permissions" mapstructure:"dir_permissions"`
+
+	// WriteRetries is the number of times a write is retried on transient
+	// filesystem errors (e.g. EINTR, ENOSPC). Zero disables retries.
+	WriteRetries int `json:"write_retries" yaml:"write_retries" mapstructure:"write_retries"`
+
+	// ReadinessTimeout is how long startup keeps retrying to create DataDir
+	// and verify it is writable before giving up. Zero checks only once.
+	ReadinessTimeout time.Duration `json:"readiness_timeout" yaml:"readiness_timeout" mapstructure:"readiness_timeout"`
+
+	// ReadinessInterval is the delay between readiness attempts
+	ReadinessInterval time.Duration `json:"readiness_interval" yaml:"readiness_interval" mapstructure:"readiness_interval"`
+
+	// BatchWrite persists the device writes of a collection cycle together
+	// through a journal instead of one file at a time, so a crash never
+	// leaves some devices updated and others not. Disabled by default.
+	BatchWrite bool `json:"batch_write" yaml:"batch_write" mapstructure:"batch_write"`
+
+	// FutureTolerance is how far ahead of the local clock a timestamp may be
+	// before PowerData is rejected as invalid. Zero uses DefaultFutureTolerance.
+	FutureTolerance time.Duration `json:"future_tolerance" yaml:"future_tolerance" mapstructure:"future_tolerance"`
+
+	// HashLongDeviceIDs stores devices whose ID is longer than 200 bytes
+	// under a fixed-length file name (a readable prefix of the ID and its
+	// SHA-256) instead of failing on the file name length limit. The
+	// original IDs are kept in a side index for ReadAll. Shorter IDs keep
+	// their plain file name. Disabled by default.
+	HashLongDeviceIDs bool `json:"hash_long_device_ids" yaml:"hash_long_device_ids" mapstructure:"hash_long_device_ids"`
+
+	// MinPersistInterval is the minimum time between two persists of the
+	// same device. Writes within the interval are held in memory, visible to
+	// Read, and persisted by the device's next write after the interval or
+	// by Flush; up to this much of a device's energy is lost if the process
+	// crashes. Zero persists every write.
+	MinPersistInterval time.Duration `json:"min_persist_interval" yaml:"min_persist_interval" mapstructure:"min_persist_interval"`
+
+	// SnapshotInterval is the time between two energy snapshots, coarse
+	// checkpoints of every device's energy kept in the snapshots directory
+	// under DataDir for long-term comparisons (e.g. 24h). Zero disables
+	// snapshots.
+	SnapshotInterval time.Duration `json:"snapshot_interval" yaml:"snapshot_interval" mapstructure:"snapshot_interval"`
+
+	// ReadAllTimeout bounds a ReadAll scan of the data directory. When it
+	// expires, ReadAll stops and returns the devices read so far together
+	// with an ErrPartialRead error. Zero disables the limit.
+	ReadAllTimeout time.Duration `json:"read_all_timeout" yaml:"read_all_timeout" mapstructure:"read_all_timeout"`
 }
 
+// DefaultDirPermissions is the default permission of a created DataDir
+const DefaultDirPermissions os.FileMode = 0755
+
+// DefaultFutureTolerance is the default maximum clock-future tolerance for
+// PowerData timestamps
+const DefaultFutureTolerance = 24 * time.Hour
+
 // DefaultConfig returns a Config with sensible default values.
 //
 // The default configuration uses:
 //   - DataDir: "./data" (relative to current working directory)
 //   - FilePermissions: 0644 (owner read/write, group/others read-only)
+//   - DirPermissions: 0755 (owner full access, group/others read/traverse)
+//   - WriteRetries: 3
+//   - ReadinessTimeout: 30s
+//   - ReadinessInterval: 1s
+//   - BatchWrite: false
+//   - FutureTolerance: 24h
+//   - HashLongDeviceIDs: false
+//   - MinPersistInterval: 0 (persist every write)
+//   - SnapshotInterval: 0 (no snapshots)
+//   - ReadAllTimeout: 0 (no limit)
 //
 // This is suitable for development and testing. For production, consider
 // using an absolute path and more restrictive permissions.
@@ -44,8 +115,13 @@ type Config struct {
 //	manager, err := storage.NewFileStorageManager(config, logger)
 func DefaultConfig() *Config {
 	return &Config{
-		DataDir:         "./data",
-		FilePermissions: 0644,
+		DataDir:           "./data",
+		FilePermissions:   0644,
+		DirPermissions:    DefaultDirPermissions,
+		WriteRetries:      3,
+		ReadinessTimeout:  30 * time.Second,
+		ReadinessInterval: time.Second,
+		FutureTolerance:   DefaultFutureTolerance,
 	}
 }
 
@@ -55,6 +131,16 @@ func DefaultConfig() *Config {
 //   - Config must not be nil
 //   - DataDir must not be empty
 //   - FilePermissions must be between 0 and 0777 (valid Unix permissions)
+//     and grant the owner read and write access
+//   - DirPermissions must be between 0 and 0777 and, when set, grant the
+//     owner read, write and search access
+//   - WriteRetries must be between 0 and 10
+//   - ReadinessTimeout must not be negative
+//   - ReadinessInterval must be positive when ReadinessTimeout is set
+//   - FutureTolerance must not be negative
+//   - MinPersistInterval must be between 0 and 1h
+//   - SnapshotInterval must be 0 or at least 1m
+//   - ReadAllTimeout must not be negative
 //
 // Returns an error if any validation rule is violated.
 //
@@ -81,5 +167,64 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("file permissions must be a valid Unix permission (0-0777)")
 	}
 
+	// The exporter rewrites and reads back its own files
+	if c.FilePermissions&0600 != 0600 {
+		return fmt.Errorf("file permissions must grant the owner read and write access, got %04o", uint32(c.FilePermissions))
+	}
+
+	if c.DirPermissions > 0777 {
+		return fmt.Errorf("directory permissions must be a valid Unix permission (0-0777)")
+	}
+
+	if c.DirPermissions != 0 && c.DirPermissions&0700 != 0700 {
+		return fmt.Errorf("directory permissions must grant the owner read, write and search access, got %04o", uint32(c.DirPermissions))
+	}
+
+	if c.WriteRetries < 0 || c.WriteRetries > 10 {
+		return fmt.Errorf("write retries must be between 0 and 10, got %d", c.WriteRetries)
+	}
+
+	if c.ReadinessTimeout < 0 {
+		return fmt.Errorf("readiness timeout cannot be negative, got %v", c.ReadinessTimeout)
+	}
+
+	if c.ReadinessTimeout > 0 && c.ReadinessInterval <= 0 {
+		return fmt.Errorf("readiness interval must be positive, got %v", c.ReadinessInterval)
+	}
+
+	if c.FutureTolerance < 0 {
+		return fmt.Errorf("future tolerance cannot be negative, got %v", c.FutureTolerance)
+	}
+
+	if c.MinPersistInterval < 0 || c.MinPersistInterval > time.Hour {
+		return fmt.Errorf("min persist interval must be between 0 and 1h, got %v", c.MinPersistInterval)
+	}
+
+	if c.SnapshotInterval < 0 || (c.SnapshotInterval > 0 && c.SnapshotInterval < snapshotCheckInterval) {
+		return fmt.Errorf("snapshot interval must be 0 or at least %v, got %v", snapshotCheckInterval, c.SnapshotInterval)
+	}
+
+	if c.ReadAllTimeout < 0 {
+		return fmt.Errorf("read all timeout cannot be negative, got %v", c.ReadAllTimeout)
+	}
+
 	return nil
 }
+
+// dirPermissions returns the configured directory permissions, falling back
+// to DefaultDirPermissions when unset
+func (c *Config) dirPermissions() os.FileMode {
+	if c.DirPermissions == 0 {
+		return DefaultDirPermissions
+	}
+	return c.DirPermissions
+}
+
+// futureTolerance returns the configured future tolerance, falling back to
+// DefaultFutureTolerance when unset
+func (c *Config) futureTolerance() time.Duration {
+	if c.FutureTolerance == 0 {
+		return DefaultFutureTolerance
+	}
+	return c.FutureTolerance
+}
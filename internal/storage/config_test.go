@@ -2,6 +2,7 @@ package storage
 
 import (
 	"testing"
+	"time"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -14,6 +15,26 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.FilePermissions != 0644 {
 		t.Errorf("FilePermissions = %v, want 0644", cfg.FilePermissions)
 	}
+
+	if cfg.DirPermissions != 0755 {
+		t.Errorf("DirPermissions = %v, want 0755", cfg.DirPermissions)
+	}
+
+	if cfg.WriteRetries != 3 {
+		t.Errorf("WriteRetries = %v, want 3", cfg.WriteRetries)
+	}
+
+	if cfg.ReadinessTimeout != 30*time.Second {
+		t.Errorf("ReadinessTimeout = %v, want 30s", cfg.ReadinessTimeout)
+	}
+
+	if cfg.ReadinessInterval != time.Second {
+		t.Errorf("ReadinessInterval = %v, want 1s", cfg.ReadinessInterval)
+	}
+
+	if cfg.FutureTolerance != 24*time.Hour {
+		t.Errorf("FutureTolerance = %v, want 24h", cfg.FutureTolerance)
+	}
 }
 
 func TestConfig_Validate(t *testing.T) {
@@ -47,6 +68,105 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "file permissions must be a valid Unix permission",
 		},
+		{
+			name: "file permissions not owner-writable",
+			config: &Config{
+				DataDir:         "./data",
+				FilePermissions: 0444,
+			},
+			wantErr: true,
+			errMsg:  "file permissions must grant the owner read and write access",
+		},
+		{
+			name: "invalid directory permissions",
+			config: &Config{
+				DataDir:         "./data",
+				FilePermissions: 0644,
+				DirPermissions:  01000,
+			},
+			wantErr: true,
+			errMsg:  "directory permissions must be a valid Unix permission",
+		},
+		{
+			name: "directory permissions not owner-accessible",
+			config: &Config{
+				DataDir:         "./data",
+				FilePermissions: 0644,
+				DirPermissions:  0575,
+			},
+			wantErr: true,
+			errMsg:  "directory permissions must grant the owner",
+		},
+		{
+			name: "negative write retries",
+			config: &Config{
+				DataDir:         "./data",
+				FilePermissions: 0644,
+				WriteRetries:    -1,
+			},
+			wantErr: true,
+			errMsg:  "write retries must be between 0 and 10",
+		},
+		{
+			name: "negative future tolerance",
+			config: &Config{
+				DataDir:         "./data",
+				FilePermissions: 0644,
+				FutureTolerance: -time.Minute,
+			},
+			wantErr: true,
+			errMsg:  "future tolerance cannot be negative",
+		},
+		{
+			name: "min persist interval above 1h",
+			config: &Config{
+				DataDir:            "./data",
+				FilePermissions:    0644,
+				MinPersistInterval: 2 * time.Hour,
+			},
+			wantErr: true,
+			errMsg:  "min persist interval must be between 0 and 1h",
+		},
+		{
+			name: "snapshot interval below 1m",
+			config: &Config{
+				DataDir:          "./data",
+				FilePermissions:  0644,
+				SnapshotInterval: time.Second,
+			},
+			wantErr: true,
+			errMsg:  "snapshot interval must be 0 or at least 1m0s",
+		},
+		{
+			name: "negative read all timeout",
+			config: &Config{
+				DataDir:         "./data",
+				FilePermissions: 0644,
+				ReadAllTimeout:  -time.Second,
+			},
+			wantErr: true,
+			errMsg:  "read all timeout cannot be negative",
+		},
+		{
+			name: "negative readiness timeout",
+			config: &Config{
+				DataDir:          "./data",
+				FilePermissions:  0644,
+				ReadinessTimeout: -time.Second,
+			},
+			wantErr: true,
+			errMsg:  "readiness timeout cannot be negative",
+		},
+		{
+			name: "readiness timeout without interval",
+			config: &Config{
+				DataDir:          "./data",
+				FilePermissions:  0644,
+				ReadinessTimeout: time.Second,
+			},
+			wantErr: true,
+			errMsg:  "readiness interval must be positive",
+		},
 		{
 			name: "valid config",
 			config: &Config{
@@ -63,6 +183,15 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid config with group-shared directory",
+			config: &Config{
+				DataDir:         "/var/lib/exporter",
+				FilePermissions: 0660,
+				DirPermissions:  0770,
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
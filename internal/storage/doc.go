@@ -2,8 +2,11 @@
 //
 // The storage module stores accumulated energy values for each device in individual
 // text files. Each file contains two lines: timestamp (Unix milliseconds) and
-// energy value (watt-hours). This simple format ensures easy debugging and
-// manual inspection when needed.
+// energy value (watt-hours). When energy is taken from the device-reported
+// counter, a third line holds the last device reading (watt-hours). Once the
+// energy has been reset, a fourth line holds the reset epoch, i.e. the number
+// of resets, after an empty third line when there is no device reading. This simple
+// format ensures easy debugging and manual inspection when needed.
 //
 // # Basic Usage
 //
@@ -47,7 +50,11 @@
 //	1234.5
 //
 // Files are stored in the configured DataDir directory with the naming pattern:
-// <device-id>.txt. The device ID is validated to prevent path traversal attacks.
+// <device-id>.txt. The device ID is validated to prevent path traversal attacks:
+// '/' and '\' are rejected on every platform, and on Windows the ID must also
+// be a valid file name (no ':', no reserved names such as NUL, no trailing dot
+// or space). DataDir may be any path of the platform, e.g. a drive letter or
+// UNC path on Windows.
 //
 // # Configuration
 //
@@ -62,6 +69,39 @@
 //	}
 //	manager, err := storage.NewFileStorageManager(config, logger)
 //
+// # Batch Writes
+//
+// With Config.BatchWrite enabled, the writes of one collection cycle can be
+// persisted together so that a crash never leaves some devices updated and
+// others not:
+//
+//	batch := manager.(storage.BatchStorage).NewBatch()
+//	_ = batch.Write("device-001", data1)
+//	_ = batch.Write("device-002", data2)
+//	err := batch.Commit()
+//
+// Commit writes all entries to the journal file .batch.journal in DataDir
+// atomically, then updates the device files and removes the journal. A
+// journal left behind by an interrupted commit is replayed when the next
+// batch starts or RecoverJournal (see JournalRecoverer) is called. The energy module stages its writes
+// in the batch carried by the context (see WithBatch).
+//
+// # Minimum Persist Interval
+//
+// With Config.MinPersistInterval set, each device is persisted at most once
+// per interval. Writes within the interval, direct or batched, are held in
+// memory and returned by Read, so that callers always see the latest value;
+// they are persisted by the device's next write after the interval, or by
+// Flush (see Flusher) at shutdown.
+//
+// # Device Metadata
+//
+// FileStorageManager also implements MetadataStorage, which keeps the name,
+// type and energy key of each device in .devices.json in DataDir. The
+// application uses it to export the stored energy with the right labels
+// before the first collection after a restart. The file is advisory: a
+// missing file reads as empty metadata and ReadAll never returns it.
+//
 // # Thread Safety
 //
 // The storage module uses atomic file operations (write to temp file + rename)
@@ -102,7 +142,8 @@
 // # Data Validation
 //
 // PowerData is validated before writing to ensure data integrity:
-//   - Timestamp must be positive and not more than 24 hours in the future
+//   - Timestamp must be positive and not more than Config.FutureTolerance
+//     (24 hours by default) in the future
 //   - Energy value must be finite (not NaN or Inf) and non-negative
 //
 // Validation happens automatically in Write() operations. You can also
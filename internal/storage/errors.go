@@ -21,6 +21,13 @@ var (
 
 	// ErrPermissionDenied indicates that file operation was denied due to permissions
 	ErrPermissionDenied = errors.New("permission denied")
+
+	// ErrNotReady indicates that the data directory did not become writable in time
+	ErrNotReady = errors.New("storage not ready")
+
+	// ErrPartialRead indicates that ReadAll was cancelled or timed out and
+	// returned only the devices read until then
+	ErrPartialRead = errors.New("partial read")
 )
 
 // StorageError represents an error that occurred during storage operations.
@@ -1,8 +1,11 @@
 package storage
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
@@ -278,3 +281,210 @@ func TestFileWriter_FileReader_Integration(t *testing.T) {
 			data.Timestamp, data.EnergyWH, updatedData.Timestamp, updatedData.EnergyWH)
 	}
 }
+
+func TestFileWriter_FileReader_DeviceEnergy(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := log.NewTestLogger()
+	config := &Config{
+		DataDir:         tmpDir,
+		FilePermissions: 0644,
+	}
+
+	writer := NewFileWriter(config, logger)
+	reader := NewFileReader(config, logger)
+
+	deviceEnergy := 98765.43
+	written := &PowerData{
+		Timestamp:      time.Now().UnixMilli(),
+		EnergyWH:       1200.5,
+		DeviceEnergyWH: &deviceEnergy,
+	}
+	if err := writer.Write("device1", written); err != nil {
+		t.Fatalf("failed to write device1: %v", err)
+	}
+
+	data, err := reader.Read("device1")
+	if err != nil {
+		t.Fatalf("failed to read device1: %v", err)
+	}
+	if data.EnergyWH != written.EnergyWH {
+		t.Errorf("EnergyWH = %v, want %v", data.EnergyWH, written.EnergyWH)
+	}
+	if data.DeviceEnergyWH == nil || *data.DeviceEnergyWH != deviceEnergy {
+		t.Errorf("DeviceEnergyWH = %v, want %v", data.DeviceEnergyWH, deviceEnergy)
+	}
+
+	// Writing without a baseline drops the third line
+	written.DeviceEnergyWH = nil
+	if err := writer.Write("device1", written); err != nil {
+		t.Fatalf("failed to rewrite device1: %v", err)
+	}
+	data, err = reader.Read("device1")
+	if err != nil {
+		t.Fatalf("failed to read device1: %v", err)
+	}
+	if data.DeviceEnergyWH != nil {
+		t.Errorf("DeviceEnergyWH = %v, want nil", *data.DeviceEnergyWH)
+	}
+}
+
+func TestFileWriter_FileReader_ResetEpoch(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := log.NewTestLogger()
+	config := &Config{
+		DataDir:         tmpDir,
+		FilePermissions: 0644,
+	}
+
+	writer := NewFileWriter(config, logger)
+	reader := NewFileReader(config, logger)
+
+	deviceEnergy := 500.25
+	tests := []struct {
+		name         string
+		deviceEnergy *float64
+		content      string
+	}{
+		{"without baseline", nil, "\n3\n"},
+		{"with baseline", &deviceEnergy, "500.25\n3\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			written := &PowerData{
+				Timestamp:      time.Now().UnixMilli(),
+				EnergyWH:       10.5,
+				DeviceEnergyWH: tt.deviceEnergy,
+				ResetEpoch:     3,
+			}
+			if err := writer.Write("device1", written); err != nil {
+				t.Fatalf("failed to write device1: %v", err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(tmpDir, "device1.txt"))
+			if err != nil {
+				t.Fatalf("failed to read device file: %v", err)
+			}
+			if !strings.HasSuffix(string(content), "10.50\n"+tt.content) {
+				t.Errorf("file content = %q, want suffix %q", content, "10.50\n"+tt.content)
+			}
+
+			data, err := reader.Read("device1")
+			if err != nil {
+				t.Fatalf("failed to read device1: %v", err)
+			}
+			if data.ResetEpoch != 3 {
+				t.Errorf("ResetEpoch = %d, want 3", data.ResetEpoch)
+			}
+			if (data.DeviceEnergyWH == nil) != (tt.deviceEnergy == nil) {
+				t.Errorf("DeviceEnergyWH = %v, want %v", data.DeviceEnergyWH, tt.deviceEnergy)
+			}
+		})
+	}
+
+	// A non-numeric epoch is rejected
+	if err := os.WriteFile(filepath.Join(tmpDir, "device2.txt"), []byte("1700000000000\n1.00\n\nabc\n"), 0644); err != nil {
+		t.Fatalf("failed to write device2: %v", err)
+	}
+	if _, err := reader.Read("device2"); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Read() error = %v, want %v", err, ErrInvalidFormat)
+	}
+}
+
+// flakyFileSystem wraps the real filesystem and fails the first N renames
+// with the configured error.
+type flakyFileSystem struct {
+	osFileSystem
+	failures int
+	err      error
+	renames  int
+}
+
+func (f *flakyFileSystem) Rename(oldpath, newpath string) error {
+	f.renames++
+	if f.renames <= f.failures {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: f.err}
+	}
+	return f.osFileSystem.Rename(oldpath, newpath)
+}
+
+func TestFileWriter_WriteRetries(t *testing.T) {
+	originalBackoff := writeRetryBackoff
+	writeRetryBackoff = time.Millisecond
+	defer func() { writeRetryBackoff = originalBackoff }()
+
+	tests := []struct {
+		name        string
+		retries     int
+		failures    int
+		err         error
+		wantErr     error
+		wantRenames int
+	}{
+		{
+			name:        "transient error recovers within retries",
+			retries:     3,
+			failures:    2,
+			err:         syscall.EINTR,
+			wantRenames: 3,
+		},
+		{
+			name:        "transient error exhausts retries",
+			retries:     2,
+			failures:    5,
+			err:         syscall.ENOSPC,
+			wantErr:     syscall.ENOSPC,
+			wantRenames: 3,
+		},
+		{
+			name:        "retries disabled",
+			retries:     0,
+			failures:    1,
+			err:         syscall.EINTR,
+			wantErr:     syscall.EINTR,
+			wantRenames: 1,
+		},
+		{
+			name:        "permission error is not retried",
+			retries:     3,
+			failures:    5,
+			err:         syscall.EACCES,
+			wantErr:     ErrPermissionDenied,
+			wantRenames: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			config := &Config{
+				DataDir:         tmpDir,
+				FilePermissions: 0644,
+				WriteRetries:    tt.retries,
+			}
+			fs := &flakyFileSystem{failures: tt.failures, err: tt.err}
+			writer := newFileWriterWithFS(config, log.NewTestLogger(), fs)
+
+			data := &PowerData{Timestamp: time.Now().UnixMilli(), EnergyWH: 42.5}
+			err := writer.Write("device1", data)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Write() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Errorf("Write() unexpected error: %v", err)
+			}
+
+			if fs.renames != tt.wantRenames {
+				t.Errorf("rename attempts = %d, want %d", fs.renames, tt.wantRenames)
+			}
+
+			if tt.wantErr == nil {
+				if _, err := os.Stat(filepath.Join(tmpDir, "device1.txt")); err != nil {
+					t.Errorf("expected device file to exist: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// fileSystem abstracts the filesystem operations used by the storage writer.
+// The default implementation delegates to the os package; tests can inject
+// an implementation that simulates transient or permanent failures.
+type fileSystem interface {
+	MkdirAll(path string, perm os.FileMode) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	OpenFile(name string, flag int, perm os.FileMode) (*os.File, error)
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	Chmod(name string, mode os.FileMode) error
+	Stat(name string) (os.FileInfo, error)
+}
+
+// osFileSystem implements fileSystem using the os package.
+type osFileSystem struct{}
+
+func (osFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (osFileSystem) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+func (osFileSystem) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	return os.OpenFile(name, flag, perm)
+}
+
+func (osFileSystem) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+func (osFileSystem) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (osFileSystem) Chmod(name string, mode os.FileMode) error {
+	return os.Chmod(name, mode)
+}
+
+func (osFileSystem) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+// ensureDir creates dir with the given permissions if it does not exist.
+// The mode is applied with an explicit chmod because MkdirAll is subject to
+// the process umask. Existing directories are left unchanged.
+func ensureDir(fsys fileSystem, dir string, perm os.FileMode) error {
+	if info, err := fsys.Stat(dir); err == nil && info.IsDir() {
+		return nil
+	}
+
+	if err := fsys.MkdirAll(dir, perm); err != nil {
+		return err
+	}
+	return fsys.Chmod(dir, perm)
+}
+
+// isTransientError reports whether a filesystem error is likely to succeed
+// when retried (e.g. interrupted system calls or a momentarily full disk).
+// Permission errors and validation errors are always treated as permanent.
+func isTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, ErrPermissionDenied) || errors.Is(err, os.ErrPermission) ||
+		errors.Is(err, ErrInvalidDeviceID) || errors.Is(err, ErrInvalidData) {
+		return false
+	}
+
+	return errors.Is(err, syscall.EINTR) ||
+		errors.Is(err, syscall.EAGAIN) ||
+		errors.Is(err, syscall.EBUSY) ||
+		errors.Is(err, syscall.ENOSPC)
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+// deviceIndexFileName is the name of the index of hashed device file names in
+// the data directory. Like the batch journal it starts with a dot so that it
+// is never mistaken for a device file.
+const deviceIndexFileName = ".device-index.json"
+
+// maxPlainDeviceIDBytes is the longest device ID stored under its own file
+// name when HashLongDeviceIDs is set. It leaves room for the file extension
+// and the temporary file suffix within the common 255-byte file name limit.
+const maxPlainDeviceIDBytes = 200
+
+// hashedPrefixBytes is the length of the readable device ID prefix kept in a
+// hashed file name
+const hashedPrefixBytes = 64
+
+// fileStem returns the file name of a device's data file without extension.
+// It is the device ID itself unless the ID is hashed.
+func (c *Config) fileStem(deviceID string) string {
+	if c.HashLongDeviceIDs && len(deviceID) > maxPlainDeviceIDBytes {
+		return hashedFileStem(deviceID)
+	}
+	return deviceID
+}
+
+// deviceFilePath validates the device ID and returns the path of its data file
+func (c *Config) deviceFilePath(deviceID string) (string, error) {
+	if err := validateDeviceID(deviceID); err != nil {
+		return "", err
+	}
+	return buildFilePath(c.DataDir, c.fileStem(deviceID))
+}
+
+// hashedFileStem returns a fixed-length file name for a long device ID: a
+// readable prefix of the ID, cut at a character boundary, followed by the
+// SHA-256 of the full ID.
+func hashedFileStem(deviceID string) string {
+	cut := hashedPrefixBytes
+	for cut > 0 && !utf8.RuneStart(deviceID[cut]) {
+		cut--
+	}
+
+	sum := sha256.Sum256([]byte(deviceID))
+	return deviceID[:cut] + "~" + hex.EncodeToString(sum[:])
+}
+
+// readDeviceIndex returns the original device IDs keyed by hashed file name.
+// A missing index yields an empty map.
+func readDeviceIndex(dataDir string) (map[string]string, error) {
+	path := filepath.Join(dataDir, deviceIndexFileName)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+		return nil, NewStorageError("read", path, wrapFSError(err))
+	}
+
+	index := make(map[string]string)
+	if err := json.Unmarshal(content, &index); err != nil {
+		return nil, NewStorageError("read", path, err)
+	}
+	return index, nil
+}
+
+// indexDeviceID records the original ID of a device stored under a hashed
+// file name, so that ReadAll reports it instead of the file name. Each device
+// is indexed once per process; devices with plain file names are skipped.
+//
+// An unreadable index is replaced rather than failing the write, since it
+// only serves reverse lookups.
+func (m *FileStorageManager) indexDeviceID(deviceID string) error {
+	stem := m.config.fileStem(deviceID)
+	if stem == deviceID {
+		return nil
+	}
+
+	m.indexMu.Lock()
+	defer m.indexMu.Unlock()
+
+	if m.indexed[stem] {
+		return nil
+	}
+
+	index, err := readDeviceIndex(m.config.DataDir)
+	if err != nil {
+		m.logger.Warn("replacing unreadable device index",
+			log.String("device_id", deviceID),
+			log.Err(err))
+		index = make(map[string]string)
+		m.indexed = make(map[string]bool)
+	}
+
+	if index[stem] != deviceID {
+		index[stem] = deviceID
+		content, err := json.Marshal(index)
+		if err != nil {
+			return NewStorageError("write", m.indexPath(), err)
+		}
+		if err := m.writeAtomic(m.indexPath(), content); err != nil {
+			return NewStorageError("write", m.indexPath(), wrapFSError(err))
+		}
+
+		m.logger.Info("stored long device ID under hashed file name",
+			log.String("device_id", deviceID),
+			log.String("file_name", stem+deviceFileExt))
+	}
+
+	if m.indexed == nil {
+		m.indexed = make(map[string]bool)
+	}
+	m.indexed[stem] = true
+	return nil
+}
+
+// indexPath returns the path of the device index
+func (m *FileStorageManager) indexPath() string {
+	return filepath.Join(m.config.DataDir, deviceIndexFileName)
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+func TestHashedFileStem(t *testing.T) {
+	long := strings.Repeat("SN", 150)
+	stem := hashedFileStem(long)
+
+	if !strings.HasPrefix(stem, long[:hashedPrefixBytes]+"~") {
+		t.Errorf("hashedFileStem() = %q, want the first %d bytes of the ID as prefix", stem, hashedPrefixBytes)
+	}
+	if len(stem) != hashedPrefixBytes+1+64 {
+		t.Errorf("hashedFileStem() length = %d, want %d", len(stem), hashedPrefixBytes+1+64)
+	}
+	if hashedFileStem(long+"x") == stem {
+		t.Error("Expected different IDs with the same prefix to get different file names")
+	}
+
+	// The prefix is cut at a character boundary
+	multiByte := strings.Repeat("a", hashedPrefixBytes-1) + strings.Repeat("设", 100)
+	prefix, _, _ := strings.Cut(hashedFileStem(multiByte), "~")
+	if prefix != strings.Repeat("a", hashedPrefixBytes-1) {
+		t.Errorf("hashedFileStem() prefix = %q, want the multi-byte character dropped", prefix)
+	}
+}
+
+func TestFileStorageManager_HashLongDeviceIDs(t *testing.T) {
+	dir := t.TempDir()
+	config := DefaultConfig()
+	config.DataDir = dir
+	config.HashLongDeviceIDs = true
+	sm, err := NewFileStorageManager(config, log.NewTestLogger())
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	manager := sm.(*FileStorageManager)
+
+	longID := "serial-" + strings.Repeat("0123456789", 30)
+	now := time.Now().UnixMilli()
+	if err := manager.Write(longID, &PowerData{Timestamp: now, EnergyWH: 12.5}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := manager.Write("ups-1", &PowerData{Timestamp: now, EnergyWH: 3}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	// Long IDs get a hashed file name, short IDs keep their plain name
+	if _, err := os.Stat(filepath.Join(dir, hashedFileStem(longID)+deviceFileExt)); err != nil {
+		t.Errorf("Expected hashed device file: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ups-1"+deviceFileExt)); err != nil {
+		t.Errorf("Expected plain device file: %v", err)
+	}
+
+	data, err := manager.Read(longID)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if data.EnergyWH != 12.5 {
+		t.Errorf("Read() energy = %v, want 12.5", data.EnergyWH)
+	}
+	if exists, err := manager.Exists(longID); err != nil || !exists {
+		t.Errorf("Exists() = %v, %v, want true", exists, err)
+	}
+
+	// ReadAll reports the original ID through the index
+	all, err := manager.ReadAll(context.Background())
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if len(all) != 2 || all[longID] == nil || all["ups-1"] == nil {
+		t.Errorf("ReadAll() keys = %v, want the long ID and ups-1", keys(all))
+	}
+
+	// An unreadable index is replaced on the next write of a hashed device
+	if err := os.WriteFile(manager.indexPath(), []byte("{"), 0644); err != nil {
+		t.Fatalf("Failed to corrupt index: %v", err)
+	}
+	manager.indexed = nil
+	if err := manager.Write(longID, &PowerData{Timestamp: now, EnergyWH: 13}); err != nil {
+		t.Fatalf("Write() with corrupt index error = %v", err)
+	}
+	index, err := readDeviceIndex(dir)
+	if err != nil {
+		t.Fatalf("readDeviceIndex() error = %v", err)
+	}
+	if index[hashedFileStem(longID)] != longID {
+		t.Errorf("readDeviceIndex() = %v, want the long ID indexed", index)
+	}
+}
+
+func TestBatch_HashLongDeviceIDs(t *testing.T) {
+	manager, dir := newBatchManager(t, true)
+	manager.config.HashLongDeviceIDs = true
+
+	longID := strings.Repeat("x", maxPlainDeviceIDBytes+1)
+	batch := manager.NewBatch()
+	if err := batch.Write(longID, &PowerData{Timestamp: time.Now().UnixMilli(), EnergyWH: 7}); err != nil {
+		t.Fatalf("Batch.Write() error = %v", err)
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, hashedFileStem(longID)+deviceFileExt)); err != nil {
+		t.Errorf("Expected hashed device file: %v", err)
+	}
+	all, err := manager.ReadAll(context.Background())
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if all[longID] == nil || all[longID].EnergyWH != 7 {
+		t.Errorf("ReadAll() = %v, want the long ID with 7 Wh", keys(all))
+	}
+}
+
+// keys returns the device IDs of a ReadAll result
+func keys(all map[string]*PowerData) []string {
+	ids := make([]string, 0, len(all))
+	for id := range all {
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -1,5 +1,7 @@
 package storage
 
+import "context"
+
 // StorageManager defines the interface for storage operations.
 // It provides methods to read and write power data for devices.
 type StorageManager interface {
@@ -12,6 +14,43 @@ type StorageManager interface {
 	// For new devices (file doesn't exist), it returns default initialized data.
 	// Returns an error if the device ID is invalid or read operation fails.
 	Read(deviceID string) (*PowerData, error)
+
+	// ReadAll retrieves power data for every device with stored data, keyed by device ID.
+	// Returns an empty map if the data directory doesn't exist yet.
+	// If ctx is cancelled during the scan, the devices read so far are
+	// returned with an error wrapping ErrPartialRead.
+	ReadAll(ctx context.Context) (map[string]*PowerData, error)
+}
+
+// BatchStorage is optionally implemented by a StorageManager that can persist
+// the writes of one collection cycle together.
+type BatchStorage interface {
+	// NewBatch starts a batch of writes, or returns nil when batch writes
+	// are disabled.
+	NewBatch() *Batch
+}
+
+// DeviceChecker is optionally implemented by a StorageManager that can tell
+// a device without stored data apart from one whose data is zero.
+type DeviceChecker interface {
+	// Exists reports whether power data has been stored for a device.
+	// Returns an error if the device ID is invalid.
+	Exists(deviceID string) (bool, error)
+}
+
+// Flusher is optionally implemented by a StorageManager that can hold writes
+// back in memory, such as FileStorageManager with MinPersistInterval set.
+type Flusher interface {
+	// Flush persists all writes held back in memory.
+	Flush() error
+}
+
+// JournalRecoverer is optionally implemented by a StorageManager that
+// journals batch writes and may need to complete an interrupted batch.
+type JournalRecoverer interface {
+	// RecoverJournal replays a batch journal left behind by an interrupted
+	// commit, if any.
+	RecoverJournal() error
 }
 
 // FileWriter defines the interface for writing device data to files.
@@ -1,6 +1,12 @@
 package storage
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
 	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
 )
 
@@ -19,6 +25,22 @@ type FileStorageManager struct {
 	reader FileReader
 	writer FileWriter
 	logger log.Logger
+
+	// batchMu serializes batch commits and journal replay
+	batchMu sync.Mutex
+
+	// indexMu guards indexed, the hashed file names already recorded in the
+	// device index by this process
+	indexMu sync.Mutex
+	indexed map[string]bool
+
+	// persistMu guards the per-device state of MinPersistInterval: when each
+	// device was last persisted, the writes held back since and the locks
+	// serializing the persists of each device
+	persistMu   sync.Mutex
+	lastPersist map[string]time.Time
+	deferred    map[string]*PowerData
+	deviceLocks map[string]*sync.Mutex
 }
 
 // NewFileStorageManager creates a new FileStorageManager with the given configuration.
@@ -38,6 +60,8 @@ type FileStorageManager struct {
 //
 // The returned manager is safe to use immediately. The data directory will be
 // created automatically on the first write operation if it doesn't exist.
+// No file is touched here; with BatchWrite, call RecoverJournal before the
+// first read to complete a batch interrupted by a previous shutdown.
 //
 // Example:
 //
@@ -55,12 +79,14 @@ func NewFileStorageManager(config *Config, logger log.Logger) (StorageManager, e
 	reader := NewFileReader(config, logger)
 	writer := NewFileWriter(config, logger)
 
-	return &FileStorageManager{
+	manager := &FileStorageManager{
 		config: config,
 		reader: reader,
 		writer: writer,
 		logger: logger,
-	}, nil
+	}
+
+	return manager, nil
 }
 
 // Write stores power data for a device.
@@ -81,6 +107,11 @@ func NewFileStorageManager(config *Config, logger log.Logger) (StorageManager, e
 // The write operation is atomic (uses temp file + rename) to ensure that files
 // are either fully written or not written at all, even if the process crashes.
 //
+// With Config.MinPersistInterval set, a write within that interval of the
+// device's last persist is held in memory instead: Read, Exists and ReadAll
+// return it at once, and it is persisted by the device's next write after the
+// interval or by Flush.
+//
 // Example:
 //
 //	data := &storage.PowerData{
@@ -91,16 +122,35 @@ func NewFileStorageManager(config *Config, logger log.Logger) (StorageManager, e
 //	    log.Printf("failed to write: %v", err)
 //	}
 func (m *FileStorageManager) Write(deviceID string, data *PowerData) error {
-	if err := m.writer.Write(deviceID, data); err != nil {
+	unlock := m.lockDevices(deviceID)
+	defer unlock()
+
+	if m.deferWrite(deviceID, data) {
+		m.logger.Debug("deferred device data write",
+			log.String("device_id", deviceID))
+		return nil
+	}
+
+	if err := m.writeDevice(deviceID, data); err != nil {
 		m.logger.Error("failed to write device data",
 			log.String("device_id", deviceID),
 			log.Err(err))
 		return err
 	}
+	m.markPersisted(deviceID)
 
 	return nil
 }
 
+// writeDevice records a hashed file name in the device index before writing
+// the device file, so that a stored file can always be traced back to its ID
+func (m *FileStorageManager) writeDevice(deviceID string, data *PowerData) error {
+	if err := m.indexDeviceID(deviceID); err != nil {
+		return err
+	}
+	return m.writer.Write(deviceID, data)
+}
+
 // Read retrieves power data for a device.
 //
 // This method:
@@ -134,6 +184,11 @@ func (m *FileStorageManager) Read(deviceID string) (*PowerData, error) {
 	m.logger.Debug("reading device data",
 		log.String("device_id", deviceID))
 
+	// A deferred write is newer than the device file
+	if data, ok := m.deferredData(deviceID); ok {
+		return data, nil
+	}
+
 	data, err := m.reader.Read(deviceID)
 	if err != nil {
 		m.logger.Error("failed to read device data",
@@ -149,3 +204,101 @@ func (m *FileStorageManager) Read(deviceID string) (*PowerData, error) {
 
 	return data, nil
 }
+
+// Exists reports whether power data has been stored for a device.
+//
+// Unlike Read, which returns default data for unknown devices, Exists lets
+// callers tell a device that was never written apart from one with zero
+// energy.
+func (m *FileStorageManager) Exists(deviceID string) (bool, error) {
+	filePath, err := m.config.deviceFilePath(deviceID)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := m.deferredData(deviceID); ok {
+		return true, nil
+	}
+
+	if _, err := os.Stat(filePath); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, NewStorageError("stat", filePath, err)
+	}
+	return true, nil
+}
+
+// ReadAll retrieves power data for every device stored in the data directory.
+//
+// Device IDs are derived from the data file names, so only devices that have
+// been written at least once are returned. A missing data directory yields an
+// empty map. Any unreadable or invalid device file fails the whole call.
+//
+// The scan is bounded by ctx and by ReadAllTimeout. When either ends it, the
+// devices read so far are returned together with an error wrapping
+// ErrPartialRead and the context error, so callers can decide whether a
+// partial result is good enough.
+//
+// Example:
+//
+//	all, err := manager.ReadAll(ctx)
+//	if errors.Is(err, storage.ErrPartialRead) {
+//	    log.Printf("using partial result: %v", err)
+//	} else if err != nil {
+//	    log.Printf("failed to read all: %v", err)
+//	    return
+//	}
+//	for deviceID, data := range all {
+//	    fmt.Printf("%s: %.2f WH\n", deviceID, data.EnergyWH)
+//	}
+func (m *FileStorageManager) ReadAll(ctx context.Context) (map[string]*PowerData, error) {
+	if m.config.ReadAllTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.config.ReadAllTimeout)
+		defer cancel()
+	}
+
+	ids, err := listDeviceIDs(m.config.DataDir)
+	if err != nil {
+		m.logger.Error("failed to list device files",
+			log.String("data_dir", m.config.DataDir),
+			log.Err(err))
+		return nil, err
+	}
+
+	all := make(map[string]*PowerData, len(ids))
+	var partialErr error
+	for _, deviceID := range ids {
+		if err := ctx.Err(); err != nil {
+			partialErr = fmt.Errorf("%w: read %d of %d device files: %w", ErrPartialRead, len(all), len(ids), err)
+			break
+		}
+		data, err := m.Read(deviceID)
+		if err != nil {
+			return nil, err
+		}
+		all[deviceID] = data
+	}
+
+	// Deferred writes of devices whose file has gone missing
+	m.persistMu.Lock()
+	for deviceID, data := range m.deferred {
+		if _, ok := all[deviceID]; !ok {
+			all[deviceID] = copyPowerData(data)
+		}
+	}
+	m.persistMu.Unlock()
+
+	if partialErr != nil {
+		m.logger.Warn("device data scan interrupted, returning partial result",
+			log.Int("device_count", len(all)),
+			log.Int("file_count", len(ids)),
+			log.Err(partialErr))
+		return all, partialErr
+	}
+
+	m.logger.Debug("all device data read successfully",
+		log.Int("device_count", len(all)))
+
+	return all, nil
+}
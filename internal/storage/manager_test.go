@@ -1,7 +1,10 @@
 package storage
 
 import (
+	"context"
+	"errors"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -145,6 +148,32 @@ func TestFileStorageManager_Read_NonExistent(t *testing.T) {
 	}
 }
 
+func TestFileStorageManager_Exists(t *testing.T) {
+	manager, err := NewFileStorageManager(&Config{
+		DataDir:         t.TempDir(),
+		FilePermissions: 0644,
+	}, log.NewTestLogger())
+	if err != nil {
+		t.Fatalf("failed to create storage manager: %v", err)
+	}
+	checker := manager.(DeviceChecker)
+
+	if exists, err := checker.Exists("device-1"); err != nil || exists {
+		t.Errorf("Exists() = %v, %v, want false, nil before write", exists, err)
+	}
+
+	if err := manager.Write("device-1", &PowerData{Timestamp: time.Now().UnixMilli()}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if exists, err := checker.Exists("device-1"); err != nil || !exists {
+		t.Errorf("Exists() = %v, %v, want true, nil after write", exists, err)
+	}
+
+	if _, err := checker.Exists("../escape"); err == nil {
+		t.Error("Exists() error = nil, want error for invalid device ID")
+	}
+}
+
 func TestFileStorageManager_MultiDevice(t *testing.T) {
 	// Create a temporary directory for testing
 	tmpDir, err := os.MkdirTemp("", "storage-test-*")
@@ -267,3 +296,119 @@ func TestFileStorageManager_Update(t *testing.T) {
 		t.Errorf("Read() EnergyWH = %v, want %v", data.EnergyWH, updatedData.EnergyWH)
 	}
 }
+
+func TestFileStorageManager_ReadAll(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	logger := log.NewTestLogger()
+	config := &Config{
+		DataDir:         tmpDir,
+		FilePermissions: 0644,
+	}
+
+	manager, err := NewFileStorageManager(config, logger)
+	if err != nil {
+		t.Fatalf("failed to create storage manager: %v", err)
+	}
+
+	now := time.Now().UnixMilli()
+	want := map[string]float64{"device1": 1000.0, "device2": 2000.5}
+	for id, energy := range want {
+		if err := manager.Write(id, &PowerData{Timestamp: now, EnergyWH: energy}); err != nil {
+			t.Fatalf("Write(%s) error = %v, want nil", id, err)
+		}
+	}
+
+	// Non-device entries are ignored
+	if err := os.WriteFile(filepath.Join(tmpDir, "device3.txt.tmp"), []byte("partial"), 0644); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "device1.history.ndjson"), []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("failed to write history file: %v", err)
+	}
+
+	all, err := manager.ReadAll(context.Background())
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v, want nil", err)
+	}
+	if len(all) != len(want) {
+		t.Fatalf("ReadAll() returned %d devices, want %d", len(all), len(want))
+	}
+	for id, energy := range want {
+		data, ok := all[id]
+		if !ok {
+			t.Fatalf("ReadAll() missing %s", id)
+		}
+		if data.EnergyWH != energy || data.Timestamp != now {
+			t.Errorf("ReadAll()[%s] = %+v, want energy %v at %v", id, data, energy, now)
+		}
+	}
+}
+
+func TestFileStorageManager_ReadAll_Cancelled(t *testing.T) {
+	config := &Config{
+		DataDir:         t.TempDir(),
+		FilePermissions: 0644,
+	}
+
+	manager, err := NewFileStorageManager(config, log.NewTestLogger())
+	if err != nil {
+		t.Fatalf("failed to create storage manager: %v", err)
+	}
+	for _, id := range []string{"device1", "device2"} {
+		if err := manager.Write(id, &PowerData{Timestamp: time.Now().UnixMilli(), EnergyWH: 100}); err != nil {
+			t.Fatalf("Write(%s) error = %v, want nil", id, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	all, err := manager.ReadAll(ctx)
+	if !errors.Is(err, ErrPartialRead) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("ReadAll() error = %v, want ErrPartialRead wrapping context.Canceled", err)
+	}
+	if all == nil || len(all) != 0 {
+		t.Errorf("ReadAll() = %v, want an empty partial result", all)
+	}
+}
+
+func TestFileStorageManager_ReadAll_Timeout(t *testing.T) {
+	config := &Config{
+		DataDir:         t.TempDir(),
+		FilePermissions: 0644,
+		ReadAllTimeout:  time.Nanosecond,
+	}
+
+	manager, err := NewFileStorageManager(config, log.NewTestLogger())
+	if err != nil {
+		t.Fatalf("failed to create storage manager: %v", err)
+	}
+	if err := manager.Write("device1", &PowerData{Timestamp: time.Now().UnixMilli(), EnergyWH: 100}); err != nil {
+		t.Fatalf("Write() error = %v, want nil", err)
+	}
+
+	if _, err := manager.ReadAll(context.Background()); !errors.Is(err, ErrPartialRead) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ReadAll() error = %v, want ErrPartialRead wrapping context.DeadlineExceeded", err)
+	}
+}
+
+func TestFileStorageManager_ReadAll_MissingDir(t *testing.T) {
+	config := &Config{
+		DataDir:         filepath.Join(t.TempDir(), "missing"),
+		FilePermissions: 0644,
+	}
+
+	manager, err := NewFileStorageManager(config, log.NewTestLogger())
+	if err != nil {
+		t.Fatalf("failed to create storage manager: %v", err)
+	}
+
+	all, err := manager.ReadAll(context.Background())
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v, want nil", err)
+	}
+	if len(all) != 0 {
+		t.Errorf("ReadAll() returned %d devices, want 0", len(all))
+	}
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+// metadataFileName is the name of the device metadata file in the data
+// directory. Like the batch journal it starts with a dot so that it is never
+// mistaken for a device file.
+const metadataFileName = ".devices.json"
+
+// DeviceMetadata describes a device whose energy is stored, so that its
+// metrics can be restored with the right labels before the first collection
+// after a restart.
+type DeviceMetadata struct {
+	// DeviceName is the device name reported by WinPower
+	DeviceName string `json:"device_name"`
+
+	// DeviceType is the device type reported by WinPower
+	DeviceType int `json:"device_type"`
+
+	// EnergyKey is the key the device's energy is stored under, when it
+	// differs from the device ID (e.g. a stable hardware identity)
+	EnergyKey string `json:"energy_key,omitempty"`
+}
+
+// MetadataStorage is optionally implemented by a StorageManager that can
+// persist device metadata next to the device data.
+type MetadataStorage interface {
+	// ReadMetadata returns the stored metadata keyed by device ID, or an
+	// empty map if none has been written yet.
+	ReadMetadata() (map[string]DeviceMetadata, error)
+
+	// WriteMetadata replaces the stored metadata.
+	WriteMetadata(devices map[string]DeviceMetadata) error
+}
+
+// ReadMetadata returns the stored device metadata keyed by device ID.
+//
+// A missing metadata file yields an empty map. The metadata is advisory, so
+// callers should treat an error as if no metadata were stored.
+func (m *FileStorageManager) ReadMetadata() (map[string]DeviceMetadata, error) {
+	path := m.metadataPath()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string]DeviceMetadata{}, nil
+		}
+		return nil, NewStorageError("read", path, wrapFSError(err))
+	}
+
+	devices := make(map[string]DeviceMetadata)
+	if err := json.Unmarshal(content, &devices); err != nil {
+		return nil, NewStorageError("read", path, err)
+	}
+	return devices, nil
+}
+
+// WriteMetadata atomically replaces the stored device metadata.
+func (m *FileStorageManager) WriteMetadata(devices map[string]DeviceMetadata) error {
+	path := m.metadataPath()
+	content, err := json.Marshal(devices)
+	if err != nil {
+		return NewStorageError("write", path, err)
+	}
+
+	if err := m.writeAtomic(path, content); err != nil {
+		m.logger.Warn("failed to write device metadata",
+			log.String("path", path),
+			log.Err(err))
+		return NewStorageError("write", path, wrapFSError(err))
+	}
+
+	m.logger.Debug("device metadata written",
+		log.Int("device_count", len(devices)))
+	return nil
+}
+
+// metadataPath returns the path of the device metadata file
+func (m *FileStorageManager) metadataPath() string {
+	return filepath.Join(m.config.DataDir, metadataFileName)
+}
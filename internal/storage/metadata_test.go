@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStorageManager_Metadata(t *testing.T) {
+	manager, dir := newBatchManager(t, false)
+
+	devices, err := manager.ReadMetadata()
+	if err != nil {
+		t.Fatalf("ReadMetadata() error = %v", err)
+	}
+	if len(devices) != 0 {
+		t.Errorf("Expected no metadata before the first write, got %v", devices)
+	}
+
+	want := map[string]DeviceMetadata{
+		"ups-1": {DeviceName: "UPS 1", DeviceType: 1},
+		"ups-2": {DeviceName: "UPS 2", DeviceType: 1, EnergyKey: "serial-SN2"},
+	}
+	if err := manager.WriteMetadata(want); err != nil {
+		t.Fatalf("WriteMetadata() error = %v", err)
+	}
+
+	got, err := manager.ReadMetadata()
+	if err != nil {
+		t.Fatalf("ReadMetadata() error = %v", err)
+	}
+	if len(got) != len(want) || got["ups-1"] != want["ups-1"] || got["ups-2"] != want["ups-2"] {
+		t.Errorf("ReadMetadata() = %v, want %v", got, want)
+	}
+
+	// The metadata file is not a device file
+	if err := manager.Write("ups-1", &PowerData{Timestamp: time.Now().UnixMilli(), EnergyWH: 1}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	all, err := manager.ReadAll(context.Background())
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if len(all) != 1 {
+		t.Errorf("Expected only the device file to be read, got %v", all)
+	}
+
+	// A corrupt file is reported
+	if err := os.WriteFile(filepath.Join(dir, metadataFileName), []byte("{"), 0644); err != nil {
+		t.Fatalf("Failed to corrupt metadata: %v", err)
+	}
+	if _, err := manager.ReadMetadata(); err == nil {
+		t.Error("Expected an error for corrupt metadata")
+	}
+}
@@ -2,12 +2,34 @@ package storage
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 )
 
+// deviceFileExt is the extension of per-device data files
+const deviceFileExt = ".txt"
+
+// windowsFileNames enables the Windows file name rules in validateDeviceID.
+// It is a variable so that tests can exercise the rules on any platform.
+var windowsFileNames = runtime.GOOS == "windows"
+
+// windowsReservedNames are the device names Windows reserves in every
+// directory, with or without an extension
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
 // validateDeviceID checks if a device ID is valid.
 // Device IDs must be non-empty and not contain path separators or relative path components.
+// Both '/' and '\' are rejected on every platform, so that data directories
+// can be moved between platforms. On Windows the ID must also be a valid
+// Windows file name (see validateWindowsFileName).
 func validateDeviceID(deviceID string) error {
 	if deviceID == "" {
 		return fmt.Errorf("%w: device ID cannot be empty", ErrInvalidDeviceID)
@@ -28,6 +50,32 @@ func validateDeviceID(deviceID string) error {
 		return fmt.Errorf("%w: device ID cannot start with a dot", ErrInvalidDeviceID)
 	}
 
+	if windowsFileNames {
+		return validateWindowsFileName(deviceID)
+	}
+	return nil
+}
+
+// validateWindowsFileName checks that a device ID names a file of its own in
+// a Windows directory. Windows treats ':' as a drive or alternate data stream
+// separator, maps reserved names such as NUL to devices and strips trailing
+// dots and spaces, so that "ups-1." would share the file of "ups-1".
+func validateWindowsFileName(deviceID string) error {
+	if i := strings.IndexFunc(deviceID, func(r rune) bool {
+		return r < 0x20 || strings.ContainsRune(`:*?"<>|`, r)
+	}); i >= 0 {
+		return fmt.Errorf("%w: device ID cannot contain %q on Windows", ErrInvalidDeviceID, deviceID[i])
+	}
+
+	if strings.HasSuffix(deviceID, ".") || strings.HasSuffix(deviceID, " ") {
+		return fmt.Errorf("%w: device ID cannot end with a dot or space on Windows", ErrInvalidDeviceID)
+	}
+
+	base, _, _ := strings.Cut(deviceID, ".")
+	if windowsReservedNames[strings.ToUpper(strings.TrimRight(base, " "))] {
+		return fmt.Errorf("%w: device ID %q is a reserved name on Windows", ErrInvalidDeviceID, deviceID)
+	}
+
 	return nil
 }
 
@@ -39,7 +87,7 @@ func buildFilePath(dataDir, deviceID string) (string, error) {
 	}
 
 	// Construct the file path
-	fileName := deviceID + ".txt"
+	fileName := deviceID + deviceFileExt
 	filePath := filepath.Join(dataDir, fileName)
 
 	// Clean the path to resolve any relative components
@@ -53,10 +101,48 @@ func buildFilePath(dataDir, deviceID string) (string, error) {
 		return "", fmt.Errorf("%w: failed to validate file path", ErrInvalidDeviceID)
 	}
 
-	// Check if the relative path tries to escape the data directory
-	if strings.HasPrefix(relPath, "..") || strings.HasPrefix(relPath, string(filepath.Separator)) {
+	// Check if the relative path tries to escape the data directory. IsLocal
+	// follows the platform's rules, e.g. it rejects volume names on Windows.
+	if !filepath.IsLocal(relPath) {
 		return "", fmt.Errorf("%w: device ID would escape data directory", ErrInvalidDeviceID)
 	}
 
 	return filePath, nil
 }
+
+// listDeviceIDs returns the IDs of all devices with a data file in dataDir.
+// Entries that are not valid device files (temp files, directories, invalid
+// IDs) are skipped. A missing data directory yields no IDs.
+//
+// Hashed file names are mapped back to the original device IDs through the
+// device index. Without a readable index they are listed under their file
+// name, which still reads the right file when hashing is enabled.
+func listDeviceIDs(dataDir string) ([]string, error) {
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, NewStorageError("list", dataDir, err)
+	}
+
+	index, _ := readDeviceIndex(dataDir)
+
+	var ids []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, deviceFileExt) {
+			continue
+		}
+		deviceID := strings.TrimSuffix(name, deviceFileExt)
+		if original, ok := index[deviceID]; ok {
+			deviceID = original
+		}
+		if validateDeviceID(deviceID) != nil {
+			continue
+		}
+		ids = append(ids, deviceID)
+	}
+
+	return ids, nil
+}
@@ -171,3 +171,51 @@ func TestBuildFilePath(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateDeviceID_WindowsFileNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		deviceID string
+		errMsg   string
+	}{
+		{name: "drive letter", deviceID: "C:ups", errMsg: `cannot contain ':' on Windows`},
+		{name: "alternate data stream", deviceID: "ups-1:stream", errMsg: `cannot contain ':' on Windows`},
+		{name: "wildcard", deviceID: "ups*", errMsg: `cannot contain '*' on Windows`},
+		{name: "control character", deviceID: "ups\x01", errMsg: "on Windows"},
+		{name: "trailing dot", deviceID: "ups-1.", errMsg: "cannot end with a dot or space"},
+		{name: "trailing space", deviceID: "ups-1 ", errMsg: "cannot end with a dot or space"},
+		{name: "reserved name", deviceID: "nul", errMsg: "reserved name"},
+		{name: "reserved name with extension", deviceID: "COM1.backup", errMsg: "reserved name"},
+		{name: "valid with dot", deviceID: "rack-1.ups-2"},
+		{name: "valid containing a reserved name", deviceID: "console-ups"},
+	}
+
+	// The rules only apply on Windows; on other platforms these IDs are valid
+	// file names and existing data files must keep working
+	if !windowsFileNames {
+		for _, tt := range tests {
+			if err := validateDeviceID(tt.deviceID); err != nil {
+				t.Errorf("validateDeviceID(%q) = %v, want nil outside Windows", tt.deviceID, err)
+			}
+		}
+	}
+
+	old := windowsFileNames
+	windowsFileNames = true
+	defer func() { windowsFileNames = old }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDeviceID(tt.deviceID)
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("validateDeviceID() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !contains(err.Error(), tt.errMsg) {
+				t.Errorf("validateDeviceID() error = %v, want error containing %q", err, tt.errMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+//go:build windows
+
+package storage
+
+import "testing"
+
+func TestBuildFilePath_WindowsDataDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		dataDir string
+		want    string
+	}{
+		{name: "drive letter", dataDir: `C:\ProgramData\winpower\data`, want: `C:\ProgramData\winpower\data\ups-1.txt`},
+		{name: "forward slashes", dataDir: "C:/ProgramData/winpower/data", want: `C:\ProgramData\winpower\data\ups-1.txt`},
+		{name: "drive relative", dataDir: `D:data`, want: `D:data\ups-1.txt`},
+		{name: "UNC share", dataDir: `\\server\share\winpower`, want: `\\server\share\winpower\ups-1.txt`},
+		{name: "relative", dataDir: `.\data`, want: `data\ups-1.txt`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildFilePath(tt.dataDir, "ups-1")
+			if err != nil {
+				t.Fatalf("buildFilePath() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("buildFilePath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildFilePath_WindowsRejectsVolumeNames(t *testing.T) {
+	for _, deviceID := range []string{`C:ups`, `ups\..\..\x`, "NUL", "ups."} {
+		if _, err := buildFilePath(`C:\data`, deviceID); err == nil {
+			t.Errorf("buildFilePath(%q) error = nil, want error", deviceID)
+		}
+	}
+}
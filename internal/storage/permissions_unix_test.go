@@ -0,0 +1,56 @@
+//go:build !windows
+
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+func TestPermissionsIgnoreUmask(t *testing.T) {
+	oldMask := syscall.Umask(0077)
+	defer syscall.Umask(oldMask)
+
+	config := &Config{
+		DataDir:         filepath.Join(t.TempDir(), "shared", "data"),
+		FilePermissions: 0660,
+		DirPermissions:  0770,
+	}
+
+	if err := CheckWritable(config.DataDir, config.DirPermissions); err != nil {
+		t.Fatalf("CheckWritable() error = %v", err)
+	}
+	assertMode(t, config.DataDir, 0770)
+
+	writer := NewFileWriter(config, log.NewTestLogger())
+	if err := writer.Write("device1", &PowerData{Timestamp: time.Now().UnixMilli(), EnergyWH: 1}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	assertMode(t, filepath.Join(config.DataDir, "device1.txt"), 0660)
+
+	// Existing directories keep their mode
+	existing := t.TempDir()
+	if err := os.Chmod(existing, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckWritable(existing, 0770); err != nil {
+		t.Fatalf("CheckWritable() error = %v", err)
+	}
+	assertMode(t, existing, 0700)
+}
+
+func assertMode(t *testing.T, path string, want os.FileMode) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat %s: %v", path, err)
+	}
+	if got := info.Mode().Perm(); got != want {
+		t.Errorf("%s mode = %04o, want %04o", filepath.Base(path), got, want)
+	}
+}
@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+// lockDevices locks the persists of the given devices, in a fixed order so
+// that concurrent callers cannot deadlock, and returns the function unlocking
+// them. Deferred writes exist only with MinPersistInterval, so nothing is
+// locked without it.
+func (m *FileStorageManager) lockDevices(deviceIDs ...string) func() {
+	if m.config.MinPersistInterval <= 0 {
+		return func() {}
+	}
+
+	sorted := append([]string(nil), deviceIDs...)
+	sort.Strings(sorted)
+
+	m.persistMu.Lock()
+	if m.deviceLocks == nil {
+		m.deviceLocks = make(map[string]*sync.Mutex)
+	}
+	locks := make([]*sync.Mutex, 0, len(sorted))
+	for _, deviceID := range sorted {
+		mu, ok := m.deviceLocks[deviceID]
+		if !ok {
+			mu = &sync.Mutex{}
+			m.deviceLocks[deviceID] = mu
+		}
+		locks = append(locks, mu)
+	}
+	m.persistMu.Unlock()
+
+	for _, mu := range locks {
+		mu.Lock()
+	}
+	return func() {
+		for i := len(locks) - 1; i >= 0; i-- {
+			locks[i].Unlock()
+		}
+	}
+}
+
+// deferWrite holds a write back in memory when the device was persisted less
+// than MinPersistInterval ago. It reports whether the write was deferred; the
+// caller persists it otherwise. Invalid writes are never deferred, so that
+// persisting them reports the error.
+func (m *FileStorageManager) deferWrite(deviceID string, data *PowerData) bool {
+	if m.config.MinPersistInterval <= 0 {
+		return false
+	}
+	if validateDeviceID(deviceID) != nil || data.ValidateWithFutureTolerance(m.config.futureTolerance()) != nil {
+		return false
+	}
+
+	m.persistMu.Lock()
+	defer m.persistMu.Unlock()
+
+	last, ok := m.lastPersist[deviceID]
+	if !ok || time.Since(last) >= m.config.MinPersistInterval {
+		return false
+	}
+
+	if m.deferred == nil {
+		m.deferred = make(map[string]*PowerData)
+	}
+	m.deferred[deviceID] = copyPowerData(data)
+	return true
+}
+
+// markPersisted records that the device was just persisted, superseding any
+// deferred write
+func (m *FileStorageManager) markPersisted(deviceID string) {
+	if m.config.MinPersistInterval <= 0 {
+		return
+	}
+
+	m.persistMu.Lock()
+	defer m.persistMu.Unlock()
+
+	if m.lastPersist == nil {
+		m.lastPersist = make(map[string]time.Time)
+	}
+	m.lastPersist[deviceID] = time.Now()
+	delete(m.deferred, deviceID)
+}
+
+// deferredData returns a copy of the deferred write of a device, if any
+func (m *FileStorageManager) deferredData(deviceID string) (*PowerData, bool) {
+	m.persistMu.Lock()
+	defer m.persistMu.Unlock()
+
+	data, ok := m.deferred[deviceID]
+	if !ok {
+		return nil, false
+	}
+	return copyPowerData(data), true
+}
+
+// Flush persists the writes deferred by MinPersistInterval, e.g. before
+// shutdown. Every device is attempted; the first error is returned and the
+// failed devices stay deferred.
+//
+// Each device is flushed under its persist lock, so a concurrent write of
+// the device either supersedes the deferred write before it is flushed or
+// persists after it; stale deferred data never overwrites newer data.
+func (m *FileStorageManager) Flush() error {
+	m.persistMu.Lock()
+	deviceIDs := make([]string, 0, len(m.deferred))
+	for deviceID := range m.deferred {
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	m.persistMu.Unlock()
+	sort.Strings(deviceIDs)
+
+	var firstErr error
+	flushed := 0
+	for _, deviceID := range deviceIDs {
+		ok, err := m.flushDevice(deviceID)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if ok {
+			flushed++
+		}
+	}
+
+	if flushed > 0 {
+		m.logger.Debug("flushed deferred device data",
+			log.Int("device_count", flushed))
+	}
+	return firstErr
+}
+
+// flushDevice persists the deferred write of a device, if it still has one,
+// and reports whether it did
+func (m *FileStorageManager) flushDevice(deviceID string) (bool, error) {
+	unlock := m.lockDevices(deviceID)
+	defer unlock()
+
+	// A write since the snapshot of the deferred devices persisted newer data
+	m.persistMu.Lock()
+	data, ok := m.deferred[deviceID]
+	m.persistMu.Unlock()
+	if !ok {
+		return false, nil
+	}
+
+	if err := m.writeDevice(deviceID, data); err != nil {
+		m.logger.Error("failed to flush deferred device data",
+			log.String("device_id", deviceID),
+			log.Err(err))
+		return false, err
+	}
+
+	m.persistMu.Lock()
+	m.lastPersist[deviceID] = time.Now()
+	delete(m.deferred, deviceID)
+	m.persistMu.Unlock()
+	return true, nil
+}
+
+// copyPowerData returns a deep copy of data
+func copyPowerData(data *PowerData) *PowerData {
+	copied := *data
+	if data.DeviceEnergyWH != nil {
+		deviceEnergy := *data.DeviceEnergyWH
+		copied.DeviceEnergyWH = &deviceEnergy
+	}
+	return &copied
+}
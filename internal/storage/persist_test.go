@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// readFileEnergy returns the energy stored in a device file
+func readFileEnergy(t *testing.T, manager *FileStorageManager, deviceID string) float64 {
+	t.Helper()
+	data, err := manager.reader.Read(deviceID)
+	if err != nil {
+		t.Fatalf("Failed to read device file: %v", err)
+	}
+	return data.EnergyWH
+}
+
+func TestFileStorageManager_MinPersistInterval(t *testing.T) {
+	manager, _ := newBatchManager(t, false)
+	manager.config.MinPersistInterval = time.Hour
+
+	now := time.Now().UnixMilli()
+	for i, energy := range []float64{1, 2, 3} {
+		if err := manager.Write("ups-1", &PowerData{Timestamp: now + int64(i), EnergyWH: energy}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	// Only the first write reaches the file, reads see the latest value
+	if energy := readFileEnergy(t, manager, "ups-1"); energy != 1 {
+		t.Errorf("Expected the first write persisted, file has %v Wh", energy)
+	}
+	data, err := manager.Read("ups-1")
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if data.EnergyWH != 3 {
+		t.Errorf("Read() energy = %v, want 3", data.EnergyWH)
+	}
+	all, err := manager.ReadAll(context.Background())
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if all["ups-1"] == nil || all["ups-1"].EnergyWH != 3 {
+		t.Errorf("ReadAll() = %v, want ups-1 with 3 Wh", all["ups-1"])
+	}
+
+	// Invalid data is rejected rather than deferred
+	if err := manager.Write("ups-1", &PowerData{Timestamp: now, EnergyWH: -1}); err == nil {
+		t.Error("Expected invalid data to be rejected")
+	}
+
+	if err := manager.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if energy := readFileEnergy(t, manager, "ups-1"); energy != 3 {
+		t.Errorf("Expected the deferred write flushed, file has %v Wh", energy)
+	}
+	if _, ok := manager.deferredData("ups-1"); ok {
+		t.Error("Expected no deferred write after Flush")
+	}
+}
+
+func TestFileStorageManager_MinPersistIntervalElapsed(t *testing.T) {
+	manager, _ := newBatchManager(t, false)
+	manager.config.MinPersistInterval = 50 * time.Millisecond
+
+	now := time.Now().UnixMilli()
+	if err := manager.Write("ups-1", &PowerData{Timestamp: now, EnergyWH: 1}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := manager.Write("ups-1", &PowerData{Timestamp: now + 1, EnergyWH: 2}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	// The first write after the interval persists the latest value
+	time.Sleep(60 * time.Millisecond)
+	if err := manager.Write("ups-1", &PowerData{Timestamp: now + 2, EnergyWH: 4}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if energy := readFileEnergy(t, manager, "ups-1"); energy != 4 {
+		t.Errorf("Expected the write after the interval persisted, file has %v Wh", energy)
+	}
+	if _, ok := manager.deferredData("ups-1"); ok {
+		t.Error("Expected the deferred write superseded")
+	}
+
+	// Other devices are tracked independently
+	if err := manager.Write("ups-2", &PowerData{Timestamp: now, EnergyWH: 7}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if energy := readFileEnergy(t, manager, "ups-2"); energy != 7 {
+		t.Errorf("Expected the first write of another device persisted, file has %v Wh", energy)
+	}
+}
+
+func TestBatch_MinPersistInterval(t *testing.T) {
+	manager, dir := newBatchManager(t, true)
+	manager.config.MinPersistInterval = time.Hour
+
+	now := time.Now().UnixMilli()
+	commit := func(energy float64) {
+		t.Helper()
+		batch := manager.NewBatch()
+		for _, deviceID := range []string{"ups-1", "ups-2"} {
+			if err := batch.Write(deviceID, &PowerData{Timestamp: now, EnergyWH: energy}); err != nil {
+				t.Fatalf("Batch.Write() error = %v", err)
+			}
+		}
+		if err := batch.Commit(); err != nil {
+			t.Fatalf("Commit() error = %v", err)
+		}
+	}
+
+	commit(1)
+	commit(2)
+
+	if energy := readFileEnergy(t, manager, "ups-2"); energy != 1 {
+		t.Errorf("Expected the second batch deferred, file has %v Wh", energy)
+	}
+	if exists, err := manager.Exists("ups-2"); err != nil || !exists {
+		t.Errorf("Exists() = %v, %v, want true", exists, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, journalFileName)); !os.IsNotExist(err) {
+		t.Errorf("Expected no journal left behind, stat error = %v", err)
+	}
+
+	if err := manager.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	for _, deviceID := range []string{"ups-1", "ups-2"} {
+		if energy := readFileEnergy(t, manager, deviceID); energy != 2 {
+			t.Errorf("Expected %s flushed with 2 Wh, file has %v Wh", deviceID, energy)
+		}
+	}
+}
+
+func TestFileStorageManager_FlushConcurrentWrite(t *testing.T) {
+	manager, _ := newBatchManager(t, false)
+	manager.config.MinPersistInterval = time.Hour
+
+	now := time.Now().UnixMilli()
+	for i := range 50 {
+		deviceID := fmt.Sprintf("ups-%d", i)
+		for j, energy := range []float64{1, 2} {
+			if err := manager.Write(deviceID, &PowerData{Timestamp: now + int64(j), EnergyWH: energy}); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+		}
+
+		// The interval elapses: the next write persists immediately, racing
+		// the flush of the deferred write
+		manager.persistMu.Lock()
+		manager.lastPersist[deviceID] = time.Now().Add(-2 * time.Hour)
+		manager.persistMu.Unlock()
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := manager.Flush(); err != nil {
+				t.Errorf("Flush() error = %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := manager.Write(deviceID, &PowerData{Timestamp: now + 2, EnergyWH: 3}); err != nil {
+				t.Errorf("Write() error = %v", err)
+			}
+		}()
+		wg.Wait()
+
+		// A write after the flush is deferred again; either way the newest
+		// data is persisted once flushed
+		if err := manager.Flush(); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+		if energy := readFileEnergy(t, manager, deviceID); energy != 3 {
+			t.Fatalf("%s: expected the newest write persisted, file has %v Wh", deviceID, energy)
+		}
+	}
+}
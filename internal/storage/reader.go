@@ -28,7 +28,7 @@ func NewFileReader(config *Config, logger log.Logger) FileReader {
 // Read reads power data from a device file.
 // If the file doesn't exist, it returns default initialized data.
 func (r *fileReader) Read(deviceID string) (*PowerData, error) {
-	filePath, err := buildFilePath(r.config.DataDir, deviceID)
+	filePath, err := r.config.deviceFilePath(deviceID)
 	if err != nil {
 		return nil, err
 	}
@@ -64,7 +64,8 @@ func (r *fileReader) Read(deviceID string) (*PowerData, error) {
 		}
 	}()
 
-	// Parse the file format (two lines: timestamp, energy)
+	// Parse the file format (timestamp, energy, optional device energy,
+	// optional reset epoch)
 	scanner := bufio.NewScanner(file)
 
 	// Read timestamp
@@ -89,6 +90,18 @@ func (r *fileReader) Read(deviceID string) (*PowerData, error) {
 	}
 	energyStr := strings.TrimSpace(scanner.Text())
 
+	// Read the optional device energy baseline
+	var deviceEnergyStr string
+	if scanner.Scan() {
+		deviceEnergyStr = strings.TrimSpace(scanner.Text())
+	}
+
+	// Read the optional reset epoch
+	var resetEpochStr string
+	if scanner.Scan() {
+		resetEpochStr = strings.TrimSpace(scanner.Text())
+	}
+
 	// Check for scanner errors
 	if err := scanner.Err(); err != nil {
 		r.logger.Error("error reading file",
@@ -125,8 +138,36 @@ func (r *fileReader) Read(deviceID string) (*PowerData, error) {
 		EnergyWH:  energy,
 	}
 
+	// Parse device energy baseline
+	if deviceEnergyStr != "" {
+		deviceEnergy, err := strconv.ParseFloat(deviceEnergyStr, 64)
+		if err != nil {
+			err := fmt.Errorf("%w: invalid device energy format: %v", ErrInvalidFormat, err)
+			r.logger.Error("failed to parse device energy value",
+				log.String("device_id", deviceID),
+				log.String("device_energy", deviceEnergyStr),
+				log.Err(err))
+			return nil, NewStorageError("read", filePath, err)
+		}
+		data.DeviceEnergyWH = &deviceEnergy
+	}
+
+	// Parse reset epoch
+	if resetEpochStr != "" {
+		resetEpoch, err := strconv.ParseUint(resetEpochStr, 10, 64)
+		if err != nil {
+			err := fmt.Errorf("%w: invalid reset epoch format: %v", ErrInvalidFormat, err)
+			r.logger.Error("failed to parse reset epoch",
+				log.String("device_id", deviceID),
+				log.String("reset_epoch", resetEpochStr),
+				log.Err(err))
+			return nil, NewStorageError("read", filePath, err)
+		}
+		data.ResetEpoch = resetEpoch
+	}
+
 	// Validate the data
-	if err := data.Validate(); err != nil {
+	if err := data.ValidateWithFutureTolerance(r.config.futureTolerance()); err != nil {
 		r.logger.Error("invalid data in file",
 			log.String("device_id", deviceID),
 			log.String("path", filePath),
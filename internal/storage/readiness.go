@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+// readinessProbePattern names the temporary file used to probe writability.
+// The leading dot keeps it out of device listings.
+const readinessProbePattern = ".readiness-probe-*"
+
+// CheckWritable creates the data directory with the given permissions if
+// needed and verifies that it accepts writes by creating and removing a
+// probe file.
+func CheckWritable(dataDir string, dirPerm os.FileMode) error {
+	if err := ensureDir(osFileSystem{}, dataDir, dirPerm); err != nil {
+		return NewStorageError("mkdir", dataDir, wrapFSError(err))
+	}
+
+	probe, err := os.CreateTemp(dataDir, readinessProbePattern)
+	if err != nil {
+		return NewStorageError("probe", dataDir, wrapFSError(err))
+	}
+	name := probe.Name()
+
+	if err := probe.Close(); err != nil {
+		_ = os.Remove(name)
+		return NewStorageError("probe", name, err)
+	}
+	if err := os.Remove(name); err != nil {
+		return NewStorageError("probe", name, err)
+	}
+
+	return nil
+}
+
+// WaitWritable blocks until the configured data directory is writable.
+//
+// It retries CheckWritable every ReadinessInterval until ReadinessTimeout
+// elapses, which tolerates network mounts (NFS/SMB) that attach shortly
+// after the process starts. With a zero ReadinessTimeout the directory is
+// checked once.
+//
+// Returns an error wrapping ErrNotReady and the last check failure if the
+// directory is still not writable when the timeout elapses, or the context
+// error if ctx is cancelled first.
+//
+// Example:
+//
+//	if err := storage.WaitWritable(ctx, config, logger); err != nil {
+//	    log.Fatalf("storage unavailable: %v", err)
+//	}
+func WaitWritable(ctx context.Context, config *Config, logger log.Logger) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(config.ReadinessTimeout)
+	for attempt := 1; ; attempt++ {
+		err := CheckWritable(config.DataDir, config.dirPermissions())
+		if err == nil {
+			logger.Info("storage directory is writable",
+				log.String("data_dir", config.DataDir),
+				log.Int("attempts", attempt))
+			return nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return fmt.Errorf("%w: %s not writable after %d attempts: %v",
+				ErrNotReady, config.DataDir, attempt, err)
+		}
+
+		logger.Warn("storage directory not writable yet, retrying",
+			log.String("data_dir", config.DataDir),
+			log.Int("attempt", attempt),
+			log.Duration("retry_in", config.ReadinessInterval),
+			log.Err(err))
+
+		wait := min(config.ReadinessInterval, remaining)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
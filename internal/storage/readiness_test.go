@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+func TestCheckWritable(t *testing.T) {
+	t.Run("creates missing directory", func(t *testing.T) {
+		dataDir := filepath.Join(t.TempDir(), "nested", "data")
+
+		if err := CheckWritable(dataDir, DefaultDirPermissions); err != nil {
+			t.Fatalf("CheckWritable() error = %v", err)
+		}
+
+		entries, err := os.ReadDir(dataDir)
+		if err != nil {
+			t.Fatalf("data directory not created: %v", err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("probe file left behind: %v", entries)
+		}
+	})
+
+	t.Run("path is a file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "file")
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var storageErr *StorageError
+		if err := CheckWritable(path, DefaultDirPermissions); !errors.As(err, &storageErr) {
+			t.Errorf("CheckWritable() error = %v, want StorageError", err)
+		}
+	})
+}
+
+func TestWaitWritable(t *testing.T) {
+	logger := log.NewTestLogger()
+
+	t.Run("retries until mount appears", func(t *testing.T) {
+		// A regular file blocks the data directory until it is removed,
+		// simulating a mount point that attaches after startup
+		mount := filepath.Join(t.TempDir(), "mnt")
+		if err := os.WriteFile(mount, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			time.Sleep(30 * time.Millisecond)
+			_ = os.Remove(mount)
+		}()
+
+		cfg := DefaultConfig()
+		cfg.DataDir = filepath.Join(mount, "data")
+		cfg.ReadinessTimeout = 5 * time.Second
+		cfg.ReadinessInterval = 10 * time.Millisecond
+
+		if err := WaitWritable(context.Background(), cfg, logger); err != nil {
+			t.Fatalf("WaitWritable() error = %v", err)
+		}
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		mount := filepath.Join(t.TempDir(), "mnt")
+		if err := os.WriteFile(mount, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg := DefaultConfig()
+		cfg.DataDir = filepath.Join(mount, "data")
+		cfg.ReadinessTimeout = 30 * time.Millisecond
+		cfg.ReadinessInterval = 10 * time.Millisecond
+
+		if err := WaitWritable(context.Background(), cfg, logger); !errors.Is(err, ErrNotReady) {
+			t.Errorf("WaitWritable() error = %v, want ErrNotReady", err)
+		}
+	})
+
+	t.Run("zero timeout checks once", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.DataDir = t.TempDir()
+		cfg.ReadinessTimeout = 0
+
+		if err := WaitWritable(context.Background(), cfg, logger); err != nil {
+			t.Errorf("WaitWritable() error = %v", err)
+		}
+	})
+
+	t.Run("context cancelled", func(t *testing.T) {
+		mount := filepath.Join(t.TempDir(), "mnt")
+		if err := os.WriteFile(mount, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg := DefaultConfig()
+		cfg.DataDir = filepath.Join(mount, "data")
+		cfg.ReadinessTimeout = time.Minute
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if err := WaitWritable(ctx, cfg, logger); !errors.Is(err, context.Canceled) {
+			t.Errorf("WaitWritable() error = %v, want context.Canceled", err)
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+const (
+	// snapshotDirName is the directory under DataDir holding the energy
+	// snapshots. Directories are never mistaken for device files.
+	snapshotDirName = "snapshots"
+
+	// snapshotPrefix and snapshotExt surround the UTC snapshot time in the
+	// snapshot file names, e.g. energy-20261016T000000Z.json
+	snapshotPrefix     = "energy-"
+	snapshotExt        = ".json"
+	snapshotTimeLayout = "20060102T150405Z"
+
+	// snapshotCheckInterval is how often the snapshot task checks whether a
+	// snapshot is due. Snapshots are therefore written up to this late.
+	snapshotCheckInterval = time.Minute
+)
+
+// EnergySnapshot is a point-in-time checkpoint of the energy of every
+// stored device
+type EnergySnapshot struct {
+	// Time is when the snapshot was taken
+	Time time.Time `json:"time"`
+
+	// Devices holds the cumulative energy in Wh keyed by device ID
+	Devices map[string]float64 `json:"devices"`
+}
+
+// SnapshotInfo identifies a snapshot file
+type SnapshotInfo struct {
+	// Name is the snapshot file name without extension, e.g.
+	// energy-20261016T000000Z
+	Name string
+
+	// Time is when the snapshot was taken, from its file name
+	Time time.Time
+}
+
+// Snapshotter is optionally implemented by a StorageManager that can keep
+// low-frequency snapshots of the device energy.
+type Snapshotter interface {
+	// StartSnapshots writes a snapshot whenever SnapshotInterval has
+	// elapsed since the latest one, until ctx is cancelled. It is a no-op
+	// when snapshots are disabled.
+	StartSnapshots(ctx context.Context)
+}
+
+// StartSnapshots writes an energy snapshot whenever SnapshotInterval has
+// elapsed since the latest snapshot on disk, checking once at start and then
+// every minute until ctx is cancelled. Because the schedule follows the
+// snapshots on disk, restarts neither skip nor repeat a snapshot. Failures
+// are logged and retried at the next check.
+func (m *FileStorageManager) StartSnapshots(ctx context.Context) {
+	if m.config.SnapshotInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(snapshotCheckInterval)
+		defer ticker.Stop()
+		for {
+			if _, err := m.SnapshotIfDue(ctx, time.Now()); err != nil {
+				m.logger.Warn("failed to write energy snapshot", log.Err(err))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// SnapshotIfDue writes a snapshot when SnapshotInterval has elapsed since
+// the latest snapshot, or none exists yet. It reports whether a snapshot was
+// written.
+func (m *FileStorageManager) SnapshotIfDue(ctx context.Context, now time.Time) (bool, error) {
+	if m.config.SnapshotInterval <= 0 {
+		return false, nil
+	}
+
+	snapshots, err := m.ListSnapshots()
+	if err != nil {
+		return false, err
+	}
+	if n := len(snapshots); n > 0 && now.Sub(snapshots[n-1].Time) < m.config.SnapshotInterval {
+		return false, nil
+	}
+
+	if _, err := m.WriteSnapshot(ctx, now); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// WriteSnapshot writes the current energy of every stored device, including
+// writes held back by MinPersistInterval, to a snapshot file named after
+// now. The file is written atomically, so a crash never leaves a partial
+// snapshot. A partial ReadAll result fails the snapshot instead of recording
+// only some devices. It returns the snapshot name.
+func (m *FileStorageManager) WriteSnapshot(ctx context.Context, now time.Time) (string, error) {
+	all, err := m.ReadAll(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	snapshot := &EnergySnapshot{
+		Time:    now.UTC().Truncate(time.Second),
+		Devices: make(map[string]float64, len(all)),
+	}
+	for deviceID, data := range all {
+		snapshot.Devices[deviceID] = data.EnergyWH
+	}
+
+	name := snapshotPrefix + snapshot.Time.Format(snapshotTimeLayout)
+	path := filepath.Join(m.snapshotDir(), name+snapshotExt)
+	content, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return "", NewStorageError("snapshot", path, err)
+	}
+
+	if err := ensureDir(osFileSystem{}, m.snapshotDir(), m.config.dirPermissions()); err != nil {
+		return "", NewStorageError("snapshot", path, wrapFSError(err))
+	}
+	if err := m.writeAtomic(path, content); err != nil {
+		return "", NewStorageError("snapshot", path, wrapFSError(err))
+	}
+
+	m.logger.Info("energy snapshot written",
+		log.String("snapshot", name),
+		log.Int("device_count", len(snapshot.Devices)))
+	return name, nil
+}
+
+// ListSnapshots returns the snapshots on disk, oldest first. A missing
+// snapshot directory yields no snapshots; files that are not snapshots are
+// skipped.
+func (m *FileStorageManager) ListSnapshots() ([]SnapshotInfo, error) {
+	dir := m.snapshotDir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, NewStorageError("list", dir, wrapFSError(err))
+	}
+
+	var snapshots []SnapshotInfo
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name, ok := strings.CutSuffix(entry.Name(), snapshotExt)
+		if !ok {
+			continue
+		}
+		taken, err := parseSnapshotName(name)
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, SnapshotInfo{Name: name, Time: taken})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Time.Before(snapshots[j].Time)
+	})
+	return snapshots, nil
+}
+
+// ReadSnapshot reads the snapshot with the given name, as returned by
+// ListSnapshots. The ".json" extension may be included. A missing snapshot
+// yields an error matching os.ErrNotExist.
+func (m *FileStorageManager) ReadSnapshot(name string) (*EnergySnapshot, error) {
+	name = strings.TrimSuffix(name, snapshotExt)
+	if _, err := parseSnapshotName(name); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(m.snapshotDir(), name+snapshotExt)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, NewStorageError("read", path, wrapFSError(err))
+	}
+
+	var snapshot EnergySnapshot
+	if err := json.Unmarshal(content, &snapshot); err != nil {
+		return nil, NewStorageError("read", path, err)
+	}
+	if snapshot.Devices == nil {
+		snapshot.Devices = map[string]float64{}
+	}
+	return &snapshot, nil
+}
+
+// parseSnapshotName returns the time of a snapshot from its name
+func parseSnapshotName(name string) (time.Time, error) {
+	stamp, ok := strings.CutPrefix(name, snapshotPrefix)
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid snapshot name %q", name)
+	}
+	taken, err := time.Parse(snapshotTimeLayout, stamp)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid snapshot name %q", name)
+	}
+	return taken, nil
+}
+
+// snapshotDir returns the directory holding the energy snapshots
+func (m *FileStorageManager) snapshotDir() string {
+	return filepath.Join(m.config.DataDir, snapshotDirName)
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStorageManager_WriteSnapshot(t *testing.T) {
+	manager, dir := newBatchManager(t, false)
+
+	now := time.Now()
+	for deviceID, energy := range map[string]float64{"ups-1": 10.5, "ups-2": 20} {
+		if err := manager.Write(deviceID, &PowerData{Timestamp: now.UnixMilli(), EnergyWH: energy}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	taken := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	name, err := manager.WriteSnapshot(context.Background(), taken)
+	if err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	if name != "energy-20261016T000000Z" {
+		t.Errorf("WriteSnapshot() name = %q", name)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "snapshots", name+".json")); err != nil {
+		t.Errorf("Expected snapshot file: %v", err)
+	}
+
+	// The snapshot directory is not listed as a device
+	all, err := manager.ReadAll(context.Background())
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("ReadAll() returned %d devices, want 2", len(all))
+	}
+
+	snapshot, err := manager.ReadSnapshot(name + ".json")
+	if err != nil {
+		t.Fatalf("ReadSnapshot() error = %v", err)
+	}
+	if !snapshot.Time.Equal(taken) {
+		t.Errorf("Snapshot time = %v, want %v", snapshot.Time, taken)
+	}
+	if snapshot.Devices["ups-1"] != 10.5 || snapshot.Devices["ups-2"] != 20 {
+		t.Errorf("Snapshot devices = %v", snapshot.Devices)
+	}
+
+	if _, err := manager.ReadSnapshot("energy-20200101T000000Z"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a missing snapshot to match os.ErrNotExist, got %v", err)
+	}
+	if _, err := manager.ReadSnapshot("../ups-1"); err == nil {
+		t.Error("Expected an invalid snapshot name to be rejected")
+	}
+}
+
+func TestFileStorageManager_SnapshotIfDue(t *testing.T) {
+	manager, dir := newBatchManager(t, false)
+
+	// Disabled by default
+	if written, err := manager.SnapshotIfDue(context.Background(), time.Now()); err != nil || written {
+		t.Fatalf("SnapshotIfDue() = %v, %v; want no snapshot when disabled", written, err)
+	}
+
+	manager.config.SnapshotInterval = 24 * time.Hour
+	start := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		now  time.Time
+		want bool
+	}{
+		{start, true},                      // no snapshot yet
+		{start.Add(23 * time.Hour), false}, // within the interval
+		{start.Add(24 * time.Hour), true},  // interval elapsed
+		{start.Add(25 * time.Hour), false},
+	}
+	for _, step := range steps {
+		written, err := manager.SnapshotIfDue(context.Background(), step.now)
+		if err != nil {
+			t.Fatalf("SnapshotIfDue(%v) error = %v", step.now, err)
+		}
+		if written != step.want {
+			t.Errorf("SnapshotIfDue(%v) = %v, want %v", step.now, written, step.want)
+		}
+	}
+
+	// Unrelated files in the snapshot directory are ignored
+	if err := os.WriteFile(filepath.Join(dir, "snapshots", "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	snapshots, err := manager.ListSnapshots()
+	if err != nil {
+		t.Fatalf("ListSnapshots() error = %v", err)
+	}
+	if len(snapshots) != 2 || !snapshots[0].Time.Equal(start) || !snapshots[1].Time.Equal(start.Add(24*time.Hour)) {
+		t.Errorf("ListSnapshots() = %v", snapshots)
+	}
+}
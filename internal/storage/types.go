@@ -9,6 +9,9 @@ package storage
 // Fields:
 //   - Timestamp: Unix timestamp in milliseconds when the data was recorded
 //   - EnergyWH: Accumulated energy in watt-hours (non-negative)
+//   - DeviceEnergyWH: Last device-reported energy counter reading, only
+//     set when energy is taken from the device instead of integrated
+//   - ResetEpoch: Number of times the energy has been reset
 //
 // The data is validated before storage to ensure:
 //   - Timestamp is valid and not too far in the future
@@ -29,4 +32,14 @@ type PowerData struct {
 
 	// EnergyWH is the accumulated energy in watt-hours
 	EnergyWH float64 `json:"energy_wh"`
+
+	// DeviceEnergyWH is the last device-reported cumulative energy reading in
+	// watt-hours, used as the baseline for the next reading. Nil when energy
+	// is integrated from power. Stored as an optional third line.
+	DeviceEnergyWH *float64 `json:"device_energy_wh,omitempty"`
+
+	// ResetEpoch counts the deliberate resets of the energy, so a drop of the
+	// value can be told apart from a rollback. Stored as an optional fourth
+	// line; zero for devices that were never reset.
+	ResetEpoch uint64 `json:"reset_epoch,omitempty"`
 }
@@ -11,9 +11,11 @@ import (
 // Validation rules:
 //   - PowerData must not be nil
 //   - Timestamp must be non-negative (0 or positive)
-//   - Timestamp must not be more than 24 hours in the future
+//   - Timestamp must not be more than DefaultFutureTolerance (24 hours)
+//     in the future
 //   - EnergyWH must be a finite number (not NaN or Inf)
 //   - EnergyWH must be non-negative
+//   - DeviceEnergyWH, when set, must be finite and non-negative
 //
 // Returns an error describing the first validation failure encountered,
 // or nil if all validations pass.
@@ -32,6 +34,14 @@ import (
 //	    return
 //	}
 func (d *PowerData) Validate() error {
+	return d.ValidateWithFutureTolerance(DefaultFutureTolerance)
+}
+
+// ValidateWithFutureTolerance checks if PowerData is valid like Validate,
+// but rejects timestamps more than tolerance ahead of the local clock.
+//
+// The storage manager validates with Config.FutureTolerance.
+func (d *PowerData) ValidateWithFutureTolerance(tolerance time.Duration) error {
 	if d == nil {
 		return fmt.Errorf("%w: PowerData cannot be nil", ErrInvalidData)
 	}
@@ -41,11 +51,10 @@ func (d *PowerData) Validate() error {
 		return fmt.Errorf("%w: timestamp cannot be negative", ErrInvalidData)
 	}
 
-	// Check if timestamp is too far in the future (more than 1 day)
+	// Check if timestamp is too far in the future
 	now := time.Now().UnixMilli()
-	oneDayInMs := int64(24 * 60 * 60 * 1000)
-	if d.Timestamp > now+oneDayInMs {
-		return fmt.Errorf("%w: timestamp is too far in the future", ErrInvalidData)
+	if d.Timestamp > now+tolerance.Milliseconds() {
+		return fmt.Errorf("%w: timestamp is too far in the future (more than %v ahead)", ErrInvalidData, tolerance)
 	}
 
 	// Validate energy - should be finite and non-negative
@@ -57,5 +66,14 @@ func (d *PowerData) Validate() error {
 		return fmt.Errorf("%w: energy value cannot be negative", ErrInvalidData)
 	}
 
+	if d.DeviceEnergyWH != nil {
+		if math.IsNaN(*d.DeviceEnergyWH) || math.IsInf(*d.DeviceEnergyWH, 0) {
+			return fmt.Errorf("%w: device energy value must be finite", ErrInvalidData)
+		}
+		if *d.DeviceEnergyWH < 0 {
+			return fmt.Errorf("%w: device energy value cannot be negative", ErrInvalidData)
+		}
+	}
+
 	return nil
 }
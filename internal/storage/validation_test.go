@@ -1,9 +1,12 @@
 package storage
 
 import (
+	"errors"
 	"math"
 	"testing"
 	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
 )
 
 func TestPowerData_Validate(t *testing.T) {
@@ -112,3 +115,54 @@ func TestPowerData_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestPowerData_ValidateWithFutureTolerance(t *testing.T) {
+	data := &PowerData{
+		Timestamp: time.Now().Add(10 * time.Minute).UnixMilli(),
+		EnergyWH:  100.0,
+	}
+
+	if err := data.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil within the default tolerance", err)
+	}
+	if err := data.ValidateWithFutureTolerance(time.Hour); err != nil {
+		t.Errorf("ValidateWithFutureTolerance(1h) error = %v, want nil", err)
+	}
+
+	err := data.ValidateWithFutureTolerance(5 * time.Minute)
+	if !errors.Is(err, ErrInvalidData) {
+		t.Fatalf("ValidateWithFutureTolerance(5m) error = %v, want ErrInvalidData", err)
+	}
+	if !contains(err.Error(), "timestamp is too far in the future") {
+		t.Errorf("ValidateWithFutureTolerance(5m) error = %v, want future timestamp error", err)
+	}
+}
+
+func TestFileStorageManager_FutureTolerance(t *testing.T) {
+	dir := t.TempDir()
+	manager, err := NewFileStorageManager(&Config{
+		DataDir:         dir,
+		FilePermissions: 0644,
+		FutureTolerance: time.Minute,
+	}, log.NewTestLogger())
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	ahead := &PowerData{Timestamp: time.Now().Add(time.Hour).UnixMilli(), EnergyWH: 100}
+	if err := manager.Write("device-1", ahead); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("Write() error = %v, want ErrInvalidData beyond the configured tolerance", err)
+	}
+
+	// An unset tolerance keeps the 24h default
+	relaxed, err := NewFileStorageManager(&Config{
+		DataDir:         dir,
+		FilePermissions: 0644,
+	}, log.NewTestLogger())
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	if err := relaxed.Write("device-1", ahead); err != nil {
+		t.Errorf("Write() error = %v, want nil within the default tolerance", err)
+	}
+}
@@ -1,65 +1,133 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
 )
 
+// writeRetryBackoff is the base delay between write attempts. The delay grows
+// linearly with the attempt number.
+var writeRetryBackoff = 50 * time.Millisecond
+
 // fileWriter implements the FileWriter interface.
 type fileWriter struct {
 	config *Config
 	logger log.Logger
+	fs     fileSystem
 }
 
 // NewFileWriter creates a new FileWriter.
 func NewFileWriter(config *Config, logger log.Logger) FileWriter {
+	return newFileWriterWithFS(config, logger, osFileSystem{})
+}
+
+// newFileWriterWithFS creates a FileWriter backed by the given filesystem.
+func newFileWriterWithFS(config *Config, logger log.Logger, fs fileSystem) *fileWriter {
 	return &fileWriter{
 		config: config,
 		logger: logger,
+		fs:     fs,
 	}
 }
 
 // Write writes power data to a device file atomically.
+//
+// Transient filesystem errors (e.g. EINTR, ENOSPC) are retried up to
+// Config.WriteRetries times with a short backoff. Permanent errors such as
+// ErrPermissionDenied fail immediately.
 func (w *fileWriter) Write(deviceID string, data *PowerData) error {
-	if err := data.Validate(); err != nil {
+	if err := data.ValidateWithFutureTolerance(w.config.futureTolerance()); err != nil {
 		w.logger.Error("invalid data for write",
 			log.String("device_id", deviceID),
 			log.Err(err))
 		return err
 	}
 
-	filePath, err := buildFilePath(w.config.DataDir, deviceID)
+	filePath, err := w.config.deviceFilePath(deviceID)
 	if err != nil {
 		return err
 	}
 
+	for attempt := 0; ; attempt++ {
+		err = w.writeOnce(deviceID, filePath, data)
+		if err == nil {
+			break
+		}
+
+		if !isTransientError(err) || attempt >= w.config.WriteRetries {
+			return err
+		}
+
+		backoff := writeRetryBackoff * time.Duration(attempt+1)
+		w.logger.Warn("transient write error, retrying",
+			log.String("device_id", deviceID),
+			log.Int("attempt", attempt+1),
+			log.Int("max_retries", w.config.WriteRetries),
+			log.Duration("backoff", backoff),
+			log.Err(err))
+		time.Sleep(backoff)
+	}
+
+	w.logger.Debug("successfully wrote device data",
+		log.String("device_id", deviceID),
+		log.String("path", filePath),
+		log.Int64("timestamp", data.Timestamp),
+		log.Float64("energy_wh", data.EnergyWH))
+
+	return nil
+}
+
+// writeOnce performs a single atomic write attempt (temp file + rename).
+func (w *fileWriter) writeOnce(deviceID, filePath string, data *PowerData) error {
 	// Ensure the data directory exists
-	if err := os.MkdirAll(w.config.DataDir, 0755); err != nil {
+	if err := ensureDir(w.fs, w.config.DataDir, w.config.dirPermissions()); err != nil {
 		w.logger.Error("failed to create data directory",
 			log.String("dir", w.config.DataDir),
 			log.Err(err))
-		return NewStorageError("write", filePath, err)
+		return NewStorageError("write", filePath, wrapFSError(err))
 	}
 
-	// Format the data (two lines: timestamp, energy)
+	// Format the data (timestamp, energy, the optional device energy baseline
+	// and the optional reset epoch). A reset epoch without a baseline is
+	// preceded by an empty line so the baseline keeps its line.
 	content := fmt.Sprintf("%d\n%.2f\n", data.Timestamp, data.EnergyWH)
+	if data.DeviceEnergyWH != nil {
+		content += fmt.Sprintf("%.2f\n", *data.DeviceEnergyWH)
+	} else if data.ResetEpoch > 0 {
+		content += "\n"
+	}
+	if data.ResetEpoch > 0 {
+		content += fmt.Sprintf("%d\n", data.ResetEpoch)
+	}
 
 	// Write atomically using a temporary file
 	tempPath := filePath + ".tmp"
 
 	// Write to temporary file
-	if err := os.WriteFile(tempPath, []byte(content), w.config.FilePermissions); err != nil {
+	if err := w.fs.WriteFile(tempPath, []byte(content), w.config.FilePermissions); err != nil {
 		w.logger.Error("failed to write temporary file",
 			log.String("device_id", deviceID),
 			log.String("temp_path", tempPath),
 			log.Err(err))
-		return NewStorageError("write", filePath, err)
+		return NewStorageError("write", filePath, wrapFSError(err))
+	}
+
+	// Apply the configured mode regardless of umask
+	if err := w.fs.Chmod(tempPath, w.config.FilePermissions); err != nil {
+		_ = w.fs.Remove(tempPath)
+		w.logger.Error("failed to set temporary file permissions",
+			log.String("device_id", deviceID),
+			log.String("temp_path", tempPath),
+			log.Err(err))
+		return NewStorageError("write", filePath, wrapFSError(err))
 	}
 
 	// Sync to ensure data is written to disk
-	file, err := os.OpenFile(tempPath, os.O_RDWR, w.config.FilePermissions)
+	file, err := w.fs.OpenFile(tempPath, os.O_RDWR, w.config.FilePermissions)
 	if err == nil {
 		_ = file.Sync()
 		if err := file.Close(); err != nil {
@@ -71,22 +139,25 @@ func (w *fileWriter) Write(deviceID string, data *PowerData) error {
 	}
 
 	// Atomically rename the temporary file to the final file
-	if err := os.Rename(tempPath, filePath); err != nil {
+	if err := w.fs.Rename(tempPath, filePath); err != nil {
 		// Clean up temp file on error
-		_ = os.Remove(tempPath)
+		_ = w.fs.Remove(tempPath)
 		w.logger.Error("failed to rename temporary file",
 			log.String("device_id", deviceID),
 			log.String("temp_path", tempPath),
 			log.String("final_path", filePath),
 			log.Err(err))
-		return NewStorageError("write", filePath, err)
+		return NewStorageError("write", filePath, wrapFSError(err))
 	}
 
-	w.logger.Debug("successfully wrote device data",
-		log.String("device_id", deviceID),
-		log.String("path", filePath),
-		log.Int64("timestamp", data.Timestamp),
-		log.Float64("energy_wh", data.EnergyWH))
-
 	return nil
 }
+
+// wrapFSError maps permission failures to ErrPermissionDenied while keeping
+// the original error in the chain.
+func wrapFSError(err error) error {
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Errorf("%w: %w", ErrPermissionDenied, err)
+	}
+	return err
+}
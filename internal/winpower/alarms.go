@@ -0,0 +1,53 @@
+package winpower
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnknownAlarmType is reported for an active alarm whose type cannot be read
+const UnknownAlarmType = "unknown"
+
+// alarmTypeKeys are the keys of an alarm object holding its type, tried in
+// order. The structure of WinPower's alarm objects varies between versions.
+var alarmTypeKeys = []string{"alarmType", "type", "alarmCode", "code", "eventType", "name"}
+
+// parseActiveAlarms returns the type of each active alarm of a device. An
+// alarm is either the type itself (a string or number) or an object whose
+// type is read from the first present key of alarmTypeKeys; alarms without a
+// readable type are reported as UnknownAlarmType. A missing or empty alarm
+// list yields no alarms.
+func parseActiveAlarms(alarms []interface{}) []string {
+	if len(alarms) == 0 {
+		return nil
+	}
+
+	types := make([]string, 0, len(alarms))
+	for _, alarm := range alarms {
+		alarmType := alarmTypeOf(alarm)
+		if alarmType == "" {
+			alarmType = UnknownAlarmType
+		}
+		types = append(types, alarmType)
+	}
+	return types
+}
+
+// alarmTypeOf returns the type of a single alarm, or "" if it has none
+func alarmTypeOf(alarm interface{}) string {
+	switch v := alarm.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return fmt.Sprintf("%g", v)
+	case map[string]interface{}:
+		for _, key := range alarmTypeKeys {
+			if value, ok := v[key]; ok {
+				if alarmType := alarmTypeOf(value); alarmType != "" {
+					return alarmType
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package winpower
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseActiveAlarms(t *testing.T) {
+	tests := []struct {
+		name   string
+		alarms string
+		want   []string
+	}{
+		{"missing", `null`, nil},
+		{"empty", `[]`, nil},
+		{"strings", `["overload","battery_fault"]`, []string{"overload", "battery_fault"}},
+		{"numbers", `[101, 2]`, []string{"101", "2"}},
+		{"objects", `[{"alarmType":"overload","level":2},{"code":42}]`, []string{"overload", "42"}},
+		{"key order", `[{"name":"Overload","alarmType":"overload"}]`, []string{"overload"}},
+		{"no readable type", `[{"level":2},{"alarmType":""},null]`, []string{UnknownAlarmType, UnknownAlarmType, UnknownAlarmType}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var alarms []interface{}
+			if err := json.Unmarshal([]byte(tt.alarms), &alarms); err != nil {
+				t.Fatalf("invalid test alarms: %v", err)
+			}
+			if got := parseActiveAlarms(alarms); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseActiveAlarms(%s) = %v, want %v", tt.alarms, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDataParser_ActiveAlarms(t *testing.T) {
+	parser := NewDataParser(nil)
+
+	var response DeviceDataResponse
+	body := `{"code":"000000","data":[
+		{"assetDevice":{"id":"ups-1"},"realtime":{"loadTotalWatt":"100"},"activeAlarms":[{"alarmType":"overload"}]},
+		{"assetDevice":{"id":"ups-2"},"realtime":{"loadTotalWatt":"100"}}
+	]}`
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		t.Fatalf("invalid test response: %v", err)
+	}
+
+	devices, err := parser.ParseResponse(&response)
+	if err != nil {
+		t.Fatalf("ParseResponse() error = %v", err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("expected 2 devices, got %d", len(devices))
+	}
+	if !reflect.DeepEqual(devices[0].ActiveAlarms, []string{"overload"}) {
+		t.Errorf("unexpected alarms of ups-1: %v", devices[0].ActiveAlarms)
+	}
+	// A device without an alarm list has no alarms
+	if len(devices[1].ActiveAlarms) != 0 {
+		t.Errorf("unexpected alarms of ups-2: %v", devices[1].ActiveAlarms)
+	}
+}
@@ -2,11 +2,13 @@ package winpower
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/timing"
 	"go.uber.org/zap"
 )
 
@@ -26,6 +28,10 @@ type Client struct {
 	collectionCount    int64
 	successCount       int64
 	errorCount         int64
+
+	// Backoff between startup connection attempts, doubling up to the max
+	startupBackoff    time.Duration
+	startupBackoffMax time.Duration
 }
 
 // Ensure Client implements WinPowerClient interface
@@ -59,13 +65,20 @@ func NewClient(cfg *Config, logger log.Logger) (*Client, error) {
 		logger,
 	)
 
+	if cfg.BackgroundRefresh {
+		tokenManager.StartBackgroundRefresh(cfg.RefreshRetries, cfg.RefreshRetryInterval)
+	}
+
 	// Create data parser
 	// DataParser requires a *zap.Logger, so we get the underlying logger
 	zapLogger := zap.NewNop()
 	if logger.Core() != nil {
 		zapLogger = zap.New(logger.Core())
 	}
-	dataParser := NewDataParser(zapLogger)
+	dataParser := NewDataParserWithFieldMap(zapLogger, cfg.FieldMap)
+	dataParser.identityField = cfg.IdentityField
+	dataParser.timeLayouts = timeLayouts(cfg.TimeLayout)
+	dataParser.timeLocation, _ = timeLocation(cfg.TimeZone) // validated above
 
 	client := &Client{
 		config:       cfg,
@@ -74,6 +87,9 @@ func NewClient(cfg *Config, logger log.Logger) (*Client, error) {
 		dataParser:   dataParser,
 		logger:       logger,
 		connected:    false,
+
+		startupBackoff:    time.Second,
+		startupBackoffMax: 30 * time.Second,
 	}
 
 	logger.Info("WinPower client created",
@@ -86,6 +102,13 @@ func NewClient(cfg *Config, logger log.Logger) (*Client, error) {
 	return client, nil
 }
 
+// SetResponseObserver sets the observer notified of response sizes and parse
+// durations. It must be called before the first collection.
+func (c *Client) SetResponseObserver(observer ResponseObserver) {
+	c.httpClient.observer = observer
+	c.dataParser.observer = observer
+}
+
 // CollectDeviceData collects device data from WinPower system.
 // This is the main entry point for data collection.
 func (c *Client) CollectDeviceData(ctx context.Context) ([]ParsedDeviceData, error) {
@@ -102,7 +125,9 @@ func (c *Client) CollectDeviceData(ctx context.Context) ([]ParsedDeviceData, err
 	}
 
 	// Step 2: Get valid token
+	stopAuth := timing.Track(ctx, timing.StageAuth)
 	token, err := c.tokenManager.GetToken(ctx)
+	stopAuth()
 	if err != nil {
 		c.recordError(err)
 		c.logger.Error("failed to get authentication token",
@@ -117,7 +142,18 @@ func (c *Client) CollectDeviceData(ctx context.Context) ([]ParsedDeviceData, err
 	)
 
 	// Step 3: Fetch device data
-	response, err := c.httpClient.GetDeviceData(ctx, token)
+	response, err := c.fetchDeviceData(ctx, token)
+	if err != nil && IsAuthenticationError(err) {
+		// The token was rejected although it had not expired locally, e.g. it
+		// expired or was revoked on the WinPower side during the cycle. Log in
+		// again and retry the fetch once so that the cycle still completes.
+		c.logger.Warn("authentication error detected, re-authenticating within the collection cycle",
+			zap.Error(err),
+			zap.Duration("elapsed", time.Since(startTime)),
+		)
+		c.tokenManager.ClearCache()
+		response, err = c.refetchDeviceData(ctx)
+	}
 	if err != nil {
 		c.recordError(err)
 		c.logger.Error("failed to fetch device data",
@@ -140,7 +176,16 @@ func (c *Client) CollectDeviceData(ctx context.Context) ([]ParsedDeviceData, err
 	)
 
 	// Step 4: Parse response data
+	stopParse := timing.Track(ctx, timing.StageParse)
 	data, err := c.dataParser.ParseResponse(response)
+	stopParse()
+	if errors.Is(err, ErrMaintenance) {
+		// WinPower is reachable, but its data is discarded for this cycle
+		c.logger.Warn("skipping collection during WinPower maintenance",
+			zap.Duration("elapsed", time.Since(startTime)),
+		)
+		return nil, err
+	}
 	if err != nil {
 		c.recordError(err)
 		c.logger.Error("failed to parse device data",
@@ -148,10 +193,24 @@ func (c *Client) CollectDeviceData(ctx context.Context) ([]ParsedDeviceData, err
 			zap.Int("raw_count", len(response.Data)),
 			zap.Duration("elapsed", time.Since(startTime)),
 		)
+
+		// An error response about the session means the token is no longer
+		// accepted; clear it to force re-login next time
+		if IsAuthenticationError(err) {
+			c.logger.Warn("session error response detected, clearing token cache")
+			c.tokenManager.ClearCache()
+		}
+
 		return nil, fmt.Errorf("data parsing failed: %w", err)
 	}
 
-	// Step 5: Update collection status
+	// Step 5: Check that no reported device is missing from the response
+	if err := c.checkDeviceCount(response, len(data)); err != nil {
+		c.recordError(err)
+		return nil, err
+	}
+
+	// Step 6: Update collection status
 	c.recordSuccess(len(data))
 
 	elapsedTime := time.Since(startTime)
@@ -167,6 +226,57 @@ func (c *Client) CollectDeviceData(ctx context.Context) ([]ParsedDeviceData, err
 	return data, nil
 }
 
+// Authenticate makes sure a valid token is cached, logging in if needed,
+// within AuthTimeout. The scheduler calls it before starting the deadline of
+// a collection cycle, so the cycle's own authentication uses the cached token
+// and the deadline only covers fetching and processing device data. It does
+// nothing when AuthTimeout is zero. A failure is not recorded as a collection
+// error; the collection that follows retries the login and records it.
+func (c *Client) Authenticate(ctx context.Context) error {
+	if c.config.AuthTimeout <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.config.AuthTimeout)
+	defer cancel()
+
+	startTime := time.Now()
+	if _, err := c.tokenManager.GetToken(ctx); err != nil {
+		c.logger.Warn("authentication before collection failed",
+			zap.Error(err),
+			zap.Duration("elapsed", time.Since(startTime)),
+			zap.Duration("auth_timeout", c.config.AuthTimeout),
+		)
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	return nil
+}
+
+// fetchDeviceData fetches the device data with the given token.
+func (c *Client) fetchDeviceData(ctx context.Context, token string) (*DeviceDataResponse, error) {
+	stopFetch := timing.Track(ctx, timing.StageFetch)
+	defer stopFetch()
+	return c.httpClient.GetDeviceData(ctx, token)
+}
+
+// refetchDeviceData logs in again and repeats the device data fetch after the
+// previous token was rejected. The login is counted in the token refresh
+// counters like any other refresh.
+func (c *Client) refetchDeviceData(ctx context.Context) (*DeviceDataResponse, error) {
+	stopAuth := timing.Track(ctx, timing.StageAuth)
+	token, err := c.tokenManager.GetToken(ctx)
+	stopAuth()
+	if err != nil {
+		return nil, fmt.Errorf("re-authentication failed: %w", err)
+	}
+
+	response, err := c.fetchDeviceData(ctx, token)
+	if err == nil {
+		c.logger.Info("device data fetched after re-authentication")
+	}
+	return response, err
+}
+
 // GetConnectionStatus returns the current connection status.
 func (c *Client) GetConnectionStatus() bool {
 	c.mu.RLock()
@@ -209,6 +319,8 @@ func (c *Client) GetStatistics() map[string]interface{} {
 func (c *Client) Close() error {
 	c.logger.Info("closing WinPower client")
 
+	c.tokenManager.StopBackgroundRefresh()
+
 	if err := c.httpClient.Close(); err != nil {
 		c.logger.Warn("error closing HTTP client", zap.Error(err))
 		return err
@@ -275,3 +387,9 @@ func (c *Client) GetTokenExpiresAt() time.Time {
 func (c *Client) IsTokenValid() bool {
 	return c.tokenManager.IsValid()
 }
+
+// GetTokenRefreshCounts returns the cumulative number of successful and
+// failed token refreshes.
+func (c *Client) GetTokenRefreshCounts() (successes, failures int64) {
+	return c.tokenManager.RefreshCounts()
+}
@@ -232,6 +232,152 @@ func TestClient_CollectDeviceData_Success(t *testing.T) {
 	assert.True(t, stats["connected"].(bool))
 }
 
+// recordingObserver records response observations
+type recordingObserver struct {
+	responseBytes  []int
+	parseDurations []time.Duration
+	requestRetries []int
+	mismatches     int
+	badTimestamps  int
+}
+
+func (o *recordingObserver) ObserveResponseBytes(bytes int) {
+	o.responseBytes = append(o.responseBytes, bytes)
+}
+
+func (o *recordingObserver) ObserveParseDuration(duration time.Duration) {
+	o.parseDurations = append(o.parseDurations, duration)
+}
+
+func (o *recordingObserver) ObserveRequestRetries(retries int) {
+	o.requestRetries = append(o.requestRetries, retries)
+}
+
+func (o *recordingObserver) ObserveDeviceCountMismatch() {
+	o.mismatches++
+}
+
+func (o *recordingObserver) ObserveInvalidTimestamp() {
+	o.badTimestamps++
+}
+
+func TestClient_CollectDeviceData_ResponseObserver(t *testing.T) {
+	deviceData := loadTestData(t, "device_data.json")
+	loginData := []byte(`{"code":"000000","msg":"OK","data":{"token":"t","deviceId":"d"}}`)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1/auth/login" {
+			_, _ = w.Write(loginData)
+			return
+		}
+		_, _ = w.Write(deviceData)
+	})
+
+	client, _, cleanup := setupTestClient(t, handler)
+	defer cleanup()
+
+	observer := &recordingObserver{}
+	client.SetResponseObserver(observer)
+
+	_, err := client.CollectDeviceData(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{len(loginData), len(deviceData)}, observer.responseBytes)
+	assert.Len(t, observer.parseDurations, 1)
+	assert.Equal(t, []int{0}, observer.requestRetries)
+}
+
+func TestClient_CollectDeviceData_DeviceCountMismatch(t *testing.T) {
+	var deviceData map[string]interface{}
+	require.NoError(t, json.Unmarshal(loadTestData(t, "device_data.json"), &deviceData))
+	deviceData["total"] = 3
+	body, err := json.Marshal(deviceData)
+	require.NoError(t, err)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1/auth/login" {
+			_, _ = w.Write([]byte(`{"code":"000000","msg":"OK","data":{"token":"t","deviceId":"d"}}`))
+			return
+		}
+		_, _ = w.Write(body)
+	})
+
+	tests := []struct {
+		name    string
+		limit   int
+		wantErr bool
+	}{
+		{name: "logged and counted without a limit", limit: 0},
+		{name: "shortfall below the limit", limit: 3},
+		{name: "shortfall reaching the limit fails", limit: 2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _, cleanup := setupTestClient(t, handler)
+			defer cleanup()
+			client.config.DeviceShortfallLimit = tt.limit
+
+			observer := &recordingObserver{}
+			client.SetResponseObserver(observer)
+
+			data, err := client.CollectDeviceData(context.Background())
+			assert.Equal(t, 1, observer.mismatches)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrDeviceCountMismatch)
+				assert.Equal(t, ErrorTypeDeviceCount, ClassifyError(err))
+				assert.Nil(t, data)
+				assert.False(t, client.GetConnectionStatus())
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, data, 1)
+		})
+	}
+}
+
+func TestClient_Authenticate(t *testing.T) {
+	deviceData := loadTestData(t, "device_data.json")
+
+	tests := []struct {
+		name       string
+		timeout    time.Duration
+		wantLogins int
+	}{
+		{name: "disabled without auth timeout", timeout: 0, wantLogins: 0},
+		{name: "logs in ahead of the collection", timeout: time.Second, wantLogins: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logins := 0
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.URL.Path == "/api/v1/auth/login" {
+					logins++
+					_, _ = w.Write([]byte(`{"code":"000000","msg":"OK","data":{"token":"t","deviceId":"d"}}`))
+					return
+				}
+				_, _ = w.Write(deviceData)
+			})
+
+			client, _, cleanup := setupTestClient(t, handler)
+			defer cleanup()
+			client.config.AuthTimeout = tt.timeout
+
+			require.NoError(t, client.Authenticate(context.Background()))
+			assert.Equal(t, tt.wantLogins, logins)
+
+			// The collection reuses the token obtained by Authenticate
+			_, err := client.CollectDeviceData(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, 1, logins)
+		})
+	}
+}
+
 func TestClient_CollectDeviceData_AuthenticationFailure(t *testing.T) {
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/api/v1/auth/login" {
@@ -267,6 +413,122 @@ func TestClient_CollectDeviceData_AuthenticationFailure(t *testing.T) {
 	assert.NotNil(t, stats["last_error"])
 }
 
+func TestClient_CollectDeviceData_ErrorResponse(t *testing.T) {
+	deviceData := loadTestData(t, "device_data.json")
+
+	loginCallCount := 0
+	sessionExpired := true
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/auth/login":
+			loginCallCount++
+			resp := LoginResponse{Code: "000000", Message: "success"}
+			resp.Data.Token = fmt.Sprintf("token-%d", loginCallCount)
+			_ = json.NewEncoder(w).Encode(resp)
+		case "/api/v1/deviceData/detail/list":
+			if sessionExpired {
+				_, _ = w.Write([]byte(`{"error":"session expired"}`))
+				return
+			}
+			_, _ = w.Write(deviceData)
+		}
+	})
+
+	client, _, cleanup := setupTestClient(t, handler)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// A 200 OK with an error body fails the collection
+	data, err := client.CollectDeviceData(ctx)
+	assert.Nil(t, data)
+	var respErr *ResponseError
+	require.ErrorAs(t, err, &respErr)
+	assert.Equal(t, "session expired", respErr.Message)
+	assert.Equal(t, ErrorTypeErrorResponse, ClassifyError(err))
+	assert.False(t, client.GetConnectionStatus())
+
+	// The session error cleared the token, so the next collection logs in again
+	sessionExpired = false
+	data, err = client.CollectDeviceData(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, data)
+	assert.Equal(t, 2, loginCallCount, "should re-login after the session error")
+}
+
+func TestClient_CollectDeviceData_TokenExpiredMidCycle(t *testing.T) {
+	deviceData := loadTestData(t, "device_data.json")
+
+	loginCallCount := 0
+	dataCallCount := 0
+	// validToken is the only token WinPower accepts; clearing it simulates
+	// the token expiring on the WinPower side before its local expiry
+	validToken := ""
+	relogin := true
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/auth/login":
+			loginCallCount++
+			resp := LoginResponse{Code: "000000", Message: "success"}
+			resp.Data.Token = fmt.Sprintf("token-%d", loginCallCount)
+			if relogin {
+				validToken = resp.Data.Token
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+		case "/api/v1/deviceData/detail/list":
+			dataCallCount++
+			if r.Header.Get("Authorization") != "Bearer "+validToken {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"code":"401","msg":"token expired"}`))
+				return
+			}
+			_, _ = w.Write(deviceData)
+		}
+	})
+
+	client, _, cleanup := setupTestClient(t, handler)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// First cycle logs in and fetches the data
+	_, err := client.CollectDeviceData(ctx)
+	require.NoError(t, err)
+
+	// The token expires after the first cycle: the next cycle gets a 401,
+	// logs in again and completes with a retried fetch
+	validToken = ""
+	data, err := client.CollectDeviceData(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, data)
+	assert.Equal(t, 2, loginCallCount, "should re-login within the cycle")
+	assert.Equal(t, 3, dataCallCount, "should retry the rejected fetch once")
+	assert.True(t, client.GetConnectionStatus())
+
+	successes, failures := client.GetTokenRefreshCounts()
+	assert.Equal(t, int64(2), successes, "the re-login counts as a token refresh")
+	assert.Equal(t, int64(0), failures)
+
+	stats := client.GetStatistics()
+	assert.Equal(t, int64(2), stats["success_count"])
+	assert.Equal(t, int64(0), stats["error_count"])
+
+	// A token rejected again after the re-login fails the cycle without
+	// further retries
+	validToken = ""
+	relogin = false
+	data, err = client.CollectDeviceData(ctx)
+	assert.Nil(t, data)
+	assert.True(t, IsAuthenticationError(err), "unexpected error: %v", err)
+	assert.Equal(t, 3, loginCallCount)
+	assert.Equal(t, 5, dataCallCount)
+	assert.False(t, client.GetConnectionStatus())
+}
+
 func TestClient_CollectDeviceData_NetworkError(t *testing.T) {
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch {
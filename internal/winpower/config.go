@@ -3,7 +3,17 @@ package winpower
 import (
 	"fmt"
 	"net/url"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/tlsconfig"
+)
+
+// Default API paths, relative to BaseURL
+const (
+	DefaultLoginPath      = "/api/v1/auth/login"
+	DefaultDeviceDataPath = "/api/v1/deviceData/detail/list"
 )
 
 // Config holds the configuration for WinPower client.
@@ -17,26 +27,176 @@ type Config struct {
 	// Password for authentication
 	Password string `yaml:"password" mapstructure:"password"`
 
+	// LoginPath is the path of the login endpoint, appended to BaseURL.
+	// Set it when WinPower serves its API under a different prefix or version.
+	LoginPath string `yaml:"login_path" mapstructure:"login_path"`
+
+	// DeviceDataPath is the path of the device data endpoint, appended to BaseURL
+	DeviceDataPath string `yaml:"device_data_path" mapstructure:"device_data_path"`
+
 	// Timeout for HTTP requests
 	Timeout time.Duration `yaml:"timeout" mapstructure:"timeout"`
 
 	// SkipSSLVerify skips SSL certificate verification (for self-signed certificates)
 	SkipSSLVerify bool `yaml:"skip_ssl_verify" mapstructure:"skip_ssl_verify"`
 
+	// TLSMinVersion is the minimum TLS version used for HTTPS connections to
+	// WinPower ("1.0", "1.1", "1.2" or "1.3"). Empty means "1.2".
+	TLSMinVersion string `yaml:"tls_min_version" mapstructure:"tls_min_version"`
+
+	// TLSCipherSuites restricts the TLS 1.2 cipher suites offered to WinPower,
+	// by Go cipher suite name. Empty uses Go's defaults.
+	TLSCipherSuites []string `yaml:"tls_cipher_suites" mapstructure:"tls_cipher_suites"`
+
 	// RefreshThreshold is the time before expiration to refresh the token
 	RefreshThreshold time.Duration `yaml:"refresh_threshold" mapstructure:"refresh_threshold"`
 
+	// BackgroundRefresh renews the token in the background ahead of expiry so
+	// that collection requests keep using the cached token and never wait
+	// on a login while it is still valid.
+	BackgroundRefresh bool `yaml:"background_refresh" mapstructure:"background_refresh"`
+
+	// RefreshRetries is the number of additional attempts a background
+	// refresh makes before keeping the current token until the next round.
+	RefreshRetries int `yaml:"refresh_retries" mapstructure:"refresh_retries"`
+
+	// RefreshRetryInterval is the delay between background refresh attempts
+	RefreshRetryInterval time.Duration `yaml:"refresh_retry_interval" mapstructure:"refresh_retry_interval"`
+
+	// AuthTimeout bounds the authentication that Authenticate performs
+	// before a scheduled collection starts its deadline, so a slow login
+	// does not eat into the time meant for fetching device data. Zero
+	// disables it: authentication then happens within the collection.
+	AuthTimeout time.Duration `yaml:"auth_timeout" mapstructure:"auth_timeout"`
+
+	// MaxRetries is the number of additional attempts a device data request
+	// makes after a retryable failure within one collection; 0 disables
+	// retries.
+	MaxRetries int `yaml:"max_retries" mapstructure:"max_retries"`
+
+	// RetryInterval is the delay between device data request attempts
+	RetryInterval time.Duration `yaml:"retry_interval" mapstructure:"retry_interval"`
+
+	// RetryableStatusCodes are HTTP status codes retried by the startup
+	// verification, device data requests and background token refresh in
+	// addition to the defaults (401, 408, 425, 429 and every 5xx), e.g. a
+	// gateway's non-standard 420.
+	RetryableStatusCodes []int `yaml:"retryable_status_codes" mapstructure:"retryable_status_codes"`
+
+	// PermanentStatusCodes are HTTP status codes never retried, overriding
+	// the defaults, e.g. 503 from a gateway that answers it for a removed
+	// route. A code must not be in both lists.
+	PermanentStatusCodes []int `yaml:"permanent_status_codes" mapstructure:"permanent_status_codes"`
+
 	// UserAgent is the User-Agent header for HTTP requests
 	UserAgent string `yaml:"user_agent" mapstructure:"user_agent"`
+
+	// FollowRedirects follows HTTP redirects from WinPower, e.g. a load
+	// balancer redirecting to a specific node. When disabled the redirect
+	// response is treated as a failed request.
+	FollowRedirects bool `yaml:"follow_redirects" mapstructure:"follow_redirects"`
+
+	// MaxRedirects is the maximum number of redirects followed per request
+	MaxRedirects int `yaml:"max_redirects" mapstructure:"max_redirects"`
+
+	// AllowCrossHostRedirects permits following redirects to a different
+	// host. The Authorization header is never sent to another host.
+	AllowCrossHostRedirects bool `yaml:"allow_cross_host_redirects" mapstructure:"allow_cross_host_redirects"`
+
+	// FieldMap overrides the JSON keys used to read realtime fields, keyed by
+	// canonical field name (e.g. "load_percent": "load_pct"). Unset entries
+	// fall back to the built-in defaults.
+	FieldMap map[string]string `yaml:"field_map" mapstructure:"field_map"`
+
+	// TimeLayout is the Go reference layout of string timestamps such as
+	// data_time, e.g. "2006-01-02 15:04:05-0700". Empty accepts RFC3339 and
+	// the common WinPower formats, with or without a zone offset. Numeric
+	// Unix timestamps are accepted either way.
+	TimeLayout string `yaml:"time_layout" mapstructure:"time_layout"`
+
+	// TimeZone is the IANA location, e.g. "Asia/Shanghai", of timestamps
+	// that carry no zone offset. Timestamps with an offset are converted
+	// with their own offset. Empty reads zoneless timestamps as UTC.
+	TimeZone string `yaml:"time_zone" mapstructure:"time_zone"`
+
+	// IdentityField is the JSON key of a stable device identifier, such as a
+	// hardware serial number, looked up in the realtime, config and setting
+	// objects of each device. When set, energy is accumulated per stable
+	// identifier so it follows a device whose ID changes on rename.
+	// Empty disables identity tracking.
+	IdentityField string `yaml:"identity_field" mapstructure:"identity_field"`
+
+	// MaintenanceField is the dot-separated path of the maintenance
+	// indicator in the device data response, e.g. "maintenance" or
+	// "system.maintenanceMode". While the indicator is set, e.g. during a
+	// firmware update, the response is discarded: energy is not integrated
+	// and the metrics keep their last known values. Empty disables detection.
+	MaintenanceField string `yaml:"maintenance_field" mapstructure:"maintenance_field"`
+
+	// DeviceShortfallLimit fails a collection whose device data response
+	// contains at least this many devices fewer than the total WinPower
+	// reports, e.g. a page truncated by a pagination bug. Smaller mismatches
+	// are only logged and counted. Zero never fails the collection.
+	DeviceShortfallLimit int `yaml:"device_shortfall_limit" mapstructure:"device_shortfall_limit"`
+
+	// IdleCloseTimeout closes idle keep-alive connections to WinPower after
+	// this long without any request. Zero disables the policy, leaving idle
+	// connections to the transport's IdleConnTimeout.
+	IdleCloseTimeout time.Duration `yaml:"idle_close_timeout" mapstructure:"idle_close_timeout"`
+
+	// RedactFields are the header, query parameter and JSON field names
+	// (case-insensitive) whose values are replaced with "***" when request
+	// and response details are logged. Nil uses DefaultRedactFields; an
+	// empty list disables redaction.
+	RedactFields []string `yaml:"redact_fields" mapstructure:"redact_fields"`
+
+	// RawResponseMaxBytes keeps the last device data response, up to this
+	// size, so the fragment of a single device can be retrieved for
+	// debugging with sensitive fields redacted. A larger response is not
+	// kept. Zero disables retention.
+	RawResponseMaxBytes int `yaml:"raw_response_max_bytes" mapstructure:"raw_response_max_bytes"`
+
+	// Signing configures optional HMAC signing of every request
+	Signing SigningConfig `yaml:"signing" mapstructure:"signing"`
+
+	// VerifyOnStart logs in to WinPower before the scheduler starts, so an
+	// unreachable or misconfigured WinPower is detected at startup.
+	VerifyOnStart bool `yaml:"verify_on_start" mapstructure:"verify_on_start"`
+
+	// StartupConnectTimeout bounds how long the startup verification retries
+	// with backoff, e.g. while WinPower is still starting in an orchestrated
+	// deployment. Zero makes a single attempt.
+	StartupConnectTimeout time.Duration `yaml:"startup_connect_timeout" mapstructure:"startup_connect_timeout"`
+
+	// StartupFailureMode decides what happens when the startup verification
+	// fails: "fatal" (default) aborts startup, "degraded" starts anyway and
+	// keeps retrying on every collection.
+	StartupFailureMode string `yaml:"startup_failure_mode" mapstructure:"startup_failure_mode"`
 }
 
 // DefaultConfig returns a Config with default values.
 func DefaultConfig() *Config {
 	return &Config{
-		Timeout:          15 * time.Second,
-		SkipSSLVerify:    false,
-		RefreshThreshold: 5 * time.Minute,
-		UserAgent:        "Mozilla/5.0 (compatible; WinPower-Exporter/1.0)",
+		LoginPath:            DefaultLoginPath,
+		DeviceDataPath:       DefaultDeviceDataPath,
+		Timeout:              15 * time.Second,
+		SkipSSLVerify:        false,
+		TLSMinVersion:        tlsconfig.DefaultMinVersion,
+		RefreshThreshold:     5 * time.Minute,
+		BackgroundRefresh:    true,
+		RefreshRetries:       3,
+		RefreshRetryInterval: 10 * time.Second,
+		RetryInterval:        time.Second,
+		UserAgent:            "Mozilla/5.0 (compatible; WinPower-Exporter/1.0)",
+		FollowRedirects:      true,
+		MaxRedirects:         10,
+		StartupFailureMode:   StartupFailureFatal,
+		RedactFields:         append([]string(nil), DefaultRedactFields...),
+		Signing: SigningConfig{
+			Algorithm:       SigningAlgorithmHMACSHA256,
+			SignatureHeader: DefaultSignatureHeader,
+			TimestampHeader: DefaultTimestampHeader,
+		},
 	}
 }
 
@@ -69,6 +229,14 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate API paths against the base URL
+	if err := validateEndpointPath("login_path", c.BaseURL, c.LoginPath); err != nil {
+		return err
+	}
+	if err := validateEndpointPath("device_data_path", c.BaseURL, c.DeviceDataPath); err != nil {
+		return err
+	}
+
 	// Validate username
 	if c.Username == "" {
 		return &ConfigError{
@@ -93,6 +261,20 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate TLS settings
+	if _, err := tlsconfig.ParseVersion(c.TLSMinVersion); err != nil {
+		return &ConfigError{
+			Field:   "tls_min_version",
+			Message: err.Error(),
+		}
+	}
+	if _, err := tlsconfig.ParseCipherSuites(c.TLSCipherSuites); err != nil {
+		return &ConfigError{
+			Field:   "tls_cipher_suites",
+			Message: err.Error(),
+		}
+	}
+
 	// Validate refresh threshold
 	if c.RefreshThreshold < time.Minute {
 		return &ConfigError{
@@ -109,50 +291,336 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate background refresh retry budget
+	if c.RefreshRetries < 0 {
+		return &ConfigError{
+			Field:   "refresh_retries",
+			Message: fmt.Sprintf("must not be negative, got %d", c.RefreshRetries),
+		}
+	}
+
+	if c.RefreshRetryInterval < 0 {
+		return &ConfigError{
+			Field:   "refresh_retry_interval",
+			Message: fmt.Sprintf("must not be negative, got %v", c.RefreshRetryInterval),
+		}
+	}
+
+	// Validate device data request retry budget
+	if c.MaxRetries < 0 {
+		return &ConfigError{
+			Field:   "max_retries",
+			Message: fmt.Sprintf("must not be negative, got %d", c.MaxRetries),
+		}
+	}
+
+	if c.RetryInterval < 0 {
+		return &ConfigError{
+			Field:   "retry_interval",
+			Message: fmt.Sprintf("must not be negative, got %v", c.RetryInterval),
+		}
+	}
+
+	if err := validateStatusCodes(c.RetryableStatusCodes, c.PermanentStatusCodes); err != nil {
+		return err
+	}
+
+	// Validate redirect limit
+	if c.MaxRedirects < 0 {
+		return &ConfigError{
+			Field:   "max_redirects",
+			Message: fmt.Sprintf("must not be negative, got %d", c.MaxRedirects),
+		}
+	}
+
+	// Validate authentication timeout
+	if c.AuthTimeout < 0 {
+		return &ConfigError{
+			Field:   "auth_timeout",
+			Message: fmt.Sprintf("must not be negative, got %v", c.AuthTimeout),
+		}
+	}
+
+	// Validate idle close timeout
+	if c.IdleCloseTimeout < 0 {
+		return &ConfigError{
+			Field:   "idle_close_timeout",
+			Message: fmt.Sprintf("must not be negative, got %v", c.IdleCloseTimeout),
+		}
+	}
+
+	// Validate raw response retention
+	if c.RawResponseMaxBytes < 0 {
+		return &ConfigError{
+			Field:   "raw_response_max_bytes",
+			Message: fmt.Sprintf("must not be negative, got %d", c.RawResponseMaxBytes),
+		}
+	}
+
+	if err := validateFieldMap(c.FieldMap); err != nil {
+		return err
+	}
+
+	if err := validateTimeFormat(c.TimeLayout, c.TimeZone); err != nil {
+		return err
+	}
+
+	if err := validateMaintenanceField(c.MaintenanceField); err != nil {
+		return err
+	}
+
+	if c.DeviceShortfallLimit < 0 {
+		return &ConfigError{
+			Field:   "device_shortfall_limit",
+			Message: fmt.Sprintf("must not be negative, got %d", c.DeviceShortfallLimit),
+		}
+	}
+
+	if err := c.Signing.validate(); err != nil {
+		return err
+	}
+
+	// Validate startup verification
+	if c.StartupConnectTimeout < 0 {
+		return &ConfigError{
+			Field:   "startup_connect_timeout",
+			Message: fmt.Sprintf("must not be negative, got %v", c.StartupConnectTimeout),
+		}
+	}
+
+	if c.StartupFailureMode != "" && !slices.Contains(StartupFailureModes, c.StartupFailureMode) {
+		return &ConfigError{
+			Field: "startup_failure_mode",
+			Message: fmt.Sprintf("must be one of %s, got %q",
+				strings.Join(StartupFailureModes, ", "), c.StartupFailureMode),
+		}
+	}
+
+	return nil
+}
+
+// validateEndpointPath checks that path is absolute and combines with the
+// base URL into a well-formed URL without query or fragment. An empty path
+// selects the default and is always valid.
+func validateEndpointPath(field, baseURL, path string) error {
+	if path == "" {
+		return nil
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		return &ConfigError{
+			Field:   field,
+			Message: fmt.Sprintf("must start with '/', got %q", path),
+		}
+	}
+
+	endpoint, err := url.Parse(baseURL + path)
+	if err != nil {
+		return &ConfigError{
+			Field:   field,
+			Message: "invalid URL format",
+			Err:     err,
+		}
+	}
+
+	if endpoint.RawQuery != "" || endpoint.Fragment != "" || strings.ContainsAny(path, "?#") {
+		return &ConfigError{
+			Field:   field,
+			Message: fmt.Sprintf("must not contain a query or fragment, got %q", path),
+		}
+	}
+
 	return nil
 }
 
+// endpointURL joins the base URL and an API path, using fallback when the
+// path is not configured.
+func endpointURL(baseURL, path, fallback string) string {
+	if path == "" {
+		path = fallback
+	}
+	return baseURL + path
+}
+
 // WithDefaults fills in missing optional fields with default values.
 func (c *Config) WithDefaults() *Config {
 	defaults := DefaultConfig()
 
+	if c.LoginPath == "" {
+		c.LoginPath = defaults.LoginPath
+	}
+
+	if c.DeviceDataPath == "" {
+		c.DeviceDataPath = defaults.DeviceDataPath
+	}
+
 	if c.Timeout == 0 {
 		c.Timeout = defaults.Timeout
 	}
 
+	if c.TLSMinVersion == "" {
+		c.TLSMinVersion = defaults.TLSMinVersion
+	}
+
 	if c.RefreshThreshold == 0 {
 		c.RefreshThreshold = defaults.RefreshThreshold
 	}
 
+	if c.RefreshRetryInterval == 0 {
+		c.RefreshRetryInterval = defaults.RefreshRetryInterval
+	}
+
+	if c.RetryInterval == 0 {
+		c.RetryInterval = defaults.RetryInterval
+	}
+
 	if c.UserAgent == "" {
 		c.UserAgent = defaults.UserAgent
 	}
 
+	if c.MaxRedirects == 0 {
+		c.MaxRedirects = defaults.MaxRedirects
+	}
+
+	if c.StartupFailureMode == "" {
+		c.StartupFailureMode = defaults.StartupFailureMode
+	}
+
+	if c.RedactFields == nil {
+		c.RedactFields = defaults.RedactFields
+	}
+
+	if c.Signing.Algorithm == "" {
+		c.Signing.Algorithm = defaults.Signing.Algorithm
+	}
+
+	if c.Signing.SignatureHeader == "" {
+		c.Signing.SignatureHeader = defaults.Signing.SignatureHeader
+	}
+
+	if c.Signing.TimestampHeader == "" {
+		c.Signing.TimestampHeader = defaults.Signing.TimestampHeader
+	}
+
 	return c
 }
 
 // Clone creates a deep copy of the configuration.
 func (c *Config) Clone() *Config {
+	var fieldMap map[string]string
+	if c.FieldMap != nil {
+		fieldMap = make(map[string]string, len(c.FieldMap))
+		for canonical, key := range c.FieldMap {
+			fieldMap[canonical] = key
+		}
+	}
+
+	var cipherSuites []string
+	if c.TLSCipherSuites != nil {
+		cipherSuites = append([]string{}, c.TLSCipherSuites...)
+	}
+
+	var redactFields []string
+	if c.RedactFields != nil {
+		redactFields = append([]string{}, c.RedactFields...)
+	}
+
+	var retryableStatusCodes, permanentStatusCodes []int
+	if c.RetryableStatusCodes != nil {
+		retryableStatusCodes = append([]int{}, c.RetryableStatusCodes...)
+	}
+	if c.PermanentStatusCodes != nil {
+		permanentStatusCodes = append([]int{}, c.PermanentStatusCodes...)
+	}
+
 	return &Config{
-		BaseURL:          c.BaseURL,
-		Username:         c.Username,
-		Password:         c.Password,
-		Timeout:          c.Timeout,
-		SkipSSLVerify:    c.SkipSSLVerify,
-		RefreshThreshold: c.RefreshThreshold,
-		UserAgent:        c.UserAgent,
+		BaseURL:                 c.BaseURL,
+		Username:                c.Username,
+		Password:                c.Password,
+		LoginPath:               c.LoginPath,
+		DeviceDataPath:          c.DeviceDataPath,
+		Timeout:                 c.Timeout,
+		SkipSSLVerify:           c.SkipSSLVerify,
+		TLSMinVersion:           c.TLSMinVersion,
+		TLSCipherSuites:         cipherSuites,
+		RefreshThreshold:        c.RefreshThreshold,
+		BackgroundRefresh:       c.BackgroundRefresh,
+		RefreshRetries:          c.RefreshRetries,
+		RefreshRetryInterval:    c.RefreshRetryInterval,
+		AuthTimeout:             c.AuthTimeout,
+		MaxRetries:              c.MaxRetries,
+		RetryInterval:           c.RetryInterval,
+		RetryableStatusCodes:    retryableStatusCodes,
+		PermanentStatusCodes:    permanentStatusCodes,
+		UserAgent:               c.UserAgent,
+		FollowRedirects:         c.FollowRedirects,
+		MaxRedirects:            c.MaxRedirects,
+		AllowCrossHostRedirects: c.AllowCrossHostRedirects,
+		FieldMap:                fieldMap,
+		TimeLayout:              c.TimeLayout,
+		TimeZone:                c.TimeZone,
+		IdentityField:           c.IdentityField,
+		MaintenanceField:        c.MaintenanceField,
+		DeviceShortfallLimit:    c.DeviceShortfallLimit,
+		IdleCloseTimeout:        c.IdleCloseTimeout,
+		RedactFields:            redactFields,
+		RawResponseMaxBytes:     c.RawResponseMaxBytes,
+		Signing:                 c.Signing,
+		VerifyOnStart:           c.VerifyOnStart,
+		StartupConnectTimeout:   c.StartupConnectTimeout,
+		StartupFailureMode:      c.StartupFailureMode,
 	}
 }
 
 // Sanitize returns a copy of the config with sensitive fields masked for logging.
 func (c *Config) Sanitize() map[string]interface{} {
+	secret := ""
+	if c.Signing.Secret != "" {
+		secret = "***REDACTED***"
+	}
+
 	return map[string]interface{}{
-		"base_url":          c.BaseURL,
-		"username":          c.Username,
-		"password":          "***REDACTED***",
-		"timeout":           c.Timeout.String(),
-		"skip_ssl_verify":   c.SkipSSLVerify,
-		"refresh_threshold": c.RefreshThreshold.String(),
-		"user_agent":        c.UserAgent,
+		"base_url":                   c.BaseURL,
+		"username":                   c.Username,
+		"password":                   "***REDACTED***",
+		"login_path":                 c.LoginPath,
+		"device_data_path":           c.DeviceDataPath,
+		"timeout":                    c.Timeout.String(),
+		"skip_ssl_verify":            c.SkipSSLVerify,
+		"tls_min_version":            c.TLSMinVersion,
+		"tls_cipher_suites":          c.TLSCipherSuites,
+		"refresh_threshold":          c.RefreshThreshold.String(),
+		"background_refresh":         c.BackgroundRefresh,
+		"refresh_retries":            c.RefreshRetries,
+		"refresh_retry_interval":     c.RefreshRetryInterval.String(),
+		"auth_timeout":               c.AuthTimeout.String(),
+		"max_retries":                c.MaxRetries,
+		"retry_interval":             c.RetryInterval.String(),
+		"retryable_status_codes":     c.RetryableStatusCodes,
+		"permanent_status_codes":     c.PermanentStatusCodes,
+		"user_agent":                 c.UserAgent,
+		"follow_redirects":           c.FollowRedirects,
+		"max_redirects":              c.MaxRedirects,
+		"allow_cross_host_redirects": c.AllowCrossHostRedirects,
+		"field_map":                  c.FieldMap,
+		"time_layout":                c.TimeLayout,
+		"time_zone":                  c.TimeZone,
+		"identity_field":             c.IdentityField,
+		"maintenance_field":          c.MaintenanceField,
+		"device_shortfall_limit":     c.DeviceShortfallLimit,
+		"idle_close_timeout":         c.IdleCloseTimeout.String(),
+		"redact_fields":              c.RedactFields,
+		"raw_response_max_bytes":     c.RawResponseMaxBytes,
+		"verify_on_start":            c.VerifyOnStart,
+		"startup_connect_timeout":    c.StartupConnectTimeout.String(),
+		"startup_failure_mode":       c.StartupFailureMode,
+		"signing": map[string]interface{}{
+			"enabled":          c.Signing.Enabled,
+			"secret":           secret,
+			"secret_file":      c.Signing.SecretFile,
+			"algorithm":        c.Signing.Algorithm,
+			"signature_header": c.Signing.SignatureHeader,
+			"timestamp_header": c.Signing.TimestampHeader,
+		},
 	}
 }
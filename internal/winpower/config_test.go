@@ -43,6 +43,19 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "unknown field_map entry",
+			cfg: &Config{
+				BaseURL:          "https://winpower.example.com",
+				Username:         "admin",
+				Password:         "secret",
+				Timeout:          15 * time.Second,
+				RefreshThreshold: 5 * time.Minute,
+				FieldMap:         map[string]string{"not_a_field": "x"},
+			},
+			wantErr: true,
+			errMsg:  "field_map",
+		},
 		{
 			name: "empty base_url",
 			cfg: &Config{
@@ -151,6 +164,188 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "refresh_threshold",
 		},
+		{
+			name: "negative idle close timeout",
+			cfg: &Config{
+				BaseURL:          "https://winpower.example.com",
+				Username:         "admin",
+				Password:         "secret",
+				Timeout:          15 * time.Second,
+				RefreshThreshold: 5 * time.Minute,
+				IdleCloseTimeout: -time.Second,
+			},
+			wantErr: true,
+			errMsg:  "idle_close_timeout",
+		},
+		{
+			name: "negative auth timeout",
+			cfg: &Config{
+				BaseURL:          "https://winpower.example.com",
+				Username:         "admin",
+				Password:         "secret",
+				Timeout:          15 * time.Second,
+				RefreshThreshold: 5 * time.Minute,
+				AuthTimeout:      -time.Second,
+			},
+			wantErr: true,
+			errMsg:  "auth_timeout",
+		},
+		{
+			name: "negative raw response size",
+			cfg: &Config{
+				BaseURL:             "https://winpower.example.com",
+				Username:            "admin",
+				Password:            "secret",
+				Timeout:             15 * time.Second,
+				RefreshThreshold:    5 * time.Minute,
+				RawResponseMaxBytes: -1,
+			},
+			wantErr: true,
+			errMsg:  "raw_response_max_bytes",
+		},
+		{
+			name: "negative device shortfall limit",
+			cfg: &Config{
+				BaseURL:              "https://winpower.example.com",
+				Username:             "admin",
+				Password:             "secret",
+				Timeout:              15 * time.Second,
+				RefreshThreshold:     5 * time.Minute,
+				DeviceShortfallLimit: -1,
+			},
+			wantErr: true,
+			errMsg:  "device_shortfall_limit",
+		},
+		{
+			name: "time layout without reference elements",
+			cfg: &Config{
+				BaseURL:          "https://winpower.example.com",
+				Username:         "admin",
+				Password:         "secret",
+				Timeout:          15 * time.Second,
+				RefreshThreshold: 5 * time.Minute,
+				TimeLayout:       "yyyy-MM-dd HH:mm:ss",
+			},
+			wantErr: true,
+			errMsg:  "time_layout",
+		},
+		{
+			name: "unknown time zone",
+			cfg: &Config{
+				BaseURL:          "https://winpower.example.com",
+				Username:         "admin",
+				Password:         "secret",
+				Timeout:          15 * time.Second,
+				RefreshThreshold: 5 * time.Minute,
+				TimeZone:         "Mars/Olympus_Mons",
+			},
+			wantErr: true,
+			errMsg:  "time_zone",
+		},
+		{
+			name: "unknown TLS version",
+			cfg: &Config{
+				BaseURL:          "https://winpower.example.com",
+				Username:         "admin",
+				Password:         "secret",
+				Timeout:          15 * time.Second,
+				RefreshThreshold: 5 * time.Minute,
+				TLSMinVersion:    "1.4",
+			},
+			wantErr: true,
+			errMsg:  "tls_min_version",
+		},
+		{
+			name: "unknown TLS cipher suite",
+			cfg: &Config{
+				BaseURL:          "https://winpower.example.com",
+				Username:         "admin",
+				Password:         "secret",
+				Timeout:          15 * time.Second,
+				RefreshThreshold: 5 * time.Minute,
+				TLSCipherSuites:  []string{"TLS_RSA_WITH_RC4_128_SHA"},
+			},
+			wantErr: true,
+			errMsg:  "tls_cipher_suites",
+		},
+		{
+			name: "custom API paths",
+			cfg: &Config{
+				BaseURL:          "https://winpower.example.com",
+				Username:         "admin",
+				Password:         "secret",
+				LoginPath:        "/winpower/api/v2/auth/login",
+				DeviceDataPath:   "/winpower/api/v2/deviceData/detail/list",
+				Timeout:          15 * time.Second,
+				RefreshThreshold: 5 * time.Minute,
+			},
+			wantErr: false,
+		},
+		{
+			name: "relative login path",
+			cfg: &Config{
+				BaseURL:          "https://winpower.example.com",
+				Username:         "admin",
+				Password:         "secret",
+				LoginPath:        "api/v1/auth/login",
+				Timeout:          15 * time.Second,
+				RefreshThreshold: 5 * time.Minute,
+			},
+			wantErr: true,
+			errMsg:  "login_path",
+		},
+		{
+			name: "device data path with query",
+			cfg: &Config{
+				BaseURL:          "https://winpower.example.com",
+				Username:         "admin",
+				Password:         "secret",
+				DeviceDataPath:   "/api/v1/deviceData/detail/list?pageSize=10",
+				Timeout:          15 * time.Second,
+				RefreshThreshold: 5 * time.Minute,
+			},
+			wantErr: true,
+			errMsg:  "device_data_path",
+		},
+		{
+			name: "malformed device data path",
+			cfg: &Config{
+				BaseURL:          "https://winpower.example.com",
+				Username:         "admin",
+				Password:         "secret",
+				DeviceDataPath:   "/api/%zz/list",
+				Timeout:          15 * time.Second,
+				RefreshThreshold: 5 * time.Minute,
+			},
+			wantErr: true,
+			errMsg:  "device_data_path",
+		},
+		{
+			name: "negative max redirects",
+			cfg: &Config{
+				BaseURL:          "https://winpower.example.com",
+				Username:         "admin",
+				Password:         "secret",
+				Timeout:          15 * time.Second,
+				RefreshThreshold: 5 * time.Minute,
+				MaxRedirects:     -1,
+			},
+			wantErr: true,
+			errMsg:  "max_redirects",
+		},
+		{
+			name: "negative refresh retries",
+			cfg: &Config{
+				BaseURL:          "https://winpower.example.com",
+				Username:         "admin",
+				Password:         "secret",
+				Timeout:          15 * time.Second,
+				RefreshThreshold: 5 * time.Minute,
+				RefreshRetries:   -1,
+			},
+			wantErr: true,
+			errMsg:  "refresh_retries",
+		},
 		{
 			name: "http URL allowed",
 			cfg: &Config{
@@ -240,6 +435,7 @@ func TestConfig_Clone(t *testing.T) {
 		SkipSSLVerify:    true,
 		RefreshThreshold: 5 * time.Minute,
 		UserAgent:        "Test Agent",
+		TLSCipherSuites:  []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
 	}
 
 	cloned := original.Clone()
@@ -272,6 +468,10 @@ func TestConfig_Clone(t *testing.T) {
 	if original.Password == "modified" {
 		t.Error("modifying clone affected original")
 	}
+	cloned.TLSCipherSuites[0] = "modified"
+	if original.TLSCipherSuites[0] == "modified" {
+		t.Error("modifying cloned cipher suites affected original")
+	}
 }
 
 func TestConfig_Sanitize(t *testing.T) {
@@ -1,8 +1,13 @@
 package winpower
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"math"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -10,17 +15,35 @@ import (
 
 // DataParser parses WinPower API responses into standardized data structures.
 type DataParser struct {
-	logger *zap.Logger
+	logger   *zap.Logger
+	fieldMap map[string]string // canonical field name -> WinPower JSON key
+
+	// identityField is the JSON key of the stable device identifier, empty
+	// when identity tracking is disabled
+	identityField string
+	observer      ResponseObserver // receives parse durations; nil disables recording
+
+	timeLayouts  []string       // layouts tried for string timestamps
+	timeLocation *time.Location // location of zoneless timestamps
 }
 
-// NewDataParser creates a new DataParser instance.
+// NewDataParser creates a new DataParser instance using the default field mapping.
 func NewDataParser(logger *zap.Logger) *DataParser {
+	return NewDataParserWithFieldMap(logger, nil)
+}
+
+// NewDataParserWithFieldMap creates a new DataParser whose realtime field keys
+// are taken from fieldMap, falling back to the defaults for unmapped fields.
+func NewDataParserWithFieldMap(logger *zap.Logger, fieldMap map[string]string) *DataParser {
 	if logger == nil {
 		// Use a no-op logger as fallback
 		logger = zap.NewNop()
 	}
 	return &DataParser{
-		logger: logger,
+		logger:       logger,
+		fieldMap:     resolveFieldMap(fieldMap),
+		timeLayouts:  defaultTimeLayouts,
+		timeLocation: time.UTC,
 	}
 }
 
@@ -30,6 +53,32 @@ func (p *DataParser) ParseResponse(response *DeviceDataResponse) ([]ParsedDevice
 		return nil, ErrInvalidResponse
 	}
 
+	start := time.Now()
+	defer func() {
+		elapsed := time.Since(start)
+		p.logger.Debug("Parsed device data response",
+			zap.Int("devices", len(response.Data)),
+			zap.Duration("duration", elapsed))
+		if p.observer != nil {
+			p.observer.ObserveParseDuration(elapsed)
+		}
+	}()
+
+	// The data of a response during maintenance is not trustworthy
+	if response.Maintenance {
+		p.logger.Warn("WinPower reports maintenance mode, discarding device data",
+			zap.Int("devices", len(response.Data)))
+		return nil, ErrMaintenance
+	}
+
+	// Some firmware reports failures as an error object in a 200 OK response
+	if err := responseError(response); err != nil {
+		p.logger.Warn("API returned an error response",
+			zap.String("message", err.Message),
+			zap.Bool("session", err.Session))
+		return nil, err
+	}
+
 	// Check response code
 	if response.Code != "000000" {
 		p.logger.Warn("API returned non-success code",
@@ -38,7 +87,8 @@ func (p *DataParser) ParseResponse(response *DeviceDataResponse) ([]ParsedDevice
 		return nil, fmt.Errorf("API error: code=%s, msg=%s", response.Code, response.Msg)
 	}
 
-	// Check if data is empty
+	// An empty device list is a valid response, e.g. when WinPower
+	// manages no devices
 	if len(response.Data) == 0 {
 		p.logger.Debug("No device data in response")
 		return []ParsedDeviceData{}, nil
@@ -59,9 +109,51 @@ func (p *DataParser) ParseResponse(response *DeviceDataResponse) ([]ParsedDevice
 		result = append(result, *parsed)
 	}
 
+	// Devices were reported but none could be parsed; do not mistake this
+	// for an empty device list
+	if len(result) == 0 {
+		return nil, fmt.Errorf("%w: none of %d devices could be parsed", ErrParseError, len(response.Data))
+	}
+
 	return result, nil
 }
 
+// sessionErrorKeywords identify error messages about an expired or invalid
+// session, matched case-insensitively.
+var sessionErrorKeywords = []string{"session", "token", "expired", "unauthorized", "not logged in"}
+
+// responseError returns the error object in the response body, or nil if
+// there is none. The error is a string or an object with a "message" field;
+// any other value is reported as raw JSON.
+func responseError(response *DeviceDataResponse) *ResponseError {
+	raw := bytes.TrimSpace(response.Error)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil
+	}
+
+	message := string(raw)
+	var text string
+	var object struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(raw, &text) == nil {
+		message = text
+	} else if json.Unmarshal(raw, &object) == nil && object.Message != "" {
+		message = object.Message
+	}
+
+	lower := strings.ToLower(message)
+	session := false
+	for _, keyword := range sessionErrorKeywords {
+		if strings.Contains(lower, keyword) {
+			session = true
+			break
+		}
+	}
+
+	return &ResponseError{Message: message, Session: session}
+}
+
 // parseDeviceInfo parses a single DeviceInfo into ParsedDeviceData.
 func (p *DataParser) parseDeviceInfo(deviceInfo *DeviceInfo) (*ParsedDeviceData, error) {
 	if deviceInfo == nil {
@@ -75,12 +167,29 @@ func (p *DataParser) parseDeviceInfo(deviceInfo *DeviceInfo) (*ParsedDeviceData,
 		Alias:       deviceInfo.AssetDevice.Alias,
 		Connected:   deviceInfo.Connected,
 		CollectedAt: time.Now(),
+		StableID:    p.parseStableID(deviceInfo),
+
+		ActiveAlarms: parseActiveAlarms(deviceInfo.ActiveAlarms),
 	}
 
 	// Parse realtime data
 	if len(deviceInfo.Realtime) > 0 {
 		realtime := p.parseRealtimeData(deviceInfo.Realtime)
 		parsed.Realtime = realtime
+
+		parsed.MissingFields, parsed.MissingOptionalFields = p.missingFields(deviceInfo.Realtime)
+		if len(parsed.MissingFields) > 0 {
+			p.logger.Warn("Mapped fields missing from realtime data",
+				zap.String("device_id", parsed.DeviceID),
+				zap.Strings("fields", parsed.MissingFields))
+		}
+
+		parsed.InvalidFields = p.invalidFields(deviceInfo.Realtime)
+		if len(parsed.InvalidFields) > 0 {
+			p.logger.Warn("Non-finite values in realtime data",
+				zap.String("device_id", parsed.DeviceID),
+				zap.Strings("fields", parsed.InvalidFields))
+		}
 	} else {
 		p.logger.Warn("No realtime data available",
 			zap.String("device_id", parsed.DeviceID))
@@ -89,6 +198,40 @@ func (p *DataParser) parseDeviceInfo(deviceInfo *DeviceInfo) (*ParsedDeviceData,
 	return parsed, nil
 }
 
+// parseStableID reads the stable device identifier from the realtime, config
+// and setting objects, in that order. String and numeric values are accepted.
+func (p *DataParser) parseStableID(deviceInfo *DeviceInfo) string {
+	if p.identityField == "" {
+		return ""
+	}
+
+	for _, raw := range []map[string]interface{}{deviceInfo.Realtime, deviceInfo.Config, deviceInfo.Setting} {
+		val, ok := raw[p.identityField]
+		if !ok {
+			continue
+		}
+		switch v := val.(type) {
+		case string:
+			if id := strings.TrimSpace(v); id != "" {
+				return id
+			}
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			p.logger.Warn("Unexpected type for identity field",
+				zap.String("device_id", deviceInfo.AssetDevice.ID),
+				zap.String("field", p.identityField),
+				zap.String("type", fmt.Sprintf("%T", v)))
+			return ""
+		}
+	}
+
+	p.logger.Debug("Identity field not found in device data",
+		zap.String("device_id", deviceInfo.AssetDevice.ID),
+		zap.String("field", p.identityField))
+	return ""
+}
+
 // parseRealtimeData parses realtime data map into RealtimeData structure.
 func (p *DataParser) parseRealtimeData(raw map[string]interface{}) RealtimeData {
 	data := RealtimeData{
@@ -96,43 +239,98 @@ func (p *DataParser) parseRealtimeData(raw map[string]interface{}) RealtimeData
 	}
 
 	// Parse power data (most important for energy calculation)
-	data.LoadTotalWatt = p.parseFloat(raw, "loadTotalWatt", "load total watt")
+	data.LoadTotalWatt = p.parseFloat(raw, p.fieldMap["load_total_watt"], "load total watt")
+
+	// Parse interval-average power (optional field without a default key)
+	if key, ok := p.fieldMap["load_avg_watt"]; ok {
+		data.LoadAvgWatt = p.parseFloat(raw, key, "load average watt")
+		data.LoadAvgReported = hasFloat(raw, key)
+	}
+
+	// Parse device-reported energy (optional field without a default key)
+	if key, ok := p.fieldMap["energy_total_wh"]; ok {
+		data.EnergyTotalWh = p.parseFloat(raw, key, "energy total Wh")
+		data.EnergyTotalReported = hasFloat(raw, key)
+	}
+
+	// Parse data refresh time (optional field without a default key)
+	if key, ok := p.fieldMap["data_time"]; ok {
+		data.DataTime = p.parseTime(raw, key, "data time")
+	}
 
 	// Parse voltage data
-	data.InputVolt1 = p.parseFloat(raw, "inputVolt1", "input volt 1")
-	data.OutputVolt1 = p.parseFloat(raw, "outputVolt1", "output volt 1")
-	data.BatVoltP = p.parseFloat(raw, "batVoltP", "battery volt percentage")
+	data.InputVolt1 = p.parseFloat(raw, p.fieldMap["input_volt_1"], "input volt 1")
+	data.OutputVolt1 = p.parseFloat(raw, p.fieldMap["output_volt_1"], "output volt 1")
+	data.BatVoltP = p.parseFloat(raw, p.fieldMap["bat_volt_p"], "battery volt percentage")
 
 	// Parse current data
-	data.OutputCurrent1 = p.parseFloat(raw, "outputCurrent1", "output current 1")
+	data.OutputCurrent1 = p.parseFloat(raw, p.fieldMap["output_current_1"], "output current 1")
 
 	// Parse frequency data
-	data.InputFreq = p.parseFloat(raw, "inputFreq", "input frequency")
-	data.OutputFreq = p.parseFloat(raw, "outputFreq", "output frequency")
+	data.InputFreq = p.parseFloat(raw, p.fieldMap["input_freq"], "input frequency")
+	data.OutputFreq = p.parseFloat(raw, p.fieldMap["output_freq"], "output frequency")
 
 	// Parse load data
-	data.LoadPercent = p.parseFloat(raw, "loadPercent", "load percent")
-	data.LoadTotalVa = p.parseFloat(raw, "loadTotalVa", "load total VA")
-	data.LoadWatt1 = p.parseFloat(raw, "loadWatt1", "load watt 1")
-	data.LoadVa1 = p.parseFloat(raw, "loadVa1", "load VA 1")
+	data.LoadPercent = p.parseFloat(raw, p.fieldMap["load_percent"], "load percent")
+	data.LoadTotalVa = p.parseFloat(raw, p.fieldMap["load_total_va"], "load total VA")
+	data.LoadWatt1 = p.parseFloat(raw, p.fieldMap["load_watt_1"], "load watt 1")
+	data.LoadVa1 = p.parseFloat(raw, p.fieldMap["load_va_1"], "load VA 1")
 
 	// Parse battery data
-	data.BatCapacity = p.parseFloat(raw, "batCapacity", "battery capacity")
-	data.BatRemainTime = p.parseInt(raw, "batRemainTime", "battery remain time")
-	data.IsCharging = p.parseBool(raw, "isCharging", "is charging")
+	data.BatCapacity = p.parseFloat(raw, p.fieldMap["bat_capacity"], "battery capacity")
+	data.BatRemainTime = p.parseInt(raw, p.fieldMap["bat_remain_time"], "battery remain time")
+	data.IsCharging = p.parseBool(raw, p.fieldMap["is_charging"], "is charging")
 
 	// Parse status data
-	data.UpsTemperature = p.parseFloat(raw, "upsTemperature", "UPS temperature")
-	data.Mode = p.parseString(raw, "mode", "mode")
-	data.Status = p.parseString(raw, "status", "status")
-	data.BatteryStatus = p.parseString(raw, "batteryStatus", "battery status")
-	data.TestStatus = p.parseString(raw, "testStatus", "test status")
-	data.FaultCode = p.parseString(raw, "faultCode", "fault code")
+	data.UpsTemperature = p.parseFloat(raw, p.fieldMap["ups_temperature"], "UPS temperature")
+	data.Mode = p.parseString(raw, p.fieldMap["mode"], "mode")
+	data.Status = p.parseString(raw, p.fieldMap["status"], "status")
+	data.BatteryStatus = p.parseString(raw, p.fieldMap["battery_status"], "battery status")
+	data.TestStatus = p.parseString(raw, p.fieldMap["test_status"], "test status")
+	data.FaultCode = p.parseString(raw, p.fieldMap["fault_code"], "fault code")
 
 	return data
 }
 
-// parseFloat extracts and converts a string field to float64.
+// missingFields returns the canonical names of mapped fields whose JSON key
+// is absent from the raw realtime data, split into built-in and optional
+// fields and sorted for stable output. Optional fields are not sent by every
+// firmware, so their absence is not an error.
+func (p *DataParser) missingFields(raw map[string]interface{}) (required, optional []string) {
+	for canonical, key := range p.fieldMap {
+		if _, ok := raw[key]; ok {
+			continue
+		}
+		if optionalFields[canonical] {
+			optional = append(optional, canonical)
+		} else {
+			required = append(required, canonical)
+		}
+	}
+	sort.Strings(required)
+	sort.Strings(optional)
+	return required, optional
+}
+
+// invalidFields returns the canonical names of mapped measurement fields
+// whose value is NaN or infinite, sorted for stable output.
+func (p *DataParser) invalidFields(raw map[string]interface{}) []string {
+	var invalid []string
+	for _, canonical := range floatFields {
+		key, ok := p.fieldMap[canonical]
+		if !ok {
+			continue
+		}
+		if f, ok := floatValue(raw[key]); ok && !isFinite(f) {
+			invalid = append(invalid, canonical)
+		}
+	}
+	sort.Strings(invalid)
+	return invalid
+}
+
+// parseFloat extracts and converts a string field to float64. NaN and
+// infinite values are reported by invalidFields and converted to zero.
 func (p *DataParser) parseFloat(raw map[string]interface{}, key, fieldName string) float64 {
 	val, ok := raw[key]
 	if !ok {
@@ -155,8 +353,14 @@ func (p *DataParser) parseFloat(raw map[string]interface{}, key, fieldName strin
 				zap.Error(err))
 			return 0
 		}
+		if !isFinite(f) {
+			return 0
+		}
 		return f
 	case float64:
+		if !isFinite(v) {
+			return 0
+		}
 		return v
 	case int:
 		return float64(v)
@@ -170,6 +374,36 @@ func (p *DataParser) parseFloat(raw map[string]interface{}, key, fieldName strin
 	}
 }
 
+// hasFloat reports whether the field holds a value parseFloat converts
+// without falling back to zero.
+func hasFloat(raw map[string]interface{}, key string) bool {
+	f, ok := floatValue(raw[key])
+	return ok && isFinite(f)
+}
+
+// floatValue converts a numeric or numeric string value to float64,
+// reporting whether it is a number.
+func floatValue(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// isFinite reports whether f is neither NaN nor infinite.
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
 // parseInt extracts and converts a string field to int.
 func (p *DataParser) parseInt(raw map[string]interface{}, key, fieldName string) int {
 	val, ok := raw[key]
@@ -237,6 +471,67 @@ func (p *DataParser) parseBool(raw map[string]interface{}, key, fieldName string
 	}
 }
 
+// parseTime extracts a timestamp field. Strings are parsed as numeric Unix
+// timestamps or with the configured time layouts, reading zoneless values in
+// the configured location; numeric Unix timestamps above 1e12 are taken as
+// milliseconds, others as seconds. Unparseable timestamps are returned as
+// the zero time.
+func (p *DataParser) parseTime(raw map[string]interface{}, key, fieldName string) time.Time {
+	val, ok := raw[key]
+	if !ok {
+		p.logger.Debug("Field not found in raw data",
+			zap.String("field", key))
+		return time.Time{}
+	}
+
+	// Handle different value types
+	switch v := val.(type) {
+	case string:
+		if v == "" {
+			return time.Time{}
+		}
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return unixTime(f)
+		}
+		t, err := parseTimestamp(v, p.timeLayouts, p.timeLocation)
+		if err != nil {
+			p.invalidTime(key, zap.String("value", v), zap.Error(err))
+		}
+		return t
+	case float64:
+		return unixTime(v)
+	case int:
+		return unixTime(float64(v))
+	case int64:
+		return unixTime(float64(v))
+	default:
+		p.invalidTime(key, zap.String("type", fmt.Sprintf("%T", v)))
+		return time.Time{}
+	}
+}
+
+// invalidTime logs and counts a timestamp that could not be parsed. The
+// timestamp is left unset rather than replaced with the current time, so a
+// wrong clock is never mistaken for a fresh sample.
+func (p *DataParser) invalidTime(key string, fields ...zap.Field) {
+	p.logger.Warn("Failed to parse time field, leaving it unset",
+		append([]zap.Field{zap.String("field", key)}, fields...)...)
+	if p.observer != nil {
+		p.observer.ObserveInvalidTimestamp()
+	}
+}
+
+// unixTime converts a Unix timestamp in seconds or milliseconds to a time.
+func unixTime(ts float64) time.Time {
+	if ts <= 0 {
+		return time.Time{}
+	}
+	if ts > 1e12 {
+		return time.UnixMilli(int64(ts))
+	}
+	return time.Unix(int64(ts), 0)
+}
+
 // parseString extracts a string field.
 func (p *DataParser) parseString(raw map[string]interface{}, key, fieldName string) string {
 	val, ok := raw[key]
@@ -1,6 +1,9 @@
 package winpower
 
 import (
+	"encoding/json"
+	"math"
+	"strconv"
 	"testing"
 	"time"
 
@@ -46,6 +49,45 @@ func TestDataParser_ParseResponse(t *testing.T) {
 		assert.Nil(t, result)
 	})
 
+	t.Run("error response", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			body    string
+			message string
+			session bool
+		}{
+			{name: "session string", body: `{"error":"session expired"}`, message: "session expired", session: true},
+			{name: "message object", body: `{"error":{"message":"Invalid Token"}}`, message: "Invalid Token", session: true},
+			{name: "other error", body: `{"error":"database unavailable"}`, message: "database unavailable"},
+			{name: "unknown shape", body: `{"error":{"code":42}}`, message: `{"code":42}`},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var response DeviceDataResponse
+				require.NoError(t, json.Unmarshal([]byte(tt.body), &response))
+
+				result, err := parser.ParseResponse(&response)
+				assert.Nil(t, result)
+
+				var respErr *ResponseError
+				require.ErrorAs(t, err, &respErr)
+				assert.Equal(t, tt.message, respErr.Message)
+				assert.Equal(t, tt.session, IsAuthenticationError(err))
+				assert.Equal(t, ErrorTypeErrorResponse, ClassifyError(err))
+			})
+		}
+	})
+
+	t.Run("null error", func(t *testing.T) {
+		var response DeviceDataResponse
+		require.NoError(t, json.Unmarshal([]byte(`{"code":"000000","error":null,"data":[]}`), &response))
+
+		result, err := parser.ParseResponse(&response)
+		assert.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
 	t.Run("empty data", func(t *testing.T) {
 		response := &DeviceDataResponse{
 			Code: "000000",
@@ -206,6 +248,198 @@ func TestDataParser_parseRealtimeData(t *testing.T) {
 	})
 }
 
+func TestDataParser_FieldMap(t *testing.T) {
+	t.Run("override maps canonical field to custom key", func(t *testing.T) {
+		parser := NewDataParserWithFieldMap(zap.NewNop(), map[string]string{
+			"load_percent": "load_pct",
+		})
+
+		info := &DeviceInfo{
+			AssetDevice: AssetDevice{ID: "dev-1"},
+			Realtime: map[string]interface{}{
+				"load_pct":      "42.5",
+				"loadTotalWatt": "800",
+			},
+		}
+
+		parsed, err := parser.parseDeviceInfo(info)
+		require.NoError(t, err)
+		assert.Equal(t, 42.5, parsed.Realtime.LoadPercent)
+		assert.Equal(t, 800.0, parsed.Realtime.LoadTotalWatt)
+		assert.NotContains(t, parsed.MissingFields, "load_percent")
+		assert.NotContains(t, parsed.MissingFields, "load_total_watt")
+		assert.Contains(t, parsed.MissingFields, "input_volt_1")
+	})
+
+	t.Run("missing mapped field is skipped", func(t *testing.T) {
+		parser := NewDataParserWithFieldMap(zap.NewNop(), map[string]string{
+			"load_percent": "load_pct",
+		})
+
+		info := &DeviceInfo{
+			AssetDevice: AssetDevice{ID: "dev-1"},
+			Realtime: map[string]interface{}{
+				"loadPercent": "42.5", // old key no longer mapped
+			},
+		}
+
+		parsed, err := parser.parseDeviceInfo(info)
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, parsed.Realtime.LoadPercent)
+		assert.Contains(t, parsed.MissingFields, "load_percent")
+	})
+
+	t.Run("missing optional field is not a missing field", func(t *testing.T) {
+		parser := NewDataParserWithFieldMap(zap.NewNop(), map[string]string{
+			"energy_total_wh": "totalEnergy",
+			"load_avg_watt":   "loadAvgWatt",
+		})
+
+		info := &DeviceInfo{
+			AssetDevice: AssetDevice{ID: "dev-1"},
+			Realtime: map[string]interface{}{
+				"loadTotalWatt": "800",
+				"loadAvgWatt":   "750",
+			},
+		}
+
+		parsed, err := parser.parseDeviceInfo(info)
+		require.NoError(t, err)
+		assert.NotContains(t, parsed.MissingFields, "energy_total_wh")
+		assert.Equal(t, []string{"energy_total_wh"}, parsed.MissingOptionalFields)
+	})
+
+	t.Run("non-finite values are reported and left at zero", func(t *testing.T) {
+		info := &DeviceInfo{
+			AssetDevice: AssetDevice{ID: "dev-1"},
+			Realtime: map[string]interface{}{
+				"inputVolt1":     "NaN",
+				"outputVolt1":    math.NaN(),
+				"outputCurrent1": "-Inf",
+				"loadPercent":    "42.5",
+				"mode":           "NaN", // Not a measurement
+			},
+		}
+
+		parsed, err := NewDataParser(zap.NewNop()).parseDeviceInfo(info)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"input_volt_1", "output_current_1", "output_volt_1"}, parsed.InvalidFields)
+		assert.Equal(t, 0.0, parsed.Realtime.InputVolt1)
+		assert.Equal(t, 0.0, parsed.Realtime.OutputVolt1)
+		assert.Equal(t, 0.0, parsed.Realtime.OutputCurrent1)
+		assert.Equal(t, 42.5, parsed.Realtime.LoadPercent)
+	})
+
+	t.Run("optional energy field is only read when mapped", func(t *testing.T) {
+		info := &DeviceInfo{
+			AssetDevice: AssetDevice{ID: "dev-1"},
+			Realtime: map[string]interface{}{
+				"totalEnergy": "12345.6",
+			},
+		}
+
+		parsed, err := NewDataParser(zap.NewNop()).parseDeviceInfo(info)
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, parsed.Realtime.EnergyTotalWh)
+		assert.False(t, parsed.Realtime.EnergyTotalReported)
+		assert.NotContains(t, parsed.MissingFields, "energy_total_wh")
+
+		parser := NewDataParserWithFieldMap(zap.NewNop(), map[string]string{
+			"energy_total_wh": "totalEnergy",
+		})
+		parsed, err = parser.parseDeviceInfo(info)
+		require.NoError(t, err)
+		assert.Equal(t, 12345.6, parsed.Realtime.EnergyTotalWh)
+		assert.True(t, parsed.Realtime.EnergyTotalReported)
+
+		// An empty, invalid or non-finite value is not reported energy
+		for _, value := range []interface{}{"", "n/a", nil, "NaN", "+Inf"} {
+			info.Realtime["totalEnergy"] = value
+			parsed, err = parser.parseDeviceInfo(info)
+			require.NoError(t, err)
+			assert.False(t, parsed.Realtime.EnergyTotalReported, "value %v", value)
+		}
+	})
+
+	t.Run("optional average power field is only read when mapped", func(t *testing.T) {
+		info := &DeviceInfo{
+			AssetDevice: AssetDevice{ID: "dev-1"},
+			Realtime: map[string]interface{}{
+				"loadTotalWatt": "800",
+				"avgPower":      "1250.5",
+			},
+		}
+
+		parsed, err := NewDataParser(zap.NewNop()).parseDeviceInfo(info)
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, parsed.Realtime.LoadAvgWatt)
+		assert.False(t, parsed.Realtime.LoadAvgReported)
+
+		parser := NewDataParserWithFieldMap(zap.NewNop(), map[string]string{
+			"load_avg_watt": "avgPower",
+		})
+		parsed, err = parser.parseDeviceInfo(info)
+		require.NoError(t, err)
+		assert.Equal(t, 1250.5, parsed.Realtime.LoadAvgWatt)
+		assert.True(t, parsed.Realtime.LoadAvgReported)
+		assert.Equal(t, 800.0, parsed.Realtime.LoadTotalWatt)
+
+		info.Realtime["avgPower"] = "NaN"
+		parsed, err = parser.parseDeviceInfo(info)
+		require.NoError(t, err)
+		assert.False(t, parsed.Realtime.LoadAvgReported)
+		assert.Contains(t, parsed.InvalidFields, "load_avg_watt")
+	})
+
+	t.Run("optional data time field is only read when mapped", func(t *testing.T) {
+		info := &DeviceInfo{
+			AssetDevice: AssetDevice{ID: "dev-1"},
+			Realtime: map[string]interface{}{
+				"updateTime": "2025-10-13T08:37:57",
+			},
+		}
+
+		parsed, err := NewDataParser(zap.NewNop()).parseDeviceInfo(info)
+		require.NoError(t, err)
+		assert.True(t, parsed.Realtime.DataTime.IsZero())
+
+		parser := NewDataParserWithFieldMap(zap.NewNop(), map[string]string{
+			"data_time": "updateTime",
+		})
+		parsed, err = parser.parseDeviceInfo(info)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 10, 13, 8, 37, 57, 0, time.UTC), parsed.Realtime.DataTime)
+	})
+
+	t.Run("defaults match built-in keys", func(t *testing.T) {
+		parser := NewDataParser(zap.NewNop())
+		assert.Equal(t, DefaultFieldMap(), parser.fieldMap)
+	})
+}
+
+func TestDataParser_parseStableID(t *testing.T) {
+	parser := NewDataParser(zap.NewNop())
+	info := createValidDeviceInfo()
+	info.Config = map[string]interface{}{"serialNumber": " SN-001 "}
+	info.Setting = map[string]interface{}{"assetNo": float64(12345)}
+
+	assert.Empty(t, parser.parseStableID(&info), "disabled without identity field")
+
+	parser.identityField = "serialNumber"
+	assert.Equal(t, "SN-001", parser.parseStableID(&info))
+
+	parser.identityField = "assetNo"
+	assert.Equal(t, "12345", parser.parseStableID(&info))
+
+	info.Realtime["assetNo"] = true
+	assert.Empty(t, parser.parseStableID(&info), "unsupported type")
+
+	parser.identityField = "missing"
+	parsed, err := parser.parseDeviceInfo(&info)
+	require.NoError(t, err)
+	assert.Empty(t, parsed.StableID)
+}
+
 func TestDataParser_parseFloat(t *testing.T) {
 	parser := NewDataParser(zap.NewNop())
 
@@ -273,6 +507,137 @@ func TestDataParser_parseFloat(t *testing.T) {
 	}
 }
 
+func TestDataParser_parseTime(t *testing.T) {
+	parser := NewDataParser(zap.NewNop())
+	expected := time.Date(2025, 10, 13, 8, 37, 57, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		raw      map[string]interface{}
+		expected time.Time
+	}{
+		{
+			name:     "WinPower format",
+			raw:      map[string]interface{}{"value": "2025-10-13T08:37:57"},
+			expected: expected,
+		},
+		{
+			name:     "RFC3339 value",
+			raw:      map[string]interface{}{"value": "2025-10-13T08:37:57Z"},
+			expected: expected,
+		},
+		{
+			name:     "RFC3339 offset",
+			raw:      map[string]interface{}{"value": "2025-10-13T16:37:57+08:00"},
+			expected: expected,
+		},
+		{
+			name:     "numeric offset without colon",
+			raw:      map[string]interface{}{"value": "2025-10-13T03:37:57.000-0500"},
+			expected: expected,
+		},
+		{
+			name:     "space separated with offset",
+			raw:      map[string]interface{}{"value": "2025-10-13 16:37:57+0800"},
+			expected: expected,
+		},
+		{
+			name:     "unix seconds string",
+			raw:      map[string]interface{}{"value": strconv.FormatInt(expected.Unix(), 10)},
+			expected: expected,
+		},
+		{
+			name:     "unix milliseconds float64",
+			raw:      map[string]interface{}{"value": float64(expected.UnixMilli())},
+			expected: expected,
+		},
+		{
+			name:     "int value",
+			raw:      map[string]interface{}{"value": int(expected.Unix())},
+			expected: expected,
+		},
+		{
+			name:     "empty string",
+			raw:      map[string]interface{}{"value": ""},
+			expected: time.Time{},
+		},
+		{
+			name:     "missing key",
+			raw:      map[string]interface{}{},
+			expected: time.Time{},
+		},
+		{
+			name:     "invalid string",
+			raw:      map[string]interface{}{"value": "yesterday"},
+			expected: time.Time{},
+		},
+		{
+			name:     "unexpected type",
+			raw:      map[string]interface{}{"value": true},
+			expected: time.Time{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parser.parseTime(tt.raw, "value", "test field")
+			assert.True(t, tt.expected.Equal(result), "expected %v, got %v", tt.expected, result)
+		})
+	}
+}
+
+func TestDataParser_parseTime_Format(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	expected := time.Date(2025, 10, 13, 8, 37, 57, 0, time.UTC)
+
+	t.Run("zoneless timestamp in configured zone", func(t *testing.T) {
+		parser := NewDataParser(zap.NewNop())
+		parser.timeLocation = shanghai
+
+		result := parser.parseTime(map[string]interface{}{"value": "2025-10-13T16:37:57"}, "value", "test field")
+		assert.True(t, expected.Equal(result), "expected %v, got %v", expected, result)
+		assert.Equal(t, time.UTC, result.Location())
+	})
+
+	t.Run("offset overrides configured zone", func(t *testing.T) {
+		parser := NewDataParser(zap.NewNop())
+		parser.timeLocation = shanghai
+
+		result := parser.parseTime(map[string]interface{}{"value": "2025-10-13T08:37:57Z"}, "value", "test field")
+		assert.True(t, expected.Equal(result), "expected %v, got %v", expected, result)
+	})
+
+	t.Run("custom layout", func(t *testing.T) {
+		parser := NewDataParser(zap.NewNop())
+		parser.timeLayouts = timeLayouts("02/01/2006 15:04:05 -07:00")
+
+		result := parser.parseTime(map[string]interface{}{"value": "13/10/2025 16:37:57 +08:00"}, "value", "test field")
+		assert.True(t, expected.Equal(result), "expected %v, got %v", expected, result)
+
+		// The built-in formats are not tried once a layout is configured
+		result = parser.parseTime(map[string]interface{}{"value": "2025-10-13T08:37:57Z"}, "value", "test field")
+		assert.True(t, result.IsZero())
+	})
+
+	t.Run("malformed timestamps are counted and left unset", func(t *testing.T) {
+		observer := &recordingObserver{}
+		parser := NewDataParser(zap.NewNop())
+		parser.observer = observer
+
+		for _, value := range []interface{}{"2025-13-45T99:00:00", "2025-10-13T08:37:57+25:00", "yesterday", true} {
+			result := parser.parseTime(map[string]interface{}{"value": value}, "value", "test field")
+			assert.True(t, result.IsZero(), "value %v parsed as %v", value, result)
+		}
+		assert.Equal(t, 4, observer.badTimestamps)
+
+		// Missing and empty timestamps are not malformed
+		parser.parseTime(map[string]interface{}{}, "value", "test field")
+		parser.parseTime(map[string]interface{}{"value": ""}, "value", "test field")
+		assert.Equal(t, 4, observer.badTimestamps)
+	})
+}
+
 func TestDataParser_parseInt(t *testing.T) {
 	parser := NewDataParser(zap.NewNop())
 
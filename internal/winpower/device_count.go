@@ -0,0 +1,45 @@
+package winpower
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// checkDeviceCount compares the device total reported by WinPower with the
+// number of devices in the response body, which can disagree when a
+// pagination bug truncates the response. A mismatch is logged and counted.
+// When DeviceShortfallLimit is set, a response missing at least that
+// many of its reported devices fails with ErrDeviceCountMismatch instead of
+// silently under-reporting devices. A reported total of zero is treated as
+// not reported and never checked.
+func (c *Client) checkDeviceCount(response *DeviceDataResponse, parsed int) error {
+	reported, received := response.Total, len(response.Data)
+	if reported <= 0 || reported == received {
+		return nil
+	}
+
+	if c.httpClient.observer != nil {
+		c.httpClient.observer.ObserveDeviceCountMismatch()
+	}
+
+	shortfall := reported - received
+	limit := c.config.DeviceShortfallLimit
+	if limit > 0 && shortfall >= limit {
+		c.logger.Error("device data response is missing reported devices, failing the collection",
+			zap.Int("reported", reported),
+			zap.Int("received", received),
+			zap.Int("parsed", parsed),
+			zap.Int("shortfall_limit", limit),
+		)
+		return fmt.Errorf("%w: WinPower reported %d devices but the response contains %d",
+			ErrDeviceCountMismatch, reported, received)
+	}
+
+	c.logger.Warn("device count reported by WinPower does not match the response",
+		zap.Int("reported", reported),
+		zap.Int("received", received),
+		zap.Int("parsed", parsed),
+	)
+	return nil
+}
@@ -1,8 +1,13 @@
 package winpower
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"net"
+	"strings"
 )
 
 // Sentinel errors for common error conditions.
@@ -33,6 +38,39 @@ var (
 
 	// ErrTimeout indicates the request timed out.
 	ErrTimeout = errors.New("winpower: request timeout")
+
+	// ErrCrossHostRedirect indicates a redirect to another host was blocked.
+	ErrCrossHostRedirect = errors.New("winpower: cross-host redirect blocked")
+
+	// ErrTooManyRedirects indicates the redirect limit was exceeded.
+	ErrTooManyRedirects = errors.New("winpower: too many redirects")
+
+	// ErrStartupConnect indicates WinPower was not reachable within the startup connect timeout.
+	ErrStartupConnect = errors.New("winpower: not reachable at startup")
+
+	// ErrRawResponseUnavailable indicates no device data response is retained
+	// for debugging, because retention is disabled, nothing was collected
+	// yet or the last response exceeded the size limit.
+	ErrRawResponseUnavailable = errors.New("winpower: raw response unavailable")
+
+	// ErrMaintenance indicates WinPower reported maintenance mode, e.g.
+	// during a firmware update, and its device data was discarded.
+	ErrMaintenance = errors.New("winpower: maintenance mode")
+
+	// ErrDeviceCountMismatch indicates the device data response contains
+	// fewer devices than WinPower reported, by at least the configured
+	// shortfall threshold.
+	ErrDeviceCountMismatch = errors.New("winpower: device count mismatch")
+
+	// ErrDeviceNotInResponse indicates the retained device data response
+	// does not contain the requested device.
+	ErrDeviceNotInResponse = errors.New("winpower: device not in response")
+
+	// errReadBody indicates the response body could not be read.
+	errReadBody = errors.New("failed to read response body")
+
+	// errNotModified signals a 304 Not Modified response to a conditional request.
+	errNotModified = errors.New("winpower: not modified")
 )
 
 // AuthenticationError represents an authentication-related error.
@@ -69,6 +107,44 @@ func (e *NetworkError) Unwrap() error {
 	return e.Err
 }
 
+// HTTPStatusError represents a non-2xx HTTP response. Err is set when the
+// status maps to a more specific error, e.g. ErrAuthenticationFailed for 401.
+type HTTPStatusError struct {
+	StatusCode int
+	Body       string
+	Err        error
+}
+
+func (e *HTTPStatusError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("HTTP request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+func (e *HTTPStatusError) Unwrap() error {
+	return e.Err
+}
+
+// ResponseError represents an error object WinPower returned in the body of a
+// 200 OK response instead of a proper status code. Errors about an expired
+// or invalid session unwrap to ErrTokenExpired so the token is refreshed.
+type ResponseError struct {
+	Message string
+	Session bool
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("WinPower returned an error response: %s", e.Message)
+}
+
+func (e *ResponseError) Unwrap() error {
+	if e.Session {
+		return ErrTokenExpired
+	}
+	return nil
+}
+
 // ParseError represents a data parsing error.
 type ParseError struct {
 	Field   string
@@ -128,3 +204,116 @@ func IsConfigError(err error) bool {
 	var cfgErr *ConfigError
 	return errors.As(err, &cfgErr) || errors.Is(err, ErrInvalidConfig)
 }
+
+// Error types returned by ClassifyError, used as the error_type label of
+// the scrape error metrics.
+const (
+	ErrorTypeDNS        = "dns"
+	ErrorTypeConnect    = "connect"
+	ErrorTypeTLS        = "tls"
+	ErrorTypeTimeout    = "timeout"
+	ErrorTypeRead       = "read"
+	ErrorTypeHTTPStatus = "http_status"
+
+	// ErrorTypeErrorResponse is not a network failure: WinPower answered
+	// 200 OK with an error object in the body
+	ErrorTypeErrorResponse = "error_response"
+
+	// ErrorTypeMaintenance is not a failure of WinPower: it reported
+	// maintenance mode and its device data was discarded
+	ErrorTypeMaintenance = "maintenance"
+
+	// ErrorTypeDeviceCount is a response missing too many of the devices
+	// WinPower reported, e.g. a page truncated by a pagination bug
+	ErrorTypeDeviceCount = "device_count_mismatch"
+)
+
+// ClassifyError returns the kind of WinPower failure behind err, or an empty
+// string if err is not a recognized network or response error:
+//   - dns: the WinPower host name could not be resolved
+//   - connect: the TCP connection failed, e.g. connection refused
+//   - tls: the TLS handshake failed or timed out
+//   - timeout: the request timed out after connecting
+//   - read: the connection failed while reading the response
+//   - http_status: WinPower answered with a non-2xx status
+//   - error_response: WinPower answered 200 OK with an error object in the body
+//   - maintenance: WinPower reported maintenance mode
+//   - device_count_mismatch: the response is missing reported devices
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	if errors.Is(err, ErrMaintenance) {
+		return ErrorTypeMaintenance
+	}
+
+	if errors.Is(err, ErrDeviceCountMismatch) {
+		return ErrorTypeDeviceCount
+	}
+
+	var respErr *ResponseError
+	if errors.As(err, &respErr) {
+		return ErrorTypeErrorResponse
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ErrorTypeDNS
+	}
+
+	if isTLSError(err) {
+		return ErrorTypeTLS
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return ErrorTypeHTTPStatus
+	}
+
+	if errors.Is(err, errReadBody) {
+		return ErrorTypeRead
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return ErrorTypeConnect
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrTimeout) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) {
+		return ErrorTypeTimeout
+	}
+
+	if opErr != nil && opErr.Op == "read" {
+		return ErrorTypeRead
+	}
+
+	return ""
+}
+
+// isTLSError reports whether err comes from the TLS handshake or
+// certificate verification
+func isTLSError(err error) bool {
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var verifyErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	if errors.As(err, &recordErr) || errors.As(err, &alertErr) || errors.As(err, &verifyErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return true
+	}
+
+	// crypto/tls reports alerts sent by the peer, e.g. when no TLS version
+	// or cipher suite is shared, as a "remote error" with an unexported type
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "remote error" {
+		return true
+	}
+
+	// net/http reports handshake timeouts with an unexported error type
+	return strings.Contains(err.Error(), "TLS handshake")
+}
@@ -1,7 +1,13 @@
 package winpower
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"fmt"
+	"net"
+	"syscall"
 	"testing"
 )
 
@@ -401,3 +407,105 @@ func TestSentinelErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "nil",
+			err:  nil,
+			want: "",
+		},
+		{
+			name: "DNS failure",
+			err:  fmt.Errorf("HTTP request failed: %w", &net.DNSError{Err: "no such host", Name: "winpower.invalid", IsNotFound: true}),
+			want: ErrorTypeDNS,
+		},
+		{
+			name: "connection refused",
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED},
+			want: ErrorTypeConnect,
+		},
+		{
+			name: "untrusted certificate",
+			err:  fmt.Errorf("HTTP request failed: %w", &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}),
+			want: ErrorTypeTLS,
+		},
+		{
+			name: "TLS handshake timeout",
+			err:  errors.New("net/http: TLS handshake timeout"),
+			want: ErrorTypeTLS,
+		},
+		{
+			name: "deadline exceeded",
+			err:  fmt.Errorf("HTTP request failed: %w", context.DeadlineExceeded),
+			want: ErrorTypeTimeout,
+		},
+		{
+			name: "ErrTimeout",
+			err:  ErrTimeout,
+			want: ErrorTypeTimeout,
+		},
+		{
+			name: "connection reset while reading",
+			err:  &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET},
+			want: ErrorTypeRead,
+		},
+		{
+			name: "body read failure",
+			err:  fmt.Errorf("%w: %w", errReadBody, errors.New("unexpected EOF")),
+			want: ErrorTypeRead,
+		},
+		{
+			name: "HTTP status",
+			err:  &HTTPStatusError{StatusCode: 502, Body: "bad gateway"},
+			want: ErrorTypeHTTPStatus,
+		},
+		{
+			name: "unauthorized",
+			err:  &HTTPStatusError{StatusCode: 401, Err: ErrAuthenticationFailed},
+			want: ErrorTypeHTTPStatus,
+		},
+		{
+			name: "error response",
+			err:  fmt.Errorf("data parsing failed: %w", &ResponseError{Message: "session expired", Session: true}),
+			want: ErrorTypeErrorResponse,
+		},
+		{
+			name: "device count mismatch",
+			err:  fmt.Errorf("%w: WinPower reported 3 devices but the response contains 1", ErrDeviceCountMismatch),
+			want: ErrorTypeDeviceCount,
+		},
+		{
+			name: "generic error",
+			err:  errors.New("generic"),
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPStatusError(t *testing.T) {
+	err := &HTTPStatusError{StatusCode: 500, Body: "oops"}
+	if got, want := err.Error(), "HTTP request failed with status 500: oops"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	authErr := &HTTPStatusError{StatusCode: 401, Err: ErrAuthenticationFailed}
+	if !errors.Is(authErr, ErrAuthenticationFailed) {
+		t.Error("expected 401 status error to wrap ErrAuthenticationFailed")
+	}
+	if authErr.Error() != ErrAuthenticationFailed.Error() {
+		t.Errorf("Error() = %q, want %q", authErr.Error(), ErrAuthenticationFailed.Error())
+	}
+}
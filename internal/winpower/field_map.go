@@ -0,0 +1,99 @@
+package winpower
+
+import "fmt"
+
+// defaultFieldMap maps canonical realtime field names (the JSON tags of
+// RealtimeData) to the keys used by the WinPower API in the realtime object.
+var defaultFieldMap = map[string]string{
+	"load_total_watt":  "loadTotalWatt",
+	"input_volt_1":     "inputVolt1",
+	"output_volt_1":    "outputVolt1",
+	"bat_volt_p":       "batVoltP",
+	"output_current_1": "outputCurrent1",
+	"input_freq":       "inputFreq",
+	"output_freq":      "outputFreq",
+	"load_percent":     "loadPercent",
+	"load_total_va":    "loadTotalVa",
+	"load_watt_1":      "loadWatt1",
+	"load_va_1":        "loadVa1",
+	"bat_capacity":     "batCapacity",
+	"bat_remain_time":  "batRemainTime",
+	"is_charging":      "isCharging",
+	"ups_temperature":  "upsTemperature",
+	"mode":             "mode",
+	"status":           "status",
+	"battery_status":   "batteryStatus",
+	"test_status":      "testStatus",
+	"fault_code":       "faultCode",
+}
+
+// optionalFields are canonical realtime fields without a built-in JSON key.
+// They are only read when mapped through the field map.
+var optionalFields = map[string]bool{
+	// Average active power over WinPower's sampling interval, preferred for
+	// energy integration with energy power_reading "average"
+	"load_avg_watt": true,
+	// Device-reported cumulative energy in Wh, used by energy source "device"
+	"energy_total_wh": true,
+	// Time WinPower last refreshed the realtime data, used by the collector
+	// to detect duplicate samples
+	"data_time": true,
+}
+
+// floatFields are the canonical realtime fields holding measurements. Their
+// NaN and infinite values, e.g. after a sensor fault, are reported as invalid.
+var floatFields = []string{
+	"load_total_watt",
+	"load_avg_watt",
+	"energy_total_wh",
+	"input_volt_1",
+	"output_volt_1",
+	"bat_volt_p",
+	"output_current_1",
+	"input_freq",
+	"output_freq",
+	"load_percent",
+	"load_total_va",
+	"load_watt_1",
+	"load_va_1",
+	"bat_capacity",
+	"ups_temperature",
+}
+
+// DefaultFieldMap returns a copy of the built-in canonical-to-JSON field mapping.
+func DefaultFieldMap() map[string]string {
+	fieldMap := make(map[string]string, len(defaultFieldMap))
+	for canonical, key := range defaultFieldMap {
+		fieldMap[canonical] = key
+	}
+	return fieldMap
+}
+
+// resolveFieldMap merges the given overrides on top of the default mapping.
+func resolveFieldMap(overrides map[string]string) map[string]string {
+	fieldMap := DefaultFieldMap()
+	for canonical, key := range overrides {
+		fieldMap[canonical] = key
+	}
+	return fieldMap
+}
+
+// validateFieldMap checks that every override refers to a known canonical
+// field and maps it to a non-empty JSON key.
+func validateFieldMap(fieldMap map[string]string) error {
+	for canonical, key := range fieldMap {
+		if _, ok := defaultFieldMap[canonical]; !ok && !optionalFields[canonical] {
+			return &ConfigError{
+				Field:   "field_map",
+				Message: fmt.Sprintf("unknown canonical field %q", canonical),
+			}
+		}
+		if key == "" {
+			return &ConfigError{
+				Field:   "field_map",
+				Message: fmt.Sprintf("JSON key for field %q cannot be empty", canonical),
+			}
+		}
+	}
+	return nil
+}
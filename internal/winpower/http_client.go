@@ -3,14 +3,17 @@ package winpower
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/tlsconfig"
 	"go.uber.org/zap"
 )
 
@@ -20,14 +23,72 @@ type HTTPClient struct {
 	baseURL   string
 	userAgent string
 	logger    log.Logger
+
+	// Full endpoint URLs
+	loginURL      string
+	deviceDataURL string
+
+	// Conditional request cache, keyed by endpoint
+	cacheMu sync.Mutex
+	cache   map[string]*cachedResponse
+
+	// Redirect policy
+	followRedirects bool
+	maxRedirects    int
+	allowCrossHost  bool
+
+	// Idle connection policy; idleTimer is nil until the first request
+	idleClose time.Duration
+	idleMu    sync.Mutex
+	idleTimer *time.Timer
+
+	// Request signing; signer is nil when signing is disabled and
+	// signerErr is set when the signing configuration could not be loaded
+	signer    *requestSigner
+	signerErr error
+
+	// observer receives response sizes and retry counts; nil disables recording
+	observer ResponseObserver
+
+	// redactor masks sensitive values in logged request details
+	redactor *redactor
+
+	// retryPolicy classifies failed requests as retryable or permanent
+	retryPolicy retryPolicy
+
+	// Device data request retries; maxRetries of zero disables retries
+	maxRetries    int
+	retryInterval time.Duration
+
+	// maintenanceField is the path of the maintenance indicator in the
+	// device data response; empty disables detection
+	maintenanceField string
+
+	// Last device data response body kept for debugging; rawMaxBytes of
+	// zero disables retention
+	rawMaxBytes int
+	rawMu       sync.Mutex
+	raw         *rawResponse
+}
+
+// cachedResponse holds the validators and decoded body of the last 200
+// response for an endpoint, so a 304 Not Modified can reuse the data.
+type cachedResponse struct {
+	etag         string
+	lastModified string
+	deviceData   *DeviceDataResponse
 }
 
 // NewHTTPClient creates a new HTTP client with the given configuration.
 func NewHTTPClient(cfg *Config, logger log.Logger) *HTTPClient {
-	// Configure TLS
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: cfg.SkipSSLVerify, //nolint:gosec // User-configurable for self-signed certs
+	// Configure TLS; the version and cipher suites were checked by
+	// Config.Validate, so an invalid setting falls back to the defaults
+	tlsConfig, err := tlsconfig.New(cfg.TLSMinVersion, cfg.TLSCipherSuites)
+	if err != nil {
+		logger.Warn("invalid TLS settings, using defaults", log.Err(err))
+		tlsConfig, _ = tlsconfig.New("", nil)
 	}
+	tlsConfig.InsecureSkipVerify = cfg.SkipSSLVerify //nolint:gosec // User-configurable for self-signed certs
 
 	// Create HTTP client with connection pooling
 	client := &http.Client{
@@ -41,12 +102,106 @@ func NewHTTPClient(cfg *Config, logger log.Logger) *HTTPClient {
 		},
 	}
 
-	return &HTTPClient{
-		client:    client,
-		baseURL:   cfg.BaseURL,
-		userAgent: cfg.UserAgent,
-		logger:    logger,
+	redactFields := cfg.RedactFields
+	if redactFields == nil {
+		redactFields = DefaultRedactFields
+	}
+
+	c := &HTTPClient{
+		client:          client,
+		baseURL:         cfg.BaseURL,
+		loginURL:        endpointURL(cfg.BaseURL, cfg.LoginPath, DefaultLoginPath),
+		deviceDataURL:   endpointURL(cfg.BaseURL, cfg.DeviceDataPath, DefaultDeviceDataPath),
+		userAgent:       cfg.UserAgent,
+		logger:          logger,
+		cache:           make(map[string]*cachedResponse),
+		followRedirects: cfg.FollowRedirects,
+		maxRedirects:    cfg.MaxRedirects,
+		allowCrossHost:  cfg.AllowCrossHostRedirects,
+		idleClose:       cfg.IdleCloseTimeout,
+		redactor:        newRedactor(redactFields),
+		rawMaxBytes:     cfg.RawResponseMaxBytes,
+
+		retryPolicy:      newRetryPolicy(cfg.RetryableStatusCodes, cfg.PermanentStatusCodes),
+		maxRetries:       cfg.MaxRetries,
+		retryInterval:    cfg.RetryInterval,
+		maintenanceField: cfg.MaintenanceField,
+	}
+	client.CheckRedirect = c.checkRedirect
+
+	// Signing was checked by Config.Validate; if the secret still cannot be
+	// loaded, fail every request rather than sending it unsigned
+	c.signer, c.signerErr = newRequestSigner(&cfg.Signing)
+	if c.signerErr != nil {
+		logger.Error("invalid request signing settings, requests will fail", log.Err(c.signerErr))
+	}
+
+	return c
+}
+
+// checkRedirect applies the configured redirect policy. Redirects to the
+// same host are followed with the original Authorization header re-attached;
+// redirects to another host are blocked unless explicitly allowed, and then
+// never carry the Authorization header.
+func (c *HTTPClient) checkRedirect(req *http.Request, via []*http.Request) error {
+	if !c.followRedirects {
+		return http.ErrUseLastResponse
+	}
+
+	if len(via) > c.maxRedirects {
+		return fmt.Errorf("%w: stopped after %d redirects", ErrTooManyRedirects, c.maxRedirects)
+	}
+
+	original := via[0]
+	if !strings.EqualFold(req.URL.Host, original.URL.Host) {
+		if !c.allowCrossHost {
+			return fmt.Errorf("%w: %s -> %s", ErrCrossHostRedirect, original.URL.Host, req.URL.Host)
+		}
+
+		req.Header.Del("Authorization")
+		c.logger.Debug("following cross-host redirect without Authorization header",
+			zap.String("from", original.URL.Host),
+			zap.String("to", req.URL.Host),
+		)
+		return nil
+	}
+
+	if auth := original.Header.Get("Authorization"); auth != "" {
+		req.Header.Set("Authorization", auth)
 	}
+
+	c.logger.Debug("following redirect",
+		zap.String("method", req.Method),
+		zap.String("url", c.redactor.redactURL(req.URL)),
+		zap.Int("redirects", len(via)),
+	)
+	return nil
+}
+
+// markActive records request activity and re-arms the idle close timer.
+// It is a no-op when the idle close policy is disabled.
+func (c *HTTPClient) markActive() {
+	if c.idleClose <= 0 {
+		return
+	}
+
+	c.idleMu.Lock()
+	defer c.idleMu.Unlock()
+
+	if c.idleTimer == nil {
+		c.idleTimer = time.AfterFunc(c.idleClose, c.closeIdleConnections)
+		return
+	}
+	c.idleTimer.Reset(c.idleClose)
+}
+
+// closeIdleConnections closes idle keep-alive connections after a period
+// without requests. The next request transparently opens a new connection.
+func (c *HTTPClient) closeIdleConnections() {
+	c.logger.Debug("closing idle WinPower connections",
+		zap.Duration("idle_close_timeout", c.idleClose),
+	)
+	c.client.CloseIdleConnections()
 }
 
 // Login authenticates with WinPower and returns the login response.
@@ -56,10 +211,10 @@ func (c *HTTPClient) Login(ctx context.Context, username, password string) (*Log
 		Password: password,
 	}
 
-	endpoint := fmt.Sprintf("%s/api/v1/auth/login", c.baseURL)
+	endpoint := c.loginURL
 
 	c.logger.Debug("attempting login",
-		zap.String("endpoint", endpoint),
+		zap.String("endpoint", c.redactor.redactRawURL(endpoint)),
 		zap.String("username", username),
 	)
 
@@ -90,9 +245,48 @@ func (c *HTTPClient) Login(ctx context.Context, username, password string) (*Log
 	return &loginResp, nil
 }
 
-// GetDeviceData retrieves device data from WinPower system.
+// GetDeviceData retrieves device data from WinPower system. A retryable
+// failure is retried up to maxRetries times, retryInterval apart; an
+// authentication failure is returned at once so the caller can log in again.
+// The number of retries made is reported to the observer.
 func (c *HTTPClient) GetDeviceData(ctx context.Context, token string) (*DeviceDataResponse, error) {
-	endpoint := fmt.Sprintf("%s/api/v1/deviceData/detail/list", c.baseURL)
+	retries := 0
+	for {
+		resp, err := c.getDeviceDataOnce(ctx, token)
+		if err == nil || retries >= c.maxRetries || IsAuthenticationError(err) ||
+			!c.retryPolicy.isRetryable(err) || !c.waitRetry(ctx, err, retries+1) {
+			if c.observer != nil {
+				c.observer.ObserveRequestRetries(retries)
+			}
+			return resp, err
+		}
+		retries++
+	}
+}
+
+// waitRetry logs the failed device data request and waits retryInterval
+// before the given retry. It returns false when ctx is done first.
+func (c *HTTPClient) waitRetry(ctx context.Context, err error, retry int) bool {
+	c.logger.Warn("device data request failed, retrying",
+		zap.Error(err),
+		zap.Int("retry", retry),
+		zap.Int("max_retries", c.maxRetries),
+		zap.Duration("retry_in", c.retryInterval),
+	)
+
+	timer := time.NewTimer(c.retryInterval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// getDeviceDataOnce makes a single device data request.
+func (c *HTTPClient) getDeviceDataOnce(ctx context.Context, token string) (*DeviceDataResponse, error) {
+	endpoint := c.deviceDataURL
 
 	// Build query parameters
 	params := map[string]string{
@@ -116,7 +310,7 @@ func (c *HTTPClient) GetDeviceData(ctx context.Context, token string) (*DeviceDa
 	}
 
 	c.logger.Debug("fetching device data",
-		zap.String("endpoint", fullURL),
+		zap.String("endpoint", c.redactor.redactRawURL(fullURL)),
 	)
 
 	// Create request
@@ -136,8 +330,30 @@ func (c *HTTPClient) GetDeviceData(ctx context.Context, token string) (*DeviceDa
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 	}
 
+	// Send validators from the last successful response, if any
+	cached := c.getCached(endpoint)
+	if cached != nil {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
 	var resp DeviceDataResponse
-	err = c.doRequest(req, &resp)
+	header, body, err := c.doRequestWithHeader(req, &resp)
+	if errors.Is(err, errNotModified) {
+		if cached == nil {
+			return nil, &NetworkError{
+				Message: "received 304 Not Modified without cached device data",
+			}
+		}
+		c.logger.Debug("device data not modified, reusing cached response",
+			zap.Int("total", cached.deviceData.Total),
+		)
+		return cached.deviceData, nil
+	}
 	if err != nil {
 		return nil, &NetworkError{
 			Message: "failed to fetch device data",
@@ -145,6 +361,23 @@ func (c *HTTPClient) GetDeviceData(ctx context.Context, token string) (*DeviceDa
 		}
 	}
 
+	// A response during maintenance is left to the parser to report; it is
+	// neither cached nor retained
+	if c.maintenanceField != "" && maintenanceActive(body, c.maintenanceField) {
+		c.logger.Debug("device data response reports maintenance mode",
+			zap.String("maintenance_field", c.maintenanceField),
+		)
+		resp.Maintenance = true
+		return &resp, nil
+	}
+
+	// An error object in a 200 OK response is left to the parser to report;
+	// it is not cached
+	if len(resp.Error) > 0 {
+		c.logger.Debug("device data response contains an error object")
+		return &resp, nil
+	}
+
 	// Check response code
 	if resp.Code != "000000" {
 		c.logger.Warn("device data request failed with error code",
@@ -161,9 +394,41 @@ func (c *HTTPClient) GetDeviceData(ctx context.Context, token string) (*DeviceDa
 		zap.Int("count", len(resp.Data)),
 	)
 
+	c.storeCached(endpoint, header, &resp)
+	c.retainRaw(body)
+
 	return &resp, nil
 }
 
+// getCached returns the cached response for the endpoint, or nil.
+func (c *HTTPClient) getCached(endpoint string) *cachedResponse {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	return c.cache[endpoint]
+}
+
+// storeCached records the response validators for the endpoint. When the
+// server sends neither ETag nor Last-Modified, any previous entry is dropped
+// so no conditional headers are sent and behavior stays unchanged.
+func (c *HTTPClient) storeCached(endpoint string, header http.Header, resp *DeviceDataResponse) {
+	etag := header.Get("ETag")
+	lastModified := header.Get("Last-Modified")
+
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+
+	if etag == "" && lastModified == "" {
+		delete(c.cache, endpoint)
+		return
+	}
+
+	c.cache[endpoint] = &cachedResponse{
+		etag:         etag,
+		lastModified: lastModified,
+		deviceData:   resp,
+	}
+}
+
 // postJSON sends a POST request with JSON body and decodes JSON response.
 func (c *HTTPClient) postJSON(ctx context.Context, url string, body interface{}, result interface{}) error {
 	jsonData, err := json.Marshal(body)
@@ -188,14 +453,34 @@ func (c *HTTPClient) postJSON(ctx context.Context, url string, body interface{},
 // It intelligently handles both successful responses and error responses where
 // the 'data' field might be a string instead of the expected type.
 func (c *HTTPClient) doRequest(req *http.Request, result interface{}) error {
+	_, _, err := c.doRequestWithHeader(req, result)
+	return err
+}
+
+// doRequestWithHeader is like doRequest but also returns the response headers
+// and body. A 304 Not Modified response yields errNotModified and leaves
+// result untouched.
+func (c *HTTPClient) doRequestWithHeader(req *http.Request, result interface{}) (http.Header, []byte, error) {
+	if c.signerErr != nil {
+		return nil, nil, fmt.Errorf("request signing unavailable: %w", c.signerErr)
+	}
+	if c.signer != nil {
+		if err := c.signer.signRequest(req); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	c.markActive()
+	defer c.markActive()
+
 	resp, err := c.client.Do(req)
 	if err != nil {
 		c.logger.Error("HTTP request failed",
 			zap.String("method", req.Method),
-			zap.String("url", req.URL.String()),
+			zap.String("url", c.redactor.redactURL(req.URL)),
 			zap.Error(err),
 		)
-		return fmt.Errorf("HTTP request failed: %w", err)
+		return nil, nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
@@ -210,16 +495,32 @@ func (c *HTTPClient) doRequest(req *http.Request, result interface{}) error {
 			zap.Int("status_code", resp.StatusCode),
 			zap.Error(err),
 		)
-		return fmt.Errorf("failed to read response body: %w", err)
+		return nil, nil, fmt.Errorf("%w: %w", errReadBody, err)
+	}
+
+	c.logger.Debug("received response",
+		zap.String("method", req.Method),
+		zap.String("url", c.redactor.redactURL(req.URL)),
+		zap.Any("request_headers", c.redactor.redactHeaders(req.Header)),
+		zap.Int("status_code", resp.StatusCode),
+		zap.Any("response_headers", c.redactor.redactHeaders(resp.Header)),
+		zap.Int("bytes", len(bodyBytes)),
+	)
+	if c.observer != nil {
+		c.observer.ObserveResponseBytes(len(bodyBytes))
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		return resp.Header, nil, errNotModified
 	}
 
 	// Check HTTP status code
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		c.logger.Warn("HTTP request returned non-2xx status",
 			zap.String("method", req.Method),
-			zap.String("url", req.URL.String()),
+			zap.String("url", c.redactor.redactURL(req.URL)),
 			zap.Int("status_code", resp.StatusCode),
-			zap.String("response_body", string(bodyBytes)),
+			zap.String("response_body", c.redactor.redactBody(bodyBytes)),
 		)
 
 		// Try to parse as error response for better error message
@@ -231,12 +532,12 @@ func (c *HTTPClient) doRequest(req *http.Request, result interface{}) error {
 					zap.String("message", errResp.Message),
 					zap.String("data", errResp.Data),
 				)
-				return ErrAuthenticationFailed
+				return nil, nil, &HTTPStatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes), Err: ErrAuthenticationFailed}
 			}
-			return ErrAuthenticationFailed
+			return nil, nil, &HTTPStatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes), Err: ErrAuthenticationFailed}
 		}
 
-		return fmt.Errorf("HTTP request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, nil, &HTTPStatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
 	}
 
 	// Try to parse as error response first to detect application-level errors
@@ -251,27 +552,33 @@ func (c *HTTPClient) doRequest(req *http.Request, result interface{}) error {
 
 		// Check if it's an authentication error (code 401)
 		if errResp.Code == "401" {
-			return ErrAuthenticationFailed
+			return nil, nil, ErrAuthenticationFailed
 		}
 
 		// Return generic error for other error codes
-		return fmt.Errorf("API error (code %s): %s", errResp.Code, errResp.Message)
+		return nil, nil, fmt.Errorf("API error (code %s): %s", errResp.Code, errResp.Message)
 	}
 
 	// Parse as successful response
 	if err := json.Unmarshal(bodyBytes, result); err != nil {
 		c.logger.Error("failed to decode JSON response",
-			zap.String("response_body", string(bodyBytes)),
+			zap.String("response_body", c.redactor.redactBody(bodyBytes)),
 			zap.Error(err),
 		)
-		return fmt.Errorf("failed to decode JSON response: %w", err)
+		return nil, nil, fmt.Errorf("failed to decode JSON response: %w", err)
 	}
 
-	return nil
+	return resp.Header, bodyBytes, nil
 }
 
 // Close closes the HTTP client and releases resources.
 func (c *HTTPClient) Close() error {
+	c.idleMu.Lock()
+	if c.idleTimer != nil {
+		c.idleTimer.Stop()
+	}
+	c.idleMu.Unlock()
+
 	if c.client != nil {
 		c.client.CloseIdleConnections()
 	}
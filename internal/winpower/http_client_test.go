@@ -2,7 +2,10 @@ package winpower
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -106,6 +109,47 @@ func TestHTTPClient_Login_Success(t *testing.T) {
 	}
 }
 
+func TestHTTPClient_CustomPaths(t *testing.T) {
+	logger := log.NewTestLogger()
+
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/winpower/api/v2/auth/login":
+			resp := LoginResponse{Code: "000000", Message: "OK"}
+			resp.Data.Token = "test-token"
+			json.NewEncoder(w).Encode(resp)
+		case "/winpower/api/v2/devices":
+			json.NewEncoder(w).Encode(DeviceDataResponse{Code: "000000", Msg: "OK"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.BaseURL = server.URL
+	cfg.LoginPath = "/winpower/api/v2/auth/login"
+	cfg.DeviceDataPath = "/winpower/api/v2/devices"
+
+	client := NewHTTPClient(cfg, logger)
+	defer client.Close()
+
+	if _, err := client.Login(context.Background(), "admin", "secret"); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	if _, err := client.GetDeviceData(context.Background(), "test-token"); err != nil {
+		t.Fatalf("GetDeviceData failed: %v", err)
+	}
+
+	want := []string{"/winpower/api/v2/auth/login", "/winpower/api/v2/devices"}
+	if len(paths) != len(want) || paths[0] != want[0] || paths[1] != want[1] {
+		t.Errorf("expected requests to %v, got %v", want, paths)
+	}
+}
+
 func TestHTTPClient_Login_Failure(t *testing.T) {
 	logger := log.NewTestLogger()
 
@@ -557,3 +601,312 @@ func TestHTTPClient_GetDeviceData_ErrorCode(t *testing.T) {
 		})
 	}
 }
+
+func TestHTTPClient_GetDeviceData_NotModified(t *testing.T) {
+	logger := log.NewTestLogger()
+
+	const etag = `"v1"`
+	const lastModified = "Mon, 02 Jan 2006 15:04:05 GMT"
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests > 1 {
+			if got := r.Header.Get("If-None-Match"); got != etag {
+				t.Errorf("expected If-None-Match %q, got %q", etag, got)
+			}
+			if got := r.Header.Get("If-Modified-Since"); got != lastModified {
+				t.Errorf("expected If-Modified-Since %q, got %q", lastModified, got)
+			}
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(DeviceDataResponse{
+			Total: 1,
+			Code:  "000000",
+			Data:  []DeviceInfo{{AssetDevice: AssetDevice{ID: "device-1"}}},
+		})
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.BaseURL = server.URL
+
+	client := NewHTTPClient(cfg, logger)
+	ctx := context.Background()
+
+	first, err := client.GetDeviceData(ctx, "test-token")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	second, err := client.GetDeviceData(ctx, "test-token")
+	if err != nil {
+		t.Fatalf("unexpected error on 304: %v", err)
+	}
+
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+	if second != first {
+		t.Error("expected cached response to be reused on 304")
+	}
+	if len(second.Data) != 1 || second.Data[0].AssetDevice.ID != "device-1" {
+		t.Errorf("unexpected cached data: %+v", second.Data)
+	}
+}
+
+func TestHTTPClient_GetDeviceData_NoValidators(t *testing.T) {
+	logger := log.NewTestLogger()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" {
+			t.Error("expected no conditional headers when server sends no validators")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(DeviceDataResponse{Code: "000000"})
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.BaseURL = server.URL
+
+	client := NewHTTPClient(cfg, logger)
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetDeviceData(context.Background(), "test-token"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestHTTPClient_IdleCloseTimeout(t *testing.T) {
+	logger := log.NewTestLogger()
+
+	closed := make(chan struct{}, 1)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(DeviceDataResponse{Code: "000000"})
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			select {
+			case closed <- struct{}{}:
+			default:
+			}
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.BaseURL = server.URL
+	cfg.IdleCloseTimeout = 50 * time.Millisecond
+
+	client := NewHTTPClient(cfg, logger)
+	defer client.Close()
+
+	if _, err := client.GetDeviceData(context.Background(), "test-token"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected idle connection to be closed")
+	}
+
+	// The next request transparently reconnects
+	if _, err := client.GetDeviceData(context.Background(), "test-token"); err != nil {
+		t.Fatalf("unexpected error after idle close: %v", err)
+	}
+}
+
+func TestHTTPClient_Redirects(t *testing.T) {
+	logger := log.NewTestLogger()
+	okBody := `{"total":0,"pageSize":100,"currentPage":1,"data":[],"code":"000000","msg":"OK"}`
+
+	// target serves device data and records the Authorization header it saw
+	newTarget := func(t *testing.T, gotAuth *string) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/node" {
+				*gotAuth = r.Header.Get("Authorization")
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(okBody))
+				return
+			}
+			http.Redirect(w, r, "/node", http.StatusFound)
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	newClient := func(baseURL string, modify func(*Config)) *HTTPClient {
+		cfg := DefaultConfig()
+		cfg.BaseURL = baseURL
+		if modify != nil {
+			modify(cfg)
+		}
+		return NewHTTPClient(cfg, logger)
+	}
+
+	t.Run("same host redirect keeps Authorization", func(t *testing.T) {
+		var gotAuth string
+		server := newTarget(t, &gotAuth)
+
+		if _, err := newClient(server.URL, nil).GetDeviceData(context.Background(), "test-token"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if gotAuth != "Bearer test-token" {
+			t.Errorf("expected Authorization to be re-attached, got %q", gotAuth)
+		}
+	})
+
+	t.Run("redirects disabled", func(t *testing.T) {
+		var gotAuth string
+		server := newTarget(t, &gotAuth)
+
+		client := newClient(server.URL, func(cfg *Config) { cfg.FollowRedirects = false })
+		if _, err := client.GetDeviceData(context.Background(), "test-token"); err == nil {
+			t.Fatal("expected error for unfollowed redirect")
+		}
+	})
+
+	t.Run("redirect limit", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, r.URL.Path+"x", http.StatusFound)
+		}))
+		defer server.Close()
+
+		client := newClient(server.URL, func(cfg *Config) { cfg.MaxRedirects = 2 })
+		_, err := client.GetDeviceData(context.Background(), "test-token")
+		if !errors.Is(err, ErrTooManyRedirects) {
+			t.Errorf("expected ErrTooManyRedirects, got %v", err)
+		}
+	})
+
+	t.Run("cross host redirect", func(t *testing.T) {
+		var gotAuth string
+		other := newTarget(t, &gotAuth)
+		lb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, other.URL+"/node", http.StatusFound)
+		}))
+		defer lb.Close()
+
+		_, err := newClient(lb.URL, nil).GetDeviceData(context.Background(), "test-token")
+		if !errors.Is(err, ErrCrossHostRedirect) {
+			t.Fatalf("expected ErrCrossHostRedirect, got %v", err)
+		}
+
+		client := newClient(lb.URL, func(cfg *Config) { cfg.AllowCrossHostRedirects = true })
+		if _, err := client.GetDeviceData(context.Background(), "test-token"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if gotAuth != "" {
+			t.Errorf("expected no Authorization on cross-host redirect, got %q", gotAuth)
+		}
+	})
+}
+
+func TestHTTPClient_TLSSettings(t *testing.T) {
+	logger := log.NewTestLogger()
+
+	// The server only speaks TLS 1.2
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	newClient := func(minVersion string) *HTTPClient {
+		cfg := DefaultConfig()
+		cfg.BaseURL = server.URL
+		cfg.Username = "admin"
+		cfg.Password = "secret"
+		cfg.SkipSSLVerify = true
+		cfg.TLSMinVersion = minVersion
+		cfg.TLSCipherSuites = []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}
+		return NewHTTPClient(cfg, logger)
+	}
+
+	transport := newClient("1.2").client.Transport.(*http.Transport)
+	if got := transport.TLSClientConfig; got.MinVersion != tls.VersionTLS12 ||
+		len(got.CipherSuites) != 1 || got.CipherSuites[0] != tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 {
+		t.Errorf("unexpected TLS client config: min version %x, cipher suites %v", got.MinVersion, got.CipherSuites)
+	}
+
+	_, err := newClient("1.2").Login(context.Background(), "admin", "secret")
+	if got := ClassifyError(err); got != ErrorTypeHTTPStatus {
+		t.Errorf("expected handshake to succeed with TLS 1.2 minimum, got %v", err)
+	}
+
+	_, err = newClient("1.3").Login(context.Background(), "admin", "secret")
+	if got := ClassifyError(err); got != ErrorTypeTLS {
+		t.Errorf("ClassifyError(%v) = %q, want %q", err, got, ErrorTypeTLS)
+	}
+}
+
+func TestHTTPClient_ErrorClassification(t *testing.T) {
+	logger := log.NewTestLogger()
+
+	newClient := func(baseURL string) *HTTPClient {
+		cfg := DefaultConfig()
+		cfg.BaseURL = baseURL
+		cfg.Username = "admin"
+		cfg.Password = "secret"
+		cfg.Timeout = 200 * time.Millisecond
+		return NewHTTPClient(cfg, logger)
+	}
+
+	t.Run("http status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		_, err := newClient(server.URL).Login(context.Background(), "admin", "secret")
+		if got := ClassifyError(err); got != ErrorTypeHTTPStatus {
+			t.Errorf("ClassifyError(%v) = %q, want %q", err, got, ErrorTypeHTTPStatus)
+		}
+	})
+
+	t.Run("connection refused", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		addr := listener.Addr().String()
+		listener.Close()
+
+		_, err = newClient("http://"+addr).Login(context.Background(), "admin", "secret")
+		if got := ClassifyError(err); got != ErrorTypeConnect {
+			t.Errorf("ClassifyError(%v) = %q, want %q", err, got, ErrorTypeConnect)
+		}
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+
+		_, err := newClient(server.URL).Login(context.Background(), "admin", "secret")
+		if got := ClassifyError(err); got != ErrorTypeTLS {
+			t.Errorf("ClassifyError(%v) = %q, want %q", err, got, ErrorTypeTLS)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(time.Second)
+		}))
+		defer server.Close()
+
+		_, err := newClient(server.URL).Login(context.Background(), "admin", "secret")
+		if got := ClassifyError(err); got != ErrorTypeTimeout {
+			t.Errorf("ClassifyError(%v) = %q, want %q", err, got, ErrorTypeTimeout)
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package winpower
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maintenanceActive reports whether the maintenance indicator at path is set
+// in the device data response body. path is a dot-separated sequence of JSON
+// object keys, e.g. "maintenance" or "system.maintenanceMode". An indicator
+// is set when it is true, a non-zero number or one of the strings "true",
+// "1", "yes" or "on" (case-insensitive); a missing indicator is not set.
+func maintenanceActive(body []byte, path string) bool {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return false
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if value, ok = object[key]; !ok {
+			return false
+		}
+	}
+
+	switch v := value.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "1", "yes", "on":
+			return true
+		}
+	}
+	return false
+}
+
+// validateMaintenanceField checks that every key of the maintenance field
+// path is non-empty. An empty path disables detection and is always valid.
+func validateMaintenanceField(path string) error {
+	if path == "" {
+		return nil
+	}
+	for _, key := range strings.Split(path, ".") {
+		if key == "" {
+			return &ConfigError{
+				Field:   "maintenance_field",
+				Message: fmt.Sprintf("invalid path %q: empty key", path),
+			}
+		}
+	}
+	return nil
+}
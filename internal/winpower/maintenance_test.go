@@ -0,0 +1,112 @@
+package winpower
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+func TestMaintenanceActive(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		path string
+		want bool
+	}{
+		{"true", `{"maintenance":true}`, "maintenance", true},
+		{"false", `{"maintenance":false}`, "maintenance", false},
+		{"non-zero number", `{"maintenance":1}`, "maintenance", true},
+		{"zero", `{"maintenance":0}`, "maintenance", false},
+		{"string yes", `{"maintenance":"YES"}`, "maintenance", true},
+		{"string no", `{"maintenance":"no"}`, "maintenance", false},
+		{"nested", `{"system":{"maintenanceMode":"on"}}`, "system.maintenanceMode", true},
+		{"missing", `{"code":"000000"}`, "maintenance", false},
+		{"parent not an object", `{"system":"up"}`, "system.maintenanceMode", false},
+		{"invalid JSON", `{`, "maintenance", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maintenanceActive([]byte(tt.body), tt.path); got != tt.want {
+				t.Errorf("maintenanceActive(%s, %q) = %v, want %v", tt.body, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_MaintenanceField(t *testing.T) {
+	validTestConfig := func() *Config {
+		cfg := DefaultConfig()
+		cfg.BaseURL = "https://winpower.example.com"
+		cfg.Username = "admin"
+		cfg.Password = "secret"
+		return cfg
+	}
+
+	for _, field := range []string{"", "maintenance", "system.maintenanceMode"} {
+		cfg := validTestConfig()
+		cfg.MaintenanceField = field
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("maintenance_field %q: unexpected error: %v", field, err)
+		}
+	}
+
+	for _, field := range []string{".", "system.", ".maintenance", "a..b"} {
+		cfg := validTestConfig()
+		cfg.MaintenanceField = field
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("maintenance_field %q: expected error", field)
+		}
+	}
+}
+
+func TestHTTPClient_GetDeviceData_Maintenance(t *testing.T) {
+	maintenance := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"v1"`)
+		if maintenance {
+			_, _ = w.Write([]byte(`{"code":"000000","total":1,"data":[{"assetDevice":{"id":"device-1"}}],"system":{"maintenanceMode":true}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":"000000","total":1,"data":[{"assetDevice":{"id":"device-1"}}],"system":{"maintenanceMode":false}}`))
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.BaseURL = server.URL
+	cfg.MaintenanceField = "system.maintenanceMode"
+	client := NewHTTPClient(cfg, log.NewTestLogger())
+
+	resp, err := client.GetDeviceData(context.Background(), "test-token")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Maintenance {
+		t.Error("expected the response to be marked as maintenance")
+	}
+	if client.getCached(client.deviceDataURL) != nil {
+		t.Error("expected a maintenance response not to be cached")
+	}
+
+	_, err = NewDataParser(nil).ParseResponse(resp)
+	if !errors.Is(err, ErrMaintenance) {
+		t.Errorf("expected ErrMaintenance from the parser, got %v", err)
+	}
+	if kind := ClassifyError(err); kind != ErrorTypeMaintenance {
+		t.Errorf("expected error kind %q, got %q", ErrorTypeMaintenance, kind)
+	}
+
+	maintenance = false
+	resp, err = client.GetDeviceData(context.Background(), "test-token")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Maintenance {
+		t.Error("expected the response not to be marked as maintenance")
+	}
+}
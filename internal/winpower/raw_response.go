@@ -0,0 +1,109 @@
+package winpower
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// rawResponse is a device data response body retained for debugging.
+type rawResponse struct {
+	body       []byte
+	receivedAt time.Time
+}
+
+// retainRaw keeps body as the last device data response when retention is
+// enabled. A body over the size limit drops the previous one, so a stale
+// response is never served as the last collection.
+func (c *HTTPClient) retainRaw(body []byte) {
+	if c.rawMaxBytes <= 0 {
+		return
+	}
+
+	c.rawMu.Lock()
+	defer c.rawMu.Unlock()
+
+	if len(body) > c.rawMaxBytes {
+		c.logger.Debug("device data response exceeds raw_response_max_bytes, not retained",
+			zap.Int("bytes", len(body)),
+			zap.Int("max_bytes", c.rawMaxBytes),
+		)
+		c.raw = nil
+		return
+	}
+
+	c.raw = &rawResponse{
+		body:       bytes.Clone(body),
+		receivedAt: time.Now(),
+	}
+}
+
+// lastRaw returns the retained device data response, or nil.
+func (c *HTTPClient) lastRaw() *rawResponse {
+	c.rawMu.Lock()
+	defer c.rawMu.Unlock()
+	return c.raw
+}
+
+// RawDeviceData returns the entry of a device in the last device data
+// response as WinPower sent it, with the values of redact_fields redacted,
+// and the time the response was received. It returns
+// ErrRawResponseUnavailable when no response is retained and
+// ErrDeviceNotInResponse when the response does not contain the device.
+func (c *Client) RawDeviceData(deviceID string) (json.RawMessage, time.Time, error) {
+	raw := c.httpClient.lastRaw()
+	if raw == nil {
+		return nil, time.Time{}, ErrRawResponseUnavailable
+	}
+
+	var resp struct {
+		Data []json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(raw.body, &resp); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to decode retained response: %w", err)
+	}
+
+	for _, entry := range resp.Data {
+		var device struct {
+			AssetDevice struct {
+				ID string `json:"id"`
+			} `json:"assetDevice"`
+		}
+		if err := json.Unmarshal(entry, &device); err != nil || device.AssetDevice.ID != deviceID {
+			continue
+		}
+
+		redacted, err := c.httpClient.redactFragment(entry)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		return redacted, raw.receivedAt, nil
+	}
+
+	return nil, time.Time{}, fmt.Errorf("%w: %s", ErrDeviceNotInResponse, deviceID)
+}
+
+// redactFragment redacts the sensitive keys of a JSON fragment. A fragment
+// without sensitive keys is returned unchanged; otherwise it is re-encoded,
+// keeping numbers as sent.
+func (c *HTTPClient) redactFragment(fragment json.RawMessage) (json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(fragment))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode device entry: %w", err)
+	}
+	if !c.redactor.redactJSON(value) {
+		return fragment, nil
+	}
+
+	redacted, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode device entry: %w", err)
+	}
+	return redacted, nil
+}
@@ -0,0 +1,110 @@
+package winpower
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rawDeviceDataBody = `{"total":2,"pageSize":20,"currentPage":1,"data":[` +
+	`{"assetDevice":{"id":"ups-1","deviceType":1},"realtime":{"inputVolt1":"230.5","loadPwr":1200.125},"config":{"password":"hunter2"}},` +
+	`{"assetDevice":{"id":"ups-2","deviceType":1},"realtime":{"inputVolt1":"NaN"}}` +
+	`],"code":"000000","msg":"OK"}`
+
+// newRawResponseClient creates a client against a WinPower stub serving
+// rawDeviceDataBody with the given raw response size limit.
+func newRawResponseClient(t *testing.T, maxBytes int) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1/auth/login" {
+			_, _ = w.Write([]byte(`{"code":"000000","msg":"OK","data":{"token":"t","deviceId":"d"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(rawDeviceDataBody))
+	}))
+
+	client, err := NewClient(&Config{
+		BaseURL:             server.URL,
+		Username:            "testuser",
+		Password:            "testpass",
+		Timeout:             5 * time.Second,
+		RefreshThreshold:    5 * time.Minute,
+		RawResponseMaxBytes: maxBytes,
+	}, log.NewTestLogger())
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = client.Close()
+		server.Close()
+	})
+	return client
+}
+
+func TestClient_RawDeviceData(t *testing.T) {
+	t.Run("returns the redacted entry of the device", func(t *testing.T) {
+		client := newRawResponseClient(t, 64*1024)
+		_, _, err := client.RawDeviceData("ups-1")
+		assert.ErrorIs(t, err, ErrRawResponseUnavailable, "nothing collected yet")
+
+		before := time.Now()
+		_, err = client.CollectDeviceData(context.Background())
+		require.NoError(t, err)
+
+		fragment, receivedAt, err := client.RawDeviceData("ups-1")
+		require.NoError(t, err)
+		assert.False(t, receivedAt.Before(before))
+		assert.NotContains(t, string(fragment), "hunter2")
+		assert.Contains(t, string(fragment), `"loadPwr":1200.125`)
+
+		var entry map[string]map[string]interface{}
+		require.NoError(t, json.Unmarshal(fragment, &entry))
+		assert.Equal(t, redactedValue, entry["config"]["password"])
+		assert.Equal(t, "230.5", entry["realtime"]["inputVolt1"])
+	})
+
+	t.Run("returns an entry without sensitive keys unchanged", func(t *testing.T) {
+		client := newRawResponseClient(t, 64*1024)
+		_, err := client.CollectDeviceData(context.Background())
+		require.NoError(t, err)
+
+		fragment, _, err := client.RawDeviceData("ups-2")
+		require.NoError(t, err)
+		assert.Equal(t, `{"assetDevice":{"id":"ups-2","deviceType":1},"realtime":{"inputVolt1":"NaN"}}`, string(fragment))
+	})
+
+	t.Run("unknown device", func(t *testing.T) {
+		client := newRawResponseClient(t, 64*1024)
+		_, err := client.CollectDeviceData(context.Background())
+		require.NoError(t, err)
+
+		_, _, err = client.RawDeviceData("ups-3")
+		assert.ErrorIs(t, err, ErrDeviceNotInResponse)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		client := newRawResponseClient(t, 0)
+		_, err := client.CollectDeviceData(context.Background())
+		require.NoError(t, err)
+
+		_, _, err = client.RawDeviceData("ups-1")
+		assert.ErrorIs(t, err, ErrRawResponseUnavailable)
+	})
+
+	t.Run("response over the size limit is not retained", func(t *testing.T) {
+		client := newRawResponseClient(t, len(rawDeviceDataBody)-1)
+		_, err := client.CollectDeviceData(context.Background())
+		require.NoError(t, err)
+
+		_, _, err = client.RawDeviceData("ups-1")
+		assert.ErrorIs(t, err, ErrRawResponseUnavailable)
+	})
+}
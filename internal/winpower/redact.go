@@ -0,0 +1,161 @@
+package winpower
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// redactedValue replaces the value of a sensitive field in log output.
+const redactedValue = "***"
+
+// DefaultRedactFields are the header, query parameter and JSON field names
+// whose values are redacted from request and response logging by default.
+var DefaultRedactFields = []string{"Authorization", "Cookie", "Set-Cookie", "password", "token"}
+
+// redactor masks sensitive values in the request and response details the
+// HTTP client logs, so enabling debug logging does not leak credentials.
+// Names are matched case-insensitively against header names, query
+// parameter names and JSON object keys at any depth.
+type redactor struct {
+	fields map[string]struct{}
+}
+
+// newRedactor creates a redactor for the given field names.
+func newRedactor(fields []string) *redactor {
+	r := &redactor{fields: make(map[string]struct{}, len(fields))}
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			r.fields[strings.ToLower(field)] = struct{}{}
+		}
+	}
+	return r
+}
+
+// sensitive reports whether the value of the named field is redacted.
+func (r *redactor) sensitive(name string) bool {
+	_, ok := r.fields[strings.ToLower(name)]
+	return ok
+}
+
+// redactURL returns the URL with the values of sensitive query parameters
+// and any userinfo password redacted.
+func (r *redactor) redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+
+	redacted := *u
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			redacted.User = url.UserPassword(u.User.Username(), redactedValue)
+		}
+	}
+
+	if u.RawQuery != "" {
+		if query := u.Query(); r.redactValues(query) {
+			redacted.RawQuery = query.Encode()
+		}
+	}
+
+	return redacted.String()
+}
+
+// redactRawURL is like redactURL for a URL string. A string that cannot be
+// parsed is redacted as a whole.
+func (r *redactor) redactRawURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return redactedValue
+	}
+	return r.redactURL(u)
+}
+
+// redactHeaders returns the headers as a flat map for logging, with the
+// values of sensitive headers redacted.
+func (r *redactor) redactHeaders(header http.Header) map[string]string {
+	result := make(map[string]string, len(header))
+	for name, values := range header {
+		if r.sensitive(name) {
+			result[name] = redactedValue
+			continue
+		}
+		result[name] = strings.Join(values, ", ")
+	}
+	return result
+}
+
+// redactBody returns a request or response body for logging. In a JSON body
+// the values of sensitive keys are redacted and a form-encoded body has its
+// sensitive parameters redacted. Other bodies are returned unchanged.
+func (r *redactor) redactBody(body []byte) string {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return string(body)
+	}
+
+	if trimmed[0] == '{' || trimmed[0] == '[' {
+		var value interface{}
+		if err := json.Unmarshal(trimmed, &value); err == nil {
+			if !r.redactJSON(value) {
+				return string(body)
+			}
+			if redacted, err := json.Marshal(value); err == nil {
+				return string(redacted)
+			}
+		}
+		return string(body)
+	}
+
+	if text := string(trimmed); strings.Contains(text, "=") {
+		query, err := url.ParseQuery(text)
+		if err == nil && r.redactValues(query) {
+			return query.Encode()
+		}
+	}
+
+	return string(body)
+}
+
+// redactValues replaces the values of sensitive parameters in place and
+// reports whether anything was redacted.
+func (r *redactor) redactValues(values url.Values) bool {
+	changed := false
+	for name, list := range values {
+		if r.sensitive(name) {
+			for i := range list {
+				list[i] = redactedValue
+			}
+			changed = true
+		}
+	}
+	return changed
+}
+
+// redactJSON replaces the values of sensitive keys in a decoded JSON value
+// in place and reports whether anything was redacted.
+func (r *redactor) redactJSON(value interface{}) bool {
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if r.sensitive(key) {
+				v[key] = redactedValue
+				changed = true
+				continue
+			}
+			if r.redactJSON(item) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if r.redactJSON(item) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,96 @@
+package winpower
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// defaultRetryableStatus lists the HTTP statuses below 500 that are retried
+// by default. Every 5xx status is retried as well; any other status is
+// permanent. 401 is retryable because WinPower rejects logins while it is
+// starting up or its session store is being reset.
+var defaultRetryableStatus = map[int]bool{
+	http.StatusUnauthorized:    true,
+	http.StatusRequestTimeout:  true,
+	http.StatusTooEarly:        true,
+	http.StatusTooManyRequests: true,
+}
+
+// retryPolicy decides which failed requests are worth retrying. Its
+// overrides take precedence over the default classification.
+type retryPolicy struct {
+	retryable map[int]bool // statuses retried in addition to the defaults
+	permanent map[int]bool // statuses never retried
+}
+
+// newRetryPolicy returns the policy with the given status overrides
+func newRetryPolicy(retryable, permanent []int) retryPolicy {
+	p := retryPolicy{
+		retryable: make(map[int]bool, len(retryable)),
+		permanent: make(map[int]bool, len(permanent)),
+	}
+	for _, code := range retryable {
+		p.retryable[code] = true
+	}
+	for _, code := range permanent {
+		p.permanent[code] = true
+	}
+	return p
+}
+
+// isRetryable reports whether the request that failed with err may succeed
+// when retried. Only HTTP status errors are classified; network failures,
+// timeouts and any other error are always retryable.
+func (p retryPolicy) isRetryable(err error) bool {
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		return true
+	}
+
+	code := statusErr.StatusCode
+	switch {
+	case p.permanent[code]:
+		return false
+	case p.retryable[code]:
+		return true
+	default:
+		return code >= 500 || defaultRetryableStatus[code]
+	}
+}
+
+// validateStatusCodes checks that every code of the retry override lists is
+// a valid HTTP status and that no code is both retryable and permanent
+func validateStatusCodes(retryable, permanent []int) error {
+	lists := []struct {
+		field string
+		codes []int
+	}{
+		{"retryable_status_codes", retryable},
+		{"permanent_status_codes", permanent},
+	}
+	for _, list := range lists {
+		for _, code := range list.codes {
+			if code < 100 || code > 599 {
+				return &ConfigError{
+					Field:   list.field,
+					Message: fmt.Sprintf("invalid HTTP status code %d, must be between 100 and 599", code),
+				}
+			}
+		}
+	}
+
+	retry := make(map[int]bool, len(retryable))
+	for _, code := range retryable {
+		retry[code] = true
+	}
+	for _, code := range permanent {
+		if retry[code] {
+			return &ConfigError{
+				Field:   "permanent_status_codes",
+				Message: fmt.Sprintf("status code %d is also listed in retryable_status_codes", code),
+			}
+		}
+	}
+	return nil
+}
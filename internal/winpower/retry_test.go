@@ -0,0 +1,192 @@
+package winpower
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+func TestRetryPolicy_IsRetryable(t *testing.T) {
+	status := func(code int) error {
+		return &AuthenticationError{Message: "login failed", Err: &HTTPStatusError{StatusCode: code}}
+	}
+
+	tests := []struct {
+		name      string
+		retryable []int
+		permanent []int
+		err       error
+		want      bool
+	}{
+		{name: "network error", err: errors.New("connection refused"), want: true},
+		{name: "5xx", err: status(http.StatusBadGateway), want: true},
+		{name: "401", err: status(http.StatusUnauthorized), want: true},
+		{name: "429", err: status(http.StatusTooManyRequests), want: true},
+		{name: "404", err: status(http.StatusNotFound), want: false},
+		{name: "non-standard status", err: status(420), want: false},
+		{name: "retryable override", retryable: []int{420}, err: status(420), want: true},
+		{name: "permanent override", permanent: []int{503}, err: status(503), want: false},
+		{name: "wrapped", err: fmt.Errorf("fetch: %w", status(http.StatusServiceUnavailable)), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := newRetryPolicy(tt.retryable, tt.permanent)
+			if got := policy.isRetryable(tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+
+	// The zero policy of a bare HTTPClient uses the defaults
+	if !(retryPolicy{}).isRetryable(status(http.StatusServiceUnavailable)) {
+		t.Error("expected the zero policy to retry 503")
+	}
+}
+
+func TestValidateStatusCodes(t *testing.T) {
+	tests := []struct {
+		name      string
+		retryable []int
+		permanent []int
+		wantField string
+	}{
+		{name: "empty"},
+		{name: "valid", retryable: []int{420}, permanent: []int{503}},
+		{name: "below range", retryable: []int{99}, wantField: "retryable_status_codes"},
+		{name: "above range", permanent: []int{600}, wantField: "permanent_status_codes"},
+		{name: "overlap", retryable: []int{420}, permanent: []int{420}, wantField: "permanent_status_codes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStatusCodes(tt.retryable, tt.permanent)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			var cfgErr *ConfigError
+			if !errors.As(err, &cfgErr) || cfgErr.Field != tt.wantField {
+				t.Errorf("expected a ConfigError for %s, got %v", tt.wantField, err)
+			}
+		})
+	}
+}
+
+func TestClient_WaitConnected_RetryClassification(t *testing.T) {
+	newClient := func(t *testing.T, status int, retryable []int) (*Client, *atomic.Int32) {
+		t.Helper()
+
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if attempts.Add(1) <= 2 {
+				w.WriteHeader(status)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"code":"000000","msg":"OK","data":{"token":"t","deviceId":"d"}}`))
+		}))
+		t.Cleanup(server.Close)
+
+		cfg := DefaultConfig()
+		cfg.BaseURL = server.URL
+		cfg.Username = "admin"
+		cfg.Password = "secret"
+		cfg.BackgroundRefresh = false
+		cfg.StartupConnectTimeout = 5 * time.Second
+		cfg.RetryableStatusCodes = retryable
+
+		client, err := NewClient(cfg, log.NewTestLogger())
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+		t.Cleanup(func() { _ = client.Close() })
+		client.startupBackoff = 10 * time.Millisecond
+		client.startupBackoffMax = 10 * time.Millisecond
+		return client, &attempts
+	}
+
+	t.Run("permanent status is not retried", func(t *testing.T) {
+		client, attempts := newClient(t, 420, nil)
+
+		if err := client.WaitConnected(context.Background()); !errors.Is(err, ErrStartupConnect) {
+			t.Fatalf("expected ErrStartupConnect, got %v", err)
+		}
+		if got := attempts.Load(); got != 1 {
+			t.Errorf("expected a single login attempt, got %d", got)
+		}
+	})
+
+	t.Run("retryable override is retried", func(t *testing.T) {
+		client, attempts := newClient(t, 420, []int{420})
+
+		if err := client.WaitConnected(context.Background()); err != nil {
+			t.Fatalf("WaitConnected() error = %v", err)
+		}
+		if got := attempts.Load(); got != 3 {
+			t.Errorf("expected 3 login attempts, got %d", got)
+		}
+	})
+}
+
+func TestHTTPClient_GetDeviceData_Retries(t *testing.T) {
+	deviceData := loadTestData(t, "device_data.json")
+
+	tests := []struct {
+		name        string
+		failures    int
+		status      int
+		maxRetries  int
+		wantErr     bool
+		wantRetries int
+	}{
+		{name: "recovers after retries", failures: 2, status: http.StatusServiceUnavailable, maxRetries: 3, wantRetries: 2},
+		{name: "retries exhausted", failures: 5, status: http.StatusServiceUnavailable, maxRetries: 2, wantErr: true, wantRetries: 2},
+		{name: "permanent status", failures: 1, status: http.StatusNotFound, maxRetries: 3, wantErr: true, wantRetries: 0},
+		{name: "retries disabled", failures: 1, status: http.StatusServiceUnavailable, wantErr: true, wantRetries: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if int(requests.Add(1)) <= tt.failures {
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write(deviceData)
+			}))
+			defer server.Close()
+
+			cfg := DefaultConfig()
+			cfg.BaseURL = server.URL
+			cfg.MaxRetries = tt.maxRetries
+			cfg.RetryInterval = time.Millisecond
+
+			client := NewHTTPClient(cfg, log.NewTestLogger())
+			observer := &recordingObserver{}
+			client.observer = observer
+
+			_, err := client.GetDeviceData(context.Background(), "token")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetDeviceData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := int(requests.Load()); got != tt.wantRetries+1 {
+				t.Errorf("expected %d requests, got %d", tt.wantRetries+1, got)
+			}
+			if len(observer.requestRetries) != 1 || observer.requestRetries[0] != tt.wantRetries {
+				t.Errorf("expected %d retries to be observed, got %v", tt.wantRetries, observer.requestRetries)
+			}
+		})
+	}
+}
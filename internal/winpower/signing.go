@@ -0,0 +1,206 @@
+package winpower
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported request signing algorithms
+const (
+	SigningAlgorithmHMACSHA256 = "hmac-sha256"
+	SigningAlgorithmHMACSHA512 = "hmac-sha512"
+)
+
+// Default request signing header names
+const (
+	DefaultSignatureHeader = "X-Signature"
+	DefaultTimestampHeader = "X-Timestamp"
+)
+
+// signingAlgorithms maps algorithm names to their hash constructors.
+var signingAlgorithms = map[string]func() hash.Hash{
+	SigningAlgorithmHMACSHA256: sha256.New,
+	SigningAlgorithmHMACSHA512: sha512.New,
+}
+
+// SigningAlgorithms returns the supported signing algorithm names, sorted.
+func SigningAlgorithms() []string {
+	names := make([]string, 0, len(signingAlgorithms))
+	for name := range signingAlgorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SigningConfig configures optional HMAC signing of every request sent to
+// WinPower, for gateways that authenticate requests with a shared secret.
+//
+// The signature is the hex-encoded HMAC of "<timestamp>\n<body>", where the
+// timestamp is the Unix time in seconds sent in TimestampHeader and the body
+// is the raw request body (empty for GET requests).
+type SigningConfig struct {
+	// Enabled turns on request signing
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// Secret is the shared HMAC secret
+	Secret string `yaml:"secret" mapstructure:"secret"`
+
+	// SecretFile is a file containing the shared secret, used instead of
+	// Secret. Trailing newlines are ignored.
+	SecretFile string `yaml:"secret_file" mapstructure:"secret_file"`
+
+	// Algorithm is the HMAC algorithm: hmac-sha256 (default) or hmac-sha512
+	Algorithm string `yaml:"algorithm" mapstructure:"algorithm"`
+
+	// SignatureHeader is the request header carrying the signature
+	SignatureHeader string `yaml:"signature_header" mapstructure:"signature_header"`
+
+	// TimestampHeader is the request header carrying the signed timestamp
+	TimestampHeader string `yaml:"timestamp_header" mapstructure:"timestamp_header"`
+}
+
+// LoadSecret returns the shared secret, reading SecretFile when it is set.
+func (s *SigningConfig) LoadSecret() ([]byte, error) {
+	if s.SecretFile == "" {
+		return []byte(s.Secret), nil
+	}
+
+	data, err := os.ReadFile(s.SecretFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing secret file: %w", err)
+	}
+	return []byte(strings.TrimRight(string(data), "\r\n")), nil
+}
+
+// validate checks the signing configuration. Disabled signing is always valid.
+func (s *SigningConfig) validate() error {
+	if !s.Enabled {
+		return nil
+	}
+
+	if s.Secret != "" && s.SecretFile != "" {
+		return &ConfigError{
+			Field:   "signing",
+			Message: "secret and secret_file are mutually exclusive",
+		}
+	}
+
+	if _, ok := signingAlgorithms[s.Algorithm]; !ok {
+		return &ConfigError{
+			Field: "signing.algorithm",
+			Message: fmt.Sprintf("unsupported algorithm %q, must be %s or %s",
+				s.Algorithm, SigningAlgorithmHMACSHA256, SigningAlgorithmHMACSHA512),
+		}
+	}
+
+	if s.SignatureHeader == "" || s.TimestampHeader == "" {
+		return &ConfigError{
+			Field:   "signing",
+			Message: "signature_header and timestamp_header cannot be empty",
+		}
+	}
+
+	secret, err := s.LoadSecret()
+	if err != nil {
+		return &ConfigError{
+			Field:   "signing.secret_file",
+			Message: "cannot be read",
+			Err:     err,
+		}
+	}
+	if len(secret) == 0 {
+		return &ConfigError{
+			Field:   "signing.secret",
+			Message: "cannot be empty when signing is enabled",
+		}
+	}
+
+	return nil
+}
+
+// requestSigner attaches an HMAC signature and timestamp to requests.
+type requestSigner struct {
+	secret          []byte
+	newHash         func() hash.Hash
+	signatureHeader string
+	timestampHeader string
+	now             func() time.Time
+}
+
+// newRequestSigner creates a signer from the configuration, or returns nil
+// when signing is disabled.
+func newRequestSigner(cfg *SigningConfig) (*requestSigner, error) {
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+
+	newHash, ok := signingAlgorithms[cfg.Algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", cfg.Algorithm)
+	}
+
+	secret, err := cfg.LoadSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	return &requestSigner{
+		secret:          secret,
+		newHash:         newHash,
+		signatureHeader: cfg.SignatureHeader,
+		timestampHeader: cfg.TimestampHeader,
+		now:             time.Now,
+	}, nil
+}
+
+// sign returns the hex-encoded HMAC of the timestamp and body.
+func (s *requestSigner) sign(timestamp string, body []byte) string {
+	mac := hmac.New(s.newHash, s.secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signRequest sets the signature and timestamp headers on the request. The
+// body is read through GetBody so the request can still be sent.
+func (s *requestSigner) signRequest(req *http.Request) error {
+	var body []byte
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return fmt.Errorf("failed to read request body for signing: %w", err)
+		}
+		body, err = io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read request body for signing: %w", err)
+		}
+	} else if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read request body for signing: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	req.Header.Set(s.timestampHeader, timestamp)
+	req.Header.Set(s.signatureHeader, s.sign(timestamp, body))
+	return nil
+}
@@ -0,0 +1,231 @@
+package winpower
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+func TestRequestSigner_KnownVectors(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		body      string
+		want      string
+	}{
+		{
+			algorithm: SigningAlgorithmHMACSHA256,
+			body:      `{"username":"admin","password":"secret"}`,
+			want:      "fa6e1f8bc1d2ae7915f86b43de52618be7c530ce9585f03370781cde42f28baa",
+		},
+		{
+			algorithm: SigningAlgorithmHMACSHA512,
+			body:      "",
+			want: "a4de6d545ea8a4756811f7381de070160fce40b320a6a6d227040cf8b6baed7b" +
+				"f62aeb04118b8c78a026c967c1d57dd15684906b65d449a5ea9711148423d70a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			signer, err := newRequestSigner(&SigningConfig{
+				Enabled:         true,
+				Secret:          "shared-secret",
+				Algorithm:       tt.algorithm,
+				SignatureHeader: DefaultSignatureHeader,
+				TimestampHeader: DefaultTimestampHeader,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			signer.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			req, err := http.NewRequest(http.MethodPost, "https://example.com", body)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := signer.signRequest(req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := req.Header.Get(DefaultTimestampHeader); got != "1700000000" {
+				t.Errorf("timestamp header = %q, want %q", got, "1700000000")
+			}
+			if got := req.Header.Get(DefaultSignatureHeader); got != tt.want {
+				t.Errorf("signature header = %q, want %q", got, tt.want)
+			}
+
+			// The body is still sent after signing
+			if req.Body != nil {
+				sent, _ := io.ReadAll(req.Body)
+				if string(sent) != tt.body {
+					t.Errorf("request body = %q, want %q", sent, tt.body)
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPClient_Signing(t *testing.T) {
+	logger := log.NewTestLogger()
+
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":"000000","msg":"OK","data":{"token":"t","deviceId":"d"}}`))
+	}))
+	defer server.Close()
+
+	newConfig := func() *Config {
+		cfg := DefaultConfig()
+		cfg.BaseURL = server.URL
+		cfg.Username = "admin"
+		cfg.Password = "secret"
+		return cfg
+	}
+
+	t.Run("disabled leaves requests unchanged", func(t *testing.T) {
+		client := NewHTTPClient(newConfig(), logger)
+		if _, err := client.Login(context.Background(), "admin", "secret"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if headers.Get(DefaultSignatureHeader) != "" || headers.Get(DefaultTimestampHeader) != "" {
+			t.Errorf("expected no signing headers, got %v", headers)
+		}
+	})
+
+	t.Run("signs with secret file", func(t *testing.T) {
+		secretFile := filepath.Join(t.TempDir(), "secret")
+		if err := os.WriteFile(secretFile, []byte("shared-secret\n"), 0600); err != nil {
+			t.Fatalf("failed to write secret file: %v", err)
+		}
+
+		cfg := newConfig()
+		cfg.Signing.Enabled = true
+		cfg.Signing.SecretFile = secretFile
+		cfg.Signing.SignatureHeader = "X-Gateway-Signature"
+		client := NewHTTPClient(cfg, logger)
+		client.signer.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+		if _, err := client.Login(context.Background(), "admin", "secret"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := headers.Get(DefaultTimestampHeader); got != "1700000000" {
+			t.Errorf("timestamp header = %q, want %q", got, "1700000000")
+		}
+		want := "fa6e1f8bc1d2ae7915f86b43de52618be7c530ce9585f03370781cde42f28baa"
+		if got := headers.Get("X-Gateway-Signature"); got != want {
+			t.Errorf("signature header = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("unreadable secret fails requests", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Signing.Enabled = true
+		cfg.Signing.SecretFile = filepath.Join(t.TempDir(), "missing")
+		client := NewHTTPClient(cfg, logger)
+
+		headers = nil
+		_, err := client.Login(context.Background(), "admin", "secret")
+		if err == nil || !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("expected secret file error, got %v", err)
+		}
+		if headers != nil {
+			t.Error("expected no request to be sent")
+		}
+	})
+}
+
+func TestSigningConfig_Validate(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secretFile, []byte("shared-secret\n"), 0600); err != nil {
+		t.Fatalf("failed to write secret file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*SigningConfig)
+		wantErr string
+	}{
+		{name: "disabled", modify: func(s *SigningConfig) { s.Algorithm = "bogus" }},
+		{name: "secret", modify: func(s *SigningConfig) { s.Enabled, s.Secret = true, "shared-secret" }},
+		{name: "secret file", modify: func(s *SigningConfig) { s.Enabled, s.SecretFile = true, secretFile }},
+		{
+			name:    "missing secret",
+			modify:  func(s *SigningConfig) { s.Enabled = true },
+			wantErr: "signing.secret",
+		},
+		{
+			name: "both secret and file",
+			modify: func(s *SigningConfig) {
+				s.Enabled, s.Secret, s.SecretFile = true, "shared-secret", secretFile
+			},
+			wantErr: "mutually exclusive",
+		},
+		{
+			name: "unreadable file",
+			modify: func(s *SigningConfig) {
+				s.Enabled, s.SecretFile = true, filepath.Join(t.TempDir(), "missing")
+			},
+			wantErr: "signing.secret_file",
+		},
+		{
+			name: "unknown algorithm",
+			modify: func(s *SigningConfig) {
+				s.Enabled, s.Secret, s.Algorithm = true, "shared-secret", "md5"
+			},
+			wantErr: "signing.algorithm",
+		},
+		{
+			name: "empty header",
+			modify: func(s *SigningConfig) {
+				s.Enabled, s.Secret, s.SignatureHeader = true, "shared-secret", ""
+			},
+			wantErr: "signature_header",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.BaseURL = "https://example.com"
+			cfg.Username = "admin"
+			cfg.Password = "secret"
+			tt.modify(&cfg.Signing)
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestConfig_SanitizeMasksSigningSecret(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Signing.Secret = "shared-secret"
+
+	signing := cfg.Sanitize()["signing"].(map[string]interface{})
+	if signing["secret"] != "***REDACTED***" {
+		t.Errorf("expected masked secret, got %v", signing["secret"])
+	}
+}
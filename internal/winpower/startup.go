@@ -0,0 +1,70 @@
+package winpower
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Startup failure modes
+const (
+	// StartupFailureFatal aborts startup when WinPower is not reachable
+	StartupFailureFatal = "fatal"
+	// StartupFailureDegraded starts anyway and keeps retrying on every collection
+	StartupFailureDegraded = "degraded"
+)
+
+// StartupFailureModes lists the valid startup failure modes
+var StartupFailureModes = []string{StartupFailureFatal, StartupFailureDegraded}
+
+// WaitConnected verifies that WinPower is reachable by logging in, retrying
+// with exponential backoff until StartupConnectTimeout elapses. A zero
+// timeout makes a single attempt, and a failure with a permanent HTTP status
+// (see Config.PermanentStatusCodes) is not retried. It returns an error wrapping
+// ErrStartupConnect when every attempt failed, or ctx.Err() when ctx is
+// cancelled, e.g. by a shutdown signal during a stuck startup.
+func (c *Client) WaitConnected(ctx context.Context) error {
+	deadline := time.Now().Add(c.config.StartupConnectTimeout)
+	backoff := c.startupBackoff
+
+	for attempt := 1; ; attempt++ {
+		_, err := c.tokenManager.GetToken(ctx)
+		if err == nil {
+			c.logger.Info("WinPower is reachable",
+				zap.String("base_url", c.config.BaseURL),
+				zap.Int("attempts", attempt),
+			)
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !c.httpClient.retryPolicy.isRetryable(err) {
+			return fmt.Errorf("%w after %d attempts, not retrying: %w", ErrStartupConnect, attempt, err)
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return fmt.Errorf("%w after %d attempts: %w", ErrStartupConnect, attempt, err)
+		}
+
+		wait := min(backoff, remaining)
+		c.logger.Warn("WinPower not reachable yet, retrying",
+			zap.Int("attempt", attempt),
+			zap.Duration("retry_in", wait),
+			zap.Error(err),
+		)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, c.startupBackoffMax)
+	}
+}
@@ -0,0 +1,122 @@
+package winpower
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+)
+
+// newStartupTestClient creates a client against a server that fails the
+// first `failures` login attempts.
+func newStartupTestClient(t *testing.T, failures int32, timeout time.Duration) (*Client, *atomic.Int32) {
+	t.Helper()
+
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":"000000","msg":"OK","data":{"token":"t","deviceId":"d"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := DefaultConfig()
+	cfg.BaseURL = server.URL
+	cfg.Username = "admin"
+	cfg.Password = "secret"
+	cfg.BackgroundRefresh = false
+	cfg.VerifyOnStart = true
+	cfg.StartupConnectTimeout = timeout
+
+	client, err := NewClient(cfg, log.NewTestLogger())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	client.startupBackoff = 10 * time.Millisecond
+	client.startupBackoffMax = 40 * time.Millisecond
+	return client, &attempts
+}
+
+func TestClient_WaitConnected(t *testing.T) {
+	t.Run("retries until reachable", func(t *testing.T) {
+		client, attempts := newStartupTestClient(t, 3, 5*time.Second)
+
+		if err := client.WaitConnected(context.Background()); err != nil {
+			t.Fatalf("WaitConnected() error = %v", err)
+		}
+		if got := attempts.Load(); got != 4 {
+			t.Errorf("expected 4 login attempts, got %d", got)
+		}
+	})
+
+	t.Run("gives up after timeout", func(t *testing.T) {
+		client, attempts := newStartupTestClient(t, 1000, 200*time.Millisecond)
+
+		start := time.Now()
+		err := client.WaitConnected(context.Background())
+		if !errors.Is(err, ErrStartupConnect) {
+			t.Fatalf("expected ErrStartupConnect, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("expected to give up after the timeout, took %v", elapsed)
+		}
+		if got := attempts.Load(); got < 2 {
+			t.Errorf("expected several login attempts, got %d", got)
+		}
+	})
+
+	t.Run("zero timeout makes a single attempt", func(t *testing.T) {
+		client, attempts := newStartupTestClient(t, 1000, 0)
+
+		if err := client.WaitConnected(context.Background()); !errors.Is(err, ErrStartupConnect) {
+			t.Fatalf("expected ErrStartupConnect, got %v", err)
+		}
+		if got := attempts.Load(); got != 1 {
+			t.Errorf("expected 1 login attempt, got %d", got)
+		}
+	})
+
+	t.Run("cancellation aborts startup", func(t *testing.T) {
+		client, _ := newStartupTestClient(t, 1000, time.Hour)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		if err := client.WaitConnected(ctx); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	})
+}
+
+func TestConfig_ValidateStartup(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.BaseURL = "https://example.com"
+	cfg.Username = "admin"
+	cfg.Password = "secret"
+
+	cfg.StartupFailureMode = StartupFailureDegraded
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg.StartupFailureMode = "ignore"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown startup failure mode")
+	}
+
+	cfg.StartupFailureMode = StartupFailureFatal
+	cfg.StartupConnectTimeout = -time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for negative startup connect timeout")
+	}
+}
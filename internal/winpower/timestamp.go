@@ -0,0 +1,73 @@
+package winpower
+
+import (
+	"fmt"
+	"time"
+)
+
+// defaultTimeLayouts are the layouts tried, in order, for string timestamps
+// when no TimeLayout is configured. They accept an RFC3339 offset ("Z",
+// "+08:00"), a numeric offset without colon ("+0800") or no zone at all,
+// with either a "T" or a space between date and time. Fractional seconds
+// are accepted by every layout.
+var defaultTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05Z0700",
+	"2006-01-02 15:04:05 Z0700",
+	"2006-01-02 15:04:05",
+}
+
+// parseTimestamp parses s with the first matching layout. Timestamps that
+// carry an offset are converted with it; zoneless timestamps are read in
+// location. The result is returned in UTC so timestamps of devices in
+// different zones compare and log consistently.
+func parseTimestamp(s string, layouts []string, location *time.Location) (time.Time, error) {
+	var err error
+	for _, layout := range layouts {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, s, location); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("timestamp %q does not match the expected format: %w", s, err)
+}
+
+// timeLayouts returns the layouts used for string timestamps
+func timeLayouts(layout string) []string {
+	if layout == "" {
+		return defaultTimeLayouts
+	}
+	return []string{layout}
+}
+
+// timeLocation returns the location of zoneless timestamps, UTC when zone
+// is empty
+func timeLocation(zone string) (*time.Location, error) {
+	if zone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(zone)
+}
+
+// validateTimeFormat checks that layout is a Go reference layout and that
+// zone is a known location
+func validateTimeFormat(layout, zone string) error {
+	// A layout without any reference element formats to itself
+	if layout != "" && (time.Time{}).Format(layout) == layout {
+		return &ConfigError{
+			Field:   "time_layout",
+			Message: fmt.Sprintf("%q is not a Go time layout, e.g. \"2006-01-02 15:04:05-0700\"", layout),
+		}
+	}
+
+	if _, err := timeLocation(zone); err != nil {
+		return &ConfigError{
+			Field:   "time_zone",
+			Message: fmt.Sprintf("unknown time zone %q: %v", zone, err),
+		}
+	}
+	return nil
+}
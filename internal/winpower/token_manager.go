@@ -3,6 +3,7 @@ package winpower
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
@@ -24,6 +25,19 @@ type TokenManager struct {
 
 	mu    sync.RWMutex
 	cache *TokenCache
+
+	// Background refresh state
+	bgMu          sync.Mutex
+	bgCancel      context.CancelFunc // nil when background refresh is not running
+	bgDone        chan struct{}
+	background    atomic.Bool   // Whether GetToken may leave refreshes to the background refresher
+	cached        chan struct{} // Signalled whenever a new token is cached
+	retries       int
+	retryInterval time.Duration
+
+	// Cumulative login outcomes, inline and background
+	refreshes       atomic.Int64
+	refreshFailures atomic.Int64
 }
 
 // NewTokenManager creates a new token manager.
@@ -34,6 +48,7 @@ func NewTokenManager(httpClient *HTTPClient, username, password string, refreshT
 		password:         password,
 		refreshThreshold: refreshThreshold,
 		logger:           logger,
+		cached:           make(chan struct{}, 1),
 	}
 }
 
@@ -42,13 +57,13 @@ func NewTokenManager(httpClient *HTTPClient, username, password string, refreshT
 func (tm *TokenManager) GetToken(ctx context.Context) (string, error) {
 	// Fast path: check if we have a valid cached token (read lock)
 	tm.mu.RLock()
-	if tm.cache != nil && !tm.shouldRefresh() {
-		token := tm.cache.Token
+	if !tm.needsInlineRefresh() {
+		token, expiresAt := tm.cache.Token, tm.cache.ExpiresAt
 		tm.mu.RUnlock()
 
 		tm.logger.Debug("using cached token",
-			zap.Time("expires_at", tm.cache.ExpiresAt),
-			zap.Duration("remaining", time.Until(tm.cache.ExpiresAt)),
+			zap.Time("expires_at", expiresAt),
+			zap.Duration("remaining", time.Until(expiresAt)),
 		)
 
 		return token, nil
@@ -60,7 +75,7 @@ func (tm *TokenManager) GetToken(ctx context.Context) (string, error) {
 	defer tm.mu.Unlock()
 
 	// Double-check after acquiring write lock (another goroutine might have refreshed)
-	if tm.cache != nil && !tm.shouldRefresh() {
+	if !tm.needsInlineRefresh() {
 		token := tm.cache.Token
 
 		tm.logger.Debug("using token refreshed by another goroutine",
@@ -76,29 +91,67 @@ func (tm *TokenManager) GetToken(ctx context.Context) (string, error) {
 		zap.Bool("has_cache", tm.cache != nil),
 	)
 
-	loginResp, err := tm.httpClient.Login(ctx, tm.username, tm.password)
+	cache, err := tm.login(ctx)
 	if err != nil {
 		tm.logger.Error("failed to refresh token",
 			zap.Error(err),
 		)
 		return "", err
 	}
+	tm.setCache(cache)
+
+	tm.logger.Info("token refreshed successfully",
+		zap.String("device_id", cache.DeviceID),
+		zap.Time("expires_at", cache.ExpiresAt),
+		zap.Duration("valid_for", tokenExpiry),
+	)
 
-	// Cache the new token
-	now := time.Now()
-	tm.cache = &TokenCache{
+	return cache.Token, nil
+}
+
+// login performs a login and records its outcome in the refresh counters.
+// It does not touch the cache, so it may be called without holding the lock.
+func (tm *TokenManager) login(ctx context.Context) (*TokenCache, error) {
+	loginResp, err := tm.httpClient.Login(ctx, tm.username, tm.password)
+	if err != nil {
+		tm.refreshFailures.Add(1)
+		return nil, err
+	}
+	tm.refreshes.Add(1)
+
+	return &TokenCache{
 		Token:     loginResp.Data.Token,
-		ExpiresAt: now.Add(tokenExpiry),
+		ExpiresAt: time.Now().Add(tokenExpiry),
 		DeviceID:  loginResp.Data.DeviceID,
+	}, nil
+}
+
+// setCache stores a new token and wakes the background refresher so that it
+// reschedules against the new expiry.
+// Must be called with the write lock held.
+func (tm *TokenManager) setCache(cache *TokenCache) {
+	tm.cache = cache
+
+	select {
+	case tm.cached <- struct{}{}:
+	default:
 	}
+}
 
-	tm.logger.Info("token refreshed successfully",
-		zap.String("device_id", loginResp.Data.DeviceID),
-		zap.Time("expires_at", tm.cache.ExpiresAt),
-		zap.Duration("valid_for", tokenExpiry),
-	)
+// needsInlineRefresh checks if GetToken has to log in before returning.
+// While the background refresher is running the cached token is used until
+// it actually expires, so collection never waits on a refresh it does not
+// need. Must be called with at least a read lock held.
+func (tm *TokenManager) needsInlineRefresh() bool {
+	if tm.cache == nil {
+		return true
+	}
+
+	if tm.background.Load() {
+		return !time.Now().Before(tm.cache.ExpiresAt)
+	}
 
-	return tm.cache.Token, nil
+	return tm.shouldRefresh()
 }
 
 // shouldRefresh checks if the token should be refreshed.
@@ -188,3 +241,172 @@ func (tm *TokenManager) ClearCache() {
 		tm.cache = nil
 	}
 }
+
+// RefreshCounts returns the cumulative number of successful and failed
+// logins, including both inline and background refreshes.
+// This method is thread-safe.
+func (tm *TokenManager) RefreshCounts() (successes, failures int64) {
+	return tm.refreshes.Load(), tm.refreshFailures.Load()
+}
+
+// StartBackgroundRefresh starts refreshing the token ahead of expiry in a
+// background goroutine. Each refresh gets up to retries additional attempts
+// spaced by retryInterval. When all attempts fail the still-valid token is
+// kept and a new round is scheduled halfway to its expiry. Calling it while
+// already running has no effect.
+func (tm *TokenManager) StartBackgroundRefresh(retries int, retryInterval time.Duration) {
+	tm.bgMu.Lock()
+	defer tm.bgMu.Unlock()
+
+	if tm.bgCancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tm.bgCancel = cancel
+	tm.bgDone = make(chan struct{})
+	tm.retries = retries
+	tm.retryInterval = retryInterval
+	tm.background.Store(true)
+
+	tm.logger.Info("starting background token refresh",
+		zap.Duration("refresh_threshold", tm.refreshThreshold),
+		zap.Int("retries", retries),
+		zap.Duration("retry_interval", retryInterval),
+	)
+
+	go tm.refreshLoop(ctx, tm.bgDone)
+}
+
+// StopBackgroundRefresh stops the background refresher and waits for it to
+// exit. GetToken falls back to refreshing inline afterwards.
+func (tm *TokenManager) StopBackgroundRefresh() {
+	tm.bgMu.Lock()
+	cancel, done := tm.bgCancel, tm.bgDone
+	tm.bgCancel, tm.bgDone = nil, nil
+	tm.bgMu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	tm.background.Store(false)
+	cancel()
+	<-done
+}
+
+// refreshLoop waits until the cached token enters the refresh threshold and
+// renews it. Nothing is scheduled until the first token has been cached.
+func (tm *TokenManager) refreshLoop(ctx context.Context, done chan<- struct{}) {
+	defer close(done)
+
+	timer := time.NewTimer(0)
+	timer.Stop()
+	defer timer.Stop()
+
+	var retryAt time.Time
+	for {
+		if wait, ok := tm.nextRefreshIn(retryAt); ok {
+			timer.Reset(wait)
+		} else {
+			timer.Stop()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-tm.cached:
+			// A new token was cached, reschedule against its expiry. A pending
+			// retryAt never precedes the threshold of a freshly cached token.
+			continue
+		case <-timer.C:
+		}
+
+		if tm.refreshWithBudget(ctx) {
+			retryAt = time.Time{}
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		// Spread further rounds out relative to the remaining validity
+		backoff := time.Until(tm.GetExpiresAt()) / 2
+		if backoff < tm.retryInterval {
+			backoff = tm.retryInterval
+		}
+		retryAt = time.Now().Add(backoff)
+
+		tm.logger.Warn("background token refresh failed, keeping cached token",
+			zap.Int("attempts", tm.retries+1),
+			zap.Time("expires_at", tm.GetExpiresAt()),
+			zap.Time("next_attempt", retryAt),
+		)
+	}
+}
+
+// nextRefreshIn returns how long to wait before the next background refresh.
+// ok is false when no token is cached yet.
+func (tm *TokenManager) nextRefreshIn(retryAt time.Time) (wait time.Duration, ok bool) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	if tm.cache == nil {
+		return 0, false
+	}
+
+	at := tm.cache.ExpiresAt.Add(-tm.refreshThreshold)
+	if at.Before(retryAt) {
+		at = retryAt
+	}
+
+	return time.Until(at), true
+}
+
+// refreshWithBudget tries to refresh the token up to retries+1 times, or
+// until a login fails with a permanent HTTP status, and reports whether it
+// succeeded. The cached token is only replaced on
+// success, and the lock is not held while logging in so that collection
+// keeps using the current token in the meantime.
+func (tm *TokenManager) refreshWithBudget(ctx context.Context) bool {
+	for attempt := 0; attempt <= tm.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return false
+			case <-time.After(tm.retryInterval):
+			}
+		}
+
+		cache, err := tm.login(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return false
+			}
+			tm.logger.Warn("background token refresh attempt failed",
+				zap.Int("attempt", attempt+1),
+				zap.Int("max_attempts", tm.retries+1),
+				zap.Error(err),
+			)
+			if !tm.httpClient.retryPolicy.isRetryable(err) {
+				// Retrying within this round is pointless; the next round
+				// still tries again before the token expires
+				return false
+			}
+			continue
+		}
+
+		tm.mu.Lock()
+		tm.setCache(cache)
+		tm.mu.Unlock()
+
+		tm.logger.Info("token refreshed in background",
+			zap.String("device_id", cache.DeviceID),
+			zap.Time("expires_at", cache.ExpiresAt),
+			zap.Int("attempt", attempt+1),
+		)
+		return true
+	}
+
+	return false
+}
@@ -449,3 +449,154 @@ func TestTokenManager_ClearCache(t *testing.T) {
 		t.Error("token should not be valid after clear")
 	}
 }
+
+// newBackgroundTestServer returns a login server whose responses fail once
+// failing is set, along with a counter of login calls.
+func newBackgroundTestServer(t *testing.T, failing *atomic.Bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("Unauthorized"))
+			return
+		}
+
+		resp := LoginResponse{
+			Code:    "000000",
+			Message: "OK",
+		}
+		resp.Data.DeviceID = "device-123"
+		resp.Data.Token = "test-token-" + string(rune('0'+n))
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &calls
+}
+
+// expireSoon moves the cached token into the refresh threshold and wakes the
+// background refresher.
+func expireSoon(tm *TokenManager) {
+	tm.mu.Lock()
+	cache := *tm.cache
+	cache.ExpiresAt = time.Now().Add(time.Minute)
+	tm.setCache(&cache)
+	tm.mu.Unlock()
+}
+
+func TestTokenManager_BackgroundRefresh(t *testing.T) {
+	logger := log.NewTestLogger()
+	var failing atomic.Bool
+	server, calls := newBackgroundTestServer(t, &failing)
+
+	cfg := DefaultConfig()
+	cfg.BaseURL = server.URL
+
+	tm := NewTokenManager(NewHTTPClient(cfg, logger), "admin", "secret", 5*time.Minute, logger)
+	tm.StartBackgroundRefresh(1, 10*time.Millisecond)
+	defer tm.StopBackgroundRefresh()
+
+	token, err := tm.GetToken(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token != "test-token-1" {
+		t.Fatalf("expected token 'test-token-1', got %q", token)
+	}
+
+	expireSoon(tm)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for tm.GetCachedToken() != "test-token-2" {
+		if time.Now().After(deadline) {
+			t.Fatalf("token was not refreshed in the background, got %q", tm.GetCachedToken())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if remaining := time.Until(tm.GetExpiresAt()); remaining <= 5*time.Minute {
+		t.Errorf("expected renewed expiry, got %v remaining", remaining)
+	}
+
+	successes, failures := tm.RefreshCounts()
+	if successes != 2 || failures != 0 {
+		t.Errorf("expected 2 successes and 0 failures, got %d and %d", successes, failures)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 login calls, got %d", calls.Load())
+	}
+}
+
+func TestTokenManager_BackgroundRefreshFailureKeepsToken(t *testing.T) {
+	logger := log.NewTestLogger()
+	var failing atomic.Bool
+	server, calls := newBackgroundTestServer(t, &failing)
+
+	cfg := DefaultConfig()
+	cfg.BaseURL = server.URL
+
+	tm := NewTokenManager(NewHTTPClient(cfg, logger), "admin", "secret", 5*time.Minute, logger)
+	tm.StartBackgroundRefresh(2, 10*time.Millisecond)
+	defer tm.StopBackgroundRefresh()
+
+	ctx := context.Background()
+	if _, err := tm.GetToken(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	failing.Store(true)
+	expireSoon(tm)
+
+	// One initial attempt plus two retries
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, failures := tm.RefreshCounts(); failures == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			_, f := tm.RefreshCounts()
+			t.Fatalf("background refresh did not exhaust its retry budget, %d failures", f)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The still-valid token keeps being served without an inline login
+	loginsBefore := calls.Load()
+	token, err := tm.GetToken(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token != "test-token-1" {
+		t.Errorf("expected cached token 'test-token-1', got %q", token)
+	}
+	if !tm.IsValid() {
+		t.Error("token should remain valid after a failed background refresh")
+	}
+	if calls.Load() != loginsBefore {
+		t.Errorf("expected no inline login, got %d extra calls", calls.Load()-loginsBefore)
+	}
+
+	successes, failures := tm.RefreshCounts()
+	if successes != 1 || failures != 3 {
+		t.Errorf("expected 1 success and 3 failures, got %d and %d", successes, failures)
+	}
+}
+
+func TestTokenManager_StopBackgroundRefresh(t *testing.T) {
+	logger := log.NewTestLogger()
+	tm := NewTokenManager(&HTTPClient{}, "admin", "secret", 5*time.Minute, logger)
+
+	tm.StartBackgroundRefresh(3, time.Second)
+	tm.StartBackgroundRefresh(3, time.Second) // no-op while running
+	tm.StopBackgroundRefresh()
+	tm.StopBackgroundRefresh() // no-op when stopped
+
+	if tm.background.Load() {
+		t.Error("background refresh should be disabled after stop")
+	}
+}
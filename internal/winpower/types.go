@@ -14,9 +14,15 @@ type FlexibleTime struct {
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface.
-// It attempts to parse time in multiple formats:
+// It accepts the layouts of defaultTimeLayouts, e.g.:
 // 1. RFC3339 with timezone (e.g., "2006-01-02T15:04:05Z07:00")
-// 2. RFC3339 without timezone (e.g., "2006-01-02T15:04:05.999999")
+// 2. A numeric offset without colon (e.g., "2006-01-02T15:04:05+0800")
+// 3. Without timezone (WinPower format, e.g., "2006-01-02T15:04:05.999999"),
+// which is read as UTC
+//
+// Input matching none of these layouts leaves the zero time instead of
+// failing the decode, so that a createTime in a layout only known to the
+// configured time_layout does not fail the whole device data response.
 func (ft *FlexibleTime) UnmarshalJSON(data []byte) error {
 	// Remove quotes from JSON string
 	s := strings.Trim(string(data), "\"")
@@ -24,30 +30,13 @@ func (ft *FlexibleTime) UnmarshalJSON(data []byte) error {
 		return nil
 	}
 
-	// Try parsing with RFC3339 format (with timezone)
-	t, err := time.Parse(time.RFC3339, s)
-	if err == nil {
-		ft.Time = t
+	t, err := parseTimestamp(s, defaultTimeLayouts, time.UTC)
+	if err != nil {
+		ft.Time = time.Time{}
 		return nil
 	}
-
-	// Try parsing without timezone (WinPower format)
-	// Format: "2006-01-02T15:04:05.999999"
-	t, err = time.Parse("2006-01-02T15:04:05.999999", s)
-	if err == nil {
-		ft.Time = t
-		return nil
-	}
-
-	// Try parsing without microseconds
-	// Format: "2006-01-02T15:04:05"
-	t, err = time.Parse("2006-01-02T15:04:05", s)
-	if err == nil {
-		ft.Time = t
-		return nil
-	}
-
-	return err
+	ft.Time = t
+	return nil
 }
 
 // MarshalJSON implements the json.Marshaler interface.
@@ -67,6 +56,29 @@ type WinPowerClient interface {
 	GetLastCollectionTime() time.Time
 }
 
+// ResponseObserver records the size and parse time of WinPower responses
+// and the retries device data requests needed. It is implemented by the
+// metrics module.
+type ResponseObserver interface {
+	// ObserveResponseBytes records the size of a response body in bytes.
+	ObserveResponseBytes(bytes int)
+
+	// ObserveParseDuration records how long parsing a device data response took.
+	ObserveParseDuration(duration time.Duration)
+
+	// ObserveRequestRetries records how many retries a device data request
+	// made before it succeeded or gave up.
+	ObserveRequestRetries(retries int)
+
+	// ObserveDeviceCountMismatch records a device data response whose
+	// reported device total differs from the devices it contains.
+	ObserveDeviceCountMismatch()
+
+	// ObserveInvalidTimestamp records a device timestamp that could not be
+	// parsed and was left unset.
+	ObserveInvalidTimestamp()
+}
+
 // ParsedDeviceData represents standardized device data structure.
 type ParsedDeviceData struct {
 	// Device basic information
@@ -75,6 +87,12 @@ type ParsedDeviceData struct {
 	Model      string `json:"model"`
 	Alias      string `json:"alias"`
 
+	// StableID is the stable hardware identity of the device (e.g. a serial
+	// number) read from the configured identity field. Unlike DeviceID it
+	// survives renames in WinPower. Empty when no identity field is
+	// configured or the device does not report it.
+	StableID string `json:"stable_id,omitempty"`
+
 	// Connection status
 	Connected bool `json:"connected"`
 
@@ -83,6 +101,23 @@ type ParsedDeviceData struct {
 
 	// Collection metadata
 	CollectedAt time.Time `json:"collected_at"`
+
+	// MissingFields lists canonical realtime fields that were not present in
+	// the response (per the configured field map) and were left at zero
+	MissingFields []string `json:"missing_fields,omitempty"`
+
+	// MissingOptionalFields lists mapped optional realtime fields (those
+	// without a built-in key) that were not present in the response
+	MissingOptionalFields []string `json:"missing_optional_fields,omitempty"`
+
+	// InvalidFields lists canonical realtime fields whose value was NaN or
+	// infinite and was left at zero
+	InvalidFields []string `json:"invalid_fields,omitempty"`
+
+	// ActiveAlarms lists the type of each active alarm of the device, e.g.
+	// overload or battery fault; empty when there are none or WinPower does
+	// not report them
+	ActiveAlarms []string `json:"active_alarms,omitempty"`
 }
 
 // RealtimeData represents real-time device data.
@@ -90,6 +125,19 @@ type RealtimeData struct {
 	// Power data (key for energy calculation)
 	LoadTotalWatt float64 `json:"load_total_watt"` // Total active power in Watts
 
+	// Average power over WinPower's sampling interval, only read when
+	// "load_avg_watt" is mapped in the field map
+	LoadAvgWatt     float64 `json:"load_avg_watt"`     // Interval-average active power in Watts
+	LoadAvgReported bool    `json:"load_avg_reported"` // Whether the response contained a value for LoadAvgWatt
+
+	// Energy data, only read when "energy_total_wh" is mapped in the field map
+	EnergyTotalWh       float64 `json:"energy_total_wh"`       // Device-reported cumulative energy in Wh
+	EnergyTotalReported bool    `json:"energy_total_reported"` // Whether the response contained a value for EnergyTotalWh
+
+	// Refresh time of the data on the WinPower side, only read when
+	// "data_time" is mapped in the field map; zero when unknown
+	DataTime time.Time `json:"data_time"`
+
 	// Voltage data
 	InputVolt1  float64 `json:"input_volt_1"`  // Input voltage phase 1
 	OutputVolt1 float64 `json:"output_volt_1"` // Output voltage phase 1
@@ -133,6 +181,15 @@ type DeviceDataResponse struct {
 	Data        []DeviceInfo `json:"data"`
 	Code        string       `json:"code"`
 	Msg         string       `json:"msg"`
+
+	// Error is set by firmware that reports failures as an error object in
+	// a 200 OK response, e.g. {"error":"session expired"}. It is a string or
+	// an object with a "message" field.
+	Error json.RawMessage `json:"error,omitempty"`
+
+	// Maintenance is set when the configured maintenance indicator is set
+	// in the response, in which case its data must not be used
+	Maintenance bool `json:"-"`
 }
 
 // ErrorResponse represents an error response structure from WinPower API.
@@ -8,15 +8,21 @@ import (
 
 func TestFlexibleTime_UnmarshalJSON(t *testing.T) {
 	tests := []struct {
-		name    string
-		input   string
-		wantErr bool
+		name     string
+		input    string
+		wantErr  bool
+		wantZero bool
 	}{
 		{
 			name:    "parse RFC3339 with timezone",
 			input:   `"2025-10-13T08:37:57.048192Z"`,
 			wantErr: false,
 		},
+		{
+			name:    "parse numeric offset without colon",
+			input:   `"2025-10-13T16:37:57+0800"`,
+			wantErr: false,
+		},
 		{
 			name:    "parse without timezone (WinPower format)",
 			input:   `"2025-10-13T08:37:57.048192"`,
@@ -38,9 +44,14 @@ func TestFlexibleTime_UnmarshalJSON(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name:    "invalid format",
-			input:   `"invalid-date"`,
-			wantErr: true,
+			name:     "unparseable value leaves zero time",
+			input:    `"2024/01/02 03:04:05"`,
+			wantZero: true,
+		},
+		{
+			name:     "invalid format",
+			input:    `"invalid-date"`,
+			wantZero: true,
 		},
 	}
 
@@ -51,6 +62,9 @@ func TestFlexibleTime_UnmarshalJSON(t *testing.T) {
 			if (err != nil) != tt.wantErr {
 				t.Errorf("FlexibleTime.UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if tt.wantZero && !ft.IsZero() {
+				t.Errorf("FlexibleTime.UnmarshalJSON() = %v, want zero time", ft.Time)
+			}
 		})
 	}
 }
@@ -111,3 +125,18 @@ func TestAssetDevice_UnmarshalJSON_WithFlexibleTime(t *testing.T) {
 			expectedYear, expectedMonth, expectedDay, year, month, day)
 	}
 }
+
+func TestAssetDevice_UnmarshalJSON_UnparseableCreateTime(t *testing.T) {
+	jsonData := `{"id": "ups-1", "deviceType": 1, "createTime": "2024/01/02 03:04:05"}`
+
+	var device AssetDevice
+	if err := json.Unmarshal([]byte(jsonData), &device); err != nil {
+		t.Fatalf("Failed to unmarshal AssetDevice: %v", err)
+	}
+	if device.ID != "ups-1" {
+		t.Errorf("Expected ID to be 'ups-1', got '%s'", device.ID)
+	}
+	if !device.CreateTime.IsZero() {
+		t.Errorf("Expected zero CreateTime, got %v", device.CreateTime)
+	}
+}
@@ -0,0 +1,148 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lay-g/winpower-g2-exporter/internal/collector"
+	"github.com/lay-g/winpower-g2-exporter/internal/energy"
+	"github.com/lay-g/winpower-g2-exporter/internal/metrics"
+	"github.com/lay-g/winpower-g2-exporter/internal/pkgs/log"
+	"github.com/lay-g/winpower-g2-exporter/internal/storage"
+	"github.com/lay-g/winpower-g2-exporter/internal/winpower"
+)
+
+// newWinPowerServer serves the WinPower login and device data endpoints,
+// reporting one device until empty is set and no devices afterwards.
+func newWinPowerServer(t *testing.T, empty *atomic.Bool) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case winpower.DefaultLoginPath:
+			resp := winpower.LoginResponse{Code: "000000", Message: "OK"}
+			resp.Data.Token = "test-token"
+			_ = json.NewEncoder(w).Encode(resp)
+		case winpower.DefaultDeviceDataPath:
+			resp := winpower.DeviceDataResponse{Code: "000000", Msg: "OK", Data: []winpower.DeviceInfo{}}
+			if !empty.Load() {
+				device := winpower.DeviceInfo{
+					Connected: true,
+					Realtime:  map[string]interface{}{"loadTotalWatt": "250"},
+				}
+				device.AssetDevice.ID = "ups-1"
+				device.AssetDevice.DeviceType = 1
+				device.AssetDevice.Alias = "UPS 1"
+				resp.Data = append(resp.Data, device)
+			}
+			resp.Total = len(resp.Data)
+			_ = json.NewEncoder(w).Encode(resp)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+// TestEmptyDeviceList runs the WinPower client, collector and metrics
+// service against a WinPower that stops reporting devices, and checks that
+// the empty list is reported as a successful collection of zero devices.
+func TestEmptyDeviceList(t *testing.T) {
+	var empty atomic.Bool
+	server := newWinPowerServer(t, &empty)
+	defer server.Close()
+
+	logger := log.NewNoopLogger()
+
+	wpCfg := winpower.DefaultConfig()
+	wpCfg.BaseURL = server.URL
+	wpCfg.Username = "admin"
+	wpCfg.Password = "secret"
+	wpCfg.BackgroundRefresh = false
+	client, err := winpower.NewClient(wpCfg, logger)
+	if err != nil {
+		t.Fatalf("Failed to create WinPower client: %v", err)
+	}
+	defer client.Close()
+
+	store, err := storage.NewFileStorageManager(&storage.Config{
+		DataDir:         t.TempDir(),
+		FilePermissions: 0644,
+	}, logger)
+	if err != nil {
+		t.Fatalf("Failed to create storage manager: %v", err)
+	}
+
+	coll, err := collector.NewCollectorService(client, energy.NewEnergyService(store, logger), logger)
+	if err != nil {
+		t.Fatalf("Failed to create collector: %v", err)
+	}
+
+	service, err := metrics.NewMetricsService(coll, logger, nil)
+	if err != nil {
+		t.Fatalf("Failed to create metrics service: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/metrics", service.HandleMetrics)
+
+	scrape := func() string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		return w.Body.String()
+	}
+
+	body := scrape()
+	if !strings.Contains(body, `device_id="ups-1"`) {
+		t.Fatalf("Expected metrics for ups-1, got:\n%s", body)
+	}
+
+	empty.Store(true)
+	body = scrape()
+
+	for _, want := range []string{
+		"winpower_exporter_device_count{",
+		"winpower_exporter_up{",
+		"winpower_connection_status{",
+	} {
+		line := metricLine(body, want)
+		if line == "" {
+			t.Fatalf("Expected metric %s in output", want)
+		}
+		wantValue := " 1"
+		if strings.HasPrefix(want, "winpower_exporter_device_count") {
+			wantValue = " 0"
+		}
+		if !strings.HasSuffix(line, wantValue) {
+			t.Errorf("Expected %q to end with %q", line, wantValue)
+		}
+	}
+
+	if strings.Contains(body, `device_id="ups-1"`) {
+		t.Errorf("Expected ups-1 metrics to be expired, got:\n%s", body)
+	}
+	if line := metricLine(body, `winpower_exporter_scrape_errors_total{error_type="collection_failed"`); line != "" {
+		t.Errorf("Expected no collection failures, got %q", line)
+	}
+}
+
+// metricLine returns the first sample line starting with prefix
+func metricLine(body, prefix string) string {
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, prefix) {
+			return line
+		}
+	}
+	return ""
+}